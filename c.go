@@ -1,6 +1,7 @@
 package colfer
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -25,12 +26,18 @@ func IsCKeyword(s string) bool {
 func GenerateC(basedir string, packages []*Package) error {
 	for _, p := range packages {
 		for _, s := range p.Structs {
-			s.NameNative = name.SnakeCase(p.Name + "_" + s.Name)
+			s.NameNative = s.Names["c"]
+			if s.NameNative == "" {
+				s.NameNative = name.SnakeCase(p.Name + "_" + s.Name)
+			}
 
 			for _, f := range s.Fields {
-				f.NameNative = name.SnakeCase(f.Name)
-				if IsCKeyword(f.NameNative) {
-					f.NameNative += "_"
+				f.NameNative = f.Names["c"]
+				if f.NameNative == "" {
+					f.NameNative = name.SnakeCase(f.Name)
+					if IsCKeyword(f.NameNative) {
+						f.NameNative += "_"
+					}
 				}
 
 				switch f.Type {
@@ -44,10 +51,17 @@ func GenerateC(basedir string, packages []*Package) error {
 					f.TypeNative = "double"
 				case "timestamp", "binary", "text":
 					f.TypeNative = "colfer_" + f.Type
+				case "any":
+					return fmt.Errorf("colfer: field %s: any type not supported with C", f)
 				}
+				f.MinNative = cBound(f.Type, f.Min)
+				f.MaxNative = cBound(f.Type, f.Max)
 			}
 		}
 	}
+	if err := checkNativeNames(packages, "c", func(s *Struct) string { return s.NameNative }, func(f *Field) string { return f.NameNative }); err != nil {
+		return err
+	}
 
 	if err := os.MkdirAll(basedir, os.ModeDir|os.ModePerm); err != nil {
 		return err
@@ -68,12 +82,35 @@ func GenerateC(basedir string, packages []*Package) error {
 	if err != nil {
 		return err
 	}
-	if err := template.Must(template.New("C").Parse(cTemplate)).Execute(f, packages); err != nil {
+	t := template.New("C")
+	template.Must(t.Parse(cTemplate))
+	template.Must(t.New("marshal-field").Parse(cMarshalField))
+	template.Must(t.New("hash-field").Parse(cHashField))
+	if err := t.Execute(f, packages); err != nil {
 		return err
 	}
 	return f.Close()
 }
 
+// cBound returns the C literal of a range rule for datatype t.
+func cBound(t, bound string) string {
+	switch {
+	case bound == "":
+		return ""
+	case t == "uint64":
+		return "UINT64_C(" + bound + ")"
+	case t == "int64" && bound == "-9223372036854775808":
+		return "INT64_MIN"
+	case t == "int64":
+		return "INT64_C(" + bound + ")"
+	case t == "float32" && strings.ContainsAny(bound, ".e"):
+		return bound + "f"
+	case t == "float32":
+		return bound + ".0f"
+	}
+	return bound
+}
+
 const cHeaderTemplate = `// Code generated by colf(1); DO NOT EDIT.
 {{- range .}}
 // The compiler used schema file {{.SchemaFileList}} for package {{.Name}}.
@@ -95,6 +132,15 @@ const cHeaderTemplate = `// Code generated by colf(1); DO NOT EDIT.
 extern "C" {
 #endif
 
+// COLFER_DEPRECATED marks the schema definitions with a deprecated annotation.
+#ifndef COLFER_DEPRECATED
+#if defined(__GNUC__) || defined(__clang__)
+#define COLFER_DEPRECATED __attribute__((deprecated))
+#else
+#define COLFER_DEPRECATED
+#endif
+#endif
+
 
 // colfer_size_max is the upper limit for serial octet sizes.
 extern size_t colfer_size_max;
@@ -102,6 +148,10 @@ extern size_t colfer_size_max;
 // colfer_list_max is the upper limit for the number of elements in a list.
 extern size_t colfer_list_max;
 
+// colfer_depth_max is the upper limit for the number of nested data structure
+// levels, including the root.
+extern size_t colfer_depth_max;
+
 
 typedef struct {
 	// sec is the Unix time.
@@ -122,12 +172,16 @@ typedef struct {
 	size_t   len;
 } colfer_binary;
 
+// colfer_hash_func receives consecutive chunks of a serial, like the update
+// operation of a hash function, with ctx as an opaque argument.
+typedef void (*colfer_hash_func)(void* ctx, const void* data, size_t datalen);
+
 {{range .}}{{range .Structs}}
 typedef struct {{.NameNative}} {{.NameNative}};
 {{end}}{{end}}
 {{range .}}{{range .Structs}}
 {{.DocText "// "}}
-struct {{.NameNative}} {
+struct {{if .Deprecated}}COLFER_DEPRECATED {{end}}{{.NameNative}} {
 {{- range .Fields}}
 {{.DocText "\t// "}}{{- if .TypeList}}
  {{- if eq .Type "float32"}}
@@ -147,7 +201,7 @@ struct {{.NameNative}} {
 	}
  {{- else}}
 	struct {
-		struct {{.TypeRef.NameNative}}* list;
+		{{.TypeRef.NameNative}}* list;
 		size_t len;
 	}
  {{- end}}
@@ -157,7 +211,7 @@ struct {{.NameNative}} {
  {{- else}}
 	{{.TypeNative}}
  {{- end}}
-{{- end}} {{.NameNative}};
+{{- end}} {{.NameNative}}{{if .Deprecated}} COLFER_DEPRECATED{{end}};
 {{- end}}
 };
 
@@ -170,13 +224,39 @@ size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o);
 // of octets written.
 size_t {{.NameNative}}_marshal(const {{.NameNative}}* o, void* buf);
 
+// {{.NameNative}}_hash feeds the Colfer serial of o into update, without
+// materializing the serial as a whole, and it returns the number of octets.
+// Equal values produce the same input for update in each of the supported
+// languages. When the return is zero then errno is set to EFBIG to indicate a
+// breach of either colfer_size_max or colfer_list_max, and update is not called.
+size_t {{.NameNative}}_hash(const {{.NameNative}}* o, colfer_hash_func update, void* ctx);
+
 // {{.NameNative}}_unmarshal decodes data as Colfer into o and returns the
 // number of octets read. The data is read up to a maximum of datalen or
 // colfer_size_max, whichever occurs first.
 // When the return is zero then errno is set to one of the following 3 values:
-// EWOULDBLOCK on incomplete data, EFBIG on a breach of either colfer_size_max
-// or colfer_list_max and EILSEQ on schema mismatch.
+// EWOULDBLOCK on incomplete data, EFBIG on a breach of either colfer_size_max,
+// colfer_list_max or colfer_depth_max and EILSEQ on schema mismatch.
 size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t datalen);
+
+// {{.NameNative}}_unmarshal_strict is like {{.NameNative}}_unmarshal, yet it also
+// rejects any serial which differs from the {{.NameNative}}_marshal output for the
+// same data. When errno is set to EILSEQ, then offset receives the index of the
+// first offending octet.
+size_t {{.NameNative}}_unmarshal_strict({{.NameNative}}* o, const void* data, size_t datalen, size_t* offset);
+
+// {{.NameNative}}_unmarshal_budget is like {{.NameNative}}_unmarshal, yet it also
+// limits the memory allocation of field values, including those of nested data
+// structures. The budget is the number of octets which may still be allocated.
+// Each allocation is deducted, and the decoding fails with EFBIG before any
+// allocation exceeds the remainder.
+size_t {{.NameNative}}_unmarshal_budget({{.NameNative}}* o, const void* data, size_t datalen, size_t* budget);
+
+// {{.NameNative}}_validate checks the fields of o against the schema rules,
+// including the ones of nested data structures. The return is NULL when valid,
+// or a static message which names the field and the rule otherwise. Pattern
+// rules are not checked, as C has no regular expressions.
+const char* {{.NameNative}}_validate(const {{.NameNative}}* o);
 {{end}}{{end}}
 
 #ifdef __cplusplus
@@ -191,6 +271,8 @@ const cTemplate = `// Code generated by colf(1); DO NOT EDIT.
 // The compiler used schema file {{.SchemaFileList}} for package {{.Name}}.
 {{- end}}
 
+// The implementation uses all definitions, including the deprecated ones.
+#define COLFER_DEPRECATED
 #include "Colfer.h"
 #include <errno.h>
 #include <stdlib.h>
@@ -208,7 +290,82 @@ const cTemplate = `// Code generated by colf(1); DO NOT EDIT.
 {{with index . 0}}
 size_t colfer_size_max = {{.SizeMax}};
 size_t colfer_list_max = {{.ListMax}};
+size_t colfer_depth_max = {{.DepthMax}};
 {{end}}
+{{range .}}{{range .Structs}}
+static size_t {{.NameNative}}_unmarshal_at({{.NameNative}}* o, const void* data, size_t datalen, size_t depth, size_t* budget, const uint8_t** fault);
+static void {{.NameNative}}_hash_at(const {{.NameNative}}* o, colfer_hash_func update, void* ctx);
+{{- end}}{{end}}
+{{- $varint := false}}{{$text := false}}{{$alloc := false}}
+{{- range .}}{{range .Structs}}{{range .Fields}}
+ {{- if or (eq .Type "uint32" "uint64" "int32" "int64" "text" "binary") .TypeList}}{{$varint = true}}{{end}}
+ {{- if eq .Type "text"}}{{$text = true}}{{end}}
+ {{- if or (eq .Type "text" "binary") .TypeList .TypeRef}}{{$alloc = true}}{{end}}
+{{- end}}{{end}}{{end}}
+{{- if $alloc}}
+
+// colfer_charge deducts n octets from budget, if any. The return is zero, with
+// errno set to EFBIG, when n exceeds the remainder.
+static int colfer_charge(size_t* budget, size_t n) {
+	if (!budget) return 1;
+	if (n > *budget) {
+		errno = EFBIG;
+		return 0;
+	}
+	*budget -= n;
+	return 1;
+}
+{{- end}}
+{{- if $varint}}
+
+// colfer_varint_size returns the octet size of x in the canonical encoding.
+static size_t colfer_varint_size(uint_fast64_t x) {
+	size_t n = 1;
+	for (; x > 127 && n < 9; x >>= 7) ++n;
+	return n;
+}
+{{- end}}
+{{- if $text}}
+
+// colfer_utf8_len returns the number of octets in p before the first invalid
+// UTF-8 sequence, if any.
+static size_t colfer_utf8_len(const uint8_t* p, size_t n) {
+	size_t i = 0;
+	while (i < n) {
+		uint_fast32_t c = p[i];
+		if (c < 128) {
+			++i;
+			continue;
+		}
+
+		size_t follow;
+		uint_fast32_t min;
+		if (c > 193 && c < 224) {
+			follow = 1;
+			min = 0x80;
+		} else if (c > 223 && c < 240) {
+			follow = 2;
+			min = 0x800;
+		} else if (c > 239 && c < 245) {
+			follow = 3;
+			min = 0x10000;
+		} else {
+			return i;
+		}
+		if (i + follow >= n) return i;
+
+		c &= 63 >> follow;
+		for (size_t j = 1; j <= follow; ++j) {
+			uint_fast32_t b = p[i + j];
+			if ((b & 192) != 128) return i;
+			c = c << 6 | (b & 63);
+		}
+		if (c < min || c > 0x10ffff || (c > 0xd7ff && c < 0xe000)) return i;
+		i += follow + 1;
+	}
+	return n;
+}
+{{- end}}
 
 {{range .}}{{range .Structs}}
 size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o) {
@@ -406,554 +563,713 @@ size_t {{.NameNative}}_marshal_len(const {{.NameNative}}* o) {
 size_t {{.NameNative}}_marshal(const {{.NameNative}}* o, void* buf) {
 	// octet pointer navigation
 	uint8_t* p = buf;
-{{range .Fields}}{{if eq .Type "bool"}}
-	if (o->{{.NameNative}}) *p++ = {{.Index}};
-{{else if eq .Type "uint8"}}
-	if (o->{{.NameNative}}) {
-		*p++ = {{.Index}};
+{{range .Fields}}{{template "marshal-field" .}}{{end}}
+	*p++ = 127;
 
-		*p++ = o->{{.NameNative}};
+	return p - (uint8_t*) buf;
+}
+
+size_t {{.NameNative}}_hash(const {{.NameNative}}* o, colfer_hash_func update, void* ctx) {
+	size_t n = {{.NameNative}}_marshal_len(o);
+	if (n) {{.NameNative}}_hash_at(o, update, ctx);
+	return n;
+}
+
+// {{.NameNative}}_hash_at is {{.NameNative}}_hash without the limit checks.
+static void {{.NameNative}}_hash_at(const {{.NameNative}}* o, colfer_hash_func update, void* ctx) {
+	// pending octets
+	uint8_t buf[32];
+	uint8_t* p = buf;
+{{range .Fields}}{{if or .TypeList .TypeRef (eq .Type "text" "binary")}}{{template "hash-field" .}}{{else}}
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
 	}
-{{else if eq .Type "uint16"}}
-	{
-		uint_fast16_t x = o->{{.NameNative}};
-		if (x) {
-			if (x < 256)  {
-				*p++ = {{.Index}} | 0x80;
+{{- template "marshal-field" .}}{{end}}{{end}}
+	*p++ = 127;
+	update(ctx, buf, p - buf);
+}
 
-				*p++ = x;
-			} else {
-				*p++ = {{.Index}};
+size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t datalen) {
+	return {{.NameNative}}_unmarshal_at(o, data, datalen, 1, NULL, NULL);
+}
 
-				*p++ = x >> 8;
-				*p++ = x;
-			}
+size_t {{.NameNative}}_unmarshal_budget({{.NameNative}}* o, const void* data, size_t datalen, size_t* budget) {
+	return {{.NameNative}}_unmarshal_at(o, data, datalen, 1, budget, NULL);
+}
+
+size_t {{.NameNative}}_unmarshal_strict({{.NameNative}}* o, const void* data, size_t datalen, size_t* offset) {
+	const uint8_t* at = data;
+	size_t n = {{.NameNative}}_unmarshal_at(o, data, datalen, 1, NULL, &at);
+	if (!n && errno == EILSEQ) *offset = (size_t) (at - (const uint8_t*) data);
+	return n;
+}
+
+// {{.NameNative}}_unmarshal_at is {{.NameNative}}_unmarshal with depth as the
+// number of data structure levels, including o. Allocation is charged to budget
+// when not NULL. Strict mode applies when fault is not NULL, which then receives
+// the location of any EILSEQ.
+static size_t {{.NameNative}}_unmarshal_at({{.NameNative}}* o, const void* data, size_t datalen, size_t depth, size_t* budget, const uint8_t** fault) {
+	if (depth > colfer_depth_max) {
+		errno = EFBIG;
+		return 0;
+	}
+
+	// octet pointer navigation
+	const uint8_t* p = data;
+	const uint8_t* end;
+	int enderr;
+	if (datalen < colfer_size_max) {
+		end = p + datalen;
+		enderr = EWOULDBLOCK;
+	} else {
+		end = p + colfer_size_max;
+		enderr = EFBIG;
+	}
+
+	if (p >= end) {
+		errno = enderr;
+		return 0;
+	}
+	uint_fast8_t header = *p++;
+{{range .Fields}}{{if eq .Type "bool"}}
+	if (header == {{.Index}}) {
+		o->{{.NameNative}} = 1;
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		header = *p++;
+	}
+{{else if eq .Type "uint8"}}
+	if (header == {{.Index}}) {
+		if (p+1 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		o->{{.NameNative}} = *p++;
+		if (fault && (!o->{{.NameNative}})) {
+			*fault = p - 2;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
+	}
+{{else if eq .Type "uint16"}}
+	if (header == {{.Index}}) {
+		if (p+2 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		uint_fast16_t x = *p++;
+		x <<= 8;
+		o->{{.NameNative}} = x | *p++;
+		if (fault && (o->{{.NameNative}} < 256)) {
+			*fault = p - 3;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
+	} else if (header == ({{.Index}} | 128)) {
+		if (p+1 >= end) {
+			errno = enderr;
+			return 0;
 		}
+		o->{{.NameNative}} = *p++;
+		if (fault && (!o->{{.NameNative}})) {
+			*fault = p - 2;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
 	}
 {{else if eq .Type "uint32"}}
-	{
-		uint_fast32_t x = o->{{.NameNative}};
-		if (x) {
-			if (x < (uint_fast32_t) 1 << 21) {
-				*p++ = {{.Index}};
-				for (; x >= 128; x >>= 7) *p++ = x | 128;
-				*p++ = x;
-			} else {
-				*p++ = {{.Index}} | 128;
-#ifdef COLFER_ENDIAN
-				memcpy(p, &o->{{.NameNative}}, 4);
-				p += 4;
-#else
-				*p++ = x >> 24;
-				*p++ = x >> 16;
-				*p++ = x >> 8;
-				*p++ = x;
-#endif
+	if (header == {{.Index}}) {
+		const uint8_t* at = p - 1;
+		if (p+1 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		uint_fast32_t x = *p++;
+		if (x > 127) {
+			x &= 127;
+			for (int shift = 7; ; shift += 7) {
+				uint_fast32_t b = *p++;
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				if (b <= 127) {
+					x |= b << shift;
+					break;
+				}
+				x |= (b & 127) << shift;
 			}
 		}
+		o->{{.NameNative}} = x;
+		if (fault && (!x || x >= (uint_fast32_t) 1 << 21 || (size_t) (p - at - 1) != colfer_varint_size(x))) {
+			*fault = at;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
+	} else if (header == ({{.Index}} | 128)) {
+		if (p+4 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		uint_fast32_t x = *p++;
+		x <<= 24;
+		x |= (uint_fast32_t) *p++ << 16;
+		x |= (uint_fast32_t) *p++ << 8;
+		x |= (uint_fast32_t) *p++;
+		o->{{.NameNative}} = x;
+		if (fault && (x < (uint_fast32_t) 1 << 21)) {
+			*fault = p - 5;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
 	}
 {{else if eq .Type "uint64"}}
-	{
-		uint_fast64_t x = o->{{.NameNative}};
-		if (x) {
-			if (x < (uint_fast64_t) 1 << 49) {
-				*p++ = {{.Index}};
-				for (; x >= 128; x >>= 7) *p++ = x | 128;
-				*p++ = x;
-			} else {
-				*p++ = {{.Index}} | 128;
-#ifdef COLFER_ENDIAN
-				memcpy(p, &o->{{.NameNative}}, 8);
-				p += 8;
-#else
-				*p++ = x >> 56;
-				*p++ = x >> 48;
-				*p++ = x >> 40;
-				*p++ = x >> 32;
-				*p++ = x >> 24;
-				*p++ = x >> 16;
-				*p++ = x >> 8;
-				*p++ = x;
-#endif
+	if (header == {{.Index}}) {
+		const uint8_t* at = p - 1;
+		if (p+1 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		uint_fast64_t x = *p++;
+		if (x > 127) {
+			x &= 127;
+			for (int shift = 7; ; shift += 7) {
+				uint_fast64_t b = *p++;
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				if (b <= 127) {
+					x |= b << shift;
+					break;
+				}
+				x |= (b & 127) << shift;
 			}
 		}
+		o->{{.NameNative}} = x;
+		if (fault && (!x || x >= (uint_fast64_t) 1 << 49 || (size_t) (p - at - 1) != colfer_varint_size(x))) {
+			*fault = at;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
+	} else if (header == ({{.Index}} | 128)) {
+		if (p+8 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		uint_fast64_t x = *p++;
+		x <<= 56;
+		x |= (uint_fast64_t) *p++ << 48;
+		x |= (uint_fast64_t) *p++ << 40;
+		x |= (uint_fast64_t) *p++ << 32;
+		x |= (uint_fast64_t) *p++ << 24;
+		x |= (uint_fast64_t) *p++ << 16;
+		x |= (uint_fast64_t) *p++ << 8;
+		x |= (uint_fast64_t) *p++;
+		o->{{.NameNative}} = x;
+		if (fault && (x < (uint_fast64_t) 1 << 49)) {
+			*fault = p - 9;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
 	}
 {{else if eq .Type "int32"}}
-	{
-		uint_fast32_t x = o->{{.NameNative}};
-		if (x) {
-			if (x & (uint_fast32_t) 1 << 31) {
-				*p++ = {{.Index}} | 128;
-				x = ~x + 1;
-			} else	*p++ = {{.Index}};
-
-			for (; x >= 128; x >>= 7) *p++ = x | 128;
-			*p++ = x;
+	if ((header & 127) == {{.Index}}) {
+		const uint8_t* at = p - 1;
+		if (p+1 >= end) {
+			errno = enderr;
+			return 0;
 		}
+		uint_fast32_t x = *p++;
+		if (x > 127) {
+			x &= 127;
+			for (int shift = 7; shift < 35; shift += 7) {
+				uint_fast32_t b = *p++;
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				if (b <= 127) {
+					x |= b << shift;
+					break;
+				}
+				x |= (b & 127) << shift;
+			}
+		}
+		if (fault && (!x || (size_t) (p - at - 1) != colfer_varint_size(x) || (p - at - 1 == 5 && p[-1] > 15) || x > (header & 128 ? (uint_fast32_t) 1 << 31 : (uint_fast32_t) INT32_MAX))) {
+			*fault = at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (header & 128) x = ~x + 1;
+		o->{{.NameNative}} = x;
+		header = *p++;
 	}
 {{else if eq .Type "int64"}}
-	{
-		uint_fast64_t x = o->{{.NameNative}};
-		if (x) {
-			if (x & (uint_fast64_t) 1 << 63) {
-				*p++ = {{.Index}} | 128;
-				x = ~x + 1;
-			} else	*p++ = {{.Index}};
-
-			uint8_t* max = p + 8;
-			for (; x >= 128 && p < max; x >>= 7) *p++ = x | 128;
-			*p++ = x;
+	if ((header & 127) == {{.Index}}) {
+		const uint8_t* at = p - 1;
+		if (p+1 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		uint_fast64_t x = *p++;
+		if (x > 127) {
+			x &= 127;
+			for (int shift = 7; ; shift += 7) {
+				uint_fast64_t b = *p++;
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				if (b <= 127 || shift == 56) {
+					x |= b << shift;
+					break;
+				}
+				x |= (b & 127) << shift;
+			}
 		}
+		if (fault && (!x || (size_t) (p - at - 1) != colfer_varint_size(x) || x > (header & 128 ? (uint_fast64_t) 1 << 63 : (uint_fast64_t) INT64_MAX))) {
+			*fault = at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (header & 128) x = ~x + 1;
+		o->{{.NameNative}} = x;
+		header = *p++;
 	}
 {{else if eq .Type "float32"}}
  {{- if not .TypeList}}
-	if (o->{{.NameNative}} != 0.0f) {
-		*p++ = {{.Index}};
-
+	if (header == {{.Index}}) {
+		if (p+4 >= end) {
+			errno = enderr;
+			return 0;
+		}
 #ifdef COLFER_ENDIAN
-		memcpy(p, &o->{{.NameNative}}, 4);
+		memcpy(&o->{{.NameNative}}, p, 4);
 		p += 4;
 #else
-		uint_fast32_t x;
-		memcpy(&x, &o->{{.NameNative}}, 4);
-		*p++ = x >> 24;
-		*p++ = x >> 16;
-		*p++ = x >> 8;
-		*p++ = x;
+		uint_fast32_t x = *p++;
+		x <<= 24;
+		x |= (uint_fast32_t) *p++ << 16;
+		x |= (uint_fast32_t) *p++ << 8;
+		x |= (uint_fast32_t) *p++;
+		memcpy(&o->{{.NameNative}}, &x, 4);
 #endif
+		if (fault && (o->{{.NameNative}} == 0)) {
+			*fault = p - 5;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
 	}
  {{- else}}
-	{
-		size_t n = o->{{.NameNative}}.len;
-		if (n) {
-			*p++ = {{.Index}};
-
-			uint_fast32_t x = n;
-			for (; x >= 128; x >>= 7) *p++ = x | 128;
-			*p++ = x;
+	if (header == {{.Index}}) {
+		const uint8_t* at = p - 1;
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		size_t n = *p++;
+		if (n > 127) {
+			n &= 127;
+			for (int shift = 7; ; shift += 7) {
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				size_t c = *p++;
+				if (c <= 127) {
+					n |= c << shift;
+					break;
+				}
+				n |= (c & 127) << shift;
+			}
+		}
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (n > colfer_list_max) {
+			errno = EFBIG;
+			return 0;
+		}
+		if (p+n*4 >= end) {
+			errno = enderr;
+			return 0;
+		}
 
+		if (!colfer_charge(budget, n * 4)) return 0;
+		float* fp = malloc(n * 4);
+		o->{{.NameNative}}.list = fp;
+		o->{{.NameNative}}.len = n;
 #ifdef COLFER_ENDIAN
-			memcpy(p, o->{{.NameNative}}.list, n * 4);
-			p += n * 4;
+		memcpy(fp, p, n * 4);
+		p += n * 4;
 #else
-			uint32_t* fp = (uint32_t*) o->{{.NameNative}}.list;
-			for (;;) {
-				memcpy(&x, fp, 4);
-				*p++ = x >> 24;
-				*p++ = x >> 16;
-				*p++ = x >> 8;
-				*p++ = x;
-				if (--n == 0) break;
-				++fp;
-			}
-#endif
+		for (; n != 0; --n, ++fp) {
+			uint_fast32_t x = *p++;
+			x <<= 24;
+			x |= (uint_fast32_t) *p++ << 16;
+			x |= (uint_fast32_t) *p++ << 8;
+			x |= (uint_fast32_t) *p++;
+			memcpy(fp, &x, 4);
 		}
+#endif
+		header = *p++;
 	}
  {{- end}}
 {{else if eq .Type "float64"}}
  {{- if not .TypeList}}
-	if (o->{{.NameNative}} != 0.0) {
-		*p++ = {{.Index}};
-
+	if (header == {{.Index}}) {
+		if (p+8 >= end) {
+			errno = enderr;
+			return 0;
+		}
 #ifdef COLFER_ENDIAN
-		memcpy(p, &o->{{.NameNative}}, 8);
+		memcpy(&o->{{.NameNative}}, p, 8);
 		p += 8;
 #else
-		uint_fast64_t x;
-		memcpy(&x, &o->{{.NameNative}}, 8);
-		*p++ = x >> 56;
-		*p++ = x >> 48;
-		*p++ = x >> 40;
-		*p++ = x >> 32;
-		*p++ = x >> 24;
-		*p++ = x >> 16;
-		*p++ = x >> 8;
-		*p++ = x;
+		uint_fast64_t x = *p++;
+		x <<= 56;
+		x |= (uint_fast64_t) *p++ << 48;
+		x |= (uint_fast64_t) *p++ << 40;
+		x |= (uint_fast64_t) *p++ << 32;
+		x |= (uint_fast64_t) *p++ << 24;
+		x |= (uint_fast64_t) *p++ << 16;
+		x |= (uint_fast64_t) *p++ << 8;
+		x |= (uint_fast64_t) *p++;
+		memcpy(&o->{{.NameNative}}, &x, 8);
 #endif
+		if (fault && (o->{{.NameNative}} == 0)) {
+			*fault = p - 9;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
 	}
  {{- else}}
-	{
-		size_t n = o->{{.NameNative}}.len;
-		if (n) {
-			*p++ = {{.Index}};
-
-			uint_fast32_t x = n;
-			for (; x >= 128; x >>= 7) *p++ = x | 128;
-			*p++ = x;
+	if (header == {{.Index}}) {
+		const uint8_t* at = p - 1;
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		size_t n = *p++;
+		if (n > 127) {
+			n &= 127;
+			for (int shift = 7; ; shift += 7) {
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				size_t c = *p++;
+				if (c <= 127) {
+					n |= c << shift;
+					break;
+				}
+				n |= (c & 127) << shift;
+			}
+		}
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (n > colfer_list_max) {
+			errno = EFBIG;
+			return 0;
+		}
+		if (p+n*8 >= end) {
+			errno = enderr;
+			return 0;
+		}
 
+		if (!colfer_charge(budget, n * 8)) return 0;
+		double* fp = malloc(n * 8);
+		o->{{.NameNative}}.len = n;
+		o->{{.NameNative}}.list = fp;
 #ifdef COLFER_ENDIAN
-			memcpy(p, o->{{.NameNative}}.list, n * 8);
-			p += n * 8;
+		memcpy(fp, p, n * 8);
+		p += n * 8;
 #else
-			uint64_t* fp = (uint64_t*) o->{{.NameNative}}.list;
-			for (;;) {
-				uint_fast64_t x;
-				memcpy(&x, fp, 8);
-				*p++ = x >> 56;
-				*p++ = x >> 48;
-				*p++ = x >> 40;
-				*p++ = x >> 32;
-				*p++ = x >> 24;
-				*p++ = x >> 16;
-				*p++ = x >> 8;
-				*p++ = x;
-				if (--n == 0) break;
-				++fp;
-			}
-#endif
+		for (; n != 0; --n, ++fp) {
+			uint_fast64_t x = *p++;
+			x <<= 56;
+			x |= (uint_fast64_t) *p++ << 48;
+			x |= (uint_fast64_t) *p++ << 40;
+			x |= (uint_fast64_t) *p++ << 32;
+			x |= (uint_fast64_t) *p++ << 24;
+			x |= (uint_fast64_t) *p++ << 16;
+			x |= (uint_fast64_t) *p++ << 8;
+			x |= (uint_fast64_t) *p++;
+			memcpy(fp, &x, 8);
 		}
+#endif
+		header = *p++;
 	}
  {{- end}}
 {{else if eq .Type "timestamp"}}
-	{
-		int_fast64_t s = o->{{.NameNative}}.sec;
-		int_fast64_t ns = o->{{.NameNative}}.nanos;
-		if (s || ns) {
-			static const int_fast64_t nano = 1000000000;
-			s += ns / nano;
-			ns %= nano;
-			if (ns < 0) {
-				--s;
-				ns += nano;
+	if ((header & 127) == {{.Index}}) {
+		if (header & 128) {
+			if (p+12 >= end) {
+				errno = enderr;
+				return 0;
 			}
-
-			uint_fast64_t x = s;
-			if (x < (uint_fast64_t) 1 << 32)
-				*p++ = {{.Index}};
-			else {
-				*p++ = {{.Index}} | 128;
-
-				*p++ = x >> 56;
-				*p++ = x >> 48;
-				*p++ = x >> 40;
-				*p++ = x >> 32;
+			uint_fast64_t x = *p++;
+			x <<= 56;
+			x |= (uint_fast64_t) *p++ << 48;
+			x |= (uint_fast64_t) *p++ << 40;
+			x |= (uint_fast64_t) *p++ << 32;
+			x |= (uint_fast64_t) *p++ << 24;
+			x |= (uint_fast64_t) *p++ << 16;
+			x |= (uint_fast64_t) *p++ << 8;
+			x |= (uint_fast64_t) *p++;
+			o->{{.NameNative}}.sec = x;
+		} else {
+			if (p+8 >= end) {
+				errno = enderr;
+				return 0;
 			}
-			*p++ = x >> 24;
-			*p++ = x >> 16;
-			*p++ = x >> 8;
-			*p++ = x;
-
-			x = ns;
-			*p++ = x >> 24;
-			*p++ = x >> 16;
-			*p++ = x >> 8;
-			*p++ = x;
-		}
-	}
-{{else if eq .Type "text"}}
- {{- if not .TypeList}}
-	{
-		size_t n = o->{{.NameNative}}.len;
-		if (n) {
-			*p++ = {{.Index}};
-
-			uint_fast32_t x = n;
-			for (; x >= 128; x >>= 7) *p++ = x | 128;
-			*p++ = x;
-
-			memcpy(p, o->{{.NameNative}}.utf8, n);
-			p += n;
-		}
-	}
- {{- else}}
-	{
-		size_t count = o->{{.NameNative}}.len;
-		if (count) {
-			*p++ = {{.Index}};
-
-			uint_fast32_t x = count;
-			for (; x >= 128; x >>= 7) *p++ = x | 128;
-			*p++ = x;
-
-			colfer_text* text = o->{{.NameNative}}.list;
-			do {
-				size_t n = text->len;
-				for (x = n; x >= 128; x >>= 7) *p++ = x | 128;
-				*p++ = x;
-
-				memcpy(p, text->utf8, n);
-				p += n;
-
-				++text;
-			} while (--count != 0);
-		}
-	}
- {{- end}}
-{{else if eq .Type "binary"}}
- {{- if not .TypeList}}
-	{
-		size_t n = o->{{.NameNative}}.len;
-		if (n) {
-			*p++ = {{.Index}};
-
-			uint_fast32_t x = n;
-			for (; x >= 128; x >>= 7) *p++ = x | 128;
-			*p++ = x;
-
-			memcpy(p, o->{{.NameNative}}.octets, n);
-			p += n;
+			uint_fast32_t x = *p++;
+			x <<= 24;
+			x |= (uint_fast32_t) *p++ << 16;
+			x |= (uint_fast32_t) *p++ << 8;
+			x |= (uint_fast32_t) *p++;
+			o->{{.NameNative}}.sec = x;
 		}
-	}
- {{- else}}
-	{
-		size_t count = o->{{.NameNative}}.len;
-		if (count) {
-			*p++ = {{.Index}};
-
-			uint_fast32_t x = count;
-			for (; x >= 128; x >>= 7) *p++ = x | 128;
-			*p++ = x;
-
-			colfer_binary* binary = o->{{.NameNative}}.list;
-			do {
-				size_t n = binary->len;
-				for (x = n; x >= 128; x >>= 7) *p++ = x | 128;
-				*p++ = x;
-
-				memcpy(p, binary->octets, n);
-				p += n;
-
-				++binary;
-			} while (--count != 0);
+		uint_fast32_t x = *p++;
+		x <<= 24;
+		x |= (uint_fast32_t) *p++ << 16;
+		x |= (uint_fast32_t) *p++ << 8;
+		x |= (uint_fast32_t) *p++;
+		o->{{.NameNative}}.nanos = x;
+		if (fault) {
+			int wide = header & 128;
+			if (x >= 1000000000) {
+				*fault = p - 4;
+				errno = EILSEQ;
+				return 0;
+			}
+			if (wide ? (uint_fast64_t) o->{{.NameNative}}.sec < (uint_fast64_t) 1 << 32 : !o->{{.NameNative}}.sec && !x) {
+				*fault = p - (wide ? 13 : 9);
+				errno = EILSEQ;
+				return 0;
+			}
 		}
+		header = *p++;
 	}
- {{- end}}
-{{else}}
+{{else if eq .Type "text"}}
  {{- if not .TypeList}}
-	{
-		if (o->{{.NameNative}}) {
-			*p++ = {{.Index}};
-
-			p += {{.TypeRef.NameNative}}_marshal(o->{{.NameNative}}, p);
-		}
-	}
- {{- else}}
-	{
-		size_t n = o->{{.NameNative}}.len;
-		if (n) {
-			*p++ = {{.Index}};
-
-			uint_fast32_t x = n;
-			for (; x >= 128; x >>= 7) *p++ = x | 128;
-			*p++ = x;
-
-			{{.TypeRef.NameNative}}* a = o->{{.NameNative}}.list;
-			for (size_t i = 0; i < n; ++i) p += {{.TypeRef.NameNative}}_marshal(&a[i], p);
-		}
-	}
- {{- end}}
-{{end}}{{end}}
-	*p++ = 127;
-
-	return p - (uint8_t*) buf;
-}
-
-size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t datalen) {
-	// octet pointer navigation
-	const uint8_t* p = data;
-	const uint8_t* end;
-	int enderr;
-	if (datalen < colfer_size_max) {
-		end = p + datalen;
-		enderr = EWOULDBLOCK;
-	} else {
-		end = p + colfer_size_max;
-		enderr = EFBIG;
-	}
-
-	if (p >= end) {
-		errno = enderr;
-		return 0;
-	}
-	uint_fast8_t header = *p++;
-{{range .Fields}}{{if eq .Type "bool"}}
 	if (header == {{.Index}}) {
-		o->{{.NameNative}} = 1;
+		const uint8_t* at = p - 1;
 		if (p >= end) {
 			errno = enderr;
 			return 0;
 		}
-		header = *p++;
-	}
-{{else if eq .Type "uint8"}}
-	if (header == {{.Index}}) {
-		if (p+1 >= end) {
-			errno = enderr;
-			return 0;
+		size_t n = *p++;
+		if (n > 127) {
+			n &= 127;
+			for (int shift = 7; shift < sizeof(size_t) * CHAR_BIT; shift += 7) {
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				size_t c = *p++;
+				if (c <= 127) {
+					n |= c << shift;
+					break;
+				}
+				n |= (c & 127) << shift;
+			}
 		}
-		o->{{.NameNative}} = *p++;
-		header = *p++;
-	}
-{{else if eq .Type "uint16"}}
-	if (header == {{.Index}}) {
-		if (p+2 >= end) {
-			errno = enderr;
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
 			return 0;
 		}
-		uint_fast16_t x = *p++;
-		x <<= 8;
-		o->{{.NameNative}} = x | *p++;
-		header = *p++;
-	} else if (header == ({{.Index}} | 128)) {
-		if (p+1 >= end) {
+		if (p+n >= end) {
 			errno = enderr;
 			return 0;
 		}
-		o->{{.NameNative}} = *p++;
+
+		if (fault) {
+			size_t valid = colfer_utf8_len(p, n);
+			if (valid < n) {
+				*fault = p + valid;
+				errno = EILSEQ;
+				return 0;
+			}
+		}
+
+		if (!colfer_charge(budget, n)) return 0;
+		void* a = malloc(n);
+		memcpy(a, p, n);
+		p += n;
+		o->{{.NameNative}}.len = n;
+		o->{{.NameNative}}.utf8 = (char*) a;
 		header = *p++;
 	}
-{{else if eq .Type "uint32"}}
+ {{- else}}
 	if (header == {{.Index}}) {
-		if (p+1 >= end) {
+		const uint8_t* at = p - 1;
+		if (p >= end) {
 			errno = enderr;
 			return 0;
 		}
-		uint_fast32_t x = *p++;
-		if (x > 127) {
-			x &= 127;
+		size_t n = *p++;
+		if (n > 127) {
+			n &= 127;
 			for (int shift = 7; ; shift += 7) {
-				uint_fast32_t b = *p++;
 				if (p >= end) {
 					errno = enderr;
 					return 0;
 				}
-				if (b <= 127) {
-					x |= b << shift;
-					break;
-				}
-				x |= (b & 127) << shift;
+				size_t c = *p++;
+				if (c <= 127) {
+					n |= c << shift;
+					break;
+				}
+				n |= (c & 127) << shift;
 			}
 		}
-		o->{{.NameNative}} = x;
-		header = *p++;
-	} else if (header == ({{.Index}} | 128)) {
-		if (p+4 >= end) {
-			errno = enderr;
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
 			return 0;
 		}
-		uint_fast32_t x = *p++;
-		x <<= 24;
-		x |= (uint_fast32_t) *p++ << 16;
-		x |= (uint_fast32_t) *p++ << 8;
-		x |= (uint_fast32_t) *p++;
-		o->{{.NameNative}} = x;
-		header = *p++;
-	}
-{{else if eq .Type "uint64"}}
-	if (header == {{.Index}}) {
-		if (p+1 >= end) {
+		if (n > colfer_list_max) {
+			errno = EFBIG;
+			return 0;
+		}
+		// each element takes at least one octet
+		if (p+n >= end) {
 			errno = enderr;
 			return 0;
 		}
-		uint_fast64_t x = *p++;
-		if (x > 127) {
-			x &= 127;
-			for (int shift = 7; ; shift += 7) {
-				uint_fast64_t b = *p++;
-				if (p >= end) {
-					errno = enderr;
-					return 0;
+
+		if (!colfer_charge(budget, n * sizeof(colfer_text))) return 0;
+		colfer_text* text = malloc(n * sizeof(colfer_text));
+		o->{{.NameNative}}.len = n;
+		o->{{.NameNative}}.list = text;
+		for (; n != 0; --n, ++text) {
+			if (p >= end) {
+				errno = enderr;
+				return 0;
+			}
+			const uint8_t* len_at = p;
+			size_t len = *p++;
+			if (len > 127) {
+				len &= 127;
+				for (int shift = 7; ; shift += 7) {
+					if (p >= end) {
+						errno = enderr;
+						return 0;
+					}
+					size_t c = *p++;
+					if (c <= 127) {
+						len |= c << shift;
+						break;
+					}
+					len |= (c & 127) << shift;
 				}
-				if (b <= 127) {
-					x |= b << shift;
-					break;
+			}
+			if (fault && (size_t) (p - len_at) != colfer_varint_size(len)) {
+				*fault = p - 1;
+				errno = EILSEQ;
+				return 0;
+			}
+			if (p+len >= end) {
+				errno = enderr;
+				return 0;
+			}
+
+			if (fault) {
+				size_t valid = colfer_utf8_len(p, len);
+				if (valid < len) {
+					*fault = p + valid;
+					errno = EILSEQ;
+					return 0;
 				}
-				x |= (b & 127) << shift;
 			}
+
+			if (!colfer_charge(budget, len)) return 0;
+			char* a = malloc(len);
+			memcpy(a, p, len);
+			p += len;
+			text->len = len;
+			text->utf8 = a;
 		}
-		o->{{.NameNative}} = x;
-		header = *p++;
-	} else if (header == ({{.Index}} | 128)) {
-		if (p+8 >= end) {
+
+		if (p >= end) {
 			errno = enderr;
 			return 0;
 		}
-		uint_fast64_t x = *p++;
-		x <<= 56;
-		x |= (uint_fast64_t) *p++ << 48;
-		x |= (uint_fast64_t) *p++ << 40;
-		x |= (uint_fast64_t) *p++ << 32;
-		x |= (uint_fast64_t) *p++ << 24;
-		x |= (uint_fast64_t) *p++ << 16;
-		x |= (uint_fast64_t) *p++ << 8;
-		x |= (uint_fast64_t) *p++;
-		o->{{.NameNative}} = x;
 		header = *p++;
 	}
-{{else if eq .Type "int32"}}
-	if ((header & 127) == {{.Index}}) {
-		if (p+1 >= end) {
+ {{- end}}
+{{else if eq .Type "binary"}}
+ {{- if not .TypeList}}
+	if (header == {{.Index}}) {
+		const uint8_t* at = p - 1;
+		if (p >= end) {
 			errno = enderr;
 			return 0;
 		}
-		uint_fast32_t x = *p++;
-		if (x > 127) {
-			x &= 127;
-			for (int shift = 7; shift < 35; shift += 7) {
-				uint_fast32_t b = *p++;
+		size_t n = *p++;
+		if (n > 127) {
+			n &= 127;
+			for (int shift = 7; ; shift += 7) {
 				if (p >= end) {
 					errno = enderr;
 					return 0;
 				}
-				if (b <= 127) {
-					x |= b << shift;
+				size_t c = *p++;
+				if (c <= 127) {
+					n |= c << shift;
 					break;
 				}
-				x |= (b & 127) << shift;
+				n |= (c & 127) << shift;
 			}
 		}
-		if (header & 128) x = ~x + 1;
-		o->{{.NameNative}} = x;
-		header = *p++;
-	}
-{{else if eq .Type "int64"}}
-	if ((header & 127) == {{.Index}}) {
-		if (p+1 >= end) {
-			errno = enderr;
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
 			return 0;
 		}
-		uint_fast64_t x = *p++;
-		if (x > 127) {
-			x &= 127;
-			for (int shift = 7; ; shift += 7) {
-				uint_fast64_t b = *p++;
-				if (p >= end) {
-					errno = enderr;
-					return 0;
-				}
-				if (b <= 127 || shift == 56) {
-					x |= b << shift;
-					break;
-				}
-				x |= (b & 127) << shift;
-			}
-		}
-		if (header & 128) x = ~x + 1;
-		o->{{.NameNative}} = x;
-		header = *p++;
-	}
-{{else if eq .Type "float32"}}
- {{- if not .TypeList}}
-	if (header == {{.Index}}) {
-		if (p+4 >= end) {
+		if (p+n >= end) {
 			errno = enderr;
 			return 0;
 		}
-#ifdef COLFER_ENDIAN
-		memcpy(&o->{{.NameNative}}, p, 4);
-		p += 4;
-#else
-		uint_fast32_t x = *p++;
-		x <<= 24;
-		x |= (uint_fast32_t) *p++ << 16;
-		x |= (uint_fast32_t) *p++ << 8;
-		x |= (uint_fast32_t) *p++;
-		memcpy(&o->{{.NameNative}}, &x, 4);
-#endif
+ {{- if .TypeColfer}}
+		if (n && p[n-1] != 127) {
+			if (fault) *fault = p + n - 1;
+			errno = EILSEQ;
+			return 0;
+		}
+ {{- end}}
+
+		if (!colfer_charge(budget, n)) return 0;
+		void* a = malloc(n);
+		memcpy(a, p, n);
+		p += n;
+		o->{{.NameNative}}.len = n;
+		o->{{.NameNative}}.octets = (uint8_t*) a;
 		header = *p++;
 	}
  {{- else}}
 	if (header == {{.Index}}) {
+		const uint8_t* at = p - 1;
 		if (p >= end) {
 			errno = enderr;
 			return 0;
@@ -974,60 +1290,93 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 				n |= (c & 127) << shift;
 			}
 		}
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
 		if (n > colfer_list_max) {
 			errno = EFBIG;
 			return 0;
 		}
-		if (p+n*4 >= end) {
+		// each element takes at least one octet
+		if (p+n >= end) {
 			errno = enderr;
 			return 0;
 		}
 
-		float* fp = malloc(n * 4);
-		o->{{.NameNative}}.list = fp;
+		if (!colfer_charge(budget, n * sizeof(colfer_binary))) return 0;
+		colfer_binary* binary = malloc(n * sizeof(colfer_binary));
 		o->{{.NameNative}}.len = n;
-#ifdef COLFER_ENDIAN
-		memcpy(fp, p, n * 4);
-		p += n * 4;
-#else
-		for (; n != 0; --n, ++fp) {
-			uint_fast32_t x = *p++;
-			x <<= 24;
-			x |= (uint_fast32_t) *p++ << 16;
-			x |= (uint_fast32_t) *p++ << 8;
-			x |= (uint_fast32_t) *p++;
-			memcpy(fp, &x, 4);
+		o->{{.NameNative}}.list = binary;
+		for (; n != 0; --n, ++binary) {
+			if (p >= end) {
+				errno = enderr;
+				return 0;
+			}
+			const uint8_t* len_at = p;
+			size_t len = *p++;
+			if (len > 127) {
+				len &= 127;
+				for (int shift = 7; ; shift += 7) {
+					if (p >= end) {
+						errno = enderr;
+						return 0;
+					}
+					size_t c = *p++;
+					if (c <= 127) {
+						len |= c << shift;
+						break;
+					}
+					len |= (c & 127) << shift;
+				}
+			}
+			if (fault && (size_t) (p - len_at) != colfer_varint_size(len)) {
+				*fault = p - 1;
+				errno = EILSEQ;
+				return 0;
+			}
+			if (p+len >= end) {
+				errno = enderr;
+				return 0;
+			}
+
+			if (!colfer_charge(budget, len)) return 0;
+			uint8_t* a = malloc(len);
+			memcpy(a, p, len);
+			p += len;
+			binary->len = len;
+			binary->octets = a;
+		}
+
+		if (p >= end) {
+			errno = enderr;
+			return 0;
 		}
-#endif
 		header = *p++;
 	}
  {{- end}}
-{{else if eq .Type "float64"}}
+{{else}}
  {{- if not .TypeList}}
 	if (header == {{.Index}}) {
-		if (p+8 >= end) {
+		if (!colfer_charge(budget, sizeof({{.TypeRef.NameNative}}))) return 0;
+		o->{{.NameNative}} = calloc(1, sizeof({{.TypeRef.NameNative}}));
+		size_t read = {{.TypeRef.NameNative}}_unmarshal_at(o->{{.NameNative}}, p, (size_t) (end - p), depth + 1, budget, fault);
+		if (!read) {
+			if (errno == EWOULDBLOCK) errno = enderr;
+			return read;
+		}
+		p += read;
+
+		if (p >= end) {
 			errno = enderr;
 			return 0;
 		}
-#ifdef COLFER_ENDIAN
-		memcpy(&o->{{.NameNative}}, p, 8);
-		p += 8;
-#else
-		uint_fast64_t x = *p++;
-		x <<= 56;
-		x |= (uint_fast64_t) *p++ << 48;
-		x |= (uint_fast64_t) *p++ << 40;
-		x |= (uint_fast64_t) *p++ << 32;
-		x |= (uint_fast64_t) *p++ << 24;
-		x |= (uint_fast64_t) *p++ << 16;
-		x |= (uint_fast64_t) *p++ << 8;
-		x |= (uint_fast64_t) *p++;
-		memcpy(&o->{{.NameNative}}, &x, 8);
-#endif
 		header = *p++;
 	}
  {{- else}}
 	if (header == {{.Index}}) {
+		const uint8_t* at = p - 1;
 		if (p >= end) {
 			errno = enderr;
 			return 0;
@@ -1048,354 +1397,571 @@ size_t {{.NameNative}}_unmarshal({{.NameNative}}* o, const void* data, size_t da
 				n |= (c & 127) << shift;
 			}
 		}
-		if (n > colfer_list_max) {
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (n > colfer_list_max) {
 			errno = EFBIG;
 			return 0;
 		}
-		if (p+n*8 >= end) {
+		// each element takes at least one octet
+		if (p+n >= end) {
 			errno = enderr;
 			return 0;
 		}
 
-		double* fp = malloc(n * 8);
+		if (!colfer_charge(budget, n * sizeof({{.TypeRef.NameNative}}))) return 0;
+		{{.TypeRef.NameNative}}* a = calloc(n, sizeof({{.TypeRef.NameNative}}));
+		for (size_t i = 0; i < n; ++i) {
+			size_t read = {{.TypeRef.NameNative}}_unmarshal_at(&a[i], p, (size_t) (end - p), depth + 1, budget, fault);
+			if (!read) {
+				if (errno == EWOULDBLOCK) errno = enderr;
+				return read;
+			}
+			p += read;
+		}
 		o->{{.NameNative}}.len = n;
-		o->{{.NameNative}}.list = fp;
-#ifdef COLFER_ENDIAN
-		memcpy(fp, p, n * 8);
-		p += n * 8;
-#else
-		for (; n != 0; --n, ++fp) {
-			uint_fast64_t x = *p++;
-			x <<= 56;
-			x |= (uint_fast64_t) *p++ << 48;
-			x |= (uint_fast64_t) *p++ << 40;
-			x |= (uint_fast64_t) *p++ << 32;
-			x |= (uint_fast64_t) *p++ << 24;
-			x |= (uint_fast64_t) *p++ << 16;
-			x |= (uint_fast64_t) *p++ << 8;
-			x |= (uint_fast64_t) *p++;
-			memcpy(fp, &x, 8);
+		o->{{.NameNative}}.list = a;
+
+		if (p >= end) {
+			errno = enderr;
+			return 0;
 		}
-#endif
 		header = *p++;
 	}
  {{- end}}
-{{else if eq .Type "timestamp"}}
-	if ((header & 127) == {{.Index}}) {
-		if (header & 128) {
-			if (p+12 >= end) {
-				errno = enderr;
-				return 0;
-			}
-			uint_fast64_t x = *p++;
-			x <<= 56;
-			x |= (uint_fast64_t) *p++ << 48;
-			x |= (uint_fast64_t) *p++ << 40;
-			x |= (uint_fast64_t) *p++ << 32;
-			x |= (uint_fast64_t) *p++ << 24;
-			x |= (uint_fast64_t) *p++ << 16;
-			x |= (uint_fast64_t) *p++ << 8;
-			x |= (uint_fast64_t) *p++;
-			o->{{.NameNative}}.sec = x;
-		} else {
-			if (p+8 >= end) {
-				errno = enderr;
-				return 0;
+{{end}}{{end}}
+	if (header != 127) {
+		if (fault) *fault = p - 1;
+		errno = EILSEQ;
+		return 0;
+	}
+
+	return (size_t) (p - (const uint8_t*) data);
+}
+
+const char* {{.NameNative}}_validate(const {{.NameNative}}* o) {
+{{- range .Fields}}
+{{- if .Required}}
+	if ({{if eq .Type "bool"}}!o->{{.NameNative}}
+ {{- else if eq .Type "timestamp"}}!o->{{.NameNative}}.sec && !o->{{.NameNative}}.nanos
+ {{- else if or .TypeList (eq .Type "text" "binary")}}!o->{{.NameNative}}.len
+ {{- else if .TypeRef}}!o->{{.NameNative}}
+ {{- else}}o->{{.NameNative}} == 0
+ {{- end}})
+		return "colfer: field {{.String}} breaks rule required";
+{{- end}}
+{{- if .Min}}
+	if ({{if eq .Type "float32" "float64"}}!(o->{{.NameNative}} >= {{.MinNative}}){{else}}o->{{.NameNative}} < {{.MinNative}}{{end}})
+		return "colfer: field {{.String}} breaks rule min {{.Min}}";
+{{- end}}
+{{- if .Max}}
+	if ({{if eq .Type "float32" "float64"}}!(o->{{.NameNative}} <= {{.MaxNative}}){{else}}o->{{.NameNative}} > {{.MaxNative}}{{end}})
+		return "colfer: field {{.String}} breaks rule max {{.Max}}";
+{{- end}}
+{{- if .MinLen}}
+	if (o->{{.NameNative}}.len < {{.MinLen}})
+		return "colfer: field {{.String}} breaks rule minlen {{.MinLen}}";
+{{- end}}
+{{- if .MaxLen}}
+	if (o->{{.NameNative}}.len > {{.MaxLen}})
+		return "colfer: field {{.String}} breaks rule maxlen {{.MaxLen}}";
+{{- end}}
+{{- end}}
+{{- range .Fields}}
+{{- if and .TypeRef .TypeList}}
+	for (size_t i = 0; i < o->{{.NameNative}}.len; ++i) {
+		const char* err = {{.TypeRef.NameNative}}_validate(&o->{{.NameNative}}.list[i]);
+		if (err) return err;
+	}
+{{- else if .TypeRef}}
+	if (o->{{.NameNative}}) {
+		const char* err = {{.TypeRef.NameNative}}_validate(o->{{.NameNative}});
+		if (err) return err;
+	}
+{{- end}}
+{{- end}}
+	return NULL;
+}
+{{end}}{{end}}`
+
+// cMarshalField writes a field through octet pointer p.
+const cMarshalField = `{{if eq .Type "bool"}}
+	if (o->{{.NameNative}}) *p++ = {{.Index}};
+{{else if eq .Type "uint8"}}
+	if (o->{{.NameNative}}) {
+		*p++ = {{.Index}};
+
+		*p++ = o->{{.NameNative}};
+	}
+{{else if eq .Type "uint16"}}
+	{
+		uint_fast16_t x = o->{{.NameNative}};
+		if (x) {
+			if (x < 256)  {
+				*p++ = {{.Index}} | 0x80;
+
+				*p++ = x;
+			} else {
+				*p++ = {{.Index}};
+
+				*p++ = x >> 8;
+				*p++ = x;
 			}
-			uint_fast32_t x = *p++;
-			x <<= 24;
-			x |= (uint_fast32_t) *p++ << 16;
-			x |= (uint_fast32_t) *p++ << 8;
-			x |= (uint_fast32_t) *p++;
-			o->{{.NameNative}}.sec = x;
 		}
-		uint_fast32_t x = *p++;
-		x <<= 24;
-		x |= (uint_fast32_t) *p++ << 16;
-		x |= (uint_fast32_t) *p++ << 8;
-		x |= (uint_fast32_t) *p++;
-		o->{{.NameNative}}.nanos = x;
-		header = *p++;
 	}
-{{else if eq .Type "text"}}
- {{- if not .TypeList}}
-	if (header == {{.Index}}) {
-		if (p >= end) {
-			errno = enderr;
-			return 0;
+{{else if eq .Type "uint32"}}
+	{
+		uint_fast32_t x = o->{{.NameNative}};
+		if (x) {
+			if (x < (uint_fast32_t) 1 << 21) {
+				*p++ = {{.Index}};
+				for (; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
+			} else {
+				*p++ = {{.Index}} | 128;
+#ifdef COLFER_ENDIAN
+				memcpy(p, &o->{{.NameNative}}, 4);
+				p += 4;
+#else
+				*p++ = x >> 24;
+				*p++ = x >> 16;
+				*p++ = x >> 8;
+				*p++ = x;
+#endif
+			}
 		}
-		size_t n = *p++;
-		if (n > 127) {
-			n &= 127;
-			for (int shift = 7; shift < sizeof(size_t) * CHAR_BIT; shift += 7) {
-				if (p >= end) {
-					errno = enderr;
-					return 0;
-				}
-				size_t c = *p++;
-				if (c <= 127) {
-					n |= c << shift;
-					break;
-				}
-				n |= (c & 127) << shift;
+	}
+{{else if eq .Type "uint64"}}
+	{
+		uint_fast64_t x = o->{{.NameNative}};
+		if (x) {
+			if (x < (uint_fast64_t) 1 << 49) {
+				*p++ = {{.Index}};
+				for (; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
+			} else {
+				*p++ = {{.Index}} | 128;
+#ifdef COLFER_ENDIAN
+				memcpy(p, &o->{{.NameNative}}, 8);
+				p += 8;
+#else
+				*p++ = x >> 56;
+				*p++ = x >> 48;
+				*p++ = x >> 40;
+				*p++ = x >> 32;
+				*p++ = x >> 24;
+				*p++ = x >> 16;
+				*p++ = x >> 8;
+				*p++ = x;
+#endif
 			}
 		}
-		if (p+n >= end) {
-			errno = enderr;
-			return 0;
+	}
+{{else if eq .Type "int32"}}
+	{
+		uint_fast32_t x = o->{{.NameNative}};
+		if (x) {
+			if (x & (uint_fast32_t) 1 << 31) {
+				*p++ = {{.Index}} | 128;
+				x = ~x + 1;
+			} else	*p++ = {{.Index}};
+
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
 		}
+	}
+{{else if eq .Type "int64"}}
+	{
+		uint_fast64_t x = o->{{.NameNative}};
+		if (x) {
+			if (x & (uint_fast64_t) 1 << 63) {
+				*p++ = {{.Index}} | 128;
+				x = ~x + 1;
+			} else	*p++ = {{.Index}};
 
-		void* a = malloc(n);
-		memcpy(a, p, n);
-		p += n;
-		o->{{.NameNative}}.len = n;
-		o->{{.NameNative}}.utf8 = (char*) a;
-		header = *p++;
+			uint8_t* max = p + 8;
+			for (; x >= 128 && p < max; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+		}
+	}
+{{else if eq .Type "float32"}}
+ {{- if not .TypeList}}
+	if (o->{{.NameNative}} != 0.0f) {
+		*p++ = {{.Index}};
+
+#ifdef COLFER_ENDIAN
+		memcpy(p, &o->{{.NameNative}}, 4);
+		p += 4;
+#else
+		uint_fast32_t x;
+		memcpy(&x, &o->{{.NameNative}}, 4);
+		*p++ = x >> 24;
+		*p++ = x >> 16;
+		*p++ = x >> 8;
+		*p++ = x;
+#endif
 	}
  {{- else}}
-	if (header == {{.Index}}) {
-		if (p >= end) {
-			errno = enderr;
-			return 0;
-		}
-		size_t n = *p++;
-		if (n > 127) {
-			n &= 127;
-			for (int shift = 7; ; shift += 7) {
-				if (p >= end) {
-					errno = enderr;
-					return 0;
-				}
-				size_t c = *p++;
-				if (c <= 127) {
-					n |= c << shift;
-					break;
-				}
-				n |= (c & 127) << shift;
+	{
+		size_t n = o->{{.NameNative}}.len;
+		if (n) {
+			*p++ = {{.Index}};
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+#ifdef COLFER_ENDIAN
+			memcpy(p, o->{{.NameNative}}.list, n * 4);
+			p += n * 4;
+#else
+			uint32_t* fp = (uint32_t*) o->{{.NameNative}}.list;
+			for (;;) {
+				memcpy(&x, fp, 4);
+				*p++ = x >> 24;
+				*p++ = x >> 16;
+				*p++ = x >> 8;
+				*p++ = x;
+				if (--n == 0) break;
+				++fp;
 			}
+#endif
 		}
-		if (n > colfer_list_max) {
-			errno = EFBIG;
-			return 0;
-		}
+	}
+ {{- end}}
+{{else if eq .Type "float64"}}
+ {{- if not .TypeList}}
+	if (o->{{.NameNative}} != 0.0) {
+		*p++ = {{.Index}};
 
-		colfer_text* text = malloc(n * sizeof(colfer_text));
-		o->{{.NameNative}}.len = n;
-		o->{{.NameNative}}.list = text;
-		for (; n != 0; --n, ++text) {
-			if (p >= end) {
-				errno = enderr;
-				return 0;
+#ifdef COLFER_ENDIAN
+		memcpy(p, &o->{{.NameNative}}, 8);
+		p += 8;
+#else
+		uint_fast64_t x;
+		memcpy(&x, &o->{{.NameNative}}, 8);
+		*p++ = x >> 56;
+		*p++ = x >> 48;
+		*p++ = x >> 40;
+		*p++ = x >> 32;
+		*p++ = x >> 24;
+		*p++ = x >> 16;
+		*p++ = x >> 8;
+		*p++ = x;
+#endif
+	}
+ {{- else}}
+	{
+		size_t n = o->{{.NameNative}}.len;
+		if (n) {
+			*p++ = {{.Index}};
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+#ifdef COLFER_ENDIAN
+			memcpy(p, o->{{.NameNative}}.list, n * 8);
+			p += n * 8;
+#else
+			uint64_t* fp = (uint64_t*) o->{{.NameNative}}.list;
+			for (;;) {
+				uint_fast64_t x;
+				memcpy(&x, fp, 8);
+				*p++ = x >> 56;
+				*p++ = x >> 48;
+				*p++ = x >> 40;
+				*p++ = x >> 32;
+				*p++ = x >> 24;
+				*p++ = x >> 16;
+				*p++ = x >> 8;
+				*p++ = x;
+				if (--n == 0) break;
+				++fp;
 			}
-			size_t len = *p++;
-			if (len > 127) {
-				len &= 127;
-				for (int shift = 7; ; shift += 7) {
-					if (p >= end) {
-						errno = enderr;
-						return 0;
-					}
-					size_t c = *p++;
-					if (c <= 127) {
-						len |= c << shift;
-						break;
-					}
-					len |= (c & 127) << shift;
-				}
+#endif
+		}
+	}
+ {{- end}}
+{{else if eq .Type "timestamp"}}
+	{
+		int_fast64_t s = o->{{.NameNative}}.sec;
+		int_fast64_t ns = o->{{.NameNative}}.nanos;
+		if (s || ns) {
+			static const int_fast64_t nano = 1000000000;
+			s += ns / nano;
+			ns %= nano;
+			if (ns < 0) {
+				--s;
+				ns += nano;
 			}
-			if (p+len >= end) {
-				errno = enderr;
-				return 0;
+
+			uint_fast64_t x = s;
+			if (x < (uint_fast64_t) 1 << 32)
+				*p++ = {{.Index}};
+			else {
+				*p++ = {{.Index}} | 128;
+
+				*p++ = x >> 56;
+				*p++ = x >> 48;
+				*p++ = x >> 40;
+				*p++ = x >> 32;
 			}
+			*p++ = x >> 24;
+			*p++ = x >> 16;
+			*p++ = x >> 8;
+			*p++ = x;
 
-			char* a = malloc(len);
-			memcpy(a, p, len);
-			p += len;
-			text->len = len;
-			text->utf8 = a;
+			x = ns;
+			*p++ = x >> 24;
+			*p++ = x >> 16;
+			*p++ = x >> 8;
+			*p++ = x;
+		}
+	}
+{{else if eq .Type "text"}}
+ {{- if not .TypeList}}
+	{
+		size_t n = o->{{.NameNative}}.len;
+		if (n) {
+			*p++ = {{.Index}};
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			memcpy(p, o->{{.NameNative}}.utf8, n);
+			p += n;
 		}
+	}
+ {{- else}}
+	{
+		size_t count = o->{{.NameNative}}.len;
+		if (count) {
+			*p++ = {{.Index}};
+
+			uint_fast32_t x = count;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			colfer_text* text = o->{{.NameNative}}.list;
+			do {
+				size_t n = text->len;
+				for (x = n; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
+
+				memcpy(p, text->utf8, n);
+				p += n;
+
+				++text;
+			} while (--count != 0);
+		}
+	}
+ {{- end}}
+{{else if eq .Type "binary"}}
+ {{- if not .TypeList}}
+	{
+		size_t n = o->{{.NameNative}}.len;
+		if (n) {
+			*p++ = {{.Index}};
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			memcpy(p, o->{{.NameNative}}.octets, n);
+			p += n;
+		}
+	}
+ {{- else}}
+	{
+		size_t count = o->{{.NameNative}}.len;
+		if (count) {
+			*p++ = {{.Index}};
+
+			uint_fast32_t x = count;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			colfer_binary* binary = o->{{.NameNative}}.list;
+			do {
+				size_t n = binary->len;
+				for (x = n; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
 
-		if (p >= end) {
-			errno = enderr;
-			return 0;
+				memcpy(p, binary->octets, n);
+				p += n;
+
+				++binary;
+			} while (--count != 0);
 		}
-		header = *p++;
 	}
  {{- end}}
-{{else if eq .Type "binary"}}
+{{else}}
  {{- if not .TypeList}}
-	if (header == {{.Index}}) {
-		if (p >= end) {
-			errno = enderr;
-			return 0;
-		}
-		size_t n = *p++;
-		if (n > 127) {
-			n &= 127;
-			for (int shift = 7; ; shift += 7) {
-				if (p >= end) {
-					errno = enderr;
-					return 0;
-				}
-				size_t c = *p++;
-				if (c <= 127) {
-					n |= c << shift;
-					break;
-				}
-				n |= (c & 127) << shift;
-			}
-		}
-		if (p+n >= end) {
-			errno = enderr;
-			return 0;
-		}
+	{
+		if (o->{{.NameNative}}) {
+			*p++ = {{.Index}};
 
-		void* a = malloc(n);
-		memcpy(a, p, n);
-		p += n;
-		o->{{.NameNative}}.len = n;
-		o->{{.NameNative}}.octets = (uint8_t*) a;
-		header = *p++;
+			p += {{.TypeRef.NameNative}}_marshal(o->{{.NameNative}}, p);
+		}
 	}
  {{- else}}
-	if (header == {{.Index}}) {
-		if (p >= end) {
-			errno = enderr;
-			return 0;
-		}
-		size_t n = *p++;
-		if (n > 127) {
-			n &= 127;
-			for (int shift = 7; ; shift += 7) {
-				if (p >= end) {
-					errno = enderr;
-					return 0;
-				}
-				size_t c = *p++;
-				if (c <= 127) {
-					n |= c << shift;
-					break;
-				}
-				n |= (c & 127) << shift;
-			}
-		}
-		if (n > colfer_list_max) {
-			errno = EFBIG;
-			return 0;
+	{
+		size_t n = o->{{.NameNative}}.len;
+		if (n) {
+			*p++ = {{.Index}};
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			{{.TypeRef.NameNative}}* a = o->{{.NameNative}}.list;
+			for (size_t i = 0; i < n; ++i) p += {{.TypeRef.NameNative}}_marshal(&a[i], p);
 		}
+	}
+ {{- end}}
+{{end}}`
 
-		colfer_binary* binary = malloc(n * sizeof(colfer_binary));
-		o->{{.NameNative}}.len = n;
-		o->{{.NameNative}}.list = binary;
-		for (; n != 0; --n, ++binary) {
-			if (p >= end) {
-				errno = enderr;
-				return 0;
+// cHashField feeds a field into update, with buf for the pending octets.
+const cHashField = `{{if eq .Type "float32" "float64"}}
+	{
+		size_t n = o->{{.NameNative}}.len;
+		if (n) {
+			update(ctx, buf, p - buf);
+			p = buf;
+			*p++ = {{.Index}};
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+#ifdef COLFER_ENDIAN
+			update(ctx, buf, p - buf);
+			p = buf;
+			update(ctx, o->{{.NameNative}}.list, n * {{if eq .Type "float32"}}4{{else}}8{{end}});
+#else
+ {{- if eq .Type "float32"}}
+			uint32_t* fp = (uint32_t*) o->{{.NameNative}}.list;
+			for (;;) {
+				if (p - buf > 28) {
+					update(ctx, buf, p - buf);
+					p = buf;
+				}
+				memcpy(&x, fp, 4);
+				*p++ = x >> 24;
+				*p++ = x >> 16;
+				*p++ = x >> 8;
+				*p++ = x;
+				if (--n == 0) break;
+				++fp;
 			}
-			size_t len = *p++;
-			if (len > 127) {
-				len &= 127;
-				for (int shift = 7; ; shift += 7) {
-					if (p >= end) {
-						errno = enderr;
-						return 0;
-					}
-					size_t c = *p++;
-					if (c <= 127) {
-						len |= c << shift;
-						break;
-					}
-					len |= (c & 127) << shift;
+ {{- else}}
+			uint64_t* fp = (uint64_t*) o->{{.NameNative}}.list;
+			for (;;) {
+				if (p - buf > 24) {
+					update(ctx, buf, p - buf);
+					p = buf;
 				}
+				uint_fast64_t x;
+				memcpy(&x, fp, 8);
+				*p++ = x >> 56;
+				*p++ = x >> 48;
+				*p++ = x >> 40;
+				*p++ = x >> 32;
+				*p++ = x >> 24;
+				*p++ = x >> 16;
+				*p++ = x >> 8;
+				*p++ = x;
+				if (--n == 0) break;
+				++fp;
 			}
-			if (p+len >= end) {
-				errno = enderr;
-				return 0;
+ {{- end}}
+#endif
+		}
+	}
+{{else if eq .Type "text" "binary"}}
+ {{- if not .TypeList}}
+	{
+		size_t n = o->{{.NameNative}}.len;
+		if (n) {
+			if (p - buf > 8) {
+				update(ctx, buf, p - buf);
+				p = buf;
 			}
+			*p++ = {{.Index}};
 
-			uint8_t* a = malloc(len);
-			memcpy(a, p, len);
-			p += len;
-			binary->len = len;
-			binary->octets = a;
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			update(ctx, buf, p - buf);
+			p = buf;
+			update(ctx, o->{{.NameNative}}.{{if eq .Type "text"}}utf8{{else}}octets{{end}}, n);
 		}
+	}
+ {{- else}}
+	{
+		size_t count = o->{{.NameNative}}.len;
+		if (count) {
+			if (p - buf > 8) {
+				update(ctx, buf, p - buf);
+				p = buf;
+			}
+			*p++ = {{.Index}};
 
-		if (p >= end) {
-			errno = enderr;
-			return 0;
+			uint_fast32_t x = count;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			colfer_{{.Type}}* v = o->{{.NameNative}}.list;
+			do {
+				size_t n = v->len;
+				for (x = n; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
+
+				update(ctx, buf, p - buf);
+				p = buf;
+				update(ctx, v->{{if eq .Type "text"}}utf8{{else}}octets{{end}}, n);
+
+				++v;
+			} while (--count != 0);
 		}
-		header = *p++;
 	}
  {{- end}}
 {{else}}
  {{- if not .TypeList}}
-	if (header == {{.Index}}) {
-		o->{{.NameNative}} = calloc(1, sizeof({{.TypeRef.NameNative}}));
-		size_t read = {{.TypeRef.NameNative}}_unmarshal(o->{{.NameNative}}, p, (size_t) (end - p));
-		if (!read) {
-			if (errno == EWOULDBLOCK) errno = enderr;
-			return read;
-		}
-		p += read;
+	{
+		if (o->{{.NameNative}}) {
+			*p++ = {{.Index}};
+			update(ctx, buf, p - buf);
+			p = buf;
 
-		if (p >= end) {
-			errno = enderr;
-			return 0;
+			{{.TypeRef.NameNative}}_hash_at(o->{{.NameNative}}, update, ctx);
 		}
-		header = *p++;
 	}
  {{- else}}
-	if (header == {{.Index}}) {
-		if (p >= end) {
-			errno = enderr;
-			return 0;
-		}
-		size_t n = *p++;
-		if (n > 127) {
-			n &= 127;
-			for (int shift = 7; ; shift += 7) {
-				if (p >= end) {
-					errno = enderr;
-					return 0;
-				}
-				size_t c = *p++;
-				if (c <= 127) {
-					n |= c << shift;
-					break;
-				}
-				n |= (c & 127) << shift;
+	{
+		size_t n = o->{{.NameNative}}.len;
+		if (n) {
+			if (p - buf > 8) {
+				update(ctx, buf, p - buf);
+				p = buf;
 			}
-		}
-		if (n > colfer_list_max) {
-			errno = EFBIG;
-			return 0;
-		}
+			*p++ = {{.Index}};
 
-		{{.TypeRef.NameNative}}* a = calloc(n, sizeof({{.TypeRef.NameNative}}));
-		for (size_t i = 0; i < n; ++i) {
-			size_t read = {{.TypeRef.NameNative}}_unmarshal(&a[i], p, (size_t) (end - p));
-			if (!read) {
-				if (errno == EWOULDBLOCK) errno = enderr;
-				return read;
-			}
-			p += read;
-		}
-		o->{{.NameNative}}.len = n;
-		o->{{.NameNative}}.list = a;
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+			update(ctx, buf, p - buf);
+			p = buf;
 
-		if (p >= end) {
-			errno = enderr;
-			return 0;
+			{{.TypeRef.NameNative}}* a = o->{{.NameNative}}.list;
+			for (size_t i = 0; i < n; ++i) {{.TypeRef.NameNative}}_hash_at(&a[i], update, ctx);
 		}
-		header = *p++;
 	}
  {{- end}}
-{{end}}{{end}}
-	if (header != 127) {
-		errno = EILSEQ;
-		return 0;
-	}
-
-	return (size_t) (p - (const uint8_t*) data);
-}
-{{end}}{{end}}`
+{{end}}`
@@ -1,6 +1,8 @@
 // Code generated by colf(1); DO NOT EDIT.
-// The compiler used schema file test.colf for package gen.
+// The compiler used schema file rules.colf and test.colf for package gen.
 
+// The implementation uses all definitions, including the deprecated ones.
+#define COLFER_DEPRECATED
 #include "Colfer.h"
 #include <errno.h>
 #include <stdlib.h>
@@ -18,7 +20,77 @@
 
 size_t colfer_size_max = 16 * 1024 * 1024;
 size_t colfer_list_max = 64 * 1024;
+size_t colfer_depth_max = 100;
+
+
+static size_t gen_o_unmarshal_at(gen_o* o, const void* data, size_t datalen, size_t depth, size_t* budget, const uint8_t** fault);
+static void gen_o_hash_at(const gen_o* o, colfer_hash_func update, void* ctx);
+static size_t gen_e_unmarshal_at(gen_e* o, const void* data, size_t datalen, size_t depth, size_t* budget, const uint8_t** fault);
+static void gen_e_hash_at(const gen_e* o, colfer_hash_func update, void* ctx);
+static size_t gen_r_unmarshal_at(gen_r* o, const void* data, size_t datalen, size_t depth, size_t* budget, const uint8_t** fault);
+static void gen_r_hash_at(const gen_r* o, colfer_hash_func update, void* ctx);
+static size_t gen_old_unmarshal_at(gen_old* o, const void* data, size_t datalen, size_t depth, size_t* budget, const uint8_t** fault);
+static void gen_old_hash_at(const gen_old* o, colfer_hash_func update, void* ctx);
+static size_t gen_renamed_unmarshal_at(gen_renamed* o, const void* data, size_t datalen, size_t depth, size_t* budget, const uint8_t** fault);
+static void gen_renamed_hash_at(const gen_renamed* o, colfer_hash_func update, void* ctx);
+
+// colfer_charge deducts n octets from budget, if any. The return is zero, with
+// errno set to EFBIG, when n exceeds the remainder.
+static int colfer_charge(size_t* budget, size_t n) {
+	if (!budget) return 1;
+	if (n > *budget) {
+		errno = EFBIG;
+		return 0;
+	}
+	*budget -= n;
+	return 1;
+}
+
+// colfer_varint_size returns the octet size of x in the canonical encoding.
+static size_t colfer_varint_size(uint_fast64_t x) {
+	size_t n = 1;
+	for (; x > 127 && n < 9; x >>= 7) ++n;
+	return n;
+}
+
+// colfer_utf8_len returns the number of octets in p before the first invalid
+// UTF-8 sequence, if any.
+static size_t colfer_utf8_len(const uint8_t* p, size_t n) {
+	size_t i = 0;
+	while (i < n) {
+		uint_fast32_t c = p[i];
+		if (c < 128) {
+			++i;
+			continue;
+		}
+
+		size_t follow;
+		uint_fast32_t min;
+		if (c > 193 && c < 224) {
+			follow = 1;
+			min = 0x80;
+		} else if (c > 223 && c < 240) {
+			follow = 2;
+			min = 0x800;
+		} else if (c > 239 && c < 245) {
+			follow = 3;
+			min = 0x10000;
+		} else {
+			return i;
+		}
+		if (i + follow >= n) return i;
 
+		c &= 63 >> follow;
+		for (size_t j = 1; j <= follow; ++j) {
+			uint_fast32_t b = p[i + j];
+			if ((b & 192) != 128) return i;
+			c = c << 6 | (b & 63);
+		}
+		if (c < min || c > 0x10ffff || (c > 0xd7ff && c < 0xe000)) return i;
+		i += follow + 1;
+	}
+	return n;
+}
 
 
 size_t gen_o_marshal_len(const gen_o* o) {
@@ -538,193 +610,680 @@ size_t gen_o_marshal(const gen_o* o, void* buf) {
 	return p - (uint8_t*) buf;
 }
 
-size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
-	// octet pointer navigation
-	const uint8_t* p = data;
-	const uint8_t* end;
-	int enderr;
-	if (datalen < colfer_size_max) {
-		end = p + datalen;
-		enderr = EWOULDBLOCK;
-	} else {
-		end = p + colfer_size_max;
-		enderr = EFBIG;
-	}
+size_t gen_o_hash(const gen_o* o, colfer_hash_func update, void* ctx) {
+	size_t n = gen_o_marshal_len(o);
+	if (n) gen_o_hash_at(o, update, ctx);
+	return n;
+}
 
-	if (p >= end) {
-		errno = enderr;
-		return 0;
-	}
-	uint_fast8_t header = *p++;
+// gen_o_hash_at is gen_o_hash without the limit checks.
+static void gen_o_hash_at(const gen_o* o, colfer_hash_func update, void* ctx) {
+	// pending octets
+	uint8_t buf[32];
+	uint8_t* p = buf;
 
-	if (header == 0) {
-		o->b = 1;
-		if (p >= end) {
-			errno = enderr;
-			return 0;
-		}
-		header = *p++;
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
 	}
+	if (o->b) *p++ = 0;
 
-	if (header == 1) {
-		if (p+1 >= end) {
-			errno = enderr;
-			return 0;
-		}
-		uint_fast32_t x = *p++;
-		if (x > 127) {
-			x &= 127;
-			for (int shift = 7; ; shift += 7) {
-				uint_fast32_t b = *p++;
-				if (p >= end) {
-					errno = enderr;
-					return 0;
-				}
-				if (b <= 127) {
-					x |= b << shift;
-					break;
-				}
-				x |= (b & 127) << shift;
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
+	}
+	{
+		uint_fast32_t x = o->u32;
+		if (x) {
+			if (x < (uint_fast32_t) 1 << 21) {
+				*p++ = 1;
+				for (; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
+			} else {
+				*p++ = 1 | 128;
+#ifdef COLFER_ENDIAN
+				memcpy(p, &o->u32, 4);
+				p += 4;
+#else
+				*p++ = x >> 24;
+				*p++ = x >> 16;
+				*p++ = x >> 8;
+				*p++ = x;
+#endif
 			}
 		}
-		o->u32 = x;
-		header = *p++;
-	} else if (header == (1 | 128)) {
-		if (p+4 >= end) {
-			errno = enderr;
-			return 0;
-		}
-		uint_fast32_t x = *p++;
-		x <<= 24;
-		x |= (uint_fast32_t) *p++ << 16;
-		x |= (uint_fast32_t) *p++ << 8;
-		x |= (uint_fast32_t) *p++;
-		o->u32 = x;
-		header = *p++;
 	}
 
-	if (header == 2) {
-		if (p+1 >= end) {
-			errno = enderr;
-			return 0;
-		}
-		uint_fast64_t x = *p++;
-		if (x > 127) {
-			x &= 127;
-			for (int shift = 7; ; shift += 7) {
-				uint_fast64_t b = *p++;
-				if (p >= end) {
-					errno = enderr;
-					return 0;
-				}
-				if (b <= 127) {
-					x |= b << shift;
-					break;
-				}
-				x |= (b & 127) << shift;
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
+	}
+	{
+		uint_fast64_t x = o->u64;
+		if (x) {
+			if (x < (uint_fast64_t) 1 << 49) {
+				*p++ = 2;
+				for (; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
+			} else {
+				*p++ = 2 | 128;
+#ifdef COLFER_ENDIAN
+				memcpy(p, &o->u64, 8);
+				p += 8;
+#else
+				*p++ = x >> 56;
+				*p++ = x >> 48;
+				*p++ = x >> 40;
+				*p++ = x >> 32;
+				*p++ = x >> 24;
+				*p++ = x >> 16;
+				*p++ = x >> 8;
+				*p++ = x;
+#endif
 			}
 		}
-		o->u64 = x;
-		header = *p++;
-	} else if (header == (2 | 128)) {
-		if (p+8 >= end) {
-			errno = enderr;
-			return 0;
-		}
-		uint_fast64_t x = *p++;
-		x <<= 56;
-		x |= (uint_fast64_t) *p++ << 48;
-		x |= (uint_fast64_t) *p++ << 40;
-		x |= (uint_fast64_t) *p++ << 32;
-		x |= (uint_fast64_t) *p++ << 24;
-		x |= (uint_fast64_t) *p++ << 16;
-		x |= (uint_fast64_t) *p++ << 8;
-		x |= (uint_fast64_t) *p++;
-		o->u64 = x;
-		header = *p++;
 	}
 
-	if ((header & 127) == 3) {
-		if (p+1 >= end) {
-			errno = enderr;
-			return 0;
-		}
-		uint_fast32_t x = *p++;
-		if (x > 127) {
-			x &= 127;
-			for (int shift = 7; shift < 35; shift += 7) {
-				uint_fast32_t b = *p++;
-				if (p >= end) {
-					errno = enderr;
-					return 0;
-				}
-				if (b <= 127) {
-					x |= b << shift;
-					break;
-				}
-				x |= (b & 127) << shift;
-			}
-		}
-		if (header & 128) x = ~x + 1;
-		o->i32 = x;
-		header = *p++;
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
 	}
+	{
+		uint_fast32_t x = o->i32;
+		if (x) {
+			if (x & (uint_fast32_t) 1 << 31) {
+				*p++ = 3 | 128;
+				x = ~x + 1;
+			} else	*p++ = 3;
 
-	if ((header & 127) == 4) {
-		if (p+1 >= end) {
-			errno = enderr;
-			return 0;
-		}
-		uint_fast64_t x = *p++;
-		if (x > 127) {
-			x &= 127;
-			for (int shift = 7; ; shift += 7) {
-				uint_fast64_t b = *p++;
-				if (p >= end) {
-					errno = enderr;
-					return 0;
-				}
-				if (b <= 127 || shift == 56) {
-					x |= b << shift;
-					break;
-				}
-				x |= (b & 127) << shift;
-			}
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
 		}
-		if (header & 128) x = ~x + 1;
-		o->i64 = x;
-		header = *p++;
 	}
 
-	if (header == 5) {
-		if (p+4 >= end) {
-			errno = enderr;
-			return 0;
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
+	}
+	{
+		uint_fast64_t x = o->i64;
+		if (x) {
+			if (x & (uint_fast64_t) 1 << 63) {
+				*p++ = 4 | 128;
+				x = ~x + 1;
+			} else	*p++ = 4;
+
+			uint8_t* max = p + 8;
+			for (; x >= 128 && p < max; x >>= 7) *p++ = x | 128;
+			*p++ = x;
 		}
+	}
+
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
+	}
+	if (o->f32 != 0.0f) {
+		*p++ = 5;
+
 #ifdef COLFER_ENDIAN
-		memcpy(&o->f32, p, 4);
+		memcpy(p, &o->f32, 4);
 		p += 4;
 #else
-		uint_fast32_t x = *p++;
-		x <<= 24;
-		x |= (uint_fast32_t) *p++ << 16;
-		x |= (uint_fast32_t) *p++ << 8;
-		x |= (uint_fast32_t) *p++;
-		memcpy(&o->f32, &x, 4);
+		uint_fast32_t x;
+		memcpy(&x, &o->f32, 4);
+		*p++ = x >> 24;
+		*p++ = x >> 16;
+		*p++ = x >> 8;
+		*p++ = x;
 #endif
-		header = *p++;
 	}
 
-	if (header == 6) {
-		if (p+8 >= end) {
-			errno = enderr;
-			return 0;
-		}
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
+	}
+	if (o->f64 != 0.0) {
+		*p++ = 6;
+
 #ifdef COLFER_ENDIAN
-		memcpy(&o->f64, p, 8);
+		memcpy(p, &o->f64, 8);
 		p += 8;
 #else
-		uint_fast64_t x = *p++;
+		uint_fast64_t x;
+		memcpy(&x, &o->f64, 8);
+		*p++ = x >> 56;
+		*p++ = x >> 48;
+		*p++ = x >> 40;
+		*p++ = x >> 32;
+		*p++ = x >> 24;
+		*p++ = x >> 16;
+		*p++ = x >> 8;
+		*p++ = x;
+#endif
+	}
+
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
+	}
+	{
+		int_fast64_t s = o->t.sec;
+		int_fast64_t ns = o->t.nanos;
+		if (s || ns) {
+			static const int_fast64_t nano = 1000000000;
+			s += ns / nano;
+			ns %= nano;
+			if (ns < 0) {
+				--s;
+				ns += nano;
+			}
+
+			uint_fast64_t x = s;
+			if (x < (uint_fast64_t) 1 << 32)
+				*p++ = 7;
+			else {
+				*p++ = 7 | 128;
+
+				*p++ = x >> 56;
+				*p++ = x >> 48;
+				*p++ = x >> 40;
+				*p++ = x >> 32;
+			}
+			*p++ = x >> 24;
+			*p++ = x >> 16;
+			*p++ = x >> 8;
+			*p++ = x;
+
+			x = ns;
+			*p++ = x >> 24;
+			*p++ = x >> 16;
+			*p++ = x >> 8;
+			*p++ = x;
+		}
+	}
+
+	{
+		size_t n = o->s.len;
+		if (n) {
+			if (p - buf > 8) {
+				update(ctx, buf, p - buf);
+				p = buf;
+			}
+			*p++ = 8;
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			update(ctx, buf, p - buf);
+			p = buf;
+			update(ctx, o->s.utf8, n);
+		}
+	}
+
+	{
+		size_t n = o->a.len;
+		if (n) {
+			if (p - buf > 8) {
+				update(ctx, buf, p - buf);
+				p = buf;
+			}
+			*p++ = 9;
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			update(ctx, buf, p - buf);
+			p = buf;
+			update(ctx, o->a.octets, n);
+		}
+	}
+
+	{
+		if (o->o) {
+			*p++ = 10;
+			update(ctx, buf, p - buf);
+			p = buf;
+
+			gen_o_hash_at(o->o, update, ctx);
+		}
+	}
+
+	{
+		size_t n = o->os.len;
+		if (n) {
+			if (p - buf > 8) {
+				update(ctx, buf, p - buf);
+				p = buf;
+			}
+			*p++ = 11;
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+			update(ctx, buf, p - buf);
+			p = buf;
+
+			gen_o* a = o->os.list;
+			for (size_t i = 0; i < n; ++i) gen_o_hash_at(&a[i], update, ctx);
+		}
+	}
+
+	{
+		size_t count = o->ss.len;
+		if (count) {
+			if (p - buf > 8) {
+				update(ctx, buf, p - buf);
+				p = buf;
+			}
+			*p++ = 12;
+
+			uint_fast32_t x = count;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			colfer_text* v = o->ss.list;
+			do {
+				size_t n = v->len;
+				for (x = n; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
+
+				update(ctx, buf, p - buf);
+				p = buf;
+				update(ctx, v->utf8, n);
+
+				++v;
+			} while (--count != 0);
+		}
+	}
+
+	{
+		size_t count = o->as.len;
+		if (count) {
+			if (p - buf > 8) {
+				update(ctx, buf, p - buf);
+				p = buf;
+			}
+			*p++ = 13;
+
+			uint_fast32_t x = count;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			colfer_binary* v = o->as.list;
+			do {
+				size_t n = v->len;
+				for (x = n; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
+
+				update(ctx, buf, p - buf);
+				p = buf;
+				update(ctx, v->octets, n);
+
+				++v;
+			} while (--count != 0);
+		}
+	}
+
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
+	}
+	if (o->u8) {
+		*p++ = 14;
+
+		*p++ = o->u8;
+	}
+
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
+	}
+	{
+		uint_fast16_t x = o->u16;
+		if (x) {
+			if (x < 256)  {
+				*p++ = 15 | 0x80;
+
+				*p++ = x;
+			} else {
+				*p++ = 15;
+
+				*p++ = x >> 8;
+				*p++ = x;
+			}
+		}
+	}
+
+	{
+		size_t n = o->f32s.len;
+		if (n) {
+			update(ctx, buf, p - buf);
+			p = buf;
+			*p++ = 16;
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+#ifdef COLFER_ENDIAN
+			update(ctx, buf, p - buf);
+			p = buf;
+			update(ctx, o->f32s.list, n * 4);
+#else
+			uint32_t* fp = (uint32_t*) o->f32s.list;
+			for (;;) {
+				if (p - buf > 28) {
+					update(ctx, buf, p - buf);
+					p = buf;
+				}
+				memcpy(&x, fp, 4);
+				*p++ = x >> 24;
+				*p++ = x >> 16;
+				*p++ = x >> 8;
+				*p++ = x;
+				if (--n == 0) break;
+				++fp;
+			}
+#endif
+		}
+	}
+
+	{
+		size_t n = o->f64s.len;
+		if (n) {
+			update(ctx, buf, p - buf);
+			p = buf;
+			*p++ = 17;
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+#ifdef COLFER_ENDIAN
+			update(ctx, buf, p - buf);
+			p = buf;
+			update(ctx, o->f64s.list, n * 8);
+#else
+			uint64_t* fp = (uint64_t*) o->f64s.list;
+			for (;;) {
+				if (p - buf > 24) {
+					update(ctx, buf, p - buf);
+					p = buf;
+				}
+				uint_fast64_t x;
+				memcpy(&x, fp, 8);
+				*p++ = x >> 56;
+				*p++ = x >> 48;
+				*p++ = x >> 40;
+				*p++ = x >> 32;
+				*p++ = x >> 24;
+				*p++ = x >> 16;
+				*p++ = x >> 8;
+				*p++ = x;
+				if (--n == 0) break;
+				++fp;
+			}
+#endif
+		}
+	}
+
+	*p++ = 127;
+	update(ctx, buf, p - buf);
+}
+
+size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
+	return gen_o_unmarshal_at(o, data, datalen, 1, NULL, NULL);
+}
+
+size_t gen_o_unmarshal_budget(gen_o* o, const void* data, size_t datalen, size_t* budget) {
+	return gen_o_unmarshal_at(o, data, datalen, 1, budget, NULL);
+}
+
+size_t gen_o_unmarshal_strict(gen_o* o, const void* data, size_t datalen, size_t* offset) {
+	const uint8_t* at = data;
+	size_t n = gen_o_unmarshal_at(o, data, datalen, 1, NULL, &at);
+	if (!n && errno == EILSEQ) *offset = (size_t) (at - (const uint8_t*) data);
+	return n;
+}
+
+// gen_o_unmarshal_at is gen_o_unmarshal with depth as the
+// number of data structure levels, including o. Allocation is charged to budget
+// when not NULL. Strict mode applies when fault is not NULL, which then receives
+// the location of any EILSEQ.
+static size_t gen_o_unmarshal_at(gen_o* o, const void* data, size_t datalen, size_t depth, size_t* budget, const uint8_t** fault) {
+	if (depth > colfer_depth_max) {
+		errno = EFBIG;
+		return 0;
+	}
+
+	// octet pointer navigation
+	const uint8_t* p = data;
+	const uint8_t* end;
+	int enderr;
+	if (datalen < colfer_size_max) {
+		end = p + datalen;
+		enderr = EWOULDBLOCK;
+	} else {
+		end = p + colfer_size_max;
+		enderr = EFBIG;
+	}
+
+	if (p >= end) {
+		errno = enderr;
+		return 0;
+	}
+	uint_fast8_t header = *p++;
+
+	if (header == 0) {
+		o->b = 1;
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		header = *p++;
+	}
+
+	if (header == 1) {
+		const uint8_t* at = p - 1;
+		if (p+1 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		uint_fast32_t x = *p++;
+		if (x > 127) {
+			x &= 127;
+			for (int shift = 7; ; shift += 7) {
+				uint_fast32_t b = *p++;
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				if (b <= 127) {
+					x |= b << shift;
+					break;
+				}
+				x |= (b & 127) << shift;
+			}
+		}
+		o->u32 = x;
+		if (fault && (!x || x >= (uint_fast32_t) 1 << 21 || (size_t) (p - at - 1) != colfer_varint_size(x))) {
+			*fault = at;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
+	} else if (header == (1 | 128)) {
+		if (p+4 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		uint_fast32_t x = *p++;
+		x <<= 24;
+		x |= (uint_fast32_t) *p++ << 16;
+		x |= (uint_fast32_t) *p++ << 8;
+		x |= (uint_fast32_t) *p++;
+		o->u32 = x;
+		if (fault && (x < (uint_fast32_t) 1 << 21)) {
+			*fault = p - 5;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
+	}
+
+	if (header == 2) {
+		const uint8_t* at = p - 1;
+		if (p+1 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		uint_fast64_t x = *p++;
+		if (x > 127) {
+			x &= 127;
+			for (int shift = 7; ; shift += 7) {
+				uint_fast64_t b = *p++;
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				if (b <= 127) {
+					x |= b << shift;
+					break;
+				}
+				x |= (b & 127) << shift;
+			}
+		}
+		o->u64 = x;
+		if (fault && (!x || x >= (uint_fast64_t) 1 << 49 || (size_t) (p - at - 1) != colfer_varint_size(x))) {
+			*fault = at;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
+	} else if (header == (2 | 128)) {
+		if (p+8 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		uint_fast64_t x = *p++;
+		x <<= 56;
+		x |= (uint_fast64_t) *p++ << 48;
+		x |= (uint_fast64_t) *p++ << 40;
+		x |= (uint_fast64_t) *p++ << 32;
+		x |= (uint_fast64_t) *p++ << 24;
+		x |= (uint_fast64_t) *p++ << 16;
+		x |= (uint_fast64_t) *p++ << 8;
+		x |= (uint_fast64_t) *p++;
+		o->u64 = x;
+		if (fault && (x < (uint_fast64_t) 1 << 49)) {
+			*fault = p - 9;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
+	}
+
+	if ((header & 127) == 3) {
+		const uint8_t* at = p - 1;
+		if (p+1 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		uint_fast32_t x = *p++;
+		if (x > 127) {
+			x &= 127;
+			for (int shift = 7; shift < 35; shift += 7) {
+				uint_fast32_t b = *p++;
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				if (b <= 127) {
+					x |= b << shift;
+					break;
+				}
+				x |= (b & 127) << shift;
+			}
+		}
+		if (fault && (!x || (size_t) (p - at - 1) != colfer_varint_size(x) || (p - at - 1 == 5 && p[-1] > 15) || x > (header & 128 ? (uint_fast32_t) 1 << 31 : (uint_fast32_t) INT32_MAX))) {
+			*fault = at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (header & 128) x = ~x + 1;
+		o->i32 = x;
+		header = *p++;
+	}
+
+	if ((header & 127) == 4) {
+		const uint8_t* at = p - 1;
+		if (p+1 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		uint_fast64_t x = *p++;
+		if (x > 127) {
+			x &= 127;
+			for (int shift = 7; ; shift += 7) {
+				uint_fast64_t b = *p++;
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				if (b <= 127 || shift == 56) {
+					x |= b << shift;
+					break;
+				}
+				x |= (b & 127) << shift;
+			}
+		}
+		if (fault && (!x || (size_t) (p - at - 1) != colfer_varint_size(x) || x > (header & 128 ? (uint_fast64_t) 1 << 63 : (uint_fast64_t) INT64_MAX))) {
+			*fault = at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (header & 128) x = ~x + 1;
+		o->i64 = x;
+		header = *p++;
+	}
+
+	if (header == 5) {
+		if (p+4 >= end) {
+			errno = enderr;
+			return 0;
+		}
+#ifdef COLFER_ENDIAN
+		memcpy(&o->f32, p, 4);
+		p += 4;
+#else
+		uint_fast32_t x = *p++;
+		x <<= 24;
+		x |= (uint_fast32_t) *p++ << 16;
+		x |= (uint_fast32_t) *p++ << 8;
+		x |= (uint_fast32_t) *p++;
+		memcpy(&o->f32, &x, 4);
+#endif
+		if (fault && (o->f32 == 0)) {
+			*fault = p - 5;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
+	}
+
+	if (header == 6) {
+		if (p+8 >= end) {
+			errno = enderr;
+			return 0;
+		}
+#ifdef COLFER_ENDIAN
+		memcpy(&o->f64, p, 8);
+		p += 8;
+#else
+		uint_fast64_t x = *p++;
 		x <<= 56;
 		x |= (uint_fast64_t) *p++ << 48;
 		x |= (uint_fast64_t) *p++ << 40;
@@ -735,131 +1294,1213 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 		x |= (uint_fast64_t) *p++;
 		memcpy(&o->f64, &x, 8);
 #endif
-		header = *p++;
+		if (fault && (o->f64 == 0)) {
+			*fault = p - 9;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
+	}
+
+	if ((header & 127) == 7) {
+		if (header & 128) {
+			if (p+12 >= end) {
+				errno = enderr;
+				return 0;
+			}
+			uint_fast64_t x = *p++;
+			x <<= 56;
+			x |= (uint_fast64_t) *p++ << 48;
+			x |= (uint_fast64_t) *p++ << 40;
+			x |= (uint_fast64_t) *p++ << 32;
+			x |= (uint_fast64_t) *p++ << 24;
+			x |= (uint_fast64_t) *p++ << 16;
+			x |= (uint_fast64_t) *p++ << 8;
+			x |= (uint_fast64_t) *p++;
+			o->t.sec = x;
+		} else {
+			if (p+8 >= end) {
+				errno = enderr;
+				return 0;
+			}
+			uint_fast32_t x = *p++;
+			x <<= 24;
+			x |= (uint_fast32_t) *p++ << 16;
+			x |= (uint_fast32_t) *p++ << 8;
+			x |= (uint_fast32_t) *p++;
+			o->t.sec = x;
+		}
+		uint_fast32_t x = *p++;
+		x <<= 24;
+		x |= (uint_fast32_t) *p++ << 16;
+		x |= (uint_fast32_t) *p++ << 8;
+		x |= (uint_fast32_t) *p++;
+		o->t.nanos = x;
+		if (fault) {
+			int wide = header & 128;
+			if (x >= 1000000000) {
+				*fault = p - 4;
+				errno = EILSEQ;
+				return 0;
+			}
+			if (wide ? (uint_fast64_t) o->t.sec < (uint_fast64_t) 1 << 32 : !o->t.sec && !x) {
+				*fault = p - (wide ? 13 : 9);
+				errno = EILSEQ;
+				return 0;
+			}
+		}
+		header = *p++;
+	}
+
+	if (header == 8) {
+		const uint8_t* at = p - 1;
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		size_t n = *p++;
+		if (n > 127) {
+			n &= 127;
+			for (int shift = 7; shift < sizeof(size_t) * CHAR_BIT; shift += 7) {
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				size_t c = *p++;
+				if (c <= 127) {
+					n |= c << shift;
+					break;
+				}
+				n |= (c & 127) << shift;
+			}
+		}
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (p+n >= end) {
+			errno = enderr;
+			return 0;
+		}
+
+		if (fault) {
+			size_t valid = colfer_utf8_len(p, n);
+			if (valid < n) {
+				*fault = p + valid;
+				errno = EILSEQ;
+				return 0;
+			}
+		}
+
+		if (!colfer_charge(budget, n)) return 0;
+		void* a = malloc(n);
+		memcpy(a, p, n);
+		p += n;
+		o->s.len = n;
+		o->s.utf8 = (char*) a;
+		header = *p++;
+	}
+
+	if (header == 9) {
+		const uint8_t* at = p - 1;
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		size_t n = *p++;
+		if (n > 127) {
+			n &= 127;
+			for (int shift = 7; ; shift += 7) {
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				size_t c = *p++;
+				if (c <= 127) {
+					n |= c << shift;
+					break;
+				}
+				n |= (c & 127) << shift;
+			}
+		}
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (p+n >= end) {
+			errno = enderr;
+			return 0;
+		}
+
+		if (!colfer_charge(budget, n)) return 0;
+		void* a = malloc(n);
+		memcpy(a, p, n);
+		p += n;
+		o->a.len = n;
+		o->a.octets = (uint8_t*) a;
+		header = *p++;
+	}
+
+	if (header == 10) {
+		if (!colfer_charge(budget, sizeof(gen_o))) return 0;
+		o->o = calloc(1, sizeof(gen_o));
+		size_t read = gen_o_unmarshal_at(o->o, p, (size_t) (end - p), depth + 1, budget, fault);
+		if (!read) {
+			if (errno == EWOULDBLOCK) errno = enderr;
+			return read;
+		}
+		p += read;
+
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		header = *p++;
+	}
+
+	if (header == 11) {
+		const uint8_t* at = p - 1;
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		size_t n = *p++;
+		if (n > 127) {
+			n &= 127;
+			for (int shift = 7; ; shift += 7) {
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				size_t c = *p++;
+				if (c <= 127) {
+					n |= c << shift;
+					break;
+				}
+				n |= (c & 127) << shift;
+			}
+		}
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (n > colfer_list_max) {
+			errno = EFBIG;
+			return 0;
+		}
+		// each element takes at least one octet
+		if (p+n >= end) {
+			errno = enderr;
+			return 0;
+		}
+
+		if (!colfer_charge(budget, n * sizeof(gen_o))) return 0;
+		gen_o* a = calloc(n, sizeof(gen_o));
+		for (size_t i = 0; i < n; ++i) {
+			size_t read = gen_o_unmarshal_at(&a[i], p, (size_t) (end - p), depth + 1, budget, fault);
+			if (!read) {
+				if (errno == EWOULDBLOCK) errno = enderr;
+				return read;
+			}
+			p += read;
+		}
+		o->os.len = n;
+		o->os.list = a;
+
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		header = *p++;
+	}
+
+	if (header == 12) {
+		const uint8_t* at = p - 1;
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		size_t n = *p++;
+		if (n > 127) {
+			n &= 127;
+			for (int shift = 7; ; shift += 7) {
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				size_t c = *p++;
+				if (c <= 127) {
+					n |= c << shift;
+					break;
+				}
+				n |= (c & 127) << shift;
+			}
+		}
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (n > colfer_list_max) {
+			errno = EFBIG;
+			return 0;
+		}
+		// each element takes at least one octet
+		if (p+n >= end) {
+			errno = enderr;
+			return 0;
+		}
+
+		if (!colfer_charge(budget, n * sizeof(colfer_text))) return 0;
+		colfer_text* text = malloc(n * sizeof(colfer_text));
+		o->ss.len = n;
+		o->ss.list = text;
+		for (; n != 0; --n, ++text) {
+			if (p >= end) {
+				errno = enderr;
+				return 0;
+			}
+			const uint8_t* len_at = p;
+			size_t len = *p++;
+			if (len > 127) {
+				len &= 127;
+				for (int shift = 7; ; shift += 7) {
+					if (p >= end) {
+						errno = enderr;
+						return 0;
+					}
+					size_t c = *p++;
+					if (c <= 127) {
+						len |= c << shift;
+						break;
+					}
+					len |= (c & 127) << shift;
+				}
+			}
+			if (fault && (size_t) (p - len_at) != colfer_varint_size(len)) {
+				*fault = p - 1;
+				errno = EILSEQ;
+				return 0;
+			}
+			if (p+len >= end) {
+				errno = enderr;
+				return 0;
+			}
+
+			if (fault) {
+				size_t valid = colfer_utf8_len(p, len);
+				if (valid < len) {
+					*fault = p + valid;
+					errno = EILSEQ;
+					return 0;
+				}
+			}
+
+			if (!colfer_charge(budget, len)) return 0;
+			char* a = malloc(len);
+			memcpy(a, p, len);
+			p += len;
+			text->len = len;
+			text->utf8 = a;
+		}
+
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		header = *p++;
+	}
+
+	if (header == 13) {
+		const uint8_t* at = p - 1;
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		size_t n = *p++;
+		if (n > 127) {
+			n &= 127;
+			for (int shift = 7; ; shift += 7) {
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				size_t c = *p++;
+				if (c <= 127) {
+					n |= c << shift;
+					break;
+				}
+				n |= (c & 127) << shift;
+			}
+		}
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (n > colfer_list_max) {
+			errno = EFBIG;
+			return 0;
+		}
+		// each element takes at least one octet
+		if (p+n >= end) {
+			errno = enderr;
+			return 0;
+		}
+
+		if (!colfer_charge(budget, n * sizeof(colfer_binary))) return 0;
+		colfer_binary* binary = malloc(n * sizeof(colfer_binary));
+		o->as.len = n;
+		o->as.list = binary;
+		for (; n != 0; --n, ++binary) {
+			if (p >= end) {
+				errno = enderr;
+				return 0;
+			}
+			const uint8_t* len_at = p;
+			size_t len = *p++;
+			if (len > 127) {
+				len &= 127;
+				for (int shift = 7; ; shift += 7) {
+					if (p >= end) {
+						errno = enderr;
+						return 0;
+					}
+					size_t c = *p++;
+					if (c <= 127) {
+						len |= c << shift;
+						break;
+					}
+					len |= (c & 127) << shift;
+				}
+			}
+			if (fault && (size_t) (p - len_at) != colfer_varint_size(len)) {
+				*fault = p - 1;
+				errno = EILSEQ;
+				return 0;
+			}
+			if (p+len >= end) {
+				errno = enderr;
+				return 0;
+			}
+
+			if (!colfer_charge(budget, len)) return 0;
+			uint8_t* a = malloc(len);
+			memcpy(a, p, len);
+			p += len;
+			binary->len = len;
+			binary->octets = a;
+		}
+
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		header = *p++;
+	}
+
+	if (header == 14) {
+		if (p+1 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		o->u8 = *p++;
+		if (fault && (!o->u8)) {
+			*fault = p - 2;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
+	}
+
+	if (header == 15) {
+		if (p+2 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		uint_fast16_t x = *p++;
+		x <<= 8;
+		o->u16 = x | *p++;
+		if (fault && (o->u16 < 256)) {
+			*fault = p - 3;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
+	} else if (header == (15 | 128)) {
+		if (p+1 >= end) {
+			errno = enderr;
+			return 0;
+		}
+		o->u16 = *p++;
+		if (fault && (!o->u16)) {
+			*fault = p - 2;
+			errno = EILSEQ;
+			return 0;
+		}
+		header = *p++;
+	}
+
+	if (header == 16) {
+		const uint8_t* at = p - 1;
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		size_t n = *p++;
+		if (n > 127) {
+			n &= 127;
+			for (int shift = 7; ; shift += 7) {
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				size_t c = *p++;
+				if (c <= 127) {
+					n |= c << shift;
+					break;
+				}
+				n |= (c & 127) << shift;
+			}
+		}
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (n > colfer_list_max) {
+			errno = EFBIG;
+			return 0;
+		}
+		if (p+n*4 >= end) {
+			errno = enderr;
+			return 0;
+		}
+
+		if (!colfer_charge(budget, n * 4)) return 0;
+		float* fp = malloc(n * 4);
+		o->f32s.list = fp;
+		o->f32s.len = n;
+#ifdef COLFER_ENDIAN
+		memcpy(fp, p, n * 4);
+		p += n * 4;
+#else
+		for (; n != 0; --n, ++fp) {
+			uint_fast32_t x = *p++;
+			x <<= 24;
+			x |= (uint_fast32_t) *p++ << 16;
+			x |= (uint_fast32_t) *p++ << 8;
+			x |= (uint_fast32_t) *p++;
+			memcpy(fp, &x, 4);
+		}
+#endif
+		header = *p++;
+	}
+
+	if (header == 17) {
+		const uint8_t* at = p - 1;
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		size_t n = *p++;
+		if (n > 127) {
+			n &= 127;
+			for (int shift = 7; ; shift += 7) {
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				size_t c = *p++;
+				if (c <= 127) {
+					n |= c << shift;
+					break;
+				}
+				n |= (c & 127) << shift;
+			}
+		}
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (n > colfer_list_max) {
+			errno = EFBIG;
+			return 0;
+		}
+		if (p+n*8 >= end) {
+			errno = enderr;
+			return 0;
+		}
+
+		if (!colfer_charge(budget, n * 8)) return 0;
+		double* fp = malloc(n * 8);
+		o->f64s.len = n;
+		o->f64s.list = fp;
+#ifdef COLFER_ENDIAN
+		memcpy(fp, p, n * 8);
+		p += n * 8;
+#else
+		for (; n != 0; --n, ++fp) {
+			uint_fast64_t x = *p++;
+			x <<= 56;
+			x |= (uint_fast64_t) *p++ << 48;
+			x |= (uint_fast64_t) *p++ << 40;
+			x |= (uint_fast64_t) *p++ << 32;
+			x |= (uint_fast64_t) *p++ << 24;
+			x |= (uint_fast64_t) *p++ << 16;
+			x |= (uint_fast64_t) *p++ << 8;
+			x |= (uint_fast64_t) *p++;
+			memcpy(fp, &x, 8);
+		}
+#endif
+		header = *p++;
+	}
+
+	if (header != 127) {
+		if (fault) *fault = p - 1;
+		errno = EILSEQ;
+		return 0;
+	}
+
+	return (size_t) (p - (const uint8_t*) data);
+}
+
+const char* gen_o_validate(const gen_o* o) {
+	if (o->o) {
+		const char* err = gen_o_validate(o->o);
+		if (err) return err;
+	}
+	for (size_t i = 0; i < o->os.len; ++i) {
+		const char* err = gen_o_validate(&o->os.list[i]);
+		if (err) return err;
+	}
+	return NULL;
+}
+
+size_t gen_e_marshal_len(const gen_e* o) {
+	size_t l = 1;
+
+	{
+		size_t n = o->m.len;
+		if (n > colfer_size_max) {
+			errno = EFBIG;
+			return 0;
+		}
+		if (n) for (l += 2 + n; n > 127; n >>= 7, ++l);
+	}
+
+	if (l > colfer_size_max) {
+		errno = EFBIG;
+		return 0;
+	}
+	return l;
+}
+
+size_t gen_e_marshal(const gen_e* o, void* buf) {
+	// octet pointer navigation
+	uint8_t* p = buf;
+
+	{
+		size_t n = o->m.len;
+		if (n) {
+			*p++ = 0;
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			memcpy(p, o->m.octets, n);
+			p += n;
+		}
+	}
+
+	*p++ = 127;
+
+	return p - (uint8_t*) buf;
+}
+
+size_t gen_e_hash(const gen_e* o, colfer_hash_func update, void* ctx) {
+	size_t n = gen_e_marshal_len(o);
+	if (n) gen_e_hash_at(o, update, ctx);
+	return n;
+}
+
+// gen_e_hash_at is gen_e_hash without the limit checks.
+static void gen_e_hash_at(const gen_e* o, colfer_hash_func update, void* ctx) {
+	// pending octets
+	uint8_t buf[32];
+	uint8_t* p = buf;
+
+	{
+		size_t n = o->m.len;
+		if (n) {
+			if (p - buf > 8) {
+				update(ctx, buf, p - buf);
+				p = buf;
+			}
+			*p++ = 0;
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			update(ctx, buf, p - buf);
+			p = buf;
+			update(ctx, o->m.octets, n);
+		}
+	}
+
+	*p++ = 127;
+	update(ctx, buf, p - buf);
+}
+
+size_t gen_e_unmarshal(gen_e* o, const void* data, size_t datalen) {
+	return gen_e_unmarshal_at(o, data, datalen, 1, NULL, NULL);
+}
+
+size_t gen_e_unmarshal_budget(gen_e* o, const void* data, size_t datalen, size_t* budget) {
+	return gen_e_unmarshal_at(o, data, datalen, 1, budget, NULL);
+}
+
+size_t gen_e_unmarshal_strict(gen_e* o, const void* data, size_t datalen, size_t* offset) {
+	const uint8_t* at = data;
+	size_t n = gen_e_unmarshal_at(o, data, datalen, 1, NULL, &at);
+	if (!n && errno == EILSEQ) *offset = (size_t) (at - (const uint8_t*) data);
+	return n;
+}
+
+// gen_e_unmarshal_at is gen_e_unmarshal with depth as the
+// number of data structure levels, including o. Allocation is charged to budget
+// when not NULL. Strict mode applies when fault is not NULL, which then receives
+// the location of any EILSEQ.
+static size_t gen_e_unmarshal_at(gen_e* o, const void* data, size_t datalen, size_t depth, size_t* budget, const uint8_t** fault) {
+	if (depth > colfer_depth_max) {
+		errno = EFBIG;
+		return 0;
+	}
+
+	// octet pointer navigation
+	const uint8_t* p = data;
+	const uint8_t* end;
+	int enderr;
+	if (datalen < colfer_size_max) {
+		end = p + datalen;
+		enderr = EWOULDBLOCK;
+	} else {
+		end = p + colfer_size_max;
+		enderr = EFBIG;
+	}
+
+	if (p >= end) {
+		errno = enderr;
+		return 0;
+	}
+	uint_fast8_t header = *p++;
+
+	if (header == 0) {
+		const uint8_t* at = p - 1;
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		size_t n = *p++;
+		if (n > 127) {
+			n &= 127;
+			for (int shift = 7; ; shift += 7) {
+				if (p >= end) {
+					errno = enderr;
+					return 0;
+				}
+				size_t c = *p++;
+				if (c <= 127) {
+					n |= c << shift;
+					break;
+				}
+				n |= (c & 127) << shift;
+			}
+		}
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (p+n >= end) {
+			errno = enderr;
+			return 0;
+		}
+		if (n && p[n-1] != 127) {
+			if (fault) *fault = p + n - 1;
+			errno = EILSEQ;
+			return 0;
+		}
+
+		if (!colfer_charge(budget, n)) return 0;
+		void* a = malloc(n);
+		memcpy(a, p, n);
+		p += n;
+		o->m.len = n;
+		o->m.octets = (uint8_t*) a;
+		header = *p++;
+	}
+
+	if (header != 127) {
+		if (fault) *fault = p - 1;
+		errno = EILSEQ;
+		return 0;
+	}
+
+	return (size_t) (p - (const uint8_t*) data);
+}
+
+const char* gen_e_validate(const gen_e* o) {
+	return NULL;
+}
+
+size_t gen_r_marshal_len(const gen_r* o) {
+	size_t l = 1;
+
+	if (o->par) l += 2;
+
+	if (o->lat != 0.0) l += 9;
+
+	{
+		uint_fast64_t x = o->big;
+		if (x) {
+			if (x >= (uint_fast64_t) 1 << 49) l += 9;
+			else for (l += 2; x > 127; x >>= 7, ++l);
+		}
+	}
+
+	{
+		size_t n = o->name.len;
+		if (n > colfer_size_max) {
+			errno = EFBIG;
+			return 0;
+		}
+		if (n) for (l += 2 + n; n > 127; n >>= 7, ++l);
+	}
+
+	{
+		size_t n = o->tags.len;
+		if (n) {
+			if (n > colfer_list_max) {
+				errno = EFBIG;
+				return 0;
+			}
+			colfer_text* a = o->tags.list;
+			for (size_t i = 0; i < n; ++i) {
+				size_t len = a[i].len;
+				if (len > colfer_size_max) {
+					errno = EFBIG;
+					return 0;
+				}
+				for (l += len + 1; len > 127; len >>= 7, ++l);
+			}
+			for (l += 2; n > 127; n >>= 7, ++l);
+			if (l > colfer_size_max) {
+				errno = EFBIG;
+				return 0;
+			}
+		}
+	}
+
+	{
+		if (o->next) l += 1 + gen_r_marshal_len(o->next);
+	}
+
+	if (l > colfer_size_max) {
+		errno = EFBIG;
+		return 0;
+	}
+	return l;
+}
+
+size_t gen_r_marshal(const gen_r* o, void* buf) {
+	// octet pointer navigation
+	uint8_t* p = buf;
+
+	if (o->par) {
+		*p++ = 0;
+
+		*p++ = o->par;
+	}
+
+	if (o->lat != 0.0) {
+		*p++ = 1;
+
+#ifdef COLFER_ENDIAN
+		memcpy(p, &o->lat, 8);
+		p += 8;
+#else
+		uint_fast64_t x;
+		memcpy(&x, &o->lat, 8);
+		*p++ = x >> 56;
+		*p++ = x >> 48;
+		*p++ = x >> 40;
+		*p++ = x >> 32;
+		*p++ = x >> 24;
+		*p++ = x >> 16;
+		*p++ = x >> 8;
+		*p++ = x;
+#endif
 	}
 
-	if ((header & 127) == 7) {
-		if (header & 128) {
-			if (p+12 >= end) {
-				errno = enderr;
-				return 0;
+	{
+		uint_fast64_t x = o->big;
+		if (x) {
+			if (x < (uint_fast64_t) 1 << 49) {
+				*p++ = 2;
+				for (; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
+			} else {
+				*p++ = 2 | 128;
+#ifdef COLFER_ENDIAN
+				memcpy(p, &o->big, 8);
+				p += 8;
+#else
+				*p++ = x >> 56;
+				*p++ = x >> 48;
+				*p++ = x >> 40;
+				*p++ = x >> 32;
+				*p++ = x >> 24;
+				*p++ = x >> 16;
+				*p++ = x >> 8;
+				*p++ = x;
+#endif
 			}
-			uint_fast64_t x = *p++;
-			x <<= 56;
-			x |= (uint_fast64_t) *p++ << 48;
-			x |= (uint_fast64_t) *p++ << 40;
-			x |= (uint_fast64_t) *p++ << 32;
-			x |= (uint_fast64_t) *p++ << 24;
-			x |= (uint_fast64_t) *p++ << 16;
-			x |= (uint_fast64_t) *p++ << 8;
-			x |= (uint_fast64_t) *p++;
-			o->t.sec = x;
-		} else {
-			if (p+8 >= end) {
-				errno = enderr;
-				return 0;
+		}
+	}
+
+	{
+		size_t n = o->name.len;
+		if (n) {
+			*p++ = 3;
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			memcpy(p, o->name.utf8, n);
+			p += n;
+		}
+	}
+
+	{
+		size_t count = o->tags.len;
+		if (count) {
+			*p++ = 4;
+
+			uint_fast32_t x = count;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			colfer_text* text = o->tags.list;
+			do {
+				size_t n = text->len;
+				for (x = n; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
+
+				memcpy(p, text->utf8, n);
+				p += n;
+
+				++text;
+			} while (--count != 0);
+		}
+	}
+
+	{
+		if (o->next) {
+			*p++ = 5;
+
+			p += gen_r_marshal(o->next, p);
+		}
+	}
+
+	*p++ = 127;
+
+	return p - (uint8_t*) buf;
+}
+
+size_t gen_r_hash(const gen_r* o, colfer_hash_func update, void* ctx) {
+	size_t n = gen_r_marshal_len(o);
+	if (n) gen_r_hash_at(o, update, ctx);
+	return n;
+}
+
+// gen_r_hash_at is gen_r_hash without the limit checks.
+static void gen_r_hash_at(const gen_r* o, colfer_hash_func update, void* ctx) {
+	// pending octets
+	uint8_t buf[32];
+	uint8_t* p = buf;
+
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
+	}
+	if (o->par) {
+		*p++ = 0;
+
+		*p++ = o->par;
+	}
+
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
+	}
+	if (o->lat != 0.0) {
+		*p++ = 1;
+
+#ifdef COLFER_ENDIAN
+		memcpy(p, &o->lat, 8);
+		p += 8;
+#else
+		uint_fast64_t x;
+		memcpy(&x, &o->lat, 8);
+		*p++ = x >> 56;
+		*p++ = x >> 48;
+		*p++ = x >> 40;
+		*p++ = x >> 32;
+		*p++ = x >> 24;
+		*p++ = x >> 16;
+		*p++ = x >> 8;
+		*p++ = x;
+#endif
+	}
+
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
+	}
+	{
+		uint_fast64_t x = o->big;
+		if (x) {
+			if (x < (uint_fast64_t) 1 << 49) {
+				*p++ = 2;
+				for (; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
+			} else {
+				*p++ = 2 | 128;
+#ifdef COLFER_ENDIAN
+				memcpy(p, &o->big, 8);
+				p += 8;
+#else
+				*p++ = x >> 56;
+				*p++ = x >> 48;
+				*p++ = x >> 40;
+				*p++ = x >> 32;
+				*p++ = x >> 24;
+				*p++ = x >> 16;
+				*p++ = x >> 8;
+				*p++ = x;
+#endif
 			}
-			uint_fast32_t x = *p++;
-			x <<= 24;
-			x |= (uint_fast32_t) *p++ << 16;
-			x |= (uint_fast32_t) *p++ << 8;
-			x |= (uint_fast32_t) *p++;
-			o->t.sec = x;
 		}
-		uint_fast32_t x = *p++;
-		x <<= 24;
-		x |= (uint_fast32_t) *p++ << 16;
-		x |= (uint_fast32_t) *p++ << 8;
-		x |= (uint_fast32_t) *p++;
-		o->t.nanos = x;
-		header = *p++;
 	}
 
-	if (header == 8) {
-		if (p >= end) {
+	{
+		size_t n = o->name.len;
+		if (n) {
+			if (p - buf > 8) {
+				update(ctx, buf, p - buf);
+				p = buf;
+			}
+			*p++ = 3;
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			update(ctx, buf, p - buf);
+			p = buf;
+			update(ctx, o->name.utf8, n);
+		}
+	}
+
+	{
+		size_t count = o->tags.len;
+		if (count) {
+			if (p - buf > 8) {
+				update(ctx, buf, p - buf);
+				p = buf;
+			}
+			*p++ = 4;
+
+			uint_fast32_t x = count;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			colfer_text* v = o->tags.list;
+			do {
+				size_t n = v->len;
+				for (x = n; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
+
+				update(ctx, buf, p - buf);
+				p = buf;
+				update(ctx, v->utf8, n);
+
+				++v;
+			} while (--count != 0);
+		}
+	}
+
+	{
+		if (o->next) {
+			*p++ = 5;
+			update(ctx, buf, p - buf);
+			p = buf;
+
+			gen_r_hash_at(o->next, update, ctx);
+		}
+	}
+
+	*p++ = 127;
+	update(ctx, buf, p - buf);
+}
+
+size_t gen_r_unmarshal(gen_r* o, const void* data, size_t datalen) {
+	return gen_r_unmarshal_at(o, data, datalen, 1, NULL, NULL);
+}
+
+size_t gen_r_unmarshal_budget(gen_r* o, const void* data, size_t datalen, size_t* budget) {
+	return gen_r_unmarshal_at(o, data, datalen, 1, budget, NULL);
+}
+
+size_t gen_r_unmarshal_strict(gen_r* o, const void* data, size_t datalen, size_t* offset) {
+	const uint8_t* at = data;
+	size_t n = gen_r_unmarshal_at(o, data, datalen, 1, NULL, &at);
+	if (!n && errno == EILSEQ) *offset = (size_t) (at - (const uint8_t*) data);
+	return n;
+}
+
+// gen_r_unmarshal_at is gen_r_unmarshal with depth as the
+// number of data structure levels, including o. Allocation is charged to budget
+// when not NULL. Strict mode applies when fault is not NULL, which then receives
+// the location of any EILSEQ.
+static size_t gen_r_unmarshal_at(gen_r* o, const void* data, size_t datalen, size_t depth, size_t* budget, const uint8_t** fault) {
+	if (depth > colfer_depth_max) {
+		errno = EFBIG;
+		return 0;
+	}
+
+	// octet pointer navigation
+	const uint8_t* p = data;
+	const uint8_t* end;
+	int enderr;
+	if (datalen < colfer_size_max) {
+		end = p + datalen;
+		enderr = EWOULDBLOCK;
+	} else {
+		end = p + colfer_size_max;
+		enderr = EFBIG;
+	}
+
+	if (p >= end) {
+		errno = enderr;
+		return 0;
+	}
+	uint_fast8_t header = *p++;
+
+	if (header == 0) {
+		if (p+1 >= end) {
 			errno = enderr;
 			return 0;
 		}
-		size_t n = *p++;
-		if (n > 127) {
-			n &= 127;
-			for (int shift = 7; shift < sizeof(size_t) * CHAR_BIT; shift += 7) {
-				if (p >= end) {
-					errno = enderr;
-					return 0;
-				}
-				size_t c = *p++;
-				if (c <= 127) {
-					n |= c << shift;
-					break;
-				}
-				n |= (c & 127) << shift;
-			}
+		o->par = *p++;
+		if (fault && (!o->par)) {
+			*fault = p - 2;
+			errno = EILSEQ;
+			return 0;
 		}
-		if (p+n >= end) {
+		header = *p++;
+	}
+
+	if (header == 1) {
+		if (p+8 >= end) {
 			errno = enderr;
 			return 0;
 		}
-
-		void* a = malloc(n);
-		memcpy(a, p, n);
-		p += n;
-		o->s.len = n;
-		o->s.utf8 = (char*) a;
+#ifdef COLFER_ENDIAN
+		memcpy(&o->lat, p, 8);
+		p += 8;
+#else
+		uint_fast64_t x = *p++;
+		x <<= 56;
+		x |= (uint_fast64_t) *p++ << 48;
+		x |= (uint_fast64_t) *p++ << 40;
+		x |= (uint_fast64_t) *p++ << 32;
+		x |= (uint_fast64_t) *p++ << 24;
+		x |= (uint_fast64_t) *p++ << 16;
+		x |= (uint_fast64_t) *p++ << 8;
+		x |= (uint_fast64_t) *p++;
+		memcpy(&o->lat, &x, 8);
+#endif
+		if (fault && (o->lat == 0)) {
+			*fault = p - 9;
+			errno = EILSEQ;
+			return 0;
+		}
 		header = *p++;
 	}
 
-	if (header == 9) {
-		if (p >= end) {
+	if (header == 2) {
+		const uint8_t* at = p - 1;
+		if (p+1 >= end) {
 			errno = enderr;
 			return 0;
 		}
-		size_t n = *p++;
-		if (n > 127) {
-			n &= 127;
+		uint_fast64_t x = *p++;
+		if (x > 127) {
+			x &= 127;
 			for (int shift = 7; ; shift += 7) {
+				uint_fast64_t b = *p++;
 				if (p >= end) {
 					errno = enderr;
 					return 0;
 				}
-				size_t c = *p++;
-				if (c <= 127) {
-					n |= c << shift;
+				if (b <= 127) {
+					x |= b << shift;
 					break;
 				}
-				n |= (c & 127) << shift;
+				x |= (b & 127) << shift;
 			}
 		}
-		if (p+n >= end) {
-			errno = enderr;
+		o->big = x;
+		if (fault && (!x || x >= (uint_fast64_t) 1 << 49 || (size_t) (p - at - 1) != colfer_varint_size(x))) {
+			*fault = at;
+			errno = EILSEQ;
 			return 0;
 		}
-
-		void* a = malloc(n);
-		memcpy(a, p, n);
-		p += n;
-		o->a.len = n;
-		o->a.octets = (uint8_t*) a;
 		header = *p++;
-	}
-
-	if (header == 10) {
-		o->o = calloc(1, sizeof(gen_o));
-		size_t read = gen_o_unmarshal(o->o, p, (size_t) (end - p));
-		if (!read) {
-			if (errno == EWOULDBLOCK) errno = enderr;
-			return read;
-		}
-		p += read;
-
-		if (p >= end) {
+	} else if (header == (2 | 128)) {
+		if (p+8 >= end) {
 			errno = enderr;
 			return 0;
 		}
+		uint_fast64_t x = *p++;
+		x <<= 56;
+		x |= (uint_fast64_t) *p++ << 48;
+		x |= (uint_fast64_t) *p++ << 40;
+		x |= (uint_fast64_t) *p++ << 32;
+		x |= (uint_fast64_t) *p++ << 24;
+		x |= (uint_fast64_t) *p++ << 16;
+		x |= (uint_fast64_t) *p++ << 8;
+		x |= (uint_fast64_t) *p++;
+		o->big = x;
+		if (fault && (x < (uint_fast64_t) 1 << 49)) {
+			*fault = p - 9;
+			errno = EILSEQ;
+			return 0;
+		}
 		header = *p++;
 	}
 
-	if (header == 11) {
+	if (header == 3) {
+		const uint8_t* at = p - 1;
 		if (p >= end) {
 			errno = enderr;
 			return 0;
@@ -867,7 +2508,7 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 		size_t n = *p++;
 		if (n > 127) {
 			n &= 127;
-			for (int shift = 7; ; shift += 7) {
+			for (int shift = 7; shift < sizeof(size_t) * CHAR_BIT; shift += 7) {
 				if (p >= end) {
 					errno = enderr;
 					return 0;
@@ -880,31 +2521,36 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 				n |= (c & 127) << shift;
 			}
 		}
-		if (n > colfer_list_max) {
-			errno = EFBIG;
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (p+n >= end) {
+			errno = enderr;
 			return 0;
 		}
 
-		gen_o* a = calloc(n, sizeof(gen_o));
-		for (size_t i = 0; i < n; ++i) {
-			size_t read = gen_o_unmarshal(&a[i], p, (size_t) (end - p));
-			if (!read) {
-				if (errno == EWOULDBLOCK) errno = enderr;
-				return read;
+		if (fault) {
+			size_t valid = colfer_utf8_len(p, n);
+			if (valid < n) {
+				*fault = p + valid;
+				errno = EILSEQ;
+				return 0;
 			}
-			p += read;
 		}
-		o->os.len = n;
-		o->os.list = a;
 
-		if (p >= end) {
-			errno = enderr;
-			return 0;
-		}
+		if (!colfer_charge(budget, n)) return 0;
+		void* a = malloc(n);
+		memcpy(a, p, n);
+		p += n;
+		o->name.len = n;
+		o->name.utf8 = (char*) a;
 		header = *p++;
 	}
 
-	if (header == 12) {
+	if (header == 4) {
+		const uint8_t* at = p - 1;
 		if (p >= end) {
 			errno = enderr;
 			return 0;
@@ -925,19 +2571,31 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 				n |= (c & 127) << shift;
 			}
 		}
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
 		if (n > colfer_list_max) {
 			errno = EFBIG;
 			return 0;
 		}
+		// each element takes at least one octet
+		if (p+n >= end) {
+			errno = enderr;
+			return 0;
+		}
 
+		if (!colfer_charge(budget, n * sizeof(colfer_text))) return 0;
 		colfer_text* text = malloc(n * sizeof(colfer_text));
-		o->ss.len = n;
-		o->ss.list = text;
+		o->tags.len = n;
+		o->tags.list = text;
 		for (; n != 0; --n, ++text) {
 			if (p >= end) {
 				errno = enderr;
 				return 0;
 			}
+			const uint8_t* len_at = p;
 			size_t len = *p++;
 			if (len > 127) {
 				len &= 127;
@@ -954,11 +2612,26 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 					len |= (c & 127) << shift;
 				}
 			}
+			if (fault && (size_t) (p - len_at) != colfer_varint_size(len)) {
+				*fault = p - 1;
+				errno = EILSEQ;
+				return 0;
+			}
 			if (p+len >= end) {
 				errno = enderr;
 				return 0;
 			}
 
+			if (fault) {
+				size_t valid = colfer_utf8_len(p, len);
+				if (valid < len) {
+					*fault = p + valid;
+					errno = EILSEQ;
+					return 0;
+				}
+			}
+
+			if (!colfer_charge(budget, len)) return 0;
 			char* a = malloc(len);
 			memcpy(a, p, len);
 			p += len;
@@ -973,7 +2646,202 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 		header = *p++;
 	}
 
-	if (header == 13) {
+	if (header == 5) {
+		if (!colfer_charge(budget, sizeof(gen_r))) return 0;
+		o->next = calloc(1, sizeof(gen_r));
+		size_t read = gen_r_unmarshal_at(o->next, p, (size_t) (end - p), depth + 1, budget, fault);
+		if (!read) {
+			if (errno == EWOULDBLOCK) errno = enderr;
+			return read;
+		}
+		p += read;
+
+		if (p >= end) {
+			errno = enderr;
+			return 0;
+		}
+		header = *p++;
+	}
+
+	if (header != 127) {
+		if (fault) *fault = p - 1;
+		errno = EILSEQ;
+		return 0;
+	}
+
+	return (size_t) (p - (const uint8_t*) data);
+}
+
+const char* gen_r_validate(const gen_r* o) {
+	if (o->par < 3)
+		return "colfer: field gen.r.par breaks rule min 3";
+	if (o->par > 5)
+		return "colfer: field gen.r.par breaks rule max 5";
+	if (!(o->lat >= -90))
+		return "colfer: field gen.r.lat breaks rule min -90";
+	if (!(o->lat <= 90))
+		return "colfer: field gen.r.lat breaks rule max 90";
+	if (o->big > UINT64_C(10000000000000000000))
+		return "colfer: field gen.r.big breaks rule max 10000000000000000000";
+	if (!o->name.len)
+		return "colfer: field gen.r.name breaks rule required";
+	if (o->name.len < 2)
+		return "colfer: field gen.r.name breaks rule minlen 2";
+	if (o->name.len > 8)
+		return "colfer: field gen.r.name breaks rule maxlen 8";
+	if (o->tags.len > 2)
+		return "colfer: field gen.r.tags breaks rule maxlen 2";
+	if (o->next) {
+		const char* err = gen_r_validate(o->next);
+		if (err) return err;
+	}
+	return NULL;
+}
+
+size_t gen_old_marshal_len(const gen_old* o) {
+	size_t l = 1;
+
+	{
+		size_t n = o->pin.len;
+		if (n > colfer_size_max) {
+			errno = EFBIG;
+			return 0;
+		}
+		if (n) for (l += 2 + n; n > 127; n >>= 7, ++l);
+	}
+
+	{
+		if (o->ref) l += 1 + gen_old_marshal_len(o->ref);
+	}
+
+	if (l > colfer_size_max) {
+		errno = EFBIG;
+		return 0;
+	}
+	return l;
+}
+
+size_t gen_old_marshal(const gen_old* o, void* buf) {
+	// octet pointer navigation
+	uint8_t* p = buf;
+
+	{
+		size_t n = o->pin.len;
+		if (n) {
+			*p++ = 0;
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			memcpy(p, o->pin.utf8, n);
+			p += n;
+		}
+	}
+
+	{
+		if (o->ref) {
+			*p++ = 1;
+
+			p += gen_old_marshal(o->ref, p);
+		}
+	}
+
+	*p++ = 127;
+
+	return p - (uint8_t*) buf;
+}
+
+size_t gen_old_hash(const gen_old* o, colfer_hash_func update, void* ctx) {
+	size_t n = gen_old_marshal_len(o);
+	if (n) gen_old_hash_at(o, update, ctx);
+	return n;
+}
+
+// gen_old_hash_at is gen_old_hash without the limit checks.
+static void gen_old_hash_at(const gen_old* o, colfer_hash_func update, void* ctx) {
+	// pending octets
+	uint8_t buf[32];
+	uint8_t* p = buf;
+
+	{
+		size_t n = o->pin.len;
+		if (n) {
+			if (p - buf > 8) {
+				update(ctx, buf, p - buf);
+				p = buf;
+			}
+			*p++ = 0;
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			update(ctx, buf, p - buf);
+			p = buf;
+			update(ctx, o->pin.utf8, n);
+		}
+	}
+
+	{
+		if (o->ref) {
+			*p++ = 1;
+			update(ctx, buf, p - buf);
+			p = buf;
+
+			gen_old_hash_at(o->ref, update, ctx);
+		}
+	}
+
+	*p++ = 127;
+	update(ctx, buf, p - buf);
+}
+
+size_t gen_old_unmarshal(gen_old* o, const void* data, size_t datalen) {
+	return gen_old_unmarshal_at(o, data, datalen, 1, NULL, NULL);
+}
+
+size_t gen_old_unmarshal_budget(gen_old* o, const void* data, size_t datalen, size_t* budget) {
+	return gen_old_unmarshal_at(o, data, datalen, 1, budget, NULL);
+}
+
+size_t gen_old_unmarshal_strict(gen_old* o, const void* data, size_t datalen, size_t* offset) {
+	const uint8_t* at = data;
+	size_t n = gen_old_unmarshal_at(o, data, datalen, 1, NULL, &at);
+	if (!n && errno == EILSEQ) *offset = (size_t) (at - (const uint8_t*) data);
+	return n;
+}
+
+// gen_old_unmarshal_at is gen_old_unmarshal with depth as the
+// number of data structure levels, including o. Allocation is charged to budget
+// when not NULL. Strict mode applies when fault is not NULL, which then receives
+// the location of any EILSEQ.
+static size_t gen_old_unmarshal_at(gen_old* o, const void* data, size_t datalen, size_t depth, size_t* budget, const uint8_t** fault) {
+	if (depth > colfer_depth_max) {
+		errno = EFBIG;
+		return 0;
+	}
+
+	// octet pointer navigation
+	const uint8_t* p = data;
+	const uint8_t* end;
+	int enderr;
+	if (datalen < colfer_size_max) {
+		end = p + datalen;
+		enderr = EWOULDBLOCK;
+	} else {
+		end = p + colfer_size_max;
+		enderr = EFBIG;
+	}
+
+	if (p >= end) {
+		errno = enderr;
+		return 0;
+	}
+	uint_fast8_t header = *p++;
+
+	if (header == 0) {
+		const uint8_t* at = p - 1;
 		if (p >= end) {
 			errno = enderr;
 			return 0;
@@ -981,7 +2849,7 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 		size_t n = *p++;
 		if (n > 127) {
 			n &= 127;
-			for (int shift = 7; ; shift += 7) {
+			for (int shift = 7; shift < sizeof(size_t) * CHAR_BIT; shift += 7) {
 				if (p >= end) {
 					errno = enderr;
 					return 0;
@@ -991,49 +2859,46 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 					n |= c << shift;
 					break;
 				}
-				n |= (c & 127) << shift;
-			}
-		}
-		if (n > colfer_list_max) {
-			errno = EFBIG;
-			return 0;
-		}
-
-		colfer_binary* binary = malloc(n * sizeof(colfer_binary));
-		o->as.len = n;
-		o->as.list = binary;
-		for (; n != 0; --n, ++binary) {
-			if (p >= end) {
-				errno = enderr;
-				return 0;
-			}
-			size_t len = *p++;
-			if (len > 127) {
-				len &= 127;
-				for (int shift = 7; ; shift += 7) {
-					if (p >= end) {
-						errno = enderr;
-						return 0;
-					}
-					size_t c = *p++;
-					if (c <= 127) {
-						len |= c << shift;
-						break;
-					}
-					len |= (c & 127) << shift;
-				}
+				n |= (c & 127) << shift;
 			}
-			if (p+len >= end) {
-				errno = enderr;
+		}
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
+			return 0;
+		}
+		if (p+n >= end) {
+			errno = enderr;
+			return 0;
+		}
+
+		if (fault) {
+			size_t valid = colfer_utf8_len(p, n);
+			if (valid < n) {
+				*fault = p + valid;
+				errno = EILSEQ;
 				return 0;
 			}
+		}
 
-			uint8_t* a = malloc(len);
-			memcpy(a, p, len);
-			p += len;
-			binary->len = len;
-			binary->octets = a;
+		if (!colfer_charge(budget, n)) return 0;
+		void* a = malloc(n);
+		memcpy(a, p, n);
+		p += n;
+		o->pin.len = n;
+		o->pin.utf8 = (char*) a;
+		header = *p++;
+	}
+
+	if (header == 1) {
+		if (!colfer_charge(budget, sizeof(gen_old))) return 0;
+		o->ref = calloc(1, sizeof(gen_old));
+		size_t read = gen_old_unmarshal_at(o->ref, p, (size_t) (end - p), depth + 1, budget, fault);
+		if (!read) {
+			if (errno == EWOULDBLOCK) errno = enderr;
+			return read;
 		}
+		p += read;
 
 		if (p >= end) {
 			errno = enderr;
@@ -1042,83 +2907,249 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 		header = *p++;
 	}
 
-	if (header == 14) {
-		if (p+1 >= end) {
-			errno = enderr;
-			return 0;
+	if (header != 127) {
+		if (fault) *fault = p - 1;
+		errno = EILSEQ;
+		return 0;
+	}
+
+	return (size_t) (p - (const uint8_t*) data);
+}
+
+const char* gen_old_validate(const gen_old* o) {
+	if (o->ref) {
+		const char* err = gen_old_validate(o->ref);
+		if (err) return err;
+	}
+	return NULL;
+}
+
+size_t gen_renamed_marshal_len(const gen_renamed* o) {
+	size_t l = 1;
+
+	{
+		uint_fast32_t x = o->ident;
+		if (x) {
+			if (x >= (uint_fast32_t) 1 << 21) l += 5;
+			else for (l += 2; x > 127; x >>= 7, ++l);
 		}
-		o->u8 = *p++;
-		header = *p++;
 	}
 
-	if (header == 15) {
-		if (p+2 >= end) {
-			errno = enderr;
+	{
+		size_t n = o->class.len;
+		if (n > colfer_size_max) {
+			errno = EFBIG;
 			return 0;
 		}
-		uint_fast16_t x = *p++;
-		x <<= 8;
-		o->u16 = x | *p++;
-		header = *p++;
-	} else if (header == (15 | 128)) {
-		if (p+1 >= end) {
-			errno = enderr;
-			return 0;
+		if (n) for (l += 2 + n; n > 127; n >>= 7, ++l);
+	}
+
+	if (l > colfer_size_max) {
+		errno = EFBIG;
+		return 0;
+	}
+	return l;
+}
+
+size_t gen_renamed_marshal(const gen_renamed* o, void* buf) {
+	// octet pointer navigation
+	uint8_t* p = buf;
+
+	{
+		uint_fast32_t x = o->ident;
+		if (x) {
+			if (x < (uint_fast32_t) 1 << 21) {
+				*p++ = 0;
+				for (; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
+			} else {
+				*p++ = 0 | 128;
+#ifdef COLFER_ENDIAN
+				memcpy(p, &o->ident, 4);
+				p += 4;
+#else
+				*p++ = x >> 24;
+				*p++ = x >> 16;
+				*p++ = x >> 8;
+				*p++ = x;
+#endif
+			}
 		}
-		o->u16 = *p++;
-		header = *p++;
 	}
 
-	if (header == 16) {
-		if (p >= end) {
+	{
+		size_t n = o->class.len;
+		if (n) {
+			*p++ = 1;
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			memcpy(p, o->class.utf8, n);
+			p += n;
+		}
+	}
+
+	*p++ = 127;
+
+	return p - (uint8_t*) buf;
+}
+
+size_t gen_renamed_hash(const gen_renamed* o, colfer_hash_func update, void* ctx) {
+	size_t n = gen_renamed_marshal_len(o);
+	if (n) gen_renamed_hash_at(o, update, ctx);
+	return n;
+}
+
+// gen_renamed_hash_at is gen_renamed_hash without the limit checks.
+static void gen_renamed_hash_at(const gen_renamed* o, colfer_hash_func update, void* ctx) {
+	// pending octets
+	uint8_t buf[32];
+	uint8_t* p = buf;
+
+	if (p - buf > 19) {
+		update(ctx, buf, p - buf);
+		p = buf;
+	}
+	{
+		uint_fast32_t x = o->ident;
+		if (x) {
+			if (x < (uint_fast32_t) 1 << 21) {
+				*p++ = 0;
+				for (; x >= 128; x >>= 7) *p++ = x | 128;
+				*p++ = x;
+			} else {
+				*p++ = 0 | 128;
+#ifdef COLFER_ENDIAN
+				memcpy(p, &o->ident, 4);
+				p += 4;
+#else
+				*p++ = x >> 24;
+				*p++ = x >> 16;
+				*p++ = x >> 8;
+				*p++ = x;
+#endif
+			}
+		}
+	}
+
+	{
+		size_t n = o->class.len;
+		if (n) {
+			if (p - buf > 8) {
+				update(ctx, buf, p - buf);
+				p = buf;
+			}
+			*p++ = 1;
+
+			uint_fast32_t x = n;
+			for (; x >= 128; x >>= 7) *p++ = x | 128;
+			*p++ = x;
+
+			update(ctx, buf, p - buf);
+			p = buf;
+			update(ctx, o->class.utf8, n);
+		}
+	}
+
+	*p++ = 127;
+	update(ctx, buf, p - buf);
+}
+
+size_t gen_renamed_unmarshal(gen_renamed* o, const void* data, size_t datalen) {
+	return gen_renamed_unmarshal_at(o, data, datalen, 1, NULL, NULL);
+}
+
+size_t gen_renamed_unmarshal_budget(gen_renamed* o, const void* data, size_t datalen, size_t* budget) {
+	return gen_renamed_unmarshal_at(o, data, datalen, 1, budget, NULL);
+}
+
+size_t gen_renamed_unmarshal_strict(gen_renamed* o, const void* data, size_t datalen, size_t* offset) {
+	const uint8_t* at = data;
+	size_t n = gen_renamed_unmarshal_at(o, data, datalen, 1, NULL, &at);
+	if (!n && errno == EILSEQ) *offset = (size_t) (at - (const uint8_t*) data);
+	return n;
+}
+
+// gen_renamed_unmarshal_at is gen_renamed_unmarshal with depth as the
+// number of data structure levels, including o. Allocation is charged to budget
+// when not NULL. Strict mode applies when fault is not NULL, which then receives
+// the location of any EILSEQ.
+static size_t gen_renamed_unmarshal_at(gen_renamed* o, const void* data, size_t datalen, size_t depth, size_t* budget, const uint8_t** fault) {
+	if (depth > colfer_depth_max) {
+		errno = EFBIG;
+		return 0;
+	}
+
+	// octet pointer navigation
+	const uint8_t* p = data;
+	const uint8_t* end;
+	int enderr;
+	if (datalen < colfer_size_max) {
+		end = p + datalen;
+		enderr = EWOULDBLOCK;
+	} else {
+		end = p + colfer_size_max;
+		enderr = EFBIG;
+	}
+
+	if (p >= end) {
+		errno = enderr;
+		return 0;
+	}
+	uint_fast8_t header = *p++;
+
+	if (header == 0) {
+		const uint8_t* at = p - 1;
+		if (p+1 >= end) {
 			errno = enderr;
 			return 0;
 		}
-		size_t n = *p++;
-		if (n > 127) {
-			n &= 127;
+		uint_fast32_t x = *p++;
+		if (x > 127) {
+			x &= 127;
 			for (int shift = 7; ; shift += 7) {
+				uint_fast32_t b = *p++;
 				if (p >= end) {
 					errno = enderr;
 					return 0;
 				}
-				size_t c = *p++;
-				if (c <= 127) {
-					n |= c << shift;
+				if (b <= 127) {
+					x |= b << shift;
 					break;
 				}
-				n |= (c & 127) << shift;
+				x |= (b & 127) << shift;
 			}
 		}
-		if (n > colfer_list_max) {
-			errno = EFBIG;
+		o->ident = x;
+		if (fault && (!x || x >= (uint_fast32_t) 1 << 21 || (size_t) (p - at - 1) != colfer_varint_size(x))) {
+			*fault = at;
+			errno = EILSEQ;
 			return 0;
 		}
-		if (p+n*4 >= end) {
+		header = *p++;
+	} else if (header == (0 | 128)) {
+		if (p+4 >= end) {
 			errno = enderr;
 			return 0;
 		}
-
-		float* fp = malloc(n * 4);
-		o->f32s.list = fp;
-		o->f32s.len = n;
-#ifdef COLFER_ENDIAN
-		memcpy(fp, p, n * 4);
-		p += n * 4;
-#else
-		for (; n != 0; --n, ++fp) {
-			uint_fast32_t x = *p++;
-			x <<= 24;
-			x |= (uint_fast32_t) *p++ << 16;
-			x |= (uint_fast32_t) *p++ << 8;
-			x |= (uint_fast32_t) *p++;
-			memcpy(fp, &x, 4);
+		uint_fast32_t x = *p++;
+		x <<= 24;
+		x |= (uint_fast32_t) *p++ << 16;
+		x |= (uint_fast32_t) *p++ << 8;
+		x |= (uint_fast32_t) *p++;
+		o->ident = x;
+		if (fault && (x < (uint_fast32_t) 1 << 21)) {
+			*fault = p - 5;
+			errno = EILSEQ;
+			return 0;
 		}
-#endif
 		header = *p++;
 	}
 
-	if (header == 17) {
+	if (header == 1) {
+		const uint8_t* at = p - 1;
 		if (p >= end) {
 			errno = enderr;
 			return 0;
@@ -1126,7 +3157,7 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 		size_t n = *p++;
 		if (n > 127) {
 			n &= 127;
-			for (int shift = 7; ; shift += 7) {
+			for (int shift = 7; shift < sizeof(size_t) * CHAR_BIT; shift += 7) {
 				if (p >= end) {
 					errno = enderr;
 					return 0;
@@ -1139,42 +3170,43 @@ size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen) {
 				n |= (c & 127) << shift;
 			}
 		}
-		if (n > colfer_list_max) {
-			errno = EFBIG;
+		if (fault && (!n || (size_t) (p - at - 1) != colfer_varint_size(n))) {
+			*fault = n ? p - 1 : at;
+			errno = EILSEQ;
 			return 0;
 		}
-		if (p+n*8 >= end) {
+		if (p+n >= end) {
 			errno = enderr;
 			return 0;
 		}
 
-		double* fp = malloc(n * 8);
-		o->f64s.len = n;
-		o->f64s.list = fp;
-#ifdef COLFER_ENDIAN
-		memcpy(fp, p, n * 8);
-		p += n * 8;
-#else
-		for (; n != 0; --n, ++fp) {
-			uint_fast64_t x = *p++;
-			x <<= 56;
-			x |= (uint_fast64_t) *p++ << 48;
-			x |= (uint_fast64_t) *p++ << 40;
-			x |= (uint_fast64_t) *p++ << 32;
-			x |= (uint_fast64_t) *p++ << 24;
-			x |= (uint_fast64_t) *p++ << 16;
-			x |= (uint_fast64_t) *p++ << 8;
-			x |= (uint_fast64_t) *p++;
-			memcpy(fp, &x, 8);
+		if (fault) {
+			size_t valid = colfer_utf8_len(p, n);
+			if (valid < n) {
+				*fault = p + valid;
+				errno = EILSEQ;
+				return 0;
+			}
 		}
-#endif
+
+		if (!colfer_charge(budget, n)) return 0;
+		void* a = malloc(n);
+		memcpy(a, p, n);
+		p += n;
+		o->class.len = n;
+		o->class.utf8 = (char*) a;
 		header = *p++;
 	}
 
 	if (header != 127) {
+		if (fault) *fault = p - 1;
 		errno = EILSEQ;
 		return 0;
 	}
 
 	return (size_t) (p - (const uint8_t*) data);
 }
+
+const char* gen_renamed_validate(const gen_renamed* o) {
+	return NULL;
+}
@@ -1,5 +1,5 @@
 // Code generated by colf(1); DO NOT EDIT.
-// The compiler used schema file test.colf for package gen.
+// The compiler used schema file rules.colf and test.colf for package gen.
 
 #ifndef COLFER_H
 #define COLFER_H
@@ -17,6 +17,15 @@
 extern "C" {
 #endif
 
+// COLFER_DEPRECATED marks the schema definitions with a deprecated annotation.
+#ifndef COLFER_DEPRECATED
+#if defined(__GNUC__) || defined(__clang__)
+#define COLFER_DEPRECATED __attribute__((deprecated))
+#else
+#define COLFER_DEPRECATED
+#endif
+#endif
+
 
 // colfer_size_max is the upper limit for serial octet sizes.
 extern size_t colfer_size_max;
@@ -24,6 +33,10 @@ extern size_t colfer_size_max;
 // colfer_list_max is the upper limit for the number of elements in a list.
 extern size_t colfer_list_max;
 
+// colfer_depth_max is the upper limit for the number of nested data structure
+// levels, including the root.
+extern size_t colfer_depth_max;
+
 
 typedef struct {
 	// sec is the Unix time.
@@ -44,9 +57,21 @@ typedef struct {
 	size_t   len;
 } colfer_binary;
 
+// colfer_hash_func receives consecutive chunks of a serial, like the update
+// operation of a hash function, with ctx as an opaque argument.
+typedef void (*colfer_hash_func)(void* ctx, const void* data, size_t datalen);
+
 
 typedef struct gen_o gen_o;
 
+typedef struct gen_e gen_e;
+
+typedef struct gen_r gen_r;
+
+typedef struct gen_old gen_old;
+
+typedef struct gen_renamed gen_renamed;
+
 
 // O contains all supported data types.
 struct gen_o {
@@ -74,7 +99,7 @@ struct gen_o {
 	gen_o* o;
 	// Os tests data structure lists.
 	struct {
-		struct gen_o* list;
+		gen_o* list;
 		size_t len;
 	} os;
 	// Ss tests text lists.
@@ -112,14 +137,264 @@ size_t gen_o_marshal_len(const gen_o* o);
 // of octets written.
 size_t gen_o_marshal(const gen_o* o, void* buf);
 
+// gen_o_hash feeds the Colfer serial of o into update, without
+// materializing the serial as a whole, and it returns the number of octets.
+// Equal values produce the same input for update in each of the supported
+// languages. When the return is zero then errno is set to EFBIG to indicate a
+// breach of either colfer_size_max or colfer_list_max, and update is not called.
+size_t gen_o_hash(const gen_o* o, colfer_hash_func update, void* ctx);
+
 // gen_o_unmarshal decodes data as Colfer into o and returns the
 // number of octets read. The data is read up to a maximum of datalen or
 // colfer_size_max, whichever occurs first.
 // When the return is zero then errno is set to one of the following 3 values:
-// EWOULDBLOCK on incomplete data, EFBIG on a breach of either colfer_size_max
-// or colfer_list_max and EILSEQ on schema mismatch.
+// EWOULDBLOCK on incomplete data, EFBIG on a breach of either colfer_size_max,
+// colfer_list_max or colfer_depth_max and EILSEQ on schema mismatch.
 size_t gen_o_unmarshal(gen_o* o, const void* data, size_t datalen);
 
+// gen_o_unmarshal_strict is like gen_o_unmarshal, yet it also
+// rejects any serial which differs from the gen_o_marshal output for the
+// same data. When errno is set to EILSEQ, then offset receives the index of the
+// first offending octet.
+size_t gen_o_unmarshal_strict(gen_o* o, const void* data, size_t datalen, size_t* offset);
+
+// gen_o_unmarshal_budget is like gen_o_unmarshal, yet it also
+// limits the memory allocation of field values, including those of nested data
+// structures. The budget is the number of octets which may still be allocated.
+// Each allocation is deducted, and the decoding fails with EFBIG before any
+// allocation exceeds the remainder.
+size_t gen_o_unmarshal_budget(gen_o* o, const void* data, size_t datalen, size_t* budget);
+
+// gen_o_validate checks the fields of o against the schema rules,
+// including the ones of nested data structures. The return is NULL when valid,
+// or a static message which names the field and the rule otherwise. Pattern
+// rules are not checked, as C has no regular expressions.
+const char* gen_o_validate(const gen_o* o);
+
+// E contains an embedded Colfer serial.
+struct gen_e {
+	// M tests embedded serials.
+	colfer_binary m;
+};
+
+// gen_e_marshal_len returns the Colfer serial octet size.
+// When the return is zero then errno is set to EFBIG to indicate a breach of
+// either colfer_size_max or colfer_list_max.
+size_t gen_e_marshal_len(const gen_e* o);
+
+// gen_e_marshal encodes o as Colfer into buf and returns the number
+// of octets written.
+size_t gen_e_marshal(const gen_e* o, void* buf);
+
+// gen_e_hash feeds the Colfer serial of o into update, without
+// materializing the serial as a whole, and it returns the number of octets.
+// Equal values produce the same input for update in each of the supported
+// languages. When the return is zero then errno is set to EFBIG to indicate a
+// breach of either colfer_size_max or colfer_list_max, and update is not called.
+size_t gen_e_hash(const gen_e* o, colfer_hash_func update, void* ctx);
+
+// gen_e_unmarshal decodes data as Colfer into o and returns the
+// number of octets read. The data is read up to a maximum of datalen or
+// colfer_size_max, whichever occurs first.
+// When the return is zero then errno is set to one of the following 3 values:
+// EWOULDBLOCK on incomplete data, EFBIG on a breach of either colfer_size_max,
+// colfer_list_max or colfer_depth_max and EILSEQ on schema mismatch.
+size_t gen_e_unmarshal(gen_e* o, const void* data, size_t datalen);
+
+// gen_e_unmarshal_strict is like gen_e_unmarshal, yet it also
+// rejects any serial which differs from the gen_e_marshal output for the
+// same data. When errno is set to EILSEQ, then offset receives the index of the
+// first offending octet.
+size_t gen_e_unmarshal_strict(gen_e* o, const void* data, size_t datalen, size_t* offset);
+
+// gen_e_unmarshal_budget is like gen_e_unmarshal, yet it also
+// limits the memory allocation of field values, including those of nested data
+// structures. The budget is the number of octets which may still be allocated.
+// Each allocation is deducted, and the decoding fails with EFBIG before any
+// allocation exceeds the remainder.
+size_t gen_e_unmarshal_budget(gen_e* o, const void* data, size_t datalen, size_t* budget);
+
+// gen_e_validate checks the fields of o against the schema rules,
+// including the ones of nested data structures. The return is NULL when valid,
+// or a static message which names the field and the rule otherwise. Pattern
+// rules are not checked, as C has no regular expressions.
+const char* gen_e_validate(const gen_e* o);
+
+// R tests validation rules.
+struct gen_r {
+	// Par tests an integer range.
+	uint8_t par;
+	// Lat tests a floating point range.
+	double lat;
+	// Big tests an unsigned range beyond the signed maximum.
+	uint64_t big;
+	// Name tests required text with size and pattern rules.
+	colfer_text name;
+	// Tags tests a list size.
+	struct {
+		colfer_text* list;
+		size_t len;
+	} tags;
+	// Next tests nested validation.
+	gen_r* next;
+};
+
+// gen_r_marshal_len returns the Colfer serial octet size.
+// When the return is zero then errno is set to EFBIG to indicate a breach of
+// either colfer_size_max or colfer_list_max.
+size_t gen_r_marshal_len(const gen_r* o);
+
+// gen_r_marshal encodes o as Colfer into buf and returns the number
+// of octets written.
+size_t gen_r_marshal(const gen_r* o, void* buf);
+
+// gen_r_hash feeds the Colfer serial of o into update, without
+// materializing the serial as a whole, and it returns the number of octets.
+// Equal values produce the same input for update in each of the supported
+// languages. When the return is zero then errno is set to EFBIG to indicate a
+// breach of either colfer_size_max or colfer_list_max, and update is not called.
+size_t gen_r_hash(const gen_r* o, colfer_hash_func update, void* ctx);
+
+// gen_r_unmarshal decodes data as Colfer into o and returns the
+// number of octets read. The data is read up to a maximum of datalen or
+// colfer_size_max, whichever occurs first.
+// When the return is zero then errno is set to one of the following 3 values:
+// EWOULDBLOCK on incomplete data, EFBIG on a breach of either colfer_size_max,
+// colfer_list_max or colfer_depth_max and EILSEQ on schema mismatch.
+size_t gen_r_unmarshal(gen_r* o, const void* data, size_t datalen);
+
+// gen_r_unmarshal_strict is like gen_r_unmarshal, yet it also
+// rejects any serial which differs from the gen_r_marshal output for the
+// same data. When errno is set to EILSEQ, then offset receives the index of the
+// first offending octet.
+size_t gen_r_unmarshal_strict(gen_r* o, const void* data, size_t datalen, size_t* offset);
+
+// gen_r_unmarshal_budget is like gen_r_unmarshal, yet it also
+// limits the memory allocation of field values, including those of nested data
+// structures. The budget is the number of octets which may still be allocated.
+// Each allocation is deducted, and the decoding fails with EFBIG before any
+// allocation exceeds the remainder.
+size_t gen_r_unmarshal_budget(gen_r* o, const void* data, size_t datalen, size_t* budget);
+
+// gen_r_validate checks the fields of o against the schema rules,
+// including the ones of nested data structures. The return is NULL when valid,
+// or a static message which names the field and the rule otherwise. Pattern
+// rules are not checked, as C has no regular expressions.
+const char* gen_r_validate(const gen_r* o);
+
+// Old tests the annotations.
+//
+// Since: v1.2
+//
+// Deprecated: use r instead.
+struct COLFER_DEPRECATED gen_old {
+	// Pin tests a sensitive field.
+	//
+	// Sensitive:
+	colfer_text pin;
+	// Ref tests a deprecated field.
+	//
+	// Deprecated: no replacement.
+	gen_old* ref COLFER_DEPRECATED;
+};
+
+// gen_old_marshal_len returns the Colfer serial octet size.
+// When the return is zero then errno is set to EFBIG to indicate a breach of
+// either colfer_size_max or colfer_list_max.
+size_t gen_old_marshal_len(const gen_old* o);
+
+// gen_old_marshal encodes o as Colfer into buf and returns the number
+// of octets written.
+size_t gen_old_marshal(const gen_old* o, void* buf);
+
+// gen_old_hash feeds the Colfer serial of o into update, without
+// materializing the serial as a whole, and it returns the number of octets.
+// Equal values produce the same input for update in each of the supported
+// languages. When the return is zero then errno is set to EFBIG to indicate a
+// breach of either colfer_size_max or colfer_list_max, and update is not called.
+size_t gen_old_hash(const gen_old* o, colfer_hash_func update, void* ctx);
+
+// gen_old_unmarshal decodes data as Colfer into o and returns the
+// number of octets read. The data is read up to a maximum of datalen or
+// colfer_size_max, whichever occurs first.
+// When the return is zero then errno is set to one of the following 3 values:
+// EWOULDBLOCK on incomplete data, EFBIG on a breach of either colfer_size_max,
+// colfer_list_max or colfer_depth_max and EILSEQ on schema mismatch.
+size_t gen_old_unmarshal(gen_old* o, const void* data, size_t datalen);
+
+// gen_old_unmarshal_strict is like gen_old_unmarshal, yet it also
+// rejects any serial which differs from the gen_old_marshal output for the
+// same data. When errno is set to EILSEQ, then offset receives the index of the
+// first offending octet.
+size_t gen_old_unmarshal_strict(gen_old* o, const void* data, size_t datalen, size_t* offset);
+
+// gen_old_unmarshal_budget is like gen_old_unmarshal, yet it also
+// limits the memory allocation of field values, including those of nested data
+// structures. The budget is the number of octets which may still be allocated.
+// Each allocation is deducted, and the decoding fails with EFBIG before any
+// allocation exceeds the remainder.
+size_t gen_old_unmarshal_budget(gen_old* o, const void* data, size_t datalen, size_t* budget);
+
+// gen_old_validate checks the fields of o against the schema rules,
+// including the ones of nested data structures. The return is NULL when valid,
+// or a static message which names the field and the rule otherwise. Pattern
+// rules are not checked, as C has no regular expressions.
+const char* gen_old_validate(const gen_old* o);
+
+// N tests native name overrides.
+//
+// Names: go:"Renamed" java:"Renamed" ecma:"Renamed" c:"gen_renamed"
+struct gen_renamed {
+	// ID tests a field override.
+	uint32_t ident;
+	// Class tests a reserved word without override, and it tests
+	// pass-through of struct tags.
+	colfer_text class;
+};
+
+// gen_renamed_marshal_len returns the Colfer serial octet size.
+// When the return is zero then errno is set to EFBIG to indicate a breach of
+// either colfer_size_max or colfer_list_max.
+size_t gen_renamed_marshal_len(const gen_renamed* o);
+
+// gen_renamed_marshal encodes o as Colfer into buf and returns the number
+// of octets written.
+size_t gen_renamed_marshal(const gen_renamed* o, void* buf);
+
+// gen_renamed_hash feeds the Colfer serial of o into update, without
+// materializing the serial as a whole, and it returns the number of octets.
+// Equal values produce the same input for update in each of the supported
+// languages. When the return is zero then errno is set to EFBIG to indicate a
+// breach of either colfer_size_max or colfer_list_max, and update is not called.
+size_t gen_renamed_hash(const gen_renamed* o, colfer_hash_func update, void* ctx);
+
+// gen_renamed_unmarshal decodes data as Colfer into o and returns the
+// number of octets read. The data is read up to a maximum of datalen or
+// colfer_size_max, whichever occurs first.
+// When the return is zero then errno is set to one of the following 3 values:
+// EWOULDBLOCK on incomplete data, EFBIG on a breach of either colfer_size_max,
+// colfer_list_max or colfer_depth_max and EILSEQ on schema mismatch.
+size_t gen_renamed_unmarshal(gen_renamed* o, const void* data, size_t datalen);
+
+// gen_renamed_unmarshal_strict is like gen_renamed_unmarshal, yet it also
+// rejects any serial which differs from the gen_renamed_marshal output for the
+// same data. When errno is set to EILSEQ, then offset receives the index of the
+// first offending octet.
+size_t gen_renamed_unmarshal_strict(gen_renamed* o, const void* data, size_t datalen, size_t* offset);
+
+// gen_renamed_unmarshal_budget is like gen_renamed_unmarshal, yet it also
+// limits the memory allocation of field values, including those of nested data
+// structures. The budget is the number of octets which may still be allocated.
+// Each allocation is deducted, and the decoding fails with EFBIG before any
+// allocation exceeds the remainder.
+size_t gen_renamed_unmarshal_budget(gen_renamed* o, const void* data, size_t datalen, size_t* budget);
+
+// gen_renamed_validate checks the fields of o against the schema rules,
+// including the ones of nested data structures. The return is NULL when valid,
+// or a static message which names the field and the rule otherwise. Pattern
+// rules are not checked, as C has no regular expressions.
+const char* gen_renamed_validate(const gen_renamed* o);
+
 
 #ifdef __cplusplus
 } // extern "C"
@@ -19,6 +19,23 @@ void hexstr(char* buf, const void* data, size_t datalen) {
 	*buf = 0;
 }
 
+// unhex maps the hex string into buf, and it returns the number of octets.
+size_t unhex(uint8_t* buf, const char* hex) {
+	size_t n = 0;
+	for (; hex[0] && hex[1]; hex += 2) {
+		unsigned int c;
+		sscanf(hex, "%2x", &c);
+		buf[n++] = (uint8_t) c;
+	}
+	return n;
+}
+
+// collect appends data to the hex string in ctx, as a colfer_hash_func.
+void collect(void* ctx, const void* data, size_t datalen) {
+	char* hex = ctx;
+	hexstr(hex + strlen(hex), data, datalen);
+}
+
 int gen_o_equal(const gen_o* pa, const gen_o* pb) {
 	if (pa == NULL || pb == NULL) return pa == pb;
 	const gen_o a = *pa, b = *pb;
@@ -208,6 +225,18 @@ int main() {
 		}
 	}
 
+	printf("TEST hash...\n");
+	for (int i = 0; i < n; ++i) {
+		golden g = golden_cases[i];
+		*(char*) hex = 0;
+		size_t size = gen_o_hash(&g.o, collect, hex);
+		if (size != strlen(g.hex) / 2)
+			printf("0x%s: hash got size %zu with errno %d\n", g.hex, size, errno);
+		if (strcmp(hex, g.hex))
+			printf("0x%s: hash got input 0x%s\n", g.hex, (char*) hex);
+		errno = 0;
+	}
+
 	printf("TEST unmarshal limits...\n");
 	for (int i = 0; i < n; ++i) {
 		golden g = golden_cases[i];
@@ -239,6 +268,182 @@ int main() {
 		colfer_size_max = 16 * 1024 * 1024;
 	}
 
+	printf("TEST unmarshal depth...\n");
+	{
+		// three levels of gen.o in field o
+		const uint8_t nested[] = {0x0a, 0x0a, 0x7f, 0x7f, 0x7f};
+
+		colfer_depth_max = 3;
+		gen_o o = {0};
+		size_t read = gen_o_unmarshal(&o, nested, sizeof nested);
+		if (read != sizeof nested || errno != 0)
+			printf("0x0a0a7f7f7f: unmarshal read %zu with errno %d for depth maximum 3\n", read, errno);
+		errno = 0;
+
+		colfer_depth_max = 2;
+		gen_o o2 = {0};
+		read = gen_o_unmarshal(&o2, nested, sizeof nested);
+		if (read || errno != EFBIG)
+			printf("0x0a0a7f7f7f: unmarshal read %zu with errno %d for depth maximum 2\n", read, errno);
+		errno = 0;
+
+		colfer_depth_max = 100;
+	}
+
+	printf("TEST unmarshal embedded...\n");
+	{
+		// o with u32 1
+		const uint8_t embed[] = {0x00, 0x03, 0x01, 0x01, 0x7f, 0x7f};
+		gen_e e = {0};
+		size_t read = gen_e_unmarshal(&e, embed, sizeof embed);
+		if (read != sizeof embed || errno != 0)
+			printf("0x000301017f7f: unmarshal read %zu with errno %d\n", read, errno);
+		else if (e.m.len != 3 || memcmp(e.m.octets, embed + 2, 3))
+			printf("0x000301017f7f: unmarshal got %zu octets\n", e.m.len);
+		free(e.m.octets);
+		errno = 0;
+
+		// missing terminator
+		const uint8_t unframed[] = {0x00, 0x02, 0x01, 0x01, 0x7f};
+		gen_e e2 = {0};
+		size_t offset = 0;
+		read = gen_e_unmarshal_strict(&e2, unframed, sizeof unframed, &offset);
+		if (read || errno != EILSEQ || offset != 3)
+			printf("0x000201017f: unmarshal read %zu with errno %d at %zu\n", read, errno, offset);
+		errno = 0;
+	}
+
+	printf("TEST unmarshal list preallocation...\n");
+	{
+		// lists of 65535 elements with one octet of data remaining
+		const uint8_t lists[][5] = {
+			{0x0b, 0xff, 0xff, 0x03, 0x7f},
+			{0x0c, 0xff, 0xff, 0x03, 0x7f},
+			{0x0d, 0xff, 0xff, 0x03, 0x7f},
+			{0x10, 0xff, 0xff, 0x03, 0x7f},
+			{0x11, 0xff, 0xff, 0x03, 0x7f},
+		};
+		for (size_t i = 0; i < sizeof lists / sizeof lists[0]; ++i) {
+			gen_o o = {0};
+			size_t read = gen_o_unmarshal(&o, lists[i], sizeof lists[i]);
+			if (read || errno != EWOULDBLOCK)
+				printf("0x%02x: unmarshal list read %zu with errno %d, want EWOULDBLOCK\n", lists[i][0], read, errno);
+			errno = 0;
+		}
+	}
+
+	printf("TEST unmarshal strict...\n");
+	for (int i = 0; i < n; ++i) {
+		golden g = golden_cases[i];
+		size_t len = unhex(buf, g.hex);
+		gen_o o = {0};
+		size_t offset = 0;
+		size_t read = gen_o_unmarshal_strict(&o, buf, len, &offset);
+		if (read != len || errno != 0)
+			printf("0x%s: strict unmarshal read %zu with errno %d at %zu\n", g.hex, read, errno, offset);
+		errno = 0;
+	}
+	{
+		const struct {
+			const char* hex;
+			size_t offset;
+		} cases[] = {
+			{"0e007f", 0},
+			{"01007f", 0},
+			{"83007f", 0},
+			{"05000000007f", 0},
+			{"05800000007f", 0},
+			{"08007f", 0},
+			{"09007f", 0},
+			{"0b007f", 0},
+			{"10007f", 0},
+			{"0f00017f", 0},
+			{"81000000017f", 0},
+			{"8200000000000000017f", 0},
+			{"01808080017f", 0},
+			{"870000000000000001000000007f", 0},
+			{"0181007f", 0},
+			{"088100617f", 2},
+			{"07000000013b9aca007f", 5},
+			{"080261ff7f", 3},
+			{"0c02016102c3287f", 5},
+			{"0a01007f7f", 1},
+			{"0b0101007f7f", 2},
+		};
+		for (size_t i = 0; i < sizeof cases / sizeof cases[0]; ++i) {
+			size_t len = unhex(buf, cases[i].hex);
+			gen_o o = {0};
+			size_t read = gen_o_unmarshal(&o, buf, len);
+			if (read != len || errno != 0)
+				printf("0x%s: unmarshal read %zu with errno %d\n", cases[i].hex, read, errno);
+			errno = 0;
+
+			gen_o strict = {0};
+			size_t offset = 0;
+			read = gen_o_unmarshal_strict(&strict, buf, len, &offset);
+			if (read || errno != EILSEQ || offset != cases[i].offset)
+				printf("0x%s: strict unmarshal read %zu with errno %d at %zu, want EILSEQ at %zu\n", cases[i].hex, read, errno, offset, cases[i].offset);
+			errno = 0;
+		}
+	}
+
+	printf("TEST validate...\n");
+	{
+		gen_r next = {0};
+		next.par = 6;
+		gen_r r = {0};
+		r.par = 4;
+		r.name.utf8 = "ams";
+		r.name.len = 3;
+		const char* err = gen_r_validate(&r);
+		if (err) printf("valid struct got %s\n", err);
+
+		r.next = &next;
+		err = gen_r_validate(&r);
+		const char* want = "colfer: field gen.r.par breaks rule max 5";
+		if (!err || strcmp(err, want))
+			printf("got %s, want %s\n", err ? err : "NULL", want);
+
+		r.next = NULL;
+		r.big = UINT64_MAX;
+		err = gen_r_validate(&r);
+		want = "colfer: field gen.r.big breaks rule max 10000000000000000000";
+		if (!err || strcmp(err, want))
+			printf("got %s, want %s\n", err ? err : "NULL", want);
+	}
+
+	printf("TEST unmarshal budget...\n");
+	{
+		// gen.o with o {os [{}, {}]} and ss ["a", "b"]
+		const uint8_t data[] = {0x0a, 0x0b, 0x02, 0x7f, 0x7f, 0x7f, 0x0c, 0x02, 0x01, 'a', 0x01, 'b', 0x7f};
+
+		gen_o o = {0};
+		size_t budget = 1 << 20;
+		size_t read = gen_o_unmarshal_budget(&o, data, sizeof data, &budget);
+		if (read != sizeof data)
+			printf("unmarshal budget read %zu with errno %d, want %zu\n", read, errno, sizeof data);
+		size_t used = (1 << 20) - budget;
+		if (!used)
+			printf("unmarshal budget not reduced\n");
+		errno = 0;
+
+		gen_o exact = {0};
+		budget = used;
+		read = gen_o_unmarshal_budget(&exact, data, sizeof data, &budget);
+		if (read != sizeof data)
+			printf("unmarshal read %zu with errno %d with the exact budget\n", read, errno);
+		if (budget)
+			printf("unmarshal got %zu octets of budget left, want 0\n", budget);
+		errno = 0;
+
+		gen_o tight = {0};
+		budget = used - 1;
+		read = gen_o_unmarshal_budget(&tight, data, sizeof data, &budget);
+		if (read || errno != EFBIG)
+			printf("unmarshal read %zu with errno %d with a short budget, want EFBIG\n", read, errno);
+		errno = 0;
+	}
+
 	free(buf);
 	free(hex);
 }
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -18,10 +22,17 @@ var (
 	format  = flag.Bool("f", false, "Normalizes schemas on the fly.")
 	verbose = flag.Bool("v", false, "Enables verbose reporting to the standard error.")
 
-	sizeMax = flag.String("s", "16 * 1024 * 1024", "Sets the default upper limit for serial byte sizes. The\n    \t`expression` is applied to the target language under the name\n    \tColferSizeMax.")
-	listMax = flag.String("l", "64 * 1024", "Sets the default upper limit for the number of elements in a\n    \tlist. The `expression` is applied to the target language under\n    \tthe name ColferListMax.")
+	sizeMax  = flag.String("s", "16 * 1024 * 1024", "Sets the default upper limit for serial byte sizes. The\n    \t`expression` is applied to the target language under the name\n    \tColferSizeMax.")
+	listMax  = flag.String("l", "64 * 1024", "Sets the default upper limit for the number of elements in a\n    \tlist. The `expression` is applied to the target language under\n    \tthe name ColferListMax.")
+	depthMax = flag.String("d", "100", "Sets the default upper limit for the number of nested data\n    \tstructure levels, including the root. The `expression` is applied\n    \tto the target language under the name ColferDepthMax.")
 
 	superClass = flag.String("x", "", "Makes all generated classes extend a super `class`. Use slash as\n    \ta package separator. Java only.")
+	noCopy     = flag.Bool("z", false, "Adds an UnmarshalNoCopy method which lets text and binary\n    \tfields share memory with the serial data. Go only.")
+	runtime    = flag.Bool("r", false, "Makes the generated code use the shared runtime package\n    \tgithub.com/pascaldekloe/colfer/rt for the error types. Go only.")
+	lazy       = flag.Bool("a", false, "Makes data structure fields decode on first access, and it\n    \tmakes untouched ones marshal their original serial data as is.\n    \tGo and Java only.")
+	jsonTags   = flag.Bool("j", false, "Adds json struct tags with the schema names to the fields, for\n    \tthird-party libraries which reflect on them. The encoding/json\n    \tpackage ignores the tags, as it uses the generated MarshalJSON\n    \tand UnmarshalJSON methods instead. Go only.")
+	sqlValue   = flag.Bool("q", false, "Adds the database/sql Scanner and driver Valuer interfaces, with\n    \tthe serial data as the column value. Go only.")
+	fuzzCorpus = flag.String("u", "", "Adds a native fuzz test per data structure, seeded with the files\n    \tin `directory`, relative to the package. Go only.")
 )
 
 var report = log.New(ioutil.Discard, "", 0)
@@ -34,26 +45,52 @@ func main() {
 		report.SetOutput(os.Stderr)
 	}
 
-	var files []string
-	switch args := flag.Args(); len(args) {
-	case 0:
+	args := flag.Args()
+	if len(args) == 0 {
 		flag.Usage()
 		os.Exit(2)
-	case 1:
-		files = []string{"."}
-	default:
-		files = args[1:]
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "inspect":
+		inspect(args[1:])
+		return
+	case "encode":
+		if len(args) < 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		encode(args[1], schemaFiles(args[2:]))
+		return
 	}
 
 	// select language
 	var gen func(string, []*colfer.Package) error
-	switch lang := flag.Arg(0); strings.ToLower(lang) {
+	switch lang := args[0]; strings.ToLower(lang) {
 	case "c":
 		report.Println("Set up for C")
 		gen = colfer.GenerateC
 		if *superClass != "" {
 			log.Fatal("colf: super class not supported with C")
 		}
+		if *noCopy {
+			log.Fatal("colf: zero-copy not supported with C")
+		}
+		if *runtime {
+			log.Fatal("colf: runtime package not supported with C")
+		}
+		if *lazy {
+			log.Fatal("colf: lazy decoding not supported with C")
+		}
+		if *jsonTags {
+			log.Fatal("colf: JSON tags not supported with C")
+		}
+		if *sqlValue {
+			log.Fatal("colf: SQL values not supported with C")
+		}
+		if *fuzzCorpus != "" {
+			log.Fatal("colf: fuzz tests not supported with C")
+		}
 
 	case "go":
 		report.Println("Set up for Go")
@@ -65,6 +102,21 @@ func main() {
 	case "java":
 		report.Println("Set up for Java")
 		gen = colfer.GenerateJava
+		if *noCopy {
+			log.Fatal("colf: zero-copy not supported with Java")
+		}
+		if *runtime {
+			log.Fatal("colf: runtime package not supported with Java")
+		}
+		if *jsonTags {
+			log.Fatal("colf: JSON tags not supported with Java")
+		}
+		if *sqlValue {
+			log.Fatal("colf: SQL values not supported with Java")
+		}
+		if *fuzzCorpus != "" {
+			log.Fatal("colf: fuzz tests not supported with Java")
+		}
 
 	case "javascript", "js", "ecmascript":
 		report.Println("Set up for ECMAScript")
@@ -72,12 +124,58 @@ func main() {
 		if *superClass != "" {
 			log.Fatal("colf: super class not supported with ECMAScript")
 		}
+		if *noCopy {
+			log.Fatal("colf: zero-copy not supported with ECMAScript")
+		}
+		if *runtime {
+			log.Fatal("colf: runtime package not supported with ECMAScript")
+		}
+		if *lazy {
+			log.Fatal("colf: lazy decoding not supported with ECMAScript")
+		}
+		if *jsonTags {
+			log.Fatal("colf: JSON tags not supported with ECMAScript")
+		}
+		if *sqlValue {
+			log.Fatal("colf: SQL values not supported with ECMAScript")
+		}
+		if *fuzzCorpus != "" {
+			log.Fatal("colf: fuzz tests not supported with ECMAScript")
+		}
 
 	default:
 		log.Fatalf("colf: unsupported language %q", lang)
 	}
 
-	// resolve clean file set
+	packages := parse(schemaFiles(args[1:]))
+
+	for _, p := range packages {
+		p.Name = path.Join(*prefix, p.Name)
+		p.SizeMax = *sizeMax
+		p.ListMax = *listMax
+		p.DepthMax = *depthMax
+		p.SuperClass = *superClass
+		p.NoCopy = *noCopy
+		p.Runtime = *runtime
+		p.Lazy = *lazy
+		p.JSONTags = *jsonTags
+		p.SQL = *sqlValue
+		p.FuzzCorpus = *fuzzCorpus
+	}
+
+	if err := gen(*basedir, packages); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// schemaFiles resolves a clean file set from the operands.
+// The working directory applies when operands is empty.
+func schemaFiles(operands []string) []string {
+	files := operands
+	if len(files) == 0 {
+		files = []string{"."}
+	}
+
 	var writeIndex int
 	for i := 0; i < len(files); i++ {
 		f := files[i]
@@ -110,7 +208,11 @@ func main() {
 	}
 	files = files[:writeIndex]
 	report.Println("Found schema files", strings.Join(files, ", "))
+	return files
+}
 
+// parse returns the schema definitions from files.
+func parse(files []string) []*colfer.Package {
 	packages, err := colfer.ParseFiles(files)
 	if err != nil {
 		log.Fatal(err)
@@ -131,19 +233,125 @@ func main() {
 	if len(packages) == 0 {
 		log.Fatal("colf: no struct definitons found")
 	}
+	for _, warning := range colfer.Warnings(packages) {
+		log.Print("colf: warning: ", warning)
+	}
+	return packages
+}
 
-	for _, p := range packages {
-		p.Name = path.Join(*prefix, p.Name)
+// encode writes the Colfer serial of each JSON document from the standard
+// input to the standard output.
+func encode(structName string, files []string) {
+	var root *colfer.Struct
+	for _, p := range parse(files) {
 		p.SizeMax = *sizeMax
 		p.ListMax = *listMax
-		p.SuperClass = *superClass
+		p.DepthMax = *depthMax
+		for _, s := range p.Structs {
+			if s.String() == structName {
+				root = s
+			}
+		}
 	}
+	if root == nil {
+		log.Fatalf("colf: struct %q not found; use the qualified name, i.e., package.struct", structName)
+	}
+	report.Println("Encode JSON as", root)
 
-	if err := gen(*basedir, packages); err != nil {
+	in := json.NewDecoder(bufio.NewReader(os.Stdin))
+	out := bufio.NewWriter(os.Stdout)
+	for n := 1; ; n++ {
+		var doc json.RawMessage
+		if err := in.Decode(&doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			log.Fatalf("colf: JSON document %d: %s", n, err)
+		}
+
+		serial, err := colfer.EncodeJSON(root, doc)
+		if err != nil {
+			log.Fatalf("colf: JSON document %d: %s", n, err)
+		}
+		if _, err := out.Write(serial); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := out.Flush(); err != nil {
 		log.Fatal(err)
 	}
 }
 
+// readingMax is the number of interpretations shown by inspect.
+const readingMax = 8
+
+// inspect prints the wire structure of each file, or the standard input when
+// files is empty, without the use of a schema.
+func inspect(files []string) {
+	if len(files) == 0 {
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatal(err)
+		}
+		inspectData(os.Stdout, data)
+		return
+	}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s:\n", file)
+		inspectData(os.Stdout, data)
+	}
+}
+
+// inspectData prints the readings of each serial in data.
+func inspectData(w io.Writer, data []byte) {
+	for offset := 0; offset < len(data); {
+		readings, err := colfer.InspectWire(data[offset:], readingMax)
+		if err != nil {
+			mismatch := err.(colfer.WireMismatch)
+			fmt.Fprintf(w, "serial at byte %d has no consistent reading; inconsistent at byte %d\n", offset, offset+int(mismatch))
+			return
+		}
+
+		for i, r := range readings {
+			if i == 0 {
+				fmt.Fprintf(w, "serial at byte %d till %d:\n", offset, offset+r.End)
+			} else {
+				fmt.Fprintf(w, "ambiguous at byte %d; alternative reading %d till %d:\n", offset+readings[0].Diverge(r), i+1, offset+r.End)
+			}
+			printWireFields(w, r.Fields, offset, "\t")
+			fmt.Fprintf(w, "\t@%d end\n", offset+r.End-1)
+		}
+		if len(readings) == readingMax {
+			fmt.Fprintf(w, "more readings omitted\n")
+		}
+
+		offset += readings[0].End
+		if offset < len(data) {
+			fmt.Fprintf(w, "data continuation at byte %d\n", offset)
+		}
+	}
+}
+
+// printWireFields prints each field, including the nested data structures,
+// with the positions shifted by offset.
+func printWireFields(w io.Writer, fields []*colfer.WireField, offset int, indent string) {
+	for _, f := range fields {
+		g := *f
+		g.Offset += offset
+		fmt.Fprintf(w, "%s%s\n", indent, &g)
+
+		for _, e := range f.Elements {
+			printWireFields(w, e.Fields, offset, indent+"\t")
+			fmt.Fprintf(w, "%s\t@%d end\n", indent, offset+e.End-1)
+		}
+	}
+}
+
 // ANSI escape codes for markup
 const (
 	bold      = "\x1b[1m"
@@ -157,6 +365,11 @@ func init() {
 	help := bold + "NAME\n\t" + cmd + clear + " \u2014 compile Colfer schemas\n\n"
 	help += bold + "SYNOPSIS\n\t" + cmd + clear
 	help += " [ " + underline + "options" + clear + " ] " + underline + "language" + clear
+	help += " [ " + underline + "file" + clear + " " + underline + "..." + clear + " ]\n"
+	help += "\t" + bold + cmd + clear
+	help += " [ " + underline + "options" + clear + " ] " + bold + "encode" + clear + " " + underline + "struct" + clear
+	help += " [ " + underline + "file" + clear + " " + underline + "..." + clear + " ]\n"
+	help += "\t" + bold + cmd + clear + " " + bold + "inspect" + clear
 	help += " [ " + underline + "file" + clear + " " + underline + "..." + clear + " ]\n\n"
 	help += bold + "DESCRIPTION\n\t" + clear
 	help += "Generates source code for a " + underline + "language" + clear + ". The options are: "
@@ -166,6 +379,14 @@ func init() {
 	help += "\tfiles with the colf extension. If " + underline + "file" + clear + " is absent, " + cmd + " includes\n"
 	help += "\tthe working directory.\n"
 	help += "\tA package can have multiple schema files.\n\n"
+	help += "\tThe " + bold + "encode" + clear + " mode reads JSON documents from the standard input and\n"
+	help += "\twrites the Colfer serial of each to the standard output. The input\n"
+	help += "\tis either one document or a stream of documents, like NDJSON. The\n"
+	help += "\t" + underline + "struct" + clear + " operand is the qualified name of the root data structure.\n\n"
+	help += "\tThe " + bold + "inspect" + clear + " mode prints the wire structure of Colfer serials without\n"
+	help += "\ta schema. The data is read from each " + underline + "file" + clear + " operand, or from the standard\n"
+	help += "\tinput when absent. Ambiguous serials list the alternative readings\n"
+	help += "\tin order of plausibility. Nesting beyond 100 levels is not recognised.\n\n"
 	help += bold + "OPTIONS\n" + clear
 
 	tail := "\n" + bold + "EXIT STATUS" + clear + "\n"
@@ -175,7 +396,11 @@ func init() {
 	tail += "\tCompile ./io.colf with compact limits as C:\n\n"
 	tail += "\t\t" + cmd + " -b src -s 2048 -l 96 C io.colf\n\n"
 	tail += "\tCompile ./api/*.colf in package com.example as Java:\n\n"
-	tail += "\t\t" + cmd + " -p com/example -x com/example/Parent Java api\n"
+	tail += "\t\t" + cmd + " -p com/example -x com/example/Parent Java api\n\n"
+	tail += "\tEncode a JSON document as demo.course from ./demo.colf:\n\n"
+	tail += "\t\t" + cmd + " encode demo.course demo.colf < course.json > course.bin\n\n"
+	tail += "\tShow the fields of a serial from an unknown schema:\n\n"
+	tail += "\t\t" + cmd + " inspect course.bin\n"
 	tail += "\n" + bold + "BUGS" + clear + "\n"
 	tail += "\tReport bugs at https://github.com/pascaldekloe/colfer/issues\n\n"
 	tail += bold + "SEE ALSO\n\t" + clear + "protoc(1)\n"
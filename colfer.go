@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf16"
 )
 
 // datatypes holds all supported names.
@@ -22,6 +24,7 @@ var datatypes = map[string]struct{}{
 	"timestamp": {},
 	"text":      {},
 	"binary":    {},
+	"any":       {},
 }
 
 type packages []*Package
@@ -46,10 +49,29 @@ type Package struct {
 	SizeMax string
 	// ListMax is the uper limit expression.
 	ListMax string
+	// DepthMax is the uper limit expression.
+	DepthMax string
 	// SuperClass is the fully qualified path.
 	SuperClass string
 	// SuperClassNative is the language specific SuperClass.
 	SuperClassNative string
+	// NoCopy enables zero-copy decoding. Go only.
+	NoCopy bool
+	// Runtime enables the shared runtime package. Go only.
+	Runtime bool
+	// Lazy enables deferred decoding of data structure fields.
+	// Go and Java only.
+	Lazy bool
+	// JSONTags enables json struct tags with the schema names, for reflection
+	// by third-party libraries. The generated JSON methods ignore them. Go
+	// only.
+	JSONTags bool
+	// SQL enables database/sql support with the serial data as the
+	// column value. Go only.
+	SQL bool
+	// FuzzCorpus enables native fuzz tests when not empty, seeded with the
+	// files in the directory, relative to the package. Go only.
+	FuzzCorpus string
 }
 
 // DocText returns the documentation lines prefixed with ident.
@@ -98,6 +120,26 @@ func (p *Package) HasFloat() bool {
 	return false
 }
 
+// HasInteger returns whether p has one or more integer fields.
+func (p *Package) HasInteger() bool {
+	for _, s := range p.Structs {
+		if s.HasInteger() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasInt64 returns whether p has one or more 64-bit integer fields.
+func (p *Package) HasInt64() bool {
+	for _, s := range p.Structs {
+		if s.HasInt64() {
+			return true
+		}
+	}
+	return false
+}
+
 // HasTimestamp returns whether p has one or more timestamp fields.
 func (p *Package) HasTimestamp() bool {
 	for _, s := range p.Structs {
@@ -118,6 +160,48 @@ func (p *Package) HasList() bool {
 	return false
 }
 
+// HasText returns whether p has one or more text fields.
+func (p *Package) HasText() bool {
+	for _, s := range p.Structs {
+		if s.HasText() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasStruct returns whether p has one or more data structure fields.
+func (p *Package) HasStruct() bool {
+	for _, s := range p.Structs {
+		for _, f := range s.Fields {
+			if f.TypeRef != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasAny returns whether p has one or more any fields.
+func (p *Package) HasAny() bool {
+	for _, s := range p.Structs {
+		if s.HasAny() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPattern returns whether p has one or more fields with a pattern rule.
+func (p *Package) HasPattern() bool {
+	for _, s := range p.Structs {
+		if s.HasPattern() {
+			return true
+		}
+	}
+	return false
+}
+
 // Struct is a data structure definition.
 type Struct struct {
 	Pkg *Package
@@ -131,10 +215,21 @@ type Struct struct {
 	Fields []*Field
 	// SchemaFile is the source filename.
 	SchemaFile string
+	// Names are the native name overrides per language, with "go",
+	// "java", "ecma" and "c" as keys.
+	Names map[string]string
+	// Annotations are the tags from Docs.
+	Annotations
+
+	// title is the language specific NameTitle, if any.
+	title string
 }
 
 // NameTitle returns the identification token in title case.
 func (s *Struct) NameTitle() string {
+	if s.title != "" {
+		return s.title
+	}
 	return strings.Title(s.Name)
 }
 
@@ -158,6 +253,27 @@ func (s *Struct) HasFloat() bool {
 	return false
 }
 
+// HasInteger returns whether s has one or more integer fields.
+func (s *Struct) HasInteger() bool {
+	for _, f := range s.Fields {
+		switch f.Type {
+		case "uint8", "uint16", "uint32", "uint64", "int32", "int64":
+			return true
+		}
+	}
+	return false
+}
+
+// HasInt64 returns whether s has one or more 64-bit integer fields.
+func (s *Struct) HasInt64() bool {
+	for _, f := range s.Fields {
+		if f.Type == "uint64" || f.Type == "int64" {
+			return true
+		}
+	}
+	return false
+}
+
 // HasText returns whether s has one or more text fields.
 func (s *Struct) HasText() bool {
 	for _, f := range s.Fields {
@@ -208,6 +324,46 @@ func (s *Struct) HasList() bool {
 	return false
 }
 
+// HasStruct returns whether s has one or more data structure fields.
+func (s *Struct) HasStruct() bool {
+	for _, f := range s.Fields {
+		if f.TypeRef != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAny returns whether s has one or more any fields.
+func (s *Struct) HasAny() bool {
+	for _, f := range s.Fields {
+		if f.Type == "any" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRules returns whether s has one or more fields with validation rules.
+func (s *Struct) HasRules() bool {
+	for _, f := range s.Fields {
+		if f.HasRules() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPattern returns whether s has one or more fields with a pattern rule.
+func (s *Struct) HasPattern() bool {
+	for _, f := range s.Fields {
+		if f.Pattern != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // Field is a Struct member definition.
 type Field struct {
 	// Struct is the parent.
@@ -228,13 +384,156 @@ type Field struct {
 	TypeRef *Struct
 	// TypeList flags whether the datatype is a list.
 	TypeList bool
+	// TypeColfer flags a binary with an embedded Colfer serial, which is
+	// validated for framing only.
+	TypeColfer bool
+
+	// Min is the lower bound of a number in decimal notation, if any.
+	Min string
+	// Max is the upper bound of a number in decimal notation, if any.
+	Max string
+	// MinNative is the language specific Min.
+	MinNative string
+	// MaxNative is the language specific Max.
+	MaxNative string
+	// MinLen is the lower bound of the size, if any. Text and binaries
+	// count in bytes, and lists count in elements.
+	MinLen string
+	// MaxLen is the upper bound of the size, if any. Text and binaries
+	// count in bytes, and lists count in elements.
+	MaxLen string
+	// Required rejects the zero value.
+	Required bool
+	// Pattern is a regular expression for text to match, if any.
+	Pattern string
+
+	// Names are the native name overrides per language, with "go",
+	// "java", "ecma" and "c" as keys.
+	Names map[string]string
+	// Tags are the struct tag pairs other than the Colfer keys, in order
+	// of appearance, e.g., `yaml:"id"`.
+	Tags []string
+	// TagNative is the language specific Tags. Go only.
+	TagNative string
+	// Annotations are the tags from Docs.
+	Annotations
+
+	// title is the language specific NameTitle, if any.
+	title string
+	// colferKeys are the Colfer keys in the struct tag, which do not pass
+	// through, in order of appearance.
+	colferKeys []string
+}
+
+// Annotations are the recognized tags in documentation. Each tag starts a
+// line, as in "Deprecated: use hole instead.", and it continues up to the
+// next empty line or tag.
+type Annotations struct {
+	// Deprecated flags a "Deprecated:" tag.
+	Deprecated bool
+	// DeprecatedText is the explanation of the Deprecated tag, if any.
+	DeprecatedText string
+	// Since is the version of a "Since:" tag, if any.
+	Since string
+	// Sensitive flags a "Sensitive:" tag, which marks the value as
+	// confidential.
+	Sensitive bool
+
+	// names is the struct tag of a "Names:" tag, if any.
+	names string
+	// body has the documentation lines without the tags.
+	body []string
+}
+
+// annotationTags are the recognized line prefixes.
+var annotationTags = []string{"Deprecated:", "Since:", "Sensitive:", "Names:"}
+
+// annotationTag returns the tag which starts the documentation line, if any.
+func annotationTag(line string) string {
+	text := strings.TrimSpace(strings.TrimPrefix(line, "//"))
+	for _, tag := range annotationTags {
+		if strings.HasPrefix(text, tag) {
+			return tag
+		}
+	}
+	return ""
+}
+
+// parseAnnotations sets the tags from docs. The return has docs with an
+// empty line before each tag which follows text, such that the tags read
+// as paragraphs in Go.
+func (a *Annotations) parseAnnotations(docs []string) []string {
+	var out []string
+	var text *string // continuation of the current tag
+	for _, line := range docs {
+		content := strings.TrimSpace(strings.TrimPrefix(line, "//"))
+		if !strings.HasPrefix(line, "//") {
+			content = ""
+		}
+
+		tag := annotationTag(line)
+		switch {
+		case tag != "":
+			if len(out) != 0 && strings.TrimSpace(out[len(out)-1]) != "//" {
+				out = append(out, "//")
+			}
+			value := strings.TrimSpace(content[len(tag):])
+			switch tag {
+			case "Deprecated:":
+				a.Deprecated = true
+				a.DeprecatedText = value
+				text = &a.DeprecatedText
+			case "Since:":
+				a.Since = value
+				text = &a.Since
+			case "Sensitive:":
+				a.Sensitive = true
+				text = nil
+			case "Names:":
+				a.names = value
+				text = nil
+			}
+		case content == "":
+			text = nil
+			if n := len(a.body); n != 0 && strings.TrimSpace(a.body[n-1]) != "//" {
+				a.body = append(a.body, line)
+			}
+		case text != nil:
+			if *text != "" {
+				*text += " "
+			}
+			*text += content
+		default:
+			a.body = append(a.body, line)
+		}
+		out = append(out, line)
+	}
+
+	// trim empty lines left by the tags
+	for len(a.body) != 0 && strings.TrimSpace(a.body[len(a.body)-1]) == "//" {
+		a.body = a.body[:len(a.body)-1]
+	}
+	return out
+}
+
+// DocTextBody is like DocText, yet without the annotation tags.
+func (a *Annotations) DocTextBody(indent string) string {
+	return docText(a.body, indent)
 }
 
 // NameTitle returns the identification token in title case.
 func (f *Field) NameTitle() string {
+	if f.title != "" {
+		return f.title
+	}
 	return strings.Title(f.Name)
 }
 
+// HasRules returns whether f has one or more validation rules.
+func (f *Field) HasRules() bool {
+	return f.Min != "" || f.Max != "" || f.MinLen != "" || f.MaxLen != "" || f.Required || f.Pattern != ""
+}
+
 // DocText returns the documentation lines prefixed with ident.
 func (f *Field) DocText(indent string) string {
 	return docText(f.Docs, indent)
@@ -245,6 +544,38 @@ func (f *Field) String() string {
 	return fmt.Sprintf("%s.%s", f.Struct, f.Name)
 }
 
+// nameLangs are the keys of the native name overrides.
+var nameLangs = []string{"go", "java", "ecma", "c"}
+
+// checkNativeNames returns an error when two structs in a package, or two
+// fields in a struct, share a native name for lang. C has one namespace for
+// the structs of all packages.
+func checkNativeNames(packages []*Package, lang string, structName func(*Struct) string, fieldName func(*Field) string) error {
+	structs := make(map[string]*Struct)
+	for _, p := range packages {
+		if lang != "c" {
+			structs = make(map[string]*Struct)
+		}
+		for _, s := range p.Structs {
+			name := structName(s)
+			if dupe, ok := structs[name]; ok {
+				return fmt.Errorf("colfer: struct %s and %s share the %s name %q", dupe, s, lang, name)
+			}
+			structs[name] = s
+
+			fields := make(map[string]*Field)
+			for _, f := range s.Fields {
+				name := fieldName(f)
+				if dupe, ok := fields[name]; ok {
+					return fmt.Errorf("colfer: field %s and %s share the %s name %q", dupe, f, lang, name)
+				}
+				fields[name] = f
+			}
+		}
+	}
+	return nil
+}
+
 func docText(docs []string, indent string) string {
 	if len(docs) == 0 {
 		return ""
@@ -255,6 +586,11 @@ func docText(docs []string, indent string) string {
 		if i != 0 {
 			buf.WriteByte('\n')
 		}
+		if strings.TrimSpace(s) == "//" {
+			// paragraph separator
+			buf.WriteString(strings.TrimRight(indent, " "))
+			continue
+		}
 		if !strings.HasPrefix(s, "// ") {
 			continue
 		}
@@ -265,3 +601,39 @@ func docText(docs []string, indent string) string {
 
 	return buf.String()
 }
+
+// quoteUTF16 returns s as a double-quoted string literal for both Java and
+// JavaScript. Anything other than printable ASCII goes in UTF-16 escapes, as
+// neither the source encoding nor the Go escapes, like "\x01", are portable.
+func quoteUTF16(s string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\r':
+			buf.WriteString(`\r`)
+		case r >= ' ' && r < 0x7f:
+			buf.WriteRune(r)
+		default:
+			// Java translates Unicode escapes before tokenization,
+			// hence the named escapes for line terminators above.
+			r1, r2 := utf16.EncodeRune(r)
+			if r1 == unicode.ReplacementChar {
+				r1 = r
+			}
+			fmt.Fprintf(&buf, `\u%04x`, r1)
+			if r2 != unicode.ReplacementChar {
+				fmt.Fprintf(&buf, `\u%04x`, r2)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
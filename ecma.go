@@ -28,19 +28,43 @@ func GenerateECMA(basedir string, packages []*Package) error {
 		}
 
 		for _, s := range p.Structs {
+			s.title = s.Names["ecma"]
 			for _, f := range s.Fields {
-				f.NameNative = f.Name
-				if IsECMAKeyword(f.NameNative) {
-					f.NameNative += "_"
+				f.NameNative, f.title = f.Names["ecma"], ""
+				if f.NameNative == "" {
+					f.NameNative = f.Name
+					if IsECMAKeyword(f.NameNative) {
+						f.NameNative += "_"
+					}
+				}
+				f.MinNative, f.MaxNative = f.Min, f.Max
+				if f.Type == "float32" {
+					// compare with the value as decoded
+					if f.Min != "" {
+						f.MinNative = "Math.fround(" + f.Min + ")"
+					}
+					if f.Max != "" {
+						f.MaxNative = "Math.fround(" + f.Max + ")"
+					}
 				}
 			}
 		}
 	}
+	if err := checkNativeNames(packages, "ecma", (*Struct).NameTitle, func(f *Field) string { return f.NameNative }); err != nil {
+		return err
+	}
 
-	t := template.New("ecma-code")
+	t := template.New("ecma-code").Funcs(template.FuncMap{
+		"quote":   quoteUTF16,
+		"pattern": func(s string) string { return nativePattern(s, "$") },
+	})
 	template.Must(t.Parse(ecmaCode))
 	template.Must(t.New("marshal").Parse(ecmaMarshal))
+	template.Must(t.New("marshal-field").Parse(ecmaMarshalField))
+	template.Must(t.New("hash").Parse(ecmaHash))
 	template.Must(t.New("unmarshal").Parse(ecmaUnmarshal))
+	template.Must(t.New("json").Parse(ecmaJSON))
+	template.Must(t.New("validate").Parse(ecmaValidate))
 
 	if err := os.MkdirAll(basedir, os.ModeDir|os.ModePerm); err != nil {
 		return err
@@ -68,13 +92,35 @@ var {{.NameNative}} = new function() {
 	// The upper limit for the number of elements in a list.
 	var colferListMax = {{.ListMax}};
 {{- end}}
+	// The upper limit for the number of nested data structure levels, including the root.
+	var colferDepthMax = {{.DepthMax}};
 {{range .Structs}}
 	// Constructor.
-{{.DocText "\t// "}}
+{{.DocTextBody "\t// "}}
 	// When init is provided all enumerable properties are merged into the new object a.k.a. shallow cloning.
+{{- if or .Since .Deprecated}}
+	/**
+ {{- if .Since}}
+	 * @since {{.Since}}
+ {{- end}}
+ {{- if .Deprecated}}
+	 * @deprecated {{.DeprecatedText}}
+ {{- end}}
+	 */
+{{- end}}
 	this.{{.NameTitle}} = function(init) {
 {{- range .Fields}}
-{{.DocText "\t\t// "}}
+{{.DocTextBody "\t\t// "}}
+ {{- if or .Since .Deprecated}}
+		/**
+  {{- if .Since}}
+		 * @since {{.Since}}
+  {{- end}}
+  {{- if .Deprecated}}
+		 * @deprecated {{.DeprecatedText}}
+  {{- end}}
+		 */
+ {{- end}}
 		this.{{.NameNative}} =
 {{- if .TypeList}} {{if eq .Type "float32"}}new Float32Array(0){{else if eq .Type "float64"}}new Float64Array(0){{else}}[]{{end}}
 {{- else if eq .Type "bool"}} false
@@ -82,14 +128,36 @@ var {{.NameNative}} = new function() {
 		this.{{.NameNative}}_ns = 0
 {{- else if eq .Type "text"}} ''
 {{- else if eq .Type "binary"}} new Uint8Array(0)
-{{- else if .TypeRef}} null
+{{- else if or .TypeRef (eq .Type "any")}} null
 {{- else}} 0
 {{- end}};{{end}}
 
 		for (var p in init) this[p] = init[p];
 	}
+{{- if .Pkg.HasAny}}
+
+	// The qualified name for any fields.
+	this.{{.NameTitle}}.prototype.colferType = '{{.String}}';
+{{- end}}
 {{template "marshal" .}}
+{{template "hash" .}}
 {{template "unmarshal" .}}
+{{template "json" .}}
+{{template "validate" .}}
+{{end}}
+{{- if .HasAny}}
+	// Returns a new data structure for the qualified name from any fields,
+	// or null when the name is not in this package.
+	this.newColferAny = function(name) {
+		switch (name) {
+{{- range .Structs}}
+		case '{{.String}}':
+			return new {{.Pkg.NameNative}}.{{.NameTitle}}();
+{{- end}}
+		}
+		return null;
+	}
+	var newColferAny = this.newColferAny;
 {{end}}
 	// private section
 
@@ -101,7 +169,30 @@ var {{.NameNative}} = new function() {
 		bytes.push(x&127);
 		return bytes;
 	}
-{{if .HasTimestamp}}
+
+	var nonCanonical = function(i) {
+		return 'colfer: non-canonical encoding at byte ' + i;
+	}
+
+	// Deducts n bytes for field from the optional budget.
+	var charge = function(budget, field, n) {
+		if (! budget) return;
+		if (n > budget.bytes) throw 'colfer: ' + field + ' exceeds the allocation budget';
+		budget.bytes -= n;
+	}
+{{if or .HasStruct .HasAny}}
+	// Unmarshals o from data at index i, with error offsets relative to data.
+	var unmarshalNested = function(o, data, i, depth, strict, validate, budget) {
+		try {
+			return o.unmarshal(data.subarray(i), depth, strict, validate, budget);
+		} catch (err) {
+			var m = /^colfer: non-canonical encoding at byte (\d+)$/.exec(err);
+			if (m) throw nonCanonical(i + Number(m[1]));
+			throw err;
+		}
+	}
+{{end}}
+{{- if .HasTimestamp}}
 	function decodeInt64(data, i) {
 		var v = 0, j = i + 7, m = 1;
 		if (data[i] & 128) {
@@ -119,6 +210,124 @@ var {{.NameNative}} = new function() {
 		return v;
 	}
 {{end}}
+{{- if .HasAny}}
+	var parseJSONAny = function(v, field) {
+		if (v == null) return null;
+		if (typeof v !== 'object' || Array.isArray(v))
+			throw 'colfer: JSON for field ' + field + ': got ' + (Array.isArray(v) ? 'array' : typeof v) + ', want object';
+		for (var name in v) if (name !== 'type' && name !== 'value')
+			throw 'colfer: JSON member ' + JSON.stringify(name) + ' not in any field ' + field;
+		var o = newColferAny(v.type);
+		if (! o) throw 'colfer: JSON for field ' + field + ': type ' + JSON.stringify(v.type) + ' not registered';
+		return o.constructor.fromJSON(v.value == null ? {} : v.value);
+	}
+{{end}}
+{{- if .HasList}}
+	var checkJSONList = function(v, field) {
+		if (v == null) return [];
+		if (! Array.isArray(v)) throw 'colfer: JSON for field ' + field + ': got ' + typeof v + ', want array';
+		if (v.length > colferListMax)
+			throw 'colfer: ' + field + ' length ' + v.length + ' exceeds ' + colferListMax + ' elements';
+		return v;
+	}
+{{end}}
+
+	var parseJSONBool = function(v, field) {
+		if (v == null) return false;
+		if (typeof v !== 'boolean') throw 'colfer: JSON for field ' + field + ': got ' + typeof v + ', want boolean';
+		return v;
+	}
+
+	var parseJSONInt = function(v, field, min, max) {
+		if (v == null) return 0;
+		if (typeof v === 'string' && /^[+-]?[0-9]+$/.test(v)) v = Number(v);
+		if (typeof v !== 'number' || ! Number.isInteger(v))
+			throw 'colfer: JSON for field ' + field + ': ' + JSON.stringify(v) + ' not an integer';
+		if (v < min || v > max)
+			throw 'colfer: JSON for field ' + field + ': ' + v + ' out of range';
+		return v;
+	}
+
+	var parseJSONText = function(v, field) {
+		if (v == null) return '';
+		if (typeof v !== 'string') throw 'colfer: JSON for field ' + field + ': got ' + typeof v + ', want string';
+		return v;
+	}
+{{if .HasFloat}}
+	var formatJSONFloat = function(f) {
+		if (Number.isNaN(f)) return 'NaN';
+		if (f == Infinity) return 'Infinity';
+		if (f == -Infinity) return '-Infinity';
+		return f;
+	}
+
+	var parseJSONFloat = function(v, field) {
+		if (v == null) return 0;
+		if (typeof v === 'number') return v;
+		if (v === 'NaN') return NaN;
+		if (v === 'Infinity') return Infinity;
+		if (v === '-Infinity') return -Infinity;
+		throw 'colfer: JSON for field ' + field + ': ' + JSON.stringify(v) + ' not a floating point';
+	}
+{{end}}
+{{- if .HasTimestamp}}
+	var formatRFC3339 = function(t, ns, field) {
+		var ms = t ? t.getTime() : 0;
+		var msf = (ms % 1E3 + 1E3) % 1E3;
+		var d = new Date(ms - msf);
+		var year = d.getUTCFullYear();
+		if (year < 0 || year > 9999)
+			throw 'colfer: ' + field + ' year ' + year + ' outside of RFC 3339 range';
+
+		var s = d.toISOString().substring(0, 19);
+		var frac = msf * 1E6 + (ns || 0);
+		if (frac) s += '.' + String(1E9 + frac).substring(1).replace(/0+$/, '');
+		return s + 'Z';
+	}
+
+	var parseRFC3339 = function(v, field) {
+		var m = typeof v === 'string' && /^([0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2})(?:\.([0-9]{1,9}))?(Z|[+-][0-9]{2}:[0-9]{2})$/.exec(v.toUpperCase());
+		var ms = m ? Date.parse(m[1] + m[3]) : NaN;
+		if (Number.isNaN(ms)) throw 'colfer: JSON for field ' + field + ': ' + JSON.stringify(v) + ' not an RFC 3339 timestamp';
+		var ns = m[2] ? Number((m[2] + '00000000').substring(0, 9)) : 0;
+		return [new Date(ms + Math.floor(ns / 1E6)), ns % 1E6];
+	}
+{{end}}
+	const base64Chars = 'ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/';
+
+	var encodeBase64 = function(bytes) {
+		var s = '';
+		if (! bytes) return s;
+		for (var i = 0; i < bytes.length; i += 3) {
+			var x = bytes[i] << 16;
+			if (i + 1 < bytes.length) x |= bytes[i + 1] << 8;
+			if (i + 2 < bytes.length) x |= bytes[i + 2];
+			s += base64Chars[x >> 18 & 63] + base64Chars[x >> 12 & 63];
+			s += i + 1 < bytes.length ? base64Chars[x >> 6 & 63] : '=';
+			s += i + 2 < bytes.length ? base64Chars[x & 63] : '=';
+		}
+		return s;
+	}
+
+	var decodeBase64 = function(v, field) {
+		if (v == null) return new Uint8Array(0);
+		if (typeof v !== 'string' || v.length % 4 || ! /^[A-Za-z0-9+\/]*={0,2}$/.test(v))
+			throw 'colfer: JSON for field ' + field + ': ' + JSON.stringify(v) + ' not base64';
+
+		var n = v.length / 4 * 3;
+		if (v.charAt(v.length - 1) == '=') n--;
+		if (v.charAt(v.length - 2) == '=') n--;
+		var bytes = new Uint8Array(n);
+		for (var i = 0, j = 0; i < v.length; i += 4) {
+			var x = base64Chars.indexOf(v[i]) << 18 | base64Chars.indexOf(v[i + 1]) << 12
+				| (base64Chars.indexOf(v[i + 2]) & 63) << 6 | (base64Chars.indexOf(v[i + 3]) & 63);
+			bytes[j++] = x >> 16;
+			if (j < n) bytes[j++] = x >> 8 & 255;
+			if (j < n) bytes[j++] = x & 255;
+		}
+		return bytes;
+	}
+
 	var encodeUTF8 = function(s) {
 		var i = 0;
 		var bytes = new Uint8Array(s.length * 4);
@@ -148,6 +357,37 @@ var {{.NameNative}} = new function() {
 		return bytes.subarray(0, i);
 	}
 
+{{- if .HasText}}
+
+	// Returns the number of bytes before the first invalid UTF-8 sequence, if any.
+	var validUTF8Len = function(bytes) {
+		for (var i = 0; i < bytes.length; ) {
+			var c = bytes[i];
+			if (c < 128) {
+				++i;
+				continue;
+			}
+
+			var n, min;
+			if (c > 193 && c < 224) n = 1, min = 0x80;
+			else if (c > 223 && c < 240) n = 2, min = 0x800;
+			else if (c > 239 && c < 245) n = 3, min = 0x10000;
+			else return i;
+			if (i + n >= bytes.length) return i;
+
+			var v = c & (63 >> n);
+			for (var j = 1; j <= n; ++j) {
+				var b = bytes[i + j];
+				if ((b & 192) != 128) return i;
+				v = v << 6 | b & 63;
+			}
+			if (v < min || v > 0x10ffff || (v > 0xd7ff && v < 0xe000)) return i;
+			i += n + 1;
+		}
+		return bytes.length;
+	}
+{{- end}}
+
 	var decodeUTF8 = function(bytes) {
 		var s = '';
 		var i = 0;
@@ -189,7 +429,57 @@ const ecmaMarshal = `
 {{- end}}{{end}}{{end}}
 	this.{{.NameTitle}}.prototype.marshal = function() {
 		var segs = [];
-{{range .Fields}}{{if eq .Type "bool"}}
+{{range .Fields}}{{if .TypeRef}}{{if .TypeList}}
+		if (this.{{.NameNative}} && this.{{.NameNative}}.length) {
+			var a = this.{{.NameNative}};
+			if (a.length > colferListMax)
+				throw 'colfer: {{.String}} length exceeds colferListMax';
+			var seg = [{{.Index}}];
+			encodeVarint(seg, a.length);
+			segs.push(seg);
+			for (var i = 0; i < a.length; i++) {
+				var v = a[i];
+				if (v == null) {
+					v = new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}();
+					a[i] = v;
+				}
+				segs.push(v.marshal());
+			};
+		}
+{{else}}
+		if (this.{{.NameNative}}) {
+			segs.push([{{.Index}}]);
+			segs.push(this.{{.NameNative}}.marshal());
+		}
+{{end}}{{else if eq .Type "any"}}
+		if (this.{{.NameNative}}) {
+			var name = encodeUTF8(this.{{.NameNative}}.colferType);
+			var seg = [{{.Index}}];
+			encodeVarint(seg, name.length);
+			segs.push(seg);
+			segs.push(name);
+			segs.push(this.{{.NameNative}}.marshal());
+		}
+{{else}}{{template "marshal-field" .}}{{end}}{{end}}
+		var size = 1;
+		segs.forEach(function(seg) {
+			size += seg.length;
+		});
+		if (size > colferSizeMax)
+			throw 'colfer: {{.String}} serial size ' + size + ' exceeds ' + colferListMax + ' bytes';
+
+		var bytes = new Uint8Array(size);
+		var i = 0;
+		segs.forEach(function(seg) {
+			bytes.set(seg, i);
+			i += seg.length;
+		});
+		bytes[i] = 127;
+		return bytes;
+	}`
+
+// ecmaMarshalField has the field serialization for all but the data structures.
+const ecmaMarshalField = `{{if eq .Type "bool"}}
 		if (this.{{.NameNative}})
 			segs.push([{{.Index}}]);
 {{else if eq .Type "uint8"}}
@@ -282,14 +572,13 @@ const ecmaMarshal = `
 			segs.push(seg);
 
 			var bytes = new Uint8Array(this.{{.NameNative}}.length * 4);
-			segs.push(bytes);
-
 			var view = new DataView(bytes.buffer);
 			this.{{.NameNative}}.forEach(function(f, i) {
 				if (f > 3.4028234663852886E38 || f < -3.4028234663852886E38)
 					throw 'colfer: {{.String}}[' + i + '] exceeds 32-bit range';
 				view.setFloat32(i * 4, f);
 			});
+			segs.push(bytes);
 		}
  {{- else}}
 		if (this.{{.NameNative}} || Number.isNaN(this.{{.NameNative}})) {
@@ -311,12 +600,11 @@ const ecmaMarshal = `
 			segs.push(seg);
 
 			var bytes = new Uint8Array(this.{{.NameNative}}.length * 8);
-			segs.push(bytes);
-
 			var view = new DataView(bytes.buffer);
 			this.{{.NameNative}}.forEach(function(f, i) {
 				view.setFloat64(i * 8, f);
 			});
+			segs.push(bytes);
 		}
  {{- else}}
 		if (this.{{.NameNative}} || Number.isNaN(this.{{.NameNative}})) {
@@ -430,7 +718,23 @@ const ecmaMarshal = `
 			segs.push(this.{{.NameNative}});
 		}
  {{- end}}
-{{else if .TypeList}}
+{{end}}`
+
+const ecmaHash = `
+	// Feeds the serial into h, without materializing the serial as a whole.
+	// Parameter h is any object with an update method for Uint8Array, such as
+	// a Node.js crypto.Hash. Equal values produce the same input for h in each
+	// of the supported languages. The return is the serial size.
+{{- range .Fields}}{{if .TypeList}}{{if eq .Type "float32" "float64"}}{{else}}
+	// All null entries in property {{.NameNative}} will be replaced with {{if eq .Type "text"}}an empty String{{else if eq .Type "binary"}}an empty Array{{else}}a new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}{{end}}.
+{{- end}}{{end}}{{end}}
+	this.{{.NameTitle}}.prototype.colferHash = function(h) {
+		var size = 1;
+		var segs = {push: function(seg) {
+			h.update(seg instanceof Uint8Array ? seg : new Uint8Array(seg));
+			size += seg.length;
+		}};
+{{range .Fields}}{{if and .TypeRef .TypeList}}
 		if (this.{{.NameNative}} && this.{{.NameNative}}.length) {
 			var a = this.{{.NameNative}};
 			if (a.length > colferListMax)
@@ -444,35 +748,46 @@ const ecmaMarshal = `
 					v = new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}();
 					a[i] = v;
 				}
-				segs.push(v.marshal());
-			};
+				size += v.colferHash(h);
+			}
 		}
-{{else}}
+{{else if .TypeRef}}
 		if (this.{{.NameNative}}) {
 			segs.push([{{.Index}}]);
-			segs.push(this.{{.NameNative}}.marshal());
+			size += this.{{.NameNative}}.colferHash(h);
 		}
-{{end}}{{end}}
-		var size = 1;
-		segs.forEach(function(seg) {
-			size += seg.length;
-		});
+{{else if eq .Type "any"}}
+		if (this.{{.NameNative}}) {
+			var name = encodeUTF8(this.{{.NameNative}}.colferType);
+			var seg = [{{.Index}}];
+			encodeVarint(seg, name.length);
+			segs.push(seg);
+			segs.push(name);
+			size += this.{{.NameNative}}.colferHash(h);
+		}
+{{else}}{{template "marshal-field" .}}{{end}}{{end}}
+		h.update(new Uint8Array([127]));
 		if (size > colferSizeMax)
-			throw 'colfer: {{.String}} serial size ' + size + ' exceeds ' + colferListMax + ' bytes';
-
-		var bytes = new Uint8Array(size);
-		var i = 0;
-		segs.forEach(function(seg) {
-			bytes.set(seg, i);
-			i += seg.length;
-		});
-		bytes[i] = 127;
-		return bytes;
+			throw 'colfer: {{.String}} serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+		return size;
 	}`
 
 const ecmaUnmarshal = `
 	// Deserializes the object from an Uint8Array and returns the number of bytes read.
-	this.{{.NameTitle}}.prototype.unmarshal = function(data) {
+	// The optional depth is the number of data structure levels, including this object.
+	// The optional strict flag rejects any serial which differs from the marshal output
+	// for the same data, with a non-canonical encoding error.
+	// The optional validate flag checks the schema rules of each data structure.
+	// The optional budget limits the allocation of field values, including those of
+	// nested data structures, with its bytes property as the number of bytes which
+	// may still be allocated. Each allocation is deducted before it happens. Text
+	// and binaries count their size in bytes, list elements count 8 bytes each (4
+	// for float32), and a nested data structure counts 8 bytes per field.
+	this.{{.NameTitle}}.prototype.unmarshal = function(data, depth, strict, validate, budget) {
+		depth = depth || 1;
+		if (depth > colferDepthMax)
+			throw 'colfer: {{.String}} exceeds nesting depth ' + colferDepthMax;
+		if (depth > 1) charge(budget, '{{.String}}', {{len .Fields}} * 8);
 		if (!data || ! data.length) throw EOF;
 		var header = data[0];
 		var i = 1;
@@ -490,6 +805,7 @@ const ecmaUnmarshal = `
 				result += (c & 127) * Math.pow(128, pos);
 				++pos;
 				if (c < 128) {
+					if (strict && c == 0 && pos > 1) throw nonCanonical(i + pos - 1);
 					i += pos;
 					if (result > Number.MAX_SAFE_INTEGER) break;
 					return result;
@@ -507,34 +823,42 @@ const ecmaUnmarshal = `
 		if (header == {{.Index}}) {
 			if (i + 1 >= data.length) throw EOF;
 			this.{{.NameNative}} = data[i++];
+			if (strict && this.{{.NameNative}} == 0) throw nonCanonical(i - 2);
 			header = data[i++];
 		}
 {{else if eq .Type "uint16"}}
 		if (header == {{.Index}}) {
 			if (i + 2 >= data.length) throw EOF;
 			this.{{.NameNative}} = (data[i++] << 8) | data[i++];
+			if (strict && this.{{.NameNative}} < 256) throw nonCanonical(i - 3);
 			header = data[i++];
 		} else if (header == ({{.Index}} | 128)) {
 			if (i + 1 >= data.length) throw EOF;
 			this.{{.NameNative}} = data[i++];
+			if (strict && this.{{.NameNative}} == 0) throw nonCanonical(i - 2);
 			header = data[i++];
 		}
 {{else if eq .Type "uint32"}}
 		if (header == {{.Index}}) {
+			var at = i - 1;
 			var x = readVarint();
 			if (x < 0) throw 'colfer: {{.Struct.Pkg.NameNative}}/{{.Struct.NameTitle}} field {{.NameNative}} exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && (x == 0 || x >= 2097152)) throw nonCanonical(at);
 			this.{{.NameNative}} = x;
 			readHeader();
 		} else if (header == ({{.Index}} | 128)) {
 			if (i + 4 > data.length) throw EOF;
 			this.{{.NameNative}} = view.getUint32(i);
+			if (strict && this.{{.NameNative}} < 2097152) throw nonCanonical(i - 1);
 			i += 4;
 			readHeader();
 		}
 {{else if eq .Type "uint64"}}
 		if (header == {{.Index}}) {
+			var at = i - 1;
 			var x = readVarint();
 			if (x < 0) throw 'colfer: {{.Struct.Pkg.NameNative}}/{{.Struct.NameTitle}} field {{.NameNative}} exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && (x == 0 || x >= 562949953421312)) throw nonCanonical(at);
 			this.{{.NameNative}} = x;
 			readHeader();
 		} else if (header == ({{.Index}} | 128)) {
@@ -543,31 +867,40 @@ const ecmaUnmarshal = `
 			x += view.getUint32(i + 4);
 			if (x > Number.MAX_SAFE_INTEGER)
 				throw 'colfer: {{.Struct.Pkg.NameNative}}/{{.Struct.NameTitle}} field {{.NameNative}} exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && x < 562949953421312) throw nonCanonical(i - 1);
 			this.{{.NameNative}} = x;
 			i += 8;
 			readHeader();
 		}
 {{else if eq .Type "int32"}}
 		if (header == {{.Index}}) {
+			var at = i - 1;
 			var x = readVarint();
 			if (x < 0) throw 'colfer: {{.Struct.Pkg.NameNative}}/{{.Struct.NameTitle}} field {{.NameNative}} exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && (x == 0 || x > 2147483647)) throw nonCanonical(at);
 			this.{{.NameNative}} = x;
 			readHeader();
 		} else if (header == ({{.Index}} | 128)) {
+			var at = i - 1;
 			var x = readVarint();
 			if (x < 0) throw 'colfer: {{.Struct.Pkg.NameNative}}/{{.Struct.NameTitle}} field {{.NameNative}} exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && (x == 0 || x > 2147483648)) throw nonCanonical(at);
 			this.{{.NameNative}} = -1 * x;
 			readHeader();
 		}
 {{else if eq .Type "int64"}}
 		if (header == {{.Index}}) {
+			var at = i - 1;
 			var x = readVarint();
 			if (x < 0) throw 'colfer: {{.Struct.Pkg.NameNative}}/{{.Struct.NameTitle}} field {{.NameNative}} exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && x == 0) throw nonCanonical(at);
 			this.{{.NameNative}} = x;
 			readHeader();
 		} else if (header == ({{.Index}} | 128)) {
+			var at = i - 1;
 			var x = readVarint();
 			if (x < 0) throw 'colfer: {{.Struct.Pkg.NameNative}}/{{.Struct.NameTitle}} field {{.NameNative}} exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && x == 0) throw nonCanonical(at);
 			this.{{.NameNative}} = -1 * x;
 			readHeader();
 		}
@@ -576,10 +909,12 @@ const ecmaUnmarshal = `
  {{- if .TypeList}}
 			var l = readVarint();
 			if (l < 0) throw 'colfer: {{.String}} length exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && l == 0) throw nonCanonical(i - 2);
 			if (l > colferListMax)
 				throw 'colfer: {{.String}} length ' + l + ' exceeds ' + colferListMax + ' elements';
 			if (i + l * 4 > data.length) throw EOF;
 
+			charge(budget, '{{.String}}', l * 4);
 			this.{{.NameNative}} = new Float32Array(l);
 			for (var n = 0; n < l; ++n) {
 				this.{{.NameNative}}[n] = view.getFloat32(i);
@@ -588,6 +923,7 @@ const ecmaUnmarshal = `
  {{- else}}
 			if (i + 4 > data.length) throw EOF;
 			this.{{.NameNative}} = view.getFloat32(i);
+			if (strict && this.{{.NameNative}} == 0) throw nonCanonical(i - 1);
 			i += 4;
  {{- end}}
 			readHeader();
@@ -597,10 +933,12 @@ const ecmaUnmarshal = `
  {{- if .TypeList}}
 			var l = readVarint();
 			if (l < 0) throw 'colfer: {{.String}} length exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && l == 0) throw nonCanonical(i - 2);
 			if (l > colferListMax)
 				throw 'colfer: {{.String}} length ' + l + ' exceeds ' + colferListMax + ' elements';
 			if (i + l * 8 > data.length) throw EOF;
 
+			charge(budget, '{{.String}}', l * 8);
 			this.{{.NameNative}} = new Float64Array(l);
 			for (var n = 0; n < l; ++n) {
 				this.{{.NameNative}}[n] = view.getFloat64(i);
@@ -609,6 +947,7 @@ const ecmaUnmarshal = `
  {{- else}}
 			if (i + 8 > data.length) throw EOF;
 			this.{{.NameNative}} = view.getFloat64(i);
+			if (strict && this.{{.NameNative}} == 0) throw nonCanonical(i - 1);
 			i += 8;
  {{- end}}
 			readHeader();
@@ -619,6 +958,7 @@ const ecmaUnmarshal = `
 
 			var ms = view.getUint32(i) * 1E3;
 			var ns = view.getUint32(i + 4);
+			if (strict && ns >= 1E9) throw nonCanonical(i + 4);
 			ms += Math.floor(ns / 1E6);
 			this.{{.NameNative}} = new Date(ms);
 			this.{{.NameNative}}_ns = ns % 1E6;
@@ -630,6 +970,10 @@ const ecmaUnmarshal = `
 
 			var ms = decodeInt64(data, i) * 1E3;
 			var ns = view.getUint32(i + 8);
+			if (strict) {
+				if (ms >= 0 && ms < 4294967296E3) throw nonCanonical(i - 1);
+				if (ns >= 1E9) throw nonCanonical(i + 8);
+			}
 			ms += Math.floor(ns / 1E6);
 			if (ms < -864E13 || ms > 864E13)
 				throw 'colfer: {{.Struct.Pkg.NameNative}}/{{.Struct.NameNative}} field {{.NameNative}} exceeds ECMA Date range';
@@ -644,9 +988,13 @@ const ecmaUnmarshal = `
  {{- if .TypeList}}
 			var l = readVarint();
 			if (l < 0) throw 'colfer: {{.String}} length exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && l == 0) throw nonCanonical(i - 2);
 			if (l > colferListMax)
 				throw 'colfer: {{.String}} length ' + l + ' exceeds ' + colferListMax + ' elements';
+			// each element takes at least one byte
+			if (i + l > data.length) throw EOF;
 
+			charge(budget, '{{.String}}', l * 8);
 			this.{{.NameNative}} = new Array(l);
 			for (var n = 0; n < l; ++n) {
 				var size = readVarint();
@@ -658,10 +1006,17 @@ const ecmaUnmarshal = `
 				var start = i;
 				i += size;
 				if (i > data.length) throw EOF;
-				this.{{.NameNative}}[n] = decodeUTF8(data.subarray(start, i));
+				var utf = data.subarray(start, i);
+				if (strict) {
+					var valid = validUTF8Len(utf);
+					if (valid < size) throw nonCanonical(start + valid);
+				}
+				charge(budget, '{{.String}}', size);
+				this.{{.NameNative}}[n] = decodeUTF8(utf);
 			}
  {{- else}}
 			var size = readVarint();
+			if (strict && size == 0) throw nonCanonical(i - 2);
 			if (size < 0)
 				throw 'colfer: {{.String}} size exceeds Number.MAX_SAFE_INTEGER';
 			else if (size > colferSizeMax)
@@ -670,7 +1025,13 @@ const ecmaUnmarshal = `
 			var start = i;
 			i += size;
 			if (i > data.length) throw EOF;
-			this.{{.NameNative}} = decodeUTF8(data.subarray(start, i));
+			var utf = data.subarray(start, i);
+			if (strict) {
+				var valid = validUTF8Len(utf);
+				if (valid < size) throw nonCanonical(start + valid);
+			}
+			charge(budget, '{{.String}}', size);
+			this.{{.NameNative}} = decodeUTF8(utf);
  {{- end}}
 			readHeader();
 		}
@@ -679,9 +1040,13 @@ const ecmaUnmarshal = `
  {{- if .TypeList}}
 			var l = readVarint();
 			if (l < 0) throw 'colfer: {{.String}} length exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && l == 0) throw nonCanonical(i - 2);
 			if (l > colferListMax)
 				throw 'colfer: {{.String}} length ' + l + ' exceeds ' + colferListMax + ' elements';
+			// each element takes at least one byte
+			if (i + l > data.length) throw EOF;
 
+			charge(budget, '{{.String}}', l * 8);
 			this.{{.NameNative}} = new Array(l);
 			for (var n = 0; n < l; ++n) {
 				var size = readVarint();
@@ -693,10 +1058,12 @@ const ecmaUnmarshal = `
 				var start = i;
 				i += size;
 				if (i > data.length) throw EOF;
+				charge(budget, '{{.String}}', size);
 				this.{{.NameNative}}[n] = data.slice(start, i);
 			}
  {{- else}}
 			var size = readVarint();
+			if (strict && size == 0) throw nonCanonical(i - 2);
 			if (size < 0)
 				throw 'colfer: {{.String}} size exceeds Number.MAX_SAFE_INTEGER';
 			else if (size > colferSizeMax)
@@ -705,20 +1072,51 @@ const ecmaUnmarshal = `
 			var start = i;
 			i += size;
 			if (i > data.length) throw EOF;
+  {{- if .TypeColfer}}
+			if (size != 0 && data[i - 1] != 127)
+				throw 'colfer: unknown header at byte ' + (i - 1);
+  {{- end}}
+			charge(budget, '{{.String}}', size);
 			this.{{.NameNative}} = data.slice(start, i);
  {{- end}}
 			readHeader();
 		}
+{{else if eq .Type "any"}}
+		if (header == {{.Index}}) {
+			var size = readVarint();
+			if (size < 0)
+				throw 'colfer: {{.String}} type size exceeds Number.MAX_SAFE_INTEGER';
+			else if (size > colferSizeMax)
+				throw 'colfer: {{.String}} type size ' + size + ' exceeds ' + colferSizeMax + ' UTF-8 bytes';
+
+			var start = i;
+			i += size;
+			if (i > data.length) throw EOF;
+			var o = null;
+			try {
+				o = newColferAny(decodeUTF8(data.subarray(start, i)));
+			} catch (err) {
+				// malformed names are not registered
+			}
+			if (! o) throw 'colfer: unknown header at byte ' + start;
+			i += unmarshalNested(o, data, i, depth + 1, strict, validate, budget);
+			this.{{.NameNative}} = o;
+			readHeader();
+		}
 {{else if .TypeList}}
 		if (header == {{.Index}}) {
 			var l = readVarint();
 			if (l < 0) throw 'colfer: {{.String}} length exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && l == 0) throw nonCanonical(i - 2);
 			if (l > colferListMax)
 				throw 'colfer: {{.String}} length ' + l + ' exceeds ' + colferListMax + ' elements';
+			// each element takes at least one byte
+			if (i + l > data.length) throw EOF;
 
+			charge(budget, '{{.String}}', l * 8);
 			for (var n = 0; n < l; ++n) {
 				var o = new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}();
-				i += o.unmarshal(data.subarray(i));
+				i += unmarshalNested(o, data, i, depth + 1, strict, validate, budget);
 				this.{{.NameNative}}[n] = o;
 			}
 			readHeader();
@@ -726,7 +1124,7 @@ const ecmaUnmarshal = `
 {{else}}
 		if (header == {{.Index}}) {
 			var o = new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}();
-			i += o.unmarshal(data.subarray(i));
+			i += unmarshalNested(o, data, i, depth + 1, strict, validate, budget);
 			this.{{.NameNative}} = o;
 			readHeader();
 		}
@@ -734,5 +1132,190 @@ const ecmaUnmarshal = `
 		if (header != 127) throw 'colfer: unknown header at byte ' + (i - 1);
 		if (i > colferSizeMax)
 			throw 'colfer: {{.String}} serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+{{- if .HasRules}}
+		if (validate) validate{{.NameTitle}}(this);
+{{- end}}
 		return i;
 	}`
+
+const ecmaJSON = `
+	// Returns the canonical JSON mapping with the schema field names as keys.
+	// JSON.stringify uses this method implicitly. The sensitive fields are
+	// masked when redact is true, conform toRedactedJSON.
+	this.{{.NameTitle}}.prototype.toJSON = function(key, redact) {
+		var o = {};
+{{- range .Fields}}
+{{- if .TypeList}}
+		if (this.{{.NameNative}} && this.{{.NameNative}}.length)
+ {{- if eq .Type "float32" "float64"}}
+			o['{{.Name}}'] = Array.prototype.map.call(this.{{.NameNative}}, formatJSONFloat);
+ {{- else if eq .Type "text"}}
+			o['{{.Name}}'] = this.{{.NameNative}}.map(function(s) { return s || ''; });
+ {{- else if eq .Type "binary"}}
+			o['{{.Name}}'] = this.{{.NameNative}}.map(encodeBase64);
+ {{- else}}
+			o['{{.Name}}'] = this.{{.NameNative}}.map(function(v) {
+				return v ? v.toJSON('', redact) : {};
+			});
+ {{- end}}
+{{- else if eq .Type "float32" "float64"}}
+		if (this.{{.NameNative}} || Number.isNaN(this.{{.NameNative}}))
+			o['{{.Name}}'] = formatJSONFloat(this.{{.NameNative}});
+{{- else if eq .Type "timestamp"}}
+		if ((this.{{.NameNative}} && this.{{.NameNative}}.getTime()) || this.{{.NameNative}}_ns)
+			o['{{.Name}}'] = formatRFC3339(this.{{.NameNative}}, this.{{.NameNative}}_ns, '{{.String}}');
+{{- else if eq .Type "binary"}}
+		if (this.{{.NameNative}} && this.{{.NameNative}}.length)
+			o['{{.Name}}'] = encodeBase64(this.{{.NameNative}});
+{{- else if .TypeRef}}
+		if (this.{{.NameNative}})
+			o['{{.Name}}'] = this.{{.NameNative}}.toJSON('', redact);
+{{- else if eq .Type "any"}}
+		if (this.{{.NameNative}})
+			o['{{.Name}}'] = {type: this.{{.NameNative}}.colferType, value: this.{{.NameNative}}.toJSON('', redact)};
+{{- else}}
+		if (this.{{.NameNative}})
+			o['{{.Name}}'] = this.{{.NameNative}};
+{{- end}}
+{{- end}}
+{{- range .Fields}}{{if .Sensitive}}
+		if (redact && '{{.Name}}' in o) o['{{.Name}}'] = '<redacted>';
+{{- end}}{{end}}
+		return o;
+	}
+
+	// Returns the JSON mapping with the sensitive fields masked, including the
+	// ones of nested data structures, which makes it safe for logging.
+	this.{{.NameTitle}}.prototype.toRedactedJSON = function() {
+		return this.toJSON('', true);
+	}
+
+	// Returns a human-readable form for debugging, with the sensitive fields masked.
+	this.{{.NameTitle}}.prototype.toString = function() {
+		return '{{.String}}' + JSON.stringify(this.toRedactedJSON());
+	}
+
+	// Parses the canonical JSON mapping, either as text or as a parsed value.
+	this.{{.NameTitle}}.fromJSON = function(json) {
+		if (typeof json === 'string') json = JSON.parse(json);
+		if (json == null) return null;
+		if (typeof json !== 'object' || Array.isArray(json))
+			throw 'colfer: JSON for struct {{.String}}: got ' + (Array.isArray(json) ? 'array' : typeof json) + ', want object';
+
+		var o = new {{.Pkg.NameNative}}.{{.NameTitle}}();
+		for (var name in json) {
+			var v = json[name];
+			switch (name) {
+{{- range .Fields}}
+			case '{{.Name}}':
+{{- if .TypeList}}
+				v = checkJSONList(v, '{{.String}}');
+ {{- if eq .Type "float32"}}
+				o.{{.NameNative}} = new Float32Array(v.map(function(f) { return parseJSONFloat(f, '{{.String}}'); }));
+ {{- else if eq .Type "float64"}}
+				o.{{.NameNative}} = new Float64Array(v.map(function(f) { return parseJSONFloat(f, '{{.String}}'); }));
+ {{- else if eq .Type "text"}}
+				o.{{.NameNative}} = v.map(function(s) { return parseJSONText(s, '{{.String}}'); });
+ {{- else if eq .Type "binary"}}
+				o.{{.NameNative}} = v.map(function(b) { return decodeBase64(b, '{{.String}}'); });
+ {{- else}}
+				o.{{.NameNative}} = v.map(function(x) {
+					return x == null ? new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}() : {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}.fromJSON(x);
+				});
+ {{- end}}
+{{- else if eq .Type "bool"}}
+				o.{{.NameNative}} = parseJSONBool(v, '{{.String}}');
+{{- else if eq .Type "uint8"}}
+				o.{{.NameNative}} = parseJSONInt(v, '{{.String}}', 0, 255);
+{{- else if eq .Type "uint16"}}
+				o.{{.NameNative}} = parseJSONInt(v, '{{.String}}', 0, 65535);
+{{- else if eq .Type "uint32"}}
+				o.{{.NameNative}} = parseJSONInt(v, '{{.String}}', 0, 4294967295);
+{{- else if eq .Type "uint64"}}
+				o.{{.NameNative}} = parseJSONInt(v, '{{.String}}', 0, Number.MAX_SAFE_INTEGER);
+{{- else if eq .Type "int32"}}
+				o.{{.NameNative}} = parseJSONInt(v, '{{.String}}', -2147483648, 2147483647);
+{{- else if eq .Type "int64"}}
+				o.{{.NameNative}} = parseJSONInt(v, '{{.String}}', Number.MIN_SAFE_INTEGER, Number.MAX_SAFE_INTEGER);
+{{- else if eq .Type "float32" "float64"}}
+				o.{{.NameNative}} = parseJSONFloat(v, '{{.String}}');
+{{- else if eq .Type "timestamp"}}
+				if (v != null) {
+					var t = parseRFC3339(v, '{{.String}}');
+					o.{{.NameNative}} = t[0];
+					o.{{.NameNative}}_ns = t[1];
+				}
+{{- else if eq .Type "text"}}
+				o.{{.NameNative}} = parseJSONText(v, '{{.String}}');
+{{- else if eq .Type "binary"}}
+				o.{{.NameNative}} = decodeBase64(v, '{{.String}}');
+{{- else if eq .Type "any"}}
+				o.{{.NameNative}} = parseJSONAny(v, '{{.String}}');
+{{- else}}
+				o.{{.NameNative}} = {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}.fromJSON(v);
+{{- end}}
+				break;
+{{- end}}
+			default:
+				throw 'colfer: JSON member ' + JSON.stringify(name) + ' not in struct {{.String}}';
+			}
+		}
+		return o;
+	}`
+
+const ecmaValidate = `
+	// Checks each property against the schema rules, including the ones of nested
+	// data structures, and throws an error on the first violation.
+	this.{{.NameTitle}}.prototype.validate = function() {
+{{- if .HasRules}}
+		validate{{.NameTitle}}(this);
+{{- end}}
+{{- range .Fields}}
+{{- if and .TypeRef .TypeList}}
+		(this.{{.NameNative}} || []).forEach(function(v) {
+			(v || new {{.TypeRef.Pkg.NameNative}}.{{.TypeRef.NameTitle}}()).validate();
+		});
+{{- else if or .TypeRef (eq .Type "any")}}
+		if (this.{{.NameNative}}) this.{{.NameNative}}.validate();
+{{- end}}
+{{- end}}
+	}
+{{- if .HasRules}}
+{{range .Fields}}{{if .Pattern}}
+	var {{.Struct.NameTitle}}{{.NameTitle}}Pattern = new RegExp({{quote (pattern .Pattern)}}, 'u');
+{{end}}{{end}}
+	// Checks each property of o against the schema rules, without the nested data structures.
+	var validate{{.NameTitle}} = function(o) {
+{{- range .Fields}}
+{{- if .Required}}
+		if ({{if eq .Type "timestamp"}}(! o.{{.NameNative}} || ! o.{{.NameNative}}.getTime()) && ! o.{{.NameNative}}_ns
+ {{- else if or .TypeList (eq .Type "binary")}}! o.{{.NameNative}} || ! o.{{.NameNative}}.length
+ {{- else if eq .Type "bool" "text" "any"}}! o.{{.NameNative}}
+ {{- else if .TypeRef}}! o.{{.NameNative}}
+ {{- else}}o.{{.NameNative}} == 0
+ {{- end}})
+			throw 'colfer: field {{.String}} breaks rule required';
+{{- end}}
+{{- if .Min}}
+		if (! (o.{{.NameNative}} >= {{.MinNative}}))
+			throw 'colfer: field {{.String}} breaks rule min {{.Min}}';
+{{- end}}
+{{- if .Max}}
+		if (! (o.{{.NameNative}} <= {{.MaxNative}}))
+			throw 'colfer: field {{.String}} breaks rule max {{.Max}}';
+{{- end}}
+{{- if .MinLen}}
+		if ({{if and (eq .Type "text") (not .TypeList)}}encodeUTF8(o.{{.NameNative}} || '').length{{else}}(o.{{.NameNative}} || []).length{{end}} < {{.MinLen}})
+			throw 'colfer: field {{.String}} breaks rule minlen {{.MinLen}}';
+{{- end}}
+{{- if .MaxLen}}
+		if ({{if and (eq .Type "text") (not .TypeList)}}encodeUTF8(o.{{.NameNative}} || '').length{{else}}(o.{{.NameNative}} || []).length{{end}} > {{.MaxLen}})
+			throw 'colfer: field {{.String}} breaks rule maxlen {{.MaxLen}}';
+{{- end}}
+{{- if .Pattern}}
+		if (! {{.Struct.NameTitle}}{{.NameTitle}}Pattern.test(o.{{.NameNative}} || ''))
+			throw {{quote (printf "colfer: field %s breaks rule pattern %s" .String .Pattern)}};
+{{- end}}
+{{- end}}
+	}
+{{- end}}`
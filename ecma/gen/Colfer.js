@@ -1,5 +1,5 @@
 // Code generated by colf(1); DO NOT EDIT.
-// The compiler used schema file test.colf for package gen.
+// The compiler used schema file any.colf, rules.colf and test.colf for package gen.
 
 // Package gen tests all field mapping options.
 var gen = new function() {
@@ -9,6 +9,8 @@ var gen = new function() {
 	var colferSizeMax = 16 * 1024 * 1024;
 	// The upper limit for the number of elements in a list.
 	var colferListMax = 64 * 1024;
+	// The upper limit for the number of nested data structure levels, including the root.
+	var colferDepthMax = 100;
 
 	// Constructor.
 	// O contains all supported data types.
@@ -55,6 +57,9 @@ var gen = new function() {
 		for (var p in init) this[p] = init[p];
 	}
 
+	// The qualified name for any fields.
+	this.O.prototype.colferType = 'gen.o';
+
 	// Serializes the object into an Uint8Array.
 	// All null entries in property os will be replaced with a new gen.O.
 	// All null entries in property ss will be replaced with an empty String.
@@ -291,14 +296,13 @@ var gen = new function() {
 			segs.push(seg);
 
 			var bytes = new Uint8Array(this.f32s.length * 4);
-			segs.push(bytes);
-
 			var view = new DataView(bytes.buffer);
 			this.f32s.forEach(function(f, i) {
 				if (f > 3.4028234663852886E38 || f < -3.4028234663852886E38)
 					throw 'colfer: gen.o.f32s[' + i + '] exceeds 32-bit range';
 				view.setFloat32(i * 4, f);
 			});
+			segs.push(bytes);
 		}
 
 		if (this.f64s && this.f64s.length) {
@@ -309,12 +313,11 @@ var gen = new function() {
 			segs.push(seg);
 
 			var bytes = new Uint8Array(this.f64s.length * 8);
-			segs.push(bytes);
-
 			var view = new DataView(bytes.buffer);
 			this.f64s.forEach(function(f, i) {
 				view.setFloat64(i * 8, f);
 			});
+			segs.push(bytes);
 		}
 
 		var size = 1;
@@ -334,162 +337,479 @@ var gen = new function() {
 		return bytes;
 	}
 
-	// Deserializes the object from an Uint8Array and returns the number of bytes read.
-	this.O.prototype.unmarshal = function(data) {
-		if (!data || ! data.length) throw EOF;
-		var header = data[0];
-		var i = 1;
-		var readHeader = function() {
-			if (i >= data.length) throw EOF;
-			header = data[i++];
-		}
+	// Feeds the serial into h, without materializing the serial as a whole.
+	// Parameter h is any object with an update method for Uint8Array, such as
+	// a Node.js crypto.Hash. Equal values produce the same input for h in each
+	// of the supported languages. The return is the serial size.
+	// All null entries in property os will be replaced with a new gen.O.
+	// All null entries in property ss will be replaced with an empty String.
+	// All null entries in property as will be replaced with an empty Array.
+	this.O.prototype.colferHash = function(h) {
+		var size = 1;
+		var segs = {push: function(seg) {
+			h.update(seg instanceof Uint8Array ? seg : new Uint8Array(seg));
+			size += seg.length;
+		}};
 
-		var view = new DataView(data.buffer);
+		if (this.b)
+			segs.push([0]);
 
-		var readVarint = function() {
-			var pos = 0, result = 0;
-			while (pos != 8) {
-				var c = data[i+pos];
-				result += (c & 127) * Math.pow(128, pos);
-				++pos;
-				if (c < 128) {
-					i += pos;
-					if (result > Number.MAX_SAFE_INTEGER) break;
-					return result;
-				}
-				if (pos == data.length) throw EOF;
+		if (this.u32) {
+			if (this.u32 > 4294967295 || this.u32 < 0)
+				throw 'colfer: gen/O field u32 out of reach: ' + this.u32;
+			if (this.u32 < 0x200000) {
+				var seg = [1];
+				encodeVarint(seg, this.u32);
+				segs.push(seg);
+			} else {
+				var bytes = new Uint8Array(5);
+				bytes[0] = 1 | 128;
+				var view = new DataView(bytes.buffer);
+				view.setUint32(1, this.u32);
+				segs.push(bytes)
 			}
-			return -1;
-		}
-
-		if (header == 0) {
-			this.b = true;
-			readHeader();
-		}
-
-		if (header == 1) {
-			var x = readVarint();
-			if (x < 0) throw 'colfer: gen/O field u32 exceeds Number.MAX_SAFE_INTEGER';
-			this.u32 = x;
-			readHeader();
-		} else if (header == (1 | 128)) {
-			if (i + 4 > data.length) throw EOF;
-			this.u32 = view.getUint32(i);
-			i += 4;
-			readHeader();
 		}
 
-		if (header == 2) {
-			var x = readVarint();
-			if (x < 0) throw 'colfer: gen/O field u64 exceeds Number.MAX_SAFE_INTEGER';
-			this.u64 = x;
-			readHeader();
-		} else if (header == (2 | 128)) {
-			if (i + 8 > data.length) throw EOF;
-			var x = view.getUint32(i) * 0x100000000;
-			x += view.getUint32(i + 4);
-			if (x > Number.MAX_SAFE_INTEGER)
+		if (this.u64) {
+			if (this.u64 < 0)
+				throw 'colfer: gen/O field u64 out of reach: ' + this.u64;
+			if (this.u64 > Number.MAX_SAFE_INTEGER)
 				throw 'colfer: gen/O field u64 exceeds Number.MAX_SAFE_INTEGER';
-			this.u64 = x;
-			i += 8;
-			readHeader();
+			if (this.u64 < 0x2000000000000) {
+				var seg = [2];
+				encodeVarint(seg, this.u64);
+				segs.push(seg);
+			} else {
+				var bytes = new Uint8Array(9);
+				bytes[0] = 2 | 128;
+				var view = new DataView(bytes.buffer);
+				view.setUint32(1, this.u64 / 0x100000000);
+				view.setUint32(5, this.u64 % 0x100000000);
+				segs.push(bytes)
+			}
 		}
 
-		if (header == 3) {
-			var x = readVarint();
-			if (x < 0) throw 'colfer: gen/O field i32 exceeds Number.MAX_SAFE_INTEGER';
-			this.i32 = x;
-			readHeader();
-		} else if (header == (3 | 128)) {
-			var x = readVarint();
-			if (x < 0) throw 'colfer: gen/O field i32 exceeds Number.MAX_SAFE_INTEGER';
-			this.i32 = -1 * x;
-			readHeader();
+		if (this.i32) {
+			var seg = [3];
+			if (this.i32 < 0) {
+				seg[0] |= 128;
+				if (this.i32 < -2147483648)
+					throw 'colfer: gen/O field i32 exceeds 32-bit range';
+				encodeVarint(seg, -this.i32);
+			} else {
+				if (this.i32 > 2147483647)
+					throw 'colfer: gen/O field i32 exceeds 32-bit range';
+				encodeVarint(seg, this.i32);
+			}
+			segs.push(seg);
 		}
 
-		if (header == 4) {
-			var x = readVarint();
-			if (x < 0) throw 'colfer: gen/O field i64 exceeds Number.MAX_SAFE_INTEGER';
-			this.i64 = x;
-			readHeader();
-		} else if (header == (4 | 128)) {
-			var x = readVarint();
-			if (x < 0) throw 'colfer: gen/O field i64 exceeds Number.MAX_SAFE_INTEGER';
-			this.i64 = -1 * x;
-			readHeader();
+		if (this.i64) {
+			var seg = [4];
+			if (this.i64 < 0) {
+				seg[0] |= 128;
+				if (this.i64 < Number.MIN_SAFE_INTEGER)
+					throw 'colfer: gen/O field i64 exceeds Number.MIN_SAFE_INTEGER';
+				encodeVarint(seg, -this.i64);
+			} else {
+				if (this.i64 > Number.MAX_SAFE_INTEGER)
+					throw 'colfer: gen/O field i64 exceeds Number.MAX_SAFE_INTEGER';
+				encodeVarint(seg, this.i64);
+			}
+			segs.push(seg);
 		}
 
-		if (header == 5) {
-			if (i + 4 > data.length) throw EOF;
-			this.f32 = view.getFloat32(i);
-			i += 4;
-			readHeader();
+		if (this.f32 || Number.isNaN(this.f32)) {
+			if (this.f32 > 3.4028234663852886E38 || this.f32 < -3.4028234663852886E38)
+				throw 'colfer: gen/O field f32 exceeds 32-bit range';
+			var bytes = new Uint8Array(5);
+			bytes[0] = 5;
+			new DataView(bytes.buffer).setFloat32(1, this.f32);
+			segs.push(bytes);
 		}
 
-		if (header == 6) {
-			if (i + 8 > data.length) throw EOF;
-			this.f64 = view.getFloat64(i);
-			i += 8;
-			readHeader();
+		if (this.f64 || Number.isNaN(this.f64)) {
+			var bytes = new Uint8Array(9);
+			bytes[0] = 6;
+			new DataView(bytes.buffer).setFloat64(1, this.f64);
+			segs.push(bytes);
 		}
 
-		if (header == 7) {
-			if (i + 8 > data.length) throw EOF;
-
-			var ms = view.getUint32(i) * 1E3;
-			var ns = view.getUint32(i + 4);
-			ms += Math.floor(ns / 1E6);
-			this.t = new Date(ms);
-			this.t_ns = ns % 1E6;
-
-			i += 8;
-			readHeader();
-		} else if (header == (7 | 128)) {
-			if (i + 12 > data.length) throw EOF;
+		if ((this.t && this.t.getTime()) || this.t_ns) {
+			var ms = this.t ? this.t.getTime() : 0;
+			var s = ms / 1E3;
 
-			var ms = decodeInt64(data, i) * 1E3;
-			var ns = view.getUint32(i + 8);
-			ms += Math.floor(ns / 1E6);
-			if (ms < -864E13 || ms > 864E13)
-				throw 'colfer: gen/ field t exceeds ECMA Date range';
-			this.t = new Date(ms);
-			this.t_ns = ns % 1E6;
+			var ns = this.t_ns || 0;
+			if (ns < 0 || ns >= 1E6)
+				throw 'colfer: gen/O field t_ns not in range (0, 1ms>';
+			var msf = ms % 1E3;
+			if (ms < 0 && msf) {
+				s--
+				msf = 1E3 + msf;
+			}
+			ns += msf * 1E6;
 
-			i += 12;
-			readHeader();
+			if (s > 0xffffffff || s < 0) {
+				var bytes = new Uint8Array(13);
+				bytes[0] = 7 | 128;
+				var view = new DataView(bytes.buffer);
+				view.setUint32(9, ns);
+				if (s > 0) {
+					view.setUint32(1, s / 0x100000000);
+					view.setUint32(5, s);
+				} else {
+					s = -s;
+					view.setUint32(1, s / 0x100000000);
+					view.setUint32(5, s);
+					var carry = 1;
+					for (var j = 8; j > 0; j--) {
+						var b = (bytes[j] ^ 255) + carry;
+						bytes[j] = b & 255;
+						carry = b >> 8;
+					}
+				}
+				segs.push(bytes);
+			} else {
+				var bytes = new Uint8Array(9);
+				bytes[0] = 7;
+				var view = new DataView(bytes.buffer);
+				view.setUint32(1, s);
+				view.setUint32(5, ns);
+				segs.push(bytes);
+			}
 		}
 
-		if (header == 8) {
-			var size = readVarint();
-			if (size < 0)
-				throw 'colfer: gen.o.s size exceeds Number.MAX_SAFE_INTEGER';
-			else if (size > colferSizeMax)
-				throw 'colfer: gen.o.s size ' + size + ' exceeds ' + colferSizeMax + ' UTF-8 bytes';
+		if (this.s) {
+			var utf = encodeUTF8(this.s);
+			var seg = [8];
+			encodeVarint(seg, utf.length);
+			segs.push(seg);
+			segs.push(utf)
+		}
 
-			var start = i;
-			i += size;
-			if (i > data.length) throw EOF;
-			this.s = decodeUTF8(data.subarray(start, i));
-			readHeader();
+		if (this.a && this.a.length) {
+			var seg = [9];
+			encodeVarint(seg, this.a.length);
+			segs.push(seg);
+			segs.push(this.a);
 		}
 
-		if (header == 9) {
-			var size = readVarint();
-			if (size < 0)
-				throw 'colfer: gen.o.a size exceeds Number.MAX_SAFE_INTEGER';
-			else if (size > colferSizeMax)
-				throw 'colfer: gen.o.a size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+		if (this.o) {
+			segs.push([10]);
+			size += this.o.colferHash(h);
+		}
+
+		if (this.os && this.os.length) {
+			var a = this.os;
+			if (a.length > colferListMax)
+				throw 'colfer: gen.o.os length exceeds colferListMax';
+			var seg = [11];
+			encodeVarint(seg, a.length);
+			segs.push(seg);
+			for (var i = 0; i < a.length; i++) {
+				var v = a[i];
+				if (v == null) {
+					v = new gen.O();
+					a[i] = v;
+				}
+				size += v.colferHash(h);
+			}
+		}
+
+		if (this.ss && this.ss.length) {
+			var a = this.ss;
+			if (a.length > colferListMax)
+				throw 'colfer: gen.o.ss length exceeds colferListMax';
+			var seg = [12];
+			encodeVarint(seg, a.length);
+			segs.push(seg);
+			for (var i = 0; i < a.length; i++) {
+				var s = a[i];
+				if (s == null) {
+					s = "";
+					a[i] = s;
+				}
+				var utf = encodeUTF8(s);
+				seg = [];
+				encodeVarint(seg, utf.length);
+				segs.push(seg);
+				segs.push(utf)
+			}
+		}
+
+		if (this.as && this.as.length) {
+			var a = this.as;
+			if (a.length > colferListMax)
+				throw 'colfer: gen.o.as length exceeds colferListMax';
+			var seg = [13];
+			encodeVarint(seg, a.length);
+			segs.push(seg);
+			for (var i = 0; i < a.length; i++) {
+				var b = a[i];
+				if (b == null) {
+					b = new Uint8Array(0);
+					a[i] = b;
+				}
+				seg = [];
+				encodeVarint(seg, b.length);
+				segs.push(seg);
+				segs.push(b)
+			}
+		}
+
+		if (this.u8) {
+			if (this.u8 > 255 || this.u8 < 0)
+				throw 'colfer: gen/O field u8 out of reach: ' + this.u8;
+			segs.push([14, this.u8]);
+		}
+
+		if (this.u16) {
+			if (this.u16 > 65535 || this.u16 < 0)
+				throw 'colfer: gen/O field u16 out of reach: ' + this.u16;
+			if (this.u16 < 256)
+				segs.push([15 | 128, this.u16]);
+			else
+				segs.push([15, this.u16 >>> 8, this.u16 & 255]);
+		}
+
+		if (this.f32s && this.f32s.length) {
+			if (this.f32s.length > colferListMax)
+				throw 'colfer: gen.o.f32s length exceeds colferListMax';
+			var seg = [16];
+			encodeVarint(seg, this.f32s.length);
+			segs.push(seg);
+
+			var bytes = new Uint8Array(this.f32s.length * 4);
+			var view = new DataView(bytes.buffer);
+			this.f32s.forEach(function(f, i) {
+				if (f > 3.4028234663852886E38 || f < -3.4028234663852886E38)
+					throw 'colfer: gen.o.f32s[' + i + '] exceeds 32-bit range';
+				view.setFloat32(i * 4, f);
+			});
+			segs.push(bytes);
+		}
+
+		if (this.f64s && this.f64s.length) {
+			if (this.f64s.length > colferListMax)
+				throw 'colfer: gen.o.f64s length exceeds colferListMax';
+			var seg = [17];
+			encodeVarint(seg, this.f64s.length);
+			segs.push(seg);
+
+			var bytes = new Uint8Array(this.f64s.length * 8);
+			var view = new DataView(bytes.buffer);
+			this.f64s.forEach(function(f, i) {
+				view.setFloat64(i * 8, f);
+			});
+			segs.push(bytes);
+		}
+
+		h.update(new Uint8Array([127]));
+		if (size > colferSizeMax)
+			throw 'colfer: gen.o serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+		return size;
+	}
+
+	// Deserializes the object from an Uint8Array and returns the number of bytes read.
+	// The optional depth is the number of data structure levels, including this object.
+	// The optional strict flag rejects any serial which differs from the marshal output
+	// for the same data, with a non-canonical encoding error.
+	// The optional validate flag checks the schema rules of each data structure.
+	// The optional budget limits the allocation of field values, including those of
+	// nested data structures, with its bytes property as the number of bytes which
+	// may still be allocated. Each allocation is deducted before it happens. Text
+	// and binaries count their size in bytes, list elements count 8 bytes each (4
+	// for float32), and a nested data structure counts 8 bytes per field.
+	this.O.prototype.unmarshal = function(data, depth, strict, validate, budget) {
+		depth = depth || 1;
+		if (depth > colferDepthMax)
+			throw 'colfer: gen.o exceeds nesting depth ' + colferDepthMax;
+		if (depth > 1) charge(budget, 'gen.o', 18 * 8);
+		if (!data || ! data.length) throw EOF;
+		var header = data[0];
+		var i = 1;
+		var readHeader = function() {
+			if (i >= data.length) throw EOF;
+			header = data[i++];
+		}
+
+		var view = new DataView(data.buffer);
+
+		var readVarint = function() {
+			var pos = 0, result = 0;
+			while (pos != 8) {
+				var c = data[i+pos];
+				result += (c & 127) * Math.pow(128, pos);
+				++pos;
+				if (c < 128) {
+					if (strict && c == 0 && pos > 1) throw nonCanonical(i + pos - 1);
+					i += pos;
+					if (result > Number.MAX_SAFE_INTEGER) break;
+					return result;
+				}
+				if (pos == data.length) throw EOF;
+			}
+			return -1;
+		}
+
+		if (header == 0) {
+			this.b = true;
+			readHeader();
+		}
+
+		if (header == 1) {
+			var at = i - 1;
+			var x = readVarint();
+			if (x < 0) throw 'colfer: gen/O field u32 exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && (x == 0 || x >= 2097152)) throw nonCanonical(at);
+			this.u32 = x;
+			readHeader();
+		} else if (header == (1 | 128)) {
+			if (i + 4 > data.length) throw EOF;
+			this.u32 = view.getUint32(i);
+			if (strict && this.u32 < 2097152) throw nonCanonical(i - 1);
+			i += 4;
+			readHeader();
+		}
+
+		if (header == 2) {
+			var at = i - 1;
+			var x = readVarint();
+			if (x < 0) throw 'colfer: gen/O field u64 exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && (x == 0 || x >= 562949953421312)) throw nonCanonical(at);
+			this.u64 = x;
+			readHeader();
+		} else if (header == (2 | 128)) {
+			if (i + 8 > data.length) throw EOF;
+			var x = view.getUint32(i) * 0x100000000;
+			x += view.getUint32(i + 4);
+			if (x > Number.MAX_SAFE_INTEGER)
+				throw 'colfer: gen/O field u64 exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && x < 562949953421312) throw nonCanonical(i - 1);
+			this.u64 = x;
+			i += 8;
+			readHeader();
+		}
+
+		if (header == 3) {
+			var at = i - 1;
+			var x = readVarint();
+			if (x < 0) throw 'colfer: gen/O field i32 exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && (x == 0 || x > 2147483647)) throw nonCanonical(at);
+			this.i32 = x;
+			readHeader();
+		} else if (header == (3 | 128)) {
+			var at = i - 1;
+			var x = readVarint();
+			if (x < 0) throw 'colfer: gen/O field i32 exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && (x == 0 || x > 2147483648)) throw nonCanonical(at);
+			this.i32 = -1 * x;
+			readHeader();
+		}
+
+		if (header == 4) {
+			var at = i - 1;
+			var x = readVarint();
+			if (x < 0) throw 'colfer: gen/O field i64 exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && x == 0) throw nonCanonical(at);
+			this.i64 = x;
+			readHeader();
+		} else if (header == (4 | 128)) {
+			var at = i - 1;
+			var x = readVarint();
+			if (x < 0) throw 'colfer: gen/O field i64 exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && x == 0) throw nonCanonical(at);
+			this.i64 = -1 * x;
+			readHeader();
+		}
+
+		if (header == 5) {
+			if (i + 4 > data.length) throw EOF;
+			this.f32 = view.getFloat32(i);
+			if (strict && this.f32 == 0) throw nonCanonical(i - 1);
+			i += 4;
+			readHeader();
+		}
+
+		if (header == 6) {
+			if (i + 8 > data.length) throw EOF;
+			this.f64 = view.getFloat64(i);
+			if (strict && this.f64 == 0) throw nonCanonical(i - 1);
+			i += 8;
+			readHeader();
+		}
+
+		if (header == 7) {
+			if (i + 8 > data.length) throw EOF;
+
+			var ms = view.getUint32(i) * 1E3;
+			var ns = view.getUint32(i + 4);
+			if (strict && ns >= 1E9) throw nonCanonical(i + 4);
+			ms += Math.floor(ns / 1E6);
+			this.t = new Date(ms);
+			this.t_ns = ns % 1E6;
+
+			i += 8;
+			readHeader();
+		} else if (header == (7 | 128)) {
+			if (i + 12 > data.length) throw EOF;
+
+			var ms = decodeInt64(data, i) * 1E3;
+			var ns = view.getUint32(i + 8);
+			if (strict) {
+				if (ms >= 0 && ms < 4294967296E3) throw nonCanonical(i - 1);
+				if (ns >= 1E9) throw nonCanonical(i + 8);
+			}
+			ms += Math.floor(ns / 1E6);
+			if (ms < -864E13 || ms > 864E13)
+				throw 'colfer: gen/ field t exceeds ECMA Date range';
+			this.t = new Date(ms);
+			this.t_ns = ns % 1E6;
+
+			i += 12;
+			readHeader();
+		}
+
+		if (header == 8) {
+			var size = readVarint();
+			if (strict && size == 0) throw nonCanonical(i - 2);
+			if (size < 0)
+				throw 'colfer: gen.o.s size exceeds Number.MAX_SAFE_INTEGER';
+			else if (size > colferSizeMax)
+				throw 'colfer: gen.o.s size ' + size + ' exceeds ' + colferSizeMax + ' UTF-8 bytes';
 
 			var start = i;
 			i += size;
 			if (i > data.length) throw EOF;
+			var utf = data.subarray(start, i);
+			if (strict) {
+				var valid = validUTF8Len(utf);
+				if (valid < size) throw nonCanonical(start + valid);
+			}
+			charge(budget, 'gen.o.s', size);
+			this.s = decodeUTF8(utf);
+			readHeader();
+		}
+
+		if (header == 9) {
+			var size = readVarint();
+			if (strict && size == 0) throw nonCanonical(i - 2);
+			if (size < 0)
+				throw 'colfer: gen.o.a size exceeds Number.MAX_SAFE_INTEGER';
+			else if (size > colferSizeMax)
+				throw 'colfer: gen.o.a size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+
+			var start = i;
+			i += size;
+			if (i > data.length) throw EOF;
+			charge(budget, 'gen.o.a', size);
 			this.a = data.slice(start, i);
 			readHeader();
 		}
 
 		if (header == 10) {
 			var o = new gen.O();
-			i += o.unmarshal(data.subarray(i));
+			i += unmarshalNested(o, data, i, depth + 1, strict, validate, budget);
 			this.o = o;
 			readHeader();
 		}
@@ -497,12 +817,16 @@ var gen = new function() {
 		if (header == 11) {
 			var l = readVarint();
 			if (l < 0) throw 'colfer: gen.o.os length exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && l == 0) throw nonCanonical(i - 2);
 			if (l > colferListMax)
 				throw 'colfer: gen.o.os length ' + l + ' exceeds ' + colferListMax + ' elements';
+			// each element takes at least one byte
+			if (i + l > data.length) throw EOF;
 
+			charge(budget, 'gen.o.os', l * 8);
 			for (var n = 0; n < l; ++n) {
 				var o = new gen.O();
-				i += o.unmarshal(data.subarray(i));
+				i += unmarshalNested(o, data, i, depth + 1, strict, validate, budget);
 				this.os[n] = o;
 			}
 			readHeader();
@@ -511,9 +835,13 @@ var gen = new function() {
 		if (header == 12) {
 			var l = readVarint();
 			if (l < 0) throw 'colfer: gen.o.ss length exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && l == 0) throw nonCanonical(i - 2);
 			if (l > colferListMax)
 				throw 'colfer: gen.o.ss length ' + l + ' exceeds ' + colferListMax + ' elements';
+			// each element takes at least one byte
+			if (i + l > data.length) throw EOF;
 
+			charge(budget, 'gen.o.ss', l * 8);
 			this.ss = new Array(l);
 			for (var n = 0; n < l; ++n) {
 				var size = readVarint();
@@ -525,7 +853,13 @@ var gen = new function() {
 				var start = i;
 				i += size;
 				if (i > data.length) throw EOF;
-				this.ss[n] = decodeUTF8(data.subarray(start, i));
+				var utf = data.subarray(start, i);
+				if (strict) {
+					var valid = validUTF8Len(utf);
+					if (valid < size) throw nonCanonical(start + valid);
+				}
+				charge(budget, 'gen.o.ss', size);
+				this.ss[n] = decodeUTF8(utf);
 			}
 			readHeader();
 		}
@@ -533,9 +867,13 @@ var gen = new function() {
 		if (header == 13) {
 			var l = readVarint();
 			if (l < 0) throw 'colfer: gen.o.as length exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && l == 0) throw nonCanonical(i - 2);
 			if (l > colferListMax)
 				throw 'colfer: gen.o.as length ' + l + ' exceeds ' + colferListMax + ' elements';
+			// each element takes at least one byte
+			if (i + l > data.length) throw EOF;
 
+			charge(budget, 'gen.o.as', l * 8);
 			this.as = new Array(l);
 			for (var n = 0; n < l; ++n) {
 				var size = readVarint();
@@ -547,6 +885,7 @@ var gen = new function() {
 				var start = i;
 				i += size;
 				if (i > data.length) throw EOF;
+				charge(budget, 'gen.o.as', size);
 				this.as[n] = data.slice(start, i);
 			}
 			readHeader();
@@ -555,26 +894,31 @@ var gen = new function() {
 		if (header == 14) {
 			if (i + 1 >= data.length) throw EOF;
 			this.u8 = data[i++];
+			if (strict && this.u8 == 0) throw nonCanonical(i - 2);
 			header = data[i++];
 		}
 
 		if (header == 15) {
 			if (i + 2 >= data.length) throw EOF;
 			this.u16 = (data[i++] << 8) | data[i++];
+			if (strict && this.u16 < 256) throw nonCanonical(i - 3);
 			header = data[i++];
 		} else if (header == (15 | 128)) {
 			if (i + 1 >= data.length) throw EOF;
 			this.u16 = data[i++];
+			if (strict && this.u16 == 0) throw nonCanonical(i - 2);
 			header = data[i++];
 		}
 
 		if (header == 16) {
 			var l = readVarint();
 			if (l < 0) throw 'colfer: gen.o.f32s length exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && l == 0) throw nonCanonical(i - 2);
 			if (l > colferListMax)
 				throw 'colfer: gen.o.f32s length ' + l + ' exceeds ' + colferListMax + ' elements';
 			if (i + l * 4 > data.length) throw EOF;
 
+			charge(budget, 'gen.o.f32s', l * 4);
 			this.f32s = new Float32Array(l);
 			for (var n = 0; n < l; ++n) {
 				this.f32s[n] = view.getFloat32(i);
@@ -586,10 +930,12 @@ var gen = new function() {
 		if (header == 17) {
 			var l = readVarint();
 			if (l < 0) throw 'colfer: gen.o.f64s length exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && l == 0) throw nonCanonical(i - 2);
 			if (l > colferListMax)
 				throw 'colfer: gen.o.f64s length ' + l + ' exceeds ' + colferListMax + ' elements';
 			if (i + l * 8 > data.length) throw EOF;
 
+			charge(budget, 'gen.o.f64s', l * 8);
 			this.f64s = new Float64Array(l);
 			for (var n = 0; n < l; ++n) {
 				this.f64s[n] = view.getFloat64(i);
@@ -604,7 +950,1435 @@ var gen = new function() {
 		return i;
 	}
 
-	// private section
+	// Returns the canonical JSON mapping with the schema field names as keys.
+	// JSON.stringify uses this method implicitly. The sensitive fields are
+	// masked when redact is true, conform toRedactedJSON.
+	this.O.prototype.toJSON = function(key, redact) {
+		var o = {};
+		if (this.b)
+			o['b'] = this.b;
+		if (this.u32)
+			o['u32'] = this.u32;
+		if (this.u64)
+			o['u64'] = this.u64;
+		if (this.i32)
+			o['i32'] = this.i32;
+		if (this.i64)
+			o['i64'] = this.i64;
+		if (this.f32 || Number.isNaN(this.f32))
+			o['f32'] = formatJSONFloat(this.f32);
+		if (this.f64 || Number.isNaN(this.f64))
+			o['f64'] = formatJSONFloat(this.f64);
+		if ((this.t && this.t.getTime()) || this.t_ns)
+			o['t'] = formatRFC3339(this.t, this.t_ns, 'gen.o.t');
+		if (this.s)
+			o['s'] = this.s;
+		if (this.a && this.a.length)
+			o['a'] = encodeBase64(this.a);
+		if (this.o)
+			o['o'] = this.o.toJSON('', redact);
+		if (this.os && this.os.length)
+			o['os'] = this.os.map(function(v) {
+				return v ? v.toJSON('', redact) : {};
+			});
+		if (this.ss && this.ss.length)
+			o['ss'] = this.ss.map(function(s) { return s || ''; });
+		if (this.as && this.as.length)
+			o['as'] = this.as.map(encodeBase64);
+		if (this.u8)
+			o['u8'] = this.u8;
+		if (this.u16)
+			o['u16'] = this.u16;
+		if (this.f32s && this.f32s.length)
+			o['f32s'] = Array.prototype.map.call(this.f32s, formatJSONFloat);
+		if (this.f64s && this.f64s.length)
+			o['f64s'] = Array.prototype.map.call(this.f64s, formatJSONFloat);
+		return o;
+	}
+
+	// Returns the JSON mapping with the sensitive fields masked, including the
+	// ones of nested data structures, which makes it safe for logging.
+	this.O.prototype.toRedactedJSON = function() {
+		return this.toJSON('', true);
+	}
+
+	// Returns a human-readable form for debugging, with the sensitive fields masked.
+	this.O.prototype.toString = function() {
+		return 'gen.o' + JSON.stringify(this.toRedactedJSON());
+	}
+
+	// Parses the canonical JSON mapping, either as text or as a parsed value.
+	this.O.fromJSON = function(json) {
+		if (typeof json === 'string') json = JSON.parse(json);
+		if (json == null) return null;
+		if (typeof json !== 'object' || Array.isArray(json))
+			throw 'colfer: JSON for struct gen.o: got ' + (Array.isArray(json) ? 'array' : typeof json) + ', want object';
+
+		var o = new gen.O();
+		for (var name in json) {
+			var v = json[name];
+			switch (name) {
+			case 'b':
+				o.b = parseJSONBool(v, 'gen.o.b');
+				break;
+			case 'u32':
+				o.u32 = parseJSONInt(v, 'gen.o.u32', 0, 4294967295);
+				break;
+			case 'u64':
+				o.u64 = parseJSONInt(v, 'gen.o.u64', 0, Number.MAX_SAFE_INTEGER);
+				break;
+			case 'i32':
+				o.i32 = parseJSONInt(v, 'gen.o.i32', -2147483648, 2147483647);
+				break;
+			case 'i64':
+				o.i64 = parseJSONInt(v, 'gen.o.i64', Number.MIN_SAFE_INTEGER, Number.MAX_SAFE_INTEGER);
+				break;
+			case 'f32':
+				o.f32 = parseJSONFloat(v, 'gen.o.f32');
+				break;
+			case 'f64':
+				o.f64 = parseJSONFloat(v, 'gen.o.f64');
+				break;
+			case 't':
+				if (v != null) {
+					var t = parseRFC3339(v, 'gen.o.t');
+					o.t = t[0];
+					o.t_ns = t[1];
+				}
+				break;
+			case 's':
+				o.s = parseJSONText(v, 'gen.o.s');
+				break;
+			case 'a':
+				o.a = decodeBase64(v, 'gen.o.a');
+				break;
+			case 'o':
+				o.o = gen.O.fromJSON(v);
+				break;
+			case 'os':
+				v = checkJSONList(v, 'gen.o.os');
+				o.os = v.map(function(x) {
+					return x == null ? new gen.O() : gen.O.fromJSON(x);
+				});
+				break;
+			case 'ss':
+				v = checkJSONList(v, 'gen.o.ss');
+				o.ss = v.map(function(s) { return parseJSONText(s, 'gen.o.ss'); });
+				break;
+			case 'as':
+				v = checkJSONList(v, 'gen.o.as');
+				o.as = v.map(function(b) { return decodeBase64(b, 'gen.o.as'); });
+				break;
+			case 'u8':
+				o.u8 = parseJSONInt(v, 'gen.o.u8', 0, 255);
+				break;
+			case 'u16':
+				o.u16 = parseJSONInt(v, 'gen.o.u16', 0, 65535);
+				break;
+			case 'f32s':
+				v = checkJSONList(v, 'gen.o.f32s');
+				o.f32s = new Float32Array(v.map(function(f) { return parseJSONFloat(f, 'gen.o.f32s'); }));
+				break;
+			case 'f64s':
+				v = checkJSONList(v, 'gen.o.f64s');
+				o.f64s = new Float64Array(v.map(function(f) { return parseJSONFloat(f, 'gen.o.f64s'); }));
+				break;
+			default:
+				throw 'colfer: JSON member ' + JSON.stringify(name) + ' not in struct gen.o';
+			}
+		}
+		return o;
+	}
+
+	// Checks each property against the schema rules, including the ones of nested
+	// data structures, and throws an error on the first violation.
+	this.O.prototype.validate = function() {
+		if (this.o) this.o.validate();
+		(this.os || []).forEach(function(v) {
+			(v || new gen.O()).validate();
+		});
+	}
+
+	// Constructor.
+	// E contains an embedded Colfer serial.
+	// When init is provided all enumerable properties are merged into the new object a.k.a. shallow cloning.
+	this.E = function(init) {
+		// M tests embedded serials.
+		this.m = new Uint8Array(0);
+
+		for (var p in init) this[p] = init[p];
+	}
+
+	// The qualified name for any fields.
+	this.E.prototype.colferType = 'gen.e';
+
+	// Serializes the object into an Uint8Array.
+	this.E.prototype.marshal = function() {
+		var segs = [];
+
+		if (this.m && this.m.length) {
+			var seg = [0];
+			encodeVarint(seg, this.m.length);
+			segs.push(seg);
+			segs.push(this.m);
+		}
+
+		var size = 1;
+		segs.forEach(function(seg) {
+			size += seg.length;
+		});
+		if (size > colferSizeMax)
+			throw 'colfer: gen.e serial size ' + size + ' exceeds ' + colferListMax + ' bytes';
+
+		var bytes = new Uint8Array(size);
+		var i = 0;
+		segs.forEach(function(seg) {
+			bytes.set(seg, i);
+			i += seg.length;
+		});
+		bytes[i] = 127;
+		return bytes;
+	}
+
+	// Feeds the serial into h, without materializing the serial as a whole.
+	// Parameter h is any object with an update method for Uint8Array, such as
+	// a Node.js crypto.Hash. Equal values produce the same input for h in each
+	// of the supported languages. The return is the serial size.
+	this.E.prototype.colferHash = function(h) {
+		var size = 1;
+		var segs = {push: function(seg) {
+			h.update(seg instanceof Uint8Array ? seg : new Uint8Array(seg));
+			size += seg.length;
+		}};
+
+		if (this.m && this.m.length) {
+			var seg = [0];
+			encodeVarint(seg, this.m.length);
+			segs.push(seg);
+			segs.push(this.m);
+		}
+
+		h.update(new Uint8Array([127]));
+		if (size > colferSizeMax)
+			throw 'colfer: gen.e serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+		return size;
+	}
+
+	// Deserializes the object from an Uint8Array and returns the number of bytes read.
+	// The optional depth is the number of data structure levels, including this object.
+	// The optional strict flag rejects any serial which differs from the marshal output
+	// for the same data, with a non-canonical encoding error.
+	// The optional validate flag checks the schema rules of each data structure.
+	// The optional budget limits the allocation of field values, including those of
+	// nested data structures, with its bytes property as the number of bytes which
+	// may still be allocated. Each allocation is deducted before it happens. Text
+	// and binaries count their size in bytes, list elements count 8 bytes each (4
+	// for float32), and a nested data structure counts 8 bytes per field.
+	this.E.prototype.unmarshal = function(data, depth, strict, validate, budget) {
+		depth = depth || 1;
+		if (depth > colferDepthMax)
+			throw 'colfer: gen.e exceeds nesting depth ' + colferDepthMax;
+		if (depth > 1) charge(budget, 'gen.e', 1 * 8);
+		if (!data || ! data.length) throw EOF;
+		var header = data[0];
+		var i = 1;
+		var readHeader = function() {
+			if (i >= data.length) throw EOF;
+			header = data[i++];
+		}
+
+		var view = new DataView(data.buffer);
+
+		var readVarint = function() {
+			var pos = 0, result = 0;
+			while (pos != 8) {
+				var c = data[i+pos];
+				result += (c & 127) * Math.pow(128, pos);
+				++pos;
+				if (c < 128) {
+					if (strict && c == 0 && pos > 1) throw nonCanonical(i + pos - 1);
+					i += pos;
+					if (result > Number.MAX_SAFE_INTEGER) break;
+					return result;
+				}
+				if (pos == data.length) throw EOF;
+			}
+			return -1;
+		}
+
+		if (header == 0) {
+			var size = readVarint();
+			if (strict && size == 0) throw nonCanonical(i - 2);
+			if (size < 0)
+				throw 'colfer: gen.e.m size exceeds Number.MAX_SAFE_INTEGER';
+			else if (size > colferSizeMax)
+				throw 'colfer: gen.e.m size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+
+			var start = i;
+			i += size;
+			if (i > data.length) throw EOF;
+			if (size != 0 && data[i - 1] != 127)
+				throw 'colfer: unknown header at byte ' + (i - 1);
+			charge(budget, 'gen.e.m', size);
+			this.m = data.slice(start, i);
+			readHeader();
+		}
+
+		if (header != 127) throw 'colfer: unknown header at byte ' + (i - 1);
+		if (i > colferSizeMax)
+			throw 'colfer: gen.e serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+		return i;
+	}
+
+	// Returns the canonical JSON mapping with the schema field names as keys.
+	// JSON.stringify uses this method implicitly. The sensitive fields are
+	// masked when redact is true, conform toRedactedJSON.
+	this.E.prototype.toJSON = function(key, redact) {
+		var o = {};
+		if (this.m && this.m.length)
+			o['m'] = encodeBase64(this.m);
+		return o;
+	}
+
+	// Returns the JSON mapping with the sensitive fields masked, including the
+	// ones of nested data structures, which makes it safe for logging.
+	this.E.prototype.toRedactedJSON = function() {
+		return this.toJSON('', true);
+	}
+
+	// Returns a human-readable form for debugging, with the sensitive fields masked.
+	this.E.prototype.toString = function() {
+		return 'gen.e' + JSON.stringify(this.toRedactedJSON());
+	}
+
+	// Parses the canonical JSON mapping, either as text or as a parsed value.
+	this.E.fromJSON = function(json) {
+		if (typeof json === 'string') json = JSON.parse(json);
+		if (json == null) return null;
+		if (typeof json !== 'object' || Array.isArray(json))
+			throw 'colfer: JSON for struct gen.e: got ' + (Array.isArray(json) ? 'array' : typeof json) + ', want object';
+
+		var o = new gen.E();
+		for (var name in json) {
+			var v = json[name];
+			switch (name) {
+			case 'm':
+				o.m = decodeBase64(v, 'gen.e.m');
+				break;
+			default:
+				throw 'colfer: JSON member ' + JSON.stringify(name) + ' not in struct gen.e';
+			}
+		}
+		return o;
+	}
+
+	// Checks each property against the schema rules, including the ones of nested
+	// data structures, and throws an error on the first violation.
+	this.E.prototype.validate = function() {
+	}
+
+	// Constructor.
+	// W wraps any data structure.
+	// When init is provided all enumerable properties are merged into the new object a.k.a. shallow cloning.
+	this.W = function(init) {
+		// V tests any data structures.
+		this.v = null;
+
+		for (var p in init) this[p] = init[p];
+	}
+
+	// The qualified name for any fields.
+	this.W.prototype.colferType = 'gen.w';
+
+	// Serializes the object into an Uint8Array.
+	this.W.prototype.marshal = function() {
+		var segs = [];
+
+		if (this.v) {
+			var name = encodeUTF8(this.v.colferType);
+			var seg = [0];
+			encodeVarint(seg, name.length);
+			segs.push(seg);
+			segs.push(name);
+			segs.push(this.v.marshal());
+		}
+
+		var size = 1;
+		segs.forEach(function(seg) {
+			size += seg.length;
+		});
+		if (size > colferSizeMax)
+			throw 'colfer: gen.w serial size ' + size + ' exceeds ' + colferListMax + ' bytes';
+
+		var bytes = new Uint8Array(size);
+		var i = 0;
+		segs.forEach(function(seg) {
+			bytes.set(seg, i);
+			i += seg.length;
+		});
+		bytes[i] = 127;
+		return bytes;
+	}
+
+	// Feeds the serial into h, without materializing the serial as a whole.
+	// Parameter h is any object with an update method for Uint8Array, such as
+	// a Node.js crypto.Hash. Equal values produce the same input for h in each
+	// of the supported languages. The return is the serial size.
+	this.W.prototype.colferHash = function(h) {
+		var size = 1;
+		var segs = {push: function(seg) {
+			h.update(seg instanceof Uint8Array ? seg : new Uint8Array(seg));
+			size += seg.length;
+		}};
+
+		if (this.v) {
+			var name = encodeUTF8(this.v.colferType);
+			var seg = [0];
+			encodeVarint(seg, name.length);
+			segs.push(seg);
+			segs.push(name);
+			size += this.v.colferHash(h);
+		}
+
+		h.update(new Uint8Array([127]));
+		if (size > colferSizeMax)
+			throw 'colfer: gen.w serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+		return size;
+	}
+
+	// Deserializes the object from an Uint8Array and returns the number of bytes read.
+	// The optional depth is the number of data structure levels, including this object.
+	// The optional strict flag rejects any serial which differs from the marshal output
+	// for the same data, with a non-canonical encoding error.
+	// The optional validate flag checks the schema rules of each data structure.
+	// The optional budget limits the allocation of field values, including those of
+	// nested data structures, with its bytes property as the number of bytes which
+	// may still be allocated. Each allocation is deducted before it happens. Text
+	// and binaries count their size in bytes, list elements count 8 bytes each (4
+	// for float32), and a nested data structure counts 8 bytes per field.
+	this.W.prototype.unmarshal = function(data, depth, strict, validate, budget) {
+		depth = depth || 1;
+		if (depth > colferDepthMax)
+			throw 'colfer: gen.w exceeds nesting depth ' + colferDepthMax;
+		if (depth > 1) charge(budget, 'gen.w', 1 * 8);
+		if (!data || ! data.length) throw EOF;
+		var header = data[0];
+		var i = 1;
+		var readHeader = function() {
+			if (i >= data.length) throw EOF;
+			header = data[i++];
+		}
+
+		var view = new DataView(data.buffer);
+
+		var readVarint = function() {
+			var pos = 0, result = 0;
+			while (pos != 8) {
+				var c = data[i+pos];
+				result += (c & 127) * Math.pow(128, pos);
+				++pos;
+				if (c < 128) {
+					if (strict && c == 0 && pos > 1) throw nonCanonical(i + pos - 1);
+					i += pos;
+					if (result > Number.MAX_SAFE_INTEGER) break;
+					return result;
+				}
+				if (pos == data.length) throw EOF;
+			}
+			return -1;
+		}
+
+		if (header == 0) {
+			var size = readVarint();
+			if (size < 0)
+				throw 'colfer: gen.w.v type size exceeds Number.MAX_SAFE_INTEGER';
+			else if (size > colferSizeMax)
+				throw 'colfer: gen.w.v type size ' + size + ' exceeds ' + colferSizeMax + ' UTF-8 bytes';
+
+			var start = i;
+			i += size;
+			if (i > data.length) throw EOF;
+			var o = null;
+			try {
+				o = newColferAny(decodeUTF8(data.subarray(start, i)));
+			} catch (err) {
+				// malformed names are not registered
+			}
+			if (! o) throw 'colfer: unknown header at byte ' + start;
+			i += unmarshalNested(o, data, i, depth + 1, strict, validate, budget);
+			this.v = o;
+			readHeader();
+		}
+
+		if (header != 127) throw 'colfer: unknown header at byte ' + (i - 1);
+		if (i > colferSizeMax)
+			throw 'colfer: gen.w serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+		return i;
+	}
+
+	// Returns the canonical JSON mapping with the schema field names as keys.
+	// JSON.stringify uses this method implicitly. The sensitive fields are
+	// masked when redact is true, conform toRedactedJSON.
+	this.W.prototype.toJSON = function(key, redact) {
+		var o = {};
+		if (this.v)
+			o['v'] = {type: this.v.colferType, value: this.v.toJSON('', redact)};
+		return o;
+	}
+
+	// Returns the JSON mapping with the sensitive fields masked, including the
+	// ones of nested data structures, which makes it safe for logging.
+	this.W.prototype.toRedactedJSON = function() {
+		return this.toJSON('', true);
+	}
+
+	// Returns a human-readable form for debugging, with the sensitive fields masked.
+	this.W.prototype.toString = function() {
+		return 'gen.w' + JSON.stringify(this.toRedactedJSON());
+	}
+
+	// Parses the canonical JSON mapping, either as text or as a parsed value.
+	this.W.fromJSON = function(json) {
+		if (typeof json === 'string') json = JSON.parse(json);
+		if (json == null) return null;
+		if (typeof json !== 'object' || Array.isArray(json))
+			throw 'colfer: JSON for struct gen.w: got ' + (Array.isArray(json) ? 'array' : typeof json) + ', want object';
+
+		var o = new gen.W();
+		for (var name in json) {
+			var v = json[name];
+			switch (name) {
+			case 'v':
+				o.v = parseJSONAny(v, 'gen.w.v');
+				break;
+			default:
+				throw 'colfer: JSON member ' + JSON.stringify(name) + ' not in struct gen.w';
+			}
+		}
+		return o;
+	}
+
+	// Checks each property against the schema rules, including the ones of nested
+	// data structures, and throws an error on the first violation.
+	this.W.prototype.validate = function() {
+		if (this.v) this.v.validate();
+	}
+
+	// Constructor.
+	// R tests validation rules.
+	// When init is provided all enumerable properties are merged into the new object a.k.a. shallow cloning.
+	this.R = function(init) {
+		// Par tests an integer range.
+		this.par = 0;
+		// Lat tests a floating point range.
+		this.lat = 0;
+		// Big tests an unsigned range beyond the signed maximum.
+		this.big = 0;
+		// Name tests required text with size and pattern rules.
+		this.name = '';
+		// Tags tests a list size.
+		this.tags = [];
+		// Next tests nested validation.
+		this.next = null;
+
+		for (var p in init) this[p] = init[p];
+	}
+
+	// The qualified name for any fields.
+	this.R.prototype.colferType = 'gen.r';
+
+	// Serializes the object into an Uint8Array.
+	// All null entries in property tags will be replaced with an empty String.
+	this.R.prototype.marshal = function() {
+		var segs = [];
+
+		if (this.par) {
+			if (this.par > 255 || this.par < 0)
+				throw 'colfer: gen/R field par out of reach: ' + this.par;
+			segs.push([0, this.par]);
+		}
+
+		if (this.lat || Number.isNaN(this.lat)) {
+			var bytes = new Uint8Array(9);
+			bytes[0] = 1;
+			new DataView(bytes.buffer).setFloat64(1, this.lat);
+			segs.push(bytes);
+		}
+
+		if (this.big) {
+			if (this.big < 0)
+				throw 'colfer: gen/R field big out of reach: ' + this.big;
+			if (this.big > Number.MAX_SAFE_INTEGER)
+				throw 'colfer: gen/R field big exceeds Number.MAX_SAFE_INTEGER';
+			if (this.big < 0x2000000000000) {
+				var seg = [2];
+				encodeVarint(seg, this.big);
+				segs.push(seg);
+			} else {
+				var bytes = new Uint8Array(9);
+				bytes[0] = 2 | 128;
+				var view = new DataView(bytes.buffer);
+				view.setUint32(1, this.big / 0x100000000);
+				view.setUint32(5, this.big % 0x100000000);
+				segs.push(bytes)
+			}
+		}
+
+		if (this.name) {
+			var utf = encodeUTF8(this.name);
+			var seg = [3];
+			encodeVarint(seg, utf.length);
+			segs.push(seg);
+			segs.push(utf)
+		}
+
+		if (this.tags && this.tags.length) {
+			var a = this.tags;
+			if (a.length > colferListMax)
+				throw 'colfer: gen.r.tags length exceeds colferListMax';
+			var seg = [4];
+			encodeVarint(seg, a.length);
+			segs.push(seg);
+			for (var i = 0; i < a.length; i++) {
+				var s = a[i];
+				if (s == null) {
+					s = "";
+					a[i] = s;
+				}
+				var utf = encodeUTF8(s);
+				seg = [];
+				encodeVarint(seg, utf.length);
+				segs.push(seg);
+				segs.push(utf)
+			}
+		}
+
+		if (this.next) {
+			segs.push([5]);
+			segs.push(this.next.marshal());
+		}
+
+		var size = 1;
+		segs.forEach(function(seg) {
+			size += seg.length;
+		});
+		if (size > colferSizeMax)
+			throw 'colfer: gen.r serial size ' + size + ' exceeds ' + colferListMax + ' bytes';
+
+		var bytes = new Uint8Array(size);
+		var i = 0;
+		segs.forEach(function(seg) {
+			bytes.set(seg, i);
+			i += seg.length;
+		});
+		bytes[i] = 127;
+		return bytes;
+	}
+
+	// Feeds the serial into h, without materializing the serial as a whole.
+	// Parameter h is any object with an update method for Uint8Array, such as
+	// a Node.js crypto.Hash. Equal values produce the same input for h in each
+	// of the supported languages. The return is the serial size.
+	// All null entries in property tags will be replaced with an empty String.
+	this.R.prototype.colferHash = function(h) {
+		var size = 1;
+		var segs = {push: function(seg) {
+			h.update(seg instanceof Uint8Array ? seg : new Uint8Array(seg));
+			size += seg.length;
+		}};
+
+		if (this.par) {
+			if (this.par > 255 || this.par < 0)
+				throw 'colfer: gen/R field par out of reach: ' + this.par;
+			segs.push([0, this.par]);
+		}
+
+		if (this.lat || Number.isNaN(this.lat)) {
+			var bytes = new Uint8Array(9);
+			bytes[0] = 1;
+			new DataView(bytes.buffer).setFloat64(1, this.lat);
+			segs.push(bytes);
+		}
+
+		if (this.big) {
+			if (this.big < 0)
+				throw 'colfer: gen/R field big out of reach: ' + this.big;
+			if (this.big > Number.MAX_SAFE_INTEGER)
+				throw 'colfer: gen/R field big exceeds Number.MAX_SAFE_INTEGER';
+			if (this.big < 0x2000000000000) {
+				var seg = [2];
+				encodeVarint(seg, this.big);
+				segs.push(seg);
+			} else {
+				var bytes = new Uint8Array(9);
+				bytes[0] = 2 | 128;
+				var view = new DataView(bytes.buffer);
+				view.setUint32(1, this.big / 0x100000000);
+				view.setUint32(5, this.big % 0x100000000);
+				segs.push(bytes)
+			}
+		}
+
+		if (this.name) {
+			var utf = encodeUTF8(this.name);
+			var seg = [3];
+			encodeVarint(seg, utf.length);
+			segs.push(seg);
+			segs.push(utf)
+		}
+
+		if (this.tags && this.tags.length) {
+			var a = this.tags;
+			if (a.length > colferListMax)
+				throw 'colfer: gen.r.tags length exceeds colferListMax';
+			var seg = [4];
+			encodeVarint(seg, a.length);
+			segs.push(seg);
+			for (var i = 0; i < a.length; i++) {
+				var s = a[i];
+				if (s == null) {
+					s = "";
+					a[i] = s;
+				}
+				var utf = encodeUTF8(s);
+				seg = [];
+				encodeVarint(seg, utf.length);
+				segs.push(seg);
+				segs.push(utf)
+			}
+		}
+
+		if (this.next) {
+			segs.push([5]);
+			size += this.next.colferHash(h);
+		}
+
+		h.update(new Uint8Array([127]));
+		if (size > colferSizeMax)
+			throw 'colfer: gen.r serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+		return size;
+	}
+
+	// Deserializes the object from an Uint8Array and returns the number of bytes read.
+	// The optional depth is the number of data structure levels, including this object.
+	// The optional strict flag rejects any serial which differs from the marshal output
+	// for the same data, with a non-canonical encoding error.
+	// The optional validate flag checks the schema rules of each data structure.
+	// The optional budget limits the allocation of field values, including those of
+	// nested data structures, with its bytes property as the number of bytes which
+	// may still be allocated. Each allocation is deducted before it happens. Text
+	// and binaries count their size in bytes, list elements count 8 bytes each (4
+	// for float32), and a nested data structure counts 8 bytes per field.
+	this.R.prototype.unmarshal = function(data, depth, strict, validate, budget) {
+		depth = depth || 1;
+		if (depth > colferDepthMax)
+			throw 'colfer: gen.r exceeds nesting depth ' + colferDepthMax;
+		if (depth > 1) charge(budget, 'gen.r', 6 * 8);
+		if (!data || ! data.length) throw EOF;
+		var header = data[0];
+		var i = 1;
+		var readHeader = function() {
+			if (i >= data.length) throw EOF;
+			header = data[i++];
+		}
+
+		var view = new DataView(data.buffer);
+
+		var readVarint = function() {
+			var pos = 0, result = 0;
+			while (pos != 8) {
+				var c = data[i+pos];
+				result += (c & 127) * Math.pow(128, pos);
+				++pos;
+				if (c < 128) {
+					if (strict && c == 0 && pos > 1) throw nonCanonical(i + pos - 1);
+					i += pos;
+					if (result > Number.MAX_SAFE_INTEGER) break;
+					return result;
+				}
+				if (pos == data.length) throw EOF;
+			}
+			return -1;
+		}
+
+		if (header == 0) {
+			if (i + 1 >= data.length) throw EOF;
+			this.par = data[i++];
+			if (strict && this.par == 0) throw nonCanonical(i - 2);
+			header = data[i++];
+		}
+
+		if (header == 1) {
+			if (i + 8 > data.length) throw EOF;
+			this.lat = view.getFloat64(i);
+			if (strict && this.lat == 0) throw nonCanonical(i - 1);
+			i += 8;
+			readHeader();
+		}
+
+		if (header == 2) {
+			var at = i - 1;
+			var x = readVarint();
+			if (x < 0) throw 'colfer: gen/R field big exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && (x == 0 || x >= 562949953421312)) throw nonCanonical(at);
+			this.big = x;
+			readHeader();
+		} else if (header == (2 | 128)) {
+			if (i + 8 > data.length) throw EOF;
+			var x = view.getUint32(i) * 0x100000000;
+			x += view.getUint32(i + 4);
+			if (x > Number.MAX_SAFE_INTEGER)
+				throw 'colfer: gen/R field big exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && x < 562949953421312) throw nonCanonical(i - 1);
+			this.big = x;
+			i += 8;
+			readHeader();
+		}
+
+		if (header == 3) {
+			var size = readVarint();
+			if (strict && size == 0) throw nonCanonical(i - 2);
+			if (size < 0)
+				throw 'colfer: gen.r.name size exceeds Number.MAX_SAFE_INTEGER';
+			else if (size > colferSizeMax)
+				throw 'colfer: gen.r.name size ' + size + ' exceeds ' + colferSizeMax + ' UTF-8 bytes';
+
+			var start = i;
+			i += size;
+			if (i > data.length) throw EOF;
+			var utf = data.subarray(start, i);
+			if (strict) {
+				var valid = validUTF8Len(utf);
+				if (valid < size) throw nonCanonical(start + valid);
+			}
+			charge(budget, 'gen.r.name', size);
+			this.name = decodeUTF8(utf);
+			readHeader();
+		}
+
+		if (header == 4) {
+			var l = readVarint();
+			if (l < 0) throw 'colfer: gen.r.tags length exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && l == 0) throw nonCanonical(i - 2);
+			if (l > colferListMax)
+				throw 'colfer: gen.r.tags length ' + l + ' exceeds ' + colferListMax + ' elements';
+			// each element takes at least one byte
+			if (i + l > data.length) throw EOF;
+
+			charge(budget, 'gen.r.tags', l * 8);
+			this.tags = new Array(l);
+			for (var n = 0; n < l; ++n) {
+				var size = readVarint();
+				if (size < 0)
+					throw 'colfer: gen.r.tags element ' + this.tags.length + ' size exceeds Number.MAX_SAFE_INTEGER';
+				else if (size > colferSizeMax)
+					throw 'colfer: gen.r.tags element ' + this.tags.length + ' size ' + size + ' exceeds ' + colferSizeMax + ' UTF-8 bytes';
+
+				var start = i;
+				i += size;
+				if (i > data.length) throw EOF;
+				var utf = data.subarray(start, i);
+				if (strict) {
+					var valid = validUTF8Len(utf);
+					if (valid < size) throw nonCanonical(start + valid);
+				}
+				charge(budget, 'gen.r.tags', size);
+				this.tags[n] = decodeUTF8(utf);
+			}
+			readHeader();
+		}
+
+		if (header == 5) {
+			var o = new gen.R();
+			i += unmarshalNested(o, data, i, depth + 1, strict, validate, budget);
+			this.next = o;
+			readHeader();
+		}
+
+		if (header != 127) throw 'colfer: unknown header at byte ' + (i - 1);
+		if (i > colferSizeMax)
+			throw 'colfer: gen.r serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+		if (validate) validateR(this);
+		return i;
+	}
+
+	// Returns the canonical JSON mapping with the schema field names as keys.
+	// JSON.stringify uses this method implicitly. The sensitive fields are
+	// masked when redact is true, conform toRedactedJSON.
+	this.R.prototype.toJSON = function(key, redact) {
+		var o = {};
+		if (this.par)
+			o['par'] = this.par;
+		if (this.lat || Number.isNaN(this.lat))
+			o['lat'] = formatJSONFloat(this.lat);
+		if (this.big)
+			o['big'] = this.big;
+		if (this.name)
+			o['name'] = this.name;
+		if (this.tags && this.tags.length)
+			o['tags'] = this.tags.map(function(s) { return s || ''; });
+		if (this.next)
+			o['next'] = this.next.toJSON('', redact);
+		return o;
+	}
+
+	// Returns the JSON mapping with the sensitive fields masked, including the
+	// ones of nested data structures, which makes it safe for logging.
+	this.R.prototype.toRedactedJSON = function() {
+		return this.toJSON('', true);
+	}
+
+	// Returns a human-readable form for debugging, with the sensitive fields masked.
+	this.R.prototype.toString = function() {
+		return 'gen.r' + JSON.stringify(this.toRedactedJSON());
+	}
+
+	// Parses the canonical JSON mapping, either as text or as a parsed value.
+	this.R.fromJSON = function(json) {
+		if (typeof json === 'string') json = JSON.parse(json);
+		if (json == null) return null;
+		if (typeof json !== 'object' || Array.isArray(json))
+			throw 'colfer: JSON for struct gen.r: got ' + (Array.isArray(json) ? 'array' : typeof json) + ', want object';
+
+		var o = new gen.R();
+		for (var name in json) {
+			var v = json[name];
+			switch (name) {
+			case 'par':
+				o.par = parseJSONInt(v, 'gen.r.par', 0, 255);
+				break;
+			case 'lat':
+				o.lat = parseJSONFloat(v, 'gen.r.lat');
+				break;
+			case 'big':
+				o.big = parseJSONInt(v, 'gen.r.big', 0, Number.MAX_SAFE_INTEGER);
+				break;
+			case 'name':
+				o.name = parseJSONText(v, 'gen.r.name');
+				break;
+			case 'tags':
+				v = checkJSONList(v, 'gen.r.tags');
+				o.tags = v.map(function(s) { return parseJSONText(s, 'gen.r.tags'); });
+				break;
+			case 'next':
+				o.next = gen.R.fromJSON(v);
+				break;
+			default:
+				throw 'colfer: JSON member ' + JSON.stringify(name) + ' not in struct gen.r';
+			}
+		}
+		return o;
+	}
+
+	// Checks each property against the schema rules, including the ones of nested
+	// data structures, and throws an error on the first violation.
+	this.R.prototype.validate = function() {
+		validateR(this);
+		if (this.next) this.next.validate();
+	}
+
+	var RNamePattern = new RegExp("^[a-z]+$", 'u');
+
+	// Checks each property of o against the schema rules, without the nested data structures.
+	var validateR = function(o) {
+		if (! (o.par >= 3))
+			throw 'colfer: field gen.r.par breaks rule min 3';
+		if (! (o.par <= 5))
+			throw 'colfer: field gen.r.par breaks rule max 5';
+		if (! (o.lat >= -90))
+			throw 'colfer: field gen.r.lat breaks rule min -90';
+		if (! (o.lat <= 90))
+			throw 'colfer: field gen.r.lat breaks rule max 90';
+		if (! (o.big <= 10000000000000000000))
+			throw 'colfer: field gen.r.big breaks rule max 10000000000000000000';
+		if (! o.name)
+			throw 'colfer: field gen.r.name breaks rule required';
+		if (encodeUTF8(o.name || '').length < 2)
+			throw 'colfer: field gen.r.name breaks rule minlen 2';
+		if (encodeUTF8(o.name || '').length > 8)
+			throw 'colfer: field gen.r.name breaks rule maxlen 8';
+		if (! RNamePattern.test(o.name || ''))
+			throw "colfer: field gen.r.name breaks rule pattern ^[a-z]+$";
+		if ((o.tags || []).length > 2)
+			throw 'colfer: field gen.r.tags breaks rule maxlen 2';
+	}
+
+	// Constructor.
+	// Old tests the annotations.
+	// When init is provided all enumerable properties are merged into the new object a.k.a. shallow cloning.
+	/**
+	 * @since v1.2
+	 * @deprecated use r instead.
+	 */
+	this.Old = function(init) {
+		// Pin tests a sensitive field.
+		this.pin = '';
+		// Ref tests a deprecated field.
+		/**
+		 * @deprecated no replacement.
+		 */
+		this.ref = null;
+
+		for (var p in init) this[p] = init[p];
+	}
+
+	// The qualified name for any fields.
+	this.Old.prototype.colferType = 'gen.old';
+
+	// Serializes the object into an Uint8Array.
+	this.Old.prototype.marshal = function() {
+		var segs = [];
+
+		if (this.pin) {
+			var utf = encodeUTF8(this.pin);
+			var seg = [0];
+			encodeVarint(seg, utf.length);
+			segs.push(seg);
+			segs.push(utf)
+		}
+
+		if (this.ref) {
+			segs.push([1]);
+			segs.push(this.ref.marshal());
+		}
+
+		var size = 1;
+		segs.forEach(function(seg) {
+			size += seg.length;
+		});
+		if (size > colferSizeMax)
+			throw 'colfer: gen.old serial size ' + size + ' exceeds ' + colferListMax + ' bytes';
+
+		var bytes = new Uint8Array(size);
+		var i = 0;
+		segs.forEach(function(seg) {
+			bytes.set(seg, i);
+			i += seg.length;
+		});
+		bytes[i] = 127;
+		return bytes;
+	}
+
+	// Feeds the serial into h, without materializing the serial as a whole.
+	// Parameter h is any object with an update method for Uint8Array, such as
+	// a Node.js crypto.Hash. Equal values produce the same input for h in each
+	// of the supported languages. The return is the serial size.
+	this.Old.prototype.colferHash = function(h) {
+		var size = 1;
+		var segs = {push: function(seg) {
+			h.update(seg instanceof Uint8Array ? seg : new Uint8Array(seg));
+			size += seg.length;
+		}};
+
+		if (this.pin) {
+			var utf = encodeUTF8(this.pin);
+			var seg = [0];
+			encodeVarint(seg, utf.length);
+			segs.push(seg);
+			segs.push(utf)
+		}
+
+		if (this.ref) {
+			segs.push([1]);
+			size += this.ref.colferHash(h);
+		}
+
+		h.update(new Uint8Array([127]));
+		if (size > colferSizeMax)
+			throw 'colfer: gen.old serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+		return size;
+	}
+
+	// Deserializes the object from an Uint8Array and returns the number of bytes read.
+	// The optional depth is the number of data structure levels, including this object.
+	// The optional strict flag rejects any serial which differs from the marshal output
+	// for the same data, with a non-canonical encoding error.
+	// The optional validate flag checks the schema rules of each data structure.
+	// The optional budget limits the allocation of field values, including those of
+	// nested data structures, with its bytes property as the number of bytes which
+	// may still be allocated. Each allocation is deducted before it happens. Text
+	// and binaries count their size in bytes, list elements count 8 bytes each (4
+	// for float32), and a nested data structure counts 8 bytes per field.
+	this.Old.prototype.unmarshal = function(data, depth, strict, validate, budget) {
+		depth = depth || 1;
+		if (depth > colferDepthMax)
+			throw 'colfer: gen.old exceeds nesting depth ' + colferDepthMax;
+		if (depth > 1) charge(budget, 'gen.old', 2 * 8);
+		if (!data || ! data.length) throw EOF;
+		var header = data[0];
+		var i = 1;
+		var readHeader = function() {
+			if (i >= data.length) throw EOF;
+			header = data[i++];
+		}
+
+		var view = new DataView(data.buffer);
+
+		var readVarint = function() {
+			var pos = 0, result = 0;
+			while (pos != 8) {
+				var c = data[i+pos];
+				result += (c & 127) * Math.pow(128, pos);
+				++pos;
+				if (c < 128) {
+					if (strict && c == 0 && pos > 1) throw nonCanonical(i + pos - 1);
+					i += pos;
+					if (result > Number.MAX_SAFE_INTEGER) break;
+					return result;
+				}
+				if (pos == data.length) throw EOF;
+			}
+			return -1;
+		}
+
+		if (header == 0) {
+			var size = readVarint();
+			if (strict && size == 0) throw nonCanonical(i - 2);
+			if (size < 0)
+				throw 'colfer: gen.old.pin size exceeds Number.MAX_SAFE_INTEGER';
+			else if (size > colferSizeMax)
+				throw 'colfer: gen.old.pin size ' + size + ' exceeds ' + colferSizeMax + ' UTF-8 bytes';
+
+			var start = i;
+			i += size;
+			if (i > data.length) throw EOF;
+			var utf = data.subarray(start, i);
+			if (strict) {
+				var valid = validUTF8Len(utf);
+				if (valid < size) throw nonCanonical(start + valid);
+			}
+			charge(budget, 'gen.old.pin', size);
+			this.pin = decodeUTF8(utf);
+			readHeader();
+		}
+
+		if (header == 1) {
+			var o = new gen.Old();
+			i += unmarshalNested(o, data, i, depth + 1, strict, validate, budget);
+			this.ref = o;
+			readHeader();
+		}
+
+		if (header != 127) throw 'colfer: unknown header at byte ' + (i - 1);
+		if (i > colferSizeMax)
+			throw 'colfer: gen.old serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+		return i;
+	}
+
+	// Returns the canonical JSON mapping with the schema field names as keys.
+	// JSON.stringify uses this method implicitly. The sensitive fields are
+	// masked when redact is true, conform toRedactedJSON.
+	this.Old.prototype.toJSON = function(key, redact) {
+		var o = {};
+		if (this.pin)
+			o['pin'] = this.pin;
+		if (this.ref)
+			o['ref'] = this.ref.toJSON('', redact);
+		if (redact && 'pin' in o) o['pin'] = '<redacted>';
+		return o;
+	}
+
+	// Returns the JSON mapping with the sensitive fields masked, including the
+	// ones of nested data structures, which makes it safe for logging.
+	this.Old.prototype.toRedactedJSON = function() {
+		return this.toJSON('', true);
+	}
+
+	// Returns a human-readable form for debugging, with the sensitive fields masked.
+	this.Old.prototype.toString = function() {
+		return 'gen.old' + JSON.stringify(this.toRedactedJSON());
+	}
+
+	// Parses the canonical JSON mapping, either as text or as a parsed value.
+	this.Old.fromJSON = function(json) {
+		if (typeof json === 'string') json = JSON.parse(json);
+		if (json == null) return null;
+		if (typeof json !== 'object' || Array.isArray(json))
+			throw 'colfer: JSON for struct gen.old: got ' + (Array.isArray(json) ? 'array' : typeof json) + ', want object';
+
+		var o = new gen.Old();
+		for (var name in json) {
+			var v = json[name];
+			switch (name) {
+			case 'pin':
+				o.pin = parseJSONText(v, 'gen.old.pin');
+				break;
+			case 'ref':
+				o.ref = gen.Old.fromJSON(v);
+				break;
+			default:
+				throw 'colfer: JSON member ' + JSON.stringify(name) + ' not in struct gen.old';
+			}
+		}
+		return o;
+	}
+
+	// Checks each property against the schema rules, including the ones of nested
+	// data structures, and throws an error on the first violation.
+	this.Old.prototype.validate = function() {
+		if (this.ref) this.ref.validate();
+	}
+
+	// Constructor.
+	// N tests native name overrides.
+	// When init is provided all enumerable properties are merged into the new object a.k.a. shallow cloning.
+	this.Renamed = function(init) {
+		// ID tests a field override.
+		this.ident = 0;
+		// Class tests a reserved word without override, and it tests
+		// pass-through of struct tags.
+		this.class_ = '';
+
+		for (var p in init) this[p] = init[p];
+	}
+
+	// The qualified name for any fields.
+	this.Renamed.prototype.colferType = 'gen.n';
+
+	// Serializes the object into an Uint8Array.
+	this.Renamed.prototype.marshal = function() {
+		var segs = [];
+
+		if (this.ident) {
+			if (this.ident > 4294967295 || this.ident < 0)
+				throw 'colfer: gen/Renamed field ident out of reach: ' + this.ident;
+			if (this.ident < 0x200000) {
+				var seg = [0];
+				encodeVarint(seg, this.ident);
+				segs.push(seg);
+			} else {
+				var bytes = new Uint8Array(5);
+				bytes[0] = 0 | 128;
+				var view = new DataView(bytes.buffer);
+				view.setUint32(1, this.ident);
+				segs.push(bytes)
+			}
+		}
+
+		if (this.class_) {
+			var utf = encodeUTF8(this.class_);
+			var seg = [1];
+			encodeVarint(seg, utf.length);
+			segs.push(seg);
+			segs.push(utf)
+		}
+
+		var size = 1;
+		segs.forEach(function(seg) {
+			size += seg.length;
+		});
+		if (size > colferSizeMax)
+			throw 'colfer: gen.n serial size ' + size + ' exceeds ' + colferListMax + ' bytes';
+
+		var bytes = new Uint8Array(size);
+		var i = 0;
+		segs.forEach(function(seg) {
+			bytes.set(seg, i);
+			i += seg.length;
+		});
+		bytes[i] = 127;
+		return bytes;
+	}
+
+	// Feeds the serial into h, without materializing the serial as a whole.
+	// Parameter h is any object with an update method for Uint8Array, such as
+	// a Node.js crypto.Hash. Equal values produce the same input for h in each
+	// of the supported languages. The return is the serial size.
+	this.Renamed.prototype.colferHash = function(h) {
+		var size = 1;
+		var segs = {push: function(seg) {
+			h.update(seg instanceof Uint8Array ? seg : new Uint8Array(seg));
+			size += seg.length;
+		}};
+
+		if (this.ident) {
+			if (this.ident > 4294967295 || this.ident < 0)
+				throw 'colfer: gen/Renamed field ident out of reach: ' + this.ident;
+			if (this.ident < 0x200000) {
+				var seg = [0];
+				encodeVarint(seg, this.ident);
+				segs.push(seg);
+			} else {
+				var bytes = new Uint8Array(5);
+				bytes[0] = 0 | 128;
+				var view = new DataView(bytes.buffer);
+				view.setUint32(1, this.ident);
+				segs.push(bytes)
+			}
+		}
+
+		if (this.class_) {
+			var utf = encodeUTF8(this.class_);
+			var seg = [1];
+			encodeVarint(seg, utf.length);
+			segs.push(seg);
+			segs.push(utf)
+		}
+
+		h.update(new Uint8Array([127]));
+		if (size > colferSizeMax)
+			throw 'colfer: gen.n serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+		return size;
+	}
+
+	// Deserializes the object from an Uint8Array and returns the number of bytes read.
+	// The optional depth is the number of data structure levels, including this object.
+	// The optional strict flag rejects any serial which differs from the marshal output
+	// for the same data, with a non-canonical encoding error.
+	// The optional validate flag checks the schema rules of each data structure.
+	// The optional budget limits the allocation of field values, including those of
+	// nested data structures, with its bytes property as the number of bytes which
+	// may still be allocated. Each allocation is deducted before it happens. Text
+	// and binaries count their size in bytes, list elements count 8 bytes each (4
+	// for float32), and a nested data structure counts 8 bytes per field.
+	this.Renamed.prototype.unmarshal = function(data, depth, strict, validate, budget) {
+		depth = depth || 1;
+		if (depth > colferDepthMax)
+			throw 'colfer: gen.n exceeds nesting depth ' + colferDepthMax;
+		if (depth > 1) charge(budget, 'gen.n', 2 * 8);
+		if (!data || ! data.length) throw EOF;
+		var header = data[0];
+		var i = 1;
+		var readHeader = function() {
+			if (i >= data.length) throw EOF;
+			header = data[i++];
+		}
+
+		var view = new DataView(data.buffer);
+
+		var readVarint = function() {
+			var pos = 0, result = 0;
+			while (pos != 8) {
+				var c = data[i+pos];
+				result += (c & 127) * Math.pow(128, pos);
+				++pos;
+				if (c < 128) {
+					if (strict && c == 0 && pos > 1) throw nonCanonical(i + pos - 1);
+					i += pos;
+					if (result > Number.MAX_SAFE_INTEGER) break;
+					return result;
+				}
+				if (pos == data.length) throw EOF;
+			}
+			return -1;
+		}
+
+		if (header == 0) {
+			var at = i - 1;
+			var x = readVarint();
+			if (x < 0) throw 'colfer: gen/Renamed field ident exceeds Number.MAX_SAFE_INTEGER';
+			if (strict && (x == 0 || x >= 2097152)) throw nonCanonical(at);
+			this.ident = x;
+			readHeader();
+		} else if (header == (0 | 128)) {
+			if (i + 4 > data.length) throw EOF;
+			this.ident = view.getUint32(i);
+			if (strict && this.ident < 2097152) throw nonCanonical(i - 1);
+			i += 4;
+			readHeader();
+		}
+
+		if (header == 1) {
+			var size = readVarint();
+			if (strict && size == 0) throw nonCanonical(i - 2);
+			if (size < 0)
+				throw 'colfer: gen.n.class size exceeds Number.MAX_SAFE_INTEGER';
+			else if (size > colferSizeMax)
+				throw 'colfer: gen.n.class size ' + size + ' exceeds ' + colferSizeMax + ' UTF-8 bytes';
+
+			var start = i;
+			i += size;
+			if (i > data.length) throw EOF;
+			var utf = data.subarray(start, i);
+			if (strict) {
+				var valid = validUTF8Len(utf);
+				if (valid < size) throw nonCanonical(start + valid);
+			}
+			charge(budget, 'gen.n.class', size);
+			this.class_ = decodeUTF8(utf);
+			readHeader();
+		}
+
+		if (header != 127) throw 'colfer: unknown header at byte ' + (i - 1);
+		if (i > colferSizeMax)
+			throw 'colfer: gen.n serial size ' + size + ' exceeds ' + colferSizeMax + ' bytes';
+		return i;
+	}
+
+	// Returns the canonical JSON mapping with the schema field names as keys.
+	// JSON.stringify uses this method implicitly. The sensitive fields are
+	// masked when redact is true, conform toRedactedJSON.
+	this.Renamed.prototype.toJSON = function(key, redact) {
+		var o = {};
+		if (this.ident)
+			o['id'] = this.ident;
+		if (this.class_)
+			o['class'] = this.class_;
+		return o;
+	}
+
+	// Returns the JSON mapping with the sensitive fields masked, including the
+	// ones of nested data structures, which makes it safe for logging.
+	this.Renamed.prototype.toRedactedJSON = function() {
+		return this.toJSON('', true);
+	}
+
+	// Returns a human-readable form for debugging, with the sensitive fields masked.
+	this.Renamed.prototype.toString = function() {
+		return 'gen.n' + JSON.stringify(this.toRedactedJSON());
+	}
+
+	// Parses the canonical JSON mapping, either as text or as a parsed value.
+	this.Renamed.fromJSON = function(json) {
+		if (typeof json === 'string') json = JSON.parse(json);
+		if (json == null) return null;
+		if (typeof json !== 'object' || Array.isArray(json))
+			throw 'colfer: JSON for struct gen.n: got ' + (Array.isArray(json) ? 'array' : typeof json) + ', want object';
+
+		var o = new gen.Renamed();
+		for (var name in json) {
+			var v = json[name];
+			switch (name) {
+			case 'id':
+				o.ident = parseJSONInt(v, 'gen.n.id', 0, 4294967295);
+				break;
+			case 'class':
+				o.class_ = parseJSONText(v, 'gen.n.class');
+				break;
+			default:
+				throw 'colfer: JSON member ' + JSON.stringify(name) + ' not in struct gen.n';
+			}
+		}
+		return o;
+	}
+
+	// Checks each property against the schema rules, including the ones of nested
+	// data structures, and throws an error on the first violation.
+	this.Renamed.prototype.validate = function() {
+	}
+
+	// Returns a new data structure for the qualified name from any fields,
+	// or null when the name is not in this package.
+	this.newColferAny = function(name) {
+		switch (name) {
+		case 'gen.o':
+			return new gen.O();
+		case 'gen.e':
+			return new gen.E();
+		case 'gen.w':
+			return new gen.W();
+		case 'gen.r':
+			return new gen.R();
+		case 'gen.old':
+			return new gen.Old();
+		case 'gen.n':
+			return new gen.Renamed();
+		}
+		return null;
+	}
+	var newColferAny = this.newColferAny;
+
+	// private section
 
 	var encodeVarint = function(bytes, x) {
 		while (x > 127) {
@@ -615,6 +2389,28 @@ var gen = new function() {
 		return bytes;
 	}
 
+	var nonCanonical = function(i) {
+		return 'colfer: non-canonical encoding at byte ' + i;
+	}
+
+	// Deducts n bytes for field from the optional budget.
+	var charge = function(budget, field, n) {
+		if (! budget) return;
+		if (n > budget.bytes) throw 'colfer: ' + field + ' exceeds the allocation budget';
+		budget.bytes -= n;
+	}
+
+	// Unmarshals o from data at index i, with error offsets relative to data.
+	var unmarshalNested = function(o, data, i, depth, strict, validate, budget) {
+		try {
+			return o.unmarshal(data.subarray(i), depth, strict, validate, budget);
+		} catch (err) {
+			var m = /^colfer: non-canonical encoding at byte (\d+)$/.exec(err);
+			if (m) throw nonCanonical(i + Number(m[1]));
+			throw err;
+		}
+	}
+
 	function decodeInt64(data, i) {
 		var v = 0, j = i + 7, m = 1;
 		if (data[i] & 128) {
@@ -632,6 +2428,121 @@ var gen = new function() {
 		return v;
 	}
 
+	var parseJSONAny = function(v, field) {
+		if (v == null) return null;
+		if (typeof v !== 'object' || Array.isArray(v))
+			throw 'colfer: JSON for field ' + field + ': got ' + (Array.isArray(v) ? 'array' : typeof v) + ', want object';
+		for (var name in v) if (name !== 'type' && name !== 'value')
+			throw 'colfer: JSON member ' + JSON.stringify(name) + ' not in any field ' + field;
+		var o = newColferAny(v.type);
+		if (! o) throw 'colfer: JSON for field ' + field + ': type ' + JSON.stringify(v.type) + ' not registered';
+		return o.constructor.fromJSON(v.value == null ? {} : v.value);
+	}
+
+	var checkJSONList = function(v, field) {
+		if (v == null) return [];
+		if (! Array.isArray(v)) throw 'colfer: JSON for field ' + field + ': got ' + typeof v + ', want array';
+		if (v.length > colferListMax)
+			throw 'colfer: ' + field + ' length ' + v.length + ' exceeds ' + colferListMax + ' elements';
+		return v;
+	}
+
+
+	var parseJSONBool = function(v, field) {
+		if (v == null) return false;
+		if (typeof v !== 'boolean') throw 'colfer: JSON for field ' + field + ': got ' + typeof v + ', want boolean';
+		return v;
+	}
+
+	var parseJSONInt = function(v, field, min, max) {
+		if (v == null) return 0;
+		if (typeof v === 'string' && /^[+-]?[0-9]+$/.test(v)) v = Number(v);
+		if (typeof v !== 'number' || ! Number.isInteger(v))
+			throw 'colfer: JSON for field ' + field + ': ' + JSON.stringify(v) + ' not an integer';
+		if (v < min || v > max)
+			throw 'colfer: JSON for field ' + field + ': ' + v + ' out of range';
+		return v;
+	}
+
+	var parseJSONText = function(v, field) {
+		if (v == null) return '';
+		if (typeof v !== 'string') throw 'colfer: JSON for field ' + field + ': got ' + typeof v + ', want string';
+		return v;
+	}
+
+	var formatJSONFloat = function(f) {
+		if (Number.isNaN(f)) return 'NaN';
+		if (f == Infinity) return 'Infinity';
+		if (f == -Infinity) return '-Infinity';
+		return f;
+	}
+
+	var parseJSONFloat = function(v, field) {
+		if (v == null) return 0;
+		if (typeof v === 'number') return v;
+		if (v === 'NaN') return NaN;
+		if (v === 'Infinity') return Infinity;
+		if (v === '-Infinity') return -Infinity;
+		throw 'colfer: JSON for field ' + field + ': ' + JSON.stringify(v) + ' not a floating point';
+	}
+
+	var formatRFC3339 = function(t, ns, field) {
+		var ms = t ? t.getTime() : 0;
+		var msf = (ms % 1E3 + 1E3) % 1E3;
+		var d = new Date(ms - msf);
+		var year = d.getUTCFullYear();
+		if (year < 0 || year > 9999)
+			throw 'colfer: ' + field + ' year ' + year + ' outside of RFC 3339 range';
+
+		var s = d.toISOString().substring(0, 19);
+		var frac = msf * 1E6 + (ns || 0);
+		if (frac) s += '.' + String(1E9 + frac).substring(1).replace(/0+$/, '');
+		return s + 'Z';
+	}
+
+	var parseRFC3339 = function(v, field) {
+		var m = typeof v === 'string' && /^([0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2})(?:\.([0-9]{1,9}))?(Z|[+-][0-9]{2}:[0-9]{2})$/.exec(v.toUpperCase());
+		var ms = m ? Date.parse(m[1] + m[3]) : NaN;
+		if (Number.isNaN(ms)) throw 'colfer: JSON for field ' + field + ': ' + JSON.stringify(v) + ' not an RFC 3339 timestamp';
+		var ns = m[2] ? Number((m[2] + '00000000').substring(0, 9)) : 0;
+		return [new Date(ms + Math.floor(ns / 1E6)), ns % 1E6];
+	}
+
+	const base64Chars = 'ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/';
+
+	var encodeBase64 = function(bytes) {
+		var s = '';
+		if (! bytes) return s;
+		for (var i = 0; i < bytes.length; i += 3) {
+			var x = bytes[i] << 16;
+			if (i + 1 < bytes.length) x |= bytes[i + 1] << 8;
+			if (i + 2 < bytes.length) x |= bytes[i + 2];
+			s += base64Chars[x >> 18 & 63] + base64Chars[x >> 12 & 63];
+			s += i + 1 < bytes.length ? base64Chars[x >> 6 & 63] : '=';
+			s += i + 2 < bytes.length ? base64Chars[x & 63] : '=';
+		}
+		return s;
+	}
+
+	var decodeBase64 = function(v, field) {
+		if (v == null) return new Uint8Array(0);
+		if (typeof v !== 'string' || v.length % 4 || ! /^[A-Za-z0-9+\/]*={0,2}$/.test(v))
+			throw 'colfer: JSON for field ' + field + ': ' + JSON.stringify(v) + ' not base64';
+
+		var n = v.length / 4 * 3;
+		if (v.charAt(v.length - 1) == '=') n--;
+		if (v.charAt(v.length - 2) == '=') n--;
+		var bytes = new Uint8Array(n);
+		for (var i = 0, j = 0; i < v.length; i += 4) {
+			var x = base64Chars.indexOf(v[i]) << 18 | base64Chars.indexOf(v[i + 1]) << 12
+				| (base64Chars.indexOf(v[i + 2]) & 63) << 6 | (base64Chars.indexOf(v[i + 3]) & 63);
+			bytes[j++] = x >> 16;
+			if (j < n) bytes[j++] = x >> 8 & 255;
+			if (j < n) bytes[j++] = x & 255;
+		}
+		return bytes;
+	}
+
 	var encodeUTF8 = function(s) {
 		var i = 0;
 		var bytes = new Uint8Array(s.length * 4);
@@ -661,6 +2572,34 @@ var gen = new function() {
 		return bytes.subarray(0, i);
 	}
 
+	// Returns the number of bytes before the first invalid UTF-8 sequence, if any.
+	var validUTF8Len = function(bytes) {
+		for (var i = 0; i < bytes.length; ) {
+			var c = bytes[i];
+			if (c < 128) {
+				++i;
+				continue;
+			}
+
+			var n, min;
+			if (c > 193 && c < 224) n = 1, min = 0x80;
+			else if (c > 223 && c < 240) n = 2, min = 0x800;
+			else if (c > 239 && c < 245) n = 3, min = 0x10000;
+			else return i;
+			if (i + n >= bytes.length) return i;
+
+			var v = c & (63 >> n);
+			for (var j = 1; j <= n; ++j) {
+				var b = bytes[i + j];
+				if ((b & 192) != 128) return i;
+				v = v << 6 | b & 63;
+			}
+			if (v < min || v > 0x10ffff || (v > 0xd7ff && v < 0xe000)) return i;
+			i += n + 1;
+		}
+		return bytes.length;
+	}
+
 	var decodeUTF8 = function(bytes) {
 		var s = '';
 		var i = 0;
@@ -83,6 +83,24 @@ QUnit.test('marshal', function(assert) {
 	}
 });
 
+QUnit.test('colferHash', function(assert) {
+	var crypto = require('crypto');
+	var golden = newGoldenCases();
+	for (hex in golden) {
+		var feed = golden[hex];
+		var desc = hex + ': ' + JSON.stringify(feed)
+		try {
+			var h = crypto.createHash('sha256');
+			var size = new gen.O(feed).colferHash(h);
+			assert.equal(size, hex.length / 2, desc);
+			var want = crypto.createHash('sha256').update(decodeHex(hex)).digest('hex');
+			assert.equal(h.digest('hex'), want, desc);
+		} catch (err) {
+			assert.equal(err, 'no error', desc);
+		}
+	}
+});
+
 QUnit.test('unmarshal', function(assert) {
 	var golden = newGoldenCases();
 	for (hex in golden) {
@@ -98,6 +116,188 @@ QUnit.test('unmarshal', function(assert) {
 	}
 });
 
+QUnit.test('depth', function(assert) {
+	// three levels of gen.o in field o
+	var data = decodeHex('0a0a7f7f7f');
+	assert.equal(new gen.O().unmarshal(data), 5, 'within limit');
+	assert.throws(function() { new gen.O().unmarshal(data, 99) }, /^colfer: gen.o exceeds nesting depth 100$/, 'limit breach');
+});
+
+QUnit.test('embedded', function(assert) {
+	// o with u32 1
+	var data = decodeHex('000301017f7f');
+	var e = new gen.E();
+	assert.equal(e.unmarshal(data), 6, 'read size');
+	assert.deepEqual(e.m, decodeHex('01017f'), 'verbatim serial');
+	assert.deepEqual(e.marshal(), data, 'marshal');
+
+	// missing terminator
+	assert.throws(function() { new gen.E().unmarshal(decodeHex('000201017f')) }, /^colfer: unknown header at byte 3$/, 'framing');
+});
+
+QUnit.test('any', function(assert) {
+	// w with o {s "x"}
+	var data = decodeHex('000567656e2e6f0801787f7f');
+	var w = new gen.W({v: new gen.O({s: 'x'})});
+	assert.deepEqual(w.marshal(), data, 'marshal');
+
+	var got = new gen.W();
+	assert.equal(got.unmarshal(data), data.length, 'read size');
+	assert.ok(got.v instanceof gen.O, 'registry type');
+	assert.equal(got.v.s, 'x', 'nested value');
+	assert.ok(gen.newColferAny('gen.e') instanceof gen.E, 'registry lookup');
+	assert.equal(gen.newColferAny('gen.z'), null, 'registry miss');
+
+	assert.throws(function() { new gen.W().unmarshal(decodeHex('000567656e2e7a7f7f')) }, /^colfer: unknown header at byte 2$/, 'unknown type');
+
+	var json = JSON.stringify(w);
+	assert.equal(json, '{"v":{"type":"gen.o","value":{"s":"x"}}}', 'JSON');
+	assert.deepEqual(gen.W.fromJSON(json), w, 'JSON round trip');
+	assert.throws(function() { gen.W.fromJSON('{"v": {"type": "gen.z"}}') }, /not registered$/, 'JSON unknown type');
+});
+
+QUnit.test('validate', function(assert) {
+	var r = new gen.R({par: 4, lat: 52.37, name: 'ams', tags: ['a', 'b']});
+	r.validate();
+	assert.ok(true, 'valid');
+
+	var golden = [
+		[{par: 6}, 'colfer: field gen.r.par breaks rule max 5'],
+		[{lat: NaN}, 'colfer: field gen.r.lat breaks rule min -90'],
+		[{name: ''}, 'colfer: field gen.r.name breaks rule required'],
+		[{name: 'ĳs'}, 'colfer: field gen.r.name breaks rule pattern ^[a-z]+$'],
+		[{name: 'amsterdam'}, 'colfer: field gen.r.name breaks rule maxlen 8'],
+		[{tags: ['a', 'b', 'c']}, 'colfer: field gen.r.tags breaks rule maxlen 2'],
+		[{next: new gen.R({par: 4, name: 'x'})}, 'colfer: field gen.r.name breaks rule minlen 2'],
+	];
+	golden.forEach(function(gold) {
+		var o = new gen.R(r);
+		for (var p in gold[0]) o[p] = gold[0][p];
+		assert.throws(function() { o.validate() }, function(err) { return err === gold[1] }, gold[1]);
+	});
+
+	// name absent
+	var data = decodeHex('00047f');
+	assert.equal(new gen.R().unmarshal(data), 3, 'unmarshal without validation');
+	assert.throws(function() { new gen.R().unmarshal(data, 1, false, true) }, /^colfer: field gen.r.name breaks rule required$/, 'unmarshal with validation');
+});
+
+QUnit.test('redact', function(assert) {
+	var o = new gen.Old({pin: '1234', ref: new gen.Old({pin: '5678'})});
+	assert.deepEqual(o.toJSON(), {pin: '1234', ref: {pin: '5678'}}, 'JSON');
+	assert.equal(JSON.stringify(o), '{"pin":"1234","ref":{"pin":"5678"}}', 'stringify');
+	assert.deepEqual(o.toRedactedJSON(), {pin: '<redacted>', ref: {pin: '<redacted>'}}, 'redacted JSON');
+	assert.equal(String(o), 'gen.old{"pin":"<redacted>","ref":{"pin":"<redacted>"}}', 'string');
+	assert.equal(String(new gen.R({par: 4, name: 'ams'})), 'gen.r{"par":4,"name":"ams"}', 'string without sensitive fields');
+});
+
+QUnit.test('names', function(assert) {
+	var o = new gen.Renamed({ident: 7});
+	var got = new gen.Renamed();
+	got.unmarshal(o.marshal());
+	assert.equal(got.ident, 7, 'override');
+	assert.deepEqual(o.toJSON(), {id: 7}, 'JSON member');
+});
+
+QUnit.test('strict', function(assert) {
+	var golden = newGoldenCases();
+	for (hex in golden) {
+		try {
+			new gen.O().unmarshal(decodeHex(hex), 1, true);
+			assert.ok(true, hex);
+		} catch (err) {
+			assert.equal(err, 'no error', hex);
+		}
+	}
+
+	var nonCanonical = {
+		// explicit zero values
+		'0e007f': 0,
+		'01007f': 0,
+		'83007f': 0,
+		'05000000007f': 0,
+		'05800000007f': 0,
+		'08007f': 0,
+		'09007f': 0,
+		'0b007f': 0,
+		'10007f': 0,
+		// fixed size where compact applies, and vice versa
+		'0f00017f': 0,
+		'81000000017f': 0,
+		'8200000000000000017f': 0,
+		'01808080017f': 0,
+		'870000000000000001000000007f': 0,
+		// redundant varint bytes
+		'0181007f': 2,
+		'088100617f': 2,
+		// nanoseconds overflow into the seconds
+		'07000000013b9aca007f': 5,
+		// invalid UTF-8
+		'080261ff7f': 3,
+		'0c02016102c3287f': 5,
+		// nested data structures
+		'0a01007f7f': 1,
+		'0b0101007f7f': 2,
+	};
+	for (hex in nonCanonical) {
+		var data = decodeHex(hex);
+		assert.throws(function() { new gen.O().unmarshal(data, 1, true) }, new RegExp('^colfer: non-canonical encoding at byte ' + nonCanonical[hex] + '$'), hex);
+	}
+});
+
+QUnit.test('alloc corpus', function(assert) {
+	var fs = require('fs');
+	var dir = '../testdata/corpus/';
+	fs.readdirSync(dir).filter(function(name) {
+		return name.startsWith('alloc-');
+	}).forEach(function(name) {
+		var data = new Uint8Array(fs.readFileSync(dir + name));
+		assert.throws(function() { new gen.O().unmarshal(data) }, /^colfer: (EOF|.* exceeds .*)$/, name);
+	});
+});
+
+QUnit.test('budget', function(assert) {
+	// gen.o with o {os [{}, {}]} and ss ["a", "b"]
+	var data = new Uint8Array([0x0a, 0x0b, 0x02, 0x7f, 0x7f, 0x7f, 0x0c, 0x02, 0x01, 0x61, 0x01, 0x62, 0x7f]);
+
+	var budget = {bytes: 1 << 20};
+	new gen.O().unmarshal(data, 1, false, false, budget);
+	var used = (1 << 20) - budget.bytes;
+	assert.ok(used > 0, 'budget reduced by ' + used + ' bytes');
+
+	budget.bytes = used;
+	new gen.O().unmarshal(data, 1, false, false, budget);
+	assert.equal(budget.bytes, 0, 'exact budget left');
+
+	budget.bytes = used - 1;
+	assert.throws(function() { new gen.O().unmarshal(data, 1, false, false, budget) }, /^colfer: gen.o.ss exceeds the allocation budget$/, 'short budget');
+});
+
+QUnit.test('JSON', function(assert) {
+	var golden = newGoldenCases();
+	for (hex in golden) {
+		var o = new gen.O(golden[hex]);
+		try {
+			var json = JSON.stringify(o);
+		} catch (err) {
+			// years beyond 9999 have no RFC 3339 notation
+			assert.ok(/outside of RFC 3339 range$/.test(err), hex + ': ' + err);
+			continue;
+		}
+		try {
+			assert.deepEqual(gen.O.fromJSON(json), o, hex + ': ' + json);
+		} catch (err) {
+			assert.equal(err, 'no error', hex + ': ' + json);
+		}
+	}
+
+	assert.throws(function() { gen.O.fromJSON('{"x": 1}') }, /^colfer: JSON member "x" not in struct gen.o$/, 'unknown member');
+	assert.throws(function() { gen.O.fromJSON('{"u8": 256}') }, /out of range$/, 'uint8 overflow');
+	assert.throws(function() { gen.O.fromJSON('{"t": "2015-09-08"}') }, /not an RFC 3339 timestamp$/, 'date only');
+	assert.deepEqual(gen.O.fromJSON('{"u64": "9007199254740991", "f32": "-Infinity"}'),
+		new gen.O({u64: Number.MAX_SAFE_INTEGER, f32: -Infinity}), 'quoted numbers');
+});
+
 function encodeHex(bytes) {
 	var s = '';
 	if (!bytes) return s;
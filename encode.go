@@ -0,0 +1,525 @@
+package colfer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// EncodeJSON returns the Colfer serial of a JSON object which is mapped on s.
+// The object's member names match the schema field names. Integers are
+// accepted as JSON numbers and as decimal JSON strings, which makes 64-bit
+// values safe for JavaScript producers. Floating points are JSON numbers or
+// one of the strings "NaN", "Infinity" and "-Infinity". Timestamps are RFC
+// 3339 strings, binaries are base64 strings (standard encoding with padding)
+// and data structures are JSON objects. Embedded Colfer serials are binaries
+// which must end with the 0x7f terminator. Any fields are JSON objects with
+// the qualified schema name as "type" and the data structure as "value".
+// Lists are JSON arrays. A JSON null equals the zero value, including for list
+// elements. Duplicate members are rejected, as their value is ambiguous.
+//
+// The limits of s.Pkg apply. An empty SizeMax, ListMax or DepthMax expression
+// disables the respective check.
+func EncodeJSON(s *Struct, doc []byte) ([]byte, error) {
+	var l limits
+	var err error
+	if l.size, err = evalLimit(s.Pkg.SizeMax); err != nil {
+		return nil, err
+	}
+	if l.list, err = evalLimit(s.Pkg.ListMax); err != nil {
+		return nil, err
+	}
+	if l.depth, err = evalLimit(s.Pkg.DepthMax); err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	v, err := decodeJSON(dec, s.String())
+	if err != nil {
+		return nil, fmt.Errorf("colfer: JSON for struct %s: %s", s, err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("colfer: JSON for struct %s: data continuation after object", s)
+	}
+
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("colfer: JSON for struct %s: got %s, want object", s, jsonKind(v))
+	}
+	return l.appendStruct(nil, s, obj, s.String())
+}
+
+// decodeJSON reads the next value from dec like encoding/json does into an
+// empty interface, yet it rejects duplicate object members. Path locates the
+// value for error reporting.
+func decodeJSON(dec *json.Decoder, path string) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		obj := make(map[string]interface{})
+		for dec.More() {
+			token, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			name := token.(string) // object keys are strings
+			if _, ok := obj[name]; ok {
+				return nil, fmt.Errorf("member %q at %s: duplicate", name, path)
+			}
+			if obj[name], err = decodeJSON(dec, path+"."+name); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+
+	case json.Delim('['):
+		a := []interface{}{}
+		for dec.More() {
+			e, err := decodeJSON(dec, fmt.Sprintf("%s[%d]", path, len(a)))
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, e)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return a, nil
+	}
+	return token, nil
+}
+
+// limits are the evaluated upper limits.
+type limits struct {
+	size, list, depth int
+
+	// level is the current number of data structures.
+	level int
+}
+
+// evalLimit returns the value of a constant Go expression, like the defaults
+// of colf(1). The empty expression has no limit.
+func evalLimit(expr string) (int, error) {
+	if expr == "" {
+		return math.MaxInt32, nil
+	}
+	tv, err := types.Eval(token.NewFileSet(), nil, token.NoPos, expr)
+	if err != nil {
+		return 0, fmt.Errorf("colfer: limit expression %q: %s", expr, err)
+	}
+	if tv.Value == nil || tv.Value.Kind() != constant.Int {
+		return 0, fmt.Errorf("colfer: limit expression %q not an integer constant", expr)
+	}
+	n, ok := constant.Int64Val(tv.Value)
+	if !ok || n < 0 || n > math.MaxInt32 {
+		return 0, fmt.Errorf("colfer: limit expression %q out of range", expr)
+	}
+	return int(n), nil
+}
+
+func (l *limits) appendStruct(buf []byte, s *Struct, obj map[string]interface{}, path string) ([]byte, error) {
+	if l.level >= l.depth {
+		return nil, fmt.Errorf("colfer: JSON at %s: struct %s exceeds nesting depth %d", path, s, l.depth)
+	}
+	l.level++
+	defer func() { l.level-- }()
+
+	start := len(buf)
+
+	values := make([]interface{}, len(s.Fields))
+	for name, v := range obj {
+		var f *Field
+		for _, candidate := range s.Fields {
+			if candidate.Name == name {
+				f = candidate
+				break
+			}
+		}
+		if f == nil {
+			return nil, fmt.Errorf("colfer: JSON member %q at %s: no such field in struct %s", name, path, s)
+		}
+		values[f.Index] = v
+	}
+
+	for _, f := range s.Fields {
+		v := values[f.Index]
+		if v == nil {
+			continue
+		}
+
+		var err error
+		fieldPath := path + "." + f.Name
+		if f.TypeList {
+			buf, err = l.appendList(buf, f, v, fieldPath)
+		} else {
+			buf, err = l.appendField(buf, f, v, fieldPath)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	buf = append(buf, 0x7f)
+
+	if len(buf)-start > l.size {
+		return nil, fmt.Errorf("colfer: JSON at %s: struct %s exceeds %d bytes", path, s, l.size)
+	}
+	return buf, nil
+}
+
+func (l *limits) appendField(buf []byte, f *Field, v interface{}, path string) ([]byte, error) {
+	index := byte(f.Index)
+
+	switch f.Type {
+	case "bool":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, typeMismatch(path, f.Type, v)
+		}
+		if b {
+			buf = append(buf, index)
+		}
+
+	case "uint8", "uint16", "uint32", "uint64":
+		x, err := jsonUint(v, f, path)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case x == 0:
+			// zero values are omitted
+		case f.Type == "uint8":
+			buf = append(buf, index, byte(x))
+		case f.Type == "uint16":
+			if x >= 1<<8 {
+				buf = append(buf, index, byte(x>>8), byte(x))
+			} else {
+				buf = append(buf, index|0x80, byte(x))
+			}
+		case f.Type == "uint32" && x >= 1<<21:
+			buf = append(buf, index|0x80, 0, 0, 0, 0)
+			binary.BigEndian.PutUint32(buf[len(buf)-4:], uint32(x))
+		case f.Type == "uint64" && x >= 1<<49:
+			buf = append(buf, index|0x80, 0, 0, 0, 0, 0, 0, 0, 0)
+			binary.BigEndian.PutUint64(buf[len(buf)-8:], x)
+		default:
+			buf = appendVarint(append(buf, index), x)
+		}
+
+	case "int32", "int64":
+		x, err := jsonInt(v, f, path)
+		if err != nil {
+			return nil, err
+		}
+		if x != 0 {
+			u := uint64(x)
+			if x < 0 {
+				u = ^u + 1
+				index |= 0x80
+			}
+			buf = append(buf, index)
+			for n := 0; u >= 0x80 && n < 8; n++ {
+				buf = append(buf, byte(u|0x80))
+				u >>= 7
+			}
+			buf = append(buf, byte(u))
+		}
+
+	case "float32", "float64":
+		x, err := jsonFloat(v, f, path)
+		if err != nil {
+			return nil, err
+		}
+		if x != 0 {
+			buf = append(buf, index)
+			buf = appendFloat(buf, f.Type, x)
+		}
+
+	case "timestamp":
+		str, ok := v.(string)
+		if !ok {
+			return nil, typeMismatch(path, f.Type, v)
+		}
+		t, err := time.Parse(time.RFC3339Nano, str)
+		if err != nil {
+			return nil, fmt.Errorf("colfer: JSON at %s: %s", path, err)
+		}
+		if t.IsZero() {
+			break
+		}
+		if s := uint64(t.Unix()); s < 1<<32 {
+			buf = append(buf, index, 0, 0, 0, 0, 0, 0, 0, 0)
+			binary.BigEndian.PutUint32(buf[len(buf)-8:], uint32(s))
+		} else {
+			buf = append(buf, index|0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+			binary.BigEndian.PutUint64(buf[len(buf)-12:], s)
+		}
+		binary.BigEndian.PutUint32(buf[len(buf)-4:], uint32(t.Nanosecond()))
+
+	case "text", "binary":
+		b, err := l.jsonBytes(v, f, path)
+		if err != nil {
+			return nil, err
+		}
+		if f.TypeColfer && len(b) != 0 && b[len(b)-1] != 0x7f {
+			return nil, fmt.Errorf("colfer: JSON at %s: embedded serial ends with 0x%02x, want 0x7f", path, b[len(b)-1])
+		}
+		if len(b) != 0 {
+			buf = appendVarint(append(buf, index), uint64(len(b)))
+			buf = append(buf, b...)
+		}
+
+	case "any":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, typeMismatch(path, f.Type, v)
+		}
+		var name string
+		var value map[string]interface{}
+		for member, e := range obj {
+			switch member {
+			case "type":
+				if name, ok = e.(string); !ok {
+					return nil, typeMismatch(path+".type", "text", e)
+				}
+			case "value":
+				if value, ok = e.(map[string]interface{}); e != nil && !ok {
+					return nil, typeMismatch(path+".value", name, e)
+				}
+			default:
+				return nil, fmt.Errorf("colfer: JSON member %q at %s: not type nor value", member, path)
+			}
+		}
+		var s *Struct
+		for _, candidate := range f.Struct.Pkg.Structs {
+			if candidate.String() == name {
+				s = candidate
+			}
+		}
+		if s == nil {
+			return nil, fmt.Errorf("colfer: JSON at %s: type %q not in package %s", path, name, f.Struct.Pkg.Name)
+		}
+		buf = appendVarint(append(buf, index), uint64(len(name)))
+		buf = append(buf, name...)
+		return l.appendStruct(buf, s, value, path+".value")
+
+	default:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, typeMismatch(path, f.Type, v)
+		}
+		buf = append(buf, index)
+		return l.appendStruct(buf, f.TypeRef, obj, path)
+	}
+
+	return buf, nil
+}
+
+func (l *limits) appendList(buf []byte, f *Field, v interface{}, path string) ([]byte, error) {
+	a, ok := v.([]interface{})
+	if !ok {
+		return nil, typeMismatch(path, "[]"+f.Type, v)
+	}
+	if len(a) == 0 {
+		return buf, nil
+	}
+	if len(a) > l.list {
+		return nil, fmt.Errorf("colfer: JSON at %s: length %d exceeds %d elements", path, len(a), l.list)
+	}
+
+	buf = appendVarint(append(buf, byte(f.Index)), uint64(len(a)))
+	for i, e := range a {
+		elementPath := fmt.Sprintf("%s[%d]", path, i)
+
+		switch f.Type {
+		case "float32", "float64":
+			var x float64
+			if e != nil {
+				var err error
+				if x, err = jsonFloat(e, f, elementPath); err != nil {
+					return nil, err
+				}
+			}
+			buf = appendFloat(buf, f.Type, x)
+
+		case "text", "binary":
+			var b []byte
+			if e != nil {
+				var err error
+				if b, err = l.jsonBytes(e, f, elementPath); err != nil {
+					return nil, err
+				}
+			}
+			buf = appendVarint(buf, uint64(len(b)))
+			buf = append(buf, b...)
+
+		default:
+			obj, ok := e.(map[string]interface{})
+			if e != nil && !ok {
+				return nil, typeMismatch(elementPath, f.Type, e)
+			}
+			var err error
+			if buf, err = l.appendStruct(buf, f.TypeRef, obj, elementPath); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buf, nil
+}
+
+// jsonBytes returns the payload of a text or binary.
+func (l *limits) jsonBytes(v interface{}, f *Field, path string) ([]byte, error) {
+	str, ok := v.(string)
+	if !ok {
+		return nil, typeMismatch(path, f.Type, v)
+	}
+
+	var b []byte
+	if f.Type == "text" {
+		if !utf8.ValidString(str) {
+			return nil, fmt.Errorf("colfer: JSON at %s: invalid UTF-8", path)
+		}
+		b = []byte(str)
+	} else {
+		var err error
+		b, err = base64.StdEncoding.DecodeString(str)
+		if err != nil {
+			return nil, fmt.Errorf("colfer: JSON at %s: base64: %s", path, err)
+		}
+	}
+
+	if len(b) > l.size {
+		return nil, fmt.Errorf("colfer: JSON at %s: size %d exceeds %d bytes", path, len(b), l.size)
+	}
+	return b, nil
+}
+
+// jsonNumber returns the textual representation of a JSON number or string.
+func jsonNumber(v interface{}) (s string, ok bool) {
+	switch v := v.(type) {
+	case json.Number:
+		return string(v), true
+	case string:
+		return v, true
+	}
+	return "", false
+}
+
+func jsonUint(v interface{}, f *Field, path string) (uint64, error) {
+	s, ok := jsonNumber(v)
+	if !ok {
+		return 0, typeMismatch(path, f.Type, v)
+	}
+	bits := 64
+	switch f.Type {
+	case "uint8":
+		bits = 8
+	case "uint16":
+		bits = 16
+	case "uint32":
+		bits = 32
+	}
+	x, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("colfer: JSON at %s: %q is not a %s", path, s, f.Type)
+	}
+	return x, nil
+}
+
+func jsonInt(v interface{}, f *Field, path string) (int64, error) {
+	s, ok := jsonNumber(v)
+	if !ok {
+		return 0, typeMismatch(path, f.Type, v)
+	}
+	bits := 64
+	if f.Type == "int32" {
+		bits = 32
+	}
+	x, err := strconv.ParseInt(s, 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("colfer: JSON at %s: %q is not an %s", path, s, f.Type)
+	}
+	return x, nil
+}
+
+func jsonFloat(v interface{}, f *Field, path string) (float64, error) {
+	switch v := v.(type) {
+	case json.Number:
+		bits := 64
+		if f.Type == "float32" {
+			bits = 32
+		}
+		x, err := strconv.ParseFloat(string(v), bits)
+		if err != nil {
+			return 0, fmt.Errorf("colfer: JSON at %s: %s exceeds %s", path, v, f.Type)
+		}
+		return x, nil
+	case string:
+		switch v {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+	}
+	return 0, typeMismatch(path, f.Type, v)
+}
+
+func appendFloat(buf []byte, typ string, x float64) []byte {
+	if typ == "float32" {
+		buf = append(buf, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(buf[len(buf)-4:], math.Float32bits(float32(x)))
+	} else {
+		buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(buf[len(buf)-8:], math.Float64bits(x))
+	}
+	return buf
+}
+
+func appendVarint(buf []byte, x uint64) []byte {
+	for x >= 0x80 {
+		buf = append(buf, byte(x|0x80))
+		x >>= 7
+	}
+	return append(buf, byte(x))
+}
+
+func typeMismatch(path, typ string, v interface{}) error {
+	return fmt.Errorf("colfer: JSON at %s: got %s for type %s", path, jsonKind(v), typ)
+}
+
+// jsonKind returns the JSON type name of a decoded value.
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,164 @@
+package colfer
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func testStruct(t *testing.T) *Struct {
+	packages, err := ParseFiles([]string{"testdata/test.colf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := packages[0]
+	p.SizeMax = "16 * 1024 * 1024"
+	p.ListMax = "64 * 1024"
+	p.DepthMax = "100"
+	return p.Structs[0]
+}
+
+func TestEncodeJSON(t *testing.T) {
+	golden := []struct{ json, serial string }{
+		{`{}`, "7f"},
+		{`{"b": true}`, "007f"},
+		{`{"b": false, "u32": 0, "s": "", "o": null}`, "7f"},
+		{`{"u32": 4294967295}`, "81ffffffff7f"},
+		{`{"u64": "18446744073709551615"}`, "82ffffffffffffffff7f"},
+		{`{"i32": -128}`, "8380017f"},
+		{`{"i64": "-9223372036854775808"}`, "848080808080808080807f"},
+		{`{"f32": "NaN"}`, "057fc000007f"},
+		{`{"f64": 1.7976931348623157e308}`, "067fefffffffffffff7f"},
+		{`{"t": "2015-09-08T19:04:10.777888999Z"}`, "0755ef312a2e5da4e77f"},
+		{`{"t": "1969-12-31T23:59:59.777888999Z"}`, "87ffffffffffffffff2e5da4e77f"},
+		{`{"s": "a\u0000"}`, "080261007f"},
+		{`{"a": "AgA="}`, "090202007f"},
+		{`{"o": {"b": true}}`, "0a007f7f"},
+		{`{"os": [{}, null]}`, "0b027f7f7f"},
+		{`{"ss": ["", "a", "b"]}`, "0c0300016101627f"},
+		{`{"as": ["AA==", "AQI="]}`, "0d0201000201027f"},
+		{`{"u8": 255}`, "0eff7f"},
+		{`{"u16": 1}`, "8f017f"},
+		{`{"f32s": [0, 1]}`, "1002000000003f8000007f"},
+		{`{"f64s": [99]}`, "11014058c000000000007f"},
+	}
+
+	s := testStruct(t)
+	for _, gold := range golden {
+		serial, err := EncodeJSON(s, []byte(gold.json))
+		if err != nil {
+			t.Errorf("%s: %s", gold.json, err)
+			continue
+		}
+		if got := hex.EncodeToString(serial); got != gold.serial {
+			t.Errorf("%s: got 0x%s, want 0x%s", gold.json, got, gold.serial)
+		}
+	}
+}
+
+func TestEncodeJSONErrors(t *testing.T) {
+	golden := []struct{ json, want string }{
+		{`[]`, "got array, want object"},
+		{`{"x": 1}`, `JSON member "x" at gen.o: no such field`},
+		{`{"b": 1}`, "at gen.o.b: got number for type bool"},
+		{`{"u8": 256}`, `at gen.o.u8: "256" is not a uint8`},
+		{`{"i32": 1.5}`, `at gen.o.i32: "1.5" is not an int32`},
+		{`{"t": 0}`, "at gen.o.t: got number for type timestamp"},
+		{`{"a": "*"}`, "at gen.o.a: base64"},
+		{`{"o": {"os": [{"s": true}]}}`, "at gen.o.o.os[0].s: got boolean for type text"},
+		{`{"ss": "a"}`, "at gen.o.ss: got string for type []text"},
+		{`{"b": true, "b": false}`, `member "b" at gen.o: duplicate`},
+		{`{"o": {"s": "a", "s": "a"}}`, `member "s" at gen.o.o: duplicate`},
+		{`{"os": [{}, {"u8": 1, "u8": 2}]}`, `member "u8" at gen.o.os[1]: duplicate`},
+	}
+
+	s := testStruct(t)
+	for _, gold := range golden {
+		_, err := EncodeJSON(s, []byte(gold.json))
+		if err == nil {
+			t.Errorf("%s: no error", gold.json)
+			continue
+		}
+		if !strings.Contains(err.Error(), gold.want) {
+			t.Errorf("%s: got error %q, want %q", gold.json, err, gold.want)
+		}
+	}
+}
+
+func TestEncodeJSONEmbedded(t *testing.T) {
+	s := testStruct(t).Pkg.Structs[1]
+	if s.Name != "e" {
+		t.Fatalf("got struct %s, want gen.e", s)
+	}
+
+	golden := []struct{ json, serial string }{
+		{`{"m": ""}`, "7f"},
+		{`{"m": "fw=="}`, "00017f7f"},
+		{`{"m": "AAB/"}`, "000300007f7f"},
+	}
+	for _, gold := range golden {
+		serial, err := EncodeJSON(s, []byte(gold.json))
+		if err != nil {
+			t.Errorf("%s: %s", gold.json, err)
+			continue
+		}
+		if got := hex.EncodeToString(serial); got != gold.serial {
+			t.Errorf("%s: got 0x%s, want 0x%s", gold.json, got, gold.serial)
+		}
+	}
+
+	_, err := EncodeJSON(s, []byte(`{"m": "fwA="}`))
+	if err == nil || !strings.Contains(err.Error(), "at gen.e.m: embedded serial ends with 0x00, want 0x7f") {
+		t.Errorf("got error %v for unterminated serial", err)
+	}
+}
+
+func TestEncodeJSONLimits(t *testing.T) {
+	s := testStruct(t)
+	s.Pkg.SizeMax = "4"
+	s.Pkg.ListMax = "2"
+
+	if _, err := EncodeJSON(s, []byte(`{"s": "four"}`)); err == nil || !strings.Contains(err.Error(), "exceeds 4 bytes") {
+		t.Errorf("got error %v for size breach", err)
+	}
+	if _, err := EncodeJSON(s, []byte(`{"ss": ["", "", ""]}`)); err == nil || !strings.Contains(err.Error(), "exceeds 2 elements") {
+		t.Errorf("got error %v for list breach", err)
+	}
+
+	s.Pkg.DepthMax = "2"
+	if _, err := EncodeJSON(s, []byte(`{"o": {}}`)); err != nil {
+		t.Errorf("got error %v within depth limit", err)
+	}
+	if _, err := EncodeJSON(s, []byte(`{"os": [{"o": {}}]}`)); err == nil || !strings.Contains(err.Error(), "at gen.o.os[0].o: struct gen.o exceeds nesting depth 2") {
+		t.Errorf("got error %v for depth breach", err)
+	}
+}
+
+func TestEncodeJSONAny(t *testing.T) {
+	packages, err := ParseFiles([]string{"testdata/test.colf", "testdata/any.colf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := packages[0]
+	p.SizeMax = "16 * 1024 * 1024"
+	p.DepthMax = "100"
+	var s *Struct
+	for _, candidate := range p.Structs {
+		if candidate.Name == "w" {
+			s = candidate
+		}
+	}
+
+	serial, err := EncodeJSON(s, []byte(`{"v": {"type": "gen.o", "value": {"s": "x"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hex.EncodeToString(serial), "000567656e2e6f0801787f7f"; got != want {
+		t.Errorf("got 0x%s, want 0x%s", got, want)
+	}
+
+	_, err = EncodeJSON(s, []byte(`{"v": {"type": "gen.z"}}`))
+	if err == nil || !strings.Contains(err.Error(), `at gen.w.v: type "gen.z" not in package gen`) {
+		t.Errorf("got error %v for unknown type", err)
+	}
+}
@@ -2,24 +2,54 @@ package colfer
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
 )
 
-// GenerateGo writes the code into file "Colfer.go".
+// GenerateGo writes the code into file "Colfer.go", and it writes the fuzz
+// tests into file "Colfer_fuzz_test.go" when enabled with FuzzCorpus.
 func GenerateGo(basedir string, packages []*Package) error {
 	t := template.New("go-code")
 	template.Must(t.Parse(goCode))
 	template.Must(t.New("marshal-field").Parse(goMarshalField))
 	template.Must(t.New("marshal-field-len").Parse(goMarshalFieldLen))
+	template.Must(t.New("append-field").Parse(goAppendField))
+	template.Must(t.New("hash-field").Parse(goHashField))
 	template.Must(t.New("unmarshal-field").Parse(goUnmarshalField))
 	template.Must(t.New("unmarshal-varint").Parse(goUnmarshalVarint))
+	template.Must(t.New("unmarshal-strict").Parse(goUnmarshalStrict))
+	template.Must(t.New("unmarshal-skip").Parse(goUnmarshalSkip))
+	template.Must(t.New("marshal-json-field").Parse(goMarshalJSONField))
+	template.Must(t.New("unmarshal-json-field").Parse(goUnmarshalJSONField))
+	template.Must(t.New("equal-field").Parse(goEqualField))
+	template.Must(t.New("clone-field").Parse(goCloneField))
+	template.Must(t.New("merge-field").Parse(goMergeField))
+	template.Must(t.New("diff-field").Parse(goDiffField))
+	template.Must(t.New("validate-field").Parse(goValidateField))
+	fuzzTemplate := template.Must(template.New("go-fuzz").Parse(goFuzz))
 
 	for _, p := range packages {
 		p.NameNative = p.Name[strings.LastIndexByte(p.Name, '/')+1:]
+		for _, s := range p.Structs {
+			s.title = s.Names["go"]
+			for _, f := range s.Fields {
+				f.title = f.Names["go"]
+			}
+		}
+	}
+	if err := checkNativeNames(packages, "go", (*Struct).NameTitle, (*Field).NameTitle); err != nil {
+		return err
+	}
+	if err := checkGoTypes(packages); err != nil {
+		return err
+	}
+	if err := checkGoMethods(packages); err != nil {
+		return err
 	}
 
 	for _, p := range packages {
@@ -31,6 +61,9 @@ func GenerateGo(basedir string, packages []*Package) error {
 						f.TypeNative = f.Type
 					} else {
 						f.TypeNative = f.TypeRef.NameTitle()
+						if p.Lazy {
+							f.TypeNative += "Lazy"
+						}
 						if f.TypeRef.Pkg != p {
 							f.TypeNative = f.TypeRef.Pkg.NameNative + "." + f.TypeNative
 						}
@@ -41,7 +74,11 @@ func GenerateGo(basedir string, packages []*Package) error {
 					f.TypeNative = "string"
 				case "binary":
 					f.TypeNative = "[]byte"
+				case "any":
+					f.TypeNative = "ColferAny"
 				}
+				f.MinNative, f.MaxNative = f.Min, f.Max
+				f.TagNative = goTag(f)
 			}
 		}
 
@@ -55,18 +92,119 @@ func GenerateGo(basedir string, packages []*Package) error {
 			return err
 		}
 
-		path = filepath.Join(path, "Colfer.go")
-		if err := ioutil.WriteFile(path, buf.Bytes(), 0666); err != nil {
+		file := filepath.Join(path, "Colfer.go")
+		if err := ioutil.WriteFile(file, buf.Bytes(), 0666); err != nil {
 			return err
 		}
-
-		if _, err := Format(path); err != nil {
+		if _, err := Format(file); err != nil {
 			return err
 		}
+
+		if p.FuzzCorpus != "" {
+			buf.Reset()
+			if err := fuzzTemplate.Execute(&buf, p); err != nil {
+				return err
+			}
+			file = filepath.Join(path, "Colfer_fuzz_test.go")
+			if err := ioutil.WriteFile(file, buf.Bytes(), 0666); err != nil {
+				return err
+			}
+			if _, err := Format(file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkGoTypes rejects structs with the name of a generated package-level
+// identifier, which are the Colfer prefix and the lazy holders.
+func checkGoTypes(packages []*Package) error {
+	for _, p := range packages {
+		names := make(map[string]*Struct, len(p.Structs))
+		for _, s := range p.Structs {
+			names[s.NameTitle()] = s
+		}
+
+		for _, s := range p.Structs {
+			name := s.NameTitle()
+			if strings.HasPrefix(name, "Colfer") || strings.HasPrefix(name, "NewColfer") {
+				return fmt.Errorf("colfer: struct %s has go name %q, which clashes with the generated Colfer prefix; set another one with a Names line", s, name)
+			}
+			if !p.Lazy {
+				continue
+			}
+			for _, lazy := range []string{name + "Lazy", "New" + name + "Lazy"} {
+				if dupe, ok := names[lazy]; ok {
+					return fmt.Errorf("colfer: struct %s has go name %q, which clashes with the lazy holder of %s; set another one with a Names line", dupe, lazy, s)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// goMethods are the names of the methods on each generated struct.
+var goMethods = []string{
+	"AppendColfer", "AppendColferWith", "Clone", "ColferHash", "ColferHashWith",
+	"Diff", "Equal", "GoString", "HasColferPath", "MarshalBinary",
+	"MarshalJSON", "MarshalJSONWith", "MarshalLen", "MarshalLenWith",
+	"MarshalTo", "Merge", "String", "Unmarshal", "UnmarshalBinary",
+	"UnmarshalJSON", "UnmarshalJSONWith", "UnmarshalNoCopy", "UnmarshalWith",
+	"Validate",
+}
+
+// checkGoMethods rejects fields with the name of a method on their struct, as
+// Go does not allow both.
+func checkGoMethods(packages []*Package) error {
+	for _, p := range packages {
+		methods := append([]string(nil), goMethods...)
+		if p.HasAny() {
+			methods = append(methods, "ColferType")
+		}
+		if p.SQL {
+			methods = append(methods, "Scan", "Value")
+		}
+
+		for _, s := range p.Structs {
+			for _, f := range s.Fields {
+				name := f.NameTitle()
+				for _, m := range methods {
+					if name == m {
+						return fmt.Errorf("colfer: field %s has go name %q, which clashes with a generated method; set another one with a go tag", f, name)
+					}
+				}
+			}
+		}
 	}
 	return nil
 }
 
+// goTag returns the struct tag literal of f, if any.
+func goTag(f *Field) string {
+	tags := f.Tags
+	if f.Struct.Pkg.JSONTags {
+		var custom bool
+		for _, t := range tags {
+			if strings.HasPrefix(t, "json:") {
+				custom = true
+			}
+		}
+		if !custom {
+			tags = append([]string{fmt.Sprintf(`json:"%s,omitempty"`, f.Name)}, tags...)
+		}
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+
+	tag := strings.Join(tags, " ")
+	if strings.ContainsRune(tag, '`') {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
 const goCode = `{{.DocText "// "}}
 package {{.NameNative}}
 
@@ -74,21 +212,45 @@ package {{.NameNative}}
 // The compiler used schema file {{.SchemaFileList}}.
 
 import (
+	"bufio"
+{{- if .SQL}}
+	"database/sql/driver"
+{{- end}}
+{{- if not .Runtime}}
 	"encoding/binary"
+{{- end}}
+	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
 {{- if .HasFloat}}
 	"math"
 {{- end}}
+{{- if .HasPattern}}
+	"regexp"
+{{- end}}
+{{- if or .HasInteger .HasFloat}}
+	"strconv"
+{{- end}}
 {{- if .HasTimestamp}}
 	"time"
 {{- end}}
+{{- if .HasText}}
+	"unicode/utf8"
+{{- end}}
+{{- if or .NoCopy .HasList .HasStruct .HasAny}}
+	"unsafe"
+{{- end}}
 {{- range .Refs}}
 	"{{.Name}}"
 {{- end}}
+{{- if .Runtime}}
+
+	"github.com/pascaldekloe/colfer/rt"
+{{- end}}
 )
 
-var intconv = binary.BigEndian
+{{if .Runtime}}var intconv = rt.IntConv{{else}}var intconv = binary.BigEndian{{end}}
 
 // Colfer configuration attributes
 var (
@@ -98,8 +260,30 @@ var (
 	// ColferListMax is the upper limit for the number of elements in a list.
 	ColferListMax = {{.ListMax}}
 {{- end}}
+	// ColferDepthMax is the upper limit for the number of nested data
+	// structure levels, including the root.
+	ColferDepthMax = {{.DepthMax}}
 )
 
+{{- if .Runtime}}
+// ColferMax signals an upper limit breach.
+type ColferMax = rt.ColferMax
+
+// ColferError signals a data mismatch as as a byte index.
+type ColferError = rt.ColferError
+
+// ColferTail signals data continuation as a byte index.
+type ColferTail = rt.ColferTail
+
+// ColferNonCanonical signals an encoding deviation as a byte index.
+type ColferNonCanonical = rt.ColferNonCanonical
+
+// ColferDiff is a field difference between two data structures.
+type ColferDiff = rt.ColferDiff
+
+// ColferInvalid signals a schema rule violation.
+type ColferInvalid = rt.ColferInvalid
+{{- else}}
 // ColferMax signals an upper limit breach.
 type ColferMax string
 
@@ -121,12 +305,412 @@ type ColferTail int
 func (i ColferTail) Error() string {
 	return fmt.Sprintf("colfer: data continuation at byte %d", i)
 }
+
+// ColferNonCanonical signals an encoding deviation as a byte index.
+type ColferNonCanonical int
+
+// Error honors the error interface.
+func (i ColferNonCanonical) Error() string {
+	return fmt.Sprintf("colfer: non-canonical encoding at byte %d", i)
+}
+
+// ColferDiff is a field difference between two data structures.
+type ColferDiff struct {
+	// Path locates the field with the schema names, e.g.,
+	// "course.holes[3].par".
+	Path string
+	// Old and New are the respective values, with nil for absence.
+	Old, New interface{}
+}
+
+// String returns the difference in a human readable form.
+func (d ColferDiff) String() string {
+	return fmt.Sprintf("%s: %v -> %v", d.Path, d.Old, d.New)
+}
+
+// ColferInvalid signals a schema rule violation.
+type ColferInvalid struct {
+	// Field is the qualified name, e.g., "demo.hole.par".
+	Field string
+	// Rule is the schema tag, e.g., "max 5".
+	Rule string
+}
+
+// Error honors the error interface.
+func (e ColferInvalid) Error() string {
+	return fmt.Sprintf("colfer: field %s breaks rule %s", e.Field, e.Rule)
+}
+{{- end}}
+
+// ColferOptions are limits for a single call, as an alternative to the
+// package-level configuration attributes. The zero value of each limit means
+// the respective configuration attribute.
+type ColferOptions struct {
+	// SizeMax is the upper limit for serial byte sizes. Zero means
+	// ColferSizeMax.
+	SizeMax int
+	// ListMax is the upper limit for the number of elements in a list. Zero
+	// means ColferListMax, if the package has lists.
+	ListMax int
+	// DepthMax is the upper limit for the number of data structure levels,
+	// including the root. Zero means ColferDepthMax, and a negative value
+	// means no limit.
+	DepthMax int
+	// Strict makes Unmarshal reject any serial which differs from the
+	// output of Marshal for the same data with a ColferNonCanonical.
+	Strict bool
+	// Validate makes Unmarshal check the schema rules of each data
+	// structure, conform Validate.
+	Validate bool
+{{- if .NoCopy}}
+	// NoCopy makes text and binary fields share memory with the serial data.
+	// See UnmarshalNoCopy for the lifetime contract.
+	NoCopy bool
+{{- end}}
+	// JSONQuote64 makes MarshalJSONWith encode 64-bit integers as JSON
+	// strings, which keeps them exact for JavaScript consumers.
+	JSONQuote64 bool
+	// Budget, when not nil, is the number of bytes which may still be
+	// allocated for field values, including those of nested data structures.
+	// Unmarshal deducts each allocation, and it fails with ColferMax before
+	// any allocation exceeds the remainder.
+	Budget *int
+	// Fields, when not nil, selects the fields to decode by path, with the
+	// schema names separated by dots, e.g., "o.s". A data structure field
+	// selects all of its nested fields. Unmarshal skips the other fields
+	// without allocation, and it leaves their values as is.
+	// See HasColferPath for validation.
+	Fields []string
+}
+
+// limits returns opts with the package-level configuration attributes in
+// place of any zero limit.
+func (opts ColferOptions) limits() ColferOptions {
+	if opts.SizeMax == 0 {
+		opts.SizeMax = ColferSizeMax
+	}
+{{- if .HasList}}
+	if opts.ListMax == 0 {
+		opts.ListMax = ColferListMax
+	}
+{{- end}}
+	if opts.DepthMax == 0 {
+		opts.DepthMax = ColferDepthMax
+	}
+	return opts
+}
+
+// nested returns the options for a data structure in field, which is a
+// level deeper.
+func (opts ColferOptions) nested(field string) (ColferOptions, error) {
+	switch {
+	case opts.DepthMax == 1:
+		return opts, ColferMax(fmt.Sprintf("colfer: field %s exceeds the nesting depth limit", field))
+	case opts.DepthMax > 1:
+		opts.DepthMax--
+	}
+	return opts, nil
+}
+
+// selects returns whether Fields includes field, either as a whole or with
+// any of its nested fields.
+func (opts ColferOptions) selects(field string) bool {
+	if opts.Fields == nil {
+		return true
+	}
+	for _, p := range opts.Fields {
+		if len(p) >= len(field) && p[:len(field)] == field && (len(p) == len(field) || p[len(field)] == '.') {
+			return true
+		}
+	}
+	return false
+}
+{{- if or .HasStruct .HasAny}}
+
+// within returns the Fields for the data structure in field, with nil for all.
+func (opts ColferOptions) within(field string) []string {
+	if opts.Fields == nil {
+		return nil
+	}
+	var paths []string
+	for _, p := range opts.Fields {
+		if p == field {
+			return nil
+		}
+		if len(p) > len(field) && p[:len(field)] == field && p[len(field)] == '.' {
+			paths = append(paths, p[len(field)+1:])
+		}
+	}
+	return paths
+}
+{{- end}}
+
+// charge deducts n bytes for field from the allocation budget, if any.
+func (opts ColferOptions) charge(field string, n int) error {
+	if opts.Budget == nil {
+		return nil
+	}
+	if n > *opts.Budget {
+		return ColferMax(fmt.Sprintf("colfer: field %s exceeds the allocation budget", field))
+	}
+	*opts.Budget -= n
+	return nil
+}
+
+// colferCanon returns a ColferNonCanonical on the first byte in serial that
+// differs from canon. The serial starts at byte index offset.
+func colferCanon(canon, serial []byte, offset int) error {
+	for i := range serial {
+		if i >= len(canon) || serial[i] != canon[i] {
+			return ColferNonCanonical(offset + i)
+		}
+	}
+	if len(serial) != len(canon) {
+		return ColferNonCanonical(offset + len(serial))
+	}
+	return nil
+}
+{{- if .HasText}}
+
+// colferUTF8Len returns the number of bytes in p before the first invalid
+// UTF-8 sequence, if any.
+func colferUTF8Len(p []byte) int {
+	for i := 0; i < len(p); {
+		r, size := utf8.DecodeRune(p[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return len(p)
+}
+{{- end}}
+
+// colferOptions returns the package-level configuration attributes.
+func colferOptions() ColferOptions {
+	return ColferOptions{
+		SizeMax: ColferSizeMax,
+{{- if .HasList}}
+		ListMax: ColferListMax,
+{{- end}}
+		DepthMax: ColferDepthMax,
+	}
+}
+{{- if .HasAny}}
+
+// ColferAny is a data structure of any type in this package. The serial of an
+// any field has the qualified schema name, e.g., "{{(index .Structs 0).String}}", next to the
+// data such that Unmarshal can decode to the concrete type. Nil means absence.
+type ColferAny interface {
+	// ColferType returns the qualified schema name.
+	ColferType() string
+	MarshalTo(buf []byte) int
+	MarshalLenWith(opts ColferOptions) (int, error)
+	AppendColferWith(dst []byte, opts ColferOptions) ([]byte, error)
+	ColferHashWith(h hash.Hash, opts ColferOptions) (int, error)
+	UnmarshalWith(data []byte, opts ColferOptions) (int, error)
+	HasColferPath(path string) bool
+	Validate() error
+	json.Marshaler
+	json.Unmarshaler
+	MarshalJSONWith(opts ColferOptions) ([]byte, error)
+	UnmarshalJSONWith(data []byte, opts ColferOptions) error
+
+	colferNew(opts ColferOptions, field string) (ColferAny, error)
+	colferEqual(other ColferAny) bool
+	colferClone() ColferAny
+	colferMerge(other ColferAny) ColferAny
+	colferDiff(other ColferAny) []ColferDiff
+}
+
+// NewColferAny returns a new data structure for the qualified schema name, or
+// nil when the name is not registered. The registry has all data structures
+// of this package.
+func NewColferAny(name string) ColferAny {
+	t := colferAnyType(name)
+	if t == nil {
+		return nil
+	}
+	v, _ := t.colferNew(ColferOptions{}, name)
+	return v
+}
+
+// colferAnyType returns a nil pointer of the data structure registered under
+// the qualified schema name, or nil when the name is not registered.
+func colferAnyType(name string) ColferAny {
+	switch name {
+{{- range .Structs}}
+	case "{{.String}}":
+		return (*{{.NameTitle}})(nil)
+{{- end}}
+	}
+	return nil
+}
+
+// colferAnyPath returns whether path locates a field in any of the registered
+// data structures.
+func colferAnyPath(path string) bool {
+	return {{range $i, $s := .Structs}}{{if $i}} ||
+		{{end}}(*{{$s.NameTitle}})(nil).HasColferPath(path){{end}}
+}
+{{- end}}
+
+// ColferDecoder reads consecutive Colfer serials from a stream.
+// The read buffer is reused between calls.
+type ColferDecoder struct {
+	r   io.Reader
+	err error // pending read error
+
+	// buf is the read buffer.
+	buf []byte
+	// offset is the index of the first data byte in buf.
+	offset int
+	// i is the index of the data end (exclusive) in buf.
+	i int
+}
+
+// NewColferDecoder returns a new decoder which reads from r.
+func NewColferDecoder(r io.Reader) *ColferDecoder {
+	size := 2048
+	if size > ColferSizeMax {
+		size = ColferSizeMax
+	}
+	return &ColferDecoder{r: r, buf: make([]byte, size)}
+}
+
+// Decode reads the next serial into v, which is any of the generated structs.
+// When the stream ends on a serial boundary, then the error is io.EOF. A stream
+// which ends within a serial gives io.ErrUnexpectedEOF instead.
+// The error return options are io.EOF, io.ErrUnexpectedEOF, ColferError and
+// ColferMax, or any error from the reader.
+func (d *ColferDecoder) Decode(v interface {
+	Unmarshal([]byte) (int, error)
+}) error {
+	for {
+		if d.offset < d.i {
+			n, err := v.Unmarshal(d.buf[d.offset:d.i])
+			if err != io.EOF {
+				if err == nil {
+					d.offset += n
+				}
+				return err
+			}
+		}
+		// not enough data
+
+		if d.err != nil {
+			if d.err == io.EOF && d.offset < d.i {
+				return io.ErrUnexpectedEOF
+			}
+			return d.err
+		}
+
+		if d.offset >= d.i {
+			d.offset, d.i = 0, 0
+		} else if d.i >= len(d.buf) {
+			if d.offset == 0 {
+				if len(d.buf) >= ColferSizeMax {
+					return ColferMax(fmt.Sprintf("colfer: serial exceeds %d bytes", ColferSizeMax))
+				}
+				// grow
+				size := len(d.buf) * 4
+				if size > ColferSizeMax {
+					size = ColferSizeMax
+				}
+				bigger := make([]byte, size)
+				copy(bigger, d.buf)
+				d.buf = bigger
+			} else {
+				// move data to start of buffer
+				copy(d.buf, d.buf[d.offset:d.i])
+				d.i -= d.offset
+				d.offset = 0
+			}
+		}
+
+		var n int
+		n, d.err = d.r.Read(d.buf[d.i:])
+		d.i += n
+	}
+}
+
+// ColferEncoder writes Colfer serials to a stream.
+// The write buffer is reused between calls.
+type ColferEncoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewColferEncoder returns a new encoder which writes to w.
+func NewColferEncoder(w io.Writer) *ColferEncoder {
+	return &ColferEncoder{w: w}
+}
+
+// Encode writes the serial of v, which is any of the generated structs, with
+// a single call to the writer.
+// The error return options are ColferMax, or any error from the writer.
+func (e *ColferEncoder) Encode(v interface {
+	MarshalLen() (int, error)
+	MarshalTo([]byte) int
+}) error {
+	l, err := v.MarshalLen()
+	if err != nil {
+		return err
+	}
+	if l > cap(e.buf) {
+		e.buf = make([]byte, l)
+	}
+	buf := e.buf[:l]
+	v.MarshalTo(buf)
+	_, err = e.w.Write(buf)
+	return err
+}
+
+// ColferSplit returns a bufio.SplitFunc for consecutive serials, which makes
+// each token a serial of v. The tokens are decoded into v as a side effect.
+// Note that bufio.Scanner limits the token size to bufio.MaxScanTokenSize,
+// unless configured otherwise, e.g., with ColferSizeMax as the maximum.
+func ColferSplit(v interface {
+	Unmarshal([]byte) (int, error)
+}) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if len(data) == 0 {
+			return 0, nil, nil
+		}
+		n, err := v.Unmarshal(data)
+		switch err {
+		case nil:
+			return n, data[:n], nil
+		case io.EOF:
+			if atEOF {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		default:
+			return 0, nil, err
+		}
+	}
+}
+{{- if .NoCopy}}
+
+// colferNoCopyString returns the bytes as a string without copying.
+func colferNoCopyString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return *(*string)(unsafe.Pointer(&b))
+}
+{{- end}}
 {{range .Structs}}
 {{.DocText "// "}}
 type {{.NameTitle}} struct {
 {{range .Fields}}{{.DocText "\t// "}}
-	{{.NameTitle}}	{{if .TypeList}}[]{{end}}{{if .TypeRef}}*{{end}}{{.TypeNative}}
+	{{.NameTitle}}	{{if .TypeList}}[]{{end}}{{if .TypeRef}}*{{end}}{{.TypeNative}}{{with .TagNative}}	{{.}}{{end}}
 {{end}}}
+{{- if .Pkg.Runtime}}
+
+var _ rt.Message = (*{{.NameTitle}})(nil)
+{{- end}}
 
 // MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
 // If the buffer is too small, MarshalTo will panic.
@@ -144,10 +728,18 @@ func (o *{{.NameTitle}}) MarshalTo(buf []byte) int {
 // MarshalLen returns the Colfer serial byte size.
 // The error return option is {{.Pkg.NameNative}}.ColferMax.
 func (o *{{.NameTitle}}) MarshalLen() (int, error) {
+	return o.MarshalLenWith(colferOptions())
+}
+
+// MarshalLenWith is like MarshalLen, yet with the limits of opts instead of the
+// package-level configuration attributes.
+// The error return option is {{.Pkg.NameNative}}.ColferMax.
+func (o *{{.NameTitle}}) MarshalLenWith(opts ColferOptions) (int, error) {
+	opts = opts.limits()
 	l := 1
 {{range .Fields}}{{template "marshal-field-len" .}}{{end}}
-	if l > ColferSizeMax {
-		return l, ColferMax(fmt.Sprintf("colfer: struct {{.String}} exceeds %d bytes", ColferSizeMax))
+	if l > opts.SizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct {{.String}} exceeds %d bytes", opts.SizeMax))
 	}
 	return l, nil
 }
@@ -167,28 +759,138 @@ func (o *{{.NameTitle}}) MarshalBinary() (data []byte, err error) {
 	return data, nil
 }
 
+// AppendColfer appends the Colfer serial of o to dst and returns the extended
+// buffer. Unlike MarshalBinary, the object is walked only once.
+{{- range .Fields}}{{if and .TypeList .TypeRef}}
+// All nil entries in o.{{.NameTitle}} will be replaced with a new value.
+{{- end}}{{end}}
+// When an error occurs, dst is returned with its original length.
+// The error return option is {{.Pkg.NameNative}}.ColferMax.
+func (o *{{.NameTitle}}) AppendColfer(dst []byte) ([]byte, error) {
+	return o.AppendColferWith(dst, colferOptions())
+}
+
+// AppendColferWith is like AppendColfer, yet with the limits of opts instead
+// of the package-level configuration attributes.
+// The error return option is {{.Pkg.NameNative}}.ColferMax.
+func (o *{{.NameTitle}}) AppendColferWith(dst []byte, opts ColferOptions) ([]byte, error) {
+	opts = opts.limits()
+	buf := dst
+{{range .Fields}}{{template "append-field" .}}{{end}}
+	buf = append(buf, 0x7f)
+	if len(buf)-len(dst) > opts.SizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct {{.String}} exceeds %d bytes", opts.SizeMax))
+	}
+	return buf, nil
+}
+
+// ColferHash writes the Colfer serial of o to h, without materializing the
+// serial as a whole. Equal values produce the same input for h in each of the
+// supported languages.
+// The error return option is {{.Pkg.NameNative}}.ColferMax.
+func (o *{{.NameTitle}}) ColferHash(h hash.Hash) error {
+	_, err := o.ColferHashWith(h, colferOptions())
+	return err
+}
+
+// ColferHashWith is like ColferHash, yet with the limits of opts instead of the
+// package-level configuration attributes. The return is the number of bytes
+// written to h. When an error occurs, then the state of h is undefined.
+// The error return option is {{.Pkg.NameNative}}.ColferMax.
+func (o *{{.NameTitle}}) ColferHashWith(h hash.Hash, opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	var scratch [32]byte
+	buf := scratch[:0]
+	var n int
+{{range .Fields}}{{template "hash-field" .}}{{end}}
+	buf = append(buf, 0x7f)
+	h.Write(buf)
+	n += len(buf)
+	if n > opts.SizeMax {
+		return n, ColferMax(fmt.Sprintf("colfer: struct {{.String}} exceeds %d bytes", opts.SizeMax))
+	}
+	return n, nil
+}
+
 // Unmarshal decodes data as Colfer and returns the number of bytes read.
 // The error return options are io.EOF, {{.Pkg.NameNative}}.ColferError and {{.Pkg.NameNative}}.ColferMax.
 func (o *{{.NameTitle}}) Unmarshal(data []byte) (int, error) {
+	return o.UnmarshalWith(data, colferOptions())
+}
+{{- if .Pkg.NoCopy}}
+
+// UnmarshalNoCopy is like Unmarshal, yet the text and binary fields, including
+// those of nested data structures, share memory with data instead of holding a
+// copy. Any modification to data is visible through o, and vice versa, for as
+// long as o is in use. The caller must not reuse or recycle data (buffers)
+// before o and all of the values read from it are no longer referenced.
+// The error return options are io.EOF, {{.Pkg.NameNative}}.ColferError and {{.Pkg.NameNative}}.ColferMax.
+func (o *{{.NameTitle}}) UnmarshalNoCopy(data []byte) (int, error) {
+	opts := colferOptions()
+	opts.NoCopy = true
+	return o.UnmarshalWith(data, opts)
+}
+{{- end}}
+
+// UnmarshalWith is like Unmarshal, yet with the limits and the field selection
+// of opts instead of the package-level configuration attributes.
+// The error return options are io.EOF, {{.Pkg.NameNative}}.ColferError, {{.Pkg.NameNative}}.ColferMax,
+// with opts.Strict, {{.Pkg.NameNative}}.ColferNonCanonical and, with opts.Validate,
+// {{.Pkg.NameNative}}.ColferInvalid.
+func (o *{{.NameTitle}}) UnmarshalWith(data []byte, opts ColferOptions) (int, error) {
+	opts = opts.limits()
 	if len(data) == 0 {
 		return 0, io.EOF
 	}
 	header := data[0]
 	i := 1
-{{range .Fields}}{{template "unmarshal-field" .}}{{end}}
+{{range .Fields}}{{template "unmarshal-skip" .}}{{template "unmarshal-field" .}}{{end}}
 	if header != 0x7f {
 		return 0, ColferError(i - 1)
 	}
-	if i < ColferSizeMax {
+	if i < opts.SizeMax {
+{{- if .HasRules}}
+		if opts.Validate {
+			if err := o.colferValidate(opts); err != nil {
+				return 0, err
+			}
+		}
+{{- end}}
 		return i, nil
 	}
 eof:
-	if i >= ColferSizeMax {
-		return 0, ColferMax(fmt.Sprintf("colfer: struct {{.String}} size exceeds %d bytes", ColferSizeMax))
+	if i >= opts.SizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct {{.String}} size exceeds %d bytes", opts.SizeMax))
 	}
 	return 0, io.EOF
 }
 
+// HasColferPath returns whether path locates a field in the schema, with the
+// names separated by dots, e.g., "o.s". See ColferOptions.Fields.
+func (*{{.NameTitle}}) HasColferPath(path string) bool {
+	name, nested := path, false
+	for i := 0; i < len(path); i++ {
+		if path[i] == '.' {
+			name, nested = path[:i], true
+			break
+		}
+	}
+
+	switch name {
+{{- range .Fields}}
+	case "{{.Name}}":
+{{- if .TypeRef}}
+		return !nested || (*{{.TypeNative}})(nil).HasColferPath(path[len(name)+1:])
+{{- else if eq .Type "any"}}
+		return !nested || colferAnyPath(path[len(name)+1:])
+{{- else}}
+		return !nested
+{{- end}}
+{{- end}}
+	}
+	return false
+}
+
 // UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
 // The error return options are io.EOF, {{.Pkg.NameNative}}.ColferError, {{.Pkg.NameNative}}.ColferTail and {{.Pkg.NameNative}}.ColferMax.
 func (o *{{.NameTitle}}) UnmarshalBinary(data []byte) error {
@@ -198,40 +900,596 @@ func (o *{{.NameTitle}}) UnmarshalBinary(data []byte) error {
 	}
 	return err
 }
-{{end}}`
+{{- if .Pkg.SQL}}
 
-const goMarshalField = `{{if eq .Type "bool"}}
-	if o.{{.NameTitle}} {
-		buf[i] = {{.Index}}
-		i++
+// Value returns the serial data conform database/sql/driver.Valuer, with nil
+// (NULL) for a nil o. The error return option is {{.Pkg.NameNative}}.ColferMax.
+func (o *{{.NameTitle}}) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
 	}
-{{else if eq .Type "uint8"}}
-	if x := o.{{.NameTitle}}; x != 0 {
-		buf[i] = {{.Index}}
-		i++
-		buf[i] = x
-		i++
+	return o.MarshalBinary()
+}
+
+// Scan decodes the serial data of a []byte or string conform database/sql.Scanner.
+// Any previous content of o is discarded, including on NULL, which reads as the
+// zero value. Scan into a **{{.NameTitle}} to get nil for NULL instead. The error
+// return options are io.ErrUnexpectedEOF, {{.Pkg.NameNative}}.ColferError,
+// {{.Pkg.NameNative}}.ColferTail and {{.Pkg.NameNative}}.ColferMax.
+func (o *{{.NameTitle}}) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*o = {{.NameTitle}}{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("colfer: struct {{.String}} scan from SQL type %T, want []byte or string", src)
 	}
-{{else if eq .Type "uint16"}}
-	if x := o.{{.NameTitle}}; x >= 1<<8 {
-		buf[i] = {{.Index}}
-		i++
-		buf[i] = byte(x >> 8)
-		i++
-		buf[i] = byte(x)
-		i++
-	} else if x != 0 {
-		buf[i] = {{.Index}} | 0x80
-		i++
-		buf[i] = byte(x)
-		i++
+
+	// no carry-over from previous rows
+	*o = {{.NameTitle}}{}
+	err := o.UnmarshalBinary(data)
+	if err == io.EOF {
+		// column content is complete
+		err = io.ErrUnexpectedEOF
 	}
-{{else if eq .Type "uint32"}}
-	if x := o.{{.NameTitle}}; x >= 1<<21 {
-		buf[i] = {{.Index}} | 0x80
-		intconv.PutUint32(buf[i+1:], x)
-		i += 5
-	} else if x != 0 {
+	return err
+}
+{{- end}}
+
+// MarshalJSON encodes o as JSON conform json.Marshaler. The members are named
+// after the schema fields and zero values are omitted. Timestamps are RFC 3339
+// strings in UTC and binaries are base64 strings. NaN and infinite floating
+// points are JSON strings too.
+func (o *{{.NameTitle}}) MarshalJSON() ([]byte, error) {
+	return o.MarshalJSONWith(colferOptions())
+}
+
+// MarshalJSONWith is like MarshalJSON, yet with the opts.JSONQuote64 mode,
+// including for nested data structures.
+func (o *{{.NameTitle}}) MarshalJSONWith(opts ColferOptions) ([]byte, error) {
+	buf := []byte{'{'}
+{{range .Fields}}{{template "marshal-json-field" .}}{{end}}
+	if len(buf) == 1 {
+		return append(buf, '}'), nil
+	}
+	buf[len(buf)-1] = '}'
+	return buf, nil
+}
+
+// UnmarshalJSON decodes data as JSON conform json.Unmarshaler, with the same
+// mapping as MarshalJSON. Integers may also be JSON strings with a decimal
+// value, and JSON null equals the zero value. Unknown members are rejected.
+// The error return option is {{.Pkg.NameNative}}.ColferMax, next to the JSON errors.
+func (o *{{.NameTitle}}) UnmarshalJSON(data []byte) error {
+	return o.UnmarshalJSONWith(data, colferOptions())
+}
+
+// UnmarshalJSONWith is like UnmarshalJSON, yet with the list limit of opts
+// instead of the package-level configuration attribute, including for nested
+// data structures.
+func (o *{{.NameTitle}}) UnmarshalJSONWith(data []byte, opts ColferOptions) error {
+	opts = opts.limits()
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	for name, raw := range members {
+		switch name {
+{{- range .Fields}}{{template "unmarshal-json-field" .}}{{end}}
+		default:
+			return fmt.Errorf("colfer: JSON member %q not in struct {{.String}}", name)
+		}
+	}
+	return nil
+}
+
+// Equal returns whether o and other have the same Colfer serial. Nil and empty
+// lists are equal, nil list entries equal the zero value, timestamps compare
+// as instants, and floating points compare by their bits, except for zero.
+func (o *{{.NameTitle}}) Equal(other *{{.NameTitle}}) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+{{range .Fields}}{{template "equal-field" .}}{{end}}
+	return true
+}
+
+// Diff returns the fields which differ between o and other in schema order,
+// with o as the old values. Data structures compare field by field and lists
+// compare element by element, conform Equal. A nil o or other compares as the
+// zero value.
+func (o *{{.NameTitle}}) Diff(other *{{.NameTitle}}) []ColferDiff {
+	if o == nil {
+		o = new({{.NameTitle}})
+	}
+	if other == nil {
+		other = new({{.NameTitle}})
+	}
+	var diffs []ColferDiff
+{{range .Fields}}{{template "diff-field" .}}{{end}}
+	return diffs
+}
+
+// Clone returns a deep copy of o, or nil when o is nil.
+func (o *{{.NameTitle}}) Clone() *{{.NameTitle}} {
+	if o == nil {
+		return nil
+	}
+	c := *o
+{{range .Fields}}{{template "clone-field" .}}{{end}}
+	return &c
+}
+
+// Merge copies the non-zero fields of other into o. Nested data structures
+// merge recursively, and lists are replaced with a copy.
+func (o *{{.NameTitle}}) Merge(other *{{.NameTitle}}) {
+	if other == nil {
+		return
+	}
+{{range .Fields}}{{template "merge-field" .}}{{end}}}
+
+// String returns a human-readable form for debugging, with the sensitive
+// fields masked.
+func (o *{{.NameTitle}}) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("{{.Pkg.NameNative}}.{{.NameTitle}}{
+{{- range $i, $f := .Fields}}{{if $i}} {{end}}{{.NameTitle}}:{{if .Sensitive}}<redacted>{{else if eq .Type "text" "binary"}}%q{{else}}%v{{end}}{{end}}}"
+{{- range .Fields}}{{if not .Sensitive}}, o.{{.NameTitle}}{{end}}{{end}})
+}
+
+// GoString returns the Go syntax for debugging, with the sensitive fields
+// masked.
+func (o *{{.NameTitle}}) GoString() string {
+	if o == nil {
+		return "(*{{.Pkg.NameNative}}.{{.NameTitle}})(nil)"
+	}
+	return fmt.Sprintf("&{{.Pkg.NameNative}}.{{.NameTitle}}{
+{{- range $i, $f := .Fields}}{{if $i}}, {{end}}{{.NameTitle}}:{{if .Sensitive}}<redacted>{{else}}%#v{{end}}{{end}}}"
+{{- range .Fields}}{{if not .Sensitive}}, o.{{.NameTitle}}{{end}}{{end}})
+}
+
+// Validate checks the fields against the schema rules, including the ones of
+// nested data structures. A nil o validates as the zero value.
+// The error return option is {{.Pkg.NameNative}}.ColferInvalid.
+func (o *{{.NameTitle}}) Validate() error {
+	if o == nil {
+		o = new({{.NameTitle}})
+	}
+{{- if .HasRules}}
+	if err := o.colferValidate(ColferOptions{}); err != nil {
+		return err
+	}
+{{- end}}
+{{- range .Fields}}
+{{- if and .TypeRef .TypeList}}
+	for _, v := range o.{{.NameTitle}} {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+{{- else if or .TypeRef (eq .Type "any")}}
+	if o.{{.NameTitle}} != nil {
+		if err := o.{{.NameTitle}}.Validate(); err != nil {
+			return err
+		}
+	}
+{{- end}}
+{{- end}}
+	return nil
+}
+{{- if .HasRules}}
+{{- range .Fields}}{{if .Pattern}}
+
+// colfer{{.Struct.NameTitle}}{{.NameTitle}}Pattern is the rule for field {{.Name}}.
+var colfer{{.Struct.NameTitle}}{{.NameTitle}}Pattern = regexp.MustCompile({{printf "%q" .Pattern}})
+{{- end}}{{end}}
+
+// colferValidate checks the fields selected by opts against the schema rules,
+// without the nested data structures.
+func (o *{{.NameTitle}}) colferValidate(opts ColferOptions) error {
+{{- range .Fields}}{{if .HasRules}}
+	if opts.selects("{{.Name}}") {
+{{- template "validate-field" .}}
+	}
+{{- end}}{{end}}
+	return nil
+}
+{{- end}}
+{{- if .Pkg.HasAny}}
+
+// ColferType returns the qualified schema name conform ColferAny.
+func (*{{.NameTitle}}) ColferType() string { return "{{.String}}" }
+
+func (*{{.NameTitle}}) colferNew(opts ColferOptions, field string) (ColferAny, error) {
+	if err := opts.charge(field, int(unsafe.Sizeof({{.NameTitle}}{}))); err != nil {
+		return nil, err
+	}
+	return new({{.NameTitle}}), nil
+}
+
+func (o *{{.NameTitle}}) colferEqual(other ColferAny) bool {
+	p, ok := other.(*{{.NameTitle}})
+	return ok && o.Equal(p)
+}
+
+func (o *{{.NameTitle}}) colferClone() ColferAny { return o.Clone() }
+
+func (o *{{.NameTitle}}) colferMerge(other ColferAny) ColferAny {
+	p, ok := other.(*{{.NameTitle}})
+	if !ok {
+		return other.colferClone()
+	}
+	o.Merge(p)
+	return o
+}
+
+func (o *{{.NameTitle}}) colferDiff(other ColferAny) []ColferDiff {
+	p, _ := other.(*{{.NameTitle}})
+	return o.Diff(p)
+}
+{{- end}}
+{{- if .Pkg.Lazy}}
+
+// {{.NameTitle}}Lazy holds a {{.String}} which is decoded on first access. The
+// serial data of an untouched value is marshalled as is. A nil value encodes
+// as the zero value. Any access may decode, so concurrent use is not safe.
+type {{.NameTitle}}Lazy struct {
+	serial []byte        // pending decode when not nil
+	opts   ColferOptions // applies to serial
+	v      *{{.NameTitle}}
+}
+
+// New{{.NameTitle}}Lazy returns a holder with v as its value.
+func New{{.NameTitle}}Lazy(v *{{.NameTitle}}) *{{.NameTitle}}Lazy {
+	return &{{.NameTitle}}Lazy{v: v}
+}
+
+// Get returns the value, which is decoded from the serial data on the first
+// call. Modifications to the value are included in the serial output. A nil l
+// has a nil value. The error return options are the ones of UnmarshalWith,
+// which can only occur when the serial data was modified (in breach of the
+// NoCopy contract).
+func (l *{{.NameTitle}}Lazy) Get() (*{{.NameTitle}}, error) {
+	if l == nil {
+		return nil, nil
+	}
+	if l.serial != nil {
+		v := new({{.NameTitle}})
+		if _, err := v.UnmarshalWith(l.serial, l.opts); err != nil {
+			return nil, err
+		}
+		l.v, l.serial = v, nil
+	}
+	return l.v, nil
+}
+
+// Set replaces the value, and it discards any pending serial data.
+func (l *{{.NameTitle}}Lazy) Set(v *{{.NameTitle}}) {
+	l.v, l.serial = v, nil
+}
+
+// value returns the value with the zero value for nil.
+func (l *{{.NameTitle}}Lazy) value() (*{{.NameTitle}}, error) {
+	if l == nil {
+		return nil, nil
+	}
+	v, err := l.Get()
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		v = new({{.NameTitle}})
+		l.v = v
+	}
+	return v, nil
+}
+
+// MarshalTo is like {{.NameTitle}}.MarshalTo.
+func (l *{{.NameTitle}}Lazy) MarshalTo(buf []byte) int {
+	switch {
+	case l.serial != nil:
+		return copy(buf, l.serial)
+	case l.v != nil:
+		return l.v.MarshalTo(buf)
+	}
+	buf[0] = 0x7f
+	return 1
+}
+
+// MarshalLenWith is like {{.NameTitle}}.MarshalLenWith.
+func (l *{{.NameTitle}}Lazy) MarshalLenWith(opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	switch {
+	case l.serial == nil && l.v != nil:
+		return l.v.MarshalLenWith(opts)
+	case len(l.serial) > opts.SizeMax:
+		return len(l.serial), ColferMax(fmt.Sprintf("colfer: struct {{.String}} exceeds %d bytes", opts.SizeMax))
+	case l.serial != nil:
+		return len(l.serial), nil
+	}
+	return 1, nil
+}
+
+// AppendColferWith is like {{.NameTitle}}.AppendColferWith.
+func (l *{{.NameTitle}}Lazy) AppendColferWith(dst []byte, opts ColferOptions) ([]byte, error) {
+	opts = opts.limits()
+	switch {
+	case l.serial == nil && l.v != nil:
+		return l.v.AppendColferWith(dst, opts)
+	case len(l.serial) > opts.SizeMax:
+		return dst, ColferMax(fmt.Sprintf("colfer: struct {{.String}} exceeds %d bytes", opts.SizeMax))
+	case l.serial != nil:
+		return append(dst, l.serial...), nil
+	}
+	return append(dst, 0x7f), nil
+}
+
+// ColferHashWith is like {{.NameTitle}}.ColferHashWith, which means that l is
+// decoded. Serial data may be non-canonical, so it is not hashed as is.
+func (l *{{.NameTitle}}Lazy) ColferHashWith(h hash.Hash, opts ColferOptions) (int, error) {
+	v, err := l.Get()
+	switch {
+	case err != nil:
+		return 0, err
+	case v == nil:
+		return h.Write([]byte{0x7f})
+	}
+	return v.ColferHashWith(h, opts)
+}
+
+// UnmarshalWith checks the serial data, and it keeps the bytes for decoding on
+// first access. Text and binaries share memory with data, like the rest of the
+// serial, only with opts.NoCopy. The opts.Strict, opts.Validate and opts.Budget
+// modes decode immediately instead.
+func (l *{{.NameTitle}}Lazy) UnmarshalWith(data []byte, opts ColferOptions) (int, error) {
+	if opts.Fields != nil && len(opts.Fields) == 0 {
+		// none selected, so the receiver is never read nor written
+		opts.Validate = false
+		return (*{{.NameTitle}})(nil).UnmarshalWith(data, opts)
+	}
+	if opts.Strict || opts.Validate || opts.Budget != nil {
+		v := new({{.NameTitle}})
+		n, err := v.UnmarshalWith(data, opts)
+		if err != nil {
+			return 0, err
+		}
+		l.Set(v)
+		return n, nil
+	}
+
+	check := opts
+	check.Fields, check.Validate = []string{}, false
+	n, err := (*{{.NameTitle}})(nil).UnmarshalWith(data, check)
+	if err != nil {
+		return 0, err
+	}
+	serial := data[:n:n]
+{{- if .Pkg.NoCopy}}
+	if !opts.NoCopy {
+		serial = append([]byte(nil), serial...)
+	}
+{{- else}}
+	serial = append([]byte(nil), serial...)
+{{- end}}
+	l.serial, l.opts, l.v = serial, opts, nil
+	return n, nil
+}
+
+// HasColferPath is like {{.NameTitle}}.HasColferPath.
+func (*{{.NameTitle}}Lazy) HasColferPath(path string) bool {
+	return (*{{.NameTitle}})(nil).HasColferPath(path)
+}
+
+// Validate is like {{.NameTitle}}.Validate, which may decode conform Get.
+func (l *{{.NameTitle}}Lazy) Validate() error {
+	v, err := l.Get()
+	if err != nil {
+		return err
+	}
+	return v.Validate()
+}
+
+// MarshalJSON is like {{.NameTitle}}.MarshalJSON, which means that l is decoded.
+func (l *{{.NameTitle}}Lazy) MarshalJSON() ([]byte, error) {
+	return l.MarshalJSONWith(colferOptions())
+}
+
+// MarshalJSONWith is like {{.NameTitle}}.MarshalJSONWith, which means that l is
+// decoded.
+func (l *{{.NameTitle}}Lazy) MarshalJSONWith(opts ColferOptions) ([]byte, error) {
+	v, err := l.value()
+	if err != nil {
+		return nil, err
+	}
+	return v.MarshalJSONWith(opts)
+}
+
+// UnmarshalJSON is like {{.NameTitle}}.UnmarshalJSON.
+func (l *{{.NameTitle}}Lazy) UnmarshalJSON(data []byte) error {
+	return l.UnmarshalJSONWith(data, colferOptions())
+}
+
+// UnmarshalJSONWith is like {{.NameTitle}}.UnmarshalJSONWith.
+func (l *{{.NameTitle}}Lazy) UnmarshalJSONWith(data []byte, opts ColferOptions) error {
+	v := new({{.NameTitle}})
+	if err := v.UnmarshalJSONWith(data, opts); err != nil {
+		return err
+	}
+	l.Set(v)
+	return nil
+}
+
+// Equal is like {{.NameTitle}}.Equal, which means that both l and other are
+// decoded. Serial data which fails to decode equals identical serial data only.
+func (l *{{.NameTitle}}Lazy) Equal(other *{{.NameTitle}}Lazy) bool {
+	v, err := l.value()
+	w, otherErr := other.value()
+	if err != nil || otherErr != nil {
+		return err != nil && otherErr != nil && string(l.serial) == string(other.serial)
+	}
+	return v.Equal(w)
+}
+
+// Diff is like {{.NameTitle}}.Diff, which means that both l and other are
+// decoded. Serial data which fails to decode differs as a whole, with an empty
+// path and with the decode error in place of the value.
+func (l *{{.NameTitle}}Lazy) Diff(other *{{.NameTitle}}Lazy) []ColferDiff {
+	v, err := l.value()
+	w, otherErr := other.value()
+	if err != nil || otherErr != nil {
+		if l.Equal(other) {
+			return nil
+		}
+		d := ColferDiff{Old: err, New: otherErr}
+		if err == nil {
+			d.Old = v
+		}
+		if otherErr == nil {
+			d.New = w
+		}
+		return []ColferDiff{d}
+	}
+	return v.Diff(w)
+}
+
+// Clone returns a deep copy of l, without decoding, or nil when l is nil.
+func (l *{{.NameTitle}}Lazy) Clone() *{{.NameTitle}}Lazy {
+	if l == nil {
+		return nil
+	}
+	c := &{{.NameTitle}}Lazy{opts: l.opts, v: l.v.Clone()}
+	if l.serial != nil {
+		c.serial = append([]byte(nil), l.serial...)
+	}
+	return c
+}
+
+// Merge is like {{.NameTitle}}.Merge, which means that both l and other are
+// decoded. Serial data which fails to decode, on either side, leaves l as is.
+func (l *{{.NameTitle}}Lazy) Merge(other *{{.NameTitle}}Lazy) {
+	if other == nil {
+		return
+	}
+	v, err := l.value()
+	if err != nil {
+		return
+	}
+	w, err := other.value()
+	if err != nil {
+		return
+	}
+	v.Merge(w)
+}
+
+// String is like {{.NameTitle}}.String, which means that l is decoded. Serial
+// data which fails to decode prints as the error.
+func (l *{{.NameTitle}}Lazy) String() string {
+	v, err := l.value()
+	if err != nil {
+		return "{{.String}}(" + err.Error() + ")"
+	}
+	return v.String()
+}
+
+// GoString is like {{.NameTitle}}.GoString, which means that l is decoded.
+// Serial data which fails to decode prints as the error.
+func (l *{{.NameTitle}}Lazy) GoString() string {
+	v, err := l.value()
+	if err != nil {
+		return "{{.String}}(" + err.Error() + ")"
+	}
+	return v.GoString()
+}
+{{- end}}
+{{end}}
+{{- if .HasInteger}}
+// colferJSONNumber returns the text of a JSON number, or the content of a JSON
+// string. JSON null reads as zero.
+func colferJSONNumber(raw json.RawMessage) string {
+	switch {
+	case string(raw) == "null":
+		return "0"
+	case len(raw) > 1 && raw[0] == '"':
+		return string(raw[1 : len(raw)-1])
+	}
+	return string(raw)
+}
+{{end}}
+{{- if .HasFloat}}
+// appendColferJSONFloat appends f as a JSON number, or as a JSON string for
+// NaN and the infinities.
+func appendColferJSONFloat(buf []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(buf, "\"NaN\""...)
+	case math.IsInf(f, 1):
+		return append(buf, "\"Infinity\""...)
+	case math.IsInf(f, -1):
+		return append(buf, "\"-Infinity\""...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize)
+}
+
+// parseColferJSONFloat reads a JSON number or one of the JSON strings "NaN",
+// "Infinity" and "-Infinity". JSON null reads as zero.
+func parseColferJSONFloat(raw json.RawMessage, bitSize int) (float64, error) {
+	switch string(raw) {
+	case "null":
+		return 0, nil
+	case "\"NaN\"":
+		return math.NaN(), nil
+	case "\"Infinity\"":
+		return math.Inf(1), nil
+	case "\"-Infinity\"":
+		return math.Inf(-1), nil
+	}
+	if len(raw) != 0 && raw[0] == '"' {
+		return 0, fmt.Errorf("JSON string %s not a floating point", raw)
+	}
+	return strconv.ParseFloat(string(raw), bitSize)
+}
+{{end}}`
+
+const goMarshalField = `{{if eq .Type "bool"}}
+	if o.{{.NameTitle}} {
+		buf[i] = {{.Index}}
+		i++
+	}
+{{else if eq .Type "uint8"}}
+	if x := o.{{.NameTitle}}; x != 0 {
+		buf[i] = {{.Index}}
+		i++
+		buf[i] = x
+		i++
+	}
+{{else if eq .Type "uint16"}}
+	if x := o.{{.NameTitle}}; x >= 1<<8 {
+		buf[i] = {{.Index}}
+		i++
+		buf[i] = byte(x >> 8)
+		i++
+		buf[i] = byte(x)
+		i++
+	} else if x != 0 {
+		buf[i] = {{.Index}} | 0x80
+		i++
+		buf[i] = byte(x)
+		i++
+	}
+{{else if eq .Type "uint32"}}
+	if x := o.{{.NameTitle}}; x >= 1<<21 {
+		buf[i] = {{.Index}} | 0x80
+		intconv.PutUint32(buf[i+1:], x)
+		i += 5
+	} else if x != 0 {
 		buf[i] = {{.Index}}
 		i++
 		for x >= 0x80 {
@@ -387,6 +1645,22 @@ const goMarshalField = `{{if eq .Type "bool"}}
 		i += copy(buf[i:], o.{{.NameTitle}})
  {{- end}}
 	}
+{{else if eq .Type "any"}}
+	if v := o.{{.NameTitle}}; v != nil {
+		buf[i] = {{.Index}}
+		i++
+		name := v.ColferType()
+		x := uint(len(name))
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], name)
+		i += v.MarshalTo(buf[i:])
+	}
 {{else if .TypeList}}
 	if l := len(o.{{.NameTitle}}); l != 0 {
 		buf[i] = {{.Index}}
@@ -415,80 +1689,440 @@ const goMarshalField = `{{if eq .Type "bool"}}
 	}
 {{end}}`
 
-const goMarshalFieldLen = `{{if eq .Type "bool"}}
+const goAppendField = `{{if eq .Type "bool"}}
 	if o.{{.NameTitle}} {
-		l++
+		buf = append(buf, {{.Index}})
 	}
 {{else if eq .Type "uint8"}}
 	if x := o.{{.NameTitle}}; x != 0 {
-		l += 2
+		buf = append(buf, {{.Index}}, x)
 	}
 {{else if eq .Type "uint16"}}
 	if x := o.{{.NameTitle}}; x >= 1<<8 {
-		l += 3
+		buf = append(buf, {{.Index}}, byte(x>>8), byte(x))
 	} else if x != 0 {
-		l += 2
+		buf = append(buf, {{.Index}}|0x80, byte(x))
 	}
 {{else if eq .Type "uint32"}}
 	if x := o.{{.NameTitle}}; x >= 1<<21 {
-		l += 5
+		buf = append(buf, {{.Index}}|0x80, 0, 0, 0, 0)
+		intconv.PutUint32(buf[len(buf)-4:], x)
 	} else if x != 0 {
-		for l += 2; x >= 0x80; l++ {
+		buf = append(buf, {{.Index}})
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
 			x >>= 7
 		}
+		buf = append(buf, byte(x))
 	}
 {{else if eq .Type "uint64"}}
 	if x := o.{{.NameTitle}}; x >= 1<<49 {
-		l += 9
+		buf = append(buf, {{.Index}}|0x80, 0, 0, 0, 0, 0, 0, 0, 0)
+		intconv.PutUint64(buf[len(buf)-8:], x)
 	} else if x != 0 {
-		for l += 2; x >= 0x80; l++ {
+		buf = append(buf, {{.Index}})
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
 			x >>= 7
 		}
+		buf = append(buf, byte(x))
 	}
 {{else if eq .Type "int32"}}
 	if v := o.{{.NameTitle}}; v != 0 {
 		x := uint32(v)
-		if v < 0 {
+		if v >= 0 {
+			buf = append(buf, {{.Index}})
+		} else {
 			x = ^x + 1
+			buf = append(buf, {{.Index}}|0x80)
 		}
-		for l += 2; x >= 0x80; l++ {
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
 			x >>= 7
 		}
+		buf = append(buf, byte(x))
 	}
 {{else if eq .Type "int64"}}
 	if v := o.{{.NameTitle}}; v != 0 {
-		l += 2
 		x := uint64(v)
-		if v < 0 {
+		if v >= 0 {
+			buf = append(buf, {{.Index}})
+		} else {
 			x = ^x + 1
+			buf = append(buf, {{.Index}}|0x80)
 		}
 		for n := 0; x >= 0x80 && n < 8; n++ {
+			buf = append(buf, byte(x|0x80))
 			x >>= 7
-			l++
 		}
+		buf = append(buf, byte(x))
 	}
-{{else if eq .Type "float32"}}
+{{else if eq .Type "float32" "float64"}}
  {{- if .TypeList}}
-	if x := len(o.{{.NameTitle}}); x != 0 {
-		if x > ColferListMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", ColferListMax))
+	if l := len(o.{{.NameTitle}}); l != 0 {
+		if l > opts.ListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", opts.ListMax))
 		}
-		for l += 2+x*4; x >= 0x80; l++ {
+		buf = append(buf, {{.Index}})
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
 			x >>= 7
 		}
+		buf = append(buf, byte(x))
+		for _, v := range o.{{.NameTitle}} {
+  {{- if eq .Type "float32"}}
+			buf = append(buf, 0, 0, 0, 0)
+			intconv.PutUint32(buf[len(buf)-4:], math.Float32bits(v))
+  {{- else}}
+			buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 0)
+			intconv.PutUint64(buf[len(buf)-8:], math.Float64bits(v))
+  {{- end}}
+		}
 	}
  {{- else}}
-	if o.{{.NameTitle}} != 0 {
-		l += 5
+	if v := o.{{.NameTitle}}; v != 0 {
+  {{- if eq .Type "float32"}}
+		buf = append(buf, {{.Index}}, 0, 0, 0, 0)
+		intconv.PutUint32(buf[len(buf)-4:], math.Float32bits(v))
+  {{- else}}
+		buf = append(buf, {{.Index}}, 0, 0, 0, 0, 0, 0, 0, 0)
+		intconv.PutUint64(buf[len(buf)-8:], math.Float64bits(v))
+  {{- end}}
 	}
  {{- end}}
-{{else if eq .Type "float64"}}
- {{- if .TypeList}}
-	if x := len(o.{{.NameTitle}}); x != 0 {
-		if x > ColferListMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", ColferListMax))
+{{else if eq .Type "timestamp"}}
+	if v := o.{{.NameTitle}}; !v.IsZero() {
+		s, ns := uint64(v.Unix()), uint32(v.Nanosecond())
+		if s < 1<<32 {
+			buf = append(buf, {{.Index}}, 0, 0, 0, 0, 0, 0, 0, 0)
+			intconv.PutUint32(buf[len(buf)-8:], uint32(s))
+		} else {
+			buf = append(buf, {{.Index}}|0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+			intconv.PutUint64(buf[len(buf)-12:], s)
 		}
-		for l += 2+x*8; x >= 0x80; l++ {
+		intconv.PutUint32(buf[len(buf)-4:], ns)
+	}
+{{else if eq .Type "text" "binary"}}
+	if l := len(o.{{.NameTitle}}); l != 0 {
+ {{- if .TypeList}}
+		if l > opts.ListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", opts.ListMax))
+		}
+ {{- else}}
+		if l > opts.SizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", opts.SizeMax))
+		}
+ {{- end}}
+		buf = append(buf, {{.Index}})
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+ {{- if .TypeList}}
+		for _, a := range o.{{.NameTitle}} {
+			if len(a) > opts.SizeMax {
+				return dst, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", opts.SizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			buf = append(buf, a...)
+		}
+ {{- else}}
+		buf = append(buf, o.{{.NameTitle}}...)
+ {{- end}}
+	}
+{{else if eq .Type "any"}}
+	if v := o.{{.NameTitle}}; v != nil {
+		sub, err := opts.nested("{{.String}}")
+		if err != nil {
+			return dst, err
+		}
+		name := v.ColferType()
+		buf = append(buf, {{.Index}})
+		x := uint(len(name))
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf, err = v.AppendColferWith(append(buf, name...), sub)
+		if err != nil {
+			return dst, err
+		}
+	}
+{{else if .TypeList}}
+	if l := len(o.{{.NameTitle}}); l != 0 {
+		if l > opts.ListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, {{.Index}})
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		sub, err := opts.nested("{{.String}}")
+		if err != nil {
+			return dst, err
+		}
+		for vi, v := range o.{{.NameTitle}} {
+			if v == nil {
+				v = new({{.TypeNative}})
+				o.{{.NameTitle}}[vi] = v
+			}
+			buf, err = v.AppendColferWith(buf, {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}sub{{else}}{{.TypeRef.Pkg.NameNative}}.ColferOptions(sub){{end}})
+			if err != nil {
+				return dst, err
+			}
+		}
+	}
+{{else}}
+	if v := o.{{.NameTitle}}; v != nil {
+		sub, err := opts.nested("{{.String}}")
+		if err != nil {
+			return dst, err
+		}
+		buf, err = v.AppendColferWith(append(buf, {{.Index}}), {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}sub{{else}}{{.TypeRef.Pkg.NameNative}}.ColferOptions(sub){{end}})
+		if err != nil {
+			return dst, err
+		}
+	}
+{{end}}`
+
+// goHashField writes the scalars through buf, which is backed by scratch.
+const goHashField = `{{if and (not .TypeList) (not .TypeRef) (not (eq .Type "text" "binary" "any"))}}{{template "append-field" .}}
+{{- else if eq .Type "float32" "float64"}}
+	if l := len(o.{{.NameTitle}}); l != 0 {
+		if l > opts.ListMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, {{.Index}})
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, v := range o.{{.NameTitle}} {
+			if len(buf) > len(scratch)-8 {
+				h.Write(buf)
+				n += len(buf)
+				buf = buf[:0]
+			}
+  {{- if eq .Type "float32"}}
+			buf = append(buf, 0, 0, 0, 0)
+			intconv.PutUint32(buf[len(buf)-4:], math.Float32bits(v))
+  {{- else}}
+			buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 0)
+			intconv.PutUint64(buf[len(buf)-8:], math.Float64bits(v))
+  {{- end}}
+		}
+	}
+{{else if eq .Type "text" "binary"}}
+	if l := len(o.{{.NameTitle}}); l != 0 {
+ {{- if .TypeList}}
+		if l > opts.ListMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", opts.ListMax))
+		}
+ {{- else}}
+		if l > opts.SizeMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", opts.SizeMax))
+		}
+ {{- end}}
+		buf = append(buf, {{.Index}})
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+ {{- if .TypeList}}
+		for _, a := range o.{{.NameTitle}} {
+			if len(a) > opts.SizeMax {
+				return n, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", opts.SizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			h.Write(buf)
+			n += len(buf) + len(a)
+			buf = buf[:0]
+  {{- if eq .Type "text"}}
+			io.WriteString(h, a)
+  {{- else}}
+			h.Write(a)
+  {{- end}}
+		}
+ {{- else}}
+		h.Write(buf)
+		n += len(buf) + l
+		buf = buf[:0]
+  {{- if eq .Type "text"}}
+		io.WriteString(h, o.{{.NameTitle}})
+  {{- else}}
+		h.Write(o.{{.NameTitle}})
+  {{- end}}
+ {{- end}}
+	}
+{{else if eq .Type "any"}}
+	if v := o.{{.NameTitle}}; v != nil {
+		sub, err := opts.nested("{{.String}}")
+		if err != nil {
+			return n, err
+		}
+		name := v.ColferType()
+		buf = append(buf, {{.Index}})
+		x := uint(len(name))
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, name...)
+		h.Write(buf)
+		n += len(buf)
+		buf = buf[:0]
+		vn, err := v.ColferHashWith(h, sub)
+		n += vn
+		if err != nil {
+			return n, err
+		}
+	}
+{{else if .TypeList}}
+	if l := len(o.{{.NameTitle}}); l != 0 {
+		if l > opts.ListMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, {{.Index}})
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		sub, err := opts.nested("{{.String}}")
+		if err != nil {
+			return n, err
+		}
+		for _, v := range o.{{.NameTitle}} {
+			if v == nil {
+				// same as the zero value
+				buf = append(buf, 0x7f)
+				continue
+			}
+			h.Write(buf)
+			n += len(buf)
+			buf = buf[:0]
+			vn, err := v.ColferHashWith(h, {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}sub{{else}}{{.TypeRef.Pkg.NameNative}}.ColferOptions(sub){{end}})
+			n += vn
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+{{else}}
+	if v := o.{{.NameTitle}}; v != nil {
+		sub, err := opts.nested("{{.String}}")
+		if err != nil {
+			return n, err
+		}
+		buf = append(buf, {{.Index}})
+		h.Write(buf)
+		n += len(buf)
+		buf = buf[:0]
+		vn, err := v.ColferHashWith(h, {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}sub{{else}}{{.TypeRef.Pkg.NameNative}}.ColferOptions(sub){{end}})
+		n += vn
+		if err != nil {
+			return n, err
+		}
+	}
+{{end}}`
+
+const goMarshalFieldLen = `{{if eq .Type "bool"}}
+	if o.{{.NameTitle}} {
+		l++
+	}
+{{else if eq .Type "uint8"}}
+	if x := o.{{.NameTitle}}; x != 0 {
+		l += 2
+	}
+{{else if eq .Type "uint16"}}
+	if x := o.{{.NameTitle}}; x >= 1<<8 {
+		l += 3
+	} else if x != 0 {
+		l += 2
+	}
+{{else if eq .Type "uint32"}}
+	if x := o.{{.NameTitle}}; x >= 1<<21 {
+		l += 5
+	} else if x != 0 {
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+{{else if eq .Type "uint64"}}
+	if x := o.{{.NameTitle}}; x >= 1<<49 {
+		l += 9
+	} else if x != 0 {
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+{{else if eq .Type "int32"}}
+	if v := o.{{.NameTitle}}; v != 0 {
+		x := uint32(v)
+		if v < 0 {
+			x = ^x + 1
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+{{else if eq .Type "int64"}}
+	if v := o.{{.NameTitle}}; v != 0 {
+		l += 2
+		x := uint64(v)
+		if v < 0 {
+			x = ^x + 1
+		}
+		for n := 0; x >= 0x80 && n < 8; n++ {
+			x >>= 7
+			l++
+		}
+	}
+{{else if eq .Type "float32"}}
+ {{- if .TypeList}}
+	if x := len(o.{{.NameTitle}}); x != 0 {
+		if x > opts.ListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", opts.ListMax))
+		}
+		for l += 2+x*4; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+ {{- else}}
+	if o.{{.NameTitle}} != 0 {
+		l += 5
+	}
+ {{- end}}
+{{else if eq .Type "float64"}}
+ {{- if .TypeList}}
+	if x := len(o.{{.NameTitle}}); x != 0 {
+		if x > opts.ListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", opts.ListMax))
+		}
+		for l += 2+x*8; x >= 0x80; l++ {
 			x >>= 7
 		}
 	}
@@ -508,59 +2142,82 @@ const goMarshalFieldLen = `{{if eq .Type "bool"}}
 {{else if eq .Type "text" "binary"}}
 	if x := len(o.{{.NameTitle}}); x != 0 {
  {{- if .TypeList}}
-		if x > ColferListMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", ColferListMax))
+		if x > opts.ListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", opts.ListMax))
 		}
 		for l += 2; x >= 0x80; l++ {
 			x >>= 7
 		}
 		for _, a := range o.{{.NameTitle}} {
 			x = len(a)
-			if x > ColferSizeMax {
-				return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", ColferSizeMax))
+			if x > opts.SizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", opts.SizeMax))
 			}
 			for l += x+1; x >= 0x80; l++ {
 				x >>= 7
 			}
 		}
-		if l >= ColferSizeMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: struct {{.Struct.String}} size exceeds %d bytes", ColferSizeMax))
+		if l >= opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct {{.Struct.String}} size exceeds %d bytes", opts.SizeMax))
 		}
  {{- else}}
-		if x > ColferSizeMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", ColferSizeMax))
+		if x > opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d bytes", opts.SizeMax))
 		}
 		for l += x+2; x >= 0x80; l++ {
 			x >>= 7
 		}
  {{- end}}
 	}
+{{else if eq .Type "any"}}
+	if v := o.{{.NameTitle}}; v != nil {
+		sub, err := opts.nested("{{.String}}")
+		if err != nil {
+			return 0, err
+		}
+		vl, err := v.MarshalLenWith(sub)
+		if err != nil {
+			return 0, err
+		}
+		x := len(v.ColferType())
+		for l += vl + x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
 {{else if .TypeList}}
 	if x := len(o.{{.NameTitle}}); x != 0 {
-		if x > ColferListMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", ColferListMax))
+		if x > opts.ListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field {{.String}} exceeds %d elements", opts.ListMax))
 		}
 		for l += 2; x >= 0x80; l++ {
 			x >>= 7
 		}
+		sub, err := opts.nested("{{.String}}")
+		if err != nil {
+			return 0, err
+		}
 		for _, v := range o.{{.NameTitle}} {
 			if v == nil {
 				l++
 				continue
 			}
-			vl, err := v.MarshalLen()
+			vl, err := v.MarshalLenWith({{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}sub{{else}}{{.TypeRef.Pkg.NameNative}}.ColferOptions(sub){{end}})
 			if err != nil {
 				return 0, err
 			}
 			l += vl
 		}
-		if x > ColferSizeMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: struct {{.Struct.String}} size exceeds %d bytes", ColferSizeMax))
+		if x > opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct {{.Struct.String}} size exceeds %d bytes", opts.SizeMax))
 		}
 	}
 {{else}}
 	if v := o.{{.NameTitle}}; v != nil {
-		vl, err := v.MarshalLen()
+		sub, err := opts.nested("{{.String}}")
+		if err != nil {
+			return 0, err
+		}
+		vl, err := v.MarshalLenWith({{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}sub{{else}}{{.TypeRef.Pkg.NameNative}}.ColferOptions(sub){{end}})
 		if err != nil {
 			return 0, err
 		}
@@ -579,37 +2236,44 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 	}
 {{else if eq .Type "uint8"}}
 	if header == {{.Index}} {
+		at := i - 1
 		start := i
 		i++
 		if i >= len(data) {
 			goto eof
 		}
 		o.{{.NameTitle}} = data[start]
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	}
 {{else if eq .Type "uint16"}}
 	if header == {{.Index}} {
+		at := i - 1
 		start := i
 		i += 2
 		if i >= len(data) {
 			goto eof
 		}
 		o.{{.NameTitle}} = intconv.Uint16(data[start:])
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	} else if header == {{.Index}}|0x80 {
+		at := i - 1
 		start := i
 		i++
 		if i >= len(data) {
 			goto eof
 		}
 		o.{{.NameTitle}} = uint16(data[start])
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	}
 {{else if eq .Type "uint32"}}
 	if header == {{.Index}} {
+		at := i - 1
 		start := i
 		i++
 		if i >= len(data) {
@@ -635,20 +2299,24 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		}
 		o.{{.NameTitle}} = x
 
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	} else if header == {{.Index}}|0x80 {
+		at := i - 1
 		start := i
 		i += 4
 		if i >= len(data) {
 			goto eof
 		}
 		o.{{.NameTitle}} = intconv.Uint32(data[start:])
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	}
 {{else if eq .Type "uint64"}}
 	if header == {{.Index}} {
+		at := i - 1
 		start := i
 		i++
 		if i >= len(data) {
@@ -674,20 +2342,24 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		}
 		o.{{.NameTitle}} = x
 
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	} else if header == {{.Index}}|0x80 {
+		at := i - 1
 		start := i
 		i += 8
 		if i >= len(data) {
 			goto eof
 		}
 		o.{{.NameTitle}} = intconv.Uint64(data[start:])
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	}
 {{else if eq .Type "int32"}}
 	if header == {{.Index}} {
+		at := i - 1
 		if i+1 >= len(data) {
 			i++
 			goto eof
@@ -713,9 +2385,11 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		}
 		o.{{.NameTitle}} = int32(x)
 
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	} else if header == {{.Index}}|0x80 {
+		at := i - 1
 		if i+1 >= len(data) {
 			i++
 			goto eof
@@ -741,11 +2415,13 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		}
 		o.{{.NameTitle}} = int32(^x + 1)
 
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	}
 {{else if eq .Type "int64"}}
 	if header == {{.Index}} {
+		at := i - 1
 		if i+1 >= len(data) {
 			i++
 			goto eof
@@ -771,9 +2447,11 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		}
 		o.{{.NameTitle}} = int64(x)
 
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	} else if header == {{.Index}}|0x80 {
+		at := i - 1
 		if i+1 >= len(data) {
 			i++
 			goto eof
@@ -799,6 +2477,7 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		}
 		o.{{.NameTitle}} = int64(^x + 1)
 
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	}
@@ -806,8 +2485,11 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
  {{- if .TypeList}}
 	if header == {{.Index}} {
 {{template "unmarshal-varint" .}}
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, opts.ListMax))
 		}
 
 		l := int(x)
@@ -816,6 +2498,9 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 			i = end
 			goto eof
 		}
+		if err := opts.charge("{{.String}}", l*int(unsafe.Sizeof(float32(0)))); err != nil {
+			return 0, err
+		}
 		a := make([]float32, l)
 		for ai := range a {
 			a[ai] = math.Float32frombits(intconv.Uint32(data[i:]))
@@ -828,12 +2513,14 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 	}
  {{- else}}
 	if header == {{.Index}} {
+		at := i - 1
 		start := i
 		i += 4
 		if i >= len(data) {
 			goto eof
 		}
 		o.{{.NameTitle}} = math.Float32frombits(intconv.Uint32(data[start:]))
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	}
@@ -842,8 +2529,11 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
  {{- if .TypeList}}
 	if header == {{.Index}} {
 {{template "unmarshal-varint" .}}
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, opts.ListMax))
 		}
 		l := int(x)
 
@@ -851,6 +2541,9 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 			i = end
 			goto eof
 		}
+		if err := opts.charge("{{.String}}", l*int(unsafe.Sizeof(float64(0)))); err != nil {
+			return 0, err
+		}
 		a := make([]float64, l)
 		for ai := range a {
 			a[ai] = math.Float64frombits(intconv.Uint64(data[i:]))
@@ -863,50 +2556,67 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 	}
  {{- else}}
 	if header == {{.Index}} {
+		at := i - 1
 		start := i
 		i += 8
 		if i >= len(data) {
 			goto eof
 		}
 		o.{{.NameTitle}} = math.Float64frombits(intconv.Uint64(data[start:]))
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	}
  {{- end}}
 {{else if eq .Type "timestamp"}}
 	if header == {{.Index}} {
+		at := i - 1
 		start := i
 		i += 8
 		if i >= len(data) {
 			goto eof
 		}
 		o.{{.NameTitle}} = time.Unix(int64(intconv.Uint32(data[start:])), int64(intconv.Uint32(data[start+4:]))).In(time.UTC)
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	} else if header == {{.Index}}|0x80 {
+		at := i - 1
 		start := i
 		i += 12
 		if i >= len(data) {
 			goto eof
 		}
 		o.{{.NameTitle}} = time.Unix(int64(intconv.Uint64(data[start:])), int64(intconv.Uint32(data[start+8:]))).In(time.UTC)
+{{template "unmarshal-strict" .}}
 		header = data[i]
 		i++
 	}
 {{else if eq .Type "text"}}
 	if header == {{.Index}} {
 {{template "unmarshal-varint" .}}
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
  {{- if .TypeList}}
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, opts.ListMax))
+		}
+		// each element takes at least one byte
+		if end := i + int(x); end >= len(data) {
+			i = end
+			goto eof
+		}
+		if err := opts.charge("{{.String}}", int(x)*int(unsafe.Sizeof(""))); err != nil {
+			return 0, err
 		}
 		a := make([]string, int(x))
 		o.{{.NameTitle}} = a
 
 		for ai := range a {
 {{template "unmarshal-varint" .}}
-			if x > uint(ColferSizeMax) {
-				return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+			if x > uint(opts.SizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} element %d size %d exceeds %d bytes", ai, x, opts.SizeMax))
 			}
 
 			start := i
@@ -914,7 +2624,26 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 			if i >= len(data) {
 				goto eof
 			}
+			if opts.Strict {
+				if n := colferUTF8Len(data[start:i]); n < int(x) {
+					return 0, ColferNonCanonical(start + n)
+				}
+			}
+{{- if .Struct.Pkg.NoCopy}}
+			if opts.NoCopy {
+				a[ai] = colferNoCopyString(data[start:i])
+			} else {
+				if err := opts.charge("{{.String}}", int(x)); err != nil {
+					return 0, err
+				}
+				a[ai] = string(data[start:i])
+			}
+{{- else}}
+			if err := opts.charge("{{.String}}", int(x)); err != nil {
+				return 0, err
+			}
 			a[ai] = string(data[start:i])
+{{- end}}
 		}
 
 		if i >= len(data) {
@@ -924,8 +2653,8 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		i++
 	}
  {{- else}}
-		if x > uint(ColferSizeMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} size %d exceeds %d bytes", x, ColferSizeMax))
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} size %d exceeds %d bytes", x, opts.SizeMax))
 		}
 
 		start := i
@@ -933,7 +2662,26 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		if i >= len(data) {
 			goto eof
 		}
+		if opts.Strict {
+			if n := colferUTF8Len(data[start:i]); n < int(x) {
+				return 0, ColferNonCanonical(start + n)
+			}
+		}
+{{- if .Struct.Pkg.NoCopy}}
+		if opts.NoCopy {
+			o.{{.NameTitle}} = colferNoCopyString(data[start:i])
+		} else {
+			if err := opts.charge("{{.String}}", int(x)); err != nil {
+				return 0, err
+			}
+			o.{{.NameTitle}} = string(data[start:i])
+		}
+{{- else}}
+		if err := opts.charge("{{.String}}", int(x)); err != nil {
+			return 0, err
+		}
 		o.{{.NameTitle}} = string(data[start:i])
+{{- end}}
 
 		header = data[i]
 		i++
@@ -942,43 +2690,106 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 {{else if eq .Type "binary"}}
 	if header == {{.Index}} {
 {{template "unmarshal-varint" .}}
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
  {{- if not .TypeList}}
-		if x > uint(ColferSizeMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} size %d exceeds %d bytes", x, ColferSizeMax))
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} size %d exceeds %d bytes", x, opts.SizeMax))
 		}
-		v := make([]byte, int(x))
+{{- if .Struct.Pkg.NoCopy}}
 
 		start := i
-		i += len(v)
+		i += int(x)
 		if i >= len(data) {
 			goto eof
 		}
-		copy(v, data[start:i])
-		o.{{.NameTitle}} = v
-
-		header = data[i]
-		i++
- {{- else}}
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
+{{- if .TypeColfer}}
+		if x != 0 && data[i-1] != 0x7f {
+			return 0, ColferError(i - 1)
 		}
-		a := make([][]byte, int(x))
-		o.{{.NameTitle}} = a
-		for ai := range a {
-{{template "unmarshal-varint" .}}
-			if x > uint(ColferSizeMax) {
-				return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
+{{- end}}
+		if opts.NoCopy {
+			o.{{.NameTitle}} = data[start:i:i]
+		} else {
+			if err := opts.charge("{{.String}}", int(x)); err != nil {
+				return 0, err
 			}
 			v := make([]byte, int(x))
-
+			copy(v, data[start:i])
+			o.{{.NameTitle}} = v
+		}
+{{- else}}
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+{{- if .TypeColfer}}
+		if x != 0 && data[i-1] != 0x7f {
+			return 0, ColferError(i - 1)
+		}
+{{- end}}
+		if err := opts.charge("{{.String}}", int(x)); err != nil {
+			return 0, err
+		}
+		v := make([]byte, int(x))
+		copy(v, data[start:i])
+		o.{{.NameTitle}} = v
+{{- end}}
+
+		header = data[i]
+		i++
+ {{- else}}
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, opts.ListMax))
+		}
+		// each element takes at least one byte
+		if end := i + int(x); end >= len(data) {
+			i = end
+			goto eof
+		}
+		if err := opts.charge("{{.String}}", int(x)*int(unsafe.Sizeof([]byte(nil)))); err != nil {
+			return 0, err
+		}
+		a := make([][]byte, int(x))
+		o.{{.NameTitle}} = a
+		for ai := range a {
+{{template "unmarshal-varint" .}}
+			if x > uint(opts.SizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} element %d size %d exceeds %d bytes", ai, x, opts.SizeMax))
+			}
+{{- if .Struct.Pkg.NoCopy}}
+
 			start := i
-			i += len(v)
+			i += int(x)
 			if i >= len(data) {
 				goto eof
 			}
 
+			if opts.NoCopy {
+				a[ai] = data[start:i:i]
+			} else {
+				if err := opts.charge("{{.String}}", int(x)); err != nil {
+					return 0, err
+				}
+				v := make([]byte, int(x))
+				copy(v, data[start:i])
+				a[ai] = v
+			}
+{{- else}}
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			if err := opts.charge("{{.String}}", int(x)); err != nil {
+				return 0, err
+			}
+			v := make([]byte, int(x))
 			copy(v, data[start:i])
 			a[ai] = v
+{{- end}}
 		}
 
 		if i >= len(data) {
@@ -988,24 +2799,88 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 		i++
  {{- end}}
 	}
+{{else if eq .Type "any"}}
+	if header == {{.Index}} {
+		sub, err := opts.nested("{{.String}}")
+		if err != nil {
+			return 0, err
+		}
+		sub.Fields = opts.within("{{.Name}}")
+
+{{template "unmarshal-varint" .}}
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} type size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		t := colferAnyType(string(data[start:i]))
+		if t == nil {
+			return 0, ColferError(start)
+		}
+		v, err := t.colferNew(opts, "{{.String}}")
+		if err != nil {
+			return 0, err
+		}
+		n, err := v.UnmarshalWith(data[i:], sub)
+		if err != nil {
+			if err == io.EOF && len(data) >= opts.SizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: {{.Struct.String}} size exceeds %d bytes", opts.SizeMax))
+			}
+			if e, ok := err.(ColferNonCanonical); ok {
+				return 0, ColferNonCanonical(i + int(e))
+			}
+			return 0, err
+		}
+		o.{{.NameTitle}} = v
+		i += n
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
 {{else if .TypeList}}
 	if header == {{.Index}} {
 {{template "unmarshal-varint" .}}
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, ColferListMax))
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, opts.ListMax))
+		}
+
+		sub, err := opts.nested("{{.String}}")
+		if err != nil {
+			return 0, err
 		}
+		sub.Fields = opts.within("{{.Name}}")
 
 		l := int(x)
+		// each element takes at least one byte
+		if end := i + l; end >= len(data) {
+			i = end
+			goto eof
+		}
+		if err := opts.charge("{{.String}}", l*int(unsafe.Sizeof((*{{.TypeNative}})(nil))+unsafe.Sizeof({{.TypeNative}}{}))); err != nil {
+			return 0, err
+		}
 		a := make([]*{{.TypeNative}}, l)
 		malloc := make([]{{.TypeNative}}, l)
 		for ai := range a {
 			v := &malloc[ai]
 			a[ai] = v
 
-			n, err := v.Unmarshal(data[i:])
+			n, err := v.UnmarshalWith(data[i:], {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}sub{{else}}{{.TypeRef.Pkg.NameNative}}.ColferOptions(sub){{end}})
 			if err != nil {
-				if err == io.EOF && len(data) >= ColferSizeMax {
-					return 0, ColferMax(fmt.Sprintf("colfer: {{.Struct.String}} size exceeds %d bytes", ColferSizeMax))
+				if err == io.EOF && len(data) >= opts.SizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: {{.Struct.String}} size exceeds %d bytes", opts.SizeMax))
+				}
+				if e, ok := err.({{if ne .TypeRef.Pkg.Name .Struct.Pkg.Name}}{{.TypeRef.Pkg.NameNative}}.{{end}}ColferNonCanonical); ok {
+					return 0, ColferNonCanonical(i + int(e))
 				}
 				return 0, err
 			}
@@ -1021,11 +2896,23 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 	}
 {{else}}
 	if header == {{.Index}} {
+		sub, err := opts.nested("{{.String}}")
+		if err != nil {
+			return 0, err
+		}
+		sub.Fields = opts.within("{{.Name}}")
+
+		if err := opts.charge("{{.String}}", int(unsafe.Sizeof({{.TypeNative}}{}))); err != nil {
+			return 0, err
+		}
 		o.{{.NameTitle}} = new({{.TypeNative}})
-		n, err := o.{{.NameTitle}}.Unmarshal(data[i:])
+		n, err := o.{{.NameTitle}}.UnmarshalWith(data[i:], {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}sub{{else}}{{.TypeRef.Pkg.NameNative}}.ColferOptions(sub){{end}})
 		if err != nil {
-			if err == io.EOF && len(data) >= ColferSizeMax {
-				return 0, ColferMax(fmt.Sprintf("colfer: {{.Struct.String}} size exceeds %d bytes", ColferSizeMax))
+			if err == io.EOF && len(data) >= opts.SizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: {{.Struct.String}} size exceeds %d bytes", opts.SizeMax))
+			}
+			if e, ok := err.({{if ne .TypeRef.Pkg.Name .Struct.Pkg.Name}}{{.TypeRef.Pkg.NameNative}}.{{end}}ColferNonCanonical); ok {
+				return 0, ColferNonCanonical(i + int(e))
 			}
 			return 0, err
 		}
@@ -1039,6 +2926,143 @@ const goUnmarshalField = `{{if eq .Type "bool"}}
 	}
 {{end}}`
 
+const goUnmarshalSkip = `
+	if header{{if eq .Type "uint16" "uint32" "uint64" "int32" "int64" "timestamp"}}&0x7f{{end}} == {{.Index}} && !opts.selects("{{.Name}}") {
+{{- if eq .Type "uint8"}}
+		i++
+{{- else if eq .Type "uint16"}}
+		if header&0x80 != 0 {
+			i++
+		} else {
+			i += 2
+		}
+{{- else if eq .Type "uint32" "uint64" "int32" "int64"}}
+ {{- if eq .Type "uint32" "uint64"}}
+		if header&0x80 != 0 {
+			i += {{if eq .Type "uint32"}}4{{else}}8{{end}}
+		} else {
+ {{- end}}
+			for {{if eq .Type "uint64" "int64"}}n := 1; ; n++ {{end}}{
+				if i >= len(data) {
+					goto eof
+				}
+				b := data[i]
+				i++
+				if b < 0x80{{if eq .Type "uint64" "int64"}} || n == 9{{end}} {
+					break
+				}
+			}
+ {{- if eq .Type "uint32" "uint64"}}
+		}
+ {{- end}}
+{{- else if eq .Type "timestamp"}}
+		if header&0x80 != 0 {
+			i += 12
+		} else {
+			i += 8
+		}
+{{- else if eq .Type "float32" "float64"}}
+ {{- if .TypeList}}
+{{template "unmarshal-varint" .}}
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, opts.ListMax))
+		}
+		i += int(x) * {{if eq .Type "float32"}}4{{else}}8{{end}}
+ {{- else}}
+		i += {{if eq .Type "float32"}}4{{else}}8{{end}}
+ {{- end}}
+{{- else if eq .Type "text" "binary"}}
+{{template "unmarshal-varint" .}}
+ {{- if .TypeList}}
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, opts.ListMax))
+		}
+		for ai, l := 0, int(x); ai < l; ai++ {
+{{template "unmarshal-varint" .}}
+			if x > uint(opts.SizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} element %d size %d exceeds %d bytes", ai, x, opts.SizeMax))
+			}
+			i += int(x)
+		}
+ {{- else}}
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+		i += int(x)
+ {{- end}}
+{{- else if eq .Type "any"}}
+{{template "unmarshal-varint" .}}
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} type size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		t := colferAnyType(string(data[start:i]))
+		if t == nil {
+			return 0, ColferError(start)
+		}
+		sub, err := opts.nested("{{.String}}")
+		if err != nil {
+			return 0, err
+		}
+		// none selected, so the receiver is never read nor written
+		sub.Fields, sub.Validate = opts.Fields[:0], false
+		n, err := t.UnmarshalWith(data[i:], sub)
+		if err != nil {
+			if err == io.EOF && len(data) >= opts.SizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: {{.Struct.String}} size exceeds %d bytes", opts.SizeMax))
+			}
+			if e, ok := err.(ColferNonCanonical); ok {
+				return 0, ColferNonCanonical(i + int(e))
+			}
+			return 0, err
+		}
+		i += n
+{{- else if .TypeRef}}
+ {{- if .TypeList}}
+{{template "unmarshal-varint" .}}
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: {{.String}} length %d exceeds %d elements", x, opts.ListMax))
+		}
+ {{- end}}
+		sub, err := opts.nested("{{.String}}")
+		if err != nil {
+			return 0, err
+		}
+		// none selected, so the receiver is never read nor written
+		sub.Fields, sub.Validate = opts.Fields[:0], false
+ {{- if .TypeList}}
+
+		for l := int(x); l > 0; l-- {
+ {{- else}}
+
+		{
+ {{- end}}
+			n, err := (*{{.TypeNative}})(nil).UnmarshalWith(data[i:], {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}sub{{else}}{{.TypeRef.Pkg.NameNative}}.ColferOptions(sub){{end}})
+			if err != nil {
+				if err == io.EOF && len(data) >= opts.SizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: {{.Struct.String}} size exceeds %d bytes", opts.SizeMax))
+				}
+				if e, ok := err.({{if ne .TypeRef.Pkg.Name .Struct.Pkg.Name}}{{.TypeRef.Pkg.NameNative}}.{{end}}ColferNonCanonical); ok {
+					return 0, ColferNonCanonical(i + int(e))
+				}
+				return 0, err
+			}
+			i += n
+		}
+{{- end}}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+`
+
 const goUnmarshalVarint = `		if i >= len(data) {
 			goto eof
 		}
@@ -1055,6 +3079,9 @@ const goUnmarshalVarint = `		if i >= len(data) {
 				i++
 
 				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
 					x |= b << shift
 					break
 				}
@@ -1062,3 +3089,554 @@ const goUnmarshalVarint = `		if i >= len(data) {
 			}
 		}
 `
+
+const goUnmarshalStrict = `		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+{{template "append-field" .}}
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+`
+
+const goMarshalJSONField = `{{if eq .Type "bool"}}
+	if o.{{.NameTitle}} {
+		buf = append(buf, "\"{{.Name}}\":true,"...)
+	}
+{{else if eq .Type "uint8" "uint16" "uint32"}}
+	if x := o.{{.NameTitle}}; x != 0 {
+		buf = append(buf, "\"{{.Name}}\":"...)
+		buf = strconv.AppendUint(buf, uint64(x), 10)
+		buf = append(buf, ',')
+	}
+{{else if eq .Type "int32"}}
+	if x := o.{{.NameTitle}}; x != 0 {
+		buf = append(buf, "\"{{.Name}}\":"...)
+		buf = strconv.AppendInt(buf, int64(x), 10)
+		buf = append(buf, ',')
+	}
+{{else if eq .Type "uint64" "int64"}}
+	if x := o.{{.NameTitle}}; x != 0 {
+		buf = append(buf, "\"{{.Name}}\":"...)
+		if opts.JSONQuote64 {
+			buf = append(buf, '"')
+		}
+		buf = strconv.Append{{if eq .Type "uint64"}}Uint{{else}}Int{{end}}(buf, x, 10)
+		if opts.JSONQuote64 {
+			buf = append(buf, '"')
+		}
+		buf = append(buf, ',')
+	}
+{{else if eq .Type "float32" "float64"}}
+ {{- if .TypeList}}
+	if len(o.{{.NameTitle}}) != 0 {
+		buf = append(buf, "\"{{.Name}}\":["...)
+		for _, v := range o.{{.NameTitle}} {
+			buf = appendColferJSONFloat(buf, float64(v), {{if eq .Type "float32"}}32{{else}}64{{end}})
+			buf = append(buf, ',')
+		}
+		buf[len(buf)-1] = ']'
+		buf = append(buf, ',')
+	}
+ {{- else}}
+	if v := o.{{.NameTitle}}; v != 0 {
+		buf = append(buf, "\"{{.Name}}\":"...)
+		buf = appendColferJSONFloat(buf, float64(v), {{if eq .Type "float32"}}32{{else}}64{{end}})
+		buf = append(buf, ',')
+	}
+ {{- end}}
+{{else if eq .Type "timestamp"}}
+	if v := o.{{.NameTitle}}.UTC(); !v.IsZero() {
+		if y := v.Year(); y < 0 || y > 9999 {
+			return nil, fmt.Errorf("colfer: field {{.String}} year %d outside of RFC 3339 range", y)
+		}
+		buf = append(buf, "\"{{.Name}}\":\""...)
+		buf = v.AppendFormat(buf, time.RFC3339Nano)
+		buf = append(buf, '"', ',')
+	}
+{{else if eq .Type "text" "binary"}}
+	if len(o.{{.NameTitle}}) != 0 {
+		// infallible for strings and byte slices
+		b, _ := json.Marshal(o.{{.NameTitle}})
+		buf = append(buf, "\"{{.Name}}\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+{{else if eq .Type "any"}}
+	if v := o.{{.NameTitle}}; v != nil {
+		b, err := v.MarshalJSONWith(opts)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, "\"{{.Name}}\":{\"type\":\""...)
+		buf = append(buf, v.ColferType()...)
+		buf = append(buf, "\",\"value\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, '}', ',')
+	}
+{{else if .TypeList}}
+	if len(o.{{.NameTitle}}) != 0 {
+		buf = append(buf, "\"{{.Name}}\":["...)
+		for _, v := range o.{{.NameTitle}} {
+			if v == nil {
+				v = new({{.TypeNative}})
+			}
+			b, err := v.MarshalJSONWith({{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}opts{{else}}{{.TypeRef.Pkg.NameNative}}.ColferOptions(opts){{end}})
+			if err != nil {
+				return nil, err
+			}
+			buf = append(buf, b...)
+			buf = append(buf, ',')
+		}
+		buf[len(buf)-1] = ']'
+		buf = append(buf, ',')
+	}
+{{else}}
+	if v := o.{{.NameTitle}}; v != nil {
+		b, err := v.MarshalJSONWith({{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}opts{{else}}{{.TypeRef.Pkg.NameNative}}.ColferOptions(opts){{end}})
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, "\"{{.Name}}\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+{{end}}`
+
+const goUnmarshalJSONField = `
+		case "{{.Name}}":
+{{- if eq .Type "uint8" "uint16" "uint32" "uint64"}}
+			x, err := strconv.ParseUint(colferJSONNumber(raw), 10, {{if eq .Type "uint8"}}8{{else if eq .Type "uint16"}}16{{else if eq .Type "uint32"}}32{{else}}64{{end}})
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field {{.String}}: %s", err)
+			}
+			o.{{.NameTitle}} = {{.Type}}(x)
+{{- else if eq .Type "int32" "int64"}}
+			x, err := strconv.ParseInt(colferJSONNumber(raw), 10, {{if eq .Type "int32"}}32{{else}}64{{end}})
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field {{.String}}: %s", err)
+			}
+			o.{{.NameTitle}} = {{.Type}}(x)
+{{- else if eq .Type "float32" "float64"}}
+ {{- if .TypeList}}
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: JSON for field {{.String}}: %s", err)
+			}
+			if len(a) > opts.ListMax {
+				return ColferMax(fmt.Sprintf("colfer: field {{.String}} length %d exceeds %d elements", len(a), opts.ListMax))
+			}
+			o.{{.NameTitle}} = nil
+			for i, e := range a {
+				f, err := parseColferJSONFloat(e, {{if eq .Type "float32"}}32{{else}}64{{end}})
+				if err != nil {
+					return fmt.Errorf("colfer: JSON for field {{.String}} index %d: %s", i, err)
+				}
+				o.{{.NameTitle}} = append(o.{{.NameTitle}}, {{.Type}}(f))
+			}
+ {{- else}}
+			f, err := parseColferJSONFloat(raw, {{if eq .Type "float32"}}32{{else}}64{{end}})
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field {{.String}}: %s", err)
+			}
+			o.{{.NameTitle}} = {{.Type}}(f)
+ {{- end}}
+{{- else if eq .Type "timestamp"}}
+			var t time.Time
+			if err := json.Unmarshal(raw, &t); err != nil {
+				return fmt.Errorf("colfer: JSON for field {{.String}}: %s", err)
+			}
+			o.{{.NameTitle}} = t.In(time.UTC)
+{{- else if eq .Type "any"}}
+			if string(raw) == "null" {
+				o.{{.NameTitle}} = nil
+				break
+			}
+			var wrap struct {
+				Type  string          `+"`"+`json:"type"`+"`"+`
+				Value json.RawMessage `+"`"+`json:"value"`+"`"+`
+			}
+			if err := json.Unmarshal(raw, &wrap); err != nil {
+				return fmt.Errorf("colfer: JSON for field {{.String}}: %s", err)
+			}
+			v := NewColferAny(wrap.Type)
+			if v == nil {
+				return fmt.Errorf("colfer: JSON for field {{.String}}: type %q not registered", wrap.Type)
+			}
+			if err := v.UnmarshalJSONWith(wrap.Value, opts); err != nil {
+				return err
+			}
+			o.{{.NameTitle}} = v
+{{- else if and .TypeRef .TypeList}}
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: JSON for field {{.String}}: %s", err)
+			}
+			if len(a) > opts.ListMax {
+				return ColferMax(fmt.Sprintf("colfer: field {{.String}} length %d exceeds %d elements", len(a), opts.ListMax))
+			}
+			o.{{.NameTitle}} = nil
+			for _, e := range a {
+				var v *{{.TypeNative}}
+				if string(e) != "null" {
+					v = new({{.TypeNative}})
+					if err := v.UnmarshalJSONWith(e, {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}opts{{else}}{{.TypeRef.Pkg.NameNative}}.ColferOptions(opts){{end}}); err != nil {
+						return err
+					}
+				}
+				o.{{.NameTitle}} = append(o.{{.NameTitle}}, v)
+			}
+{{- else if .TypeRef}}
+			if string(raw) == "null" {
+				o.{{.NameTitle}} = nil
+				break
+			}
+			v := new({{.TypeNative}})
+			if err := v.UnmarshalJSONWith(raw, {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}opts{{else}}{{.TypeRef.Pkg.NameNative}}.ColferOptions(opts){{end}}); err != nil {
+				return err
+			}
+			o.{{.NameTitle}} = v
+{{- else}}
+ {{- if and (not .TypeList) (eq .Type "bool" "text")}}
+			// JSON null has no effect on the decode
+			o.{{.NameTitle}} = {{if eq .Type "bool"}}false{{else}}""{{end}}
+ {{- end}}
+			if err := json.Unmarshal(raw, &o.{{.NameTitle}}); err != nil {
+				return fmt.Errorf("colfer: JSON for field {{.String}}: %s", err)
+			}
+ {{- if .TypeList}}
+			if len(o.{{.NameTitle}}) > opts.ListMax {
+				return ColferMax(fmt.Sprintf("colfer: field {{.String}} length %d exceeds %d elements", len(o.{{.NameTitle}}), opts.ListMax))
+			}
+ {{- end}}
+{{- end}}`
+
+const goEqualField = `{{if .TypeList}}
+	if len(o.{{.NameTitle}}) != len(other.{{.NameTitle}}) {
+		return false
+	}
+	for i, v := range o.{{.NameTitle}} {
+ {{- if eq .Type "float32"}}
+		if math.Float32bits(v) != math.Float32bits(other.{{.NameTitle}}[i]) {
+ {{- else if eq .Type "float64"}}
+		if math.Float64bits(v) != math.Float64bits(other.{{.NameTitle}}[i]) {
+ {{- else if eq .Type "binary"}}
+		if string(v) != string(other.{{.NameTitle}}[i]) {
+ {{- else if .TypeRef}}
+		w := other.{{.NameTitle}}[i]
+		if v == nil {
+			v = new({{.TypeNative}})
+		}
+		if w == nil {
+			w = new({{.TypeNative}})
+		}
+		if !v.Equal(w) {
+ {{- else}}
+		if v != other.{{.NameTitle}}[i] {
+ {{- end}}
+			return false
+		}
+	}
+{{else if eq .Type "float32"}}
+	if a, b := o.{{.NameTitle}}, other.{{.NameTitle}}; (a != 0 || b != 0) && math.Float32bits(a) != math.Float32bits(b) {
+		return false
+	}
+{{else if eq .Type "float64"}}
+	if a, b := o.{{.NameTitle}}, other.{{.NameTitle}}; (a != 0 || b != 0) && math.Float64bits(a) != math.Float64bits(b) {
+		return false
+	}
+{{else if eq .Type "timestamp"}}
+	if !o.{{.NameTitle}}.Equal(other.{{.NameTitle}}) {
+		return false
+	}
+{{else if eq .Type "binary"}}
+	if string(o.{{.NameTitle}}) != string(other.{{.NameTitle}}) {
+		return false
+	}
+{{else if eq .Type "any"}}
+	if v, w := o.{{.NameTitle}}, other.{{.NameTitle}}; v != w && (v == nil || w == nil || !v.colferEqual(w)) {
+		return false
+	}
+{{else if .TypeRef}}
+	if !o.{{.NameTitle}}.Equal(other.{{.NameTitle}}) {
+		return false
+	}
+{{else}}
+	if o.{{.NameTitle}} != other.{{.NameTitle}} {
+		return false
+	}
+{{end}}`
+
+const goCloneField = `{{if .TypeList}}
+	if o.{{.NameTitle}} != nil {
+		c.{{.NameTitle}} = make([]{{if .TypeRef}}*{{end}}{{.TypeNative}}, len(o.{{.NameTitle}}))
+ {{- if .TypeRef}}
+		for i, v := range o.{{.NameTitle}} {
+			c.{{.NameTitle}}[i] = v.Clone()
+		}
+ {{- else if eq .Type "binary"}}
+		for i, v := range o.{{.NameTitle}} {
+			if v != nil {
+				c.{{.NameTitle}}[i] = append(make([]byte, 0, len(v)), v...)
+			}
+		}
+ {{- else}}
+		copy(c.{{.NameTitle}}, o.{{.NameTitle}})
+ {{- end}}
+	}
+{{else if eq .Type "binary"}}
+	if o.{{.NameTitle}} != nil {
+		c.{{.NameTitle}} = append(make([]byte, 0, len(o.{{.NameTitle}})), o.{{.NameTitle}}...)
+	}
+{{else if eq .Type "any"}}
+	if v := o.{{.NameTitle}}; v != nil {
+		c.{{.NameTitle}} = v.colferClone()
+	}
+{{else if .TypeRef}}
+	c.{{.NameTitle}} = o.{{.NameTitle}}.Clone()
+{{end}}`
+
+const goMergeField = `{{if .TypeList}}
+	if len(other.{{.NameTitle}}) != 0 {
+		o.{{.NameTitle}} = make([]{{if .TypeRef}}*{{end}}{{.TypeNative}}, len(other.{{.NameTitle}}))
+ {{- if .TypeRef}}
+		for i, v := range other.{{.NameTitle}} {
+			o.{{.NameTitle}}[i] = v.Clone()
+		}
+ {{- else if eq .Type "binary"}}
+		for i, v := range other.{{.NameTitle}} {
+			if v != nil {
+				o.{{.NameTitle}}[i] = append(make([]byte, 0, len(v)), v...)
+			}
+		}
+ {{- else}}
+		copy(o.{{.NameTitle}}, other.{{.NameTitle}})
+ {{- end}}
+	}
+{{else if eq .Type "bool"}}
+	if other.{{.NameTitle}} {
+		o.{{.NameTitle}} = true
+	}
+{{else if eq .Type "timestamp"}}
+	if !other.{{.NameTitle}}.IsZero() {
+		o.{{.NameTitle}} = other.{{.NameTitle}}
+	}
+{{else if eq .Type "text"}}
+	if other.{{.NameTitle}} != "" {
+		o.{{.NameTitle}} = other.{{.NameTitle}}
+	}
+{{else if eq .Type "binary"}}
+	if len(other.{{.NameTitle}}) != 0 {
+		o.{{.NameTitle}} = append(make([]byte, 0, len(other.{{.NameTitle}})), other.{{.NameTitle}}...)
+	}
+{{else if eq .Type "any"}}
+	if v := other.{{.NameTitle}}; v != nil {
+		if o.{{.NameTitle}} == nil {
+			o.{{.NameTitle}} = v.colferClone()
+		} else {
+			o.{{.NameTitle}} = o.{{.NameTitle}}.colferMerge(v)
+		}
+	}
+{{else if .TypeRef}}
+	if v := other.{{.NameTitle}}; v != nil {
+		if o.{{.NameTitle}} == nil {
+			o.{{.NameTitle}} = v.Clone()
+		} else {
+			o.{{.NameTitle}}.Merge(v)
+		}
+	}
+{{else}}
+	if other.{{.NameTitle}} != 0 {
+		o.{{.NameTitle}} = other.{{.NameTitle}}
+	}
+{{end}}`
+
+const goDiffField = `{{if .TypeList}}
+	for i, a, b := 0, o.{{.NameTitle}}, other.{{.NameTitle}}; i < len(a) || i < len(b); i++ {
+		switch {
+		case i >= len(a):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("{{.Name}}[%d]", i), New: b[i]})
+		case i >= len(b):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("{{.Name}}[%d]", i), Old: a[i]})
+ {{- if .TypeRef}}
+		default:
+			for _, d := range a[i].Diff(b[i]) {
+  {{- if .Struct.Pkg.Lazy}}
+				if d.Path == "" {
+					d.Path = fmt.Sprintf("{{.Name}}[%d]", i)
+				} else {
+					d.Path = fmt.Sprintf("{{.Name}}[%d].", i) + d.Path
+				}
+  {{- else}}
+				d.Path = fmt.Sprintf("{{.Name}}[%d].", i) + d.Path
+  {{- end}}
+				diffs = append(diffs, {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}d{{else}}ColferDiff(d){{end}})
+			}
+ {{- else}}
+  {{- if eq .Type "float32"}}
+		case math.Float32bits(a[i]) != math.Float32bits(b[i]):
+  {{- else if eq .Type "float64"}}
+		case math.Float64bits(a[i]) != math.Float64bits(b[i]):
+  {{- else if eq .Type "binary"}}
+		case string(a[i]) != string(b[i]):
+  {{- else}}
+		case a[i] != b[i]:
+  {{- end}}
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("{{.Name}}[%d]", i), Old: a[i], New: b[i]})
+ {{- end}}
+		}
+	}
+{{else if eq .Type "any"}}
+	if v, w := o.{{.NameTitle}}, other.{{.NameTitle}}; v != nil && w != nil && v.ColferType() == w.ColferType() {
+		for _, d := range v.colferDiff(w) {
+			d.Path = "{{.Name}}." + d.Path
+			diffs = append(diffs, d)
+		}
+	} else if v != nil || w != nil {
+		diffs = append(diffs, ColferDiff{Path: "{{.Name}}", Old: v, New: w})
+	}
+{{else if .TypeRef}}
+	if v, w := o.{{.NameTitle}}, other.{{.NameTitle}}; v != nil && w != nil {
+		for _, d := range v.Diff(w) {
+ {{- if .Struct.Pkg.Lazy}}
+			if d.Path == "" {
+				d.Path = "{{.Name}}"
+			} else {
+				d.Path = "{{.Name}}." + d.Path
+			}
+ {{- else}}
+			d.Path = "{{.Name}}." + d.Path
+ {{- end}}
+			diffs = append(diffs, {{if eq .TypeRef.Pkg.Name .Struct.Pkg.Name}}d{{else}}ColferDiff(d){{end}})
+		}
+	} else if v != nil {
+		diffs = append(diffs, ColferDiff{Path: "{{.Name}}", Old: v})
+	} else if w != nil {
+		diffs = append(diffs, ColferDiff{Path: "{{.Name}}", New: w})
+	}
+{{else}}
+ {{- if eq .Type "float32"}}
+	if a, b := o.{{.NameTitle}}, other.{{.NameTitle}}; (a != 0 || b != 0) && math.Float32bits(a) != math.Float32bits(b) {
+ {{- else if eq .Type "float64"}}
+	if a, b := o.{{.NameTitle}}, other.{{.NameTitle}}; (a != 0 || b != 0) && math.Float64bits(a) != math.Float64bits(b) {
+ {{- else if eq .Type "timestamp"}}
+	if a, b := o.{{.NameTitle}}, other.{{.NameTitle}}; !a.Equal(b) {
+ {{- else if eq .Type "binary"}}
+	if a, b := o.{{.NameTitle}}, other.{{.NameTitle}}; string(a) != string(b) {
+ {{- else}}
+	if a, b := o.{{.NameTitle}}, other.{{.NameTitle}}; a != b {
+ {{- end}}
+		diffs = append(diffs, ColferDiff{Path: "{{.Name}}", Old: a, New: b})
+	}
+{{end}}`
+
+const goValidateField = `
+{{- if .Required}}
+	if {{if eq .Type "bool"}}!o.{{.NameTitle}}
+ {{- else if eq .Type "timestamp"}}o.{{.NameTitle}}.IsZero()
+ {{- else if eq .Type "text"}}o.{{.NameTitle}} == ""
+ {{- else if or .TypeList (eq .Type "binary")}}len(o.{{.NameTitle}}) == 0
+ {{- else if or .TypeRef (eq .Type "any")}}o.{{.NameTitle}} == nil
+ {{- else}}o.{{.NameTitle}} == 0
+ {{- end}} {
+		return ColferInvalid{Field: "{{.String}}", Rule: "required"}
+	}
+{{- end}}
+{{- if .Min}}
+	if {{if eq .Type "float32" "float64"}}!(o.{{.NameTitle}} >= {{.MinNative}}){{else}}o.{{.NameTitle}} < {{.MinNative}}{{end}} {
+		return ColferInvalid{Field: "{{.String}}", Rule: "min {{.Min}}"}
+	}
+{{- end}}
+{{- if .Max}}
+	if {{if eq .Type "float32" "float64"}}!(o.{{.NameTitle}} <= {{.MaxNative}}){{else}}o.{{.NameTitle}} > {{.MaxNative}}{{end}} {
+		return ColferInvalid{Field: "{{.String}}", Rule: "max {{.Max}}"}
+	}
+{{- end}}
+{{- if .MinLen}}
+	if len(o.{{.NameTitle}}) < {{.MinLen}} {
+		return ColferInvalid{Field: "{{.String}}", Rule: "minlen {{.MinLen}}"}
+	}
+{{- end}}
+{{- if .MaxLen}}
+	if len(o.{{.NameTitle}}) > {{.MaxLen}} {
+		return ColferInvalid{Field: "{{.String}}", Rule: "maxlen {{.MaxLen}}"}
+	}
+{{- end}}
+{{- if .Pattern}}
+	if !colfer{{.Struct.NameTitle}}{{.NameTitle}}Pattern.MatchString(o.{{.NameTitle}}) {
+		return ColferInvalid{Field: "{{.String}}", Rule: {{printf "%q" (printf "pattern %s" .Pattern)}}}
+	}
+{{- end}}`
+
+const goFuzz = `package {{.NameNative}}
+
+// Code generated by colf(1); DO NOT EDIT.
+// The compiler used schema file {{.SchemaFileList}}.
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// colferFuzzSeed adds the files in the corpus directory to the seed corpus of f.
+func colferFuzzSeed(f *testing.F) {
+	f.Add([]byte{0x7f})
+	paths, err := filepath.Glob(filepath.Join({{printf "%q" .FuzzCorpus}}, "*"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+}
+{{range .Structs}}
+// Fuzz{{.NameTitle}} checks that any serial which decodes as a {{.String}}
+// encodes without error, and that the encoding decodes to an equal value with
+// the same encoding.
+{{- if not .Pkg.Lazy}} Input which passes strict mode must encode as is.
+{{- end}}
+func Fuzz{{.NameTitle}}(f *testing.F) {
+	colferFuzzSeed(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		o := new({{.NameTitle}})
+		{{if .Pkg.Lazy}}_{{else}}n{{end}}, err := o.Unmarshal(data)
+		if err != nil {
+			return
+		}
+		serial, err := o.MarshalBinary()
+		if err != nil {
+			t.Fatal("marshal error:", err)
+		}
+{{- if not .Pkg.Lazy}}
+
+		opts := colferOptions()
+		opts.Strict = true
+		if _, err := new({{.NameTitle}}).UnmarshalWith(data[:n], opts); err == nil && !bytes.Equal(serial, data[:n]) {
+			t.Errorf("strict input %#x encoded as %#x", data[:n], serial)
+		}
+{{- end}}
+
+		got := new({{.NameTitle}})
+		if err := got.UnmarshalBinary(serial); err != nil {
+			t.Fatalf("encoding %#x got error: %s", serial, err)
+		}
+		if !got.Equal(o) {
+			t.Errorf("encoding %#x decoded as %v, want %v", serial, got, o)
+		}
+		again, err := got.MarshalBinary()
+		if err != nil {
+			t.Fatal("marshal error on decoded encoding:", err)
+		}
+		if !bytes.Equal(again, serial) {
+			t.Errorf("encoding %#x encoded again as %#x", serial, again)
+		}
+	})
+}
+{{end}}`
@@ -0,0 +1,172 @@
+package testdata
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/pascaldekloe/colfer/go/gen"
+)
+
+// anySerial is gen.w with gen.o {s "x"} in field v.
+const anySerial = "00" + "05" + "67656e2e6f" + "0801787f" + "7f"
+
+func TestAny(t *testing.T) {
+	w := &gen.W{V: &gen.O{S: "x"}}
+	got, err := w.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	if want, _ := hex.DecodeString(anySerial); !bytes.Equal(got, want) {
+		t.Errorf("got serial 0x%x, want 0x%s", got, anySerial)
+	}
+	if got, err := w.AppendColfer(nil); err != nil || hex.EncodeToString(got) != anySerial {
+		t.Errorf("got append 0x%x and error %v, want 0x%s", got, err, anySerial)
+	}
+
+	h := sha256.New()
+	if err := w.ColferHash(h); err != nil {
+		t.Fatal("hash error:", err)
+	}
+	if want := sha256.Sum256(got); !bytes.Equal(h.Sum(nil), want[:]) {
+		t.Error("hash differs from the serial")
+	}
+
+	decoded := new(gen.W)
+	if err := decoded.UnmarshalBinary(got); err != nil {
+		t.Fatal("unmarshal error:", err)
+	}
+	if o, ok := decoded.V.(*gen.O); !ok || o.S != "x" {
+		t.Errorf("got value %#v, want a *gen.O with s x", decoded.V)
+	}
+	if !decoded.Equal(w) {
+		t.Error("decoded copy not equal")
+	}
+	if c := decoded.Clone(); !c.Equal(w) || c.V == decoded.V {
+		t.Error("clone not an equal copy")
+	}
+
+	other := &gen.W{V: &gen.O{S: "y"}}
+	if diffs := w.Diff(other); len(diffs) != 1 || diffs[0].String() != "v.s: x -> y" {
+		t.Errorf("got diffs %q", diffs)
+	}
+	if diffs := w.Diff(&gen.W{V: &gen.E{}}); len(diffs) != 1 || diffs[0].Path != "v" {
+		t.Errorf("got diffs %q for a type change", diffs)
+	}
+	if w.Equal(&gen.W{V: &gen.E{}}) || w.Equal(new(gen.W)) {
+		t.Error("equal with another type")
+	}
+
+	merged := &gen.W{V: &gen.O{U8: 1}}
+	merged.Merge(w)
+	if o := merged.V.(*gen.O); o.U8 != 1 || o.S != "x" {
+		t.Errorf("got merge %#v", o)
+	}
+	merged.Merge(&gen.W{V: &gen.E{M: []byte{0x7f}}})
+	if _, ok := merged.V.(*gen.E); !ok {
+		t.Errorf("got merge %#v, want the other type", merged.V)
+	}
+}
+
+func TestAnyUnmarshalErrors(t *testing.T) {
+	golden := []struct {
+		serial string
+		err    error
+	}{
+		// type gen.z not registered
+		{"000567656e2e7a7f7f", gen.ColferError(2)},
+		// empty type name
+		{"00007f7f", gen.ColferError(2)},
+		// nested unknown header
+		{"000567656e2e6f657f", gen.ColferError(0)},
+	}
+	for _, gold := range golden {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := new(gen.W).Unmarshal(data); err != gold.err {
+			t.Errorf("0x%s: got error %v, want %v", gold.serial, err, gold.err)
+		}
+	}
+
+	// nested w in w
+	data, err := hex.DecodeString("000567656e2e77" + "000567656e2e77" + "7f" + "7f" + "7f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := gen.ColferOptions{SizeMax: gen.ColferSizeMax, DepthMax: 2}
+	if _, err := new(gen.W).UnmarshalWith(data, opts); err == nil {
+		t.Error("no error for nesting depth 3 with limit 2")
+	}
+	opts.DepthMax = 3
+	if n, err := new(gen.W).UnmarshalWith(data, opts); err != nil || n != len(data) {
+		t.Errorf("got %d bytes and error %v for nesting depth 3 with limit 3", n, err)
+	}
+}
+
+func TestAnyFields(t *testing.T) {
+	data, err := hex.DecodeString(anySerial)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := gen.ColferOptions{SizeMax: gen.ColferSizeMax, Fields: []string{}}
+	w := new(gen.W)
+	if n, err := w.UnmarshalWith(data, opts); err != nil || n != len(data) {
+		t.Fatalf("got %d bytes and error %v with none selected", n, err)
+	}
+	if w.V != nil {
+		t.Errorf("got value %#v with none selected", w.V)
+	}
+
+	opts.Fields = []string{"v.s"}
+	if _, err := w.UnmarshalWith(data, opts); err != nil {
+		t.Fatal(err)
+	}
+	if o, ok := w.V.(*gen.O); !ok || o.S != "x" {
+		t.Errorf("got value %#v with v.s selected", w.V)
+	}
+
+	for path, want := range map[string]bool{"v": true, "v.s": true, "v.m": true, "v.z": false, "v.o.s": true} {
+		if got := w.HasColferPath(path); got != want {
+			t.Errorf("got HasColferPath(%q) %t, want %t", path, got, want)
+		}
+	}
+}
+
+func TestAnyJSON(t *testing.T) {
+	w := &gen.W{V: &gen.O{S: "x"}}
+	got, err := json.Marshal(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"v":{"type":"gen.o","value":{"s":"x"}}}`
+	if string(got) != want {
+		t.Errorf("got JSON %s, want %s", got, want)
+	}
+
+	decoded := new(gen.W)
+	if err := json.Unmarshal(got, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(w) {
+		t.Errorf("got %#v from JSON", decoded.V)
+	}
+
+	if err := json.Unmarshal([]byte(`{"v":{"type":"gen.z","value":{}}}`), decoded); err == nil {
+		t.Error("no error for unregistered type")
+	}
+	if err := json.Unmarshal([]byte(`{"v":null}`), decoded); err != nil || decoded.V != nil {
+		t.Errorf("got %#v and error %v for null", decoded.V, err)
+	}
+
+	if v := gen.NewColferAny("gen.e"); v == nil || v.ColferType() != "gen.e" {
+		t.Errorf("got registry entry %#v for gen.e", v)
+	}
+	if v := gen.NewColferAny("gen.z"); v != nil {
+		t.Errorf("got registry entry %#v for gen.z", v)
+	}
+}
@@ -228,6 +228,31 @@ func BenchmarkMarshalReuse(b *testing.B) {
 	})
 }
 
+func BenchmarkAppendColfer(b *testing.B) {
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := b.N; i > 0; i-- {
+			var err error
+			holdSerial, err = testData[i%len(testData)].AppendColfer(nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("reuse", func(b *testing.B) {
+		buf := make([]byte, 0, gen.ColferSizeMax)
+		b.ReportAllocs()
+		for i := b.N; i > 0; i-- {
+			var err error
+			holdSerial, err = testData[i%len(testData)].AppendColfer(buf[:0])
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 func BenchmarkUnmarshalReuse(b *testing.B) {
 	holdData = new(gen.Colfer)
 	holdProtoBufData = new(gen.ProtoBuf)
@@ -4,6 +4,7 @@ import "github.com/pascaldekloe/colfer/go/gen"
 
 // Fuzz is a test for the generated code.
 // See https://github.com/dvyukov/go-fuzz
+// The native fuzz tests are in gen/Colfer_fuzz_test.go.
 func Fuzz(data []byte) int {
 	o := new(gen.O)
 	err := o.UnmarshalBinary(data)
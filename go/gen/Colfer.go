@@ -2,17 +2,26 @@
 package gen
 
 // Code generated by colf(1); DO NOT EDIT.
-// The compiler used schema file test.colf.
+// The compiler used schema file any.colf, rules.colf and test.colf.
 
 import (
-	"encoding/binary"
+	"bufio"
+	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
 	"math"
+	"regexp"
+	"strconv"
 	"time"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/pascaldekloe/colfer/rt"
 )
 
-var intconv = binary.BigEndian
+var intconv = rt.IntConv
 
 // Colfer configuration attributes
 var (
@@ -20,70 +29,429 @@ var (
 	ColferSizeMax = 16 * 1024 * 1024
 	// ColferListMax is the upper limit for the number of elements in a list.
 	ColferListMax = 64 * 1024
+	// ColferDepthMax is the upper limit for the number of nested data
+	// structure levels, including the root.
+	ColferDepthMax = 100
 )
 
 // ColferMax signals an upper limit breach.
-type ColferMax string
-
-// Error honors the error interface.
-func (m ColferMax) Error() string { return string(m) }
+type ColferMax = rt.ColferMax
 
 // ColferError signals a data mismatch as as a byte index.
-type ColferError int
+type ColferError = rt.ColferError
 
-// Error honors the error interface.
-func (i ColferError) Error() string {
-	return fmt.Sprintf("colfer: unknown header at byte %d", i)
+// ColferTail signals data continuation as a byte index.
+type ColferTail = rt.ColferTail
+
+// ColferNonCanonical signals an encoding deviation as a byte index.
+type ColferNonCanonical = rt.ColferNonCanonical
+
+// ColferDiff is a field difference between two data structures.
+type ColferDiff = rt.ColferDiff
+
+// ColferInvalid signals a schema rule violation.
+type ColferInvalid = rt.ColferInvalid
+
+// ColferOptions are limits for a single call, as an alternative to the
+// package-level configuration attributes. The zero value of each limit means
+// the respective configuration attribute.
+type ColferOptions struct {
+	// SizeMax is the upper limit for serial byte sizes. Zero means
+	// ColferSizeMax.
+	SizeMax int
+	// ListMax is the upper limit for the number of elements in a list. Zero
+	// means ColferListMax, if the package has lists.
+	ListMax int
+	// DepthMax is the upper limit for the number of data structure levels,
+	// including the root. Zero means ColferDepthMax, and a negative value
+	// means no limit.
+	DepthMax int
+	// Strict makes Unmarshal reject any serial which differs from the
+	// output of Marshal for the same data with a ColferNonCanonical.
+	Strict bool
+	// Validate makes Unmarshal check the schema rules of each data
+	// structure, conform Validate.
+	Validate bool
+	// NoCopy makes text and binary fields share memory with the serial data.
+	// See UnmarshalNoCopy for the lifetime contract.
+	NoCopy bool
+	// JSONQuote64 makes MarshalJSONWith encode 64-bit integers as JSON
+	// strings, which keeps them exact for JavaScript consumers.
+	JSONQuote64 bool
+	// Budget, when not nil, is the number of bytes which may still be
+	// allocated for field values, including those of nested data structures.
+	// Unmarshal deducts each allocation, and it fails with ColferMax before
+	// any allocation exceeds the remainder.
+	Budget *int
+	// Fields, when not nil, selects the fields to decode by path, with the
+	// schema names separated by dots, e.g., "o.s". A data structure field
+	// selects all of its nested fields. Unmarshal skips the other fields
+	// without allocation, and it leaves their values as is.
+	// See HasColferPath for validation.
+	Fields []string
 }
 
-// ColferTail signals data continuation as a byte index.
-type ColferTail int
+// limits returns opts with the package-level configuration attributes in
+// place of any zero limit.
+func (opts ColferOptions) limits() ColferOptions {
+	if opts.SizeMax == 0 {
+		opts.SizeMax = ColferSizeMax
+	}
+	if opts.ListMax == 0 {
+		opts.ListMax = ColferListMax
+	}
+	if opts.DepthMax == 0 {
+		opts.DepthMax = ColferDepthMax
+	}
+	return opts
+}
+
+// nested returns the options for a data structure in field, which is a
+// level deeper.
+func (opts ColferOptions) nested(field string) (ColferOptions, error) {
+	switch {
+	case opts.DepthMax == 1:
+		return opts, ColferMax(fmt.Sprintf("colfer: field %s exceeds the nesting depth limit", field))
+	case opts.DepthMax > 1:
+		opts.DepthMax--
+	}
+	return opts, nil
+}
+
+// selects returns whether Fields includes field, either as a whole or with
+// any of its nested fields.
+func (opts ColferOptions) selects(field string) bool {
+	if opts.Fields == nil {
+		return true
+	}
+	for _, p := range opts.Fields {
+		if len(p) >= len(field) && p[:len(field)] == field && (len(p) == len(field) || p[len(field)] == '.') {
+			return true
+		}
+	}
+	return false
+}
+
+// within returns the Fields for the data structure in field, with nil for all.
+func (opts ColferOptions) within(field string) []string {
+	if opts.Fields == nil {
+		return nil
+	}
+	var paths []string
+	for _, p := range opts.Fields {
+		if p == field {
+			return nil
+		}
+		if len(p) > len(field) && p[:len(field)] == field && p[len(field)] == '.' {
+			paths = append(paths, p[len(field)+1:])
+		}
+	}
+	return paths
+}
+
+// charge deducts n bytes for field from the allocation budget, if any.
+func (opts ColferOptions) charge(field string, n int) error {
+	if opts.Budget == nil {
+		return nil
+	}
+	if n > *opts.Budget {
+		return ColferMax(fmt.Sprintf("colfer: field %s exceeds the allocation budget", field))
+	}
+	*opts.Budget -= n
+	return nil
+}
+
+// colferCanon returns a ColferNonCanonical on the first byte in serial that
+// differs from canon. The serial starts at byte index offset.
+func colferCanon(canon, serial []byte, offset int) error {
+	for i := range serial {
+		if i >= len(canon) || serial[i] != canon[i] {
+			return ColferNonCanonical(offset + i)
+		}
+	}
+	if len(serial) != len(canon) {
+		return ColferNonCanonical(offset + len(serial))
+	}
+	return nil
+}
+
+// colferUTF8Len returns the number of bytes in p before the first invalid
+// UTF-8 sequence, if any.
+func colferUTF8Len(p []byte) int {
+	for i := 0; i < len(p); {
+		r, size := utf8.DecodeRune(p[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return len(p)
+}
+
+// colferOptions returns the package-level configuration attributes.
+func colferOptions() ColferOptions {
+	return ColferOptions{
+		SizeMax:  ColferSizeMax,
+		ListMax:  ColferListMax,
+		DepthMax: ColferDepthMax,
+	}
+}
+
+// ColferAny is a data structure of any type in this package. The serial of an
+// any field has the qualified schema name, e.g., "gen.o", next to the
+// data such that Unmarshal can decode to the concrete type. Nil means absence.
+type ColferAny interface {
+	// ColferType returns the qualified schema name.
+	ColferType() string
+	MarshalTo(buf []byte) int
+	MarshalLenWith(opts ColferOptions) (int, error)
+	AppendColferWith(dst []byte, opts ColferOptions) ([]byte, error)
+	ColferHashWith(h hash.Hash, opts ColferOptions) (int, error)
+	UnmarshalWith(data []byte, opts ColferOptions) (int, error)
+	HasColferPath(path string) bool
+	Validate() error
+	json.Marshaler
+	json.Unmarshaler
+	MarshalJSONWith(opts ColferOptions) ([]byte, error)
+	UnmarshalJSONWith(data []byte, opts ColferOptions) error
+
+	colferNew(opts ColferOptions, field string) (ColferAny, error)
+	colferEqual(other ColferAny) bool
+	colferClone() ColferAny
+	colferMerge(other ColferAny) ColferAny
+	colferDiff(other ColferAny) []ColferDiff
+}
+
+// NewColferAny returns a new data structure for the qualified schema name, or
+// nil when the name is not registered. The registry has all data structures
+// of this package.
+func NewColferAny(name string) ColferAny {
+	t := colferAnyType(name)
+	if t == nil {
+		return nil
+	}
+	v, _ := t.colferNew(ColferOptions{}, name)
+	return v
+}
+
+// colferAnyType returns a nil pointer of the data structure registered under
+// the qualified schema name, or nil when the name is not registered.
+func colferAnyType(name string) ColferAny {
+	switch name {
+	case "gen.o":
+		return (*O)(nil)
+	case "gen.e":
+		return (*E)(nil)
+	case "gen.w":
+		return (*W)(nil)
+	case "gen.r":
+		return (*R)(nil)
+	case "gen.old":
+		return (*Old)(nil)
+	case "gen.n":
+		return (*Renamed)(nil)
+	}
+	return nil
+}
+
+// colferAnyPath returns whether path locates a field in any of the registered
+// data structures.
+func colferAnyPath(path string) bool {
+	return (*O)(nil).HasColferPath(path) ||
+		(*E)(nil).HasColferPath(path) ||
+		(*W)(nil).HasColferPath(path) ||
+		(*R)(nil).HasColferPath(path) ||
+		(*Old)(nil).HasColferPath(path) ||
+		(*Renamed)(nil).HasColferPath(path)
+}
+
+// ColferDecoder reads consecutive Colfer serials from a stream.
+// The read buffer is reused between calls.
+type ColferDecoder struct {
+	r   io.Reader
+	err error // pending read error
+
+	// buf is the read buffer.
+	buf []byte
+	// offset is the index of the first data byte in buf.
+	offset int
+	// i is the index of the data end (exclusive) in buf.
+	i int
+}
+
+// NewColferDecoder returns a new decoder which reads from r.
+func NewColferDecoder(r io.Reader) *ColferDecoder {
+	size := 2048
+	if size > ColferSizeMax {
+		size = ColferSizeMax
+	}
+	return &ColferDecoder{r: r, buf: make([]byte, size)}
+}
+
+// Decode reads the next serial into v, which is any of the generated structs.
+// When the stream ends on a serial boundary, then the error is io.EOF. A stream
+// which ends within a serial gives io.ErrUnexpectedEOF instead.
+// The error return options are io.EOF, io.ErrUnexpectedEOF, ColferError and
+// ColferMax, or any error from the reader.
+func (d *ColferDecoder) Decode(v interface {
+	Unmarshal([]byte) (int, error)
+}) error {
+	for {
+		if d.offset < d.i {
+			n, err := v.Unmarshal(d.buf[d.offset:d.i])
+			if err != io.EOF {
+				if err == nil {
+					d.offset += n
+				}
+				return err
+			}
+		}
+		// not enough data
+
+		if d.err != nil {
+			if d.err == io.EOF && d.offset < d.i {
+				return io.ErrUnexpectedEOF
+			}
+			return d.err
+		}
+
+		if d.offset >= d.i {
+			d.offset, d.i = 0, 0
+		} else if d.i >= len(d.buf) {
+			if d.offset == 0 {
+				if len(d.buf) >= ColferSizeMax {
+					return ColferMax(fmt.Sprintf("colfer: serial exceeds %d bytes", ColferSizeMax))
+				}
+				// grow
+				size := len(d.buf) * 4
+				if size > ColferSizeMax {
+					size = ColferSizeMax
+				}
+				bigger := make([]byte, size)
+				copy(bigger, d.buf)
+				d.buf = bigger
+			} else {
+				// move data to start of buffer
+				copy(d.buf, d.buf[d.offset:d.i])
+				d.i -= d.offset
+				d.offset = 0
+			}
+		}
+
+		var n int
+		n, d.err = d.r.Read(d.buf[d.i:])
+		d.i += n
+	}
+}
+
+// ColferEncoder writes Colfer serials to a stream.
+// The write buffer is reused between calls.
+type ColferEncoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewColferEncoder returns a new encoder which writes to w.
+func NewColferEncoder(w io.Writer) *ColferEncoder {
+	return &ColferEncoder{w: w}
+}
+
+// Encode writes the serial of v, which is any of the generated structs, with
+// a single call to the writer.
+// The error return options are ColferMax, or any error from the writer.
+func (e *ColferEncoder) Encode(v interface {
+	MarshalLen() (int, error)
+	MarshalTo([]byte) int
+}) error {
+	l, err := v.MarshalLen()
+	if err != nil {
+		return err
+	}
+	if l > cap(e.buf) {
+		e.buf = make([]byte, l)
+	}
+	buf := e.buf[:l]
+	v.MarshalTo(buf)
+	_, err = e.w.Write(buf)
+	return err
+}
+
+// ColferSplit returns a bufio.SplitFunc for consecutive serials, which makes
+// each token a serial of v. The tokens are decoded into v as a side effect.
+// Note that bufio.Scanner limits the token size to bufio.MaxScanTokenSize,
+// unless configured otherwise, e.g., with ColferSizeMax as the maximum.
+func ColferSplit(v interface {
+	Unmarshal([]byte) (int, error)
+}) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if len(data) == 0 {
+			return 0, nil, nil
+		}
+		n, err := v.Unmarshal(data)
+		switch err {
+		case nil:
+			return n, data[:n], nil
+		case io.EOF:
+			if atEOF {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		default:
+			return 0, nil, err
+		}
+	}
+}
 
-// Error honors the error interface.
-func (i ColferTail) Error() string {
-	return fmt.Sprintf("colfer: data continuation at byte %d", i)
+// colferNoCopyString returns the bytes as a string without copying.
+func colferNoCopyString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return *(*string)(unsafe.Pointer(&b))
 }
 
 // O contains all supported data types.
 type O struct {
 	// B tests booleans.
-	B bool
+	B bool `json:"b,omitempty"`
 	// U32 tests unsigned 32-bit integers.
-	U32 uint32
+	U32 uint32 `json:"u32,omitempty"`
 	// U64 tests unsigned 64-bit integers.
-	U64 uint64
+	U64 uint64 `json:"u64,omitempty"`
 	// I32 tests signed 32-bit integers.
-	I32 int32
+	I32 int32 `json:"i32,omitempty"`
 	// I64 tests signed 64-bit integers.
-	I64 int64
+	I64 int64 `json:"i64,omitempty"`
 	// F32 tests 32-bit floating points.
-	F32 float32
+	F32 float32 `json:"f32,omitempty"`
 	// F64 tests 64-bit floating points.
-	F64 float64
+	F64 float64 `json:"f64,omitempty"`
 	// T tests timestamps.
-	T time.Time
+	T time.Time `json:"t,omitempty"`
 	// S tests text.
-	S string
+	S string `json:"s,omitempty"`
 	// A tests binaries.
-	A []byte
+	A []byte `json:"a,omitempty"`
 	// O tests nested data structures.
-	O *O
+	O *O `json:"o,omitempty"`
 	// Os tests data structure lists.
-	Os []*O
+	Os []*O `json:"os,omitempty"`
 	// Ss tests text lists.
-	Ss []string
+	Ss []string `json:"ss,omitempty"`
 	// As tests binary lists.
-	As [][]byte
+	As [][]byte `json:"as,omitempty"`
 	// U8 tests unsigned 8-bit integers.
-	U8 uint8
+	U8 uint8 `json:"u8,omitempty"`
 	// U16 tests unsigned 16-bit integers.
-	U16 uint16
+	U16 uint16 `json:"u16,omitempty"`
 	// F32s tests 32-bit floating point lists.
-	F32s []float32
+	F32s []float32 `json:"f32s,omitempty"`
 	// F64s tests 64-bit floating point lists.
-	F64s []float64
+	F64s []float64 `json:"f64s,omitempty"`
 }
 
+var _ rt.Message = (*O)(nil)
+
 // MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
 // If the buffer is too small, MarshalTo will panic.
 // All nil entries in o.Os will be replaced with a new value.
@@ -355,6 +723,14 @@ func (o *O) MarshalTo(buf []byte) int {
 // MarshalLen returns the Colfer serial byte size.
 // The error return option is gen.ColferMax.
 func (o *O) MarshalLen() (int, error) {
+	return o.MarshalLenWith(colferOptions())
+}
+
+// MarshalLenWith is like MarshalLen, yet with the limits of opts instead of the
+// package-level configuration attributes.
+// The error return option is gen.ColferMax.
+func (o *O) MarshalLenWith(opts ColferOptions) (int, error) {
+	opts = opts.limits()
 	l := 1
 
 	if o.B {
@@ -416,8 +792,8 @@ func (o *O) MarshalLen() (int, error) {
 	}
 
 	if x := len(o.S); x != 0 {
-		if x > ColferSizeMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.s exceeds %d bytes", ColferSizeMax))
+		if x > opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.s exceeds %d bytes", opts.SizeMax))
 		}
 		for l += x + 2; x >= 0x80; l++ {
 			x >>= 7
@@ -425,8 +801,8 @@ func (o *O) MarshalLen() (int, error) {
 	}
 
 	if x := len(o.A); x != 0 {
-		if x > ColferSizeMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.a exceeds %d bytes", ColferSizeMax))
+		if x > opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.a exceeds %d bytes", opts.SizeMax))
 		}
 		for l += x + 2; x >= 0x80; l++ {
 			x >>= 7
@@ -434,7 +810,11 @@ func (o *O) MarshalLen() (int, error) {
 	}
 
 	if v := o.O; v != nil {
-		vl, err := v.MarshalLen()
+		sub, err := opts.nested("gen.o.o")
+		if err != nil {
+			return 0, err
+		}
+		vl, err := v.MarshalLenWith(sub)
 		if err != nil {
 			return 0, err
 		}
@@ -442,67 +822,71 @@ func (o *O) MarshalLen() (int, error) {
 	}
 
 	if x := len(o.Os); x != 0 {
-		if x > ColferListMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.os exceeds %d elements", ColferListMax))
+		if x > opts.ListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.os exceeds %d elements", opts.ListMax))
 		}
 		for l += 2; x >= 0x80; l++ {
 			x >>= 7
 		}
+		sub, err := opts.nested("gen.o.os")
+		if err != nil {
+			return 0, err
+		}
 		for _, v := range o.Os {
 			if v == nil {
 				l++
 				continue
 			}
-			vl, err := v.MarshalLen()
+			vl, err := v.MarshalLenWith(sub)
 			if err != nil {
 				return 0, err
 			}
 			l += vl
 		}
-		if x > ColferSizeMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", ColferSizeMax))
+		if x > opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", opts.SizeMax))
 		}
 	}
 
 	if x := len(o.Ss); x != 0 {
-		if x > ColferListMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.ss exceeds %d elements", ColferListMax))
+		if x > opts.ListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.ss exceeds %d elements", opts.ListMax))
 		}
 		for l += 2; x >= 0x80; l++ {
 			x >>= 7
 		}
 		for _, a := range o.Ss {
 			x = len(a)
-			if x > ColferSizeMax {
-				return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.ss exceeds %d bytes", ColferSizeMax))
+			if x > opts.SizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.ss exceeds %d bytes", opts.SizeMax))
 			}
 			for l += x + 1; x >= 0x80; l++ {
 				x >>= 7
 			}
 		}
-		if l >= ColferSizeMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", ColferSizeMax))
+		if l >= opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", opts.SizeMax))
 		}
 	}
 
 	if x := len(o.As); x != 0 {
-		if x > ColferListMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.as exceeds %d elements", ColferListMax))
+		if x > opts.ListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.as exceeds %d elements", opts.ListMax))
 		}
 		for l += 2; x >= 0x80; l++ {
 			x >>= 7
 		}
 		for _, a := range o.As {
 			x = len(a)
-			if x > ColferSizeMax {
-				return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.as exceeds %d bytes", ColferSizeMax))
+			if x > opts.SizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.as exceeds %d bytes", opts.SizeMax))
 			}
 			for l += x + 1; x >= 0x80; l++ {
 				x >>= 7
 			}
 		}
-		if l >= ColferSizeMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", ColferSizeMax))
+		if l >= opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", opts.SizeMax))
 		}
 	}
 
@@ -517,8 +901,8 @@ func (o *O) MarshalLen() (int, error) {
 	}
 
 	if x := len(o.F32s); x != 0 {
-		if x > ColferListMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.f32s exceeds %d elements", ColferListMax))
+		if x > opts.ListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.f32s exceeds %d elements", opts.ListMax))
 		}
 		for l += 2 + x*4; x >= 0x80; l++ {
 			x >>= 7
@@ -526,16 +910,16 @@ func (o *O) MarshalLen() (int, error) {
 	}
 
 	if x := len(o.F64s); x != 0 {
-		if x > ColferListMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.f64s exceeds %d elements", ColferListMax))
+		if x > opts.ListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.o.f64s exceeds %d elements", opts.ListMax))
 		}
 		for l += 2 + x*8; x >= 0x80; l++ {
 			x >>= 7
 		}
 	}
 
-	if l > ColferSizeMax {
-		return l, ColferMax(fmt.Sprintf("colfer: struct gen.o exceeds %d bytes", ColferSizeMax))
+	if l > opts.SizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct gen.o exceeds %d bytes", opts.SizeMax))
 	}
 	return l, nil
 }
@@ -553,475 +937,6703 @@ func (o *O) MarshalBinary() (data []byte, err error) {
 	return data, nil
 }
 
-// Unmarshal decodes data as Colfer and returns the number of bytes read.
-// The error return options are io.EOF, gen.ColferError and gen.ColferMax.
-func (o *O) Unmarshal(data []byte) (int, error) {
-	if len(data) == 0 {
-		return 0, io.EOF
+// AppendColfer appends the Colfer serial of o to dst and returns the extended
+// buffer. Unlike MarshalBinary, the object is walked only once.
+// All nil entries in o.Os will be replaced with a new value.
+// When an error occurs, dst is returned with its original length.
+// The error return option is gen.ColferMax.
+func (o *O) AppendColfer(dst []byte) ([]byte, error) {
+	return o.AppendColferWith(dst, colferOptions())
+}
+
+// AppendColferWith is like AppendColfer, yet with the limits of opts instead
+// of the package-level configuration attributes.
+// The error return option is gen.ColferMax.
+func (o *O) AppendColferWith(dst []byte, opts ColferOptions) ([]byte, error) {
+	opts = opts.limits()
+	buf := dst
+
+	if o.B {
+		buf = append(buf, 0)
 	}
-	header := data[0]
-	i := 1
 
-	if header == 0 {
-		if i >= len(data) {
-			goto eof
+	if x := o.U32; x >= 1<<21 {
+		buf = append(buf, 1|0x80, 0, 0, 0, 0)
+		intconv.PutUint32(buf[len(buf)-4:], x)
+	} else if x != 0 {
+		buf = append(buf, 1)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
 		}
-		o.B = true
-		header = data[i]
-		i++
+		buf = append(buf, byte(x))
 	}
 
-	if header == 1 {
-		start := i
-		i++
-		if i >= len(data) {
-			goto eof
+	if x := o.U64; x >= 1<<49 {
+		buf = append(buf, 2|0x80, 0, 0, 0, 0, 0, 0, 0, 0)
+		intconv.PutUint64(buf[len(buf)-8:], x)
+	} else if x != 0 {
+		buf = append(buf, 2)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
 		}
-		x := uint32(data[start])
-
-		if x >= 0x80 {
-			x &= 0x7f
-			for shift := uint(7); ; shift += 7 {
-				b := uint32(data[i])
-				i++
-				if i >= len(data) {
-					goto eof
-				}
+		buf = append(buf, byte(x))
+	}
 
-				if b < 0x80 {
-					x |= b << shift
-					break
-				}
-				x |= (b & 0x7f) << shift
-			}
+	if v := o.I32; v != 0 {
+		x := uint32(v)
+		if v >= 0 {
+			buf = append(buf, 3)
+		} else {
+			x = ^x + 1
+			buf = append(buf, 3|0x80)
 		}
-		o.U32 = x
-
-		header = data[i]
-		i++
-	} else if header == 1|0x80 {
-		start := i
-		i += 4
-		if i >= len(data) {
-			goto eof
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
 		}
-		o.U32 = intconv.Uint32(data[start:])
-		header = data[i]
-		i++
+		buf = append(buf, byte(x))
 	}
 
-	if header == 2 {
-		start := i
-		i++
-		if i >= len(data) {
-			goto eof
+	if v := o.I64; v != 0 {
+		x := uint64(v)
+		if v >= 0 {
+			buf = append(buf, 4)
+		} else {
+			x = ^x + 1
+			buf = append(buf, 4|0x80)
 		}
-		x := uint64(data[start])
+		for n := 0; x >= 0x80 && n < 8; n++ {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
 
-		if x >= 0x80 {
-			x &= 0x7f
-			for shift := uint(7); ; shift += 7 {
-				b := uint64(data[i])
-				i++
-				if i >= len(data) {
-					goto eof
-				}
+	if v := o.F32; v != 0 {
+		buf = append(buf, 5, 0, 0, 0, 0)
+		intconv.PutUint32(buf[len(buf)-4:], math.Float32bits(v))
+	}
 
-				if b < 0x80 || shift == 56 {
-					x |= b << shift
-					break
-				}
-				x |= (b & 0x7f) << shift
-			}
-		}
-		o.U64 = x
+	if v := o.F64; v != 0 {
+		buf = append(buf, 6, 0, 0, 0, 0, 0, 0, 0, 0)
+		intconv.PutUint64(buf[len(buf)-8:], math.Float64bits(v))
+	}
 
-		header = data[i]
-		i++
-	} else if header == 2|0x80 {
-		start := i
-		i += 8
-		if i >= len(data) {
-			goto eof
+	if v := o.T; !v.IsZero() {
+		s, ns := uint64(v.Unix()), uint32(v.Nanosecond())
+		if s < 1<<32 {
+			buf = append(buf, 7, 0, 0, 0, 0, 0, 0, 0, 0)
+			intconv.PutUint32(buf[len(buf)-8:], uint32(s))
+		} else {
+			buf = append(buf, 7|0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+			intconv.PutUint64(buf[len(buf)-12:], s)
 		}
-		o.U64 = intconv.Uint64(data[start:])
-		header = data[i]
-		i++
+		intconv.PutUint32(buf[len(buf)-4:], ns)
 	}
 
-	if header == 3 {
-		if i+1 >= len(data) {
-			i++
-			goto eof
+	if l := len(o.S); l != 0 {
+		if l > opts.SizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.s exceeds %d bytes", opts.SizeMax))
 		}
-		x := uint32(data[i])
-		i++
-
-		if x >= 0x80 {
-			x &= 0x7f
-			for shift := uint(7); ; shift += 7 {
-				b := uint32(data[i])
-				i++
-				if i >= len(data) {
-					goto eof
-				}
-
-				if b < 0x80 {
-					x |= b << shift
-					break
-				}
-				x |= (b & 0x7f) << shift
-			}
+		buf = append(buf, 8)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
 		}
-		o.I32 = int32(x)
+		buf = append(buf, byte(x))
+		buf = append(buf, o.S...)
+	}
 
-		header = data[i]
-		i++
-	} else if header == 3|0x80 {
-		if i+1 >= len(data) {
-			i++
-			goto eof
+	if l := len(o.A); l != 0 {
+		if l > opts.SizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.a exceeds %d bytes", opts.SizeMax))
 		}
-		x := uint32(data[i])
-		i++
+		buf = append(buf, 9)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.A...)
+	}
 
-		if x >= 0x80 {
+	if v := o.O; v != nil {
+		sub, err := opts.nested("gen.o.o")
+		if err != nil {
+			return dst, err
+		}
+		buf, err = v.AppendColferWith(append(buf, 10), sub)
+		if err != nil {
+			return dst, err
+		}
+	}
+
+	if l := len(o.Os); l != 0 {
+		if l > opts.ListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.os exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, 11)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		sub, err := opts.nested("gen.o.os")
+		if err != nil {
+			return dst, err
+		}
+		for vi, v := range o.Os {
+			if v == nil {
+				v = new(O)
+				o.Os[vi] = v
+			}
+			buf, err = v.AppendColferWith(buf, sub)
+			if err != nil {
+				return dst, err
+			}
+		}
+	}
+
+	if l := len(o.Ss); l != 0 {
+		if l > opts.ListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.ss exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, 12)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, a := range o.Ss {
+			if len(a) > opts.SizeMax {
+				return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.ss exceeds %d bytes", opts.SizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			buf = append(buf, a...)
+		}
+	}
+
+	if l := len(o.As); l != 0 {
+		if l > opts.ListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.as exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, 13)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, a := range o.As {
+			if len(a) > opts.SizeMax {
+				return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.as exceeds %d bytes", opts.SizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			buf = append(buf, a...)
+		}
+	}
+
+	if x := o.U8; x != 0 {
+		buf = append(buf, 14, x)
+	}
+
+	if x := o.U16; x >= 1<<8 {
+		buf = append(buf, 15, byte(x>>8), byte(x))
+	} else if x != 0 {
+		buf = append(buf, 15|0x80, byte(x))
+	}
+
+	if l := len(o.F32s); l != 0 {
+		if l > opts.ListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.f32s exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, 16)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, v := range o.F32s {
+			buf = append(buf, 0, 0, 0, 0)
+			intconv.PutUint32(buf[len(buf)-4:], math.Float32bits(v))
+		}
+	}
+
+	if l := len(o.F64s); l != 0 {
+		if l > opts.ListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.o.f64s exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, 17)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, v := range o.F64s {
+			buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 0)
+			intconv.PutUint64(buf[len(buf)-8:], math.Float64bits(v))
+		}
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-len(dst) > opts.SizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct gen.o exceeds %d bytes", opts.SizeMax))
+	}
+	return buf, nil
+}
+
+// ColferHash writes the Colfer serial of o to h, without materializing the
+// serial as a whole. Equal values produce the same input for h in each of the
+// supported languages.
+// The error return option is gen.ColferMax.
+func (o *O) ColferHash(h hash.Hash) error {
+	_, err := o.ColferHashWith(h, colferOptions())
+	return err
+}
+
+// ColferHashWith is like ColferHash, yet with the limits of opts instead of the
+// package-level configuration attributes. The return is the number of bytes
+// written to h. When an error occurs, then the state of h is undefined.
+// The error return option is gen.ColferMax.
+func (o *O) ColferHashWith(h hash.Hash, opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	var scratch [32]byte
+	buf := scratch[:0]
+	var n int
+
+	if o.B {
+		buf = append(buf, 0)
+	}
+
+	if x := o.U32; x >= 1<<21 {
+		buf = append(buf, 1|0x80, 0, 0, 0, 0)
+		intconv.PutUint32(buf[len(buf)-4:], x)
+	} else if x != 0 {
+		buf = append(buf, 1)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if x := o.U64; x >= 1<<49 {
+		buf = append(buf, 2|0x80, 0, 0, 0, 0, 0, 0, 0, 0)
+		intconv.PutUint64(buf[len(buf)-8:], x)
+	} else if x != 0 {
+		buf = append(buf, 2)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if v := o.I32; v != 0 {
+		x := uint32(v)
+		if v >= 0 {
+			buf = append(buf, 3)
+		} else {
+			x = ^x + 1
+			buf = append(buf, 3|0x80)
+		}
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if v := o.I64; v != 0 {
+		x := uint64(v)
+		if v >= 0 {
+			buf = append(buf, 4)
+		} else {
+			x = ^x + 1
+			buf = append(buf, 4|0x80)
+		}
+		for n := 0; x >= 0x80 && n < 8; n++ {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if v := o.F32; v != 0 {
+		buf = append(buf, 5, 0, 0, 0, 0)
+		intconv.PutUint32(buf[len(buf)-4:], math.Float32bits(v))
+	}
+
+	if v := o.F64; v != 0 {
+		buf = append(buf, 6, 0, 0, 0, 0, 0, 0, 0, 0)
+		intconv.PutUint64(buf[len(buf)-8:], math.Float64bits(v))
+	}
+
+	if v := o.T; !v.IsZero() {
+		s, ns := uint64(v.Unix()), uint32(v.Nanosecond())
+		if s < 1<<32 {
+			buf = append(buf, 7, 0, 0, 0, 0, 0, 0, 0, 0)
+			intconv.PutUint32(buf[len(buf)-8:], uint32(s))
+		} else {
+			buf = append(buf, 7|0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+			intconv.PutUint64(buf[len(buf)-12:], s)
+		}
+		intconv.PutUint32(buf[len(buf)-4:], ns)
+	}
+
+	if l := len(o.S); l != 0 {
+		if l > opts.SizeMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field gen.o.s exceeds %d bytes", opts.SizeMax))
+		}
+		buf = append(buf, 8)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		h.Write(buf)
+		n += len(buf) + l
+		buf = buf[:0]
+		io.WriteString(h, o.S)
+	}
+
+	if l := len(o.A); l != 0 {
+		if l > opts.SizeMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field gen.o.a exceeds %d bytes", opts.SizeMax))
+		}
+		buf = append(buf, 9)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		h.Write(buf)
+		n += len(buf) + l
+		buf = buf[:0]
+		h.Write(o.A)
+	}
+
+	if v := o.O; v != nil {
+		sub, err := opts.nested("gen.o.o")
+		if err != nil {
+			return n, err
+		}
+		buf = append(buf, 10)
+		h.Write(buf)
+		n += len(buf)
+		buf = buf[:0]
+		vn, err := v.ColferHashWith(h, sub)
+		n += vn
+		if err != nil {
+			return n, err
+		}
+	}
+
+	if l := len(o.Os); l != 0 {
+		if l > opts.ListMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field gen.o.os exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, 11)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		sub, err := opts.nested("gen.o.os")
+		if err != nil {
+			return n, err
+		}
+		for _, v := range o.Os {
+			if v == nil {
+				// same as the zero value
+				buf = append(buf, 0x7f)
+				continue
+			}
+			h.Write(buf)
+			n += len(buf)
+			buf = buf[:0]
+			vn, err := v.ColferHashWith(h, sub)
+			n += vn
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+
+	if l := len(o.Ss); l != 0 {
+		if l > opts.ListMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field gen.o.ss exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, 12)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, a := range o.Ss {
+			if len(a) > opts.SizeMax {
+				return n, ColferMax(fmt.Sprintf("colfer: field gen.o.ss exceeds %d bytes", opts.SizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			h.Write(buf)
+			n += len(buf) + len(a)
+			buf = buf[:0]
+			io.WriteString(h, a)
+		}
+	}
+
+	if l := len(o.As); l != 0 {
+		if l > opts.ListMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field gen.o.as exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, 13)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, a := range o.As {
+			if len(a) > opts.SizeMax {
+				return n, ColferMax(fmt.Sprintf("colfer: field gen.o.as exceeds %d bytes", opts.SizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			h.Write(buf)
+			n += len(buf) + len(a)
+			buf = buf[:0]
+			h.Write(a)
+		}
+	}
+
+	if x := o.U8; x != 0 {
+		buf = append(buf, 14, x)
+	}
+
+	if x := o.U16; x >= 1<<8 {
+		buf = append(buf, 15, byte(x>>8), byte(x))
+	} else if x != 0 {
+		buf = append(buf, 15|0x80, byte(x))
+	}
+
+	if l := len(o.F32s); l != 0 {
+		if l > opts.ListMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field gen.o.f32s exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, 16)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, v := range o.F32s {
+			if len(buf) > len(scratch)-8 {
+				h.Write(buf)
+				n += len(buf)
+				buf = buf[:0]
+			}
+			buf = append(buf, 0, 0, 0, 0)
+			intconv.PutUint32(buf[len(buf)-4:], math.Float32bits(v))
+		}
+	}
+
+	if l := len(o.F64s); l != 0 {
+		if l > opts.ListMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field gen.o.f64s exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, 17)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, v := range o.F64s {
+			if len(buf) > len(scratch)-8 {
+				h.Write(buf)
+				n += len(buf)
+				buf = buf[:0]
+			}
+			buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 0)
+			intconv.PutUint64(buf[len(buf)-8:], math.Float64bits(v))
+		}
+	}
+
+	buf = append(buf, 0x7f)
+	h.Write(buf)
+	n += len(buf)
+	if n > opts.SizeMax {
+		return n, ColferMax(fmt.Sprintf("colfer: struct gen.o exceeds %d bytes", opts.SizeMax))
+	}
+	return n, nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, gen.ColferError and gen.ColferMax.
+func (o *O) Unmarshal(data []byte) (int, error) {
+	return o.UnmarshalWith(data, colferOptions())
+}
+
+// UnmarshalNoCopy is like Unmarshal, yet the text and binary fields, including
+// those of nested data structures, share memory with data instead of holding a
+// copy. Any modification to data is visible through o, and vice versa, for as
+// long as o is in use. The caller must not reuse or recycle data (buffers)
+// before o and all of the values read from it are no longer referenced.
+// The error return options are io.EOF, gen.ColferError and gen.ColferMax.
+func (o *O) UnmarshalNoCopy(data []byte) (int, error) {
+	opts := colferOptions()
+	opts.NoCopy = true
+	return o.UnmarshalWith(data, opts)
+}
+
+// UnmarshalWith is like Unmarshal, yet with the limits and the field selection
+// of opts instead of the package-level configuration attributes.
+// The error return options are io.EOF, gen.ColferError, gen.ColferMax,
+// with opts.Strict, gen.ColferNonCanonical and, with opts.Validate,
+// gen.ColferInvalid.
+func (o *O) UnmarshalWith(data []byte, opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 && !opts.selects("b") {
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		o.B = true
+		header = data[i]
+		i++
+	}
+
+	if header&0x7f == 1 && !opts.selects("u32") {
+		if header&0x80 != 0 {
+			i += 4
+		} else {
+			for {
+				if i >= len(data) {
+					goto eof
+				}
+				b := data[i]
+				i++
+				if b < 0x80 {
+					break
+				}
+			}
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		at := i - 1
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint32(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.U32 = x
+
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.U32; x >= 1<<21 {
+				buf = append(buf, 1|0x80, 0, 0, 0, 0)
+				intconv.PutUint32(buf[len(buf)-4:], x)
+			} else if x != 0 {
+				buf = append(buf, 1)
+				for x >= 0x80 {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	} else if header == 1|0x80 {
+		at := i - 1
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.U32 = intconv.Uint32(data[start:])
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.U32; x >= 1<<21 {
+				buf = append(buf, 1|0x80, 0, 0, 0, 0)
+				intconv.PutUint32(buf[len(buf)-4:], x)
+			} else if x != 0 {
+				buf = append(buf, 1)
+				for x >= 0x80 {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header&0x7f == 2 && !opts.selects("u64") {
+		if header&0x80 != 0 {
+			i += 8
+		} else {
+			for n := 1; ; n++ {
+				if i >= len(data) {
+					goto eof
+				}
+				b := data[i]
+				i++
+				if b < 0x80 || n == 9 {
+					break
+				}
+			}
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 2 {
+		at := i - 1
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint64(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.U64 = x
+
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.U64; x >= 1<<49 {
+				buf = append(buf, 2|0x80, 0, 0, 0, 0, 0, 0, 0, 0)
+				intconv.PutUint64(buf[len(buf)-8:], x)
+			} else if x != 0 {
+				buf = append(buf, 2)
+				for x >= 0x80 {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	} else if header == 2|0x80 {
+		at := i - 1
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.U64 = intconv.Uint64(data[start:])
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.U64; x >= 1<<49 {
+				buf = append(buf, 2|0x80, 0, 0, 0, 0, 0, 0, 0, 0)
+				intconv.PutUint64(buf[len(buf)-8:], x)
+			} else if x != 0 {
+				buf = append(buf, 2)
+				for x >= 0x80 {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header&0x7f == 3 && !opts.selects("i32") {
+		for {
+			if i >= len(data) {
+				goto eof
+			}
+			b := data[i]
+			i++
+			if b < 0x80 {
+				break
+			}
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 3 {
+		at := i - 1
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint32(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I32 = int32(x)
+
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if v := o.I32; v != 0 {
+				x := uint32(v)
+				if v >= 0 {
+					buf = append(buf, 3)
+				} else {
+					x = ^x + 1
+					buf = append(buf, 3|0x80)
+				}
+				for x >= 0x80 {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	} else if header == 3|0x80 {
+		at := i - 1
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint32(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I32 = int32(^x + 1)
+
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if v := o.I32; v != 0 {
+				x := uint32(v)
+				if v >= 0 {
+					buf = append(buf, 3)
+				} else {
+					x = ^x + 1
+					buf = append(buf, 3|0x80)
+				}
+				for x >= 0x80 {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header&0x7f == 4 && !opts.selects("i64") {
+		for n := 1; ; n++ {
+			if i >= len(data) {
+				goto eof
+			}
+			b := data[i]
+			i++
+			if b < 0x80 || n == 9 {
+				break
+			}
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 4 {
+		at := i - 1
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint64(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I64 = int64(x)
+
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if v := o.I64; v != 0 {
+				x := uint64(v)
+				if v >= 0 {
+					buf = append(buf, 4)
+				} else {
+					x = ^x + 1
+					buf = append(buf, 4|0x80)
+				}
+				for n := 0; x >= 0x80 && n < 8; n++ {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	} else if header == 4|0x80 {
+		at := i - 1
+		if i+1 >= len(data) {
+			i++
+			goto eof
+		}
+		x := uint64(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.I64 = int64(^x + 1)
+
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if v := o.I64; v != 0 {
+				x := uint64(v)
+				if v >= 0 {
+					buf = append(buf, 4)
+				} else {
+					x = ^x + 1
+					buf = append(buf, 4|0x80)
+				}
+				for n := 0; x >= 0x80 && n < 8; n++ {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header == 5 && !opts.selects("f32") {
+		i += 4
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 5 {
+		at := i - 1
+		start := i
+		i += 4
+		if i >= len(data) {
+			goto eof
+		}
+		o.F32 = math.Float32frombits(intconv.Uint32(data[start:]))
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if v := o.F32; v != 0 {
+				buf = append(buf, 5, 0, 0, 0, 0)
+				intconv.PutUint32(buf[len(buf)-4:], math.Float32bits(v))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header == 6 && !opts.selects("f64") {
+		i += 8
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 6 {
+		at := i - 1
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.F64 = math.Float64frombits(intconv.Uint64(data[start:]))
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if v := o.F64; v != 0 {
+				buf = append(buf, 6, 0, 0, 0, 0, 0, 0, 0, 0)
+				intconv.PutUint64(buf[len(buf)-8:], math.Float64bits(v))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header&0x7f == 7 && !opts.selects("t") {
+		if header&0x80 != 0 {
+			i += 12
+		} else {
+			i += 8
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 7 {
+		at := i - 1
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.T = time.Unix(int64(intconv.Uint32(data[start:])), int64(intconv.Uint32(data[start+4:]))).In(time.UTC)
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if v := o.T; !v.IsZero() {
+				s, ns := uint64(v.Unix()), uint32(v.Nanosecond())
+				if s < 1<<32 {
+					buf = append(buf, 7, 0, 0, 0, 0, 0, 0, 0, 0)
+					intconv.PutUint32(buf[len(buf)-8:], uint32(s))
+				} else {
+					buf = append(buf, 7|0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+					intconv.PutUint64(buf[len(buf)-12:], s)
+				}
+				intconv.PutUint32(buf[len(buf)-4:], ns)
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	} else if header == 7|0x80 {
+		at := i - 1
+		start := i
+		i += 12
+		if i >= len(data) {
+			goto eof
+		}
+		o.T = time.Unix(int64(intconv.Uint64(data[start:])), int64(intconv.Uint32(data[start+8:]))).In(time.UTC)
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if v := o.T; !v.IsZero() {
+				s, ns := uint64(v.Unix()), uint32(v.Nanosecond())
+				if s < 1<<32 {
+					buf = append(buf, 7, 0, 0, 0, 0, 0, 0, 0, 0)
+					intconv.PutUint32(buf[len(buf)-8:], uint32(s))
+				} else {
+					buf = append(buf, 7|0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+					intconv.PutUint64(buf[len(buf)-12:], s)
+				}
+				intconv.PutUint32(buf[len(buf)-4:], ns)
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header == 8 && !opts.selects("s") {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.s size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+		i += int(x)
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 8 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.s size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if opts.Strict {
+			if n := colferUTF8Len(data[start:i]); n < int(x) {
+				return 0, ColferNonCanonical(start + n)
+			}
+		}
+		if opts.NoCopy {
+			o.S = colferNoCopyString(data[start:i])
+		} else {
+			if err := opts.charge("gen.o.s", int(x)); err != nil {
+				return 0, err
+			}
+			o.S = string(data[start:i])
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header == 9 && !opts.selects("a") {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.a size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+		i += int(x)
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 9 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.a size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if opts.NoCopy {
+			o.A = data[start:i:i]
+		} else {
+			if err := opts.charge("gen.o.a", int(x)); err != nil {
+				return 0, err
+			}
+			v := make([]byte, int(x))
+			copy(v, data[start:i])
+			o.A = v
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header == 10 && !opts.selects("o") {
+		sub, err := opts.nested("gen.o.o")
+		if err != nil {
+			return 0, err
+		}
+		// none selected, so the receiver is never read nor written
+		sub.Fields, sub.Validate = opts.Fields[:0], false
+
+		{
+			n, err := (*O)(nil).UnmarshalWith(data[i:], sub)
+			if err != nil {
+				if err == io.EOF && len(data) >= opts.SizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: gen.o size exceeds %d bytes", opts.SizeMax))
+				}
+				if e, ok := err.(ColferNonCanonical); ok {
+					return 0, ColferNonCanonical(i + int(e))
+				}
+				return 0, err
+			}
+			i += n
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 10 {
+		sub, err := opts.nested("gen.o.o")
+		if err != nil {
+			return 0, err
+		}
+		sub.Fields = opts.within("o")
+
+		if err := opts.charge("gen.o.o", int(unsafe.Sizeof(O{}))); err != nil {
+			return 0, err
+		}
+		o.O = new(O)
+		n, err := o.O.UnmarshalWith(data[i:], sub)
+		if err != nil {
+			if err == io.EOF && len(data) >= opts.SizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: gen.o size exceeds %d bytes", opts.SizeMax))
+			}
+			if e, ok := err.(ColferNonCanonical); ok {
+				return 0, ColferNonCanonical(i + int(e))
+			}
+			return 0, err
+		}
+		i += n
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 11 && !opts.selects("os") {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.os length %d exceeds %d elements", x, opts.ListMax))
+		}
+		sub, err := opts.nested("gen.o.os")
+		if err != nil {
+			return 0, err
+		}
+		// none selected, so the receiver is never read nor written
+		sub.Fields, sub.Validate = opts.Fields[:0], false
+
+		for l := int(x); l > 0; l-- {
+			n, err := (*O)(nil).UnmarshalWith(data[i:], sub)
+			if err != nil {
+				if err == io.EOF && len(data) >= opts.SizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: gen.o size exceeds %d bytes", opts.SizeMax))
+				}
+				if e, ok := err.(ColferNonCanonical); ok {
+					return 0, ColferNonCanonical(i + int(e))
+				}
+				return 0, err
+			}
+			i += n
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 11 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.os length %d exceeds %d elements", x, opts.ListMax))
+		}
+
+		sub, err := opts.nested("gen.o.os")
+		if err != nil {
+			return 0, err
+		}
+		sub.Fields = opts.within("os")
+
+		l := int(x)
+		// each element takes at least one byte
+		if end := i + l; end >= len(data) {
+			i = end
+			goto eof
+		}
+		if err := opts.charge("gen.o.os", l*int(unsafe.Sizeof((*O)(nil))+unsafe.Sizeof(O{}))); err != nil {
+			return 0, err
+		}
+		a := make([]*O, l)
+		malloc := make([]O, l)
+		for ai := range a {
+			v := &malloc[ai]
+			a[ai] = v
+
+			n, err := v.UnmarshalWith(data[i:], sub)
+			if err != nil {
+				if err == io.EOF && len(data) >= opts.SizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: gen.o size exceeds %d bytes", opts.SizeMax))
+				}
+				if e, ok := err.(ColferNonCanonical); ok {
+					return 0, ColferNonCanonical(i + int(e))
+				}
+				return 0, err
+			}
+			i += n
+		}
+		o.Os = a
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 12 && !opts.selects("ss") {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.ss length %d exceeds %d elements", x, opts.ListMax))
+		}
+		for ai, l := 0, int(x); ai < l; ai++ {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						if b == 0 && opts.Strict {
+							return 0, ColferNonCanonical(i - 1)
+						}
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(opts.SizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: gen.o.ss element %d size %d exceeds %d bytes", ai, x, opts.SizeMax))
+			}
+			i += int(x)
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 12 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.ss length %d exceeds %d elements", x, opts.ListMax))
+		}
+		// each element takes at least one byte
+		if end := i + int(x); end >= len(data) {
+			i = end
+			goto eof
+		}
+		if err := opts.charge("gen.o.ss", int(x)*int(unsafe.Sizeof(""))); err != nil {
+			return 0, err
+		}
+		a := make([]string, int(x))
+		o.Ss = a
+
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						if b == 0 && opts.Strict {
+							return 0, ColferNonCanonical(i - 1)
+						}
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(opts.SizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: gen.o.ss element %d size %d exceeds %d bytes", ai, x, opts.SizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			if opts.Strict {
+				if n := colferUTF8Len(data[start:i]); n < int(x) {
+					return 0, ColferNonCanonical(start + n)
+				}
+			}
+			if opts.NoCopy {
+				a[ai] = colferNoCopyString(data[start:i])
+			} else {
+				if err := opts.charge("gen.o.ss", int(x)); err != nil {
+					return 0, err
+				}
+				a[ai] = string(data[start:i])
+			}
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 13 && !opts.selects("as") {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.as length %d exceeds %d elements", x, opts.ListMax))
+		}
+		for ai, l := 0, int(x); ai < l; ai++ {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						if b == 0 && opts.Strict {
+							return 0, ColferNonCanonical(i - 1)
+						}
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(opts.SizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: gen.o.as element %d size %d exceeds %d bytes", ai, x, opts.SizeMax))
+			}
+			i += int(x)
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 13 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.as length %d exceeds %d elements", x, opts.ListMax))
+		}
+		// each element takes at least one byte
+		if end := i + int(x); end >= len(data) {
+			i = end
+			goto eof
+		}
+		if err := opts.charge("gen.o.as", int(x)*int(unsafe.Sizeof([]byte(nil)))); err != nil {
+			return 0, err
+		}
+		a := make([][]byte, int(x))
+		o.As = a
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						if b == 0 && opts.Strict {
+							return 0, ColferNonCanonical(i - 1)
+						}
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(opts.SizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: gen.o.as element %d size %d exceeds %d bytes", ai, x, opts.SizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+
+			if opts.NoCopy {
+				a[ai] = data[start:i:i]
+			} else {
+				if err := opts.charge("gen.o.as", int(x)); err != nil {
+					return 0, err
+				}
+				v := make([]byte, int(x))
+				copy(v, data[start:i])
+				a[ai] = v
+			}
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 14 && !opts.selects("u8") {
+		i++
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 14 {
+		at := i - 1
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.U8 = data[start]
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.U8; x != 0 {
+				buf = append(buf, 14, x)
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header&0x7f == 15 && !opts.selects("u16") {
+		if header&0x80 != 0 {
+			i++
+		} else {
+			i += 2
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 15 {
+		at := i - 1
+		start := i
+		i += 2
+		if i >= len(data) {
+			goto eof
+		}
+		o.U16 = intconv.Uint16(data[start:])
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.U16; x >= 1<<8 {
+				buf = append(buf, 15, byte(x>>8), byte(x))
+			} else if x != 0 {
+				buf = append(buf, 15|0x80, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	} else if header == 15|0x80 {
+		at := i - 1
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.U16 = uint16(data[start])
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.U16; x >= 1<<8 {
+				buf = append(buf, 15, byte(x>>8), byte(x))
+			} else if x != 0 {
+				buf = append(buf, 15|0x80, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header == 16 && !opts.selects("f32s") {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.f32s length %d exceeds %d elements", x, opts.ListMax))
+		}
+		i += int(x) * 4
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 16 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.f32s length %d exceeds %d elements", x, opts.ListMax))
+		}
+
+		l := int(x)
+
+		if end := i + l*4; end >= len(data) {
+			i = end
+			goto eof
+		}
+		if err := opts.charge("gen.o.f32s", l*int(unsafe.Sizeof(float32(0)))); err != nil {
+			return 0, err
+		}
+		a := make([]float32, l)
+		for ai := range a {
+			a[ai] = math.Float32frombits(intconv.Uint32(data[i:]))
+			i += 4
+		}
+		o.F32s = a
+
+		header = data[i]
+		i++
+	}
+
+	if header == 17 && !opts.selects("f64s") {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.f64s length %d exceeds %d elements", x, opts.ListMax))
+		}
+		i += int(x) * 8
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 17 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.f64s length %d exceeds %d elements", x, opts.ListMax))
+		}
+		l := int(x)
+
+		if end := i + l*8; end >= len(data) {
+			i = end
+			goto eof
+		}
+		if err := opts.charge("gen.o.f64s", l*int(unsafe.Sizeof(float64(0)))); err != nil {
+			return 0, err
+		}
+		a := make([]float64, l)
+		for ai := range a {
+			a[ai] = math.Float64frombits(intconv.Uint64(data[i:]))
+			i += 8
+		}
+		o.F64s = a
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < opts.SizeMax {
+		return i, nil
+	}
+eof:
+	if i >= opts.SizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", opts.SizeMax))
+	}
+	return 0, io.EOF
+}
+
+// HasColferPath returns whether path locates a field in the schema, with the
+// names separated by dots, e.g., "o.s". See ColferOptions.Fields.
+func (*O) HasColferPath(path string) bool {
+	name, nested := path, false
+	for i := 0; i < len(path); i++ {
+		if path[i] == '.' {
+			name, nested = path[:i], true
+			break
+		}
+	}
+
+	switch name {
+	case "b":
+		return !nested
+	case "u32":
+		return !nested
+	case "u64":
+		return !nested
+	case "i32":
+		return !nested
+	case "i64":
+		return !nested
+	case "f32":
+		return !nested
+	case "f64":
+		return !nested
+	case "t":
+		return !nested
+	case "s":
+		return !nested
+	case "a":
+		return !nested
+	case "o":
+		return !nested || (*O)(nil).HasColferPath(path[len(name)+1:])
+	case "os":
+		return !nested || (*O)(nil).HasColferPath(path[len(name)+1:])
+	case "ss":
+		return !nested
+	case "as":
+		return !nested
+	case "u8":
+		return !nested
+	case "u16":
+		return !nested
+	case "f32s":
+		return !nested
+	case "f64s":
+		return !nested
+	}
+	return false
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, gen.ColferError, gen.ColferTail and gen.ColferMax.
+func (o *O) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// Value returns the serial data conform database/sql/driver.Valuer, with nil
+// (NULL) for a nil o. The error return option is gen.ColferMax.
+func (o *O) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+	return o.MarshalBinary()
+}
+
+// Scan decodes the serial data of a []byte or string conform database/sql.Scanner.
+// Any previous content of o is discarded, including on NULL, which reads as the
+// zero value. Scan into a **O to get nil for NULL instead. The error
+// return options are io.ErrUnexpectedEOF, gen.ColferError,
+// gen.ColferTail and gen.ColferMax.
+func (o *O) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*o = O{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("colfer: struct gen.o scan from SQL type %T, want []byte or string", src)
+	}
+
+	// no carry-over from previous rows
+	*o = O{}
+	err := o.UnmarshalBinary(data)
+	if err == io.EOF {
+		// column content is complete
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// MarshalJSON encodes o as JSON conform json.Marshaler. The members are named
+// after the schema fields and zero values are omitted. Timestamps are RFC 3339
+// strings in UTC and binaries are base64 strings. NaN and infinite floating
+// points are JSON strings too.
+func (o *O) MarshalJSON() ([]byte, error) {
+	return o.MarshalJSONWith(colferOptions())
+}
+
+// MarshalJSONWith is like MarshalJSON, yet with the opts.JSONQuote64 mode,
+// including for nested data structures.
+func (o *O) MarshalJSONWith(opts ColferOptions) ([]byte, error) {
+	buf := []byte{'{'}
+
+	if o.B {
+		buf = append(buf, "\"b\":true,"...)
+	}
+
+	if x := o.U32; x != 0 {
+		buf = append(buf, "\"u32\":"...)
+		buf = strconv.AppendUint(buf, uint64(x), 10)
+		buf = append(buf, ',')
+	}
+
+	if x := o.U64; x != 0 {
+		buf = append(buf, "\"u64\":"...)
+		if opts.JSONQuote64 {
+			buf = append(buf, '"')
+		}
+		buf = strconv.AppendUint(buf, x, 10)
+		if opts.JSONQuote64 {
+			buf = append(buf, '"')
+		}
+		buf = append(buf, ',')
+	}
+
+	if x := o.I32; x != 0 {
+		buf = append(buf, "\"i32\":"...)
+		buf = strconv.AppendInt(buf, int64(x), 10)
+		buf = append(buf, ',')
+	}
+
+	if x := o.I64; x != 0 {
+		buf = append(buf, "\"i64\":"...)
+		if opts.JSONQuote64 {
+			buf = append(buf, '"')
+		}
+		buf = strconv.AppendInt(buf, x, 10)
+		if opts.JSONQuote64 {
+			buf = append(buf, '"')
+		}
+		buf = append(buf, ',')
+	}
+
+	if v := o.F32; v != 0 {
+		buf = append(buf, "\"f32\":"...)
+		buf = appendColferJSONFloat(buf, float64(v), 32)
+		buf = append(buf, ',')
+	}
+
+	if v := o.F64; v != 0 {
+		buf = append(buf, "\"f64\":"...)
+		buf = appendColferJSONFloat(buf, float64(v), 64)
+		buf = append(buf, ',')
+	}
+
+	if v := o.T.UTC(); !v.IsZero() {
+		if y := v.Year(); y < 0 || y > 9999 {
+			return nil, fmt.Errorf("colfer: field gen.o.t year %d outside of RFC 3339 range", y)
+		}
+		buf = append(buf, "\"t\":\""...)
+		buf = v.AppendFormat(buf, time.RFC3339Nano)
+		buf = append(buf, '"', ',')
+	}
+
+	if len(o.S) != 0 {
+		// infallible for strings and byte slices
+		b, _ := json.Marshal(o.S)
+		buf = append(buf, "\"s\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+
+	if len(o.A) != 0 {
+		// infallible for strings and byte slices
+		b, _ := json.Marshal(o.A)
+		buf = append(buf, "\"a\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+
+	if v := o.O; v != nil {
+		b, err := v.MarshalJSONWith(opts)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, "\"o\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+
+	if len(o.Os) != 0 {
+		buf = append(buf, "\"os\":["...)
+		for _, v := range o.Os {
+			if v == nil {
+				v = new(O)
+			}
+			b, err := v.MarshalJSONWith(opts)
+			if err != nil {
+				return nil, err
+			}
+			buf = append(buf, b...)
+			buf = append(buf, ',')
+		}
+		buf[len(buf)-1] = ']'
+		buf = append(buf, ',')
+	}
+
+	if len(o.Ss) != 0 {
+		// infallible for strings and byte slices
+		b, _ := json.Marshal(o.Ss)
+		buf = append(buf, "\"ss\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+
+	if len(o.As) != 0 {
+		// infallible for strings and byte slices
+		b, _ := json.Marshal(o.As)
+		buf = append(buf, "\"as\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+
+	if x := o.U8; x != 0 {
+		buf = append(buf, "\"u8\":"...)
+		buf = strconv.AppendUint(buf, uint64(x), 10)
+		buf = append(buf, ',')
+	}
+
+	if x := o.U16; x != 0 {
+		buf = append(buf, "\"u16\":"...)
+		buf = strconv.AppendUint(buf, uint64(x), 10)
+		buf = append(buf, ',')
+	}
+
+	if len(o.F32s) != 0 {
+		buf = append(buf, "\"f32s\":["...)
+		for _, v := range o.F32s {
+			buf = appendColferJSONFloat(buf, float64(v), 32)
+			buf = append(buf, ',')
+		}
+		buf[len(buf)-1] = ']'
+		buf = append(buf, ',')
+	}
+
+	if len(o.F64s) != 0 {
+		buf = append(buf, "\"f64s\":["...)
+		for _, v := range o.F64s {
+			buf = appendColferJSONFloat(buf, float64(v), 64)
+			buf = append(buf, ',')
+		}
+		buf[len(buf)-1] = ']'
+		buf = append(buf, ',')
+	}
+
+	if len(buf) == 1 {
+		return append(buf, '}'), nil
+	}
+	buf[len(buf)-1] = '}'
+	return buf, nil
+}
+
+// UnmarshalJSON decodes data as JSON conform json.Unmarshaler, with the same
+// mapping as MarshalJSON. Integers may also be JSON strings with a decimal
+// value, and JSON null equals the zero value. Unknown members are rejected.
+// The error return option is gen.ColferMax, next to the JSON errors.
+func (o *O) UnmarshalJSON(data []byte) error {
+	return o.UnmarshalJSONWith(data, colferOptions())
+}
+
+// UnmarshalJSONWith is like UnmarshalJSON, yet with the list limit of opts
+// instead of the package-level configuration attribute, including for nested
+// data structures.
+func (o *O) UnmarshalJSONWith(data []byte, opts ColferOptions) error {
+	opts = opts.limits()
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	for name, raw := range members {
+		switch name {
+		case "b":
+			// JSON null has no effect on the decode
+			o.B = false
+			if err := json.Unmarshal(raw, &o.B); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.b: %s", err)
+			}
+		case "u32":
+			x, err := strconv.ParseUint(colferJSONNumber(raw), 10, 32)
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.u32: %s", err)
+			}
+			o.U32 = uint32(x)
+		case "u64":
+			x, err := strconv.ParseUint(colferJSONNumber(raw), 10, 64)
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.u64: %s", err)
+			}
+			o.U64 = uint64(x)
+		case "i32":
+			x, err := strconv.ParseInt(colferJSONNumber(raw), 10, 32)
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.i32: %s", err)
+			}
+			o.I32 = int32(x)
+		case "i64":
+			x, err := strconv.ParseInt(colferJSONNumber(raw), 10, 64)
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.i64: %s", err)
+			}
+			o.I64 = int64(x)
+		case "f32":
+			f, err := parseColferJSONFloat(raw, 32)
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.f32: %s", err)
+			}
+			o.F32 = float32(f)
+		case "f64":
+			f, err := parseColferJSONFloat(raw, 64)
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.f64: %s", err)
+			}
+			o.F64 = float64(f)
+		case "t":
+			var t time.Time
+			if err := json.Unmarshal(raw, &t); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.t: %s", err)
+			}
+			o.T = t.In(time.UTC)
+		case "s":
+			// JSON null has no effect on the decode
+			o.S = ""
+			if err := json.Unmarshal(raw, &o.S); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.s: %s", err)
+			}
+		case "a":
+			if err := json.Unmarshal(raw, &o.A); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.a: %s", err)
+			}
+		case "o":
+			if string(raw) == "null" {
+				o.O = nil
+				break
+			}
+			v := new(O)
+			if err := v.UnmarshalJSONWith(raw, opts); err != nil {
+				return err
+			}
+			o.O = v
+		case "os":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.os: %s", err)
+			}
+			if len(a) > opts.ListMax {
+				return ColferMax(fmt.Sprintf("colfer: field gen.o.os length %d exceeds %d elements", len(a), opts.ListMax))
+			}
+			o.Os = nil
+			for _, e := range a {
+				var v *O
+				if string(e) != "null" {
+					v = new(O)
+					if err := v.UnmarshalJSONWith(e, opts); err != nil {
+						return err
+					}
+				}
+				o.Os = append(o.Os, v)
+			}
+		case "ss":
+			if err := json.Unmarshal(raw, &o.Ss); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.ss: %s", err)
+			}
+			if len(o.Ss) > opts.ListMax {
+				return ColferMax(fmt.Sprintf("colfer: field gen.o.ss length %d exceeds %d elements", len(o.Ss), opts.ListMax))
+			}
+		case "as":
+			if err := json.Unmarshal(raw, &o.As); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.as: %s", err)
+			}
+			if len(o.As) > opts.ListMax {
+				return ColferMax(fmt.Sprintf("colfer: field gen.o.as length %d exceeds %d elements", len(o.As), opts.ListMax))
+			}
+		case "u8":
+			x, err := strconv.ParseUint(colferJSONNumber(raw), 10, 8)
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.u8: %s", err)
+			}
+			o.U8 = uint8(x)
+		case "u16":
+			x, err := strconv.ParseUint(colferJSONNumber(raw), 10, 16)
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.u16: %s", err)
+			}
+			o.U16 = uint16(x)
+		case "f32s":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.f32s: %s", err)
+			}
+			if len(a) > opts.ListMax {
+				return ColferMax(fmt.Sprintf("colfer: field gen.o.f32s length %d exceeds %d elements", len(a), opts.ListMax))
+			}
+			o.F32s = nil
+			for i, e := range a {
+				f, err := parseColferJSONFloat(e, 32)
+				if err != nil {
+					return fmt.Errorf("colfer: JSON for field gen.o.f32s index %d: %s", i, err)
+				}
+				o.F32s = append(o.F32s, float32(f))
+			}
+		case "f64s":
+			var a []json.RawMessage
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.o.f64s: %s", err)
+			}
+			if len(a) > opts.ListMax {
+				return ColferMax(fmt.Sprintf("colfer: field gen.o.f64s length %d exceeds %d elements", len(a), opts.ListMax))
+			}
+			o.F64s = nil
+			for i, e := range a {
+				f, err := parseColferJSONFloat(e, 64)
+				if err != nil {
+					return fmt.Errorf("colfer: JSON for field gen.o.f64s index %d: %s", i, err)
+				}
+				o.F64s = append(o.F64s, float64(f))
+			}
+		default:
+			return fmt.Errorf("colfer: JSON member %q not in struct gen.o", name)
+		}
+	}
+	return nil
+}
+
+// Equal returns whether o and other have the same Colfer serial. Nil and empty
+// lists are equal, nil list entries equal the zero value, timestamps compare
+// as instants, and floating points compare by their bits, except for zero.
+func (o *O) Equal(other *O) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+
+	if o.B != other.B {
+		return false
+	}
+
+	if o.U32 != other.U32 {
+		return false
+	}
+
+	if o.U64 != other.U64 {
+		return false
+	}
+
+	if o.I32 != other.I32 {
+		return false
+	}
+
+	if o.I64 != other.I64 {
+		return false
+	}
+
+	if a, b := o.F32, other.F32; (a != 0 || b != 0) && math.Float32bits(a) != math.Float32bits(b) {
+		return false
+	}
+
+	if a, b := o.F64, other.F64; (a != 0 || b != 0) && math.Float64bits(a) != math.Float64bits(b) {
+		return false
+	}
+
+	if !o.T.Equal(other.T) {
+		return false
+	}
+
+	if o.S != other.S {
+		return false
+	}
+
+	if string(o.A) != string(other.A) {
+		return false
+	}
+
+	if !o.O.Equal(other.O) {
+		return false
+	}
+
+	if len(o.Os) != len(other.Os) {
+		return false
+	}
+	for i, v := range o.Os {
+		w := other.Os[i]
+		if v == nil {
+			v = new(O)
+		}
+		if w == nil {
+			w = new(O)
+		}
+		if !v.Equal(w) {
+			return false
+		}
+	}
+
+	if len(o.Ss) != len(other.Ss) {
+		return false
+	}
+	for i, v := range o.Ss {
+		if v != other.Ss[i] {
+			return false
+		}
+	}
+
+	if len(o.As) != len(other.As) {
+		return false
+	}
+	for i, v := range o.As {
+		if string(v) != string(other.As[i]) {
+			return false
+		}
+	}
+
+	if o.U8 != other.U8 {
+		return false
+	}
+
+	if o.U16 != other.U16 {
+		return false
+	}
+
+	if len(o.F32s) != len(other.F32s) {
+		return false
+	}
+	for i, v := range o.F32s {
+		if math.Float32bits(v) != math.Float32bits(other.F32s[i]) {
+			return false
+		}
+	}
+
+	if len(o.F64s) != len(other.F64s) {
+		return false
+	}
+	for i, v := range o.F64s {
+		if math.Float64bits(v) != math.Float64bits(other.F64s[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Diff returns the fields which differ between o and other in schema order,
+// with o as the old values. Data structures compare field by field and lists
+// compare element by element, conform Equal. A nil o or other compares as the
+// zero value.
+func (o *O) Diff(other *O) []ColferDiff {
+	if o == nil {
+		o = new(O)
+	}
+	if other == nil {
+		other = new(O)
+	}
+	var diffs []ColferDiff
+
+	if a, b := o.B, other.B; a != b {
+		diffs = append(diffs, ColferDiff{Path: "b", Old: a, New: b})
+	}
+
+	if a, b := o.U32, other.U32; a != b {
+		diffs = append(diffs, ColferDiff{Path: "u32", Old: a, New: b})
+	}
+
+	if a, b := o.U64, other.U64; a != b {
+		diffs = append(diffs, ColferDiff{Path: "u64", Old: a, New: b})
+	}
+
+	if a, b := o.I32, other.I32; a != b {
+		diffs = append(diffs, ColferDiff{Path: "i32", Old: a, New: b})
+	}
+
+	if a, b := o.I64, other.I64; a != b {
+		diffs = append(diffs, ColferDiff{Path: "i64", Old: a, New: b})
+	}
+
+	if a, b := o.F32, other.F32; (a != 0 || b != 0) && math.Float32bits(a) != math.Float32bits(b) {
+		diffs = append(diffs, ColferDiff{Path: "f32", Old: a, New: b})
+	}
+
+	if a, b := o.F64, other.F64; (a != 0 || b != 0) && math.Float64bits(a) != math.Float64bits(b) {
+		diffs = append(diffs, ColferDiff{Path: "f64", Old: a, New: b})
+	}
+
+	if a, b := o.T, other.T; !a.Equal(b) {
+		diffs = append(diffs, ColferDiff{Path: "t", Old: a, New: b})
+	}
+
+	if a, b := o.S, other.S; a != b {
+		diffs = append(diffs, ColferDiff{Path: "s", Old: a, New: b})
+	}
+
+	if a, b := o.A, other.A; string(a) != string(b) {
+		diffs = append(diffs, ColferDiff{Path: "a", Old: a, New: b})
+	}
+
+	if v, w := o.O, other.O; v != nil && w != nil {
+		for _, d := range v.Diff(w) {
+			d.Path = "o." + d.Path
+			diffs = append(diffs, d)
+		}
+	} else if v != nil {
+		diffs = append(diffs, ColferDiff{Path: "o", Old: v})
+	} else if w != nil {
+		diffs = append(diffs, ColferDiff{Path: "o", New: w})
+	}
+
+	for i, a, b := 0, o.Os, other.Os; i < len(a) || i < len(b); i++ {
+		switch {
+		case i >= len(a):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("os[%d]", i), New: b[i]})
+		case i >= len(b):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("os[%d]", i), Old: a[i]})
+		default:
+			for _, d := range a[i].Diff(b[i]) {
+				d.Path = fmt.Sprintf("os[%d].", i) + d.Path
+				diffs = append(diffs, d)
+			}
+		}
+	}
+
+	for i, a, b := 0, o.Ss, other.Ss; i < len(a) || i < len(b); i++ {
+		switch {
+		case i >= len(a):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("ss[%d]", i), New: b[i]})
+		case i >= len(b):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("ss[%d]", i), Old: a[i]})
+		case a[i] != b[i]:
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("ss[%d]", i), Old: a[i], New: b[i]})
+		}
+	}
+
+	for i, a, b := 0, o.As, other.As; i < len(a) || i < len(b); i++ {
+		switch {
+		case i >= len(a):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("as[%d]", i), New: b[i]})
+		case i >= len(b):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("as[%d]", i), Old: a[i]})
+		case string(a[i]) != string(b[i]):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("as[%d]", i), Old: a[i], New: b[i]})
+		}
+	}
+
+	if a, b := o.U8, other.U8; a != b {
+		diffs = append(diffs, ColferDiff{Path: "u8", Old: a, New: b})
+	}
+
+	if a, b := o.U16, other.U16; a != b {
+		diffs = append(diffs, ColferDiff{Path: "u16", Old: a, New: b})
+	}
+
+	for i, a, b := 0, o.F32s, other.F32s; i < len(a) || i < len(b); i++ {
+		switch {
+		case i >= len(a):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("f32s[%d]", i), New: b[i]})
+		case i >= len(b):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("f32s[%d]", i), Old: a[i]})
+		case math.Float32bits(a[i]) != math.Float32bits(b[i]):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("f32s[%d]", i), Old: a[i], New: b[i]})
+		}
+	}
+
+	for i, a, b := 0, o.F64s, other.F64s; i < len(a) || i < len(b); i++ {
+		switch {
+		case i >= len(a):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("f64s[%d]", i), New: b[i]})
+		case i >= len(b):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("f64s[%d]", i), Old: a[i]})
+		case math.Float64bits(a[i]) != math.Float64bits(b[i]):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("f64s[%d]", i), Old: a[i], New: b[i]})
+		}
+	}
+
+	return diffs
+}
+
+// Clone returns a deep copy of o, or nil when o is nil.
+func (o *O) Clone() *O {
+	if o == nil {
+		return nil
+	}
+	c := *o
+
+	if o.A != nil {
+		c.A = append(make([]byte, 0, len(o.A)), o.A...)
+	}
+
+	c.O = o.O.Clone()
+
+	if o.Os != nil {
+		c.Os = make([]*O, len(o.Os))
+		for i, v := range o.Os {
+			c.Os[i] = v.Clone()
+		}
+	}
+
+	if o.Ss != nil {
+		c.Ss = make([]string, len(o.Ss))
+		copy(c.Ss, o.Ss)
+	}
+
+	if o.As != nil {
+		c.As = make([][]byte, len(o.As))
+		for i, v := range o.As {
+			if v != nil {
+				c.As[i] = append(make([]byte, 0, len(v)), v...)
+			}
+		}
+	}
+
+	if o.F32s != nil {
+		c.F32s = make([]float32, len(o.F32s))
+		copy(c.F32s, o.F32s)
+	}
+
+	if o.F64s != nil {
+		c.F64s = make([]float64, len(o.F64s))
+		copy(c.F64s, o.F64s)
+	}
+
+	return &c
+}
+
+// Merge copies the non-zero fields of other into o. Nested data structures
+// merge recursively, and lists are replaced with a copy.
+func (o *O) Merge(other *O) {
+	if other == nil {
+		return
+	}
+
+	if other.B {
+		o.B = true
+	}
+
+	if other.U32 != 0 {
+		o.U32 = other.U32
+	}
+
+	if other.U64 != 0 {
+		o.U64 = other.U64
+	}
+
+	if other.I32 != 0 {
+		o.I32 = other.I32
+	}
+
+	if other.I64 != 0 {
+		o.I64 = other.I64
+	}
+
+	if other.F32 != 0 {
+		o.F32 = other.F32
+	}
+
+	if other.F64 != 0 {
+		o.F64 = other.F64
+	}
+
+	if !other.T.IsZero() {
+		o.T = other.T
+	}
+
+	if other.S != "" {
+		o.S = other.S
+	}
+
+	if len(other.A) != 0 {
+		o.A = append(make([]byte, 0, len(other.A)), other.A...)
+	}
+
+	if v := other.O; v != nil {
+		if o.O == nil {
+			o.O = v.Clone()
+		} else {
+			o.O.Merge(v)
+		}
+	}
+
+	if len(other.Os) != 0 {
+		o.Os = make([]*O, len(other.Os))
+		for i, v := range other.Os {
+			o.Os[i] = v.Clone()
+		}
+	}
+
+	if len(other.Ss) != 0 {
+		o.Ss = make([]string, len(other.Ss))
+		copy(o.Ss, other.Ss)
+	}
+
+	if len(other.As) != 0 {
+		o.As = make([][]byte, len(other.As))
+		for i, v := range other.As {
+			if v != nil {
+				o.As[i] = append(make([]byte, 0, len(v)), v...)
+			}
+		}
+	}
+
+	if other.U8 != 0 {
+		o.U8 = other.U8
+	}
+
+	if other.U16 != 0 {
+		o.U16 = other.U16
+	}
+
+	if len(other.F32s) != 0 {
+		o.F32s = make([]float32, len(other.F32s))
+		copy(o.F32s, other.F32s)
+	}
+
+	if len(other.F64s) != 0 {
+		o.F64s = make([]float64, len(other.F64s))
+		copy(o.F64s, other.F64s)
+	}
+}
+
+// String returns a human-readable form for debugging, with the sensitive
+// fields masked.
+func (o *O) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("gen.O{B:%v U32:%v U64:%v I32:%v I64:%v F32:%v F64:%v T:%v S:%q A:%q O:%v Os:%v Ss:%q As:%q U8:%v U16:%v F32s:%v F64s:%v}", o.B, o.U32, o.U64, o.I32, o.I64, o.F32, o.F64, o.T, o.S, o.A, o.O, o.Os, o.Ss, o.As, o.U8, o.U16, o.F32s, o.F64s)
+}
+
+// GoString returns the Go syntax for debugging, with the sensitive fields
+// masked.
+func (o *O) GoString() string {
+	if o == nil {
+		return "(*gen.O)(nil)"
+	}
+	return fmt.Sprintf("&gen.O{B:%#v, U32:%#v, U64:%#v, I32:%#v, I64:%#v, F32:%#v, F64:%#v, T:%#v, S:%#v, A:%#v, O:%#v, Os:%#v, Ss:%#v, As:%#v, U8:%#v, U16:%#v, F32s:%#v, F64s:%#v}", o.B, o.U32, o.U64, o.I32, o.I64, o.F32, o.F64, o.T, o.S, o.A, o.O, o.Os, o.Ss, o.As, o.U8, o.U16, o.F32s, o.F64s)
+}
+
+// Validate checks the fields against the schema rules, including the ones of
+// nested data structures. A nil o validates as the zero value.
+// The error return option is gen.ColferInvalid.
+func (o *O) Validate() error {
+	if o == nil {
+		o = new(O)
+	}
+	if o.O != nil {
+		if err := o.O.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, v := range o.Os {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ColferType returns the qualified schema name conform ColferAny.
+func (*O) ColferType() string { return "gen.o" }
+
+func (*O) colferNew(opts ColferOptions, field string) (ColferAny, error) {
+	if err := opts.charge(field, int(unsafe.Sizeof(O{}))); err != nil {
+		return nil, err
+	}
+	return new(O), nil
+}
+
+func (o *O) colferEqual(other ColferAny) bool {
+	p, ok := other.(*O)
+	return ok && o.Equal(p)
+}
+
+func (o *O) colferClone() ColferAny { return o.Clone() }
+
+func (o *O) colferMerge(other ColferAny) ColferAny {
+	p, ok := other.(*O)
+	if !ok {
+		return other.colferClone()
+	}
+	o.Merge(p)
+	return o
+}
+
+func (o *O) colferDiff(other ColferAny) []ColferDiff {
+	p, _ := other.(*O)
+	return o.Diff(p)
+}
+
+// E contains an embedded Colfer serial.
+type E struct {
+	// M tests embedded serials.
+	M []byte `json:"m,omitempty"`
+}
+
+var _ rt.Message = (*E)(nil)
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic.
+func (o *E) MarshalTo(buf []byte) int {
+	var i int
+
+	if l := len(o.M); l != 0 {
+		buf[i] = 0
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.M)
+	}
+
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is gen.ColferMax.
+func (o *E) MarshalLen() (int, error) {
+	return o.MarshalLenWith(colferOptions())
+}
+
+// MarshalLenWith is like MarshalLen, yet with the limits of opts instead of the
+// package-level configuration attributes.
+// The error return option is gen.ColferMax.
+func (o *E) MarshalLenWith(opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	l := 1
+
+	if x := len(o.M); x != 0 {
+		if x > opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.e.m exceeds %d bytes", opts.SizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if l > opts.SizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct gen.e exceeds %d bytes", opts.SizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// The error return option is gen.ColferMax.
+func (o *E) MarshalBinary() (data []byte, err error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return nil, err
+	}
+	data = make([]byte, l)
+	o.MarshalTo(data)
+	return data, nil
+}
+
+// AppendColfer appends the Colfer serial of o to dst and returns the extended
+// buffer. Unlike MarshalBinary, the object is walked only once.
+// When an error occurs, dst is returned with its original length.
+// The error return option is gen.ColferMax.
+func (o *E) AppendColfer(dst []byte) ([]byte, error) {
+	return o.AppendColferWith(dst, colferOptions())
+}
+
+// AppendColferWith is like AppendColfer, yet with the limits of opts instead
+// of the package-level configuration attributes.
+// The error return option is gen.ColferMax.
+func (o *E) AppendColferWith(dst []byte, opts ColferOptions) ([]byte, error) {
+	opts = opts.limits()
+	buf := dst
+
+	if l := len(o.M); l != 0 {
+		if l > opts.SizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.e.m exceeds %d bytes", opts.SizeMax))
+		}
+		buf = append(buf, 0)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.M...)
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-len(dst) > opts.SizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct gen.e exceeds %d bytes", opts.SizeMax))
+	}
+	return buf, nil
+}
+
+// ColferHash writes the Colfer serial of o to h, without materializing the
+// serial as a whole. Equal values produce the same input for h in each of the
+// supported languages.
+// The error return option is gen.ColferMax.
+func (o *E) ColferHash(h hash.Hash) error {
+	_, err := o.ColferHashWith(h, colferOptions())
+	return err
+}
+
+// ColferHashWith is like ColferHash, yet with the limits of opts instead of the
+// package-level configuration attributes. The return is the number of bytes
+// written to h. When an error occurs, then the state of h is undefined.
+// The error return option is gen.ColferMax.
+func (o *E) ColferHashWith(h hash.Hash, opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	var scratch [32]byte
+	buf := scratch[:0]
+	var n int
+
+	if l := len(o.M); l != 0 {
+		if l > opts.SizeMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field gen.e.m exceeds %d bytes", opts.SizeMax))
+		}
+		buf = append(buf, 0)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		h.Write(buf)
+		n += len(buf) + l
+		buf = buf[:0]
+		h.Write(o.M)
+	}
+
+	buf = append(buf, 0x7f)
+	h.Write(buf)
+	n += len(buf)
+	if n > opts.SizeMax {
+		return n, ColferMax(fmt.Sprintf("colfer: struct gen.e exceeds %d bytes", opts.SizeMax))
+	}
+	return n, nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, gen.ColferError and gen.ColferMax.
+func (o *E) Unmarshal(data []byte) (int, error) {
+	return o.UnmarshalWith(data, colferOptions())
+}
+
+// UnmarshalNoCopy is like Unmarshal, yet the text and binary fields, including
+// those of nested data structures, share memory with data instead of holding a
+// copy. Any modification to data is visible through o, and vice versa, for as
+// long as o is in use. The caller must not reuse or recycle data (buffers)
+// before o and all of the values read from it are no longer referenced.
+// The error return options are io.EOF, gen.ColferError and gen.ColferMax.
+func (o *E) UnmarshalNoCopy(data []byte) (int, error) {
+	opts := colferOptions()
+	opts.NoCopy = true
+	return o.UnmarshalWith(data, opts)
+}
+
+// UnmarshalWith is like Unmarshal, yet with the limits and the field selection
+// of opts instead of the package-level configuration attributes.
+// The error return options are io.EOF, gen.ColferError, gen.ColferMax,
+// with opts.Strict, gen.ColferNonCanonical and, with opts.Validate,
+// gen.ColferInvalid.
+func (o *E) UnmarshalWith(data []byte, opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 && !opts.selects("m") {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.e.m size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+		i += int(x)
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 0 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.e.m size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if x != 0 && data[i-1] != 0x7f {
+			return 0, ColferError(i - 1)
+		}
+		if opts.NoCopy {
+			o.M = data[start:i:i]
+		} else {
+			if err := opts.charge("gen.e.m", int(x)); err != nil {
+				return 0, err
+			}
+			v := make([]byte, int(x))
+			copy(v, data[start:i])
+			o.M = v
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < opts.SizeMax {
+		return i, nil
+	}
+eof:
+	if i >= opts.SizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct gen.e size exceeds %d bytes", opts.SizeMax))
+	}
+	return 0, io.EOF
+}
+
+// HasColferPath returns whether path locates a field in the schema, with the
+// names separated by dots, e.g., "o.s". See ColferOptions.Fields.
+func (*E) HasColferPath(path string) bool {
+	name, nested := path, false
+	for i := 0; i < len(path); i++ {
+		if path[i] == '.' {
+			name, nested = path[:i], true
+			break
+		}
+	}
+
+	switch name {
+	case "m":
+		return !nested
+	}
+	return false
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, gen.ColferError, gen.ColferTail and gen.ColferMax.
+func (o *E) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// Value returns the serial data conform database/sql/driver.Valuer, with nil
+// (NULL) for a nil o. The error return option is gen.ColferMax.
+func (o *E) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+	return o.MarshalBinary()
+}
+
+// Scan decodes the serial data of a []byte or string conform database/sql.Scanner.
+// Any previous content of o is discarded, including on NULL, which reads as the
+// zero value. Scan into a **E to get nil for NULL instead. The error
+// return options are io.ErrUnexpectedEOF, gen.ColferError,
+// gen.ColferTail and gen.ColferMax.
+func (o *E) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*o = E{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("colfer: struct gen.e scan from SQL type %T, want []byte or string", src)
+	}
+
+	// no carry-over from previous rows
+	*o = E{}
+	err := o.UnmarshalBinary(data)
+	if err == io.EOF {
+		// column content is complete
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// MarshalJSON encodes o as JSON conform json.Marshaler. The members are named
+// after the schema fields and zero values are omitted. Timestamps are RFC 3339
+// strings in UTC and binaries are base64 strings. NaN and infinite floating
+// points are JSON strings too.
+func (o *E) MarshalJSON() ([]byte, error) {
+	return o.MarshalJSONWith(colferOptions())
+}
+
+// MarshalJSONWith is like MarshalJSON, yet with the opts.JSONQuote64 mode,
+// including for nested data structures.
+func (o *E) MarshalJSONWith(opts ColferOptions) ([]byte, error) {
+	buf := []byte{'{'}
+
+	if len(o.M) != 0 {
+		// infallible for strings and byte slices
+		b, _ := json.Marshal(o.M)
+		buf = append(buf, "\"m\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+
+	if len(buf) == 1 {
+		return append(buf, '}'), nil
+	}
+	buf[len(buf)-1] = '}'
+	return buf, nil
+}
+
+// UnmarshalJSON decodes data as JSON conform json.Unmarshaler, with the same
+// mapping as MarshalJSON. Integers may also be JSON strings with a decimal
+// value, and JSON null equals the zero value. Unknown members are rejected.
+// The error return option is gen.ColferMax, next to the JSON errors.
+func (o *E) UnmarshalJSON(data []byte) error {
+	return o.UnmarshalJSONWith(data, colferOptions())
+}
+
+// UnmarshalJSONWith is like UnmarshalJSON, yet with the list limit of opts
+// instead of the package-level configuration attribute, including for nested
+// data structures.
+func (o *E) UnmarshalJSONWith(data []byte, opts ColferOptions) error {
+	opts = opts.limits()
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	for name, raw := range members {
+		switch name {
+		case "m":
+			if err := json.Unmarshal(raw, &o.M); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.e.m: %s", err)
+			}
+		default:
+			return fmt.Errorf("colfer: JSON member %q not in struct gen.e", name)
+		}
+	}
+	return nil
+}
+
+// Equal returns whether o and other have the same Colfer serial. Nil and empty
+// lists are equal, nil list entries equal the zero value, timestamps compare
+// as instants, and floating points compare by their bits, except for zero.
+func (o *E) Equal(other *E) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+
+	if string(o.M) != string(other.M) {
+		return false
+	}
+
+	return true
+}
+
+// Diff returns the fields which differ between o and other in schema order,
+// with o as the old values. Data structures compare field by field and lists
+// compare element by element, conform Equal. A nil o or other compares as the
+// zero value.
+func (o *E) Diff(other *E) []ColferDiff {
+	if o == nil {
+		o = new(E)
+	}
+	if other == nil {
+		other = new(E)
+	}
+	var diffs []ColferDiff
+
+	if a, b := o.M, other.M; string(a) != string(b) {
+		diffs = append(diffs, ColferDiff{Path: "m", Old: a, New: b})
+	}
+
+	return diffs
+}
+
+// Clone returns a deep copy of o, or nil when o is nil.
+func (o *E) Clone() *E {
+	if o == nil {
+		return nil
+	}
+	c := *o
+
+	if o.M != nil {
+		c.M = append(make([]byte, 0, len(o.M)), o.M...)
+	}
+
+	return &c
+}
+
+// Merge copies the non-zero fields of other into o. Nested data structures
+// merge recursively, and lists are replaced with a copy.
+func (o *E) Merge(other *E) {
+	if other == nil {
+		return
+	}
+
+	if len(other.M) != 0 {
+		o.M = append(make([]byte, 0, len(other.M)), other.M...)
+	}
+}
+
+// String returns a human-readable form for debugging, with the sensitive
+// fields masked.
+func (o *E) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("gen.E{M:%q}", o.M)
+}
+
+// GoString returns the Go syntax for debugging, with the sensitive fields
+// masked.
+func (o *E) GoString() string {
+	if o == nil {
+		return "(*gen.E)(nil)"
+	}
+	return fmt.Sprintf("&gen.E{M:%#v}", o.M)
+}
+
+// Validate checks the fields against the schema rules, including the ones of
+// nested data structures. A nil o validates as the zero value.
+// The error return option is gen.ColferInvalid.
+func (o *E) Validate() error {
+	if o == nil {
+		o = new(E)
+	}
+	return nil
+}
+
+// ColferType returns the qualified schema name conform ColferAny.
+func (*E) ColferType() string { return "gen.e" }
+
+func (*E) colferNew(opts ColferOptions, field string) (ColferAny, error) {
+	if err := opts.charge(field, int(unsafe.Sizeof(E{}))); err != nil {
+		return nil, err
+	}
+	return new(E), nil
+}
+
+func (o *E) colferEqual(other ColferAny) bool {
+	p, ok := other.(*E)
+	return ok && o.Equal(p)
+}
+
+func (o *E) colferClone() ColferAny { return o.Clone() }
+
+func (o *E) colferMerge(other ColferAny) ColferAny {
+	p, ok := other.(*E)
+	if !ok {
+		return other.colferClone()
+	}
+	o.Merge(p)
+	return o
+}
+
+func (o *E) colferDiff(other ColferAny) []ColferDiff {
+	p, _ := other.(*E)
+	return o.Diff(p)
+}
+
+// W wraps any data structure.
+type W struct {
+	// V tests any data structures.
+	V ColferAny `json:"v,omitempty"`
+}
+
+var _ rt.Message = (*W)(nil)
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic.
+func (o *W) MarshalTo(buf []byte) int {
+	var i int
+
+	if v := o.V; v != nil {
+		buf[i] = 0
+		i++
+		name := v.ColferType()
+		x := uint(len(name))
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], name)
+		i += v.MarshalTo(buf[i:])
+	}
+
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is gen.ColferMax.
+func (o *W) MarshalLen() (int, error) {
+	return o.MarshalLenWith(colferOptions())
+}
+
+// MarshalLenWith is like MarshalLen, yet with the limits of opts instead of the
+// package-level configuration attributes.
+// The error return option is gen.ColferMax.
+func (o *W) MarshalLenWith(opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	l := 1
+
+	if v := o.V; v != nil {
+		sub, err := opts.nested("gen.w.v")
+		if err != nil {
+			return 0, err
+		}
+		vl, err := v.MarshalLenWith(sub)
+		if err != nil {
+			return 0, err
+		}
+		x := len(v.ColferType())
+		for l += vl + x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if l > opts.SizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct gen.w exceeds %d bytes", opts.SizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// The error return option is gen.ColferMax.
+func (o *W) MarshalBinary() (data []byte, err error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return nil, err
+	}
+	data = make([]byte, l)
+	o.MarshalTo(data)
+	return data, nil
+}
+
+// AppendColfer appends the Colfer serial of o to dst and returns the extended
+// buffer. Unlike MarshalBinary, the object is walked only once.
+// When an error occurs, dst is returned with its original length.
+// The error return option is gen.ColferMax.
+func (o *W) AppendColfer(dst []byte) ([]byte, error) {
+	return o.AppendColferWith(dst, colferOptions())
+}
+
+// AppendColferWith is like AppendColfer, yet with the limits of opts instead
+// of the package-level configuration attributes.
+// The error return option is gen.ColferMax.
+func (o *W) AppendColferWith(dst []byte, opts ColferOptions) ([]byte, error) {
+	opts = opts.limits()
+	buf := dst
+
+	if v := o.V; v != nil {
+		sub, err := opts.nested("gen.w.v")
+		if err != nil {
+			return dst, err
+		}
+		name := v.ColferType()
+		buf = append(buf, 0)
+		x := uint(len(name))
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf, err = v.AppendColferWith(append(buf, name...), sub)
+		if err != nil {
+			return dst, err
+		}
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-len(dst) > opts.SizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct gen.w exceeds %d bytes", opts.SizeMax))
+	}
+	return buf, nil
+}
+
+// ColferHash writes the Colfer serial of o to h, without materializing the
+// serial as a whole. Equal values produce the same input for h in each of the
+// supported languages.
+// The error return option is gen.ColferMax.
+func (o *W) ColferHash(h hash.Hash) error {
+	_, err := o.ColferHashWith(h, colferOptions())
+	return err
+}
+
+// ColferHashWith is like ColferHash, yet with the limits of opts instead of the
+// package-level configuration attributes. The return is the number of bytes
+// written to h. When an error occurs, then the state of h is undefined.
+// The error return option is gen.ColferMax.
+func (o *W) ColferHashWith(h hash.Hash, opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	var scratch [32]byte
+	buf := scratch[:0]
+	var n int
+
+	if v := o.V; v != nil {
+		sub, err := opts.nested("gen.w.v")
+		if err != nil {
+			return n, err
+		}
+		name := v.ColferType()
+		buf = append(buf, 0)
+		x := uint(len(name))
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, name...)
+		h.Write(buf)
+		n += len(buf)
+		buf = buf[:0]
+		vn, err := v.ColferHashWith(h, sub)
+		n += vn
+		if err != nil {
+			return n, err
+		}
+	}
+
+	buf = append(buf, 0x7f)
+	h.Write(buf)
+	n += len(buf)
+	if n > opts.SizeMax {
+		return n, ColferMax(fmt.Sprintf("colfer: struct gen.w exceeds %d bytes", opts.SizeMax))
+	}
+	return n, nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, gen.ColferError and gen.ColferMax.
+func (o *W) Unmarshal(data []byte) (int, error) {
+	return o.UnmarshalWith(data, colferOptions())
+}
+
+// UnmarshalNoCopy is like Unmarshal, yet the text and binary fields, including
+// those of nested data structures, share memory with data instead of holding a
+// copy. Any modification to data is visible through o, and vice versa, for as
+// long as o is in use. The caller must not reuse or recycle data (buffers)
+// before o and all of the values read from it are no longer referenced.
+// The error return options are io.EOF, gen.ColferError and gen.ColferMax.
+func (o *W) UnmarshalNoCopy(data []byte) (int, error) {
+	opts := colferOptions()
+	opts.NoCopy = true
+	return o.UnmarshalWith(data, opts)
+}
+
+// UnmarshalWith is like Unmarshal, yet with the limits and the field selection
+// of opts instead of the package-level configuration attributes.
+// The error return options are io.EOF, gen.ColferError, gen.ColferMax,
+// with opts.Strict, gen.ColferNonCanonical and, with opts.Validate,
+// gen.ColferInvalid.
+func (o *W) UnmarshalWith(data []byte, opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 && !opts.selects("v") {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.w.v type size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		t := colferAnyType(string(data[start:i]))
+		if t == nil {
+			return 0, ColferError(start)
+		}
+		sub, err := opts.nested("gen.w.v")
+		if err != nil {
+			return 0, err
+		}
+		// none selected, so the receiver is never read nor written
+		sub.Fields, sub.Validate = opts.Fields[:0], false
+		n, err := t.UnmarshalWith(data[i:], sub)
+		if err != nil {
+			if err == io.EOF && len(data) >= opts.SizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: gen.w size exceeds %d bytes", opts.SizeMax))
+			}
+			if e, ok := err.(ColferNonCanonical); ok {
+				return 0, ColferNonCanonical(i + int(e))
+			}
+			return 0, err
+		}
+		i += n
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 0 {
+		sub, err := opts.nested("gen.w.v")
+		if err != nil {
+			return 0, err
+		}
+		sub.Fields = opts.within("v")
+
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.w.v type size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		t := colferAnyType(string(data[start:i]))
+		if t == nil {
+			return 0, ColferError(start)
+		}
+		v, err := t.colferNew(opts, "gen.w.v")
+		if err != nil {
+			return 0, err
+		}
+		n, err := v.UnmarshalWith(data[i:], sub)
+		if err != nil {
+			if err == io.EOF && len(data) >= opts.SizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: gen.w size exceeds %d bytes", opts.SizeMax))
+			}
+			if e, ok := err.(ColferNonCanonical); ok {
+				return 0, ColferNonCanonical(i + int(e))
+			}
+			return 0, err
+		}
+		o.V = v
+		i += n
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < opts.SizeMax {
+		return i, nil
+	}
+eof:
+	if i >= opts.SizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct gen.w size exceeds %d bytes", opts.SizeMax))
+	}
+	return 0, io.EOF
+}
+
+// HasColferPath returns whether path locates a field in the schema, with the
+// names separated by dots, e.g., "o.s". See ColferOptions.Fields.
+func (*W) HasColferPath(path string) bool {
+	name, nested := path, false
+	for i := 0; i < len(path); i++ {
+		if path[i] == '.' {
+			name, nested = path[:i], true
+			break
+		}
+	}
+
+	switch name {
+	case "v":
+		return !nested || colferAnyPath(path[len(name)+1:])
+	}
+	return false
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, gen.ColferError, gen.ColferTail and gen.ColferMax.
+func (o *W) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// Value returns the serial data conform database/sql/driver.Valuer, with nil
+// (NULL) for a nil o. The error return option is gen.ColferMax.
+func (o *W) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+	return o.MarshalBinary()
+}
+
+// Scan decodes the serial data of a []byte or string conform database/sql.Scanner.
+// Any previous content of o is discarded, including on NULL, which reads as the
+// zero value. Scan into a **W to get nil for NULL instead. The error
+// return options are io.ErrUnexpectedEOF, gen.ColferError,
+// gen.ColferTail and gen.ColferMax.
+func (o *W) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*o = W{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("colfer: struct gen.w scan from SQL type %T, want []byte or string", src)
+	}
+
+	// no carry-over from previous rows
+	*o = W{}
+	err := o.UnmarshalBinary(data)
+	if err == io.EOF {
+		// column content is complete
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// MarshalJSON encodes o as JSON conform json.Marshaler. The members are named
+// after the schema fields and zero values are omitted. Timestamps are RFC 3339
+// strings in UTC and binaries are base64 strings. NaN and infinite floating
+// points are JSON strings too.
+func (o *W) MarshalJSON() ([]byte, error) {
+	return o.MarshalJSONWith(colferOptions())
+}
+
+// MarshalJSONWith is like MarshalJSON, yet with the opts.JSONQuote64 mode,
+// including for nested data structures.
+func (o *W) MarshalJSONWith(opts ColferOptions) ([]byte, error) {
+	buf := []byte{'{'}
+
+	if v := o.V; v != nil {
+		b, err := v.MarshalJSONWith(opts)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, "\"v\":{\"type\":\""...)
+		buf = append(buf, v.ColferType()...)
+		buf = append(buf, "\",\"value\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, '}', ',')
+	}
+
+	if len(buf) == 1 {
+		return append(buf, '}'), nil
+	}
+	buf[len(buf)-1] = '}'
+	return buf, nil
+}
+
+// UnmarshalJSON decodes data as JSON conform json.Unmarshaler, with the same
+// mapping as MarshalJSON. Integers may also be JSON strings with a decimal
+// value, and JSON null equals the zero value. Unknown members are rejected.
+// The error return option is gen.ColferMax, next to the JSON errors.
+func (o *W) UnmarshalJSON(data []byte) error {
+	return o.UnmarshalJSONWith(data, colferOptions())
+}
+
+// UnmarshalJSONWith is like UnmarshalJSON, yet with the list limit of opts
+// instead of the package-level configuration attribute, including for nested
+// data structures.
+func (o *W) UnmarshalJSONWith(data []byte, opts ColferOptions) error {
+	opts = opts.limits()
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	for name, raw := range members {
+		switch name {
+		case "v":
+			if string(raw) == "null" {
+				o.V = nil
+				break
+			}
+			var wrap struct {
+				Type  string          `json:"type"`
+				Value json.RawMessage `json:"value"`
+			}
+			if err := json.Unmarshal(raw, &wrap); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.w.v: %s", err)
+			}
+			v := NewColferAny(wrap.Type)
+			if v == nil {
+				return fmt.Errorf("colfer: JSON for field gen.w.v: type %q not registered", wrap.Type)
+			}
+			if err := v.UnmarshalJSONWith(wrap.Value, opts); err != nil {
+				return err
+			}
+			o.V = v
+		default:
+			return fmt.Errorf("colfer: JSON member %q not in struct gen.w", name)
+		}
+	}
+	return nil
+}
+
+// Equal returns whether o and other have the same Colfer serial. Nil and empty
+// lists are equal, nil list entries equal the zero value, timestamps compare
+// as instants, and floating points compare by their bits, except for zero.
+func (o *W) Equal(other *W) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+
+	if v, w := o.V, other.V; v != w && (v == nil || w == nil || !v.colferEqual(w)) {
+		return false
+	}
+
+	return true
+}
+
+// Diff returns the fields which differ between o and other in schema order,
+// with o as the old values. Data structures compare field by field and lists
+// compare element by element, conform Equal. A nil o or other compares as the
+// zero value.
+func (o *W) Diff(other *W) []ColferDiff {
+	if o == nil {
+		o = new(W)
+	}
+	if other == nil {
+		other = new(W)
+	}
+	var diffs []ColferDiff
+
+	if v, w := o.V, other.V; v != nil && w != nil && v.ColferType() == w.ColferType() {
+		for _, d := range v.colferDiff(w) {
+			d.Path = "v." + d.Path
+			diffs = append(diffs, d)
+		}
+	} else if v != nil || w != nil {
+		diffs = append(diffs, ColferDiff{Path: "v", Old: v, New: w})
+	}
+
+	return diffs
+}
+
+// Clone returns a deep copy of o, or nil when o is nil.
+func (o *W) Clone() *W {
+	if o == nil {
+		return nil
+	}
+	c := *o
+
+	if v := o.V; v != nil {
+		c.V = v.colferClone()
+	}
+
+	return &c
+}
+
+// Merge copies the non-zero fields of other into o. Nested data structures
+// merge recursively, and lists are replaced with a copy.
+func (o *W) Merge(other *W) {
+	if other == nil {
+		return
+	}
+
+	if v := other.V; v != nil {
+		if o.V == nil {
+			o.V = v.colferClone()
+		} else {
+			o.V = o.V.colferMerge(v)
+		}
+	}
+}
+
+// String returns a human-readable form for debugging, with the sensitive
+// fields masked.
+func (o *W) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("gen.W{V:%v}", o.V)
+}
+
+// GoString returns the Go syntax for debugging, with the sensitive fields
+// masked.
+func (o *W) GoString() string {
+	if o == nil {
+		return "(*gen.W)(nil)"
+	}
+	return fmt.Sprintf("&gen.W{V:%#v}", o.V)
+}
+
+// Validate checks the fields against the schema rules, including the ones of
+// nested data structures. A nil o validates as the zero value.
+// The error return option is gen.ColferInvalid.
+func (o *W) Validate() error {
+	if o == nil {
+		o = new(W)
+	}
+	if o.V != nil {
+		if err := o.V.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ColferType returns the qualified schema name conform ColferAny.
+func (*W) ColferType() string { return "gen.w" }
+
+func (*W) colferNew(opts ColferOptions, field string) (ColferAny, error) {
+	if err := opts.charge(field, int(unsafe.Sizeof(W{}))); err != nil {
+		return nil, err
+	}
+	return new(W), nil
+}
+
+func (o *W) colferEqual(other ColferAny) bool {
+	p, ok := other.(*W)
+	return ok && o.Equal(p)
+}
+
+func (o *W) colferClone() ColferAny { return o.Clone() }
+
+func (o *W) colferMerge(other ColferAny) ColferAny {
+	p, ok := other.(*W)
+	if !ok {
+		return other.colferClone()
+	}
+	o.Merge(p)
+	return o
+}
+
+func (o *W) colferDiff(other ColferAny) []ColferDiff {
+	p, _ := other.(*W)
+	return o.Diff(p)
+}
+
+// R tests validation rules.
+type R struct {
+	// Par tests an integer range.
+	Par uint8 `json:"par,omitempty"`
+	// Lat tests a floating point range.
+	Lat float64 `json:"lat,omitempty"`
+	// Big tests an unsigned range beyond the signed maximum.
+	Big uint64 `json:"big,omitempty"`
+	// Name tests required text with size and pattern rules.
+	Name string `json:"name,omitempty"`
+	// Tags tests a list size.
+	Tags []string `json:"tags,omitempty"`
+	// Next tests nested validation.
+	Next *R `json:"next,omitempty"`
+}
+
+var _ rt.Message = (*R)(nil)
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic.
+func (o *R) MarshalTo(buf []byte) int {
+	var i int
+
+	if x := o.Par; x != 0 {
+		buf[i] = 0
+		i++
+		buf[i] = x
+		i++
+	}
+
+	if v := o.Lat; v != 0 {
+		buf[i] = 1
+		intconv.PutUint64(buf[i+1:], math.Float64bits(v))
+		i += 9
+	}
+
+	if x := o.Big; x >= 1<<49 {
+		buf[i] = 2 | 0x80
+		intconv.PutUint64(buf[i+1:], x)
+		i += 9
+	} else if x != 0 {
+		buf[i] = 2
+		i++
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+	}
+
+	if l := len(o.Name); l != 0 {
+		buf[i] = 3
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.Name)
+	}
+
+	if l := len(o.Tags); l != 0 {
+		buf[i] = 4
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		for _, a := range o.Tags {
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf[i] = byte(x | 0x80)
+				x >>= 7
+				i++
+			}
+			buf[i] = byte(x)
+			i++
+			i += copy(buf[i:], a)
+		}
+	}
+
+	if v := o.Next; v != nil {
+		buf[i] = 5
+		i++
+		i += v.MarshalTo(buf[i:])
+	}
+
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is gen.ColferMax.
+func (o *R) MarshalLen() (int, error) {
+	return o.MarshalLenWith(colferOptions())
+}
+
+// MarshalLenWith is like MarshalLen, yet with the limits of opts instead of the
+// package-level configuration attributes.
+// The error return option is gen.ColferMax.
+func (o *R) MarshalLenWith(opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	l := 1
+
+	if x := o.Par; x != 0 {
+		l += 2
+	}
+
+	if o.Lat != 0 {
+		l += 9
+	}
+
+	if x := o.Big; x >= 1<<49 {
+		l += 9
+	} else if x != 0 {
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.Name); x != 0 {
+		if x > opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.r.name exceeds %d bytes", opts.SizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if x := len(o.Tags); x != 0 {
+		if x > opts.ListMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.r.tags exceeds %d elements", opts.ListMax))
+		}
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+		for _, a := range o.Tags {
+			x = len(a)
+			if x > opts.SizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: field gen.r.tags exceeds %d bytes", opts.SizeMax))
+			}
+			for l += x + 1; x >= 0x80; l++ {
+				x >>= 7
+			}
+		}
+		if l >= opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: struct gen.r size exceeds %d bytes", opts.SizeMax))
+		}
+	}
+
+	if v := o.Next; v != nil {
+		sub, err := opts.nested("gen.r.next")
+		if err != nil {
+			return 0, err
+		}
+		vl, err := v.MarshalLenWith(sub)
+		if err != nil {
+			return 0, err
+		}
+		l += vl + 1
+	}
+
+	if l > opts.SizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct gen.r exceeds %d bytes", opts.SizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// The error return option is gen.ColferMax.
+func (o *R) MarshalBinary() (data []byte, err error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return nil, err
+	}
+	data = make([]byte, l)
+	o.MarshalTo(data)
+	return data, nil
+}
+
+// AppendColfer appends the Colfer serial of o to dst and returns the extended
+// buffer. Unlike MarshalBinary, the object is walked only once.
+// When an error occurs, dst is returned with its original length.
+// The error return option is gen.ColferMax.
+func (o *R) AppendColfer(dst []byte) ([]byte, error) {
+	return o.AppendColferWith(dst, colferOptions())
+}
+
+// AppendColferWith is like AppendColfer, yet with the limits of opts instead
+// of the package-level configuration attributes.
+// The error return option is gen.ColferMax.
+func (o *R) AppendColferWith(dst []byte, opts ColferOptions) ([]byte, error) {
+	opts = opts.limits()
+	buf := dst
+
+	if x := o.Par; x != 0 {
+		buf = append(buf, 0, x)
+	}
+
+	if v := o.Lat; v != 0 {
+		buf = append(buf, 1, 0, 0, 0, 0, 0, 0, 0, 0)
+		intconv.PutUint64(buf[len(buf)-8:], math.Float64bits(v))
+	}
+
+	if x := o.Big; x >= 1<<49 {
+		buf = append(buf, 2|0x80, 0, 0, 0, 0, 0, 0, 0, 0)
+		intconv.PutUint64(buf[len(buf)-8:], x)
+	} else if x != 0 {
+		buf = append(buf, 2)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if l := len(o.Name); l != 0 {
+		if l > opts.SizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.r.name exceeds %d bytes", opts.SizeMax))
+		}
+		buf = append(buf, 3)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Name...)
+	}
+
+	if l := len(o.Tags); l != 0 {
+		if l > opts.ListMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.r.tags exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, 4)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, a := range o.Tags {
+			if len(a) > opts.SizeMax {
+				return dst, ColferMax(fmt.Sprintf("colfer: field gen.r.tags exceeds %d bytes", opts.SizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			buf = append(buf, a...)
+		}
+	}
+
+	if v := o.Next; v != nil {
+		sub, err := opts.nested("gen.r.next")
+		if err != nil {
+			return dst, err
+		}
+		buf, err = v.AppendColferWith(append(buf, 5), sub)
+		if err != nil {
+			return dst, err
+		}
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-len(dst) > opts.SizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct gen.r exceeds %d bytes", opts.SizeMax))
+	}
+	return buf, nil
+}
+
+// ColferHash writes the Colfer serial of o to h, without materializing the
+// serial as a whole. Equal values produce the same input for h in each of the
+// supported languages.
+// The error return option is gen.ColferMax.
+func (o *R) ColferHash(h hash.Hash) error {
+	_, err := o.ColferHashWith(h, colferOptions())
+	return err
+}
+
+// ColferHashWith is like ColferHash, yet with the limits of opts instead of the
+// package-level configuration attributes. The return is the number of bytes
+// written to h. When an error occurs, then the state of h is undefined.
+// The error return option is gen.ColferMax.
+func (o *R) ColferHashWith(h hash.Hash, opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	var scratch [32]byte
+	buf := scratch[:0]
+	var n int
+
+	if x := o.Par; x != 0 {
+		buf = append(buf, 0, x)
+	}
+
+	if v := o.Lat; v != 0 {
+		buf = append(buf, 1, 0, 0, 0, 0, 0, 0, 0, 0)
+		intconv.PutUint64(buf[len(buf)-8:], math.Float64bits(v))
+	}
+
+	if x := o.Big; x >= 1<<49 {
+		buf = append(buf, 2|0x80, 0, 0, 0, 0, 0, 0, 0, 0)
+		intconv.PutUint64(buf[len(buf)-8:], x)
+	} else if x != 0 {
+		buf = append(buf, 2)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if l := len(o.Name); l != 0 {
+		if l > opts.SizeMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field gen.r.name exceeds %d bytes", opts.SizeMax))
+		}
+		buf = append(buf, 3)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		h.Write(buf)
+		n += len(buf) + l
+		buf = buf[:0]
+		io.WriteString(h, o.Name)
+	}
+
+	if l := len(o.Tags); l != 0 {
+		if l > opts.ListMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field gen.r.tags exceeds %d elements", opts.ListMax))
+		}
+		buf = append(buf, 4)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		for _, a := range o.Tags {
+			if len(a) > opts.SizeMax {
+				return n, ColferMax(fmt.Sprintf("colfer: field gen.r.tags exceeds %d bytes", opts.SizeMax))
+			}
+			x = uint(len(a))
+			for x >= 0x80 {
+				buf = append(buf, byte(x|0x80))
+				x >>= 7
+			}
+			buf = append(buf, byte(x))
+			h.Write(buf)
+			n += len(buf) + len(a)
+			buf = buf[:0]
+			io.WriteString(h, a)
+		}
+	}
+
+	if v := o.Next; v != nil {
+		sub, err := opts.nested("gen.r.next")
+		if err != nil {
+			return n, err
+		}
+		buf = append(buf, 5)
+		h.Write(buf)
+		n += len(buf)
+		buf = buf[:0]
+		vn, err := v.ColferHashWith(h, sub)
+		n += vn
+		if err != nil {
+			return n, err
+		}
+	}
+
+	buf = append(buf, 0x7f)
+	h.Write(buf)
+	n += len(buf)
+	if n > opts.SizeMax {
+		return n, ColferMax(fmt.Sprintf("colfer: struct gen.r exceeds %d bytes", opts.SizeMax))
+	}
+	return n, nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, gen.ColferError and gen.ColferMax.
+func (o *R) Unmarshal(data []byte) (int, error) {
+	return o.UnmarshalWith(data, colferOptions())
+}
+
+// UnmarshalNoCopy is like Unmarshal, yet the text and binary fields, including
+// those of nested data structures, share memory with data instead of holding a
+// copy. Any modification to data is visible through o, and vice versa, for as
+// long as o is in use. The caller must not reuse or recycle data (buffers)
+// before o and all of the values read from it are no longer referenced.
+// The error return options are io.EOF, gen.ColferError and gen.ColferMax.
+func (o *R) UnmarshalNoCopy(data []byte) (int, error) {
+	opts := colferOptions()
+	opts.NoCopy = true
+	return o.UnmarshalWith(data, opts)
+}
+
+// UnmarshalWith is like Unmarshal, yet with the limits and the field selection
+// of opts instead of the package-level configuration attributes.
+// The error return options are io.EOF, gen.ColferError, gen.ColferMax,
+// with opts.Strict, gen.ColferNonCanonical and, with opts.Validate,
+// gen.ColferInvalid.
+func (o *R) UnmarshalWith(data []byte, opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 && !opts.selects("par") {
+		i++
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 0 {
+		at := i - 1
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		o.Par = data[start]
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.Par; x != 0 {
+				buf = append(buf, 0, x)
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header == 1 && !opts.selects("lat") {
+		i += 8
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 1 {
+		at := i - 1
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.Lat = math.Float64frombits(intconv.Uint64(data[start:]))
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if v := o.Lat; v != 0 {
+				buf = append(buf, 1, 0, 0, 0, 0, 0, 0, 0, 0)
+				intconv.PutUint64(buf[len(buf)-8:], math.Float64bits(v))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header&0x7f == 2 && !opts.selects("big") {
+		if header&0x80 != 0 {
+			i += 8
+		} else {
+			for n := 1; ; n++ {
+				if i >= len(data) {
+					goto eof
+				}
+				b := data[i]
+				i++
+				if b < 0x80 || n == 9 {
+					break
+				}
+			}
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 2 {
+		at := i - 1
+		start := i
+		i++
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint64(data[start])
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				b := uint64(data[i])
+				i++
+				if i >= len(data) {
+					goto eof
+				}
+
+				if b < 0x80 || shift == 56 {
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+		o.Big = x
+
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.Big; x >= 1<<49 {
+				buf = append(buf, 2|0x80, 0, 0, 0, 0, 0, 0, 0, 0)
+				intconv.PutUint64(buf[len(buf)-8:], x)
+			} else if x != 0 {
+				buf = append(buf, 2)
+				for x >= 0x80 {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	} else if header == 2|0x80 {
+		at := i - 1
+		start := i
+		i += 8
+		if i >= len(data) {
+			goto eof
+		}
+		o.Big = intconv.Uint64(data[start:])
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.Big; x >= 1<<49 {
+				buf = append(buf, 2|0x80, 0, 0, 0, 0, 0, 0, 0, 0)
+				intconv.PutUint64(buf[len(buf)-8:], x)
+			} else if x != 0 {
+				buf = append(buf, 2)
+				for x >= 0x80 {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header == 3 && !opts.selects("name") {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.r.name size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+		i += int(x)
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 3 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.r.name size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if opts.Strict {
+			if n := colferUTF8Len(data[start:i]); n < int(x) {
+				return 0, ColferNonCanonical(start + n)
+			}
+		}
+		if opts.NoCopy {
+			o.Name = colferNoCopyString(data[start:i])
+		} else {
+			if err := opts.charge("gen.r.name", int(x)); err != nil {
+				return 0, err
+			}
+			o.Name = string(data[start:i])
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header == 4 && !opts.selects("tags") {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.r.tags length %d exceeds %d elements", x, opts.ListMax))
+		}
+		for ai, l := 0, int(x); ai < l; ai++ {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						if b == 0 && opts.Strict {
+							return 0, ColferNonCanonical(i - 1)
+						}
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(opts.SizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: gen.r.tags element %d size %d exceeds %d bytes", ai, x, opts.SizeMax))
+			}
+			i += int(x)
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 4 {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.ListMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.r.tags length %d exceeds %d elements", x, opts.ListMax))
+		}
+		// each element takes at least one byte
+		if end := i + int(x); end >= len(data) {
+			i = end
+			goto eof
+		}
+		if err := opts.charge("gen.r.tags", int(x)*int(unsafe.Sizeof(""))); err != nil {
+			return 0, err
+		}
+		a := make([]string, int(x))
+		o.Tags = a
+
+		for ai := range a {
+			if i >= len(data) {
+				goto eof
+			}
+			x := uint(data[i])
+			i++
+
+			if x >= 0x80 {
+				x &= 0x7f
+				for shift := uint(7); ; shift += 7 {
+					if i >= len(data) {
+						goto eof
+					}
+					b := uint(data[i])
+					i++
+
+					if b < 0x80 {
+						if b == 0 && opts.Strict {
+							return 0, ColferNonCanonical(i - 1)
+						}
+						x |= b << shift
+						break
+					}
+					x |= (b & 0x7f) << shift
+				}
+			}
+
+			if x > uint(opts.SizeMax) {
+				return 0, ColferMax(fmt.Sprintf("colfer: gen.r.tags element %d size %d exceeds %d bytes", ai, x, opts.SizeMax))
+			}
+
+			start := i
+			i += int(x)
+			if i >= len(data) {
+				goto eof
+			}
+			if opts.Strict {
+				if n := colferUTF8Len(data[start:i]); n < int(x) {
+					return 0, ColferNonCanonical(start + n)
+				}
+			}
+			if opts.NoCopy {
+				a[ai] = colferNoCopyString(data[start:i])
+			} else {
+				if err := opts.charge("gen.r.tags", int(x)); err != nil {
+					return 0, err
+				}
+				a[ai] = string(data[start:i])
+			}
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 5 && !opts.selects("next") {
+		sub, err := opts.nested("gen.r.next")
+		if err != nil {
+			return 0, err
+		}
+		// none selected, so the receiver is never read nor written
+		sub.Fields, sub.Validate = opts.Fields[:0], false
+
+		{
+			n, err := (*R)(nil).UnmarshalWith(data[i:], sub)
+			if err != nil {
+				if err == io.EOF && len(data) >= opts.SizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: gen.r size exceeds %d bytes", opts.SizeMax))
+				}
+				if e, ok := err.(ColferNonCanonical); ok {
+					return 0, ColferNonCanonical(i + int(e))
+				}
+				return 0, err
+			}
+			i += n
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header == 5 {
+		sub, err := opts.nested("gen.r.next")
+		if err != nil {
+			return 0, err
+		}
+		sub.Fields = opts.within("next")
+
+		if err := opts.charge("gen.r.next", int(unsafe.Sizeof(R{}))); err != nil {
+			return 0, err
+		}
+		o.Next = new(R)
+		n, err := o.Next.UnmarshalWith(data[i:], sub)
+		if err != nil {
+			if err == io.EOF && len(data) >= opts.SizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: gen.r size exceeds %d bytes", opts.SizeMax))
+			}
+			if e, ok := err.(ColferNonCanonical); ok {
+				return 0, ColferNonCanonical(i + int(e))
+			}
+			return 0, err
+		}
+		i += n
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < opts.SizeMax {
+		if opts.Validate {
+			if err := o.colferValidate(opts); err != nil {
+				return 0, err
+			}
+		}
+		return i, nil
+	}
+eof:
+	if i >= opts.SizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct gen.r size exceeds %d bytes", opts.SizeMax))
+	}
+	return 0, io.EOF
+}
+
+// HasColferPath returns whether path locates a field in the schema, with the
+// names separated by dots, e.g., "o.s". See ColferOptions.Fields.
+func (*R) HasColferPath(path string) bool {
+	name, nested := path, false
+	for i := 0; i < len(path); i++ {
+		if path[i] == '.' {
+			name, nested = path[:i], true
+			break
+		}
+	}
+
+	switch name {
+	case "par":
+		return !nested
+	case "lat":
+		return !nested
+	case "big":
+		return !nested
+	case "name":
+		return !nested
+	case "tags":
+		return !nested
+	case "next":
+		return !nested || (*R)(nil).HasColferPath(path[len(name)+1:])
+	}
+	return false
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, gen.ColferError, gen.ColferTail and gen.ColferMax.
+func (o *R) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// Value returns the serial data conform database/sql/driver.Valuer, with nil
+// (NULL) for a nil o. The error return option is gen.ColferMax.
+func (o *R) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+	return o.MarshalBinary()
+}
+
+// Scan decodes the serial data of a []byte or string conform database/sql.Scanner.
+// Any previous content of o is discarded, including on NULL, which reads as the
+// zero value. Scan into a **R to get nil for NULL instead. The error
+// return options are io.ErrUnexpectedEOF, gen.ColferError,
+// gen.ColferTail and gen.ColferMax.
+func (o *R) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*o = R{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("colfer: struct gen.r scan from SQL type %T, want []byte or string", src)
+	}
+
+	// no carry-over from previous rows
+	*o = R{}
+	err := o.UnmarshalBinary(data)
+	if err == io.EOF {
+		// column content is complete
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// MarshalJSON encodes o as JSON conform json.Marshaler. The members are named
+// after the schema fields and zero values are omitted. Timestamps are RFC 3339
+// strings in UTC and binaries are base64 strings. NaN and infinite floating
+// points are JSON strings too.
+func (o *R) MarshalJSON() ([]byte, error) {
+	return o.MarshalJSONWith(colferOptions())
+}
+
+// MarshalJSONWith is like MarshalJSON, yet with the opts.JSONQuote64 mode,
+// including for nested data structures.
+func (o *R) MarshalJSONWith(opts ColferOptions) ([]byte, error) {
+	buf := []byte{'{'}
+
+	if x := o.Par; x != 0 {
+		buf = append(buf, "\"par\":"...)
+		buf = strconv.AppendUint(buf, uint64(x), 10)
+		buf = append(buf, ',')
+	}
+
+	if v := o.Lat; v != 0 {
+		buf = append(buf, "\"lat\":"...)
+		buf = appendColferJSONFloat(buf, float64(v), 64)
+		buf = append(buf, ',')
+	}
+
+	if x := o.Big; x != 0 {
+		buf = append(buf, "\"big\":"...)
+		if opts.JSONQuote64 {
+			buf = append(buf, '"')
+		}
+		buf = strconv.AppendUint(buf, x, 10)
+		if opts.JSONQuote64 {
+			buf = append(buf, '"')
+		}
+		buf = append(buf, ',')
+	}
+
+	if len(o.Name) != 0 {
+		// infallible for strings and byte slices
+		b, _ := json.Marshal(o.Name)
+		buf = append(buf, "\"name\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+
+	if len(o.Tags) != 0 {
+		// infallible for strings and byte slices
+		b, _ := json.Marshal(o.Tags)
+		buf = append(buf, "\"tags\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+
+	if v := o.Next; v != nil {
+		b, err := v.MarshalJSONWith(opts)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, "\"next\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+
+	if len(buf) == 1 {
+		return append(buf, '}'), nil
+	}
+	buf[len(buf)-1] = '}'
+	return buf, nil
+}
+
+// UnmarshalJSON decodes data as JSON conform json.Unmarshaler, with the same
+// mapping as MarshalJSON. Integers may also be JSON strings with a decimal
+// value, and JSON null equals the zero value. Unknown members are rejected.
+// The error return option is gen.ColferMax, next to the JSON errors.
+func (o *R) UnmarshalJSON(data []byte) error {
+	return o.UnmarshalJSONWith(data, colferOptions())
+}
+
+// UnmarshalJSONWith is like UnmarshalJSON, yet with the list limit of opts
+// instead of the package-level configuration attribute, including for nested
+// data structures.
+func (o *R) UnmarshalJSONWith(data []byte, opts ColferOptions) error {
+	opts = opts.limits()
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	for name, raw := range members {
+		switch name {
+		case "par":
+			x, err := strconv.ParseUint(colferJSONNumber(raw), 10, 8)
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.r.par: %s", err)
+			}
+			o.Par = uint8(x)
+		case "lat":
+			f, err := parseColferJSONFloat(raw, 64)
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.r.lat: %s", err)
+			}
+			o.Lat = float64(f)
+		case "big":
+			x, err := strconv.ParseUint(colferJSONNumber(raw), 10, 64)
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.r.big: %s", err)
+			}
+			o.Big = uint64(x)
+		case "name":
+			// JSON null has no effect on the decode
+			o.Name = ""
+			if err := json.Unmarshal(raw, &o.Name); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.r.name: %s", err)
+			}
+		case "tags":
+			if err := json.Unmarshal(raw, &o.Tags); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.r.tags: %s", err)
+			}
+			if len(o.Tags) > opts.ListMax {
+				return ColferMax(fmt.Sprintf("colfer: field gen.r.tags length %d exceeds %d elements", len(o.Tags), opts.ListMax))
+			}
+		case "next":
+			if string(raw) == "null" {
+				o.Next = nil
+				break
+			}
+			v := new(R)
+			if err := v.UnmarshalJSONWith(raw, opts); err != nil {
+				return err
+			}
+			o.Next = v
+		default:
+			return fmt.Errorf("colfer: JSON member %q not in struct gen.r", name)
+		}
+	}
+	return nil
+}
+
+// Equal returns whether o and other have the same Colfer serial. Nil and empty
+// lists are equal, nil list entries equal the zero value, timestamps compare
+// as instants, and floating points compare by their bits, except for zero.
+func (o *R) Equal(other *R) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+
+	if o.Par != other.Par {
+		return false
+	}
+
+	if a, b := o.Lat, other.Lat; (a != 0 || b != 0) && math.Float64bits(a) != math.Float64bits(b) {
+		return false
+	}
+
+	if o.Big != other.Big {
+		return false
+	}
+
+	if o.Name != other.Name {
+		return false
+	}
+
+	if len(o.Tags) != len(other.Tags) {
+		return false
+	}
+	for i, v := range o.Tags {
+		if v != other.Tags[i] {
+			return false
+		}
+	}
+
+	if !o.Next.Equal(other.Next) {
+		return false
+	}
+
+	return true
+}
+
+// Diff returns the fields which differ between o and other in schema order,
+// with o as the old values. Data structures compare field by field and lists
+// compare element by element, conform Equal. A nil o or other compares as the
+// zero value.
+func (o *R) Diff(other *R) []ColferDiff {
+	if o == nil {
+		o = new(R)
+	}
+	if other == nil {
+		other = new(R)
+	}
+	var diffs []ColferDiff
+
+	if a, b := o.Par, other.Par; a != b {
+		diffs = append(diffs, ColferDiff{Path: "par", Old: a, New: b})
+	}
+
+	if a, b := o.Lat, other.Lat; (a != 0 || b != 0) && math.Float64bits(a) != math.Float64bits(b) {
+		diffs = append(diffs, ColferDiff{Path: "lat", Old: a, New: b})
+	}
+
+	if a, b := o.Big, other.Big; a != b {
+		diffs = append(diffs, ColferDiff{Path: "big", Old: a, New: b})
+	}
+
+	if a, b := o.Name, other.Name; a != b {
+		diffs = append(diffs, ColferDiff{Path: "name", Old: a, New: b})
+	}
+
+	for i, a, b := 0, o.Tags, other.Tags; i < len(a) || i < len(b); i++ {
+		switch {
+		case i >= len(a):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("tags[%d]", i), New: b[i]})
+		case i >= len(b):
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("tags[%d]", i), Old: a[i]})
+		case a[i] != b[i]:
+			diffs = append(diffs, ColferDiff{Path: fmt.Sprintf("tags[%d]", i), Old: a[i], New: b[i]})
+		}
+	}
+
+	if v, w := o.Next, other.Next; v != nil && w != nil {
+		for _, d := range v.Diff(w) {
+			d.Path = "next." + d.Path
+			diffs = append(diffs, d)
+		}
+	} else if v != nil {
+		diffs = append(diffs, ColferDiff{Path: "next", Old: v})
+	} else if w != nil {
+		diffs = append(diffs, ColferDiff{Path: "next", New: w})
+	}
+
+	return diffs
+}
+
+// Clone returns a deep copy of o, or nil when o is nil.
+func (o *R) Clone() *R {
+	if o == nil {
+		return nil
+	}
+	c := *o
+
+	if o.Tags != nil {
+		c.Tags = make([]string, len(o.Tags))
+		copy(c.Tags, o.Tags)
+	}
+
+	c.Next = o.Next.Clone()
+
+	return &c
+}
+
+// Merge copies the non-zero fields of other into o. Nested data structures
+// merge recursively, and lists are replaced with a copy.
+func (o *R) Merge(other *R) {
+	if other == nil {
+		return
+	}
+
+	if other.Par != 0 {
+		o.Par = other.Par
+	}
+
+	if other.Lat != 0 {
+		o.Lat = other.Lat
+	}
+
+	if other.Big != 0 {
+		o.Big = other.Big
+	}
+
+	if other.Name != "" {
+		o.Name = other.Name
+	}
+
+	if len(other.Tags) != 0 {
+		o.Tags = make([]string, len(other.Tags))
+		copy(o.Tags, other.Tags)
+	}
+
+	if v := other.Next; v != nil {
+		if o.Next == nil {
+			o.Next = v.Clone()
+		} else {
+			o.Next.Merge(v)
+		}
+	}
+}
+
+// String returns a human-readable form for debugging, with the sensitive
+// fields masked.
+func (o *R) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("gen.R{Par:%v Lat:%v Big:%v Name:%q Tags:%q Next:%v}", o.Par, o.Lat, o.Big, o.Name, o.Tags, o.Next)
+}
+
+// GoString returns the Go syntax for debugging, with the sensitive fields
+// masked.
+func (o *R) GoString() string {
+	if o == nil {
+		return "(*gen.R)(nil)"
+	}
+	return fmt.Sprintf("&gen.R{Par:%#v, Lat:%#v, Big:%#v, Name:%#v, Tags:%#v, Next:%#v}", o.Par, o.Lat, o.Big, o.Name, o.Tags, o.Next)
+}
+
+// Validate checks the fields against the schema rules, including the ones of
+// nested data structures. A nil o validates as the zero value.
+// The error return option is gen.ColferInvalid.
+func (o *R) Validate() error {
+	if o == nil {
+		o = new(R)
+	}
+	if err := o.colferValidate(ColferOptions{}); err != nil {
+		return err
+	}
+	if o.Next != nil {
+		if err := o.Next.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// colferRNamePattern is the rule for field name.
+var colferRNamePattern = regexp.MustCompile("^[a-z]+$")
+
+// colferValidate checks the fields selected by opts against the schema rules,
+// without the nested data structures.
+func (o *R) colferValidate(opts ColferOptions) error {
+	if opts.selects("par") {
+		if o.Par < 3 {
+			return ColferInvalid{Field: "gen.r.par", Rule: "min 3"}
+		}
+		if o.Par > 5 {
+			return ColferInvalid{Field: "gen.r.par", Rule: "max 5"}
+		}
+	}
+	if opts.selects("lat") {
+		if !(o.Lat >= -90) {
+			return ColferInvalid{Field: "gen.r.lat", Rule: "min -90"}
+		}
+		if !(o.Lat <= 90) {
+			return ColferInvalid{Field: "gen.r.lat", Rule: "max 90"}
+		}
+	}
+	if opts.selects("big") {
+		if o.Big > 10000000000000000000 {
+			return ColferInvalid{Field: "gen.r.big", Rule: "max 10000000000000000000"}
+		}
+	}
+	if opts.selects("name") {
+		if o.Name == "" {
+			return ColferInvalid{Field: "gen.r.name", Rule: "required"}
+		}
+		if len(o.Name) < 2 {
+			return ColferInvalid{Field: "gen.r.name", Rule: "minlen 2"}
+		}
+		if len(o.Name) > 8 {
+			return ColferInvalid{Field: "gen.r.name", Rule: "maxlen 8"}
+		}
+		if !colferRNamePattern.MatchString(o.Name) {
+			return ColferInvalid{Field: "gen.r.name", Rule: "pattern ^[a-z]+$"}
+		}
+	}
+	if opts.selects("tags") {
+		if len(o.Tags) > 2 {
+			return ColferInvalid{Field: "gen.r.tags", Rule: "maxlen 2"}
+		}
+	}
+	return nil
+}
+
+// ColferType returns the qualified schema name conform ColferAny.
+func (*R) ColferType() string { return "gen.r" }
+
+func (*R) colferNew(opts ColferOptions, field string) (ColferAny, error) {
+	if err := opts.charge(field, int(unsafe.Sizeof(R{}))); err != nil {
+		return nil, err
+	}
+	return new(R), nil
+}
+
+func (o *R) colferEqual(other ColferAny) bool {
+	p, ok := other.(*R)
+	return ok && o.Equal(p)
+}
+
+func (o *R) colferClone() ColferAny { return o.Clone() }
+
+func (o *R) colferMerge(other ColferAny) ColferAny {
+	p, ok := other.(*R)
+	if !ok {
+		return other.colferClone()
+	}
+	o.Merge(p)
+	return o
+}
+
+func (o *R) colferDiff(other ColferAny) []ColferDiff {
+	p, _ := other.(*R)
+	return o.Diff(p)
+}
+
+// Old tests the annotations.
+//
+// Since: v1.2
+//
+// Deprecated: use r instead.
+type Old struct {
+	// Pin tests a sensitive field.
+	//
+	// Sensitive:
+	Pin string `json:"pin,omitempty"`
+	// Ref tests a deprecated field.
+	//
+	// Deprecated: no replacement.
+	Ref *Old `json:"ref,omitempty"`
+}
+
+var _ rt.Message = (*Old)(nil)
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic.
+func (o *Old) MarshalTo(buf []byte) int {
+	var i int
+
+	if l := len(o.Pin); l != 0 {
+		buf[i] = 0
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
+		}
+		buf[i] = byte(x)
+		i++
+		i += copy(buf[i:], o.Pin)
+	}
+
+	if v := o.Ref; v != nil {
+		buf[i] = 1
+		i++
+		i += v.MarshalTo(buf[i:])
+	}
+
+	buf[i] = 0x7f
+	i++
+	return i
+}
+
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is gen.ColferMax.
+func (o *Old) MarshalLen() (int, error) {
+	return o.MarshalLenWith(colferOptions())
+}
+
+// MarshalLenWith is like MarshalLen, yet with the limits of opts instead of the
+// package-level configuration attributes.
+// The error return option is gen.ColferMax.
+func (o *Old) MarshalLenWith(opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	l := 1
+
+	if x := len(o.Pin); x != 0 {
+		if x > opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.old.pin exceeds %d bytes", opts.SizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
+		}
+	}
+
+	if v := o.Ref; v != nil {
+		sub, err := opts.nested("gen.old.ref")
+		if err != nil {
+			return 0, err
+		}
+		vl, err := v.MarshalLenWith(sub)
+		if err != nil {
+			return 0, err
+		}
+		l += vl + 1
+	}
+
+	if l > opts.SizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct gen.old exceeds %d bytes", opts.SizeMax))
+	}
+	return l, nil
+}
+
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// The error return option is gen.ColferMax.
+func (o *Old) MarshalBinary() (data []byte, err error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return nil, err
+	}
+	data = make([]byte, l)
+	o.MarshalTo(data)
+	return data, nil
+}
+
+// AppendColfer appends the Colfer serial of o to dst and returns the extended
+// buffer. Unlike MarshalBinary, the object is walked only once.
+// When an error occurs, dst is returned with its original length.
+// The error return option is gen.ColferMax.
+func (o *Old) AppendColfer(dst []byte) ([]byte, error) {
+	return o.AppendColferWith(dst, colferOptions())
+}
+
+// AppendColferWith is like AppendColfer, yet with the limits of opts instead
+// of the package-level configuration attributes.
+// The error return option is gen.ColferMax.
+func (o *Old) AppendColferWith(dst []byte, opts ColferOptions) ([]byte, error) {
+	opts = opts.limits()
+	buf := dst
+
+	if l := len(o.Pin); l != 0 {
+		if l > opts.SizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.old.pin exceeds %d bytes", opts.SizeMax))
+		}
+		buf = append(buf, 0)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Pin...)
+	}
+
+	if v := o.Ref; v != nil {
+		sub, err := opts.nested("gen.old.ref")
+		if err != nil {
+			return dst, err
+		}
+		buf, err = v.AppendColferWith(append(buf, 1), sub)
+		if err != nil {
+			return dst, err
+		}
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-len(dst) > opts.SizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct gen.old exceeds %d bytes", opts.SizeMax))
+	}
+	return buf, nil
+}
+
+// ColferHash writes the Colfer serial of o to h, without materializing the
+// serial as a whole. Equal values produce the same input for h in each of the
+// supported languages.
+// The error return option is gen.ColferMax.
+func (o *Old) ColferHash(h hash.Hash) error {
+	_, err := o.ColferHashWith(h, colferOptions())
+	return err
+}
+
+// ColferHashWith is like ColferHash, yet with the limits of opts instead of the
+// package-level configuration attributes. The return is the number of bytes
+// written to h. When an error occurs, then the state of h is undefined.
+// The error return option is gen.ColferMax.
+func (o *Old) ColferHashWith(h hash.Hash, opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	var scratch [32]byte
+	buf := scratch[:0]
+	var n int
+
+	if l := len(o.Pin); l != 0 {
+		if l > opts.SizeMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field gen.old.pin exceeds %d bytes", opts.SizeMax))
+		}
+		buf = append(buf, 0)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		h.Write(buf)
+		n += len(buf) + l
+		buf = buf[:0]
+		io.WriteString(h, o.Pin)
+	}
+
+	if v := o.Ref; v != nil {
+		sub, err := opts.nested("gen.old.ref")
+		if err != nil {
+			return n, err
+		}
+		buf = append(buf, 1)
+		h.Write(buf)
+		n += len(buf)
+		buf = buf[:0]
+		vn, err := v.ColferHashWith(h, sub)
+		n += vn
+		if err != nil {
+			return n, err
+		}
+	}
+
+	buf = append(buf, 0x7f)
+	h.Write(buf)
+	n += len(buf)
+	if n > opts.SizeMax {
+		return n, ColferMax(fmt.Sprintf("colfer: struct gen.old exceeds %d bytes", opts.SizeMax))
+	}
+	return n, nil
+}
+
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, gen.ColferError and gen.ColferMax.
+func (o *Old) Unmarshal(data []byte) (int, error) {
+	return o.UnmarshalWith(data, colferOptions())
+}
+
+// UnmarshalNoCopy is like Unmarshal, yet the text and binary fields, including
+// those of nested data structures, share memory with data instead of holding a
+// copy. Any modification to data is visible through o, and vice versa, for as
+// long as o is in use. The caller must not reuse or recycle data (buffers)
+// before o and all of the values read from it are no longer referenced.
+// The error return options are io.EOF, gen.ColferError and gen.ColferMax.
+func (o *Old) UnmarshalNoCopy(data []byte) (int, error) {
+	opts := colferOptions()
+	opts.NoCopy = true
+	return o.UnmarshalWith(data, opts)
+}
+
+// UnmarshalWith is like Unmarshal, yet with the limits and the field selection
+// of opts instead of the package-level configuration attributes.
+// The error return options are io.EOF, gen.ColferError, gen.ColferMax,
+// with opts.Strict, gen.ColferNonCanonical and, with opts.Validate,
+// gen.ColferInvalid.
+func (o *Old) UnmarshalWith(data []byte, opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+	header := data[0]
+	i := 1
+
+	if header == 0 && !opts.selects("pin") {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
 			x &= 0x7f
 			for shift := uint(7); ; shift += 7 {
-				b := uint32(data[i])
-				i++
 				if i >= len(data) {
 					goto eof
 				}
+				b := uint(data[i])
+				i++
 
 				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
 					x |= b << shift
 					break
 				}
 				x |= (b & 0x7f) << shift
 			}
 		}
-		o.I32 = int32(^x + 1)
 
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.old.pin size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+		i += int(x)
+
+		if i >= len(data) {
+			goto eof
+		}
 		header = data[i]
 		i++
 	}
 
-	if header == 4 {
-		if i+1 >= len(data) {
-			i++
+	if header == 0 {
+		if i >= len(data) {
 			goto eof
 		}
-		x := uint64(data[i])
+		x := uint(data[i])
 		i++
 
 		if x >= 0x80 {
 			x &= 0x7f
 			for shift := uint(7); ; shift += 7 {
-				b := uint64(data[i])
-				i++
 				if i >= len(data) {
 					goto eof
 				}
+				b := uint(data[i])
+				i++
 
-				if b < 0x80 || shift == 56 {
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
 					x |= b << shift
 					break
 				}
 				x |= (b & 0x7f) << shift
 			}
 		}
-		o.I64 = int64(x)
+
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.old.pin size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+
+		start := i
+		i += int(x)
+		if i >= len(data) {
+			goto eof
+		}
+		if opts.Strict {
+			if n := colferUTF8Len(data[start:i]); n < int(x) {
+				return 0, ColferNonCanonical(start + n)
+			}
+		}
+		if opts.NoCopy {
+			o.Pin = colferNoCopyString(data[start:i])
+		} else {
+			if err := opts.charge("gen.old.pin", int(x)); err != nil {
+				return 0, err
+			}
+			o.Pin = string(data[start:i])
+		}
 
 		header = data[i]
 		i++
-	} else if header == 4|0x80 {
-		if i+1 >= len(data) {
-			i++
+	}
+
+	if header == 1 && !opts.selects("ref") {
+		sub, err := opts.nested("gen.old.ref")
+		if err != nil {
+			return 0, err
+		}
+		// none selected, so the receiver is never read nor written
+		sub.Fields, sub.Validate = opts.Fields[:0], false
+
+		{
+			n, err := (*Old)(nil).UnmarshalWith(data[i:], sub)
+			if err != nil {
+				if err == io.EOF && len(data) >= opts.SizeMax {
+					return 0, ColferMax(fmt.Sprintf("colfer: gen.old size exceeds %d bytes", opts.SizeMax))
+				}
+				if e, ok := err.(ColferNonCanonical); ok {
+					return 0, ColferNonCanonical(i + int(e))
+				}
+				return 0, err
+			}
+			i += n
+		}
+
+		if i >= len(data) {
 			goto eof
 		}
-		x := uint64(data[i])
+		header = data[i]
 		i++
+	}
 
-		if x >= 0x80 {
-			x &= 0x7f
-			for shift := uint(7); ; shift += 7 {
-				b := uint64(data[i])
-				i++
-				if i >= len(data) {
-					goto eof
-				}
+	if header == 1 {
+		sub, err := opts.nested("gen.old.ref")
+		if err != nil {
+			return 0, err
+		}
+		sub.Fields = opts.within("ref")
 
-				if b < 0x80 || shift == 56 {
-					x |= b << shift
-					break
-				}
-				x |= (b & 0x7f) << shift
+		if err := opts.charge("gen.old.ref", int(unsafe.Sizeof(Old{}))); err != nil {
+			return 0, err
+		}
+		o.Ref = new(Old)
+		n, err := o.Ref.UnmarshalWith(data[i:], sub)
+		if err != nil {
+			if err == io.EOF && len(data) >= opts.SizeMax {
+				return 0, ColferMax(fmt.Sprintf("colfer: gen.old size exceeds %d bytes", opts.SizeMax))
 			}
+			if e, ok := err.(ColferNonCanonical); ok {
+				return 0, ColferNonCanonical(i + int(e))
+			}
+			return 0, err
 		}
-		o.I64 = int64(^x + 1)
+		i += n
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
+	if header != 0x7f {
+		return 0, ColferError(i - 1)
+	}
+	if i < opts.SizeMax {
+		return i, nil
+	}
+eof:
+	if i >= opts.SizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct gen.old size exceeds %d bytes", opts.SizeMax))
+	}
+	return 0, io.EOF
+}
+
+// HasColferPath returns whether path locates a field in the schema, with the
+// names separated by dots, e.g., "o.s". See ColferOptions.Fields.
+func (*Old) HasColferPath(path string) bool {
+	name, nested := path, false
+	for i := 0; i < len(path); i++ {
+		if path[i] == '.' {
+			name, nested = path[:i], true
+			break
+		}
+	}
+
+	switch name {
+	case "pin":
+		return !nested
+	case "ref":
+		return !nested || (*Old)(nil).HasColferPath(path[len(name)+1:])
+	}
+	return false
+}
+
+// UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
+// The error return options are io.EOF, gen.ColferError, gen.ColferTail and gen.ColferMax.
+func (o *Old) UnmarshalBinary(data []byte) error {
+	i, err := o.Unmarshal(data)
+	if i < len(data) && err == nil {
+		return ColferTail(i)
+	}
+	return err
+}
+
+// Value returns the serial data conform database/sql/driver.Valuer, with nil
+// (NULL) for a nil o. The error return option is gen.ColferMax.
+func (o *Old) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+	return o.MarshalBinary()
+}
+
+// Scan decodes the serial data of a []byte or string conform database/sql.Scanner.
+// Any previous content of o is discarded, including on NULL, which reads as the
+// zero value. Scan into a **Old to get nil for NULL instead. The error
+// return options are io.ErrUnexpectedEOF, gen.ColferError,
+// gen.ColferTail and gen.ColferMax.
+func (o *Old) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*o = Old{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("colfer: struct gen.old scan from SQL type %T, want []byte or string", src)
+	}
+
+	// no carry-over from previous rows
+	*o = Old{}
+	err := o.UnmarshalBinary(data)
+	if err == io.EOF {
+		// column content is complete
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// MarshalJSON encodes o as JSON conform json.Marshaler. The members are named
+// after the schema fields and zero values are omitted. Timestamps are RFC 3339
+// strings in UTC and binaries are base64 strings. NaN and infinite floating
+// points are JSON strings too.
+func (o *Old) MarshalJSON() ([]byte, error) {
+	return o.MarshalJSONWith(colferOptions())
+}
+
+// MarshalJSONWith is like MarshalJSON, yet with the opts.JSONQuote64 mode,
+// including for nested data structures.
+func (o *Old) MarshalJSONWith(opts ColferOptions) ([]byte, error) {
+	buf := []byte{'{'}
+
+	if len(o.Pin) != 0 {
+		// infallible for strings and byte slices
+		b, _ := json.Marshal(o.Pin)
+		buf = append(buf, "\"pin\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+
+	if v := o.Ref; v != nil {
+		b, err := v.MarshalJSONWith(opts)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, "\"ref\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+
+	if len(buf) == 1 {
+		return append(buf, '}'), nil
+	}
+	buf[len(buf)-1] = '}'
+	return buf, nil
+}
+
+// UnmarshalJSON decodes data as JSON conform json.Unmarshaler, with the same
+// mapping as MarshalJSON. Integers may also be JSON strings with a decimal
+// value, and JSON null equals the zero value. Unknown members are rejected.
+// The error return option is gen.ColferMax, next to the JSON errors.
+func (o *Old) UnmarshalJSON(data []byte) error {
+	return o.UnmarshalJSONWith(data, colferOptions())
+}
+
+// UnmarshalJSONWith is like UnmarshalJSON, yet with the list limit of opts
+// instead of the package-level configuration attribute, including for nested
+// data structures.
+func (o *Old) UnmarshalJSONWith(data []byte, opts ColferOptions) error {
+	opts = opts.limits()
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	for name, raw := range members {
+		switch name {
+		case "pin":
+			// JSON null has no effect on the decode
+			o.Pin = ""
+			if err := json.Unmarshal(raw, &o.Pin); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.old.pin: %s", err)
+			}
+		case "ref":
+			if string(raw) == "null" {
+				o.Ref = nil
+				break
+			}
+			v := new(Old)
+			if err := v.UnmarshalJSONWith(raw, opts); err != nil {
+				return err
+			}
+			o.Ref = v
+		default:
+			return fmt.Errorf("colfer: JSON member %q not in struct gen.old", name)
+		}
+	}
+	return nil
+}
+
+// Equal returns whether o and other have the same Colfer serial. Nil and empty
+// lists are equal, nil list entries equal the zero value, timestamps compare
+// as instants, and floating points compare by their bits, except for zero.
+func (o *Old) Equal(other *Old) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+
+	if o.Pin != other.Pin {
+		return false
+	}
+
+	if !o.Ref.Equal(other.Ref) {
+		return false
+	}
+
+	return true
+}
+
+// Diff returns the fields which differ between o and other in schema order,
+// with o as the old values. Data structures compare field by field and lists
+// compare element by element, conform Equal. A nil o or other compares as the
+// zero value.
+func (o *Old) Diff(other *Old) []ColferDiff {
+	if o == nil {
+		o = new(Old)
+	}
+	if other == nil {
+		other = new(Old)
+	}
+	var diffs []ColferDiff
+
+	if a, b := o.Pin, other.Pin; a != b {
+		diffs = append(diffs, ColferDiff{Path: "pin", Old: a, New: b})
+	}
+
+	if v, w := o.Ref, other.Ref; v != nil && w != nil {
+		for _, d := range v.Diff(w) {
+			d.Path = "ref." + d.Path
+			diffs = append(diffs, d)
+		}
+	} else if v != nil {
+		diffs = append(diffs, ColferDiff{Path: "ref", Old: v})
+	} else if w != nil {
+		diffs = append(diffs, ColferDiff{Path: "ref", New: w})
+	}
+
+	return diffs
+}
+
+// Clone returns a deep copy of o, or nil when o is nil.
+func (o *Old) Clone() *Old {
+	if o == nil {
+		return nil
+	}
+	c := *o
+
+	c.Ref = o.Ref.Clone()
+
+	return &c
+}
+
+// Merge copies the non-zero fields of other into o. Nested data structures
+// merge recursively, and lists are replaced with a copy.
+func (o *Old) Merge(other *Old) {
+	if other == nil {
+		return
+	}
 
-		header = data[i]
-		i++
+	if other.Pin != "" {
+		o.Pin = other.Pin
 	}
 
-	if header == 5 {
-		start := i
-		i += 4
-		if i >= len(data) {
-			goto eof
+	if v := other.Ref; v != nil {
+		if o.Ref == nil {
+			o.Ref = v.Clone()
+		} else {
+			o.Ref.Merge(v)
 		}
-		o.F32 = math.Float32frombits(intconv.Uint32(data[start:]))
-		header = data[i]
-		i++
 	}
+}
 
-	if header == 6 {
-		start := i
-		i += 8
-		if i >= len(data) {
-			goto eof
-		}
-		o.F64 = math.Float64frombits(intconv.Uint64(data[start:]))
-		header = data[i]
-		i++
+// String returns a human-readable form for debugging, with the sensitive
+// fields masked.
+func (o *Old) String() string {
+	if o == nil {
+		return "<nil>"
 	}
+	return fmt.Sprintf("gen.Old{Pin:<redacted> Ref:%v}", o.Ref)
+}
 
-	if header == 7 {
-		start := i
-		i += 8
-		if i >= len(data) {
-			goto eof
+// GoString returns the Go syntax for debugging, with the sensitive fields
+// masked.
+func (o *Old) GoString() string {
+	if o == nil {
+		return "(*gen.Old)(nil)"
+	}
+	return fmt.Sprintf("&gen.Old{Pin:<redacted>, Ref:%#v}", o.Ref)
+}
+
+// Validate checks the fields against the schema rules, including the ones of
+// nested data structures. A nil o validates as the zero value.
+// The error return option is gen.ColferInvalid.
+func (o *Old) Validate() error {
+	if o == nil {
+		o = new(Old)
+	}
+	if o.Ref != nil {
+		if err := o.Ref.Validate(); err != nil {
+			return err
 		}
-		o.T = time.Unix(int64(intconv.Uint32(data[start:])), int64(intconv.Uint32(data[start+4:]))).In(time.UTC)
-		header = data[i]
+	}
+	return nil
+}
+
+// ColferType returns the qualified schema name conform ColferAny.
+func (*Old) ColferType() string { return "gen.old" }
+
+func (*Old) colferNew(opts ColferOptions, field string) (ColferAny, error) {
+	if err := opts.charge(field, int(unsafe.Sizeof(Old{}))); err != nil {
+		return nil, err
+	}
+	return new(Old), nil
+}
+
+func (o *Old) colferEqual(other ColferAny) bool {
+	p, ok := other.(*Old)
+	return ok && o.Equal(p)
+}
+
+func (o *Old) colferClone() ColferAny { return o.Clone() }
+
+func (o *Old) colferMerge(other ColferAny) ColferAny {
+	p, ok := other.(*Old)
+	if !ok {
+		return other.colferClone()
+	}
+	o.Merge(p)
+	return o
+}
+
+func (o *Old) colferDiff(other ColferAny) []ColferDiff {
+	p, _ := other.(*Old)
+	return o.Diff(p)
+}
+
+// N tests native name overrides.
+//
+// Names: go:"Renamed" java:"Renamed" ecma:"Renamed" c:"gen_renamed"
+type Renamed struct {
+	// ID tests a field override.
+	ID uint32 `json:"id,omitempty"`
+	// Class tests a reserved word without override, and it tests
+	// pass-through of struct tags.
+	Class string `json:"class,omitempty" yaml:"klass" db:"class"`
+}
+
+var _ rt.Message = (*Renamed)(nil)
+
+// MarshalTo encodes o as Colfer into buf and returns the number of bytes written.
+// If the buffer is too small, MarshalTo will panic.
+func (o *Renamed) MarshalTo(buf []byte) int {
+	var i int
+
+	if x := o.ID; x >= 1<<21 {
+		buf[i] = 0 | 0x80
+		intconv.PutUint32(buf[i+1:], x)
+		i += 5
+	} else if x != 0 {
+		buf[i] = 0
 		i++
-	} else if header == 7|0x80 {
-		start := i
-		i += 12
-		if i >= len(data) {
-			goto eof
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
 		}
-		o.T = time.Unix(int64(intconv.Uint64(data[start:])), int64(intconv.Uint32(data[start+8:]))).In(time.UTC)
-		header = data[i]
+		buf[i] = byte(x)
 		i++
 	}
 
-	if header == 8 {
-		if i >= len(data) {
-			goto eof
+	if l := len(o.Class); l != 0 {
+		buf[i] = 1
+		i++
+		x := uint(l)
+		for x >= 0x80 {
+			buf[i] = byte(x | 0x80)
+			x >>= 7
+			i++
 		}
-		x := uint(data[i])
+		buf[i] = byte(x)
 		i++
+		i += copy(buf[i:], o.Class)
+	}
 
-		if x >= 0x80 {
-			x &= 0x7f
-			for shift := uint(7); ; shift += 7 {
-				if i >= len(data) {
-					goto eof
-				}
-				b := uint(data[i])
-				i++
+	buf[i] = 0x7f
+	i++
+	return i
+}
 
-				if b < 0x80 {
-					x |= b << shift
-					break
-				}
-				x |= (b & 0x7f) << shift
-			}
-		}
+// MarshalLen returns the Colfer serial byte size.
+// The error return option is gen.ColferMax.
+func (o *Renamed) MarshalLen() (int, error) {
+	return o.MarshalLenWith(colferOptions())
+}
+
+// MarshalLenWith is like MarshalLen, yet with the limits of opts instead of the
+// package-level configuration attributes.
+// The error return option is gen.ColferMax.
+func (o *Renamed) MarshalLenWith(opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	l := 1
 
-		if x > uint(ColferSizeMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.s size %d exceeds %d bytes", x, ColferSizeMax))
+	if x := o.ID; x >= 1<<21 {
+		l += 5
+	} else if x != 0 {
+		for l += 2; x >= 0x80; l++ {
+			x >>= 7
 		}
+	}
 
-		start := i
-		i += int(x)
-		if i >= len(data) {
-			goto eof
+	if x := len(o.Class); x != 0 {
+		if x > opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field gen.n.class exceeds %d bytes", opts.SizeMax))
+		}
+		for l += x + 2; x >= 0x80; l++ {
+			x >>= 7
 		}
-		o.S = string(data[start:i])
+	}
 
-		header = data[i]
-		i++
+	if l > opts.SizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct gen.n exceeds %d bytes", opts.SizeMax))
 	}
+	return l, nil
+}
 
-	if header == 9 {
-		if i >= len(data) {
-			goto eof
-		}
-		x := uint(data[i])
-		i++
+// MarshalBinary encodes o as Colfer conform encoding.BinaryMarshaler.
+// The error return option is gen.ColferMax.
+func (o *Renamed) MarshalBinary() (data []byte, err error) {
+	l, err := o.MarshalLen()
+	if err != nil {
+		return nil, err
+	}
+	data = make([]byte, l)
+	o.MarshalTo(data)
+	return data, nil
+}
 
-		if x >= 0x80 {
-			x &= 0x7f
-			for shift := uint(7); ; shift += 7 {
-				if i >= len(data) {
-					goto eof
-				}
-				b := uint(data[i])
-				i++
+// AppendColfer appends the Colfer serial of o to dst and returns the extended
+// buffer. Unlike MarshalBinary, the object is walked only once.
+// When an error occurs, dst is returned with its original length.
+// The error return option is gen.ColferMax.
+func (o *Renamed) AppendColfer(dst []byte) ([]byte, error) {
+	return o.AppendColferWith(dst, colferOptions())
+}
 
-				if b < 0x80 {
-					x |= b << shift
-					break
-				}
-				x |= (b & 0x7f) << shift
-			}
-		}
+// AppendColferWith is like AppendColfer, yet with the limits of opts instead
+// of the package-level configuration attributes.
+// The error return option is gen.ColferMax.
+func (o *Renamed) AppendColferWith(dst []byte, opts ColferOptions) ([]byte, error) {
+	opts = opts.limits()
+	buf := dst
 
-		if x > uint(ColferSizeMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.a size %d exceeds %d bytes", x, ColferSizeMax))
+	if x := o.ID; x >= 1<<21 {
+		buf = append(buf, 0|0x80, 0, 0, 0, 0)
+		intconv.PutUint32(buf[len(buf)-4:], x)
+	} else if x != 0 {
+		buf = append(buf, 0)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
 		}
-		v := make([]byte, int(x))
+		buf = append(buf, byte(x))
+	}
 
-		start := i
-		i += len(v)
-		if i >= len(data) {
-			goto eof
+	if l := len(o.Class); l != 0 {
+		if l > opts.SizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field gen.n.class exceeds %d bytes", opts.SizeMax))
 		}
-		copy(v, data[start:i])
-		o.A = v
+		buf = append(buf, 1)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Class...)
+	}
 
-		header = data[i]
-		i++
+	buf = append(buf, 0x7f)
+	if len(buf)-len(dst) > opts.SizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct gen.n exceeds %d bytes", opts.SizeMax))
 	}
+	return buf, nil
+}
 
-	if header == 10 {
-		o.O = new(O)
-		n, err := o.O.Unmarshal(data[i:])
-		if err != nil {
-			if err == io.EOF && len(data) >= ColferSizeMax {
-				return 0, ColferMax(fmt.Sprintf("colfer: gen.o size exceeds %d bytes", ColferSizeMax))
-			}
-			return 0, err
-		}
-		i += n
+// ColferHash writes the Colfer serial of o to h, without materializing the
+// serial as a whole. Equal values produce the same input for h in each of the
+// supported languages.
+// The error return option is gen.ColferMax.
+func (o *Renamed) ColferHash(h hash.Hash) error {
+	_, err := o.ColferHashWith(h, colferOptions())
+	return err
+}
 
-		if i >= len(data) {
-			goto eof
+// ColferHashWith is like ColferHash, yet with the limits of opts instead of the
+// package-level configuration attributes. The return is the number of bytes
+// written to h. When an error occurs, then the state of h is undefined.
+// The error return option is gen.ColferMax.
+func (o *Renamed) ColferHashWith(h hash.Hash, opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	var scratch [32]byte
+	buf := scratch[:0]
+	var n int
+
+	if x := o.ID; x >= 1<<21 {
+		buf = append(buf, 0|0x80, 0, 0, 0, 0)
+		intconv.PutUint32(buf[len(buf)-4:], x)
+	} else if x != 0 {
+		buf = append(buf, 0)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
 		}
-		header = data[i]
-		i++
+		buf = append(buf, byte(x))
 	}
 
-	if header == 11 {
-		if i >= len(data) {
-			goto eof
+	if l := len(o.Class); l != 0 {
+		if l > opts.SizeMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field gen.n.class exceeds %d bytes", opts.SizeMax))
 		}
-		x := uint(data[i])
-		i++
-
-		if x >= 0x80 {
-			x &= 0x7f
-			for shift := uint(7); ; shift += 7 {
-				if i >= len(data) {
-					goto eof
-				}
-				b := uint(data[i])
-				i++
-
-				if b < 0x80 {
-					x |= b << shift
-					break
-				}
-				x |= (b & 0x7f) << shift
-			}
+		buf = append(buf, 1)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
 		}
+		buf = append(buf, byte(x))
+		h.Write(buf)
+		n += len(buf) + l
+		buf = buf[:0]
+		io.WriteString(h, o.Class)
+	}
 
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.os length %d exceeds %d elements", x, ColferListMax))
-		}
+	buf = append(buf, 0x7f)
+	h.Write(buf)
+	n += len(buf)
+	if n > opts.SizeMax {
+		return n, ColferMax(fmt.Sprintf("colfer: struct gen.n exceeds %d bytes", opts.SizeMax))
+	}
+	return n, nil
+}
 
-		l := int(x)
-		a := make([]*O, l)
-		malloc := make([]O, l)
-		for ai := range a {
-			v := &malloc[ai]
-			a[ai] = v
+// Unmarshal decodes data as Colfer and returns the number of bytes read.
+// The error return options are io.EOF, gen.ColferError and gen.ColferMax.
+func (o *Renamed) Unmarshal(data []byte) (int, error) {
+	return o.UnmarshalWith(data, colferOptions())
+}
 
-			n, err := v.Unmarshal(data[i:])
-			if err != nil {
-				if err == io.EOF && len(data) >= ColferSizeMax {
-					return 0, ColferMax(fmt.Sprintf("colfer: gen.o size exceeds %d bytes", ColferSizeMax))
-				}
-				return 0, err
-			}
-			i += n
-		}
-		o.Os = a
+// UnmarshalNoCopy is like Unmarshal, yet the text and binary fields, including
+// those of nested data structures, share memory with data instead of holding a
+// copy. Any modification to data is visible through o, and vice versa, for as
+// long as o is in use. The caller must not reuse or recycle data (buffers)
+// before o and all of the values read from it are no longer referenced.
+// The error return options are io.EOF, gen.ColferError and gen.ColferMax.
+func (o *Renamed) UnmarshalNoCopy(data []byte) (int, error) {
+	opts := colferOptions()
+	opts.NoCopy = true
+	return o.UnmarshalWith(data, opts)
+}
 
-		if i >= len(data) {
-			goto eof
-		}
-		header = data[i]
-		i++
+// UnmarshalWith is like Unmarshal, yet with the limits and the field selection
+// of opts instead of the package-level configuration attributes.
+// The error return options are io.EOF, gen.ColferError, gen.ColferMax,
+// with opts.Strict, gen.ColferNonCanonical and, with opts.Validate,
+// gen.ColferInvalid.
+func (o *Renamed) UnmarshalWith(data []byte, opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	if len(data) == 0 {
+		return 0, io.EOF
 	}
+	header := data[0]
+	i := 1
 
-	if header == 12 {
-		if i >= len(data) {
-			goto eof
-		}
-		x := uint(data[i])
-		i++
-
-		if x >= 0x80 {
-			x &= 0x7f
-			for shift := uint(7); ; shift += 7 {
+	if header&0x7f == 0 && !opts.selects("id") {
+		if header&0x80 != 0 {
+			i += 4
+		} else {
+			for {
 				if i >= len(data) {
 					goto eof
 				}
-				b := uint(data[i])
+				b := data[i]
 				i++
-
 				if b < 0x80 {
-					x |= b << shift
 					break
 				}
-				x |= (b & 0x7f) << shift
-			}
-		}
-
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.ss length %d exceeds %d elements", x, ColferListMax))
-		}
-		a := make([]string, int(x))
-		o.Ss = a
-
-		for ai := range a {
-			if i >= len(data) {
-				goto eof
-			}
-			x := uint(data[i])
-			i++
-
-			if x >= 0x80 {
-				x &= 0x7f
-				for shift := uint(7); ; shift += 7 {
-					if i >= len(data) {
-						goto eof
-					}
-					b := uint(data[i])
-					i++
-
-					if b < 0x80 {
-						x |= b << shift
-						break
-					}
-					x |= (b & 0x7f) << shift
-				}
-			}
-
-			if x > uint(ColferSizeMax) {
-				return 0, ColferMax(fmt.Sprintf("colfer: gen.o.ss element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
 			}
-
-			start := i
-			i += int(x)
-			if i >= len(data) {
-				goto eof
-			}
-			a[ai] = string(data[start:i])
 		}
 
 		if i >= len(data) {
@@ -1031,21 +7643,23 @@ func (o *O) Unmarshal(data []byte) (int, error) {
 		i++
 	}
 
-	if header == 13 {
+	if header == 0 {
+		at := i - 1
+		start := i
+		i++
 		if i >= len(data) {
 			goto eof
 		}
-		x := uint(data[i])
-		i++
+		x := uint32(data[start])
 
 		if x >= 0x80 {
 			x &= 0x7f
 			for shift := uint(7); ; shift += 7 {
+				b := uint32(data[i])
+				i++
 				if i >= len(data) {
 					goto eof
 				}
-				b := uint(data[i])
-				i++
 
 				if b < 0x80 {
 					x |= b << shift
@@ -1054,90 +7668,65 @@ func (o *O) Unmarshal(data []byte) (int, error) {
 				x |= (b & 0x7f) << shift
 			}
 		}
-
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.as length %d exceeds %d elements", x, ColferListMax))
-		}
-		a := make([][]byte, int(x))
-		o.As = a
-		for ai := range a {
-			if i >= len(data) {
-				goto eof
-			}
-			x := uint(data[i])
-			i++
-
-			if x >= 0x80 {
-				x &= 0x7f
-				for shift := uint(7); ; shift += 7 {
-					if i >= len(data) {
-						goto eof
-					}
-					b := uint(data[i])
-					i++
-
-					if b < 0x80 {
-						x |= b << shift
-						break
-					}
-					x |= (b & 0x7f) << shift
+		o.ID = x
+
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.ID; x >= 1<<21 {
+				buf = append(buf, 0|0x80, 0, 0, 0, 0)
+				intconv.PutUint32(buf[len(buf)-4:], x)
+			} else if x != 0 {
+				buf = append(buf, 0)
+				for x >= 0x80 {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
 				}
+				buf = append(buf, byte(x))
 			}
 
-			if x > uint(ColferSizeMax) {
-				return 0, ColferMax(fmt.Sprintf("colfer: gen.o.as element %d size %d exceeds %d bytes", ai, x, ColferSizeMax))
-			}
-			v := make([]byte, int(x))
-
-			start := i
-			i += len(v)
-			if i >= len(data) {
-				goto eof
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
 			}
-
-			copy(v, data[start:i])
-			a[ai] = v
 		}
 
-		if i >= len(data) {
-			goto eof
-		}
 		header = data[i]
 		i++
-	}
-
-	if header == 14 {
+	} else if header == 0|0x80 {
+		at := i - 1
 		start := i
-		i++
+		i += 4
 		if i >= len(data) {
 			goto eof
 		}
-		o.U8 = data[start]
-		header = data[i]
-		i++
-	}
+		o.ID = intconv.Uint32(data[start:])
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.ID; x >= 1<<21 {
+				buf = append(buf, 0|0x80, 0, 0, 0, 0)
+				intconv.PutUint32(buf[len(buf)-4:], x)
+			} else if x != 0 {
+				buf = append(buf, 0)
+				for x >= 0x80 {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
 
-	if header == 15 {
-		start := i
-		i += 2
-		if i >= len(data) {
-			goto eof
-		}
-		o.U16 = intconv.Uint16(data[start:])
-		header = data[i]
-		i++
-	} else if header == 15|0x80 {
-		start := i
-		i++
-		if i >= len(data) {
-			goto eof
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
 		}
-		o.U16 = uint16(data[start])
+
 		header = data[i]
 		i++
 	}
 
-	if header == 16 {
+	if header == 1 && !opts.selects("class") {
 		if i >= len(data) {
 			goto eof
 		}
@@ -1154,6 +7743,9 @@ func (o *O) Unmarshal(data []byte) (int, error) {
 				i++
 
 				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
 					x |= b << shift
 					break
 				}
@@ -1161,28 +7753,19 @@ func (o *O) Unmarshal(data []byte) (int, error) {
 			}
 		}
 
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.f32s length %d exceeds %d elements", x, ColferListMax))
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.n.class size %d exceeds %d bytes", x, opts.SizeMax))
 		}
+		i += int(x)
 
-		l := int(x)
-
-		if end := i + l*4; end >= len(data) {
-			i = end
+		if i >= len(data) {
 			goto eof
 		}
-		a := make([]float32, l)
-		for ai := range a {
-			a[ai] = math.Float32frombits(intconv.Uint32(data[i:]))
-			i += 4
-		}
-		o.F32s = a
-
 		header = data[i]
 		i++
 	}
 
-	if header == 17 {
+	if header == 1 {
 		if i >= len(data) {
 			goto eof
 		}
@@ -1199,6 +7782,9 @@ func (o *O) Unmarshal(data []byte) (int, error) {
 				i++
 
 				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
 					x |= b << shift
 					break
 				}
@@ -1206,21 +7792,31 @@ func (o *O) Unmarshal(data []byte) (int, error) {
 			}
 		}
 
-		if x > uint(ColferListMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: gen.o.f64s length %d exceeds %d elements", x, ColferListMax))
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: gen.n.class size %d exceeds %d bytes", x, opts.SizeMax))
 		}
-		l := int(x)
 
-		if end := i + l*8; end >= len(data) {
-			i = end
+		start := i
+		i += int(x)
+		if i >= len(data) {
 			goto eof
 		}
-		a := make([]float64, l)
-		for ai := range a {
-			a[ai] = math.Float64frombits(intconv.Uint64(data[i:]))
-			i += 8
+		if opts.Strict {
+			if n := colferUTF8Len(data[start:i]); n < int(x) {
+				return 0, ColferNonCanonical(start + n)
+			}
+		}
+		if opts.NoCopy {
+			o.Class = colferNoCopyString(data[start:i])
+		} else {
+			if err := opts.charge("gen.n.class", int(x)); err != nil {
+				return 0, err
+			}
+			o.Class = string(data[start:i])
 		}
-		o.F64s = a
 
 		header = data[i]
 		i++
@@ -1229,22 +7825,326 @@ func (o *O) Unmarshal(data []byte) (int, error) {
 	if header != 0x7f {
 		return 0, ColferError(i - 1)
 	}
-	if i < ColferSizeMax {
+	if i < opts.SizeMax {
 		return i, nil
 	}
 eof:
-	if i >= ColferSizeMax {
-		return 0, ColferMax(fmt.Sprintf("colfer: struct gen.o size exceeds %d bytes", ColferSizeMax))
+	if i >= opts.SizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct gen.n size exceeds %d bytes", opts.SizeMax))
 	}
 	return 0, io.EOF
 }
 
+// HasColferPath returns whether path locates a field in the schema, with the
+// names separated by dots, e.g., "o.s". See ColferOptions.Fields.
+func (*Renamed) HasColferPath(path string) bool {
+	name, nested := path, false
+	for i := 0; i < len(path); i++ {
+		if path[i] == '.' {
+			name, nested = path[:i], true
+			break
+		}
+	}
+
+	switch name {
+	case "id":
+		return !nested
+	case "class":
+		return !nested
+	}
+	return false
+}
+
 // UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
 // The error return options are io.EOF, gen.ColferError, gen.ColferTail and gen.ColferMax.
-func (o *O) UnmarshalBinary(data []byte) error {
+func (o *Renamed) UnmarshalBinary(data []byte) error {
 	i, err := o.Unmarshal(data)
 	if i < len(data) && err == nil {
 		return ColferTail(i)
 	}
 	return err
 }
+
+// Value returns the serial data conform database/sql/driver.Valuer, with nil
+// (NULL) for a nil o. The error return option is gen.ColferMax.
+func (o *Renamed) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+	return o.MarshalBinary()
+}
+
+// Scan decodes the serial data of a []byte or string conform database/sql.Scanner.
+// Any previous content of o is discarded, including on NULL, which reads as the
+// zero value. Scan into a **Renamed to get nil for NULL instead. The error
+// return options are io.ErrUnexpectedEOF, gen.ColferError,
+// gen.ColferTail and gen.ColferMax.
+func (o *Renamed) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*o = Renamed{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("colfer: struct gen.n scan from SQL type %T, want []byte or string", src)
+	}
+
+	// no carry-over from previous rows
+	*o = Renamed{}
+	err := o.UnmarshalBinary(data)
+	if err == io.EOF {
+		// column content is complete
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// MarshalJSON encodes o as JSON conform json.Marshaler. The members are named
+// after the schema fields and zero values are omitted. Timestamps are RFC 3339
+// strings in UTC and binaries are base64 strings. NaN and infinite floating
+// points are JSON strings too.
+func (o *Renamed) MarshalJSON() ([]byte, error) {
+	return o.MarshalJSONWith(colferOptions())
+}
+
+// MarshalJSONWith is like MarshalJSON, yet with the opts.JSONQuote64 mode,
+// including for nested data structures.
+func (o *Renamed) MarshalJSONWith(opts ColferOptions) ([]byte, error) {
+	buf := []byte{'{'}
+
+	if x := o.ID; x != 0 {
+		buf = append(buf, "\"id\":"...)
+		buf = strconv.AppendUint(buf, uint64(x), 10)
+		buf = append(buf, ',')
+	}
+
+	if len(o.Class) != 0 {
+		// infallible for strings and byte slices
+		b, _ := json.Marshal(o.Class)
+		buf = append(buf, "\"class\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+
+	if len(buf) == 1 {
+		return append(buf, '}'), nil
+	}
+	buf[len(buf)-1] = '}'
+	return buf, nil
+}
+
+// UnmarshalJSON decodes data as JSON conform json.Unmarshaler, with the same
+// mapping as MarshalJSON. Integers may also be JSON strings with a decimal
+// value, and JSON null equals the zero value. Unknown members are rejected.
+// The error return option is gen.ColferMax, next to the JSON errors.
+func (o *Renamed) UnmarshalJSON(data []byte) error {
+	return o.UnmarshalJSONWith(data, colferOptions())
+}
+
+// UnmarshalJSONWith is like UnmarshalJSON, yet with the list limit of opts
+// instead of the package-level configuration attribute, including for nested
+// data structures.
+func (o *Renamed) UnmarshalJSONWith(data []byte, opts ColferOptions) error {
+	opts = opts.limits()
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	for name, raw := range members {
+		switch name {
+		case "id":
+			x, err := strconv.ParseUint(colferJSONNumber(raw), 10, 32)
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.n.id: %s", err)
+			}
+			o.ID = uint32(x)
+		case "class":
+			// JSON null has no effect on the decode
+			o.Class = ""
+			if err := json.Unmarshal(raw, &o.Class); err != nil {
+				return fmt.Errorf("colfer: JSON for field gen.n.class: %s", err)
+			}
+		default:
+			return fmt.Errorf("colfer: JSON member %q not in struct gen.n", name)
+		}
+	}
+	return nil
+}
+
+// Equal returns whether o and other have the same Colfer serial. Nil and empty
+// lists are equal, nil list entries equal the zero value, timestamps compare
+// as instants, and floating points compare by their bits, except for zero.
+func (o *Renamed) Equal(other *Renamed) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+
+	if o.ID != other.ID {
+		return false
+	}
+
+	if o.Class != other.Class {
+		return false
+	}
+
+	return true
+}
+
+// Diff returns the fields which differ between o and other in schema order,
+// with o as the old values. Data structures compare field by field and lists
+// compare element by element, conform Equal. A nil o or other compares as the
+// zero value.
+func (o *Renamed) Diff(other *Renamed) []ColferDiff {
+	if o == nil {
+		o = new(Renamed)
+	}
+	if other == nil {
+		other = new(Renamed)
+	}
+	var diffs []ColferDiff
+
+	if a, b := o.ID, other.ID; a != b {
+		diffs = append(diffs, ColferDiff{Path: "id", Old: a, New: b})
+	}
+
+	if a, b := o.Class, other.Class; a != b {
+		diffs = append(diffs, ColferDiff{Path: "class", Old: a, New: b})
+	}
+
+	return diffs
+}
+
+// Clone returns a deep copy of o, or nil when o is nil.
+func (o *Renamed) Clone() *Renamed {
+	if o == nil {
+		return nil
+	}
+	c := *o
+
+	return &c
+}
+
+// Merge copies the non-zero fields of other into o. Nested data structures
+// merge recursively, and lists are replaced with a copy.
+func (o *Renamed) Merge(other *Renamed) {
+	if other == nil {
+		return
+	}
+
+	if other.ID != 0 {
+		o.ID = other.ID
+	}
+
+	if other.Class != "" {
+		o.Class = other.Class
+	}
+}
+
+// String returns a human-readable form for debugging, with the sensitive
+// fields masked.
+func (o *Renamed) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("gen.Renamed{ID:%v Class:%q}", o.ID, o.Class)
+}
+
+// GoString returns the Go syntax for debugging, with the sensitive fields
+// masked.
+func (o *Renamed) GoString() string {
+	if o == nil {
+		return "(*gen.Renamed)(nil)"
+	}
+	return fmt.Sprintf("&gen.Renamed{ID:%#v, Class:%#v}", o.ID, o.Class)
+}
+
+// Validate checks the fields against the schema rules, including the ones of
+// nested data structures. A nil o validates as the zero value.
+// The error return option is gen.ColferInvalid.
+func (o *Renamed) Validate() error {
+	if o == nil {
+		o = new(Renamed)
+	}
+	return nil
+}
+
+// ColferType returns the qualified schema name conform ColferAny.
+func (*Renamed) ColferType() string { return "gen.n" }
+
+func (*Renamed) colferNew(opts ColferOptions, field string) (ColferAny, error) {
+	if err := opts.charge(field, int(unsafe.Sizeof(Renamed{}))); err != nil {
+		return nil, err
+	}
+	return new(Renamed), nil
+}
+
+func (o *Renamed) colferEqual(other ColferAny) bool {
+	p, ok := other.(*Renamed)
+	return ok && o.Equal(p)
+}
+
+func (o *Renamed) colferClone() ColferAny { return o.Clone() }
+
+func (o *Renamed) colferMerge(other ColferAny) ColferAny {
+	p, ok := other.(*Renamed)
+	if !ok {
+		return other.colferClone()
+	}
+	o.Merge(p)
+	return o
+}
+
+func (o *Renamed) colferDiff(other ColferAny) []ColferDiff {
+	p, _ := other.(*Renamed)
+	return o.Diff(p)
+}
+
+// colferJSONNumber returns the text of a JSON number, or the content of a JSON
+// string. JSON null reads as zero.
+func colferJSONNumber(raw json.RawMessage) string {
+	switch {
+	case string(raw) == "null":
+		return "0"
+	case len(raw) > 1 && raw[0] == '"':
+		return string(raw[1 : len(raw)-1])
+	}
+	return string(raw)
+}
+
+// appendColferJSONFloat appends f as a JSON number, or as a JSON string for
+// NaN and the infinities.
+func appendColferJSONFloat(buf []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(buf, "\"NaN\""...)
+	case math.IsInf(f, 1):
+		return append(buf, "\"Infinity\""...)
+	case math.IsInf(f, -1):
+		return append(buf, "\"-Infinity\""...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize)
+}
+
+// parseColferJSONFloat reads a JSON number or one of the JSON strings "NaN",
+// "Infinity" and "-Infinity". JSON null reads as zero.
+func parseColferJSONFloat(raw json.RawMessage, bitSize int) (float64, error) {
+	switch string(raw) {
+	case "null":
+		return 0, nil
+	case "\"NaN\"":
+		return math.NaN(), nil
+	case "\"Infinity\"":
+		return math.Inf(1), nil
+	case "\"-Infinity\"":
+		return math.Inf(-1), nil
+	}
+	if len(raw) != 0 && raw[0] == '"' {
+		return 0, fmt.Errorf("JSON string %s not a floating point", raw)
+	}
+	return strconv.ParseFloat(string(raw), bitSize)
+}
@@ -1,19 +1,28 @@
 package testdata
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"math"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/pascaldekloe/goe/verify"
 
+	"github.com/pascaldekloe/colfer"
 	"github.com/pascaldekloe/colfer/go/gen"
+	"github.com/pascaldekloe/colfer/rt"
 )
 
 type golden struct {
@@ -97,6 +106,109 @@ func TestMarshal(t *testing.T) {
 	}
 }
 
+func TestAppendColfer(t *testing.T) {
+	prefix := []byte{0xc0, 0x1f}
+	for _, gold := range newGoldenCases() {
+		data, err := gold.object.AppendColfer(prefix[:len(prefix):len(prefix)])
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		if !bytes.HasPrefix(data, prefix) {
+			t.Errorf("0x%s: got 0x%x, want prefix 0x%x", gold.serial, data, prefix)
+			continue
+		}
+		if got := hex.EncodeToString(data[len(prefix):]); got != gold.serial {
+			t.Errorf("Got 0x%s, want 0x%s", got, gold.serial)
+		}
+	}
+}
+
+func TestAppendColferMax(t *testing.T) {
+	origSize, origList := gen.ColferSizeMax, gen.ColferListMax
+	defer func() {
+		gen.ColferSizeMax, gen.ColferListMax = origSize, origList
+	}()
+	gen.ColferSizeMax, gen.ColferListMax = 8, 2
+
+	golden := []*gen.O{
+		{S: "12345678"},
+		{A: []byte("123456")},
+		{Ss: []string{"a", "b", "c"}},
+		{O: &gen.O{S: "12345"}},
+		{Os: []*gen.O{nil, {S: "123"}}},
+	}
+	for _, o := range golden {
+		dst := []byte{0x00}
+		got, err := o.AppendColfer(dst)
+		if _, ok := err.(gen.ColferMax); !ok {
+			t.Errorf("%+v: got error %v, want gen.ColferMax", o, err)
+		}
+		if len(got) != len(dst) {
+			t.Errorf("%+v: got %d bytes with error, want original %d", o, len(got), len(dst))
+		}
+		if _, err := o.MarshalLen(); err == nil {
+			t.Errorf("%+v: MarshalLen did not error", o)
+		}
+	}
+}
+
+func TestColferHash(t *testing.T) {
+	for _, gold := range newGoldenCases() {
+		serial, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := sha256.Sum256(serial)
+
+		h := sha256.New()
+		n, err := gold.object.ColferHashWith(h, gen.ColferOptions{SizeMax: gen.ColferSizeMax, ListMax: gen.ColferListMax})
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		if n != len(serial) {
+			t.Errorf("0x%s: wrote %d bytes, want %d", gold.serial, n, len(serial))
+		}
+		if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Errorf("0x%s: got hash 0x%x, want 0x%x", gold.serial, got, want)
+		}
+	}
+
+	// nil entries hash as the zero value, without modification
+	withNil := &gen.O{Os: []*gen.O{nil}}
+	a, b := sha256.New(), sha256.New()
+	if err := withNil.ColferHash(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&gen.O{Os: []*gen.O{{}}}).ColferHash(b); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a.Sum(nil), b.Sum(nil)) {
+		t.Error("nil list entry hash differs from the zero value")
+	}
+	if withNil.Os[0] != nil {
+		t.Error("nil list entry was replaced")
+	}
+}
+
+func TestColferHashMax(t *testing.T) {
+	opts := gen.ColferOptions{SizeMax: 8, ListMax: 2}
+	golden := []*gen.O{
+		{S: "12345678"},
+		{A: []byte("123456")},
+		{Ss: []string{"a", "b", "c"}},
+		{O: &gen.O{S: "12345"}},
+		{Os: []*gen.O{nil, {S: "123"}}},
+	}
+	for _, o := range golden {
+		_, err := o.ColferHashWith(sha256.New(), opts)
+		if _, ok := err.(gen.ColferMax); !ok {
+			t.Errorf("%+v: got error %v, want gen.ColferMax", o, err)
+		}
+	}
+}
+
 func TestUnmarshal(t *testing.T) {
 	for _, gold := range newGoldenCases() {
 		data, err := hex.DecodeString(gold.serial)
@@ -113,6 +225,316 @@ func TestUnmarshal(t *testing.T) {
 	}
 }
 
+func TestRuntimeErrors(t *testing.T) {
+	var m rt.Message = new(gen.O)
+
+	_, err := m.Unmarshal([]byte{0x7e})
+	var mismatch rt.ColferError
+	if !errors.As(err, &mismatch) || mismatch != 0 {
+		t.Errorf("got error %#v for unknown header, want rt.ColferError(0)", err)
+	}
+
+	err = m.(*gen.O).UnmarshalBinary([]byte{0x7f, 0x7f})
+	var tail rt.ColferTail
+	if !errors.As(err, &tail) || tail != 1 {
+		t.Errorf("got error %#v for continuation, want rt.ColferTail(1)", err)
+	}
+
+	orig := gen.ColferSizeMax
+	defer func() {
+		gen.ColferSizeMax = orig
+	}()
+	gen.ColferSizeMax = 2
+
+	_, err = (&gen.O{S: "hello"}).MarshalLen()
+	var max rt.ColferMax
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &max) {
+		t.Errorf("got error %#v for size breach, want rt.ColferMax", err)
+	}
+}
+
+func TestUnmarshalWith(t *testing.T) {
+	// gen.o with o {os [{}, {}]} and ss ["a", "b"]
+	data := []byte{0x0a, 0x0b, 0x02, 0x7f, 0x7f, 0x7f, 0x0c, 0x02, 0x01, 'a', 0x01, 'b', 0x7f}
+
+	golden := []struct {
+		opts gen.ColferOptions
+		want string // error
+	}{
+		{gen.ColferOptions{SizeMax: len(data) + 1, ListMax: 2}, ""},
+		{gen.ColferOptions{SizeMax: len(data) + 1, ListMax: 2, DepthMax: 3}, ""},
+		{gen.ColferOptions{SizeMax: len(data), ListMax: 2}, "colfer: struct gen.o size exceeds 13 bytes"},
+		{gen.ColferOptions{SizeMax: len(data) + 1, ListMax: 1}, "colfer: gen.o.os length 2 exceeds 1 elements"},
+		{gen.ColferOptions{SizeMax: len(data) + 1, ListMax: 2, DepthMax: 2}, "colfer: field gen.o.os exceeds the nesting depth limit"},
+		{gen.ColferOptions{SizeMax: len(data) + 1, ListMax: 2, DepthMax: 1}, "colfer: field gen.o.o exceeds the nesting depth limit"},
+		// zero limits take the package-level configuration attributes
+		{gen.ColferOptions{}, ""},
+		{gen.ColferOptions{ListMax: 1}, "colfer: gen.o.os length 2 exceeds 1 elements"},
+		{gen.ColferOptions{SizeMax: len(data)}, "colfer: struct gen.o size exceeds 13 bytes"},
+		{gen.ColferOptions{DepthMax: -1}, ""},
+	}
+	for _, gold := range golden {
+		var got gen.O
+		n, err := got.UnmarshalWith(data, gold.opts)
+		switch {
+		case gold.want == "" && err != nil:
+			t.Errorf("%+v: got error %q", gold.opts, err)
+		case gold.want == "" && n != len(data):
+			t.Errorf("%+v: read %d bytes, want %d", gold.opts, n, len(data))
+		case gold.want != "" && (err == nil || err.Error() != gold.want):
+			t.Errorf("%+v: got error %v, want %q", gold.opts, err, gold.want)
+		}
+	}
+
+	if gen.ColferSizeMax != 16*1024*1024 || gen.ColferListMax != 64*1024 {
+		t.Error("package-level configuration attributes modified")
+	}
+}
+
+func TestColferOptionsZero(t *testing.T) {
+	origSize, origList, origDepth := gen.ColferSizeMax, gen.ColferListMax, gen.ColferDepthMax
+	defer func() {
+		gen.ColferSizeMax, gen.ColferListMax, gen.ColferDepthMax = origSize, origList, origDepth
+	}()
+
+	// gen.o with o {os [{}, {}]} and ss ["a", "b"]
+	data := []byte{0x0a, 0x0b, 0x02, 0x7f, 0x7f, 0x7f, 0x0c, 0x02, 0x01, 'a', 0x01, 'b', 0x7f}
+	o := &gen.O{O: &gen.O{Os: []*gen.O{{}, {}}}, Ss: []string{"a", "b"}}
+
+	gen.ColferListMax = 1
+	if _, err := new(gen.O).UnmarshalWith(data, gen.ColferOptions{}); err == nil {
+		t.Error("unmarshal with zero options passed ColferListMax")
+	}
+	gen.ColferListMax = origList
+
+	gen.ColferDepthMax = 2
+	if _, err := new(gen.O).UnmarshalWith(data, gen.ColferOptions{}); err == nil {
+		t.Error("unmarshal with zero options passed ColferDepthMax")
+	}
+	if _, err := new(gen.O).UnmarshalWith(data, gen.ColferOptions{DepthMax: -1}); err != nil {
+		t.Errorf("unmarshal with negative DepthMax: %s", err)
+	}
+	gen.ColferDepthMax = origDepth
+
+	gen.ColferSizeMax = len(data) - 1
+	if _, err := o.MarshalLenWith(gen.ColferOptions{}); err == nil {
+		t.Error("marshal length with zero options passed ColferSizeMax")
+	}
+	if _, err := o.AppendColferWith(nil, gen.ColferOptions{}); err == nil {
+		t.Error("append with zero options passed ColferSizeMax")
+	}
+	if _, err := o.ColferHashWith(sha256.New(), gen.ColferOptions{}); err == nil {
+		t.Error("hash with zero options passed ColferSizeMax")
+	}
+	gen.ColferSizeMax = origSize
+
+	if _, err := o.MarshalLenWith(gen.ColferOptions{}); err != nil {
+		t.Errorf("marshal length with zero options: %s", err)
+	}
+	if err := new(gen.O).UnmarshalJSONWith([]byte(`{"ss":["a","b"]}`), gen.ColferOptions{}); err != nil {
+		t.Errorf("unmarshal JSON with zero options: %s", err)
+	}
+}
+
+func TestUnmarshalFields(t *testing.T) {
+	all := []string{"b", "u32", "u64", "i32", "i64", "f32", "f64", "t", "s", "a", "o", "os", "ss", "as", "u8", "u16", "f32s", "f64s"}
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got gen.O
+		opts := gen.ColferOptions{SizeMax: gen.ColferSizeMax, ListMax: gen.ColferListMax, Fields: all}
+		if n, err := got.UnmarshalWith(data, opts); err != nil {
+			t.Errorf("0x%s: got error %q with all fields", gold.serial, err)
+		} else if n != len(data) {
+			t.Errorf("0x%s: read %d bytes with all fields, want %d", gold.serial, n, len(data))
+		} else if !got.Equal(&gold.object) {
+			t.Errorf("0x%s: got %+v with all fields, want %+v", gold.serial, got, gold.object)
+		}
+
+		got = gen.O{}
+		opts.Fields = []string{}
+		if n, err := got.UnmarshalWith(data, opts); err != nil {
+			t.Errorf("0x%s: got error %q with no fields", gold.serial, err)
+		} else if n != len(data) {
+			t.Errorf("0x%s: read %d bytes with no fields, want %d", gold.serial, n, len(data))
+		} else if !got.Equal(&gen.O{}) {
+			t.Errorf("0x%s: got %+v with no fields, want the zero value", gold.serial, got)
+		}
+	}
+
+	data, err := (&gen.O{
+		S:  "skip",
+		A:  bytes.Repeat([]byte{9}, 1024),
+		O:  &gen.O{B: true, S: "x", Os: []*gen.O{{S: "y", U8: 1}}},
+		Ss: []string{"a", "b"},
+		U8: 2,
+	}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := gen.O{S: "keep"}
+	opts := gen.ColferOptions{SizeMax: gen.ColferSizeMax, ListMax: gen.ColferListMax, Fields: []string{"o.s", "o.os.u8", "ss"}}
+	if _, err := got.UnmarshalWith(data, opts); err != nil {
+		t.Fatal(err)
+	}
+	want := gen.O{
+		S:  "keep",
+		O:  &gen.O{S: "x", Os: []*gen.O{{U8: 1}}},
+		Ss: []string{"a", "b"},
+	}
+	if diffs := want.Diff(&got); len(diffs) != 0 {
+		t.Errorf("got differences with selected fields: %q", diffs)
+	}
+
+	opts.Fields = []string{"u8"}
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := got.UnmarshalWith(data, opts); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("UnmarshalWith did %g allocations for skipped fields, want 0", allocs)
+	}
+	if got.U8 != 2 {
+		t.Errorf("got u8 %d, want 2", got.U8)
+	}
+}
+
+func TestHasColferPath(t *testing.T) {
+	golden := map[string]bool{
+		"s":         true,
+		"o":         true,
+		"o.s":       true,
+		"os.o.f64s": true,
+		"":          false,
+		"x":         false,
+		"o.":        false,
+		"o.x":       false,
+		"s.b":       false,
+		".s":        false,
+	}
+	for path, want := range golden {
+		if got := new(gen.O).HasColferPath(path); got != want {
+			t.Errorf("%q: got %t, want %t", path, got, want)
+		}
+	}
+}
+
+func TestUnmarshalDepthMax(t *testing.T) {
+	orig := gen.ColferDepthMax
+	defer func() {
+		gen.ColferDepthMax = orig
+	}()
+
+	// three levels of gen.o in field o
+	data := []byte{0x0a, 0x0a, 0x7f, 0x7f, 0x7f}
+
+	gen.ColferDepthMax = 3
+	if _, err := new(gen.O).Unmarshal(data); err != nil {
+		t.Errorf("got error %q within the depth limit", err)
+	}
+
+	gen.ColferDepthMax = 2
+	_, err := new(gen.O).Unmarshal(data)
+	if _, ok := err.(gen.ColferMax); !ok {
+		t.Errorf("got error %v for depth breach, want gen.ColferMax", err)
+	}
+
+	// deep nesting must not exhaust the stack
+	gen.ColferDepthMax = orig
+	deep := bytes.Repeat([]byte{0x0a}, 1<<20)
+	_, err = new(gen.O).Unmarshal(deep)
+	if _, ok := err.(gen.ColferMax); !ok {
+		t.Errorf("got error %v for excessive nesting, want gen.ColferMax", err)
+	}
+}
+
+func TestMarshalWithDepth(t *testing.T) {
+	cycle := new(gen.O)
+	cycle.O = cycle
+
+	opts := gen.ColferOptions{SizeMax: gen.ColferSizeMax, ListMax: gen.ColferListMax, DepthMax: 8}
+	const want = "colfer: field gen.o.o exceeds the nesting depth limit"
+	if _, err := cycle.MarshalLenWith(opts); err == nil || err.Error() != want {
+		t.Errorf("MarshalLenWith got error %v, want %q", err, want)
+	}
+	if _, err := cycle.AppendColferWith(nil, opts); err == nil || err.Error() != want {
+		t.Errorf("AppendColferWith got error %v, want %q", err, want)
+	}
+}
+
+func TestUnmarshalNoCopy(t *testing.T) {
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := gen.O{}
+		n, err := got.UnmarshalNoCopy(data)
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+		if n != len(data) {
+			t.Errorf("0x%s: read %d bytes, want %d", gold.serial, n, len(data))
+		}
+		verify.Values(t, fmt.Sprintf("0x%s", gold.serial), got, gold.object)
+	}
+}
+
+func TestUnmarshalNoCopyAlias(t *testing.T) {
+	// gen.o with s "hello" and a 0xff, plus as [0x00] in a nested struct
+	data := []byte{0x08, 0x05, 'h', 'e', 'l', 'l', 'o', 0x09, 0x01, 0xff, 0x0a, 0x0d, 0x01, 0x01, 0x00, 0x7f, 0x7f}
+
+	var o gen.O
+	if _, err := o.UnmarshalNoCopy(data); err != nil {
+		t.Fatal(err)
+	}
+	data[2], data[9], data[14] = 'j', 0xfe, 0x01
+	if o.S != "jello" || o.A[0] != 0xfe || o.O.As[0][0] != 0x01 {
+		t.Errorf("got s %q, a %#x and o.as %#x, want modifications of the data", o.S, o.A, o.O.As)
+	}
+	if cap(o.A) != len(o.A) {
+		t.Errorf("got binary capacity %d, want %d to protect the data on append", cap(o.A), len(o.A))
+	}
+
+	o = gen.O{}
+	if _, err := o.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	data[2], data[9], data[14] = 'h', 0xff, 0x00
+	if o.S != "jello" || o.A[0] != 0xfe || o.O.As[0][0] != 0x01 {
+		t.Errorf("Unmarshal got s %q, a %#x and o.as %#x, want copies", o.S, o.A, o.O.As)
+	}
+}
+
+func TestUnmarshalNoCopyAllocs(t *testing.T) {
+	data := []byte{0x08, 0x05, 'h', 'e', 'l', 'l', 'o', 0x09, 0x01, 0xff, 0x7f}
+
+	var o gen.O
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := o.UnmarshalNoCopy(data); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("UnmarshalNoCopy did %g allocations for text and binary, want 0", allocs)
+	}
+
+	allocs = testing.AllocsPerRun(100, func() {
+		if _, err := o.Unmarshal(data); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 2 {
+		t.Errorf("Unmarshal did %g allocations for text and binary, want 2", allocs)
+	}
+}
+
 func TestUnmarshalEOF(t *testing.T) {
 	for _, gold := range newGoldenCases() {
 		data, err := hex.DecodeString(gold.serial)
@@ -129,6 +551,49 @@ func TestUnmarshalEOF(t *testing.T) {
 	}
 }
 
+func TestUnmarshalEmbedded(t *testing.T) {
+	golden := []struct {
+		serial string
+		m      string // hex
+		err    error
+	}{
+		{"7f", "", nil},
+		// o with u32 1
+		{"0003" + "01017f" + "7f", "01017f", nil},
+		// empty struct from any schema
+		{"00017f7f", "7f", nil},
+		// missing terminator
+		{"00020101" + "7f", "", gen.ColferError(3)},
+		{"0001007f", "", gen.ColferError(2)},
+	}
+
+	for _, gold := range golden {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var e gen.E
+		_, err = e.Unmarshal(data)
+		if err != gold.err {
+			t.Errorf("0x%s: got error %v, want %v", gold.serial, err, gold.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := hex.EncodeToString(e.M); got != gold.m {
+			t.Errorf("0x%s: got m 0x%s, want 0x%s", gold.serial, got, gold.m)
+		}
+		got, err := e.MarshalBinary()
+		if err != nil {
+			t.Errorf("0x%s: marshal error: %s", gold.serial, err)
+		} else if !bytes.Equal(got, data) {
+			t.Errorf("0x%s: marshal got 0x%x", gold.serial, got)
+		}
+	}
+}
+
 func TestUnmarshalSizeMax(t *testing.T) {
 	orig := gen.ColferSizeMax
 	defer func() {
@@ -168,6 +633,532 @@ func TestUnmarshalSizeMax(t *testing.T) {
 	}
 }
 
+func TestUnmarshalStrict(t *testing.T) {
+	opts := gen.ColferOptions{SizeMax: gen.ColferSizeMax, ListMax: gen.ColferListMax, Strict: true}
+
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := new(gen.O).UnmarshalWith(data, opts); err != nil {
+			t.Errorf("0x%s: got error %q", gold.serial, err)
+		}
+	}
+
+	golden := []struct {
+		serial string
+		want   string // error
+	}{
+		// explicit zero values
+		{"0e007f", "colfer: non-canonical encoding at byte 0"},
+		{"01007f", "colfer: non-canonical encoding at byte 0"},
+		{"83007f", "colfer: non-canonical encoding at byte 0"},
+		{"05000000007f", "colfer: non-canonical encoding at byte 0"},
+		{"05800000007f", "colfer: non-canonical encoding at byte 0"},
+		{"08007f", "colfer: non-canonical encoding at byte 0"},
+		{"09007f", "colfer: non-canonical encoding at byte 0"},
+		{"0b007f", "colfer: non-canonical encoding at byte 0"},
+		{"10007f", "colfer: non-canonical encoding at byte 0"},
+		// fixed size where compact applies, and vice versa
+		{"0f00017f", "colfer: non-canonical encoding at byte 0"},
+		{"81000000017f", "colfer: non-canonical encoding at byte 0"},
+		{"8200000000000000017f", "colfer: non-canonical encoding at byte 0"},
+		{"01808080017f", "colfer: non-canonical encoding at byte 0"},
+		{"870000000000000001000000007f", "colfer: non-canonical encoding at byte 0"},
+		// redundant varint bytes
+		{"0181007f", "colfer: non-canonical encoding at byte 1"},
+		{"088100617f", "colfer: non-canonical encoding at byte 2"},
+		// nanoseconds overflow into the seconds
+		{"07000000013b9aca007f", "colfer: non-canonical encoding at byte 4"},
+		// invalid UTF-8
+		{"080261ff7f", "colfer: non-canonical encoding at byte 3"},
+		{"0c020161016f7f", ""},
+		{"0c02016102c3287f", "colfer: non-canonical encoding at byte 5"},
+		// nested data structures
+		{"0a01007f7f", "colfer: non-canonical encoding at byte 1"},
+		{"0b0101007f7f", "colfer: non-canonical encoding at byte 2"},
+	}
+	for _, gold := range golden {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := new(gen.O).Unmarshal(data); err != nil {
+			t.Errorf("0x%s: got error %q without strict mode", gold.serial, err)
+		}
+
+		_, err = new(gen.O).UnmarshalWith(data, opts)
+		switch {
+		case gold.want == "" && err != nil:
+			t.Errorf("0x%s: got error %q", gold.serial, err)
+		case gold.want != "" && (err == nil || err.Error() != gold.want):
+			t.Errorf("0x%s: got error %v, want %q", gold.serial, err, gold.want)
+		}
+	}
+
+	// fields out of order are rejected regardless
+	_, err := new(gen.O).UnmarshalWith([]byte{0x01, 0x01, 0x00, 0x7f}, opts)
+	if _, ok := err.(gen.ColferError); !ok {
+		t.Errorf("got error %v for fields out of order, want gen.ColferError", err)
+	}
+}
+
+func TestUnmarshalAllocCorpus(t *testing.T) {
+	paths, err := filepath.Glob("../testdata/corpus/alloc-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no adversarial corpus")
+	}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err = new(gen.O).Unmarshal(data)
+		runtime.ReadMemStats(&after)
+
+		if _, ok := err.(gen.ColferMax); !ok && err != io.EOF {
+			t.Errorf("%s: got error %v, want io.EOF or gen.ColferMax", path, err)
+		}
+		// lists of 64k elements would take megabytes
+		if n := after.TotalAlloc - before.TotalAlloc; n > 64*1024 {
+			t.Errorf("%s: allocated %d bytes for %d bytes of input", path, n, len(data))
+		}
+	}
+}
+
+func TestUnmarshalBudget(t *testing.T) {
+	// gen.o with o {os [{}, {}]} and ss ["a", "b"]
+	data := []byte{0x0a, 0x0b, 0x02, 0x7f, 0x7f, 0x7f, 0x0c, 0x02, 0x01, 'a', 0x01, 'b', 0x7f}
+
+	opts := gen.ColferOptions{SizeMax: len(data) + 1, ListMax: 2}
+	budget := 1 << 20
+	opts.Budget = &budget
+	if _, err := new(gen.O).UnmarshalWith(data, opts); err != nil {
+		t.Fatal(err)
+	}
+	used := 1<<20 - budget
+	if used <= 0 {
+		t.Fatalf("budget reduced by %d bytes", used)
+	}
+
+	budget = used
+	if _, err := new(gen.O).UnmarshalWith(data, opts); err != nil {
+		t.Errorf("got error %q with the exact budget", err)
+	}
+	if budget != 0 {
+		t.Errorf("got %d bytes of budget left, want 0", budget)
+	}
+
+	budget = used - 1
+	_, err := new(gen.O).UnmarshalWith(data, opts)
+	if want := "colfer: field gen.o.ss exceeds the allocation budget"; err == nil || err.Error() != want {
+		t.Errorf("got error %v with a short budget, want %q", err, want)
+	}
+	if _, ok := err.(gen.ColferMax); !ok {
+		t.Errorf("got error type %T, want gen.ColferMax", err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	var want bytes.Buffer
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want.Write(data)
+	}
+
+	var stream bytes.Buffer
+	enc := gen.NewColferEncoder(&stream)
+	for _, gold := range newGoldenCases() {
+		if err := enc.Encode(&gold.object); err != nil {
+			t.Fatalf("0x%s: encode error: %s", gold.serial, err)
+		}
+	}
+	if got := stream.Bytes(); !bytes.Equal(got, want.Bytes()) {
+		t.Fatalf("encoded stream: got 0x%x\nwant 0x%x", got, want.Bytes())
+	}
+
+	dec := gen.NewColferDecoder(iotest.OneByteReader(&stream))
+	for _, gold := range newGoldenCases() {
+		var got gen.O
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("0x%s: decode error: %s", gold.serial, err)
+		}
+		verify.Values(t, fmt.Sprintf("0x%s", gold.serial), got, gold.object)
+	}
+	if err := dec.Decode(new(gen.O)); err != io.EOF {
+		t.Errorf("got error %v after the last serial, want io.EOF", err)
+	}
+
+	var o gen.O
+	scanner := bufio.NewScanner(bytes.NewReader(want.Bytes()))
+	scanner.Buffer(nil, gen.ColferSizeMax)
+	scanner.Split(gen.ColferSplit(&o))
+	for _, gold := range newGoldenCases() {
+		if !scanner.Scan() {
+			t.Fatalf("0x%s: scan stopped with error %v", gold.serial, scanner.Err())
+		}
+		if got := hex.EncodeToString(scanner.Bytes()); got != gold.serial {
+			t.Errorf("got token 0x%s, want 0x%s", got, gold.serial)
+		}
+		verify.Values(t, fmt.Sprintf("0x%s", gold.serial), o, gold.object)
+		o = gen.O{}
+	}
+	if scanner.Scan() {
+		t.Errorf("got token 0x%x after the last serial", scanner.Bytes())
+	} else if err := scanner.Err(); err != nil {
+		t.Errorf("got scan error %q after the last serial", err)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	// text field with one of five bytes
+	partial := []byte{0x7f, 0x08, 0x05, 'h'}
+
+	dec := gen.NewColferDecoder(bytes.NewReader(partial))
+	if err := dec.Decode(new(gen.O)); err != nil {
+		t.Fatal("first serial error:", err)
+	}
+	if err := dec.Decode(new(gen.O)); err != io.ErrUnexpectedEOF {
+		t.Errorf("got decode error %v, want io.ErrUnexpectedEOF", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(partial))
+	scanner.Split(gen.ColferSplit(new(gen.O)))
+	for scanner.Scan() {
+	}
+	if err := scanner.Err(); err != io.ErrUnexpectedEOF {
+		t.Errorf("got scan error %v, want io.ErrUnexpectedEOF", err)
+	}
+
+	orig := gen.ColferSizeMax
+	defer func() {
+		gen.ColferSizeMax = orig
+	}()
+	gen.ColferSizeMax = 4
+
+	dec = gen.NewColferDecoder(bytes.NewReader([]byte{0x08, 0x03, 'a', 'b', 'c', 0x7f}))
+	if err := dec.Decode(new(gen.O)); err == nil {
+		t.Error("no error for serial size exceeding ColferSizeMax")
+	} else if _, ok := err.(gen.ColferMax); !ok {
+		t.Errorf("got error %T for serial size exceeding ColferSizeMax, want gen.ColferMax: %q", err, err)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	golden := newGoldenCases()
+	for i, a := range golden {
+		for j, b := range golden {
+			if got := a.object.Equal(&b.object); got != (i == j) {
+				t.Errorf("0x%s: got equal %t for 0x%s", a.serial, got, b.serial)
+			}
+		}
+
+		data, err := hex.DecodeString(a.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got gen.O
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(&a.object) {
+			t.Errorf("0x%s: unmarshalled not equal", a.serial)
+		}
+	}
+
+	equal := []struct{ a, b *gen.O }{
+		{nil, nil},
+		{&gen.O{Ss: nil}, &gen.O{Ss: []string{}}},
+		{&gen.O{A: nil}, &gen.O{A: []byte{}}},
+		{&gen.O{Os: []*gen.O{nil}}, &gen.O{Os: []*gen.O{{}}}},
+		{&gen.O{T: time.Unix(1, 2)}, &gen.O{T: time.Unix(1, 2).In(time.FixedZone("X", 3600))}},
+		{&gen.O{F64: 0}, &gen.O{F64: math.Copysign(0, -1)}},
+	}
+	for _, c := range equal {
+		if !c.a.Equal(c.b) || !c.b.Equal(c.a) {
+			t.Errorf("%+v not equal to %+v", c.a, c.b)
+		}
+	}
+
+	notEqual := []struct{ a, b *gen.O }{
+		{nil, &gen.O{}},
+		{&gen.O{O: nil}, &gen.O{O: &gen.O{}}},
+		{&gen.O{T: time.Time{}}, &gen.O{T: time.Unix(0, 0)}},
+		{&gen.O{F32s: []float32{0}}, &gen.O{F32s: []float32{float32(math.Copysign(0, -1))}}},
+	}
+	for _, c := range notEqual {
+		if c.a.Equal(c.b) || c.b.Equal(c.a) {
+			t.Errorf("%+v equal to %+v", c.a, c.b)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	golden := newGoldenCases()
+	for _, a := range golden {
+		for _, b := range golden {
+			diffs := a.object.Diff(&b.object)
+			if equal := a.object.Equal(&b.object); equal != (len(diffs) == 0) {
+				t.Errorf("0x%s: got diffs %q with 0x%s, while equal is %t", a.serial, diffs, b.serial, equal)
+			}
+		}
+	}
+
+	a := &gen.O{
+		U8: 1,
+		S:  "same",
+		O:  &gen.O{Os: []*gen.O{{}, {S: "old"}}},
+		Ss: []string{"a", "b"},
+	}
+	b := &gen.O{
+		U8: 2,
+		S:  "same",
+		O:  &gen.O{Os: []*gen.O{nil, {S: "new"}, {B: true}}},
+		Ss: []string{"a"},
+		A:  []byte{7},
+	}
+	want := []string{
+		"a: [] -> [7]",
+		"o.os[1].s: old -> new",
+		"o.os[2]: <nil> -> b:true",
+		"ss[1]: b -> <nil>",
+		"u8: 1 -> 2",
+	}
+	var got []string
+	for _, d := range a.Diff(b) {
+		s := d.String()
+		if d.Path == "o.os[2]" {
+			s = fmt.Sprintf("%s: %v -> b:%t", d.Path, d.Old, d.New.(*gen.O).B)
+		}
+		got = append(got, s)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got diffs:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if diffs := (*gen.O)(nil).Diff(&gen.O{}); len(diffs) != 0 {
+		t.Errorf("nil got diffs %q with the zero value", diffs)
+	}
+}
+
+func TestClone(t *testing.T) {
+	for _, gold := range newGoldenCases() {
+		if c := gold.object.Clone(); !c.Equal(&gold.object) {
+			t.Errorf("0x%s: clone not equal", gold.serial)
+		}
+	}
+	if (*gen.O)(nil).Clone() != nil {
+		t.Error("clone of nil not nil")
+	}
+
+	o := &gen.O{
+		A:    []byte{1},
+		O:    &gen.O{S: "nested"},
+		Os:   []*gen.O{{U8: 2}, nil},
+		Ss:   []string{"a"},
+		As:   [][]byte{{3}},
+		F32s: []float32{4},
+		F64s: []float64{5},
+	}
+	c := o.Clone()
+	// MarshalBinary replaces nil entries
+	want, err := o.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.A[0]++
+	c.O.S = "changed"
+	c.Os[0].U8++
+	c.Ss[0] = "b"
+	c.As[0][0]++
+	c.F32s[0]++
+	c.F64s[0]++
+	if c.Os[1] != nil {
+		t.Error("nil list entry cloned as non-nil")
+	}
+
+	got, err := o.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("original changed with clone to 0x%x, want 0x%x", got, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	for _, gold := range newGoldenCases() {
+		o := new(gen.O)
+		o.Merge(&gold.object)
+		if !o.Equal(&gold.object) {
+			t.Errorf("0x%s: merge into zero value got %+v", gold.serial, o)
+		}
+
+		o.Merge(new(gen.O))
+		o.Merge(nil)
+		if !o.Equal(&gold.object) {
+			t.Errorf("0x%s: merge of zero value got %+v", gold.serial, o)
+		}
+	}
+
+	o := &gen.O{U8: 1, S: "keep", O: &gen.O{B: true}, Ss: []string{"a", "b"}}
+	o.Merge(&gen.O{U8: 2, O: &gen.O{U16: 3}, Ss: []string{"c"}, T: time.Unix(4, 5)})
+	want := &gen.O{U8: 2, S: "keep", O: &gen.O{B: true, U16: 3}, Ss: []string{"c"}, T: time.Unix(4, 5)}
+	if !o.Equal(want) {
+		t.Errorf("got %+v, want %+v", o, want)
+	}
+
+	// no memory shared with the source
+	src := &gen.O{A: []byte{1}, O: &gen.O{U8: 1}}
+	dst := new(gen.O)
+	dst.Merge(src)
+	dst.A[0]++
+	dst.O.U8++
+	if src.A[0] != 1 || src.O.U8 != 1 {
+		t.Errorf("merge source changed to %+v", src)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	packages, err := colfer.ParseFiles([]string{"../testdata/test.colf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := packages[0].Structs[0]
+
+	for _, gold := range newGoldenCases() {
+		doc, err := gold.object.MarshalJSON()
+		if y := gold.object.T.Year(); y < 0 || y > 9999 {
+			if err == nil {
+				t.Errorf("0x%s: no error for year %d", gold.serial, y)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+
+		// same mapping as the schema-based encoder
+		serial, err := colfer.EncodeJSON(schema, doc)
+		if err != nil {
+			t.Errorf("0x%s: encode %s: %s", gold.serial, doc, err)
+		} else if got := hex.EncodeToString(serial); got != gold.serial {
+			t.Errorf("%s: got 0x%s, want 0x%s", doc, got, gold.serial)
+		}
+
+		var got gen.O
+		if err := got.UnmarshalJSON(doc); err != nil {
+			t.Errorf("%s: %s", doc, err)
+			continue
+		}
+		verify.Values(t, string(doc), got, gold.object)
+	}
+}
+
+func TestJSONQuote64(t *testing.T) {
+	o := gen.O{U64: math.MaxUint64, I64: math.MinInt64, I32: -1, Os: []*gen.O{{I64: 1}}}
+	doc, err := o.MarshalJSONWith(gen.ColferOptions{JSONQuote64: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"u64":"18446744073709551615","i32":-1,"i64":"-9223372036854775808","os":[{"i64":"1"}]}`
+	if string(doc) != want {
+		t.Errorf("got %s, want %s", doc, want)
+	}
+
+	// per call only
+	doc, err = o.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const plain = `{"u64":18446744073709551615,"i32":-1,"i64":-9223372036854775808,"os":[{"i64":1}]}`
+	if string(doc) != plain {
+		t.Errorf("got %s, want %s", doc, plain)
+	}
+
+	var got gen.O
+	if err := got.UnmarshalJSON(doc); err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "quoted", got, o)
+}
+
+func TestUnmarshalJSONNull(t *testing.T) {
+	o := &gen.O{B: true, U32: 1, I64: -1, F64: 1.5, T: time.Unix(1, 0), S: "x", A: []byte{1}, O: new(gen.O), Os: []*gen.O{nil}, Ss: []string{"y"}, F32s: []float32{2}}
+	doc, err := o.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &members); err != nil {
+		t.Fatal(err)
+	}
+	for name := range members {
+		members[name] = json.RawMessage("null")
+	}
+	doc, err = json.Marshal(members)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// reuse must reset each field
+	if err := o.UnmarshalJSON(doc); err != nil {
+		t.Fatalf("%s: %s", doc, err)
+	}
+	verify.Values(t, string(doc), o, new(gen.O))
+}
+
+func TestUnmarshalJSONListMax(t *testing.T) {
+	doc := []byte(`{"o":{"ss":["a","b"]}}`)
+	if err := new(gen.O).UnmarshalJSON(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	err := new(gen.O).UnmarshalJSONWith(doc, gen.ColferOptions{ListMax: 1})
+	if want := "colfer: field gen.o.ss length 2 exceeds 1 elements"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+	if _, ok := err.(gen.ColferMax); !ok {
+		t.Errorf("got error type %T, want gen.ColferMax", err)
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	golden := []struct{ json, want string }{
+		{`[]`, "cannot unmarshal array"},
+		{`{"x": 1}`, `JSON member "x" not in struct gen.o`},
+		{`{"u8": 256}`, "JSON for field gen.o.u8"},
+		{`{"u32": -1}`, "JSON for field gen.o.u32"},
+		{`{"f64": "1"}`, "JSON for field gen.o.f64"},
+		{`{"t": "yesterday"}`, "JSON for field gen.o.t"},
+		{`{"o": {"os": [{"b": 1}]}}`, "JSON for field gen.o.b"},
+	}
+
+	for _, gold := range golden {
+		err := new(gen.O).UnmarshalJSON([]byte(gold.json))
+		if err == nil {
+			t.Errorf("%s: no error", gold.json)
+			continue
+		}
+		if !strings.Contains(err.Error(), gold.want) {
+			t.Errorf("%s: got error %q, want %q", gold.json, err, gold.want)
+		}
+	}
+}
+
 // TestFuzzSeed updates the initial input corpus for fuzz testing.
 func TestFuzzSeed(t *testing.T) {
 	for _, gold := range newGoldenCases() {
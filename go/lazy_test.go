@@ -0,0 +1,200 @@
+package testdata
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/pascaldekloe/colfer/go/gen"
+	lazy "github.com/pascaldekloe/colfer/go/lazy/gen"
+)
+
+func TestLazyGolden(t *testing.T) {
+	for _, gold := range newGoldenCases() {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var o lazy.O
+		if err := o.UnmarshalBinary(data); err != nil {
+			t.Errorf("0x%s: unmarshal error: %s", gold.serial, err)
+			continue
+		}
+		got, err := o.MarshalBinary()
+		if err != nil {
+			t.Errorf("0x%s: marshal error: %s", gold.serial, err)
+			continue
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("0x%s: marshal got 0x%x", gold.serial, got)
+		}
+
+		// decode everything
+		if _, err := o.O.Get(); err != nil {
+			t.Errorf("0x%s: got error %q for o", gold.serial, err)
+		}
+		for i, l := range o.Os {
+			if _, err := l.Get(); err != nil {
+				t.Errorf("0x%s: got error %q for os[%d]", gold.serial, err, i)
+			}
+		}
+		got, err = o.MarshalBinary()
+		if err != nil {
+			t.Errorf("0x%s: marshal error after decode: %s", gold.serial, err)
+		} else if !bytes.Equal(got, data) {
+			t.Errorf("0x%s: marshal got 0x%x after decode", gold.serial, got)
+		}
+	}
+}
+
+func TestLazyVerbatim(t *testing.T) {
+	// gen.o with o {u32 1} in a non-canonical fixed size encoding
+	data := []byte{0x0a, 0x81, 0x00, 0x00, 0x00, 0x01, 0x7f, 0x7f}
+
+	var o lazy.O
+	if err := o.UnmarshalBinary(data); err != nil {
+		t.Fatal("unmarshal error:", err)
+	}
+	got, err := o.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("got serial 0x%x for the untouched value, want 0x%x", got, data)
+	}
+
+	v, err := o.O.Get()
+	if err != nil {
+		t.Fatal("nested decode error:", err)
+	}
+	if v.U32 != 1 {
+		t.Errorf("got u32 %d, want 1", v.U32)
+	}
+	v.U32 = 2
+	got, err = o.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error after modification:", err)
+	}
+	if want := []byte{0x0a, 0x01, 0x02, 0x7f, 0x7f}; !bytes.Equal(got, want) {
+		t.Errorf("got serial 0x%x after modification, want 0x%x", got, want)
+	}
+
+	o.O.Set(nil)
+	got, err = o.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error after set:", err)
+	}
+	if want := []byte{0x0a, 0x7f, 0x7f}; !bytes.Equal(got, want) {
+		t.Errorf("got serial 0x%x after set nil, want 0x%x", got, want)
+	}
+}
+
+func TestLazyErrors(t *testing.T) {
+	golden := []string{
+		// nested data structure incomplete
+		"0a00",
+		// nested data structure with unknown header
+		"0a657f7f",
+		// nested list element with unknown header
+		"0b01657f7f",
+		// nested list element incomplete
+		"0b027f",
+	}
+	for _, serial := range golden {
+		data, err := hex.DecodeString(serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, want := new(gen.O).Unmarshal(data)
+		_, err = new(lazy.O).Unmarshal(data)
+		if err == nil || err.Error() != want.Error() {
+			t.Errorf("0x%s: got error %v, want %v", serial, err, want)
+		}
+	}
+}
+
+func TestLazyStrict(t *testing.T) {
+	// gen.o with o {u32 1} in a non-canonical fixed size encoding
+	data := []byte{0x0a, 0x81, 0x00, 0x00, 0x00, 0x01, 0x7f, 0x7f}
+
+	opts := lazy.ColferOptions{SizeMax: lazy.ColferSizeMax, ListMax: lazy.ColferListMax, Strict: true}
+	_, err := new(lazy.O).UnmarshalWith(data, opts)
+	if want := lazy.ColferNonCanonical(1); err != want {
+		t.Errorf("got error %v, want %v", err, want)
+	}
+}
+
+func TestLazyValidate(t *testing.T) {
+	r := &gen.R{Par: 4, Name: "ams", Next: &gen.R{Par: 3, Name: "nl"}}
+	data, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+
+	opts := lazy.ColferOptions{SizeMax: lazy.ColferSizeMax, ListMax: lazy.ColferListMax, Validate: true}
+	got := new(lazy.R)
+	if n, err := got.UnmarshalWith(data, opts); err != nil || n != len(data) {
+		t.Fatalf("got %d bytes and error %v, want %d bytes", n, err, len(data))
+	}
+
+	r.Next.Par = 1
+	data, err = r.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	_, err = new(lazy.R).UnmarshalWith(data, opts)
+	if want := (lazy.ColferInvalid{Field: "gen.r.par", Rule: "min 3"}); err != want {
+		t.Errorf("invalid next got error %v, want %v", err, want)
+	}
+}
+
+func TestLazyEqual(t *testing.T) {
+	a := &lazy.O{O: lazy.NewOLazy(&lazy.O{S: "x"})}
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := new(lazy.O)
+	if err := b.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !a.Equal(b) || !b.Equal(a) {
+		t.Error("decoded copy not equal")
+	}
+	if c := b.Clone(); !c.Equal(a) {
+		t.Error("clone not equal")
+	}
+
+	b.O.Set(&lazy.O{S: "y"})
+	if diffs := a.Diff(b); len(diffs) != 1 || diffs[0].String() != "o.s: x -> y" {
+		t.Errorf("got diffs %q", diffs)
+	}
+}
+
+func TestLazyHash(t *testing.T) {
+	// gen.o with o {u32 1} in a non-canonical fixed size encoding
+	data := []byte{0x0a, 0x81, 0x00, 0x00, 0x00, 0x01, 0x7f, 0x7f}
+
+	var eager gen.O
+	if err := eager.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	want := sha256.New()
+	if err := eager.ColferHash(want); err != nil {
+		t.Fatal(err)
+	}
+
+	var l lazy.O
+	if err := l.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	got := sha256.New()
+	if err := l.ColferHash(got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Sum(nil), want.Sum(nil)) {
+		t.Errorf("got hash %x, want %x", got.Sum(nil), want.Sum(nil))
+	}
+}
@@ -0,0 +1,168 @@
+package testdata
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pascaldekloe/colfer/go/gen"
+)
+
+func newValidR() *gen.R {
+	return &gen.R{Par: 4, Lat: 52.37, Name: "ams", Tags: []string{"a", "b"}}
+}
+
+func TestValidate(t *testing.T) {
+	if err := newValidR().Validate(); err != nil {
+		t.Fatal("valid got error:", err)
+	}
+
+	golden := []struct {
+		desc   string
+		modify func(r *gen.R)
+		want   gen.ColferInvalid
+	}{
+		{"par below", func(r *gen.R) { r.Par = 2 }, gen.ColferInvalid{Field: "gen.r.par", Rule: "min 3"}},
+		{"par above", func(r *gen.R) { r.Par = 6 }, gen.ColferInvalid{Field: "gen.r.par", Rule: "max 5"}},
+		{"lat below", func(r *gen.R) { r.Lat = -90.5 }, gen.ColferInvalid{Field: "gen.r.lat", Rule: "min -90"}},
+		{"lat NaN", func(r *gen.R) { r.Lat = math.NaN() }, gen.ColferInvalid{Field: "gen.r.lat", Rule: "min -90"}},
+		{"big above", func(r *gen.R) { r.Big = math.MaxUint64 }, gen.ColferInvalid{Field: "gen.r.big", Rule: "max 10000000000000000000"}},
+		{"name absent", func(r *gen.R) { r.Name = "" }, gen.ColferInvalid{Field: "gen.r.name", Rule: "required"}},
+		{"name short", func(r *gen.R) { r.Name = "a" }, gen.ColferInvalid{Field: "gen.r.name", Rule: "minlen 2"}},
+		{"name long", func(r *gen.R) { r.Name = "amsterdam" }, gen.ColferInvalid{Field: "gen.r.name", Rule: "maxlen 8"}},
+		{"name pattern", func(r *gen.R) { r.Name = "Ams" }, gen.ColferInvalid{Field: "gen.r.name", Rule: "pattern ^[a-z]+$"}},
+		{"tags long", func(r *gen.R) { r.Tags = append(r.Tags, "c") }, gen.ColferInvalid{Field: "gen.r.tags", Rule: "maxlen 2"}},
+		{"next", func(r *gen.R) { r.Next = &gen.R{Par: 4, Name: "x"} }, gen.ColferInvalid{Field: "gen.r.name", Rule: "minlen 2"}},
+	}
+	for _, gold := range golden {
+		r := newValidR()
+		gold.modify(r)
+		err := r.Validate()
+		if err != gold.want {
+			t.Errorf("%s: got error %v, want %v", gold.desc, err, gold.want)
+		}
+	}
+
+	if err := (*gen.R)(nil).Validate(); err != (gen.ColferInvalid{Field: "gen.r.par", Rule: "min 3"}) {
+		t.Errorf("nil got error %v", err)
+	}
+	if err := (&gen.W{V: new(gen.R)}).Validate(); err == nil {
+		t.Error("no error for invalid any value")
+	}
+}
+
+func TestUnmarshalValidate(t *testing.T) {
+	r := newValidR()
+	r.Next = &gen.R{Par: 1}
+	data, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+
+	if _, err := new(gen.R).Unmarshal(data); err != nil {
+		t.Error("unmarshal without validation got error:", err)
+	}
+
+	opts := gen.ColferOptions{SizeMax: gen.ColferSizeMax, ListMax: gen.ColferListMax, Validate: true}
+	_, err = new(gen.R).UnmarshalWith(data, opts)
+	if want := (gen.ColferInvalid{Field: "gen.r.par", Rule: "min 3"}); err != want {
+		t.Errorf("got error %v, want %v", err, want)
+	}
+
+	r.Next = nil
+	data, err = r.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	if n, err := new(gen.R).UnmarshalWith(data, opts); err != nil || n != len(data) {
+		t.Errorf("got %d bytes and error %v, want %d bytes", n, err, len(data))
+	}
+}
+
+func TestUnmarshalValidateSelection(t *testing.T) {
+	r := newValidR()
+	r.Next = &gen.R{Par: 1}
+	data, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+
+	opts := gen.ColferOptions{SizeMax: 1 << 20, ListMax: 100, DepthMax: 10, Validate: true, Fields: []string{"par"}}
+	got := new(gen.R)
+	if _, err := got.UnmarshalWith(data, opts); err != nil {
+		t.Error("skipped next got error:", err)
+	} else if got.Par != r.Par || got.Name != "" || got.Next != nil {
+		t.Errorf("got %v, want par only", got)
+	}
+
+	invalidPar, err := (&gen.R{Par: 1, Name: "ams"}).MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	opts.Fields = []string{"name"}
+	if _, err := new(gen.R).UnmarshalWith(invalidPar, opts); err != nil {
+		t.Error("unselected par got error:", err)
+	}
+
+	data, err = (&gen.W{V: r}).MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	opts.Fields = []string{}
+	if n, err := new(gen.W).UnmarshalWith(data, opts); err != nil || n != len(data) {
+		t.Errorf("no fields got %d bytes and error %v, want %d bytes", n, err, len(data))
+	}
+}
+
+func TestStringRedaction(t *testing.T) {
+	if got, want := newValidR().String(), `gen.R{Par:4 Lat:52.37 Big:0 Name:"ams" Tags:["a" "b"] Next:<nil>}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	o := &gen.Old{Pin: "1234", Ref: &gen.Old{Pin: "5678"}}
+	if got, want := o.String(), "gen.Old{Pin:<redacted> Ref:gen.Old{Pin:<redacted> Ref:<nil>}}"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := o.GoString(), "&gen.Old{Pin:<redacted>, Ref:&gen.Old{Pin:<redacted>, Ref:(*gen.Old)(nil)}}"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		s := fmt.Sprintf(format, o)
+		if strings.Contains(s, "1234") || strings.Contains(s, "5678") {
+			t.Errorf("format %s leaks a sensitive field: %s", format, s)
+		}
+	}
+}
+
+func TestNameOverrides(t *testing.T) {
+	data, err := (&gen.Renamed{ID: 7}).MarshalBinary()
+	if err != nil {
+		t.Fatal("marshal error:", err)
+	}
+	got := new(gen.Renamed)
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal("unmarshal error:", err)
+	}
+	if got.ID != 7 {
+		t.Errorf("got ID %d, want 7", got.ID)
+	}
+}
+
+func TestStructTags(t *testing.T) {
+	typ := reflect.TypeOf(gen.Renamed{})
+	golden := []struct{ field, key, want string }{
+		{"ID", "json", "id,omitempty"},
+		{"ID", "go", ""},
+		{"Class", "json", "class,omitempty"},
+		{"Class", "yaml", "klass"},
+		{"Class", "db", "class"},
+	}
+	for _, gold := range golden {
+		f, _ := typ.FieldByName(gold.field)
+		if got := f.Tag.Get(gold.key); got != gold.want {
+			t.Errorf("field %s got tag %s %q, want %q", gold.field, gold.key, got, gold.want)
+		}
+	}
+}
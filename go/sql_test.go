@@ -0,0 +1,178 @@
+package testdata
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/pascaldekloe/colfer/go/gen"
+)
+
+func init() {
+	sql.Register("colfer-fake", new(fakeDriver))
+}
+
+// fakeDriver is a database with one column. Statement "INSERT" appends the
+// argument and any other statement selects all rows in order of insertion.
+type fakeDriver struct {
+	sync.Mutex
+	rows []driver.Value
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{d}, nil
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{c.d, query}, nil
+}
+
+func (fakeConn) Close() error { return nil }
+
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fake driver: no transaction support")
+}
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (fakeStmt) Close() error { return nil }
+
+func (s fakeStmt) NumInput() int {
+	if s.query == "INSERT" {
+		return 1
+	}
+	return 0
+}
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.Lock()
+	defer s.d.Unlock()
+	s.d.rows = append(s.d.rows, args[0])
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.Lock()
+	defer s.d.Unlock()
+	return &fakeRows{values: append([]driver.Value(nil), s.d.rows...)}, nil
+}
+
+type fakeRows struct{ values []driver.Value }
+
+func (*fakeRows) Columns() []string { return []string{"serial"} }
+
+func (*fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+// openFake returns a database with rows as its content.
+func openFake(t *testing.T, rows ...driver.Value) *sql.DB {
+	db, err := sql.Open("colfer-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	d := db.Driver().(*fakeDriver)
+	d.Lock()
+	d.rows = rows
+	d.Unlock()
+	return db
+}
+
+func TestSQLRoundTrip(t *testing.T) {
+	db := openFake(t)
+	defer db.Close()
+
+	want := &gen.O{S: "x", U32: 42, Os: []*gen.O{{B: true}}}
+	for _, v := range []*gen.O{want, nil} {
+		if _, err := db.Exec("INSERT", v); err != nil {
+			t.Fatal("insert error:", err)
+		}
+	}
+
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal("select error:", err)
+	}
+	defer rows.Close()
+
+	var got []*gen.O
+	for rows.Next() {
+		var o *gen.O
+		if err := rows.Scan(&o); err != nil {
+			t.Fatal("scan error:", err)
+		}
+		got = append(got, o)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal("rows error:", err)
+	}
+	if len(got) != 2 || !got[0].Equal(want) || got[1] != nil {
+		t.Errorf("got %v, want [%v <nil>]", got, want)
+	}
+}
+
+func TestSQLScan(t *testing.T) {
+	o := &gen.O{S: "x"}
+	if err := o.Scan(nil); err != nil || !reflect.DeepEqual(o, new(gen.O)) {
+		t.Errorf("NULL got %v and error %v, want zero value", o, err)
+	}
+	if err := o.Scan(string([]byte{0x08, 0x01, 'x', 0x7f})); err != nil || o.S != "x" {
+		t.Errorf("string got %v and error %v", o, err)
+	}
+	// reuse must not carry over the previous row
+	if err := o.Scan([]byte{0x01, 0x2a, 0x7f}); err != nil || !reflect.DeepEqual(o, &gen.O{U32: 42}) {
+		t.Errorf("reuse got %v and error %v, want u32 42 only", o, err)
+	}
+
+	golden := []struct {
+		src  interface{}
+		want error
+	}{
+		{[]byte{0x08, 0x01}, io.ErrUnexpectedEOF},
+		{[]byte{0x7f, 0x7f}, gen.ColferTail(1)},
+	}
+	for _, gold := range golden {
+		if err := new(gen.O).Scan(gold.src); err != gold.want {
+			t.Errorf("scan %#x got error %v, want %v", gold.src, err, gold.want)
+		}
+	}
+	if err := new(gen.O).Scan(42); err == nil {
+		t.Error("scan int64 got no error")
+	}
+}
+
+func TestSQLSizeMax(t *testing.T) {
+	defer func(max int) { gen.ColferSizeMax = max }(gen.ColferSizeMax)
+	gen.ColferSizeMax = 4
+
+	db := openFake(t)
+	defer db.Close()
+	if _, err := db.Exec("INSERT", &gen.O{S: "too long"}); err == nil {
+		t.Error("insert beyond size maximum got no error")
+	}
+
+	db = openFake(t, []byte{0x08, 0x08, 't', 'o', 'o', ' ', 'l', 'o', 'n', 'g', 0x7f})
+	defer db.Close()
+	var o gen.O
+	err := db.QueryRow("SELECT").Scan(&o)
+	var maxErr gen.ColferMax
+	if !errors.As(err, &maxErr) {
+		t.Errorf("scan beyond size maximum got error %v, want a ColferMax", err)
+	}
+}
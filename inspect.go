@@ -0,0 +1,734 @@
+package colfer
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// WireField is a field interpretation of serial data.
+type WireField struct {
+	// Offset is the position of the header byte.
+	Offset int
+	// End is the position after the last payload byte.
+	End int
+	// Index is the field index from the header.
+	Index int
+	// Flag is the 0x80 bit from the header.
+	Flag bool
+	// Type lists the datatype candidates, separated by a vertical bar.
+	Type string
+	// Value is a representation of the payload.
+	Value string
+	// Elements are the nested data structures, if any. Each element holds
+	// the most plausible reading only.
+	Elements []*WireReading
+
+	// odd is the plausibility penalty of the payload.
+	odd int
+	// height is the lowest number of nested data structure levels in the
+	// payload.
+	height int
+}
+
+// String returns a one line description.
+func (f *WireField) String() string {
+	flag := ""
+	if f.Flag {
+		flag = " flagged"
+	}
+	s := fmt.Sprintf("@%d field %d%s %s", f.Offset, f.Index, flag, f.Type)
+	if f.Value != "" {
+		s += " " + f.Value
+	}
+	return s
+}
+
+// WireReading is a consistent interpretation of serial data.
+type WireReading struct {
+	// Fields are the top level fields in order of appearance.
+	Fields []*WireField
+	// End is the position after the 0x7f terminator.
+	End int
+}
+
+// Diverge returns the header position of the first field which is read
+// differently by o, or -1 when both readings are equal.
+func (r *WireReading) Diverge(o *WireReading) int {
+	for i, f := range r.Fields {
+		if i >= len(o.Fields) {
+			return f.Offset
+		}
+		g := o.Fields[i]
+		if f.End != g.End || f.Type != g.Type {
+			return f.Offset
+		}
+	}
+	if len(o.Fields) > len(r.Fields) {
+		return o.Fields[len(r.Fields)].Offset
+	}
+	if r.End != o.End {
+		return r.End - 1
+	}
+	return -1
+}
+
+// WireMismatch signals the absence of a consistent reading. The value is the
+// furthest byte index reached by any interpretation.
+type WireMismatch int
+
+// Error honors the error interface.
+func (i WireMismatch) Error() string {
+	return fmt.Sprintf("colfer: no consistent reading; data inconsistent at byte %d", i)
+}
+
+// Plausibility penalties rank the readings.
+const (
+	oddField = 2             // per field
+	oddGap   = 1             // skipped field indices
+	oddLeap  = 8             // more than 16 skipped field indices
+	oddValue = 4             // unusual payload
+	oddNever = math.MaxInt32 // inconsistent
+)
+
+// wireDepthMax is the upper limit for the number of nested data structure
+// levels considered, including the root, conform the ColferDepthMax default.
+// The limit bounds the recursion in the evaluation of penalties.
+const wireDepthMax = 100
+
+// InspectWire returns up to max interpretations of the Colfer serial at the
+// start of data without a schema. Both the field order and the 0x7f
+// terminator are verified, and the payloads must fit the datatypes. The
+// readings are ordered by plausibility, which favours dense field indices,
+// valid UTF-8 and floating points or timestamps in a common range. When no
+// reading is consistent then the error is a WireMismatch. Data structures
+// nested deeper than 100 levels are not recognised. A max of zero or less
+// verifies the consistency only, without any readings.
+func InspectWire(data []byte, max int) ([]*WireReading, error) {
+	w := &wireScan{
+		data:   data,
+		odds:   make(map[[4]int]int),
+		chains: make(map[[4]int]int),
+	}
+	w.scan()
+
+	var ends []int
+	for _, e := range w.reachable(0, 0) {
+		ends = append(ends, e.pos)
+	}
+	if len(ends) == 0 {
+		return nil, WireMismatch(w.furthest(0, 0))
+	}
+	if max <= 0 {
+		return nil, nil
+	}
+	// longest first on equal plausibility
+	sort.Sort(sort.Reverse(sort.IntSlice(ends)))
+	sort.SliceStable(ends, func(i, j int) bool {
+		return w.endOdd(ends[i]) < w.endOdd(ends[j])
+	})
+
+	var readings []*WireReading
+	for _, end := range ends {
+		w.walk(0, 0, end, nil, func(fields []*WireField) bool {
+			readings = append(readings, &WireReading{Fields: fields, End: end})
+			return len(readings) < max
+		})
+		if len(readings) >= max {
+			break
+		}
+	}
+	return readings, nil
+}
+
+// wireEnd is a message end, with the lowest number of nested data structure
+// levels in any reading till there.
+type wireEnd struct {
+	pos    int
+	height int
+}
+
+// wireScan is an inspection state.
+type wireScan struct {
+	data []byte
+	// reach has the message ends per header position, in ascending order.
+	reach [][]wireEnd
+	// cands has the field interpretations per header position.
+	cands [][]*WireField
+	// furs has the highest position evaluated per header position.
+	furs []int
+	// tails has whether the data from a position consists of messages only.
+	tails []bool
+	// union is a buffer for the evaluation of message ends.
+	union endUnion
+	// odds has the lowest penalty per position, minimum field index,
+	// message end and depth.
+	odds map[[4]int]int
+	// chains has the lowest penalty per position, element count, list end
+	// and depth.
+	chains map[[4]int]int
+	// depth is the number of data structure levels in evaluation. The
+	// nesting height of candidates is limited to what remains, so the
+	// penalties depend on depth, while the message ends do not.
+	depth int
+}
+
+// scan resolves the candidates, the message ends and the tails of each
+// position. Each position depends on the positions after it only, so the
+// evaluation runs from the end of the data backwards, without recursion.
+func (w *wireScan) scan() {
+	n := len(w.data)
+	w.union.heights = make([]int, n+1)
+	for i := range w.union.heights {
+		w.union.heights[i] = -1
+	}
+	w.reach = make([][]wireEnd, n)
+	w.cands = make([][]*WireField, n)
+	w.furs = make([]int, n)
+	w.tails = make([]bool, n+1)
+	w.tails[n] = true
+
+	for pos := n - 1; pos >= 0; pos-- {
+		w.furs[pos] = pos
+		switch header := w.data[pos]; header {
+		case 0x7f:
+			w.reach[pos] = []wireEnd{{pos + 1, 0}}
+		case 0xff:
+			break // field index 0x7f is reserved
+		default:
+			w.cands[pos] = w.candidates(pos)
+			for _, c := range w.cands[pos] {
+				w.union.add(w.reachable(c.End, c.Index+1), c.height)
+				if f := w.furthest(c.End, c.Index+1); f > w.furs[pos] {
+					w.furs[pos] = f
+				}
+			}
+			w.reach[pos] = w.union.take()
+		}
+
+		for _, e := range w.reach[pos] {
+			if w.tails[e.pos] {
+				w.tails[pos] = true
+				break
+			}
+		}
+	}
+}
+
+// reachable returns the message ends which can be reached from pos, with next
+// as the lowest field index allowed.
+func (w *wireScan) reachable(pos, next int) []wireEnd {
+	if pos >= len(w.data) {
+		return nil
+	}
+	if header := w.data[pos]; header != 0x7f && int(header&0x7f) < next {
+		return nil // field order violation
+	}
+	return w.reach[pos]
+}
+
+// furthest returns the highest position evaluated for a header at pos, with
+// next as the lowest field index allowed.
+func (w *wireScan) furthest(pos, next int) int {
+	if pos >= len(w.data) {
+		return pos
+	}
+	if index := int(w.data[pos] & 0x7f); index < next || index == 0x7f {
+		return pos
+	}
+	return w.furs[pos]
+}
+
+// endOdd returns the lowest penalty for the message from the start of the data
+// till end. Data which continues after end counts as a field, and data which
+// continues with anything other than more messages counts as an unusual
+// payload too.
+func (w *wireScan) endOdd(end int) int {
+	odd := w.odd(0, 0, end)
+	if end < len(w.data) {
+		odd += oddField
+		if !w.tails[end] {
+			odd += oddValue
+		}
+	}
+	return odd
+}
+
+// odd returns the lowest penalty for the message remainder from pos till end,
+// with next as the lowest field index allowed.
+func (w *wireScan) odd(pos, next, end int) int {
+	key := [4]int{pos, next, end, w.depth}
+	if odd, ok := w.odds[key]; ok {
+		return odd
+	}
+
+	odd := oddNever
+	if pos < end {
+		header := w.data[pos]
+		index := int(header & 0x7f)
+		switch {
+		case header == 0x7f:
+			if pos+1 == end {
+				odd = 0
+			}
+		case index < next || index == 0x7f:
+			break // field order violation
+		default:
+			for _, c := range w.cands[pos] {
+				if o := w.fieldOdd(c, next, end); o < odd {
+					odd = o
+				}
+			}
+		}
+	}
+
+	w.odds[key] = odd
+	return odd
+}
+
+// fieldOdd returns the lowest penalty for the message remainder from c till
+// end, with next as the lowest field index allowed before c.
+func (w *wireScan) fieldOdd(c *WireField, next, end int) int {
+	if c.End >= end || w.depth+c.height >= wireDepthMax {
+		return oddNever
+	}
+	rest := w.odd(c.End, c.Index+1, end)
+	if rest == oddNever {
+		return oddNever
+	}
+
+	odd := oddField + c.odd + rest
+	if gap := c.Index - next; gap > 16 {
+		odd += oddLeap
+	} else if gap > 0 {
+		odd += oddGap
+	}
+
+	nested := 0
+	w.depth++
+	switch c.Type {
+	case "struct":
+		nested = w.odd(c.Offset+1, 0, c.End)
+	case "[]struct":
+		n, pos, _ := w.varint(c.Offset + 1)
+		nested = w.chainOdd(pos, int(n), c.End)
+	}
+	w.depth--
+	if nested == oddNever {
+		return oddNever
+	}
+	return odd + nested
+}
+
+// chainOdd returns the lowest penalty for n consecutive messages from pos
+// till end.
+func (w *wireScan) chainOdd(pos, n, end int) int {
+	key := [4]int{pos, n, end, w.depth}
+	if odd, ok := w.chains[key]; ok {
+		return odd
+	}
+
+	odd := oddNever
+	if step, ok := w.chainSteps(pos, n, end)[n][end]; ok {
+		odd = step.odd
+	}
+	w.chains[key] = odd
+	return odd
+}
+
+// chainStep is a message in a chain.
+type chainStep struct {
+	odd  int // lowest penalty for the chain till here
+	prev int // start of the message
+}
+
+// chainSteps returns the lowest penalty per message end for each number of
+// consecutive messages from pos, up to n, within end. The iteration keeps the
+// stack flat for long lists.
+func (w *wireScan) chainSteps(pos, n, end int) []map[int]chainStep {
+	steps := make([]map[int]chainStep, n+1)
+	steps[0] = map[int]chainStep{pos: {0, -1}}
+	for i := 1; i <= n; i++ {
+		steps[i] = make(map[int]chainStep)
+		for p, s := range steps[i-1] {
+			for _, e := range w.reachable(p, 0) {
+				// remaining messages take one byte at least
+				if end-e.pos < n-i {
+					break
+				}
+				if w.depth+e.height >= wireDepthMax {
+					continue
+				}
+				o := w.odd(p, 0, e.pos)
+				if o == oddNever {
+					continue
+				}
+				t, ok := steps[i][e.pos]
+				if !ok || s.odd+o < t.odd || s.odd+o == t.odd && p < t.prev {
+					steps[i][e.pos] = chainStep{s.odd + o, p}
+				}
+			}
+		}
+	}
+	return steps
+}
+
+// walk passes every field sequence from pos till end to f, in order of
+// plausibility, until f returns false. The return is false when walk was
+// aborted.
+func (w *wireScan) walk(pos, next, end int, fields []*WireField, f func([]*WireField) bool) bool {
+	if w.data[pos] == 0x7f {
+		if pos+1 != end {
+			return true
+		}
+		return f(append([]*WireField(nil), fields...))
+	}
+
+	var options []*WireField
+	for _, c := range w.cands[pos] {
+		if w.fieldOdd(c, next, end) != oddNever {
+			options = append(options, c)
+		}
+	}
+	sort.SliceStable(options, func(i, j int) bool {
+		return w.fieldOdd(options[i], next, end) < w.fieldOdd(options[j], next, end)
+	})
+
+	for _, c := range options {
+		w.expand(c)
+		if !w.walk(c.End, c.Index+1, end, append(fields, c), f) {
+			return false
+		}
+	}
+	return true
+}
+
+// first returns the most plausible reading of the message from pos till end.
+func (w *wireScan) first(pos, end int) *WireReading {
+	r := &WireReading{End: end}
+	w.walk(pos, 0, end, nil, func(fields []*WireField) bool {
+		r.Fields = fields
+		return false
+	})
+	return r
+}
+
+// expand resolves the nested data structures of c.
+func (w *wireScan) expand(c *WireField) {
+	if c.Elements != nil {
+		return
+	}
+	w.depth++
+	defer func() { w.depth-- }()
+
+	switch c.Type {
+	case "struct":
+		c.Elements = []*WireReading{w.first(c.Offset+1, c.End)}
+	case "[]struct":
+		x, pos, _ := w.varint(c.Offset + 1)
+		n := int(x)
+		steps := w.chainSteps(pos, n, c.End)
+		// backtrack from the list end
+		ends := make([]int, n)
+		for i, e := n, c.End; i > 0; i-- {
+			ends[i-1] = e
+			e = steps[i][e].prev
+		}
+		c.Elements = make([]*WireReading, 0, n)
+		for _, e := range ends {
+			c.Elements = append(c.Elements, w.first(pos, e))
+			pos = e
+		}
+	}
+}
+
+// chain returns the ends of n consecutive messages from pos, and the highest
+// position evaluated.
+func (w *wireScan) chain(pos, n int) (ends []wireEnd, furthest int) {
+	ends, furthest = []wireEnd{{pos, 0}}, pos
+	for ; n > 0 && len(ends) != 0; n-- {
+		for _, e := range ends {
+			w.union.add(w.reachable(e.pos, 0), e.height)
+			if f := w.furthest(e.pos, 0); f > furthest {
+				furthest = f
+			}
+		}
+		ends = w.union.take()
+	}
+	return ends, furthest
+}
+
+// varint returns the value and the position after the encoding, with ok false
+// on EOF.
+func (w *wireScan) varint(pos int) (x uint64, end int, ok bool) {
+	for shift := uint(0); pos < len(w.data); shift += 7 {
+		b := w.data[pos]
+		pos++
+		if b < 0x80 || shift == 56 {
+			return x | uint64(b)<<shift, pos, true
+		}
+		x |= uint64(b&0x7f) << shift
+	}
+	return x, pos, false
+}
+
+// candidates returns the payload interpretations of the field at pos, which
+// requires the scan of each position after pos. Datatypes with an identical
+// layout share a WireField. Encodings which are never produced by the
+// generated code, such as zero values and fixed sizes for small numbers, are
+// not considered plausible.
+func (w *wireScan) candidates(pos int) []*WireField {
+	data := w.data
+	header := data[pos]
+	index := int(header & 0x7f)
+	flag := header&0x80 != 0
+	start := pos + 1
+
+	var a []*WireField
+	add := func(typ string, size int, value string, odd int) *WireField {
+		if start+size > len(data) {
+			return nil
+		}
+		f := &WireField{Offset: pos, End: start + size, Index: index, Flag: flag, Type: typ, Value: value, odd: odd}
+		a = append(a, f)
+		return f
+	}
+	fixed := func(size int) []byte {
+		if start+size > len(data) {
+			return nil
+		}
+		return data[start : start+size]
+	}
+
+	x, end, ok := w.varint(start)
+	// overlong encodings are not canonical
+	ok = ok && x != 0 && (end-start == 1 || data[end-1] != 0)
+
+	if flag {
+		if b := fixed(1); b != nil && b[0] != 0 {
+			add("uint16", 1, strconv.Itoa(int(b[0])), 0)
+		}
+		if b := fixed(4); b != nil && binary.BigEndian.Uint32(b) >= 1<<21 {
+			add("uint32", 4, strconv.FormatUint(uint64(binary.BigEndian.Uint32(b)), 10), 0)
+		}
+		if b := fixed(8); b != nil && binary.BigEndian.Uint64(b) >= 1<<49 {
+			add("uint64", 8, strconv.FormatUint(binary.BigEndian.Uint64(b), 10), 0)
+		}
+		if b := fixed(12); b != nil {
+			s, odd := wireTime(int64(binary.BigEndian.Uint64(b)), binary.BigEndian.Uint32(b[8:]))
+			add("timestamp", 12, s, odd)
+		}
+		if ok {
+			add("int32|int64", end-start, "-"+strconv.FormatUint(x, 10), 0)
+		}
+		return a
+	}
+
+	add("bool", 0, "true", 0)
+	if b := fixed(1); b != nil && b[0] != 0 {
+		if b[0] < 0x80 {
+			add("uint8|uint32|uint64|int32|int64", 1, strconv.Itoa(int(b[0])), 0)
+		} else {
+			add("uint8", 1, strconv.Itoa(int(b[0])), 0)
+		}
+	}
+	if b := fixed(2); b != nil && b[0] != 0 {
+		add("uint16", 2, strconv.Itoa(int(binary.BigEndian.Uint16(b))), 0)
+	}
+	if b := fixed(4); b != nil && binary.BigEndian.Uint32(b)<<1 != 0 {
+		s, odd := wireFloat(float64(math.Float32frombits(binary.BigEndian.Uint32(b))), 32)
+		add("float32", 4, s, odd)
+	}
+	if b := fixed(8); b != nil {
+		f, fOdd := wireFloat(math.Float64frombits(binary.BigEndian.Uint64(b)), 64)
+		t, tOdd := wireTime(int64(binary.BigEndian.Uint32(b)), binary.BigEndian.Uint32(b[4:]))
+		switch {
+		case fOdd < tOdd:
+			add("float64", 8, f, fOdd)
+		case tOdd < fOdd:
+			add("timestamp", 8, t, tOdd)
+		default:
+			add("float64|timestamp", 8, f+" | "+t, fOdd)
+		}
+	}
+
+	if ok && end-start > 1 {
+		add("uint32|uint64|int32|int64", end-start, strconv.FormatUint(x, 10), 0)
+	}
+
+	// length-prefixed payloads
+	if ok && x <= uint64(len(data)-end) {
+		n := int(x)
+		payload := data[end : end+n]
+		typ, odd := "binary", oddValue/2
+		if utf8.Valid(payload) {
+			typ, odd = "text|binary", wireTextOdd(payload)
+		}
+		add(typ, end-start+n, wirePayload(payload), odd)
+
+		if n*4 <= len(data)-end {
+			odd := 0
+			for i := end; i < end+n*4; i += 4 {
+				_, o := wireFloat(float64(math.Float32frombits(binary.BigEndian.Uint32(data[i:]))), 32)
+				odd += o
+			}
+			add("[]float32", end-start+n*4, fmt.Sprintf("%d elements", n), odd)
+		}
+		if n*8 <= len(data)-end {
+			odd := 0
+			for i := end; i < end+n*8; i += 8 {
+				_, o := wireFloat(math.Float64frombits(binary.BigEndian.Uint64(data[i:])), 64)
+				odd += o
+			}
+			add("[]float64", end-start+n*8, fmt.Sprintf("%d elements", n), odd)
+		}
+		if p, odd, ok := w.sizedList(end, n); ok {
+			add("[]text|[]binary", p-start, fmt.Sprintf("%d elements", n), odd)
+		}
+		ends, furthest := w.chain(end, n)
+		if furthest > w.furs[pos] {
+			w.furs[pos] = furthest
+		}
+		for _, e := range ends {
+			if e.height+1 < wireDepthMax {
+				add("[]struct", e.pos-start, fmt.Sprintf("%d elements", n), 0).height = e.height + 1
+			}
+		}
+	}
+
+	// nested data structure
+	if f := w.furthest(start, 0); f > w.furs[pos] {
+		w.furs[pos] = f
+	}
+	for _, e := range w.reachable(start, 0) {
+		if e.height+1 < wireDepthMax {
+			add("struct", e.pos-start, "", 0).height = e.height + 1
+		}
+	}
+	return a
+}
+
+// sizedList returns the end of n length-prefixed payloads from pos.
+func (w *wireScan) sizedList(pos, n int) (end, odd int, ok bool) {
+	for ; n > 0; n-- {
+		x, p, ok := w.varint(pos)
+		if !ok || x > uint64(len(w.data)-p) {
+			return 0, 0, false
+		}
+		pos = p + int(x)
+
+		payload := w.data[p:pos]
+		if utf8.Valid(payload) {
+			odd += wireTextOdd(payload)
+		} else {
+			odd += oddValue / 2
+		}
+	}
+	return pos, odd, true
+}
+
+// wireFloat returns a representation with its plausibility penalty.
+func wireFloat(f float64, bitSize int) (string, int) {
+	s := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-9 || abs > 1e15 || math.IsNaN(f)) {
+		return s, oddValue
+	}
+	return s, 0
+}
+
+// wireTime returns a representation with its plausibility penalty.
+func wireTime(sec int64, nsec uint32) (string, int) {
+	t := time.Unix(sec, int64(nsec)).UTC()
+	s := t.Format(time.RFC3339Nano)
+	if nsec >= 1e9 || t.Year() < 1900 || t.Year() > 2200 {
+		return s, oddValue
+	}
+	return s, 0
+}
+
+// wireTextOdd returns the plausibility penalty for a UTF-8 payload.
+func wireTextOdd(b []byte) int {
+	for _, c := range b {
+		if c < ' ' && c != '\t' && c != '\n' && c != '\r' {
+			return oddValue
+		}
+	}
+	return 0
+}
+
+// wirePayload returns a representation of a text or binary.
+func wirePayload(b []byte) string {
+	const max = 32
+	if utf8.Valid(b) {
+		if len(b) > max {
+			return strconv.Quote(string(b[:max])) + "…"
+		}
+		return strconv.Quote(string(b))
+	}
+	if len(b) > max {
+		return "0x" + hex.EncodeToString(b[:max]) + "…"
+	}
+	return "0x" + hex.EncodeToString(b)
+}
+
+// endUnion collects message ends with the lowest height for each.
+type endUnion struct {
+	// heights has the lowest height per position, or -1 for none.
+	heights []int
+	// ends has each position with a height.
+	ends []wireEnd
+	// lo and hi are the lowest and the highest position in ends.
+	lo, hi int
+}
+
+// add includes the ends with their heights raised to height at least.
+func (u *endUnion) add(ends []wireEnd, height int) {
+	for _, e := range ends {
+		if e.height < height {
+			e.height = height
+		}
+		switch h := u.heights[e.pos]; {
+		case h < 0:
+			if len(u.ends) == 0 || e.pos < u.lo {
+				u.lo = e.pos
+			}
+			if len(u.ends) == 0 || e.pos > u.hi {
+				u.hi = e.pos
+			}
+			u.ends = append(u.ends, e)
+			u.heights[e.pos] = e.height
+		case e.height < h:
+			u.heights[e.pos] = e.height
+		}
+	}
+}
+
+// take returns the ends collected in ascending order, and it resets u.
+func (u *endUnion) take() []wireEnd {
+	if len(u.ends) == 0 {
+		return nil
+	}
+	ends := make([]wireEnd, 0, len(u.ends))
+	if span := u.hi - u.lo; span/16 < len(u.ends) {
+		// dense; sort by position lookup
+		for pos := u.lo; pos <= u.hi; pos++ {
+			if h := u.heights[pos]; h >= 0 {
+				ends = append(ends, wireEnd{pos, h})
+				u.heights[pos] = -1
+			}
+		}
+	} else {
+		for _, e := range u.ends {
+			ends = append(ends, wireEnd{e.pos, u.heights[e.pos]})
+			u.heights[e.pos] = -1
+		}
+		sort.Slice(ends, func(i, j int) bool { return ends[i].pos < ends[j].pos })
+	}
+	u.ends = u.ends[:0]
+	return ends
+}
@@ -0,0 +1,113 @@
+package colfer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestInspectWire(t *testing.T) {
+	golden := []struct {
+		serial string
+		end    int
+		types  []string
+	}{
+		{"7f", 1, nil},
+		{"007f", 2, []string{"bool"}},
+		{"8380017f", 4, []string{"int32|int64"}},
+		{"080568656c6c6f7f", 8, []string{"text|binary"}},
+		{"0755ef312a2e5da4e77f", 10, []string{"timestamp"}},
+		{"063fb999999999999a7f", 10, []string{"float64"}},
+		{"0b020e037f7f7f", 7, []string{"[]struct"}},
+		{"7f7f", 1, nil},
+	}
+
+	for _, gold := range golden {
+		data, err := hex.DecodeString(gold.serial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		readings, err := InspectWire(data, 4)
+		if err != nil {
+			t.Errorf("0x%s: %s", gold.serial, err)
+			continue
+		}
+
+		r := readings[0]
+		if r.End != gold.end {
+			t.Errorf("0x%s: got end %d, want %d", gold.serial, r.End, gold.end)
+		}
+		if len(r.Fields) != len(gold.types) {
+			t.Errorf("0x%s: got %d fields, want %d", gold.serial, len(r.Fields), len(gold.types))
+			continue
+		}
+		for i, f := range r.Fields {
+			if f.Type != gold.types[i] {
+				t.Errorf("0x%s: got field %d type %q, want %q", gold.serial, i, f.Type, gold.types[i])
+			}
+		}
+	}
+}
+
+func TestInspectWireNested(t *testing.T) {
+	// gen.o with os [{u8: 3}, {}]
+	data := []byte{0x0b, 0x02, 0x0e, 0x03, 0x7f, 0x7f, 0x7f}
+	readings, err := InspectWire(data, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := readings[0].Fields[0]
+	if f.Type != "[]struct" || len(f.Elements) != 2 {
+		t.Fatalf("got field %s with %d elements, want []struct with 2 elements", f, len(f.Elements))
+	}
+	if got := f.Elements[0].Fields; len(got) != 1 || got[0].Index != 14 || got[0].Value != "3" {
+		t.Errorf("got first element fields %v, want field 14 with value 3", got)
+	}
+	if got := f.Elements[1]; len(got.Fields) != 0 || got.End != 6 {
+		t.Errorf("got second element with %d fields till %d, want no fields till 6", len(got.Fields), got.End)
+	}
+}
+
+func TestInspectWireMismatch(t *testing.T) {
+	// field order violation
+	_, err := InspectWire([]byte{0x00, 0x02, 0x01, 0x05}, 1)
+	if err != WireMismatch(4) {
+		t.Errorf("got error %v, want WireMismatch(4)", err)
+	}
+	if _, err := InspectWire(nil, 1); err != WireMismatch(0) {
+		t.Errorf("got error %v for no data, want WireMismatch(0)", err)
+	}
+}
+
+func TestInspectWireDepth(t *testing.T) {
+	// nested data structure headers beyond the depth limit
+	data := append(bytes.Repeat([]byte{0x0a}, 1000), bytes.Repeat([]byte{0x7f}, 1001)...)
+	if _, err := InspectWire(data, 1); err == nil {
+		t.Error("got no error for 1000 nested levels")
+	} else if _, ok := err.(WireMismatch); !ok {
+		t.Errorf("got error %v, want a WireMismatch", err)
+	}
+}
+
+func TestInspectWireRepeat(t *testing.T) {
+	// ambiguous in many ways
+	data := bytes.Repeat([]byte{0x01, 0x02, 0x01, 0x7f}, 200)
+	readings, err := InspectWire(data, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(readings) != 3 {
+		t.Errorf("got %d readings, want 3", len(readings))
+	}
+}
+
+func TestInspectWireNoMax(t *testing.T) {
+	readings, err := InspectWire([]byte{0x00, 0x7f}, 0)
+	if err != nil || readings != nil {
+		t.Errorf("got %v and error %v, want none", readings, err)
+	}
+	if _, err := InspectWire([]byte{0x00}, 0); err != WireMismatch(1) {
+		t.Errorf("got error %v for incomplete data, want WireMismatch(1)", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
 )
@@ -24,8 +25,19 @@ func IsJavaKeyword(s string) bool {
 func GenerateJava(basedir string, packages []*Package) error {
 	packageTemplate := template.New("java-package")
 	template.Must(packageTemplate.Parse(javaPackage))
-	codeTemplate := template.New("java-code")
+	jsonTemplate := template.New("java-json")
+	template.Must(jsonTemplate.Parse(javaJSON))
+	diffTemplate := template.New("java-diff")
+	template.Must(diffTemplate.Parse(javaDiff))
+	anyTemplate := template.New("java-any")
+	template.Must(anyTemplate.Parse(javaAny))
+	codeTemplate := template.New("java-code").Funcs(template.FuncMap{
+		"quote":   quoteUTF16,
+		"pattern": func(s string) string { return nativePattern(s, `\z`) },
+	})
 	template.Must(codeTemplate.Parse(javaCode))
+	template.Must(codeTemplate.New("java-validate-value").Parse(javaValidateValue))
+	template.Must(codeTemplate.New("java-validate-len").Parse(javaValidateLen))
 
 	for _, p := range packages {
 		var buf bytes.Buffer
@@ -51,6 +63,28 @@ func GenerateJava(basedir string, packages []*Package) error {
 			}
 		}
 		p.SuperClassNative = buf.String()
+
+		for _, s := range p.Structs {
+			s.title = s.Names["java"]
+			for _, f := range s.Fields {
+				f.NameNative, f.title = f.Names["java"], ""
+				if f.NameNative != "" {
+					// accessors
+					f.title = strings.Title(f.NameNative)
+				} else {
+					f.NameNative = f.Name
+					if IsJavaKeyword(f.NameNative) {
+						f.NameNative += "_"
+					}
+				}
+			}
+		}
+	}
+	if err := checkNativeNames(packages, "java", (*Struct).NameTitle, func(f *Field) string { return f.NameNative }); err != nil {
+		return err
+	}
+	if err := checkNativeNames(packages, "java", (*Struct).NameTitle, (*Field).NameTitle); err != nil {
+		return err
 	}
 
 	for _, p := range packages {
@@ -71,6 +105,38 @@ func GenerateJava(basedir string, packages []*Package) error {
 			}
 		}
 
+		f, err := os.Create(filepath.Join(pkgdir, "ColferJSON.java"))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if err := jsonTemplate.Execute(f, p); err != nil {
+			return err
+		}
+
+		f, err = os.Create(filepath.Join(pkgdir, "ColferDiff.java"))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if err := diffTemplate.Execute(f, p); err != nil {
+			return err
+		}
+
+		if p.HasAny() {
+			f, err = os.Create(filepath.Join(pkgdir, "ColferAny.java"))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			if err := anyTemplate.Execute(f, p); err != nil {
+				return err
+			}
+		}
+
 		for _, s := range p.Structs {
 			for _, f := range s.Fields {
 				switch f.Type {
@@ -103,12 +169,12 @@ func GenerateJava(basedir string, packages []*Package) error {
 					f.TypeNative = "String"
 				case "binary":
 					f.TypeNative = "byte[]"
+				case "any":
+					f.TypeNative = "ColferAny"
 				}
 
-				f.NameNative = f.Name
-				if IsJavaKeyword(f.NameNative) {
-					f.NameNative += "_"
-				}
+				f.MinNative = javaBound(f.Type, f.Min)
+				f.MaxNative = javaBound(f.Type, f.Max)
 			}
 
 			f, err := os.Create(filepath.Join(pkgdir, s.NameTitle()+".java"))
@@ -125,6 +191,40 @@ func GenerateJava(basedir string, packages []*Package) error {
 	return nil
 }
 
+// javaBound returns the Java literal of a range rule for datatype t, with
+// unsigned 64-bit integers in their signed representation.
+func javaBound(t, bound string) string {
+	if bound == "" {
+		return ""
+	}
+	switch t {
+	case "uint32", "int64":
+		return bound + "L"
+	case "uint64":
+		v, _ := strconv.ParseUint(bound, 10, 64)
+		return strconv.FormatInt(int64(v), 10) + "L"
+	case "float32":
+		return bound + "f"
+	}
+	return bound
+}
+
+// javaValidateValue has the integer of a range rule, with unsigned values
+// up to 32 bits widened.
+const javaValidateValue = `
+{{- if eq .Type "uint8"}}(this.{{.NameNative}} & 0xff)
+{{- else if eq .Type "uint16"}}(this.{{.NameNative}} & 0xffff)
+{{- else if eq .Type "uint32"}}(this.{{.NameNative}} & 0xffffffffL)
+{{- else}}this.{{.NameNative}}
+{{- end}}`
+
+// javaValidateLen has the size of a size rule.
+const javaValidateLen = `
+{{- if .TypeList}}(this.{{.NameNative}} == null ? 0 : this.{{.NameNative}}.length)
+{{- else if eq .Type "text"}}(this.{{.NameNative}} == null ? 0 : this.{{.NameNative}}.getBytes(StandardCharsets.UTF_8).length)
+{{- else}}(this.{{.NameNative}} == null ? 0 : this.{{.NameNative}}.length)
+{{- end}}`
+
 const javaPackage = `// Code generated by colf(1); DO NOT EDIT.
 // The compiler used schema file {{.SchemaFileList}}.
 
@@ -149,21 +249,37 @@ import java.io.ObjectStreamException;
 import java.io.OutputStream;
 import java.io.Serializable;
 {{- if .HasText}}
+import java.nio.charset.CharacterCodingException;
+{{- end}}
+{{- if or .HasText .HasAny}}
 import java.nio.charset.StandardCharsets;
 {{- end}}
 import java.util.InputMismatchException;
 import java.nio.BufferOverflowException;
 import java.nio.BufferUnderflowException;
+{{- if .HasText}}
+import java.nio.ByteBuffer;
+{{- end}}
+import java.security.MessageDigest;
 
 
 /**
  * Data bean with built-in serialization support.
-{{.DocText " * "}}
+{{.DocTextBody " * "}}
+{{- if .Since}}
+ * @since {{.Since}}
+{{- end}}
+{{- if .Deprecated}}
+ * @deprecated {{.DeprecatedText}}
+{{- end}}
  * @author generated by colf(1)
  * @see <a href="https://github.com/pascaldekloe/colfer">Colfer's home</a>
  */
 @javax.annotation.Generated(value="colf(1)", comments="Colfer from schema file {{.SchemaFile}}")
-{{$class := .NameTitle}}public class {{$class}}{{if .Pkg.SuperClassNative}} extends {{.Pkg.SuperClassNative}}{{end}} implements Serializable {
+{{- if .Deprecated}}
+@Deprecated
+{{- end}}
+{{$class := .NameTitle}}public class {{$class}}{{if .Pkg.SuperClassNative}} extends {{.Pkg.SuperClassNative}}{{end}} implements Serializable{{if .Pkg.HasAny}}, ColferAny{{end}} {
 
 	/** The upper limit for serial byte sizes. */
 	public static int colferSizeMax = {{.Pkg.SizeMax}};
@@ -171,14 +287,36 @@ import java.nio.BufferUnderflowException;
 	/** The upper limit for the number of elements in a list. */
 	public static int colferListMax = {{.Pkg.ListMax}};
 {{end}}
+	/** The upper limit for the number of nested data structure levels, including the root. */
+	public static int colferDepthMax = {{.Pkg.DepthMax}};
+
 
 {{range .Fields}}
 {{if .Docs}}
 	/**
-{{.DocText "\t * "}}
+ {{- with .DocTextBody "\t * "}}
+{{.}}
+ {{- end}}
+ {{- if .Since}}
+	 * @since {{.Since}}
+ {{- end}}
+ {{- if .Deprecated}}
+	 * @deprecated {{.DeprecatedText}}
+ {{- end}}
 	 */
+{{- end}}
+{{- if .Deprecated}}
+	@Deprecated
 {{- end}}
 	public {{.TypeNative}}{{if .TypeList}}[]{{end}} {{.NameNative}};{{end}}
+{{- if and .Pkg.Lazy .HasStruct}}
+{{range .Fields}}{{if .TypeRef}}
+	// Undecoded serial of {@link #{{.NameNative}}}, if any.
+	private byte[] _{{.NameNative}}Serial;
+	// Field selection for {@link #_{{.NameNative}}Serial}.
+	private String[] _{{.NameNative}}Fields;
+{{- end}}{{end}}
+{{- end}}
 
 
 	/** Default constructor */
@@ -285,7 +423,7 @@ import java.nio.BufferUnderflowException;
 				} else if (i == buf.length) {
 					byte[] src = this.buf;
 					// TODO: better size estimation
-					if (offset == 0) this.buf = new byte[Math.min({{$class}}.colferSizeMax, this.buf.length * 4)];
+					if (offset == 0) this.buf = new byte[(int) Math.min({{$class}}.colferSizeMax, this.buf.length * 4L)];
 					System.arraycopy(src, this.offset, this.buf, 0, this.i - this.offset);
 					this.i -= this.offset;
 					this.offset = 0;
@@ -328,7 +466,7 @@ import java.nio.BufferUnderflowException;
 			try {
 				i = marshal(buf, 0);
 			} catch (BufferOverflowException e) {
-				buf = new byte[Math.min({{$class}}.colferSizeMax, buf.length * 4)];
+				buf = new byte[(int) Math.min({{$class}}.colferSizeMax, buf.length * 4L)];
 				continue;
 			}
 
@@ -713,8 +851,32 @@ import java.nio.BufferUnderflowException;
 				System.arraycopy(this.{{.NameNative}}, 0, buf, start, size);
 			}
  {{- end}}
+{{else if eq .Type "any"}}
+			if (this.{{.NameNative}} != null) {
+				buf[i++] = (byte) {{.Index}};
+				byte[] name = this.{{.NameNative}}.colferType().getBytes(StandardCharsets.UTF_8);
+
+				int x = name.length;
+				while (x > 0x7f) {
+					buf[i++] = (byte) (x | 0x80);
+					x >>>= 7;
+				}
+				buf[i++] = (byte) x;
+
+				System.arraycopy(name, 0, buf, i, name.length);
+				i += name.length;
+				i = this.{{.NameNative}}.marshal(buf, i);
+			}
 {{else if .TypeList}}
+{{- if .Struct.Pkg.Lazy}}
+			if (this._{{.NameNative}}Serial != null && this.{{.NameNative}} == _zero{{.NameTitle}}) {
+				buf[i++] = (byte) {{.Index}};
+				System.arraycopy(this._{{.NameNative}}Serial, 0, buf, i, this._{{.NameNative}}Serial.length);
+				i += this._{{.NameNative}}Serial.length;
+			} else if (this.{{.NameNative}}.length != 0) {
+{{- else}}
 			if (this.{{.NameNative}}.length != 0) {
+{{- end}}
 				buf[i++] = (byte) {{.Index}};
 				{{.TypeNative}}[] a = this.{{.NameNative}};
 
@@ -737,7 +899,15 @@ import java.nio.BufferUnderflowException;
 				}
 			}
 {{else}}
+{{- if .Struct.Pkg.Lazy}}
+			if (this._{{.NameNative}}Serial != null && this.{{.NameNative}} == null) {
+				buf[i++] = (byte) {{.Index}};
+				System.arraycopy(this._{{.NameNative}}Serial, 0, buf, i, this._{{.NameNative}}Serial.length);
+				i += this._{{.NameNative}}Serial.length;
+			} else if (this.{{.NameNative}} != null) {
+{{- else}}
 			if (this.{{.NameNative}} != null) {
+{{- end}}
 				buf[i++] = (byte) {{.Index}};
 				i = this.{{.NameNative}}.marshal(buf, i);
 			}
@@ -752,6 +922,203 @@ import java.nio.BufferUnderflowException;
 		}
 	}
 
+	/**
+	 * Feeds the serial into a digest, without materializing the serial as a whole.
+	 * Equal values produce the same digest input in each of the supported languages.
+	 * Unlike marshal, any {@code null} elements in lists are left as is.
+	 * @param md the digest to update.
+	 * @throws IllegalStateException on an upper limit breach defined by{{if .HasList}} either{{end}} {@link #colferSizeMax}{{if .HasList}} or {@link #colferListMax}{{end}}.
+	 */
+	public void colferHash(MessageDigest md) {
+{{- range .Fields}}{{if eq .Type "bool"}}
+		if (this.{{.NameNative}}) md.update((byte) {{.Index}});
+{{else if eq .Type "uint8"}}
+		if (this.{{.NameNative}} != 0) {
+			md.update((byte) {{.Index}});
+			md.update(this.{{.NameNative}});
+		}
+{{else if eq .Type "uint16"}}
+		if (this.{{.NameNative}} != 0) {
+			short x = this.{{.NameNative}};
+			if ((x & (short)0xff00) != 0) {
+				md.update((byte) {{.Index}});
+				md.update((byte) (x >>> 8));
+			} else {
+				md.update((byte) ({{.Index}} | 0x80));
+			}
+			md.update((byte) x);
+		}
+{{else if eq .Type "uint32"}}
+		if (this.{{.NameNative}} != 0) {
+			int x = this.{{.NameNative}};
+			if ((x & ~((1 << 21) - 1)) != 0) {
+				md.update((byte) ({{.Index}} | 0x80));
+				hashFixed(md, x, 4);
+			} else {
+				md.update((byte) {{.Index}});
+				hashVarint(md, x);
+			}
+		}
+{{else if eq .Type "uint64"}}
+		if (this.{{.NameNative}} != 0) {
+			long x = this.{{.NameNative}};
+			if ((x & ~((1L << 49) - 1)) != 0) {
+				md.update((byte) ({{.Index}} | 0x80));
+				hashFixed(md, x, 8);
+			} else {
+				md.update((byte) {{.Index}});
+				hashVarint(md, x);
+			}
+		}
+{{else if eq .Type "int32" "int64"}}
+		if (this.{{.NameNative}} != 0) {
+			{{.TypeNative}} x = this.{{.NameNative}};
+			if (x < 0) {
+				x = -x;
+				md.update((byte) ({{.Index}} | 0x80));
+			} else
+				md.update((byte) {{.Index}});
+			hashVarint(md, {{if eq .Type "int32"}}x & 0xffffffffL{{else}}x{{end}});
+		}
+{{else if eq .Type "float32" "float64"}}
+ {{- if .TypeList}}
+		if (this.{{.NameNative}}.length != 0) {
+			{{.TypeNative}}[] a = this.{{.NameNative}};
+			if (a.length > {{$class}}.colferListMax)
+				throw new IllegalStateException(format("colfer: {{.String}} length %d exceeds %d elements", a.length, {{$class}}.colferListMax));
+			md.update((byte) {{.Index}});
+			hashVarint(md, a.length);
+			for ({{.TypeNative}} f : a)
+  {{- if eq .Type "float32"}}
+				hashFixed(md, Float.floatToRawIntBits(f), 4);
+  {{- else}}
+				hashFixed(md, Double.doubleToRawLongBits(f), 8);
+  {{- end}}
+		}
+ {{- else if eq .Type "float32"}}
+		if (this.{{.NameNative}} != 0.0f) {
+			md.update((byte) {{.Index}});
+			hashFixed(md, Float.floatToRawIntBits(this.{{.NameNative}}), 4);
+		}
+ {{- else}}
+		if (this.{{.NameNative}} != 0.0) {
+			md.update((byte) {{.Index}});
+			hashFixed(md, Double.doubleToRawLongBits(this.{{.NameNative}}), 8);
+		}
+ {{- end}}
+{{else if eq .Type "timestamp"}}
+		if (this.{{.NameNative}} != null) {
+			long s = this.{{.NameNative}}.getEpochSecond();
+			int ns = this.{{.NameNative}}.getNano();
+			if (s != 0 || ns != 0) {
+				if (s >= 0 && s < (1L << 32)) {
+					md.update((byte) {{.Index}});
+					hashFixed(md, s, 4);
+				} else {
+					md.update((byte) ({{.Index}} | 0x80));
+					hashFixed(md, s, 8);
+				}
+				hashFixed(md, ns, 4);
+			}
+		}
+{{else if eq .Type "text" "binary"}}
+ {{- if .TypeList}}
+		if (this.{{.NameNative}}.length != 0) {
+			{{.TypeNative}}[] a = this.{{.NameNative}};
+			if (a.length > {{$class}}.colferListMax)
+				throw new IllegalStateException(format("colfer: {{.String}} length %d exceeds %d elements", a.length, {{$class}}.colferListMax));
+			md.update((byte) {{.Index}});
+			hashVarint(md, a.length);
+			for (int ai = 0; ai < a.length; ai++) {
+				if (a[ai] == null) {
+					md.update((byte) 0);
+					continue;
+				}
+  {{- if eq .Type "text"}}
+				byte[] b = a[ai].getBytes(StandardCharsets.UTF_8);
+				if (b.length > {{$class}}.colferSizeMax)
+					throw new IllegalStateException(format("colfer: {{.String}}[%d] size %d exceeds %d UTF-8 bytes", ai, b.length, {{$class}}.colferSizeMax));
+  {{- else}}
+				byte[] b = a[ai];
+				if (b.length > {{$class}}.colferSizeMax)
+					throw new IllegalStateException(format("colfer: {{.String}}[%d] size %d exceeds %d bytes", ai, b.length, {{$class}}.colferSizeMax));
+  {{- end}}
+				hashVarint(md, b.length);
+				md.update(b);
+			}
+		}
+ {{- else}}
+		if ({{if eq .Type "text"}}! this.{{.NameNative}}.isEmpty(){{else}}this.{{.NameNative}}.length != 0{{end}}) {
+  {{- if eq .Type "text"}}
+			byte[] b = this.{{.NameNative}}.getBytes(StandardCharsets.UTF_8);
+			if (b.length > {{$class}}.colferSizeMax)
+				throw new IllegalStateException(format("colfer: {{.String}} size %d exceeds %d UTF-8 bytes", b.length, {{$class}}.colferSizeMax));
+  {{- else}}
+			byte[] b = this.{{.NameNative}};
+			if (b.length > {{$class}}.colferSizeMax)
+				throw new IllegalStateException(format("colfer: {{.String}} size %d exceeds %d bytes", b.length, {{$class}}.colferSizeMax));
+  {{- end}}
+			md.update((byte) {{.Index}});
+			hashVarint(md, b.length);
+			md.update(b);
+		}
+ {{- end}}
+{{else if eq .Type "any"}}
+		if (this.{{.NameNative}} != null) {
+			byte[] name = this.{{.NameNative}}.colferType().getBytes(StandardCharsets.UTF_8);
+			md.update((byte) {{.Index}});
+			hashVarint(md, name.length);
+			md.update(name);
+			this.{{.NameNative}}.colferHash(md);
+		}
+{{else if .TypeList}}
+{{- if .Struct.Pkg.Lazy}}
+		if (this._{{.NameNative}}Serial != null && this.{{.NameNative}} == _zero{{.NameTitle}}) {
+			md.update((byte) {{.Index}});
+			md.update(this._{{.NameNative}}Serial);
+		} else if (this.{{.NameNative}}.length != 0) {
+{{- else}}
+		if (this.{{.NameNative}}.length != 0) {
+{{- end}}
+			{{.TypeNative}}[] a = this.{{.NameNative}};
+			if (a.length > {{$class}}.colferListMax)
+				throw new IllegalStateException(format("colfer: {{.String}} length %d exceeds %d elements", a.length, {{$class}}.colferListMax));
+			md.update((byte) {{.Index}});
+			hashVarint(md, a.length);
+			for ({{.TypeNative}} o : a) {
+				if (o == null) md.update((byte) 0x7f);
+				else o.colferHash(md);
+			}
+		}
+{{else}}
+{{- if .Struct.Pkg.Lazy}}
+		if (this._{{.NameNative}}Serial != null && this.{{.NameNative}} == null) {
+			md.update((byte) {{.Index}});
+			md.update(this._{{.NameNative}}Serial);
+		} else if (this.{{.NameNative}} != null) {
+{{- else}}
+		if (this.{{.NameNative}} != null) {
+{{- end}}
+			md.update((byte) {{.Index}});
+			this.{{.NameNative}}.colferHash(md);
+		}
+{{end}}{{end}}
+		md.update((byte) 0x7f);
+	}
+
+	private static void hashVarint(MessageDigest md, long x) {
+		for (int n = 0; n < 8 && (x & ~0x7fL) != 0; n++) {
+			md.update((byte) (x | 0x80));
+			x >>>= 7;
+		}
+		md.update((byte) x);
+	}
+
+	private static void hashFixed(MessageDigest md, long x, int size) {
+		for (int shift = (size - 1) * 8; shift >= 0; shift -= 8)
+			md.update((byte) (x >>> shift));
+	}
+
 	/**
 	 * Deserializes the object.
 	 * @param buf the data source.
@@ -776,12 +1143,183 @@ import java.nio.BufferUnderflowException;
 	 * @throws InputMismatchException when the data does not match this object's schema.
 	 */
 	public int unmarshal(byte[] buf, int offset, int end) {
+		return unmarshal(buf, offset, end, 1);
+	}
+
+	/**
+	 * Deserializes the object as a nested data structure.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param depth the number of data structure levels, including this object.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by either {@link #colferSizeMax}{{if .HasList}}, {@link #colferListMax}{{end}} or {@link #colferDepthMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema.
+	 */
+	public int unmarshal(byte[] buf, int offset, int end, int depth) {
+		return unmarshal(buf, offset, end, depth, null);
+	}
+
+	/**
+	 * Deserializes the selected fields only. The other fields are skipped
+	 * without decoding, and they keep their current value.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param fields the field paths, with the schema names separated by dots, e.g., {@code "o.s"},
+	 * or {@code null} for all. A data structure field selects all of its nested fields.
+	 * See {@link #hasFieldPath(String)} for validation.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by{{if .HasList}} either{{end}} {@link #colferSizeMax}{{if .HasList}} or {@link #colferListMax}{{end}}.
+	 * @throws InputMismatchException when the data does not match this object's schema.
+	 */
+	public int unmarshal(byte[] buf, int offset, int end, String[] fields) {
+		return unmarshal(buf, offset, end, 1, fields);
+	}
+
+	/**
+	 * Deserializes the object like {@link #unmarshal(byte[], int, int)}, yet it
+	 * also rejects any serial which differs from the marshal output for the same
+	 * data, including the nested data structures.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by{{if .HasList}} either{{end}} {@link #colferSizeMax}{{if .HasList}} or {@link #colferListMax}{{end}}.
+	 * @throws InputMismatchException when the data does not match this object's schema, or when the encoding is not canonical.
+	 */
+	public int unmarshalStrict(byte[] buf, int offset, int end) {
+		return unmarshal(buf, offset, end, 1, null, true, false, null);
+	}
+
+	/**
+	 * Deserializes the selected fields of the object as a nested data structure.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param depth the number of data structure levels, including this object.
+	 * @param fields the field paths, or {@code null} for all. See {@link #unmarshal(byte[], int, int, String[])}.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by either {@link #colferSizeMax}{{if .HasList}}, {@link #colferListMax}{{end}} or {@link #colferDepthMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema.
+	 */
+	public int unmarshal(byte[] buf, int offset, int end, int depth, String[] fields) {
+		return unmarshal(buf, offset, end, depth, fields, false, false, null);
+	}
+
+	/**
+	 * Deserializes the selected fields of the object as a nested data structure.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param depth the number of data structure levels, including this object.
+	 * @param fields the field paths, or {@code null} for all. See {@link #unmarshal(byte[], int, int, String[])}.
+	 * @param strict whether to reject any serial which differs from the marshal output for the same data.
+	 * @param validate whether to check the selected fields against the schema rules, conform {@link #validate()}.
+	 * @param budget the number of bytes which may still be allocated in its only element, or {@code null} for no limit.
+	 * Each allocation of field values, including those of nested data structures, is deducted before it happens.
+	 * Text and binaries count their size in bytes, list elements count 8 bytes each (4 for {@code float32}),
+	 * and a nested data structure counts 8 bytes per field.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by either {@link #colferSizeMax}{{if .HasList}}, {@link #colferListMax}{{end}} or {@link #colferDepthMax}, or when an allocation exceeds the {@code budget}.
+	 * @throws InputMismatchException when the data does not match this object's schema, or with {@code strict} when the encoding is not canonical, or with {@code validate} when a schema rule is broken.
+	 */
+	public int unmarshal(byte[] buf, int offset, int end, int depth, String[] fields, boolean strict, boolean validate, long[] budget) {
+		if (depth > {{$class}}.colferDepthMax)
+			throw new SecurityException(format("colfer: {{.String}} exceeds nesting depth %d", {{$class}}.colferDepthMax));
+		if (depth > 1) charge(budget, "{{.String}}", {{len .Fields}} * 8L);
 		if (end > buf.length) end = buf.length;
 		int i = offset;
 
 		try {
 			byte header = buf[i++];
-{{range .Fields}}{{if eq .Type "bool"}}
+{{range .Fields}}
+			if (fields != null && {{if eq .Type "uint16" "uint32" "uint64" "int32" "int64" "timestamp"}}(header & 0x7f) == {{.Index}}{{else}}header == (byte) {{.Index}}{{end}} && !selects(fields, "{{.Name}}")) {
+{{- if eq .Type "uint8"}}
+				i++;
+{{- else if eq .Type "uint16"}}
+				i += header < 0 ? 1 : 2;
+{{- else if eq .Type "timestamp"}}
+				i += header < 0 ? 12 : 8;
+{{- else if eq .Type "uint32" "uint64" "int32" "int64"}}
+ {{- if eq .Type "uint32" "uint64"}}
+				if (header < 0) {
+					i += {{if eq .Type "uint32"}}4{{else}}8{{end}};
+				} else {
+					for (int shift = 0; true; shift += 7)
+						if (buf[i++] >= 0 || shift == {{if eq .Type "uint32"}}28{{else}}56{{end}}) break;
+				}
+ {{- else}}
+				for (int shift = 0; true; shift += 7)
+					if (buf[i++] >= 0 || shift == {{if eq .Type "int32"}}28{{else}}56{{end}}) break;
+ {{- end}}
+{{- else if and (eq .Type "float32" "float64") (not .TypeList)}}
+				i += {{if eq .Type "float32"}}4{{else}}8{{end}};
+{{- else if and .TypeRef (not .TypeList)}}
+				i = new {{.TypeNative}}().unmarshal(buf, i, end, depth + 1, NO_FIELDS, strict, false, null);
+{{- else if eq .Type "any"}}
+				int size = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					size |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (size < 0 || size > {{$class}}.colferSizeMax)
+					throw new SecurityException(format("colfer: {{.String}} type size %d exceeds %d bytes", size, {{$class}}.colferSizeMax));
+
+				if (size >= end - i) {
+					i += size; // reports in finally
+					throw new BufferUnderflowException();
+				}
+				int start = i;
+				i += size;
+				ColferAny skip = ColferAny.newInstance(new String(buf, start, size, StandardCharsets.UTF_8));
+				if (skip == null)
+					throw new InputMismatchException(format("colfer: unknown header at byte %d", start));
+				i = skip.unmarshal(buf, i, end, depth + 1, NO_FIELDS, strict, false, null);
+{{- else if ne .Type "bool"}}
+				int length = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					length |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+ {{- if not .TypeList}}
+				if (length < 0 || length > {{$class}}.colferSizeMax)
+					throw new SecurityException(format("colfer: {{.String}} size %d exceeds %d {{if eq .Type "text"}}UTF-8 {{end}}bytes", length, {{$class}}.colferSizeMax));
+				i += length;
+ {{- else}}
+				if (length < 0 || length > {{$class}}.colferListMax)
+					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{$class}}.colferListMax));
+  {{- if eq .Type "float32" "float64"}}
+				i += length * {{if eq .Type "float32"}}4{{else}}8{{end}};
+  {{- else if .TypeRef}}
+				{{.TypeNative}} skip = new {{.TypeNative}}();
+				for (int ai = 0; ai < length; ai++)
+					i = skip.unmarshal(buf, i, end, depth + 1, NO_FIELDS, strict, false, null);
+  {{- else}}
+				for (int ai = 0; ai < length; ai++) {
+					int size = 0;
+					for (int shift = 0; true; shift += 7) {
+						byte b = buf[i++];
+						size |= (b & 0x7f) << shift;
+						if (shift == 28 || b >= 0) break;
+					}
+					if (size < 0 || size > {{$class}}.colferSizeMax)
+						throw new SecurityException(format("colfer: {{.String}}[%d] size %d exceeds %d {{if eq .Type "text"}}UTF-8 {{end}}bytes", ai, size, {{$class}}.colferSizeMax));
+					i += size;
+				}
+  {{- end}}
+ {{- end}}
+{{- end}}
+				header = buf[i++];
+			}
+{{if eq .Type "bool"}}
 			if (header == (byte) {{.Index}}) {
 				this.{{.NameNative}} = true;
 				header = buf[i++];
@@ -789,32 +1327,40 @@ import java.nio.BufferUnderflowException;
 {{else if eq .Type "uint8"}}
 			if (header == (byte) {{.Index}}) {
 				this.{{.NameNative}} = buf[i++];
+				if (strict && this.{{.NameNative}} == 0) throw nonCanonical(i - 2);
 				header = buf[i++];
 			}
 {{else if eq .Type "uint16"}}
 			if (header == (byte) {{.Index}}) {
 				this.{{.NameNative}} = (short) ((buf[i++] & 0xff) << 8 | (buf[i++] & 0xff));
+				if (strict && (this.{{.NameNative}} & 0xffff) < 1 << 8) throw nonCanonical(i - 3);
 				header = buf[i++];
 			} else if (header == (byte) ({{.Index}} | 0x80)) {
 				this.{{.NameNative}} = (short) (buf[i++] & 0xff);
+				if (strict && this.{{.NameNative}} == 0) throw nonCanonical(i - 2);
 				header = buf[i++];
 			}
 {{else if eq .Type "uint32"}}
 			if (header == (byte) {{.Index}}) {
+				int start = i;
 				int x = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					x |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict && (x == 0 || (x & ~((1 << 21) - 1)) != 0 || i - start != varintSize(x)))
+					throw nonCanonical(start - 1);
 				this.{{.NameNative}} = x;
 				header = buf[i++];
 			} else if (header == (byte) ({{.Index}} | 0x80)) {
 				this.{{.NameNative}} = (buf[i++] & 0xff) << 24 | (buf[i++] & 0xff) << 16 | (buf[i++] & 0xff) << 8 | (buf[i++] & 0xff);
+				if (strict && (this.{{.NameNative}} & ~((1 << 21) - 1)) == 0) throw nonCanonical(i - 5);
 				header = buf[i++];
 			}
 {{else if eq .Type "uint64"}}
 			if (header == (byte) {{.Index}}) {
+				int start = i;
 				long x = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
@@ -824,35 +1370,45 @@ import java.nio.BufferUnderflowException;
 					}
 					x |= (b & 0x7fL) << shift;
 				}
+				if (strict && (x == 0 || (x & ~((1L << 49) - 1)) != 0 || i - start != varintSize(x)))
+					throw nonCanonical(start - 1);
 				this.{{.NameNative}} = x;
 				header = buf[i++];
 			} else if (header == (byte) ({{.Index}} | 0x80)) {
 				this.{{.NameNative}} = (buf[i++] & 0xffL) << 56 | (buf[i++] & 0xffL) << 48 | (buf[i++] & 0xffL) << 40 | (buf[i++] & 0xffL) << 32
 					| (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+				if (strict && (this.{{.NameNative}} & ~((1L << 49) - 1)) == 0) throw nonCanonical(i - 9);
 				header = buf[i++];
 			}
 {{else if eq .Type "int32"}}
 			if (header == (byte) {{.Index}}) {
+				int start = i;
 				int x = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					x |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict && (x <= 0 || i - start != varintSize(x) || (buf[i - 1] & 0xf0) != 0 && i - start == 5))
+					throw nonCanonical(start - 1);
 				this.{{.NameNative}} = x;
 				header = buf[i++];
 			} else if (header == (byte) ({{.Index}} | 0x80)) {
+				int start = i;
 				int x = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					x |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict && (x == 0 || x < 0 && x != Integer.MIN_VALUE || i - start != varintSize(x & 0xffffffffL) || (buf[i - 1] & 0xf0) != 0 && i - start == 5))
+					throw nonCanonical(start - 1);
 				this.{{.NameNative}} = -x;
 				header = buf[i++];
 			}
 {{else if eq .Type "int64"}}
 			if (header == (byte) {{.Index}}) {
+				int start = i;
 				long x = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
@@ -862,9 +1418,12 @@ import java.nio.BufferUnderflowException;
 					}
 					x |= (b & 0x7fL) << shift;
 				}
+				if (strict && (x <= 0 || i - start != varintSize(x)))
+					throw nonCanonical(start - 1);
 				this.{{.NameNative}} = x;
 				header = buf[i++];
 			} else if (header == (byte) ({{.Index}} | 0x80)) {
+				int start = i;
 				long x = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
@@ -874,21 +1433,33 @@ import java.nio.BufferUnderflowException;
 					}
 					x |= (b & 0x7fL) << shift;
 				}
+				if (strict && (x == 0 || x < 0 && x != Long.MIN_VALUE || i - start != varintSize(x)))
+					throw nonCanonical(start - 1);
 				this.{{.NameNative}} = -x;
 				header = buf[i++];
 			}
 {{else if eq .Type "float32"}}
 			if (header == (byte) {{.Index}}) {
  {{- if .TypeList}}
+				int at = i - 1;
 				int length = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict) {
+					if (length == 0) throw nonCanonical(at);
+					if (i - at - 1 != varintSize(length)) throw nonCanonical(i - 1);
+				}
 				if (length < 0 || length > {{$class}}.colferListMax)
 					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{$class}}.colferListMax));
 
+				if ((long) length * 4 >= end - i) {
+					i = (int) Math.min(i + (long) length * 4, Integer.MAX_VALUE); // reports in finally
+					throw new BufferUnderflowException();
+				}
+				charge(budget, "{{.String}}", length * 4L);
 				float[] a = new float[length];
 				for (int ai = 0; ai < length; ai++) {
 					int x = (buf[i++] & 0xff) << 24 | (buf[i++] & 0xff) << 16 | (buf[i++] & 0xff) << 8 | (buf[i++] & 0xff);
@@ -897,6 +1468,7 @@ import java.nio.BufferUnderflowException;
 				this.{{.NameNative}} = a;
  {{- else}}
 				int x = (buf[i++] & 0xff) << 24 | (buf[i++] & 0xff) << 16 | (buf[i++] & 0xff) << 8 | (buf[i++] & 0xff);
+				if (strict && (x & 0x7fffffff) == 0) throw nonCanonical(i - 5);
 				this.{{.NameNative}} = Float.intBitsToFloat(x);
  {{- end}}
 				header = buf[i++];
@@ -904,15 +1476,25 @@ import java.nio.BufferUnderflowException;
 {{else if eq .Type "float64"}}
 			if (header == (byte) {{.Index}}) {
  {{- if .TypeList}}
+				int at = i - 1;
 				int length = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict) {
+					if (length == 0) throw nonCanonical(at);
+					if (i - at - 1 != varintSize(length)) throw nonCanonical(i - 1);
+				}
 				if (length < 0 || length > {{$class}}.colferListMax)
 					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{$class}}.colferListMax));
 
+				if ((long) length * 8 >= end - i) {
+					i = (int) Math.min(i + (long) length * 8, Integer.MAX_VALUE); // reports in finally
+					throw new BufferUnderflowException();
+				}
+				charge(budget, "{{.String}}", length * 8L);
 				double[] a = new double[length];
 				for (int ai = 0; ai < length; ai++) {
 					long x = (buf[i++] & 0xffL) << 56 | (buf[i++] & 0xffL) << 48 | (buf[i++] & 0xffL) << 40 | (buf[i++] & 0xffL) << 32
@@ -923,6 +1505,7 @@ import java.nio.BufferUnderflowException;
  {{- else}}
 				long x = (buf[i++] & 0xffL) << 56 | (buf[i++] & 0xffL) << 48 | (buf[i++] & 0xffL) << 40 | (buf[i++] & 0xffL) << 32
 					| (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+				if (strict && (x & 0x7fffffffffffffffL) == 0) throw nonCanonical(i - 9);
 				this.{{.NameNative}} = Double.longBitsToDouble(x);
  {{- end}}
 				header = buf[i++];
@@ -931,55 +1514,82 @@ import java.nio.BufferUnderflowException;
 			if (header == (byte) {{.Index}}) {
 				long s = (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
 				long ns = (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+				if (strict && ns >= 1000000000) throw nonCanonical(i - 4);
 				this.{{.NameNative}} = java.time.Instant.ofEpochSecond(s, ns);
 				header = buf[i++];
 			} else if (header == (byte) ({{.Index}} | 0x80)) {
 				long s = (buf[i++] & 0xffL) << 56 | (buf[i++] & 0xffL) << 48 | (buf[i++] & 0xffL) << 40 | (buf[i++] & 0xffL) << 32
 					| (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
 				long ns = (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+				if (strict) {
+					if (s >= 0 && s < (1L << 32)) throw nonCanonical(i - 13);
+					if (ns >= 1000000000) throw nonCanonical(i - 4);
+				}
 				this.{{.NameNative}} = java.time.Instant.ofEpochSecond(s, ns);
 				header = buf[i++];
 			}
 {{else if eq .Type "text"}}
 			if (header == (byte) {{.Index}}) {
  {{- if .TypeList}}
+				int at = i - 1;
 				int length = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict) {
+					if (length == 0) throw nonCanonical(at);
+					if (i - at - 1 != varintSize(length)) throw nonCanonical(i - 1);
+				}
 				if (length < 0 || length > {{$class}}.colferListMax)
 					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{$class}}.colferListMax));
 
+				// each element takes at least one byte
+				if (length >= end - i) {
+					i += length; // reports in finally
+					throw new BufferUnderflowException();
+				}
+				charge(budget, "{{.String}}", length * 8L);
 				{{.TypeNative}}[] a = new {{.TypeNative}}[length];
 				for (int ai = 0; ai < length; ai++) {
+					int sizeAt = i;
 					int size = 0;
 					for (int shift = 0; true; shift += 7) {
 						byte b = buf[i++];
 						size |= (b & 0x7f) << shift;
 						if (shift == 28 || b >= 0) break;
 					}
+					if (strict && i - sizeAt != varintSize(size)) throw nonCanonical(i - 1);
 					if (size < 0 || size > {{$class}}.colferSizeMax)
 						throw new SecurityException(format("colfer: {{.String}}[%d] size %d exceeds %d UTF-8 bytes", ai, size, {{$class}}.colferSizeMax));
 
 					int start = i;
 					i += size;
+					if (strict) checkUTF8(buf, start, size);
+					charge(budget, "{{.String}}", size);
 					a[ai] = new String(buf, start, size, StandardCharsets.UTF_8);
 				}
 				this.{{.NameNative}} = a;
  {{- else}}
+				int at = i - 1;
 				int size = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					size |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict) {
+					if (size == 0) throw nonCanonical(at);
+					if (i - at - 1 != varintSize(size)) throw nonCanonical(i - 1);
+				}
 				if (size < 0 || size > {{$class}}.colferSizeMax)
 					throw new SecurityException(format("colfer: {{.String}} size %d exceeds %d UTF-8 bytes", size, {{$class}}.colferSizeMax));
 
 				int start = i;
 				i += size;
+				if (strict) checkUTF8(buf, start, size);
+				charge(budget, "{{.String}}", size);
 				this.{{.NameNative}} = new String(buf, start, size, StandardCharsets.UTF_8);
  {{- end}}
 				header = buf[i++];
@@ -987,26 +1597,44 @@ import java.nio.BufferUnderflowException;
 {{else if eq .Type "binary"}}
  {{- if .TypeList}}
 			if (header == (byte) {{.Index}}) {
+				int at = i - 1;
 				int length = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict) {
+					if (length == 0) throw nonCanonical(at);
+					if (i - at - 1 != varintSize(length)) throw nonCanonical(i - 1);
+				}
 				if (length < 0 || length > {{$class}}.colferListMax)
 					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{$class}}.colferListMax));
 
+				// each element takes at least one byte
+				if (length >= end - i) {
+					i += length; // reports in finally
+					throw new BufferUnderflowException();
+				}
+				charge(budget, "{{.String}}", length * 8L);
 				byte[][] a = new byte[length][];
 				for (int ai = 0; ai < length; ai++) {
+					int sizeAt = i;
 					int size = 0;
 					for (int shift = 0; true; shift += 7) {
 						byte b = buf[i++];
 						size |= (b & 0x7f) << shift;
 						if (shift == 28 || b >= 0) break;
 					}
+					if (strict && i - sizeAt != varintSize(size)) throw nonCanonical(i - 1);
 					if (size < 0 || size > {{$class}}.colferSizeMax)
 						throw new SecurityException(format("colfer: {{.String}}[%d] size %d exceeds %d bytes", ai, size, {{$class}}.colferSizeMax));
 
+					if (size >= end - i) {
+						i += size; // reports in finally
+						throw new BufferUnderflowException();
+					}
+					charge(budget, "{{.String}}", size);
 					byte[] e = new byte[size];
 					int start = i;
 					i += size;
@@ -1019,15 +1647,29 @@ import java.nio.BufferUnderflowException;
 			}
  {{- else}}
 			if (header == (byte) {{.Index}}) {
+				int at = i - 1;
 				int size = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					size |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict) {
+					if (size == 0) throw nonCanonical(at);
+					if (i - at - 1 != varintSize(size)) throw nonCanonical(i - 1);
+				}
 				if (size < 0 || size > {{$class}}.colferSizeMax)
 					throw new SecurityException(format("colfer: {{.String}} size %d exceeds %d bytes", size, {{$class}}.colferSizeMax));
 
+				if (size >= end - i) {
+					i += size; // reports in finally
+					throw new BufferUnderflowException();
+				}
+ {{- if .TypeColfer}}
+				if (size != 0 && buf[i + size - 1] != 0x7f)
+					throw new InputMismatchException(format("colfer: unknown header at byte %d", i + size - 1));
+ {{- end}}
+				charge(budget, "{{.String}}", size);
 				this.{{.NameNative}} = new byte[size];
 				int start = i;
 				i += size;
@@ -1036,30 +1678,105 @@ import java.nio.BufferUnderflowException;
 				header = buf[i++];
 			}
  {{- end}}
+{{else if eq .Type "any"}}
+			if (header == (byte) {{.Index}}) {
+				int at = i - 1;
+				int size = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					size |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (strict && i - at - 1 != varintSize(size)) throw nonCanonical(i - 1);
+				if (size < 0 || size > {{$class}}.colferSizeMax)
+					throw new SecurityException(format("colfer: {{.String}} type size %d exceeds %d bytes", size, {{$class}}.colferSizeMax));
+
+				if (size >= end - i) {
+					i += size; // reports in finally
+					throw new BufferUnderflowException();
+				}
+				int start = i;
+				i += size;
+				ColferAny v = ColferAny.newInstance(new String(buf, start, size, StandardCharsets.UTF_8));
+				if (v == null)
+					throw new InputMismatchException(format("colfer: unknown header at byte %d", start));
+				i = v.unmarshal(buf, i, end, depth + 1, within(fields, "{{.Name}}"), strict, validate, budget);
+				this.{{.NameNative}} = v;
+				header = buf[i++];
+			}
 {{else if .TypeList}}
 			if (header == (byte) {{.Index}}) {
+				int at = i - 1;
 				int length = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict) {
+					if (length == 0) throw nonCanonical(at);
+					if (i - at - 1 != varintSize(length)) throw nonCanonical(i - 1);
+				}
 				if (length < 0 || length > {{$class}}.colferListMax)
 					throw new SecurityException(format("colfer: {{.String}} length %d exceeds %d elements", length, {{$class}}.colferListMax));
 
+				// each element takes at least one byte
+				if (length >= end - i) {
+					i += length; // reports in finally
+					throw new BufferUnderflowException();
+				}
+{{- if .Struct.Pkg.Lazy}}
+				if (! strict && ! validate && budget == null) {
+					{{.TypeNative}} skip = new {{.TypeNative}}();
+					for (int ai = 0; ai < length; ai++)
+						i = skip.unmarshal(buf, i, end, depth + 1, NO_FIELDS, strict, false, null);
+					// includes the length
+					this._{{.NameNative}}Serial = java.util.Arrays.copyOfRange(buf, at + 1, i);
+					this._{{.NameNative}}Fields = within(fields, "{{.Name}}");
+					this.{{.NameNative}} = _zero{{.NameTitle}};
+				} else {
+					String[] sub = within(fields, "{{.Name}}");
+					charge(budget, "{{.String}}", length * 8L);
+					{{.TypeNative}}[] a = new {{.TypeNative}}[length];
+					for (int ai = 0; ai < length; ai++) {
+						{{.TypeNative}} o = new {{.TypeNative}}();
+						i = o.unmarshal(buf, i, end, depth + 1, sub, strict, validate, budget);
+						a[ai] = o;
+					}
+					this.{{.NameNative}} = a;
+					this._{{.NameNative}}Serial = null;
+				}
+{{- else}}
+				String[] sub = within(fields, "{{.Name}}");
+				charge(budget, "{{.String}}", length * 8L);
 				{{.TypeNative}}[] a = new {{.TypeNative}}[length];
 				for (int ai = 0; ai < length; ai++) {
 					{{.TypeNative}} o = new {{.TypeNative}}();
-					i = o.unmarshal(buf, i, end);
+					i = o.unmarshal(buf, i, end, depth + 1, sub, strict, validate, budget);
 					a[ai] = o;
 				}
 				this.{{.NameNative}} = a;
+{{- end}}
 				header = buf[i++];
 			}
 {{else}}
 			if (header == (byte) {{.Index}}) {
+{{- if .Struct.Pkg.Lazy}}
+				if (! strict && ! validate && budget == null) {
+					int start = i;
+					i = new {{.TypeNative}}().unmarshal(buf, i, end, depth + 1, NO_FIELDS, strict, false, null);
+					this._{{.NameNative}}Serial = java.util.Arrays.copyOfRange(buf, start, i);
+					this._{{.NameNative}}Fields = within(fields, "{{.Name}}");
+					this.{{.NameNative}} = null;
+				} else {
+					this.{{.NameNative}} = new {{.TypeNative}}();
+					i = this.{{.NameNative}}.unmarshal(buf, i, end, depth + 1, within(fields, "{{.Name}}"), strict, validate, budget);
+					this._{{.NameNative}}Serial = null;
+				}
+{{- else}}
 				this.{{.NameNative}} = new {{.TypeNative}}();
-				i = this.{{.NameNative}}.unmarshal(buf, i, end);
+				i = this.{{.NameNative}}.unmarshal(buf, i, end, depth + 1, within(fields, "{{.Name}}"), strict, validate, budget);
+{{- end}}
 				header = buf[i++];
 			}
 {{end}}{{end}}
@@ -1071,10 +1788,95 @@ import java.nio.BufferUnderflowException;
 				throw new SecurityException(format("colfer: {{.String}} exceeds %d bytes", {{$class}}.colferSizeMax));
 			if (i > end) throw new BufferUnderflowException();
 		}
+{{- if .HasRules}}
 
+		if (validate) validateFields(fields);
+{{- end}}
 		return i;
 	}
 
+	/**
+	 * Gets whether the path locates a field in the schema, with the names
+	 * separated by dots, e.g., {@code "o.s"}.
+	 * @param path the field path.
+	 * @return whether {@code path} is valid for {@link #unmarshal(byte[], int, int, String[])}.
+	 */
+	public static boolean hasFieldPath(String path) {
+		int dot = path.indexOf('.');
+		String name = dot < 0 ? path : path.substring(0, dot);
+		switch (name) {
+{{- range .Fields}}
+		case "{{.Name}}":
+{{- if .TypeRef}}
+			return dot < 0 || {{.TypeNative}}.hasFieldPath(path.substring(dot + 1));
+{{- else if eq .Type "any"}}
+			return dot < 0 || ColferAny.hasFieldPath(path.substring(dot + 1));
+{{- else}}
+			return dot < 0;
+{{- end}}
+{{- end}}
+		}
+		return false;
+	}
+
+{{if and .Pkg.Lazy .HasStruct}}	// Decodes any pending serial of the data structure fields.
+	private void decodeLazy() {
+{{- range .Fields}}{{if .TypeRef}}
+		get{{.NameTitle}}();
+{{- end}}{{end}}
+	}
+
+{{end}}	private static boolean selects(String[] fields, String name) {
+		for (String p : fields)
+			if (p.startsWith(name) && (p.length() == name.length() || p.charAt(name.length()) == '.'))
+				return true;
+		return false;
+	}
+{{- if or .HasStruct .HasAny}}
+
+	private static String[] within(String[] fields, String name) {
+		if (fields == null) return null;
+		java.util.List<String> paths = new java.util.ArrayList<>();
+		for (String p : fields) {
+			if (p.equals(name)) return null;
+			if (p.length() > name.length() && p.startsWith(name) && p.charAt(name.length()) == '.')
+				paths.add(p.substring(name.length() + 1));
+		}
+		return paths.toArray(NO_FIELDS);
+	}
+{{- end}}
+
+	private static int varintSize(long x) {
+		int n = 1;
+		for (; n < 9 && (x & ~0x7fL) != 0; x >>>= 7) n++;
+		return n;
+	}
+
+{{- if .HasText}}
+
+	private static void checkUTF8(byte[] buf, int offset, int length) {
+		ByteBuffer in = ByteBuffer.wrap(buf, offset, length);
+		try {
+			StandardCharsets.UTF_8.newDecoder().decode(in);
+		} catch (CharacterCodingException e) {
+			throw nonCanonical(in.position());
+		}
+	}
+{{- end}}
+
+	private static void charge(long[] budget, String field, long n) {
+		if (budget == null) return;
+		if (n > budget[0])
+			throw new SecurityException(format("colfer: %s exceeds the allocation budget", field));
+		budget[0] -= n;
+	}
+
+	private static InputMismatchException nonCanonical(int i) {
+		return new InputMismatchException(format("colfer: non-canonical encoding at byte %d", i));
+	}
+
+	private static final String[] NO_FIELDS = {};
+
 	// {@link Serializable} version number.
 	private static final long serialVersionUID = {{len .Fields}}L;
 
@@ -1111,32 +1913,360 @@ import java.nio.BufferUnderflowException;
 {{range .Fields}}
 	/**
 	 * Gets {{.String}}.
+{{- if and .Struct.Pkg.Lazy .TypeRef}}
+	 * The serial data is decoded on first access. Until then, {@link #{{.NameNative}}}
+	 * is {{if .TypeList}}empty{{else}}{@code null}{{end}}, and marshal copies the original serial data as is.
+{{- end}}
 	 * @return the value.
+{{- if .Deprecated}}
+	 * @deprecated {{.DeprecatedText}}
+	 */
+	@Deprecated
+{{- else}}
 	 */
+{{- end}}
 	public {{.TypeNative}}{{if .TypeList}}[]{{end}} get{{.NameTitle}}() {
+{{- if and .Struct.Pkg.Lazy .TypeRef}}
+		byte[] serial = this._{{.NameNative}}Serial;
+		if (serial != null) {
+			this._{{.NameNative}}Serial = null;
+ {{- if .TypeList}}
+			if (this.{{.NameNative}} == _zero{{.NameTitle}}) {
+				int i = 0;
+				int length = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = serial[i++];
+					length |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				{{.TypeNative}}[] a = new {{.TypeNative}}[length];
+				for (int ai = 0; ai < length; ai++) {
+					a[ai] = new {{.TypeNative}}();
+					i = a[ai].unmarshal(serial, i, serial.length, 1, this._{{.NameNative}}Fields);
+				}
+				this.{{.NameNative}} = a;
+			}
+ {{- else}}
+			if (this.{{.NameNative}} == null) {
+				{{.TypeNative}} v = new {{.TypeNative}}();
+				v.unmarshal(serial, 0, serial.length, 1, this._{{.NameNative}}Fields);
+				this.{{.NameNative}} = v;
+			}
+ {{- end}}
+		}
+{{- end}}
 		return this.{{.NameNative}};
 	}
 
 	/**
 	 * Sets {{.String}}.
 	 * @param value the replacement.
+{{- if .Deprecated}}
+	 * @deprecated {{.DeprecatedText}}
+	 */
+	@Deprecated
+{{- else}}
 	 */
+{{- end}}
 	public void set{{.NameTitle}}({{.TypeNative}}{{if .TypeList}}[]{{end}} value) {
 		this.{{.NameNative}} = value;
+{{- if and .Struct.Pkg.Lazy .TypeRef}}
+		this._{{.NameNative}}Serial = null;
+{{- end}}
 	}
 
 	/**
 	 * Sets {{.String}}.
 	 * @param value the replacement.
 	 * @return {link this}.
+{{- if .Deprecated}}
+	 * @deprecated {{.DeprecatedText}}
 	 */
+	@Deprecated
+{{- else}}
+	 */
+{{- end}}
 	public {{$class}} with{{.NameTitle}}({{.TypeNative}}{{if .TypeList}}[]{{end}} value) {
+{{- if and .Struct.Pkg.Lazy .TypeRef}}
+		set{{.NameTitle}}(value);
+{{- else}}
 		this.{{.NameNative}} = value;
+{{- end}}
 		return this;
 	}
 {{end}}
+	/**
+	 * Serializes the object as JSON. The members are named after the schema fields
+	 * and zero values are omitted. Timestamps are RFC 3339 strings in UTC and
+	 * binaries are base64 strings. NaN and infinite floating points are JSON strings too.
+	 * @return the JSON object.
+	 * @throws IllegalStateException when a timestamp exceeds the RFC 3339 range.
+	 */
+	public String toJSON() {
+		return toJSON(false);
+	}
+
+	/**
+	 * Serializes the object as JSON.
+	 * @param quote64 whether to encode 64-bit integers as JSON strings, which keeps them exact for JavaScript consumers.
+	 * @return the JSON object.
+	 * @throws IllegalStateException when a timestamp exceeds the RFC 3339 range.
+	 * @see #toJSON()
+	 */
+	public String toJSON(boolean quote64) {
+		StringBuilder buf = new StringBuilder();
+		toJSON(buf, quote64);
+		return buf.toString();
+	}
+
+	/**
+	 * Serializes the object as JSON.
+	 * @param buf the data destination.
+	 * @throws IllegalStateException when a timestamp exceeds the RFC 3339 range.
+	 * @see #toJSON()
+	 */
+	public void toJSON(StringBuilder buf) {
+		toJSON(buf, false);
+	}
+
+	/**
+	 * Serializes the object as JSON.
+	 * @param buf the data destination.
+	 * @param quote64 whether to encode 64-bit integers as JSON strings, including those of nested data structures.
+	 * @throws IllegalStateException when a timestamp exceeds the RFC 3339 range.
+	 * @see #toJSON()
+	 */
+	public void toJSON(StringBuilder buf, boolean quote64) {
+{{- if and .Pkg.Lazy .HasStruct}}
+		decodeLazy();
+{{- end}}
+		int start = buf.length();
+		buf.append('{');
+{{- range .Fields}}
+{{- if eq .Type "bool"}}
+		if (this.{{.NameNative}}) buf.append("\"{{.Name}}\":true,");
+{{- else if eq .Type "uint8"}}
+		if (this.{{.NameNative}} != 0) buf.append("\"{{.Name}}\":").append(this.{{.NameNative}} & 0xff).append(',');
+{{- else if eq .Type "uint16"}}
+		if (this.{{.NameNative}} != 0) buf.append("\"{{.Name}}\":").append(this.{{.NameNative}} & 0xffff).append(',');
+{{- else if eq .Type "uint32"}}
+		if (this.{{.NameNative}} != 0) buf.append("\"{{.Name}}\":").append(Integer.toUnsignedString(this.{{.NameNative}})).append(',');
+{{- else if eq .Type "int32"}}
+		if (this.{{.NameNative}} != 0) buf.append("\"{{.Name}}\":").append(this.{{.NameNative}}).append(',');
+{{- else if eq .Type "uint64" "int64"}}
+		if (this.{{.NameNative}} != 0) {
+			String x = {{if eq .Type "uint64"}}Long.toUnsignedString{{else}}Long.toString{{end}}(this.{{.NameNative}});
+			buf.append("\"{{.Name}}\":");
+			if (quote64) buf.append('"').append(x).append('"');
+			else buf.append(x);
+			buf.append(',');
+		}
+{{- else if eq .Type "float32" "float64"}}
+ {{- if .TypeList}}
+		if (this.{{.NameNative}}.length != 0) {
+			buf.append("\"{{.Name}}\":[");
+			for ({{.TypeNative}} f : this.{{.NameNative}}) {
+				ColferJSON.append{{if eq .Type "float32"}}Float{{else}}Double{{end}}(buf, f);
+				buf.append(',');
+			}
+			buf.setCharAt(buf.length() - 1, ']');
+			buf.append(',');
+		}
+ {{- else}}
+		if (this.{{.NameNative}} != 0.0{{if eq .Type "float32"}}f{{end}}) {
+			buf.append("\"{{.Name}}\":");
+			ColferJSON.append{{if eq .Type "float32"}}Float{{else}}Double{{end}}(buf, this.{{.NameNative}});
+			buf.append(',');
+		}
+ {{- end}}
+{{- else if eq .Type "timestamp"}}
+		if (this.{{.NameNative}} != null) {
+			buf.append("\"{{.Name}}\":");
+			ColferJSON.appendInstant(buf, this.{{.NameNative}}, "{{.String}}");
+			buf.append(',');
+		}
+{{- else if eq .Type "text" "binary"}}
+ {{- if .TypeList}}
+		if (this.{{.NameNative}}.length != 0) {
+			buf.append("\"{{.Name}}\":[");
+			for ({{.TypeNative}} v : this.{{.NameNative}}) {
+  {{- if eq .Type "text"}}
+				ColferJSON.appendText(buf, v == null ? "" : v);
+  {{- else}}
+				ColferJSON.appendBinary(buf, v == null ? _zeroBytes : v);
+  {{- end}}
+				buf.append(',');
+			}
+			buf.setCharAt(buf.length() - 1, ']');
+			buf.append(',');
+		}
+ {{- else if eq .Type "text"}}
+		if (! this.{{.NameNative}}.isEmpty()) {
+			buf.append("\"{{.Name}}\":");
+			ColferJSON.appendText(buf, this.{{.NameNative}});
+			buf.append(',');
+		}
+ {{- else}}
+		if (this.{{.NameNative}}.length != 0) {
+			buf.append("\"{{.Name}}\":");
+			ColferJSON.appendBinary(buf, this.{{.NameNative}});
+			buf.append(',');
+		}
+ {{- end}}
+{{- else if .TypeList}}
+		if (this.{{.NameNative}}.length != 0) {
+			buf.append("\"{{.Name}}\":[");
+			for ({{.TypeNative}} v : this.{{.NameNative}}) {
+				if (v == null) buf.append("{}");
+				else v.toJSON(buf, quote64);
+				buf.append(',');
+			}
+			buf.setCharAt(buf.length() - 1, ']');
+			buf.append(',');
+		}
+{{- else if eq .Type "any"}}
+		if (this.{{.NameNative}} != null) {
+			buf.append("\"{{.Name}}\":{\"type\":");
+			ColferJSON.appendText(buf, this.{{.NameNative}}.colferType());
+			buf.append(",\"value\":");
+			this.{{.NameNative}}.toJSON(buf, quote64);
+			buf.append("},");
+		}
+{{- else}}
+		if (this.{{.NameNative}} != null) {
+			buf.append("\"{{.Name}}\":");
+			this.{{.NameNative}}.toJSON(buf, quote64);
+			buf.append(',');
+		}
+{{- end}}
+{{- end}}
+		if (buf.length() - start == 1) buf.append('}');
+		else buf.setCharAt(buf.length() - 1, '}');
+	}
+
+	/**
+	 * Deserializes a JSON object with the mapping of {@link #toJSON()}.
+	 * Integers may also be JSON strings with a decimal value, and JSON null
+	 * equals the zero value. Unknown members are rejected.
+	 * @param json the JSON text.
+	 * @return the result or {@code null} for JSON null.
+	 * @throws InputMismatchException when the JSON does not match this object's schema.{{if .HasList}}
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferListMax}.{{end}}
+	 */
+	public static {{$class}} fromJSON(String json) {
+		return fromJSON(json, {{if .HasList}}colferListMax{{else}}{{.Pkg.ListMax}}{{end}});
+	}
+
+	/**
+	 * Deserializes a JSON object like {@link #fromJSON(String)}, yet with a list limit for the call.
+	 * @param json the JSON text.
+	 * @param listMax the upper limit for the number of elements in a list, including those of nested data structures.
+	 * @return the result or {@code null} for JSON null.
+	 * @throws InputMismatchException when the JSON does not match this object's schema.
+	 * @throws SecurityException on an upper limit breach defined by {@code listMax}.
+	 */
+	public static {{$class}} fromJSON(String json, int listMax) {
+		return fromJSON(ColferJSON.toObject(ColferJSON.parse(json), "{{.String}}"), listMax);
+	}
+
+	/**
+	 * Deserializes a parsed JSON object with the mapping of {@link #toJSON()}.
+	 * The values are {@link java.util.Map}, {@link java.util.List}, {@link String},
+	 * {@link java.math.BigDecimal}, {@link Boolean} or {@code null}.
+	 * @param members the JSON object or {@code null}.
+	 * @return the result or {@code null} for JSON null.
+	 * @throws InputMismatchException when the JSON does not match this object's schema.{{if .HasList}}
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferListMax}.{{end}}
+	 * @see #fromJSON(String)
+	 */
+	public static {{$class}} fromJSON(java.util.Map<String, ?> members) {
+		return fromJSON(members, {{if .HasList}}colferListMax{{else}}{{.Pkg.ListMax}}{{end}});
+	}
+
+	/**
+	 * Deserializes a parsed JSON object like {@link #fromJSON(java.util.Map)}, yet with a list limit for the call.
+	 * @param members the JSON object or {@code null}.
+	 * @param listMax the upper limit for the number of elements in a list, including those of nested data structures.
+	 * @return the result or {@code null} for JSON null.
+	 * @throws InputMismatchException when the JSON does not match this object's schema.
+	 * @throws SecurityException on an upper limit breach defined by {@code listMax}.
+	 */
+	public static {{$class}} fromJSON(java.util.Map<String, ?> members, int listMax) {
+		if (members == null) return null;
+
+		{{$class}} o = new {{$class}}();
+		for (java.util.Map.Entry<String, ?> member : members.entrySet()) {
+			Object v = member.getValue();
+			switch (member.getKey()) {
+{{- range .Fields}}
+			case "{{.Name}}":
+{{- if eq .Type "bool"}}
+				o.{{.NameNative}} = ColferJSON.toBool(v, "{{.String}}");
+{{- else if eq .Type "uint8"}}
+				o.{{.NameNative}} = (byte) ColferJSON.toInt(v, "{{.String}}", 8, false);
+{{- else if eq .Type "uint16"}}
+				o.{{.NameNative}} = (short) ColferJSON.toInt(v, "{{.String}}", 16, false);
+{{- else if eq .Type "uint32"}}
+				o.{{.NameNative}} = (int) ColferJSON.toInt(v, "{{.String}}", 32, false);
+{{- else if eq .Type "int32"}}
+				o.{{.NameNative}} = (int) ColferJSON.toInt(v, "{{.String}}", 32, true);
+{{- else if eq .Type "uint64"}}
+				o.{{.NameNative}} = ColferJSON.toInt(v, "{{.String}}", 64, false);
+{{- else if eq .Type "int64"}}
+				o.{{.NameNative}} = ColferJSON.toInt(v, "{{.String}}", 64, true);
+{{- else if eq .Type "float32" "float64"}}
+ {{- if .TypeList}}
+				{
+					java.util.List<?> l = ColferJSON.toList(v, "{{.String}}", listMax);
+					{{.TypeNative}}[] a = new {{.TypeNative}}[l.size()];
+					for (int i = 0; i < a.length; i++)
+						a[i] = ColferJSON.to{{if eq .Type "float32"}}Float32{{else}}Float64{{end}}(l.get(i), "{{.String}}");
+					o.{{.NameNative}} = a;
+				}
+ {{- else}}
+				o.{{.NameNative}} = ColferJSON.to{{if eq .Type "float32"}}Float32{{else}}Float64{{end}}(v, "{{.String}}");
+ {{- end}}
+{{- else if eq .Type "timestamp"}}
+				o.{{.NameNative}} = ColferJSON.toInstant(v, "{{.String}}");
+{{- else if eq .Type "text" "binary"}}
+ {{- if .TypeList}}
+				{
+					java.util.List<?> l = ColferJSON.toList(v, "{{.String}}", listMax);
+					{{.TypeNative}}[] a = new {{if eq .Type "text"}}String[l.size()]{{else}}byte[l.size()][]{{end}};
+					for (int i = 0; i < a.length; i++)
+						a[i] = ColferJSON.to{{if eq .Type "text"}}Text{{else}}Binary{{end}}(l.get(i), "{{.String}}");
+					o.{{.NameNative}} = a;
+				}
+ {{- else}}
+				o.{{.NameNative}} = ColferJSON.to{{if eq .Type "text"}}Text{{else}}Binary{{end}}(v, "{{.String}}");
+ {{- end}}
+{{- else if .TypeList}}
+				{
+					java.util.List<?> l = ColferJSON.toList(v, "{{.String}}", listMax);
+					{{.TypeNative}}[] a = new {{.TypeNative}}[l.size()];
+					for (int i = 0; i < a.length; i++) {
+						{{.TypeNative}} e = {{.TypeNative}}.fromJSON(ColferJSON.toObject(l.get(i), "{{.String}}"), listMax);
+						a[i] = e == null ? new {{.TypeNative}}() : e;
+					}
+					o.{{.NameNative}} = a;
+				}
+{{- else}}
+				o.{{.NameNative}} = {{.TypeNative}}.fromJSON(ColferJSON.toObject(v, "{{.String}}"), listMax);
+{{- end}}
+				break;
+{{- end}}
+			default:
+				throw new InputMismatchException(format("colfer: JSON member \"%s\" not in struct {{.String}}", member.getKey()));
+			}
+		}
+		return o;
+	}
+
 	@Override
 	public final int hashCode() {
+{{- if and .Pkg.Lazy .HasStruct}}
+		decodeLazy();
+{{- end}}
 		int h = 1;
 {{- range .Fields}}
 {{- if eq .Type "bool"}}
@@ -1176,7 +2306,186 @@ import java.nio.BufferUnderflowException;
 		return h;
 	}
 
+	/**
+	 * Gets a human-readable form for debugging, with the sensitive fields masked.
+	 * @return the description.
+	 */
+	@Override
+	public String toString() {
+{{- if and .Pkg.Lazy .HasStruct}}
+		decodeLazy();
+{{- end}}
+		StringBuilder buf = new StringBuilder("{{.String}}{");
+{{- range $i, $f := .Fields}}
+		buf.append("{{if $i}}, {{end}}{{.Name}}=");
+{{- if .Sensitive}}
+		buf.append("<redacted>");
+{{- else if eq .Type "uint8"}}
+		buf.append(this.{{.NameNative}} & 0xff);
+{{- else if eq .Type "uint16"}}
+		buf.append(this.{{.NameNative}} & 0xffff);
+{{- else if eq .Type "uint32"}}
+		buf.append(Integer.toUnsignedString(this.{{.NameNative}}));
+{{- else if eq .Type "uint64"}}
+		buf.append(Long.toUnsignedString(this.{{.NameNative}}));
+{{- else if and .TypeList (eq .Type "binary")}}
+		buf.append(java.util.Arrays.deepToString(this.{{.NameNative}}));
+{{- else if or .TypeList (eq .Type "binary")}}
+		buf.append(java.util.Arrays.toString(this.{{.NameNative}}));
+{{- else if eq .Type "text"}}
+		buf.append('"').append(this.{{.NameNative}}).append('"');
+{{- else}}
+		buf.append(this.{{.NameNative}});
+{{- end}}
+{{- end}}
+		return buf.append('}').toString();
+	}
+
+	/**
+	 * Compares each field with another object. Data structures compare field by field
+	 * and lists compare element by element, conform {@link #equals({{$class}})}.
+	 * @param other the new values, with {@code null} for the zero value.
+	 * @return the differences in schema order, with this object as the old values.
+	 */
+	public java.util.List<ColferDiff> diff({{$class}} other) {
+		if (other == null) other = new {{$class}}();
+{{- if and .Pkg.Lazy .HasStruct}}
+		this.decodeLazy();
+		other.decodeLazy();
+{{- end}}
+		java.util.List<ColferDiff> diffs = new java.util.ArrayList<>();
+{{- range .Fields}}
+{{- if .TypeList}}
+		{
+			{{.TypeNative}}[] a = this.{{.NameNative}}, b = other.{{.NameNative}};
+			for (int i = 0; i < a.length || i < b.length; i++) {
+				String path = "{{.Name}}[" + i + "]";
+				if (i >= a.length) {
+					diffs.add(new ColferDiff(path, null, b[i]));
+				} else if (i >= b.length) {
+					diffs.add(new ColferDiff(path, a[i], null));
+ {{- if .TypeRef}}
+				} else {
+					{{.TypeNative}} v = a[i] == null ? new {{.TypeNative}}() : a[i];
+					for ({{if ne .TypeRef.Pkg.Name .Struct.Pkg.Name}}{{.TypeRef.Pkg.NameNative}}.{{end}}ColferDiff d : v.diff(b[i]))
+						diffs.add(new ColferDiff(path + "." + d.path, d.oldValue, d.newValue));
+				}
+ {{- else}}
+  {{- if eq .Type "float32" "float64"}}
+				} else if (a[i] != b[i] && (a[i] == a[i] || b[i] == b[i])) {
+  {{- else if eq .Type "binary"}}
+				} else if (! java.util.Arrays.equals(a[i], b[i])) {
+  {{- else}}
+				} else if (! java.util.Objects.equals(a[i], b[i])) {
+  {{- end}}
+					diffs.add(new ColferDiff(path, a[i], b[i]));
+				}
+ {{- end}}
+			}
+		}
+{{- else if eq .Type "any"}}
+		if (this.{{.NameNative}} != null && other.{{.NameNative}} != null && this.{{.NameNative}}.getClass() == other.{{.NameNative}}.getClass()) {
+			for (ColferDiff d : this.{{.NameNative}}.colferDiff(other.{{.NameNative}}))
+				diffs.add(new ColferDiff("{{.Name}}." + d.path, d.oldValue, d.newValue));
+		} else if (! java.util.Objects.equals(this.{{.NameNative}}, other.{{.NameNative}})) {
+			diffs.add(new ColferDiff("{{.Name}}", this.{{.NameNative}}, other.{{.NameNative}}));
+		}
+{{- else if .TypeRef}}
+		if (this.{{.NameNative}} != null && other.{{.NameNative}} != null) {
+			for ({{if ne .TypeRef.Pkg.Name .Struct.Pkg.Name}}{{.TypeRef.Pkg.NameNative}}.{{end}}ColferDiff d : this.{{.NameNative}}.diff(other.{{.NameNative}}))
+				diffs.add(new ColferDiff("{{.Name}}." + d.path, d.oldValue, d.newValue));
+		} else if (this.{{.NameNative}} != other.{{.NameNative}}) {
+			diffs.add(new ColferDiff("{{.Name}}", this.{{.NameNative}}, other.{{.NameNative}}));
+		}
+{{- else}}
+ {{- if eq .Type "bool" "uint8" "uint16" "uint32" "uint64" "int32" "int64"}}
+		if (this.{{.NameNative}} != other.{{.NameNative}})
+ {{- else if eq .Type "float32" "float64"}}
+		if (this.{{.NameNative}} != other.{{.NameNative}} && (this.{{.NameNative}} == this.{{.NameNative}} || other.{{.NameNative}} == other.{{.NameNative}}))
+ {{- else if eq .Type "binary"}}
+		if (! java.util.Arrays.equals(this.{{.NameNative}}, other.{{.NameNative}}))
+ {{- else}}
+		if (! java.util.Objects.equals(this.{{.NameNative}}, other.{{.NameNative}}))
+ {{- end}}
+			diffs.add(new ColferDiff("{{.Name}}", this.{{.NameNative}}, other.{{.NameNative}}));
+{{- end}}{{end}}
+		return diffs;
+	}
+
+	/**
+	 * Checks each field against the schema rules, including the ones of nested data structures.
+	 * @throws InputMismatchException when a schema rule is broken.
+	 */
+	public void validate() {
+{{- if and .Pkg.Lazy .HasStruct}}
+		this.decodeLazy();
+{{- end}}
+{{- if .HasRules}}
+		validateFields(null);
+{{- end}}
+{{- range .Fields}}
+{{- if and .TypeRef .TypeList}}
+		if (this.{{.NameNative}} != null) for ({{.TypeNative}} v : this.{{.NameNative}})
+			(v == null ? new {{.TypeNative}}() : v).validate();
+{{- else if or .TypeRef (eq .Type "any")}}
+		if (this.{{.NameNative}} != null) this.{{.NameNative}}.validate();
+{{- end}}
+{{- end}}
+	}
+{{if .HasRules}}
+	// Checks the selected fields against the schema rules, without the nested data structures.
+	private void validateFields(String[] fields) {
+{{- range .Fields}}{{if .HasRules}}
+		if (fields == null || selects(fields, "{{.Name}}")) {
+{{- if .Required}}
+			if ({{if eq .Type "bool"}}! this.{{.NameNative}}
+ {{- else if eq .Type "timestamp"}}this.{{.NameNative}} == null || (this.{{.NameNative}}.getEpochSecond() == 0 && this.{{.NameNative}}.getNano() == 0)
+ {{- else if eq .Type "text"}}this.{{.NameNative}} == null || this.{{.NameNative}}.isEmpty()
+ {{- else if or .TypeList (eq .Type "binary")}}this.{{.NameNative}} == null || this.{{.NameNative}}.length == 0
+ {{- else if or .TypeRef (eq .Type "any")}}this.{{.NameNative}} == null
+ {{- else}}this.{{.NameNative}} == 0
+ {{- end}})
+				throw new InputMismatchException("colfer: field {{.String}} breaks rule required");
+{{- end}}
+{{- if .Min}}
+			if ({{if eq .Type "uint64"}}Long.compareUnsigned(this.{{.NameNative}}, {{.MinNative}}) < 0{{else if eq .Type "float32" "float64"}}! (this.{{.NameNative}} >= {{.MinNative}}){{else}}{{template "java-validate-value" .}} < {{.MinNative}}{{end}})
+				throw new InputMismatchException("colfer: field {{.String}} breaks rule min {{.Min}}");
+{{- end}}
+{{- if .Max}}
+			if ({{if eq .Type "uint64"}}Long.compareUnsigned(this.{{.NameNative}}, {{.MaxNative}}) > 0{{else if eq .Type "float32" "float64"}}! (this.{{.NameNative}} <= {{.MaxNative}}){{else}}{{template "java-validate-value" .}} > {{.MaxNative}}{{end}})
+				throw new InputMismatchException("colfer: field {{.String}} breaks rule max {{.Max}}");
+{{- end}}
+{{- if .MinLen}}
+			if ({{template "java-validate-len" .}} < {{.MinLen}})
+				throw new InputMismatchException("colfer: field {{.String}} breaks rule minlen {{.MinLen}}");
+{{- end}}
+{{- if .MaxLen}}
+			if ({{template "java-validate-len" .}} > {{.MaxLen}})
+				throw new InputMismatchException("colfer: field {{.String}} breaks rule maxlen {{.MaxLen}}");
+{{- end}}
+{{- if .Pattern}}
+			if (! {{.NameNative}}Pattern.matcher(this.{{.NameNative}} == null ? "" : this.{{.NameNative}}).find())
+				throw new InputMismatchException({{quote (printf "colfer: field %s breaks rule pattern %s" .String .Pattern)}});
+{{- end}}
+		}
+{{- end}}{{end}}
+	}
+{{- range .Fields}}{{if .Pattern}}
+
+	private static final java.util.regex.Pattern {{.NameNative}}Pattern = java.util.regex.Pattern.compile({{quote (pattern .Pattern)}});
+{{- end}}{{end}}
+{{end}}
+{{if .Pkg.HasAny}}	@Override
+	public String colferType() {
+		return "{{.String}}";
+	}
+
 	@Override
+	public java.util.List<ColferDiff> colferDiff(ColferAny other) {
+		return diff(({{$class}}) other);
+	}
+
+{{end}}	@Override
 	public final boolean equals(Object o) {
 		return o instanceof {{$class}} && equals(({{$class}}) o);
 	}
@@ -1184,6 +2493,10 @@ import java.nio.BufferUnderflowException;
 	public final boolean equals({{$class}} o) {
 		if (o == null) return false;
 		if (o == this) return true;
+{{- if and .Pkg.Lazy .HasStruct}}
+		this.decodeLazy();
+		o.decodeLazy();
+{{- end}}
 		return o.getClass() == {{$class}}.class
 {{- range .Fields}}
 {{- if .TypeList}}
@@ -1216,3 +2529,501 @@ import java.nio.BufferUnderflowException;
 {{end}}
 }
 `
+
+const javaJSON = `package {{.NameNative}};
+
+
+// Code generated by colf(1); DO NOT EDIT.
+
+
+import static java.lang.String.format;
+import java.math.BigDecimal;
+import java.math.BigInteger;
+import java.util.ArrayList;
+import java.util.Base64;
+import java.util.InputMismatchException;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+
+
+/**
+ * JSON mapping support for the data beans in this package.
+ * @author generated by colf(1)
+ * @see <a href="https://github.com/pascaldekloe/colfer">Colfer's home</a>
+ */
+@javax.annotation.Generated(value="colf(1)", comments="Colfer from schema file {{.SchemaFileList}}")
+final class ColferJSON {
+
+	private ColferJSON() { }
+
+	static void appendText(StringBuilder buf, String s) {
+		buf.append('"');
+		for (int i = 0, n = s.length(); i < n; i++) {
+			char c = s.charAt(i);
+			switch (c) {
+			case '"':
+				buf.append("\\\"");
+				break;
+			case '\\':
+				buf.append("\\\\");
+				break;
+			case '\n':
+				buf.append("\\n");
+				break;
+			case '\r':
+				buf.append("\\r");
+				break;
+			case '\t':
+				buf.append("\\t");
+				break;
+			default:
+				if (c < ' ') buf.append(format("\\u%04x", (int) c));
+				else buf.append(c);
+			}
+		}
+		buf.append('"');
+	}
+
+	static void appendBinary(StringBuilder buf, byte[] b) {
+		buf.append('"').append(Base64.getEncoder().encodeToString(b)).append('"');
+	}
+
+	static void appendFloat(StringBuilder buf, float f) {
+		if (Float.isNaN(f)) buf.append("\"NaN\"");
+		else if (Float.isInfinite(f)) buf.append(f > 0 ? "\"Infinity\"" : "\"-Infinity\"");
+		else buf.append(f);
+	}
+
+	static void appendDouble(StringBuilder buf, double f) {
+		if (Double.isNaN(f)) buf.append("\"NaN\"");
+		else if (Double.isInfinite(f)) buf.append(f > 0 ? "\"Infinity\"" : "\"-Infinity\"");
+		else buf.append(f);
+	}
+
+	static void appendInstant(StringBuilder buf, java.time.Instant t, String field) {
+		int year = t.atOffset(java.time.ZoneOffset.UTC).getYear();
+		if (year < 0 || year > 9999)
+			throw new IllegalStateException(format("colfer: field %s year %d outside of RFC 3339 range", field, year));
+		buf.append('"').append(java.time.format.DateTimeFormatter.ISO_INSTANT.format(t)).append('"');
+	}
+
+	static boolean toBool(Object v, String field) {
+		if (v == null) return false;
+		if (v instanceof Boolean) return (Boolean) v;
+		throw mismatch(v, field);
+	}
+
+	static long toInt(Object v, String field, int bits, boolean signed) {
+		if (v == null) return 0;
+
+		BigDecimal d;
+		if (v instanceof BigDecimal) {
+			d = (BigDecimal) v;
+		} else if (v instanceof String) {
+			try {
+				d = new BigDecimal((String) v);
+			} catch (NumberFormatException e) {
+				throw new InputMismatchException(format("colfer: JSON for field %s: string %s not a number", field, v));
+			}
+		} else {
+			throw mismatch(v, field);
+		}
+
+		BigInteger x;
+		try {
+			// bound the magnitude before conversion
+			if (d.precision() - d.scale() > 20) throw new ArithmeticException();
+			x = d.toBigIntegerExact();
+		} catch (ArithmeticException e) {
+			throw new InputMismatchException(format("colfer: JSON for field %s: %s not an integer in range", field, d));
+		}
+		if (signed ? x.bitLength() >= bits : x.signum() < 0 || x.bitLength() > bits)
+			throw new InputMismatchException(format("colfer: JSON for field %s: %s overflows %s%d", field, x, signed ? "int" : "uint", bits));
+		return x.longValue();
+	}
+
+	static float toFloat32(Object v, String field) {
+		if (v instanceof BigDecimal) return ((BigDecimal) v).floatValue();
+		return (float) toFloat64(v, field);
+	}
+
+	static double toFloat64(Object v, String field) {
+		if (v == null) return 0;
+		if (v instanceof BigDecimal) return ((BigDecimal) v).doubleValue();
+		if ("NaN".equals(v)) return Double.NaN;
+		if ("Infinity".equals(v)) return Double.POSITIVE_INFINITY;
+		if ("-Infinity".equals(v)) return Double.NEGATIVE_INFINITY;
+		throw mismatch(v, field);
+	}
+
+	static java.time.Instant toInstant(Object v, String field) {
+		if (v == null) return null;
+		if (! (v instanceof String)) throw mismatch(v, field);
+		try {
+			return java.time.OffsetDateTime.parse((String) v).toInstant();
+		} catch (java.time.format.DateTimeParseException e) {
+			throw new InputMismatchException(format("colfer: JSON for field %s: %s", field, e.getMessage()));
+		}
+	}
+
+	static String toText(Object v, String field) {
+		if (v == null) return "";
+		if (v instanceof String) return (String) v;
+		throw mismatch(v, field);
+	}
+
+	static byte[] toBinary(Object v, String field) {
+		if (v == null) return new byte[0];
+		if (! (v instanceof String)) throw mismatch(v, field);
+		try {
+			return Base64.getDecoder().decode((String) v);
+		} catch (IllegalArgumentException e) {
+			throw new InputMismatchException(format("colfer: JSON for field %s: %s", field, e.getMessage()));
+		}
+	}
+
+	static List<?> toList(Object v, String field, int max) {
+		if (v == null) return new ArrayList<Object>(0);
+		if (! (v instanceof List)) throw mismatch(v, field);
+		List<?> l = (List<?>) v;
+		if (l.size() > max)
+			throw new SecurityException(format("colfer: field %s length %d exceeds %d elements", field, l.size(), max));
+		return l;
+	}
+
+	@SuppressWarnings("unchecked")
+	static Map<String, ?> toObject(Object v, String field) {
+		if (v == null) return null;
+		if (v instanceof Map) return (Map<String, ?>) v;
+		throw mismatch(v, field);
+	}
+
+	private static InputMismatchException mismatch(Object v, String field) {
+		String kind = "object";
+		if (v instanceof Boolean) kind = "boolean";
+		else if (v instanceof BigDecimal) kind = "number";
+		else if (v instanceof String) kind = "string";
+		else if (v instanceof List) kind = "array";
+		return new InputMismatchException(format("colfer: JSON for field %s: got %s", field, kind));
+	}
+
+	/**
+	 * Parses a JSON text into {@link Map}, {@link List}, {@link String},
+	 * {@link BigDecimal}, {@link Boolean} and {@code null} values.
+	 * @param json the JSON text.
+	 * @return the value.
+	 * @throws InputMismatchException on malformed JSON.
+	 */
+	static Object parse(String json) {
+		Parser p = new Parser(json);
+		Object v = p.value();
+		p.skipSpace();
+		if (p.i != json.length()) throw p.syntax("data continuation");
+		return v;
+	}
+
+	private static final class Parser {
+
+		/** The JSON text. */
+		final String s;
+
+		/** The read index for {@link #s}. */
+		int i;
+
+		Parser(String s) {
+			this.s = s;
+		}
+
+		InputMismatchException syntax(String reason) {
+			return new InputMismatchException(format("colfer: JSON %s at character %d", reason, this.i));
+		}
+
+		void skipSpace() {
+			for (; this.i < this.s.length(); this.i++) {
+				char c = this.s.charAt(this.i);
+				if (c != ' ' && c != '\t' && c != '\n' && c != '\r') break;
+			}
+		}
+
+		boolean next(char c) {
+			skipSpace();
+			if (this.i < this.s.length() && this.s.charAt(this.i) == c) {
+				this.i++;
+				return true;
+			}
+			return false;
+		}
+
+		void expect(char c) {
+			if (! next(c)) throw syntax("'" + c + "' expected");
+		}
+
+		Object value() {
+			skipSpace();
+			if (this.i >= this.s.length()) throw syntax("value expected");
+
+			switch (this.s.charAt(this.i)) {
+			case '{': {
+				this.i++;
+				Map<String, Object> m = new LinkedHashMap<>();
+				if (next('}')) return m;
+				do {
+					skipSpace();
+					if (this.i >= this.s.length() || this.s.charAt(this.i) != '"')
+						throw syntax("member name expected");
+					String name = string();
+					expect(':');
+					m.put(name, value());
+				} while (next(','));
+				expect('}');
+				return m;
+			}
+			case '[': {
+				this.i++;
+				List<Object> l = new ArrayList<>();
+				if (next(']')) return l;
+				do {
+					l.add(value());
+				} while (next(','));
+				expect(']');
+				return l;
+			}
+			case '"':
+				return string();
+			case 't':
+				literal("true");
+				return Boolean.TRUE;
+			case 'f':
+				literal("false");
+				return Boolean.FALSE;
+			case 'n':
+				literal("null");
+				return null;
+			default: {
+				int start = this.i;
+				while (this.i < this.s.length() && "+-.0123456789eE".indexOf(this.s.charAt(this.i)) >= 0)
+					this.i++;
+				try {
+					return new BigDecimal(this.s.substring(start, this.i));
+				} catch (NumberFormatException e) {
+					this.i = start;
+					throw syntax("invalid value");
+				}
+			}
+			}
+		}
+
+		void literal(String word) {
+			if (! this.s.startsWith(word, this.i)) throw syntax("invalid literal");
+			this.i += word.length();
+		}
+
+		String string() {
+			this.i++; // opening quote
+			StringBuilder buf = new StringBuilder();
+			while (true) {
+				if (this.i >= this.s.length()) throw syntax("unterminated string");
+				char c = this.s.charAt(this.i++);
+				if (c == '"') return buf.toString();
+				if (c != '\\') {
+					buf.append(c);
+					continue;
+				}
+
+				if (this.i >= this.s.length()) throw syntax("unterminated string");
+				c = this.s.charAt(this.i++);
+				switch (c) {
+				case '"':
+				case '\\':
+				case '/':
+					buf.append(c);
+					break;
+				case 'b':
+					buf.append('\b');
+					break;
+				case 'f':
+					buf.append('\f');
+					break;
+				case 'n':
+					buf.append('\n');
+					break;
+				case 'r':
+					buf.append('\r');
+					break;
+				case 't':
+					buf.append('\t');
+					break;
+				case 'u':
+					if (this.i + 4 > this.s.length()) throw syntax("incomplete escape");
+					try {
+						buf.append((char) Integer.parseInt(this.s.substring(this.i, this.i + 4), 16));
+					} catch (NumberFormatException e) {
+						throw syntax("invalid escape");
+					}
+					this.i += 4;
+					break;
+				default:
+					throw syntax("invalid escape");
+				}
+			}
+		}
+
+	}
+
+}
+`
+
+const javaDiff = `package {{.NameNative}};
+
+
+// Code generated by colf(1); DO NOT EDIT.
+
+
+/**
+ * A field difference between two data beans.
+ * @author generated by colf(1)
+ * @see <a href="https://github.com/pascaldekloe/colfer">Colfer's home</a>
+ */
+@javax.annotation.Generated(value="colf(1)", comments="Colfer from schema file {{.SchemaFileList}}")
+public final class ColferDiff {
+
+	/** The location of the field with the schema names, e.g., {@code course.holes[3].par}. */
+	public final String path;
+
+	/** The original value, with {@code null} for absence. */
+	public final Object oldValue;
+
+	/** The other value, with {@code null} for absence. */
+	public final Object newValue;
+
+	public ColferDiff(String path, Object oldValue, Object newValue) {
+		this.path = path;
+		this.oldValue = oldValue;
+		this.newValue = newValue;
+	}
+
+	@Override
+	public String toString() {
+		return path + ": " + format(oldValue) + " -> " + format(newValue);
+	}
+
+	private static String format(Object v) {
+		if (v instanceof byte[]) return java.util.Arrays.toString((byte[]) v);
+		return String.valueOf(v);
+	}
+
+}
+`
+
+const javaAny = `package {{.NameNative}};
+
+
+// Code generated by colf(1); DO NOT EDIT.
+
+
+import static java.lang.String.format;
+import java.security.MessageDigest;
+import java.util.InputMismatchException;
+
+
+/**
+ * A data bean of any type in this package. The serial of an any field has the
+ * qualified schema name, e.g., {@code "{{(index .Structs 0).String}}"}, next to the data, such that
+ * unmarshal can decode to the concrete type.
+ * @author generated by colf(1)
+ * @see <a href="https://github.com/pascaldekloe/colfer">Colfer's home</a>
+ */
+@javax.annotation.Generated(value="colf(1)", comments="Colfer from schema file {{.SchemaFileList}}")
+public interface ColferAny {
+
+	/**
+	 * Gets the qualified schema name.
+	 * @return the registry key.
+	 */
+	String colferType();
+
+	int marshal(byte[] buf, int offset);
+
+	void colferHash(MessageDigest md);
+
+	int unmarshal(byte[] buf, int offset, int end, int depth, String[] fields, boolean strict, boolean validate, long[] budget);
+
+	void toJSON(StringBuilder buf);
+
+	void toJSON(StringBuilder buf, boolean quote64);
+
+	void validate();
+
+	/**
+	 * Compares each field with another data bean of the same type.
+	 * @param other the new values, with {@code null} for the zero value.
+	 * @return the differences in schema order, with this object as the old values.
+	 * @throws ClassCastException when {@code other} is of another type.
+	 */
+	java.util.List<ColferDiff> colferDiff(ColferAny other);
+
+	/**
+	 * Gets a new data bean from the registry, which has all types in this package.
+	 * @param name the qualified schema name.
+	 * @return the new instance or {@code null} when {@code name} is not registered.
+	 */
+	static ColferAny newInstance(String name) {
+		switch (name) {
+{{- range .Structs}}
+		case "{{.String}}":
+			return new {{.NameTitle}}();
+{{- end}}
+		}
+		return null;
+	}
+
+	/**
+	 * Gets whether the path locates a field in any of the registered types.
+	 * @param path the field path.
+	 * @return whether {@code path} is valid for an any field.
+	 */
+	static boolean hasFieldPath(String path) {
+		return {{range $i, $s := .Structs}}{{if $i}}
+			|| {{end}}{{$s.NameTitle}}.hasFieldPath(path){{end}};
+	}
+
+	/**
+	 * Deserializes a parsed JSON object with the qualified schema name as
+	 * {@code "type"} and the data bean as {@code "value"}, with the default list limit.
+	 * @param members the JSON object or {@code null}.
+	 * @return the result or {@code null} for JSON null.
+	 * @throws InputMismatchException when the JSON does not match a registered type.
+	 */
+	static ColferAny fromJSON(java.util.Map<String, ?> members) {
+		return fromJSON(members, {{.ListMax}});
+	}
+
+	/**
+	 * Deserializes a parsed JSON object like {@link #fromJSON(java.util.Map)}, yet with a list limit for the call.
+	 * @param members the JSON object or {@code null}.
+	 * @param listMax the upper limit for the number of elements in a list, including those of nested data structures.
+	 * @return the result or {@code null} for JSON null.
+	 * @throws InputMismatchException when the JSON does not match a registered type.
+	 * @throws SecurityException on an upper limit breach defined by {@code listMax}.
+	 */
+	static ColferAny fromJSON(java.util.Map<String, ?> members, int listMax) {
+		if (members == null) return null;
+
+		for (String key : members.keySet()) {
+			if (! key.equals("type") && ! key.equals("value"))
+				throw new InputMismatchException(format("colfer: JSON member \"%s\" not type nor value", key));
+		}
+		String name = ColferJSON.toText(members.get("type"), "type");
+		java.util.Map<String, ?> value = ColferJSON.toObject(members.get("value"), "value");
+		switch (name) {
+{{- range .Structs}}
+		case "{{.String}}":
+			return value == null ? new {{.NameTitle}}() : {{.NameTitle}}.fromJSON(value, listMax);
+{{- end}}
+		}
+		throw new InputMismatchException(format("colfer: JSON type \"%s\" not registered", name));
+	}
+
+}
+`
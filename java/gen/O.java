@@ -12,10 +12,13 @@ import java.io.ObjectOutputStream;
 import java.io.ObjectStreamException;
 import java.io.OutputStream;
 import java.io.Serializable;
+import java.nio.charset.CharacterCodingException;
 import java.nio.charset.StandardCharsets;
 import java.util.InputMismatchException;
 import java.nio.BufferOverflowException;
 import java.nio.BufferUnderflowException;
+import java.nio.ByteBuffer;
+import java.security.MessageDigest;
 
 
 /**
@@ -25,7 +28,7 @@ import java.nio.BufferUnderflowException;
  * @see <a href="https://github.com/pascaldekloe/colfer">Colfer's home</a>
  */
 @javax.annotation.Generated(value="colf(1)", comments="Colfer from schema file test.colf")
-public class O implements Serializable {
+public class O implements Serializable, ColferAny {
 
 	/** The upper limit for serial byte sizes. */
 	public static int colferSizeMax = 16 * 1024 * 1024;
@@ -33,6 +36,9 @@ public class O implements Serializable {
 	/** The upper limit for the number of elements in a list. */
 	public static int colferListMax = 64 * 1024;
 
+	/** The upper limit for the number of nested data structure levels, including the root. */
+	public static int colferDepthMax = 100;
+
 
 
 
@@ -219,7 +225,7 @@ public class O implements Serializable {
 				} else if (i == buf.length) {
 					byte[] src = this.buf;
 					// TODO: better size estimation
-					if (offset == 0) this.buf = new byte[Math.min(O.colferSizeMax, this.buf.length * 4)];
+					if (offset == 0) this.buf = new byte[(int) Math.min(O.colferSizeMax, this.buf.length * 4L)];
 					System.arraycopy(src, this.offset, this.buf, 0, this.i - this.offset);
 					this.i -= this.offset;
 					this.offset = 0;
@@ -262,7 +268,7 @@ public class O implements Serializable {
 			try {
 				i = marshal(buf, 0);
 			} catch (BufferOverflowException e) {
-				buf = new byte[Math.min(O.colferSizeMax, buf.length * 4)];
+				buf = new byte[(int) Math.min(O.colferSizeMax, buf.length * 4L)];
 				continue;
 			}
 
@@ -677,6 +683,208 @@ public class O implements Serializable {
 		}
 	}
 
+	/**
+	 * Feeds the serial into a digest, without materializing the serial as a whole.
+	 * Equal values produce the same digest input in each of the supported languages.
+	 * Unlike marshal, any {@code null} elements in lists are left as is.
+	 * @param md the digest to update.
+	 * @throws IllegalStateException on an upper limit breach defined by either {@link #colferSizeMax} or {@link #colferListMax}.
+	 */
+	public void colferHash(MessageDigest md) {
+		if (this.b) md.update((byte) 0);
+
+		if (this.u32 != 0) {
+			int x = this.u32;
+			if ((x & ~((1 << 21) - 1)) != 0) {
+				md.update((byte) (1 | 0x80));
+				hashFixed(md, x, 4);
+			} else {
+				md.update((byte) 1);
+				hashVarint(md, x);
+			}
+		}
+
+		if (this.u64 != 0) {
+			long x = this.u64;
+			if ((x & ~((1L << 49) - 1)) != 0) {
+				md.update((byte) (2 | 0x80));
+				hashFixed(md, x, 8);
+			} else {
+				md.update((byte) 2);
+				hashVarint(md, x);
+			}
+		}
+
+		if (this.i32 != 0) {
+			int x = this.i32;
+			if (x < 0) {
+				x = -x;
+				md.update((byte) (3 | 0x80));
+			} else
+				md.update((byte) 3);
+			hashVarint(md, x & 0xffffffffL);
+		}
+
+		if (this.i64 != 0) {
+			long x = this.i64;
+			if (x < 0) {
+				x = -x;
+				md.update((byte) (4 | 0x80));
+			} else
+				md.update((byte) 4);
+			hashVarint(md, x);
+		}
+
+		if (this.f32 != 0.0f) {
+			md.update((byte) 5);
+			hashFixed(md, Float.floatToRawIntBits(this.f32), 4);
+		}
+
+		if (this.f64 != 0.0) {
+			md.update((byte) 6);
+			hashFixed(md, Double.doubleToRawLongBits(this.f64), 8);
+		}
+
+		if (this.t != null) {
+			long s = this.t.getEpochSecond();
+			int ns = this.t.getNano();
+			if (s != 0 || ns != 0) {
+				if (s >= 0 && s < (1L << 32)) {
+					md.update((byte) 7);
+					hashFixed(md, s, 4);
+				} else {
+					md.update((byte) (7 | 0x80));
+					hashFixed(md, s, 8);
+				}
+				hashFixed(md, ns, 4);
+			}
+		}
+
+		if (! this.s.isEmpty()) {
+			byte[] b = this.s.getBytes(StandardCharsets.UTF_8);
+			if (b.length > O.colferSizeMax)
+				throw new IllegalStateException(format("colfer: gen.o.s size %d exceeds %d UTF-8 bytes", b.length, O.colferSizeMax));
+			md.update((byte) 8);
+			hashVarint(md, b.length);
+			md.update(b);
+		}
+
+		if (this.a.length != 0) {
+			byte[] b = this.a;
+			if (b.length > O.colferSizeMax)
+				throw new IllegalStateException(format("colfer: gen.o.a size %d exceeds %d bytes", b.length, O.colferSizeMax));
+			md.update((byte) 9);
+			hashVarint(md, b.length);
+			md.update(b);
+		}
+
+		if (this.o != null) {
+			md.update((byte) 10);
+			this.o.colferHash(md);
+		}
+
+		if (this.os.length != 0) {
+			O[] a = this.os;
+			if (a.length > O.colferListMax)
+				throw new IllegalStateException(format("colfer: gen.o.os length %d exceeds %d elements", a.length, O.colferListMax));
+			md.update((byte) 11);
+			hashVarint(md, a.length);
+			for (O o : a) {
+				if (o == null) md.update((byte) 0x7f);
+				else o.colferHash(md);
+			}
+		}
+
+		if (this.ss.length != 0) {
+			String[] a = this.ss;
+			if (a.length > O.colferListMax)
+				throw new IllegalStateException(format("colfer: gen.o.ss length %d exceeds %d elements", a.length, O.colferListMax));
+			md.update((byte) 12);
+			hashVarint(md, a.length);
+			for (int ai = 0; ai < a.length; ai++) {
+				if (a[ai] == null) {
+					md.update((byte) 0);
+					continue;
+				}
+				byte[] b = a[ai].getBytes(StandardCharsets.UTF_8);
+				if (b.length > O.colferSizeMax)
+					throw new IllegalStateException(format("colfer: gen.o.ss[%d] size %d exceeds %d UTF-8 bytes", ai, b.length, O.colferSizeMax));
+				hashVarint(md, b.length);
+				md.update(b);
+			}
+		}
+
+		if (this.as.length != 0) {
+			byte[][] a = this.as;
+			if (a.length > O.colferListMax)
+				throw new IllegalStateException(format("colfer: gen.o.as length %d exceeds %d elements", a.length, O.colferListMax));
+			md.update((byte) 13);
+			hashVarint(md, a.length);
+			for (int ai = 0; ai < a.length; ai++) {
+				if (a[ai] == null) {
+					md.update((byte) 0);
+					continue;
+				}
+				byte[] b = a[ai];
+				if (b.length > O.colferSizeMax)
+					throw new IllegalStateException(format("colfer: gen.o.as[%d] size %d exceeds %d bytes", ai, b.length, O.colferSizeMax));
+				hashVarint(md, b.length);
+				md.update(b);
+			}
+		}
+
+		if (this.u8 != 0) {
+			md.update((byte) 14);
+			md.update(this.u8);
+		}
+
+		if (this.u16 != 0) {
+			short x = this.u16;
+			if ((x & (short)0xff00) != 0) {
+				md.update((byte) 15);
+				md.update((byte) (x >>> 8));
+			} else {
+				md.update((byte) (15 | 0x80));
+			}
+			md.update((byte) x);
+		}
+
+		if (this.f32s.length != 0) {
+			float[] a = this.f32s;
+			if (a.length > O.colferListMax)
+				throw new IllegalStateException(format("colfer: gen.o.f32s length %d exceeds %d elements", a.length, O.colferListMax));
+			md.update((byte) 16);
+			hashVarint(md, a.length);
+			for (float f : a)
+				hashFixed(md, Float.floatToRawIntBits(f), 4);
+		}
+
+		if (this.f64s.length != 0) {
+			double[] a = this.f64s;
+			if (a.length > O.colferListMax)
+				throw new IllegalStateException(format("colfer: gen.o.f64s length %d exceeds %d elements", a.length, O.colferListMax));
+			md.update((byte) 17);
+			hashVarint(md, a.length);
+			for (double f : a)
+				hashFixed(md, Double.doubleToRawLongBits(f), 8);
+		}
+
+		md.update((byte) 0x7f);
+	}
+
+	private static void hashVarint(MessageDigest md, long x) {
+		for (int n = 0; n < 8 && (x & ~0x7fL) != 0; n++) {
+			md.update((byte) (x | 0x80));
+			x >>>= 7;
+		}
+		md.update((byte) x);
+	}
+
+	private static void hashFixed(MessageDigest md, long x, int size) {
+		for (int shift = (size - 1) * 8; shift >= 0; shift -= 8)
+			md.update((byte) (x >>> shift));
+	}
+
 	/**
 	 * Deserializes the object.
 	 * @param buf the data source.
@@ -701,32 +909,151 @@ public class O implements Serializable {
 	 * @throws InputMismatchException when the data does not match this object's schema.
 	 */
 	public int unmarshal(byte[] buf, int offset, int end) {
+		return unmarshal(buf, offset, end, 1);
+	}
+
+	/**
+	 * Deserializes the object as a nested data structure.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param depth the number of data structure levels, including this object.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by either {@link #colferSizeMax}, {@link #colferListMax} or {@link #colferDepthMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema.
+	 */
+	public int unmarshal(byte[] buf, int offset, int end, int depth) {
+		return unmarshal(buf, offset, end, depth, null);
+	}
+
+	/**
+	 * Deserializes the selected fields only. The other fields are skipped
+	 * without decoding, and they keep their current value.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param fields the field paths, with the schema names separated by dots, e.g., {@code "o.s"},
+	 * or {@code null} for all. A data structure field selects all of its nested fields.
+	 * See {@link #hasFieldPath(String)} for validation.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by either {@link #colferSizeMax} or {@link #colferListMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema.
+	 */
+	public int unmarshal(byte[] buf, int offset, int end, String[] fields) {
+		return unmarshal(buf, offset, end, 1, fields);
+	}
+
+	/**
+	 * Deserializes the object like {@link #unmarshal(byte[], int, int)}, yet it
+	 * also rejects any serial which differs from the marshal output for the same
+	 * data, including the nested data structures.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by either {@link #colferSizeMax} or {@link #colferListMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema, or when the encoding is not canonical.
+	 */
+	public int unmarshalStrict(byte[] buf, int offset, int end) {
+		return unmarshal(buf, offset, end, 1, null, true, false, null);
+	}
+
+	/**
+	 * Deserializes the selected fields of the object as a nested data structure.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param depth the number of data structure levels, including this object.
+	 * @param fields the field paths, or {@code null} for all. See {@link #unmarshal(byte[], int, int, String[])}.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by either {@link #colferSizeMax}, {@link #colferListMax} or {@link #colferDepthMax}.
+	 * @throws InputMismatchException when the data does not match this object's schema.
+	 */
+	public int unmarshal(byte[] buf, int offset, int end, int depth, String[] fields) {
+		return unmarshal(buf, offset, end, depth, fields, false, false, null);
+	}
+
+	/**
+	 * Deserializes the selected fields of the object as a nested data structure.
+	 * @param buf the data source.
+	 * @param offset the initial index for {@code buf}, inclusive.
+	 * @param end the index limit for {@code buf}, exclusive.
+	 * @param depth the number of data structure levels, including this object.
+	 * @param fields the field paths, or {@code null} for all. See {@link #unmarshal(byte[], int, int, String[])}.
+	 * @param strict whether to reject any serial which differs from the marshal output for the same data.
+	 * @param validate whether to check the selected fields against the schema rules, conform {@link #validate()}.
+	 * @param budget the number of bytes which may still be allocated in its only element, or {@code null} for no limit.
+	 * Each allocation of field values, including those of nested data structures, is deducted before it happens.
+	 * Text and binaries count their size in bytes, list elements count 8 bytes each (4 for {@code float32}),
+	 * and a nested data structure counts 8 bytes per field.
+	 * @return the final index for {@code buf}, exclusive.
+	 * @throws BufferUnderflowException when {@code buf} is incomplete. (EOF)
+	 * @throws SecurityException on an upper limit breach defined by either {@link #colferSizeMax}, {@link #colferListMax} or {@link #colferDepthMax}, or when an allocation exceeds the {@code budget}.
+	 * @throws InputMismatchException when the data does not match this object's schema, or with {@code strict} when the encoding is not canonical, or with {@code validate} when a schema rule is broken.
+	 */
+	public int unmarshal(byte[] buf, int offset, int end, int depth, String[] fields, boolean strict, boolean validate, long[] budget) {
+		if (depth > O.colferDepthMax)
+			throw new SecurityException(format("colfer: gen.o exceeds nesting depth %d", O.colferDepthMax));
+		if (depth > 1) charge(budget, "gen.o", 18 * 8L);
 		if (end > buf.length) end = buf.length;
 		int i = offset;
 
 		try {
 			byte header = buf[i++];
 
+			if (fields != null && header == (byte) 0 && !selects(fields, "b")) {
+				header = buf[i++];
+			}
+
 			if (header == (byte) 0) {
 				this.b = true;
 				header = buf[i++];
 			}
 
+			if (fields != null && (header & 0x7f) == 1 && !selects(fields, "u32")) {
+				if (header < 0) {
+					i += 4;
+				} else {
+					for (int shift = 0; true; shift += 7)
+						if (buf[i++] >= 0 || shift == 28) break;
+				}
+				header = buf[i++];
+			}
+
 			if (header == (byte) 1) {
+				int start = i;
 				int x = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					x |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict && (x == 0 || (x & ~((1 << 21) - 1)) != 0 || i - start != varintSize(x)))
+					throw nonCanonical(start - 1);
 				this.u32 = x;
 				header = buf[i++];
 			} else if (header == (byte) (1 | 0x80)) {
 				this.u32 = (buf[i++] & 0xff) << 24 | (buf[i++] & 0xff) << 16 | (buf[i++] & 0xff) << 8 | (buf[i++] & 0xff);
+				if (strict && (this.u32 & ~((1 << 21) - 1)) == 0) throw nonCanonical(i - 5);
+				header = buf[i++];
+			}
+
+			if (fields != null && (header & 0x7f) == 2 && !selects(fields, "u64")) {
+				if (header < 0) {
+					i += 8;
+				} else {
+					for (int shift = 0; true; shift += 7)
+						if (buf[i++] >= 0 || shift == 56) break;
+				}
 				header = buf[i++];
 			}
 
 			if (header == (byte) 2) {
+				int start = i;
 				long x = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
@@ -736,35 +1063,57 @@ public class O implements Serializable {
 					}
 					x |= (b & 0x7fL) << shift;
 				}
+				if (strict && (x == 0 || (x & ~((1L << 49) - 1)) != 0 || i - start != varintSize(x)))
+					throw nonCanonical(start - 1);
 				this.u64 = x;
 				header = buf[i++];
 			} else if (header == (byte) (2 | 0x80)) {
 				this.u64 = (buf[i++] & 0xffL) << 56 | (buf[i++] & 0xffL) << 48 | (buf[i++] & 0xffL) << 40 | (buf[i++] & 0xffL) << 32
 					| (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+				if (strict && (this.u64 & ~((1L << 49) - 1)) == 0) throw nonCanonical(i - 9);
+				header = buf[i++];
+			}
+
+			if (fields != null && (header & 0x7f) == 3 && !selects(fields, "i32")) {
+				for (int shift = 0; true; shift += 7)
+					if (buf[i++] >= 0 || shift == 28) break;
 				header = buf[i++];
 			}
 
 			if (header == (byte) 3) {
+				int start = i;
 				int x = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					x |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict && (x <= 0 || i - start != varintSize(x) || (buf[i - 1] & 0xf0) != 0 && i - start == 5))
+					throw nonCanonical(start - 1);
 				this.i32 = x;
 				header = buf[i++];
 			} else if (header == (byte) (3 | 0x80)) {
+				int start = i;
 				int x = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					x |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict && (x == 0 || x < 0 && x != Integer.MIN_VALUE || i - start != varintSize(x & 0xffffffffL) || (buf[i - 1] & 0xf0) != 0 && i - start == 5))
+					throw nonCanonical(start - 1);
 				this.i32 = -x;
 				header = buf[i++];
 			}
 
+			if (fields != null && (header & 0x7f) == 4 && !selects(fields, "i64")) {
+				for (int shift = 0; true; shift += 7)
+					if (buf[i++] >= 0 || shift == 56) break;
+				header = buf[i++];
+			}
+
 			if (header == (byte) 4) {
+				int start = i;
 				long x = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
@@ -774,9 +1123,12 @@ public class O implements Serializable {
 					}
 					x |= (b & 0x7fL) << shift;
 				}
+				if (strict && (x <= 0 || i - start != varintSize(x)))
+					throw nonCanonical(start - 1);
 				this.i64 = x;
 				header = buf[i++];
 			} else if (header == (byte) (4 | 0x80)) {
+				int start = i;
 				long x = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
@@ -786,62 +1138,129 @@ public class O implements Serializable {
 					}
 					x |= (b & 0x7fL) << shift;
 				}
+				if (strict && (x == 0 || x < 0 && x != Long.MIN_VALUE || i - start != varintSize(x)))
+					throw nonCanonical(start - 1);
 				this.i64 = -x;
 				header = buf[i++];
 			}
 
+			if (fields != null && header == (byte) 5 && !selects(fields, "f32")) {
+				i += 4;
+				header = buf[i++];
+			}
+
 			if (header == (byte) 5) {
 				int x = (buf[i++] & 0xff) << 24 | (buf[i++] & 0xff) << 16 | (buf[i++] & 0xff) << 8 | (buf[i++] & 0xff);
+				if (strict && (x & 0x7fffffff) == 0) throw nonCanonical(i - 5);
 				this.f32 = Float.intBitsToFloat(x);
 				header = buf[i++];
 			}
 
+			if (fields != null && header == (byte) 6 && !selects(fields, "f64")) {
+				i += 8;
+				header = buf[i++];
+			}
+
 			if (header == (byte) 6) {
 				long x = (buf[i++] & 0xffL) << 56 | (buf[i++] & 0xffL) << 48 | (buf[i++] & 0xffL) << 40 | (buf[i++] & 0xffL) << 32
 					| (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+				if (strict && (x & 0x7fffffffffffffffL) == 0) throw nonCanonical(i - 9);
 				this.f64 = Double.longBitsToDouble(x);
 				header = buf[i++];
 			}
 
+			if (fields != null && (header & 0x7f) == 7 && !selects(fields, "t")) {
+				i += header < 0 ? 12 : 8;
+				header = buf[i++];
+			}
+
 			if (header == (byte) 7) {
 				long s = (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
 				long ns = (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+				if (strict && ns >= 1000000000) throw nonCanonical(i - 4);
 				this.t = java.time.Instant.ofEpochSecond(s, ns);
 				header = buf[i++];
 			} else if (header == (byte) (7 | 0x80)) {
 				long s = (buf[i++] & 0xffL) << 56 | (buf[i++] & 0xffL) << 48 | (buf[i++] & 0xffL) << 40 | (buf[i++] & 0xffL) << 32
 					| (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
 				long ns = (buf[i++] & 0xffL) << 24 | (buf[i++] & 0xffL) << 16 | (buf[i++] & 0xffL) << 8 | (buf[i++] & 0xffL);
+				if (strict) {
+					if (s >= 0 && s < (1L << 32)) throw nonCanonical(i - 13);
+					if (ns >= 1000000000) throw nonCanonical(i - 4);
+				}
 				this.t = java.time.Instant.ofEpochSecond(s, ns);
 				header = buf[i++];
 			}
 
+			if (fields != null && header == (byte) 8 && !selects(fields, "s")) {
+				int length = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					length |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (length < 0 || length > O.colferSizeMax)
+					throw new SecurityException(format("colfer: gen.o.s size %d exceeds %d UTF-8 bytes", length, O.colferSizeMax));
+				i += length;
+				header = buf[i++];
+			}
+
 			if (header == (byte) 8) {
+				int at = i - 1;
 				int size = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					size |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict) {
+					if (size == 0) throw nonCanonical(at);
+					if (i - at - 1 != varintSize(size)) throw nonCanonical(i - 1);
+				}
 				if (size < 0 || size > O.colferSizeMax)
 					throw new SecurityException(format("colfer: gen.o.s size %d exceeds %d UTF-8 bytes", size, O.colferSizeMax));
 
 				int start = i;
 				i += size;
+				if (strict) checkUTF8(buf, start, size);
+				charge(budget, "gen.o.s", size);
 				this.s = new String(buf, start, size, StandardCharsets.UTF_8);
 				header = buf[i++];
 			}
 
+			if (fields != null && header == (byte) 9 && !selects(fields, "a")) {
+				int length = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					length |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (length < 0 || length > O.colferSizeMax)
+					throw new SecurityException(format("colfer: gen.o.a size %d exceeds %d bytes", length, O.colferSizeMax));
+				i += length;
+				header = buf[i++];
+			}
+
 			if (header == (byte) 9) {
+				int at = i - 1;
 				int size = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					size |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict) {
+					if (size == 0) throw nonCanonical(at);
+					if (i - at - 1 != varintSize(size)) throw nonCanonical(i - 1);
+				}
 				if (size < 0 || size > O.colferSizeMax)
 					throw new SecurityException(format("colfer: gen.o.a size %d exceeds %d bytes", size, O.colferSizeMax));
 
+				if (size >= end - i) {
+					i += size; // reports in finally
+					throw new BufferUnderflowException();
+				}
+				charge(budget, "gen.o.a", size);
 				this.a = new byte[size];
 				int start = i;
 				i += size;
@@ -850,82 +1269,193 @@ public class O implements Serializable {
 				header = buf[i++];
 			}
 
+			if (fields != null && header == (byte) 10 && !selects(fields, "o")) {
+				i = new O().unmarshal(buf, i, end, depth + 1, NO_FIELDS, strict, false, null);
+				header = buf[i++];
+			}
+
 			if (header == (byte) 10) {
 				this.o = new O();
-				i = this.o.unmarshal(buf, i, end);
+				i = this.o.unmarshal(buf, i, end, depth + 1, within(fields, "o"), strict, validate, budget);
+				header = buf[i++];
+			}
+
+			if (fields != null && header == (byte) 11 && !selects(fields, "os")) {
+				int length = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					length |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (length < 0 || length > O.colferListMax)
+					throw new SecurityException(format("colfer: gen.o.os length %d exceeds %d elements", length, O.colferListMax));
+				O skip = new O();
+				for (int ai = 0; ai < length; ai++)
+					i = skip.unmarshal(buf, i, end, depth + 1, NO_FIELDS, strict, false, null);
 				header = buf[i++];
 			}
 
 			if (header == (byte) 11) {
+				int at = i - 1;
 				int length = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict) {
+					if (length == 0) throw nonCanonical(at);
+					if (i - at - 1 != varintSize(length)) throw nonCanonical(i - 1);
+				}
 				if (length < 0 || length > O.colferListMax)
 					throw new SecurityException(format("colfer: gen.o.os length %d exceeds %d elements", length, O.colferListMax));
 
+				// each element takes at least one byte
+				if (length >= end - i) {
+					i += length; // reports in finally
+					throw new BufferUnderflowException();
+				}
+				String[] sub = within(fields, "os");
+				charge(budget, "gen.o.os", length * 8L);
 				O[] a = new O[length];
 				for (int ai = 0; ai < length; ai++) {
 					O o = new O();
-					i = o.unmarshal(buf, i, end);
+					i = o.unmarshal(buf, i, end, depth + 1, sub, strict, validate, budget);
 					a[ai] = o;
 				}
 				this.os = a;
 				header = buf[i++];
 			}
 
+			if (fields != null && header == (byte) 12 && !selects(fields, "ss")) {
+				int length = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					length |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (length < 0 || length > O.colferListMax)
+					throw new SecurityException(format("colfer: gen.o.ss length %d exceeds %d elements", length, O.colferListMax));
+				for (int ai = 0; ai < length; ai++) {
+					int size = 0;
+					for (int shift = 0; true; shift += 7) {
+						byte b = buf[i++];
+						size |= (b & 0x7f) << shift;
+						if (shift == 28 || b >= 0) break;
+					}
+					if (size < 0 || size > O.colferSizeMax)
+						throw new SecurityException(format("colfer: gen.o.ss[%d] size %d exceeds %d UTF-8 bytes", ai, size, O.colferSizeMax));
+					i += size;
+				}
+				header = buf[i++];
+			}
+
 			if (header == (byte) 12) {
+				int at = i - 1;
 				int length = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict) {
+					if (length == 0) throw nonCanonical(at);
+					if (i - at - 1 != varintSize(length)) throw nonCanonical(i - 1);
+				}
 				if (length < 0 || length > O.colferListMax)
 					throw new SecurityException(format("colfer: gen.o.ss length %d exceeds %d elements", length, O.colferListMax));
 
+				// each element takes at least one byte
+				if (length >= end - i) {
+					i += length; // reports in finally
+					throw new BufferUnderflowException();
+				}
+				charge(budget, "gen.o.ss", length * 8L);
 				String[] a = new String[length];
 				for (int ai = 0; ai < length; ai++) {
+					int sizeAt = i;
 					int size = 0;
 					for (int shift = 0; true; shift += 7) {
 						byte b = buf[i++];
 						size |= (b & 0x7f) << shift;
 						if (shift == 28 || b >= 0) break;
 					}
+					if (strict && i - sizeAt != varintSize(size)) throw nonCanonical(i - 1);
 					if (size < 0 || size > O.colferSizeMax)
 						throw new SecurityException(format("colfer: gen.o.ss[%d] size %d exceeds %d UTF-8 bytes", ai, size, O.colferSizeMax));
 
 					int start = i;
 					i += size;
+					if (strict) checkUTF8(buf, start, size);
+					charge(budget, "gen.o.ss", size);
 					a[ai] = new String(buf, start, size, StandardCharsets.UTF_8);
 				}
 				this.ss = a;
 				header = buf[i++];
 			}
 
+			if (fields != null && header == (byte) 13 && !selects(fields, "as")) {
+				int length = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					length |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (length < 0 || length > O.colferListMax)
+					throw new SecurityException(format("colfer: gen.o.as length %d exceeds %d elements", length, O.colferListMax));
+				for (int ai = 0; ai < length; ai++) {
+					int size = 0;
+					for (int shift = 0; true; shift += 7) {
+						byte b = buf[i++];
+						size |= (b & 0x7f) << shift;
+						if (shift == 28 || b >= 0) break;
+					}
+					if (size < 0 || size > O.colferSizeMax)
+						throw new SecurityException(format("colfer: gen.o.as[%d] size %d exceeds %d bytes", ai, size, O.colferSizeMax));
+					i += size;
+				}
+				header = buf[i++];
+			}
+
 			if (header == (byte) 13) {
+				int at = i - 1;
 				int length = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict) {
+					if (length == 0) throw nonCanonical(at);
+					if (i - at - 1 != varintSize(length)) throw nonCanonical(i - 1);
+				}
 				if (length < 0 || length > O.colferListMax)
 					throw new SecurityException(format("colfer: gen.o.as length %d exceeds %d elements", length, O.colferListMax));
 
+				// each element takes at least one byte
+				if (length >= end - i) {
+					i += length; // reports in finally
+					throw new BufferUnderflowException();
+				}
+				charge(budget, "gen.o.as", length * 8L);
 				byte[][] a = new byte[length][];
 				for (int ai = 0; ai < length; ai++) {
+					int sizeAt = i;
 					int size = 0;
 					for (int shift = 0; true; shift += 7) {
 						byte b = buf[i++];
 						size |= (b & 0x7f) << shift;
 						if (shift == 28 || b >= 0) break;
 					}
+					if (strict && i - sizeAt != varintSize(size)) throw nonCanonical(i - 1);
 					if (size < 0 || size > O.colferSizeMax)
 						throw new SecurityException(format("colfer: gen.o.as[%d] size %d exceeds %d bytes", ai, size, O.colferSizeMax));
 
+					if (size >= end - i) {
+						i += size; // reports in finally
+						throw new BufferUnderflowException();
+					}
+					charge(budget, "gen.o.as", size);
 					byte[] e = new byte[size];
 					int start = i;
 					i += size;
@@ -937,29 +1467,65 @@ public class O implements Serializable {
 				header = buf[i++];
 			}
 
+			if (fields != null && header == (byte) 14 && !selects(fields, "u8")) {
+				i++;
+				header = buf[i++];
+			}
+
 			if (header == (byte) 14) {
 				this.u8 = buf[i++];
+				if (strict && this.u8 == 0) throw nonCanonical(i - 2);
+				header = buf[i++];
+			}
+
+			if (fields != null && (header & 0x7f) == 15 && !selects(fields, "u16")) {
+				i += header < 0 ? 1 : 2;
 				header = buf[i++];
 			}
 
 			if (header == (byte) 15) {
 				this.u16 = (short) ((buf[i++] & 0xff) << 8 | (buf[i++] & 0xff));
+				if (strict && (this.u16 & 0xffff) < 1 << 8) throw nonCanonical(i - 3);
 				header = buf[i++];
 			} else if (header == (byte) (15 | 0x80)) {
 				this.u16 = (short) (buf[i++] & 0xff);
+				if (strict && this.u16 == 0) throw nonCanonical(i - 2);
+				header = buf[i++];
+			}
+
+			if (fields != null && header == (byte) 16 && !selects(fields, "f32s")) {
+				int length = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					length |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (length < 0 || length > O.colferListMax)
+					throw new SecurityException(format("colfer: gen.o.f32s length %d exceeds %d elements", length, O.colferListMax));
+				i += length * 4;
 				header = buf[i++];
 			}
 
 			if (header == (byte) 16) {
+				int at = i - 1;
 				int length = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict) {
+					if (length == 0) throw nonCanonical(at);
+					if (i - at - 1 != varintSize(length)) throw nonCanonical(i - 1);
+				}
 				if (length < 0 || length > O.colferListMax)
 					throw new SecurityException(format("colfer: gen.o.f32s length %d exceeds %d elements", length, O.colferListMax));
 
+				if ((long) length * 4 >= end - i) {
+					i = (int) Math.min(i + (long) length * 4, Integer.MAX_VALUE); // reports in finally
+					throw new BufferUnderflowException();
+				}
+				charge(budget, "gen.o.f32s", length * 4L);
 				float[] a = new float[length];
 				for (int ai = 0; ai < length; ai++) {
 					int x = (buf[i++] & 0xff) << 24 | (buf[i++] & 0xff) << 16 | (buf[i++] & 0xff) << 8 | (buf[i++] & 0xff);
@@ -969,16 +1535,39 @@ public class O implements Serializable {
 				header = buf[i++];
 			}
 
+			if (fields != null && header == (byte) 17 && !selects(fields, "f64s")) {
+				int length = 0;
+				for (int shift = 0; true; shift += 7) {
+					byte b = buf[i++];
+					length |= (b & 0x7f) << shift;
+					if (shift == 28 || b >= 0) break;
+				}
+				if (length < 0 || length > O.colferListMax)
+					throw new SecurityException(format("colfer: gen.o.f64s length %d exceeds %d elements", length, O.colferListMax));
+				i += length * 8;
+				header = buf[i++];
+			}
+
 			if (header == (byte) 17) {
+				int at = i - 1;
 				int length = 0;
 				for (int shift = 0; true; shift += 7) {
 					byte b = buf[i++];
 					length |= (b & 0x7f) << shift;
 					if (shift == 28 || b >= 0) break;
 				}
+				if (strict) {
+					if (length == 0) throw nonCanonical(at);
+					if (i - at - 1 != varintSize(length)) throw nonCanonical(i - 1);
+				}
 				if (length < 0 || length > O.colferListMax)
 					throw new SecurityException(format("colfer: gen.o.f64s length %d exceeds %d elements", length, O.colferListMax));
 
+				if ((long) length * 8 >= end - i) {
+					i = (int) Math.min(i + (long) length * 8, Integer.MAX_VALUE); // reports in finally
+					throw new BufferUnderflowException();
+				}
+				charge(budget, "gen.o.f64s", length * 8L);
 				double[] a = new double[length];
 				for (int ai = 0; ai < length; ai++) {
 					long x = (buf[i++] & 0xffL) << 56 | (buf[i++] & 0xffL) << 48 | (buf[i++] & 0xffL) << 40 | (buf[i++] & 0xffL) << 32
@@ -997,10 +1586,105 @@ public class O implements Serializable {
 				throw new SecurityException(format("colfer: gen.o exceeds %d bytes", O.colferSizeMax));
 			if (i > end) throw new BufferUnderflowException();
 		}
-
 		return i;
 	}
 
+	/**
+	 * Gets whether the path locates a field in the schema, with the names
+	 * separated by dots, e.g., {@code "o.s"}.
+	 * @param path the field path.
+	 * @return whether {@code path} is valid for {@link #unmarshal(byte[], int, int, String[])}.
+	 */
+	public static boolean hasFieldPath(String path) {
+		int dot = path.indexOf('.');
+		String name = dot < 0 ? path : path.substring(0, dot);
+		switch (name) {
+		case "b":
+			return dot < 0;
+		case "u32":
+			return dot < 0;
+		case "u64":
+			return dot < 0;
+		case "i32":
+			return dot < 0;
+		case "i64":
+			return dot < 0;
+		case "f32":
+			return dot < 0;
+		case "f64":
+			return dot < 0;
+		case "t":
+			return dot < 0;
+		case "s":
+			return dot < 0;
+		case "a":
+			return dot < 0;
+		case "o":
+			return dot < 0 || O.hasFieldPath(path.substring(dot + 1));
+		case "os":
+			return dot < 0 || O.hasFieldPath(path.substring(dot + 1));
+		case "ss":
+			return dot < 0;
+		case "as":
+			return dot < 0;
+		case "u8":
+			return dot < 0;
+		case "u16":
+			return dot < 0;
+		case "f32s":
+			return dot < 0;
+		case "f64s":
+			return dot < 0;
+		}
+		return false;
+	}
+
+	private static boolean selects(String[] fields, String name) {
+		for (String p : fields)
+			if (p.startsWith(name) && (p.length() == name.length() || p.charAt(name.length()) == '.'))
+				return true;
+		return false;
+	}
+
+	private static String[] within(String[] fields, String name) {
+		if (fields == null) return null;
+		java.util.List<String> paths = new java.util.ArrayList<>();
+		for (String p : fields) {
+			if (p.equals(name)) return null;
+			if (p.length() > name.length() && p.startsWith(name) && p.charAt(name.length()) == '.')
+				paths.add(p.substring(name.length() + 1));
+		}
+		return paths.toArray(NO_FIELDS);
+	}
+
+	private static int varintSize(long x) {
+		int n = 1;
+		for (; n < 9 && (x & ~0x7fL) != 0; x >>>= 7) n++;
+		return n;
+	}
+
+	private static void checkUTF8(byte[] buf, int offset, int length) {
+		ByteBuffer in = ByteBuffer.wrap(buf, offset, length);
+		try {
+			StandardCharsets.UTF_8.newDecoder().decode(in);
+		} catch (CharacterCodingException e) {
+			throw nonCanonical(in.position());
+		}
+	}
+
+	private static void charge(long[] budget, String field, long n) {
+		if (budget == null) return;
+		if (n > budget[0])
+			throw new SecurityException(format("colfer: %s exceeds the allocation budget", field));
+		budget[0] -= n;
+	}
+
+	private static InputMismatchException nonCanonical(int i) {
+		return new InputMismatchException(format("colfer: non-canonical encoding at byte %d", i));
+	}
+
+	private static final String[] NO_FIELDS = {};
+
 	// {@link Serializable} version number.
 	private static final long serialVersionUID = 18L;
 
@@ -1503,6 +2187,296 @@ public class O implements Serializable {
 		return this;
 	}
 
+	/**
+	 * Serializes the object as JSON. The members are named after the schema fields
+	 * and zero values are omitted. Timestamps are RFC 3339 strings in UTC and
+	 * binaries are base64 strings. NaN and infinite floating points are JSON strings too.
+	 * @return the JSON object.
+	 * @throws IllegalStateException when a timestamp exceeds the RFC 3339 range.
+	 */
+	public String toJSON() {
+		return toJSON(false);
+	}
+
+	/**
+	 * Serializes the object as JSON.
+	 * @param quote64 whether to encode 64-bit integers as JSON strings, which keeps them exact for JavaScript consumers.
+	 * @return the JSON object.
+	 * @throws IllegalStateException when a timestamp exceeds the RFC 3339 range.
+	 * @see #toJSON()
+	 */
+	public String toJSON(boolean quote64) {
+		StringBuilder buf = new StringBuilder();
+		toJSON(buf, quote64);
+		return buf.toString();
+	}
+
+	/**
+	 * Serializes the object as JSON.
+	 * @param buf the data destination.
+	 * @throws IllegalStateException when a timestamp exceeds the RFC 3339 range.
+	 * @see #toJSON()
+	 */
+	public void toJSON(StringBuilder buf) {
+		toJSON(buf, false);
+	}
+
+	/**
+	 * Serializes the object as JSON.
+	 * @param buf the data destination.
+	 * @param quote64 whether to encode 64-bit integers as JSON strings, including those of nested data structures.
+	 * @throws IllegalStateException when a timestamp exceeds the RFC 3339 range.
+	 * @see #toJSON()
+	 */
+	public void toJSON(StringBuilder buf, boolean quote64) {
+		int start = buf.length();
+		buf.append('{');
+		if (this.b) buf.append("\"b\":true,");
+		if (this.u32 != 0) buf.append("\"u32\":").append(Integer.toUnsignedString(this.u32)).append(',');
+		if (this.u64 != 0) {
+			String x = Long.toUnsignedString(this.u64);
+			buf.append("\"u64\":");
+			if (quote64) buf.append('"').append(x).append('"');
+			else buf.append(x);
+			buf.append(',');
+		}
+		if (this.i32 != 0) buf.append("\"i32\":").append(this.i32).append(',');
+		if (this.i64 != 0) {
+			String x = Long.toString(this.i64);
+			buf.append("\"i64\":");
+			if (quote64) buf.append('"').append(x).append('"');
+			else buf.append(x);
+			buf.append(',');
+		}
+		if (this.f32 != 0.0f) {
+			buf.append("\"f32\":");
+			ColferJSON.appendFloat(buf, this.f32);
+			buf.append(',');
+		}
+		if (this.f64 != 0.0) {
+			buf.append("\"f64\":");
+			ColferJSON.appendDouble(buf, this.f64);
+			buf.append(',');
+		}
+		if (this.t != null) {
+			buf.append("\"t\":");
+			ColferJSON.appendInstant(buf, this.t, "gen.o.t");
+			buf.append(',');
+		}
+		if (! this.s.isEmpty()) {
+			buf.append("\"s\":");
+			ColferJSON.appendText(buf, this.s);
+			buf.append(',');
+		}
+		if (this.a.length != 0) {
+			buf.append("\"a\":");
+			ColferJSON.appendBinary(buf, this.a);
+			buf.append(',');
+		}
+		if (this.o != null) {
+			buf.append("\"o\":");
+			this.o.toJSON(buf, quote64);
+			buf.append(',');
+		}
+		if (this.os.length != 0) {
+			buf.append("\"os\":[");
+			for (O v : this.os) {
+				if (v == null) buf.append("{}");
+				else v.toJSON(buf, quote64);
+				buf.append(',');
+			}
+			buf.setCharAt(buf.length() - 1, ']');
+			buf.append(',');
+		}
+		if (this.ss.length != 0) {
+			buf.append("\"ss\":[");
+			for (String v : this.ss) {
+				ColferJSON.appendText(buf, v == null ? "" : v);
+				buf.append(',');
+			}
+			buf.setCharAt(buf.length() - 1, ']');
+			buf.append(',');
+		}
+		if (this.as.length != 0) {
+			buf.append("\"as\":[");
+			for (byte[] v : this.as) {
+				ColferJSON.appendBinary(buf, v == null ? _zeroBytes : v);
+				buf.append(',');
+			}
+			buf.setCharAt(buf.length() - 1, ']');
+			buf.append(',');
+		}
+		if (this.u8 != 0) buf.append("\"u8\":").append(this.u8 & 0xff).append(',');
+		if (this.u16 != 0) buf.append("\"u16\":").append(this.u16 & 0xffff).append(',');
+		if (this.f32s.length != 0) {
+			buf.append("\"f32s\":[");
+			for (float f : this.f32s) {
+				ColferJSON.appendFloat(buf, f);
+				buf.append(',');
+			}
+			buf.setCharAt(buf.length() - 1, ']');
+			buf.append(',');
+		}
+		if (this.f64s.length != 0) {
+			buf.append("\"f64s\":[");
+			for (double f : this.f64s) {
+				ColferJSON.appendDouble(buf, f);
+				buf.append(',');
+			}
+			buf.setCharAt(buf.length() - 1, ']');
+			buf.append(',');
+		}
+		if (buf.length() - start == 1) buf.append('}');
+		else buf.setCharAt(buf.length() - 1, '}');
+	}
+
+	/**
+	 * Deserializes a JSON object with the mapping of {@link #toJSON()}.
+	 * Integers may also be JSON strings with a decimal value, and JSON null
+	 * equals the zero value. Unknown members are rejected.
+	 * @param json the JSON text.
+	 * @return the result or {@code null} for JSON null.
+	 * @throws InputMismatchException when the JSON does not match this object's schema.
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferListMax}.
+	 */
+	public static O fromJSON(String json) {
+		return fromJSON(json, colferListMax);
+	}
+
+	/**
+	 * Deserializes a JSON object like {@link #fromJSON(String)}, yet with a list limit for the call.
+	 * @param json the JSON text.
+	 * @param listMax the upper limit for the number of elements in a list, including those of nested data structures.
+	 * @return the result or {@code null} for JSON null.
+	 * @throws InputMismatchException when the JSON does not match this object's schema.
+	 * @throws SecurityException on an upper limit breach defined by {@code listMax}.
+	 */
+	public static O fromJSON(String json, int listMax) {
+		return fromJSON(ColferJSON.toObject(ColferJSON.parse(json), "gen.o"), listMax);
+	}
+
+	/**
+	 * Deserializes a parsed JSON object with the mapping of {@link #toJSON()}.
+	 * The values are {@link java.util.Map}, {@link java.util.List}, {@link String},
+	 * {@link java.math.BigDecimal}, {@link Boolean} or {@code null}.
+	 * @param members the JSON object or {@code null}.
+	 * @return the result or {@code null} for JSON null.
+	 * @throws InputMismatchException when the JSON does not match this object's schema.
+	 * @throws SecurityException on an upper limit breach defined by {@link #colferListMax}.
+	 * @see #fromJSON(String)
+	 */
+	public static O fromJSON(java.util.Map<String, ?> members) {
+		return fromJSON(members, colferListMax);
+	}
+
+	/**
+	 * Deserializes a parsed JSON object like {@link #fromJSON(java.util.Map)}, yet with a list limit for the call.
+	 * @param members the JSON object or {@code null}.
+	 * @param listMax the upper limit for the number of elements in a list, including those of nested data structures.
+	 * @return the result or {@code null} for JSON null.
+	 * @throws InputMismatchException when the JSON does not match this object's schema.
+	 * @throws SecurityException on an upper limit breach defined by {@code listMax}.
+	 */
+	public static O fromJSON(java.util.Map<String, ?> members, int listMax) {
+		if (members == null) return null;
+
+		O o = new O();
+		for (java.util.Map.Entry<String, ?> member : members.entrySet()) {
+			Object v = member.getValue();
+			switch (member.getKey()) {
+			case "b":
+				o.b = ColferJSON.toBool(v, "gen.o.b");
+				break;
+			case "u32":
+				o.u32 = (int) ColferJSON.toInt(v, "gen.o.u32", 32, false);
+				break;
+			case "u64":
+				o.u64 = ColferJSON.toInt(v, "gen.o.u64", 64, false);
+				break;
+			case "i32":
+				o.i32 = (int) ColferJSON.toInt(v, "gen.o.i32", 32, true);
+				break;
+			case "i64":
+				o.i64 = ColferJSON.toInt(v, "gen.o.i64", 64, true);
+				break;
+			case "f32":
+				o.f32 = ColferJSON.toFloat32(v, "gen.o.f32");
+				break;
+			case "f64":
+				o.f64 = ColferJSON.toFloat64(v, "gen.o.f64");
+				break;
+			case "t":
+				o.t = ColferJSON.toInstant(v, "gen.o.t");
+				break;
+			case "s":
+				o.s = ColferJSON.toText(v, "gen.o.s");
+				break;
+			case "a":
+				o.a = ColferJSON.toBinary(v, "gen.o.a");
+				break;
+			case "o":
+				o.o = O.fromJSON(ColferJSON.toObject(v, "gen.o.o"), listMax);
+				break;
+			case "os":
+				{
+					java.util.List<?> l = ColferJSON.toList(v, "gen.o.os", listMax);
+					O[] a = new O[l.size()];
+					for (int i = 0; i < a.length; i++) {
+						O e = O.fromJSON(ColferJSON.toObject(l.get(i), "gen.o.os"), listMax);
+						a[i] = e == null ? new O() : e;
+					}
+					o.os = a;
+				}
+				break;
+			case "ss":
+				{
+					java.util.List<?> l = ColferJSON.toList(v, "gen.o.ss", listMax);
+					String[] a = new String[l.size()];
+					for (int i = 0; i < a.length; i++)
+						a[i] = ColferJSON.toText(l.get(i), "gen.o.ss");
+					o.ss = a;
+				}
+				break;
+			case "as":
+				{
+					java.util.List<?> l = ColferJSON.toList(v, "gen.o.as", listMax);
+					byte[][] a = new byte[l.size()][];
+					for (int i = 0; i < a.length; i++)
+						a[i] = ColferJSON.toBinary(l.get(i), "gen.o.as");
+					o.as = a;
+				}
+				break;
+			case "u8":
+				o.u8 = (byte) ColferJSON.toInt(v, "gen.o.u8", 8, false);
+				break;
+			case "u16":
+				o.u16 = (short) ColferJSON.toInt(v, "gen.o.u16", 16, false);
+				break;
+			case "f32s":
+				{
+					java.util.List<?> l = ColferJSON.toList(v, "gen.o.f32s", listMax);
+					float[] a = new float[l.size()];
+					for (int i = 0; i < a.length; i++)
+						a[i] = ColferJSON.toFloat32(l.get(i), "gen.o.f32s");
+					o.f32s = a;
+				}
+				break;
+			case "f64s":
+				{
+					java.util.List<?> l = ColferJSON.toList(v, "gen.o.f64s", listMax);
+					double[] a = new double[l.size()];
+					for (int i = 0; i < a.length; i++)
+						a[i] = ColferJSON.toFloat64(l.get(i), "gen.o.f64s");
+					o.f64s = a;
+				}
+				break;
+			default:
+				throw new InputMismatchException(format("colfer: JSON member \"%s\" not in struct gen.o", member.getKey()));
+			}
+		}
+		return o;
+	}
+
 	@Override
 	public final int hashCode() {
 		int h = 1;
@@ -1528,6 +2502,181 @@ public class O implements Serializable {
 		return h;
 	}
 
+	/**
+	 * Gets a human-readable form for debugging, with the sensitive fields masked.
+	 * @return the description.
+	 */
+	@Override
+	public String toString() {
+		StringBuilder buf = new StringBuilder("gen.o{");
+		buf.append("b=");
+		buf.append(this.b);
+		buf.append(", u32=");
+		buf.append(Integer.toUnsignedString(this.u32));
+		buf.append(", u64=");
+		buf.append(Long.toUnsignedString(this.u64));
+		buf.append(", i32=");
+		buf.append(this.i32);
+		buf.append(", i64=");
+		buf.append(this.i64);
+		buf.append(", f32=");
+		buf.append(this.f32);
+		buf.append(", f64=");
+		buf.append(this.f64);
+		buf.append(", t=");
+		buf.append(this.t);
+		buf.append(", s=");
+		buf.append('"').append(this.s).append('"');
+		buf.append(", a=");
+		buf.append(java.util.Arrays.toString(this.a));
+		buf.append(", o=");
+		buf.append(this.o);
+		buf.append(", os=");
+		buf.append(java.util.Arrays.toString(this.os));
+		buf.append(", ss=");
+		buf.append(java.util.Arrays.toString(this.ss));
+		buf.append(", as=");
+		buf.append(java.util.Arrays.deepToString(this.as));
+		buf.append(", u8=");
+		buf.append(this.u8 & 0xff);
+		buf.append(", u16=");
+		buf.append(this.u16 & 0xffff);
+		buf.append(", f32s=");
+		buf.append(java.util.Arrays.toString(this.f32s));
+		buf.append(", f64s=");
+		buf.append(java.util.Arrays.toString(this.f64s));
+		return buf.append('}').toString();
+	}
+
+	/**
+	 * Compares each field with another object. Data structures compare field by field
+	 * and lists compare element by element, conform {@link #equals(O)}.
+	 * @param other the new values, with {@code null} for the zero value.
+	 * @return the differences in schema order, with this object as the old values.
+	 */
+	public java.util.List<ColferDiff> diff(O other) {
+		if (other == null) other = new O();
+		java.util.List<ColferDiff> diffs = new java.util.ArrayList<>();
+		if (this.b != other.b)
+			diffs.add(new ColferDiff("b", this.b, other.b));
+		if (this.u32 != other.u32)
+			diffs.add(new ColferDiff("u32", this.u32, other.u32));
+		if (this.u64 != other.u64)
+			diffs.add(new ColferDiff("u64", this.u64, other.u64));
+		if (this.i32 != other.i32)
+			diffs.add(new ColferDiff("i32", this.i32, other.i32));
+		if (this.i64 != other.i64)
+			diffs.add(new ColferDiff("i64", this.i64, other.i64));
+		if (this.f32 != other.f32 && (this.f32 == this.f32 || other.f32 == other.f32))
+			diffs.add(new ColferDiff("f32", this.f32, other.f32));
+		if (this.f64 != other.f64 && (this.f64 == this.f64 || other.f64 == other.f64))
+			diffs.add(new ColferDiff("f64", this.f64, other.f64));
+		if (! java.util.Objects.equals(this.t, other.t))
+			diffs.add(new ColferDiff("t", this.t, other.t));
+		if (! java.util.Objects.equals(this.s, other.s))
+			diffs.add(new ColferDiff("s", this.s, other.s));
+		if (! java.util.Arrays.equals(this.a, other.a))
+			diffs.add(new ColferDiff("a", this.a, other.a));
+		if (this.o != null && other.o != null) {
+			for (ColferDiff d : this.o.diff(other.o))
+				diffs.add(new ColferDiff("o." + d.path, d.oldValue, d.newValue));
+		} else if (this.o != other.o) {
+			diffs.add(new ColferDiff("o", this.o, other.o));
+		}
+		{
+			O[] a = this.os, b = other.os;
+			for (int i = 0; i < a.length || i < b.length; i++) {
+				String path = "os[" + i + "]";
+				if (i >= a.length) {
+					diffs.add(new ColferDiff(path, null, b[i]));
+				} else if (i >= b.length) {
+					diffs.add(new ColferDiff(path, a[i], null));
+				} else {
+					O v = a[i] == null ? new O() : a[i];
+					for (ColferDiff d : v.diff(b[i]))
+						diffs.add(new ColferDiff(path + "." + d.path, d.oldValue, d.newValue));
+				}
+			}
+		}
+		{
+			String[] a = this.ss, b = other.ss;
+			for (int i = 0; i < a.length || i < b.length; i++) {
+				String path = "ss[" + i + "]";
+				if (i >= a.length) {
+					diffs.add(new ColferDiff(path, null, b[i]));
+				} else if (i >= b.length) {
+					diffs.add(new ColferDiff(path, a[i], null));
+				} else if (! java.util.Objects.equals(a[i], b[i])) {
+					diffs.add(new ColferDiff(path, a[i], b[i]));
+				}
+			}
+		}
+		{
+			byte[][] a = this.as, b = other.as;
+			for (int i = 0; i < a.length || i < b.length; i++) {
+				String path = "as[" + i + "]";
+				if (i >= a.length) {
+					diffs.add(new ColferDiff(path, null, b[i]));
+				} else if (i >= b.length) {
+					diffs.add(new ColferDiff(path, a[i], null));
+				} else if (! java.util.Arrays.equals(a[i], b[i])) {
+					diffs.add(new ColferDiff(path, a[i], b[i]));
+				}
+			}
+		}
+		if (this.u8 != other.u8)
+			diffs.add(new ColferDiff("u8", this.u8, other.u8));
+		if (this.u16 != other.u16)
+			diffs.add(new ColferDiff("u16", this.u16, other.u16));
+		{
+			float[] a = this.f32s, b = other.f32s;
+			for (int i = 0; i < a.length || i < b.length; i++) {
+				String path = "f32s[" + i + "]";
+				if (i >= a.length) {
+					diffs.add(new ColferDiff(path, null, b[i]));
+				} else if (i >= b.length) {
+					diffs.add(new ColferDiff(path, a[i], null));
+				} else if (a[i] != b[i] && (a[i] == a[i] || b[i] == b[i])) {
+					diffs.add(new ColferDiff(path, a[i], b[i]));
+				}
+			}
+		}
+		{
+			double[] a = this.f64s, b = other.f64s;
+			for (int i = 0; i < a.length || i < b.length; i++) {
+				String path = "f64s[" + i + "]";
+				if (i >= a.length) {
+					diffs.add(new ColferDiff(path, null, b[i]));
+				} else if (i >= b.length) {
+					diffs.add(new ColferDiff(path, a[i], null));
+				} else if (a[i] != b[i] && (a[i] == a[i] || b[i] == b[i])) {
+					diffs.add(new ColferDiff(path, a[i], b[i]));
+				}
+			}
+		}
+		return diffs;
+	}
+
+	/**
+	 * Checks each field against the schema rules, including the ones of nested data structures.
+	 * @throws InputMismatchException when a schema rule is broken.
+	 */
+	public void validate() {
+		if (this.o != null) this.o.validate();
+		if (this.os != null) for (O v : this.os)
+			(v == null ? new O() : v).validate();
+	}
+
+	@Override
+	public String colferType() {
+		return "gen.o";
+	}
+
+	@Override
+	public java.util.List<ColferDiff> colferDiff(ColferAny other) {
+		return diff((O) other);
+	}
+
 	@Override
 	public final boolean equals(Object o) {
 		return o instanceof O && equals((O) o);
@@ -1,5 +1,5 @@
 // Code generated by colf(1); DO NOT EDIT.
-// The compiler used schema file test.colf.
+// The compiler used schema file any.colf, rules.colf and test.colf.
 
 /**
  * Package gen tests all field mapping options.
@@ -1,13 +1,20 @@
+import gen.E;
 import gen.O;
+import gen.Old;
+import gen.R;
+import gen.W;
 
 import java.io.ByteArrayOutputStream;
 import java.io.ByteArrayInputStream;
 import java.io.ObjectInputStream;
 import java.io.ObjectOutputStream;
 import java.math.BigInteger;
+import java.nio.BufferUnderflowException;
 import java.nio.ByteBuffer;
+import java.security.MessageDigest;
 import java.time.Instant;
 import java.util.Arrays;
+import java.util.InputMismatchException;
 import java.util.LinkedHashMap;
 import java.util.Map;
 import java.util.Map.Entry;
@@ -27,7 +34,16 @@ public class test {
 
 			marshal();
 			unmarshal();
+			unmarshalFields();
+			unmarshalLazy();
+			unmarshalEmbedded();
+			unmarshalAny();
+			validate();
+			redact();
+			colferHash();
+			diff();
 			stream();
+			json();
 
 			marshalMax();
 			marshalTextMax();
@@ -38,6 +54,10 @@ public class test {
 			unmarshalTextMax();
 			unmarshalBinaryMax();
 			unmarshalListMax();
+			unmarshalDepthMax();
+			unmarshalListPrealloc();
+			unmarshalBudget();
+			unmarshalStrict();
 
 			serializable();
 		} catch (Exception e) {
@@ -155,6 +175,47 @@ public class test {
 		}
 	}
 
+	static void colferHash() throws Exception {
+		for (Entry<String, O> e : newGoldenCases().entrySet()) {
+			MessageDigest md = MessageDigest.getInstance("SHA-256");
+			e.getValue().colferHash(md);
+			String got = toHex(md.digest());
+			String want = toHex(MessageDigest.getInstance("SHA-256").digest(parseHex(e.getKey())));
+			if (! got.equals(want))
+				fail("colferHash: got 0x%s for serial 0x%s, want 0x%s", got, e.getKey(), want);
+		}
+	}
+
+	static void diff() {
+		Map<String, O> golden = newGoldenCases();
+		for (Entry<String, O> a : golden.entrySet()) {
+			for (Entry<String, O> b : golden.entrySet()) {
+				boolean same = a.getValue().diff(b.getValue()).isEmpty();
+				if (same != a.getKey().equals(b.getKey()))
+					fail("diff: 0x%s with 0x%s got %s", a.getKey(), b.getKey(), a.getValue().diff(b.getValue()));
+			}
+		}
+
+		O a = new O();
+		a.u8 = 1;
+		a.o = new O();
+		a.o.os = new O[]{new O(), new O()};
+		a.o.os[1].s = "old";
+		a.ss = new String[]{"a", "b"};
+		O b = new O();
+		b.u8 = 2;
+		b.o = new O();
+		b.o.os = new O[]{null, new O()};
+		b.o.os[1].s = "new";
+		b.ss = new String[]{"a"};
+		b.a = new byte[]{7};
+
+		String got = a.diff(b).toString();
+		String want = "[a: [] -> [7], o.os[1].s: old -> new, ss[1]: b -> null, u8: 1 -> 2]";
+		if (! got.equals(want))
+			fail("diff: got %s, want %s", got, want);
+	}
+
 	static void unmarshal() {
 		for (Entry<String, O> e : newGoldenCases().entrySet()) {
 			O o = new O();
@@ -168,6 +229,215 @@ public class test {
 		}
 	}
 
+	static void unmarshalFields() {
+		String[] all = {"b", "u32", "u64", "i32", "i64", "f32", "f64", "t", "s", "a", "o", "os", "ss", "as", "u8", "u16", "f32s", "f64s"};
+		for (Entry<String, O> e : newGoldenCases().entrySet()) {
+			byte[] serial = parseHex(e.getKey());
+
+			O o = new O();
+			int i = o.unmarshal(serial, 0, serial.length, all);
+			if (i != serial.length)
+				fail("unmarshal fields: 0x%s: got read index %d with all fields", e.getKey(), i);
+			if (! e.getValue().equals(o))
+				fail("unmarshal fields: mismatch for serial 0x%s with all fields", e.getKey());
+
+			o = new O();
+			i = o.unmarshal(serial, 0, serial.length, new String[0]);
+			if (i != serial.length)
+				fail("unmarshal fields: 0x%s: got read index %d with no fields", e.getKey(), i);
+			if (! new O().equals(o))
+				fail("unmarshal fields: 0x%s: got %s with no fields", e.getKey(), o);
+		}
+
+		O src = new O();
+		src.s = "skip";
+		src.a = new byte[1024];
+		src.o = new O();
+		src.o.b = true;
+		src.o.s = "x";
+		src.o.os = new O[]{new O()};
+		src.o.os[0].s = "y";
+		src.o.os[0].u8 = 1;
+		src.ss = new String[]{"a", "b"};
+		src.u8 = 2;
+		byte[] buf = new byte[O.colferSizeMax];
+		int n = src.marshal(buf, 0);
+
+		O got = new O();
+		got.s = "keep";
+		got.unmarshal(buf, 0, n, new String[]{"o.s", "o.os.u8", "ss"});
+		O want = new O();
+		want.s = "keep";
+		want.o = new O();
+		want.o.s = "x";
+		want.o.os = new O[]{new O()};
+		want.o.os[0].u8 = 1;
+		want.ss = new String[]{"a", "b"};
+		if (! want.equals(got))
+			fail("unmarshal fields: got differences %s", want.diff(got));
+
+		String[] valid = {"s", "o", "o.s", "os.o.f64s"};
+		for (String path : valid)
+			if (! O.hasFieldPath(path))
+				fail("hasFieldPath %s: got false", path);
+		String[] invalid = {"", "x", "o.", "o.x", "s.b", ".s"};
+		for (String path : invalid)
+			if (O.hasFieldPath(path))
+				fail("hasFieldPath %s: got true", path);
+	}
+
+	static void unmarshalLazy() {
+		for (Entry<String, O> e : newGoldenCases().entrySet()) {
+			byte[] serial = parseHex(e.getKey());
+			lazy.gen.O o = new lazy.gen.O();
+			o.unmarshal(serial, 0);
+
+			byte[] buf = new byte[serial.length];
+			int n = o.marshal(buf, 0);
+			if (! Arrays.equals(Arrays.copyOf(buf, n), serial))
+				fail("unmarshal lazy: 0x%s: got 0x%s", e.getKey(), toHex(Arrays.copyOf(buf, n)));
+
+			o.getO();
+			o.getOs();
+			n = o.marshal(buf, 0);
+			if (! Arrays.equals(Arrays.copyOf(buf, n), serial))
+				fail("unmarshal lazy: 0x%s: got 0x%s after decode", e.getKey(), toHex(Arrays.copyOf(buf, n)));
+		}
+
+		// gen.o with o {u32 1} in a non-canonical fixed size encoding
+		byte[] serial = parseHex("0a81000000017f7f");
+		lazy.gen.O o = new lazy.gen.O();
+		o.unmarshal(serial, 0);
+		if (o.o != null)
+			fail("unmarshal lazy: field o decoded before access");
+		byte[] buf = new byte[serial.length];
+		int n = o.marshal(buf, 0);
+		if (! Arrays.equals(Arrays.copyOf(buf, n), serial))
+			fail("unmarshal lazy: got 0x%s for the untouched value", toHex(Arrays.copyOf(buf, n)));
+
+		if (o.getO().u32 != 1)
+			fail("unmarshal lazy: got u32 %d, want 1", o.getO().u32);
+		o.getO().u32 = 2;
+		n = o.marshal(buf, 0);
+		if (! toHex(Arrays.copyOf(buf, n)).equals("0a01027f7f"))
+			fail("unmarshal lazy: got 0x%s after modification", toHex(Arrays.copyOf(buf, n)));
+	}
+
+	static void unmarshalEmbedded() {
+		// o with u32 1
+		byte[] serial = parseHex("000301017f7f");
+		E e = new E();
+		int i = e.unmarshal(serial, 0);
+		if (i != serial.length)
+			fail("unmarshal embedded: got read index %d", i);
+		if (! Arrays.equals(e.m, parseHex("01017f")))
+			fail("unmarshal embedded: got m %s", Arrays.toString(e.m));
+
+		// missing terminator
+		try {
+			new E().unmarshal(parseHex("000201017f"), 0);
+			fail("unmarshal embedded: no exception for missing terminator");
+		} catch (InputMismatchException ex) {
+			String want = "colfer: unknown header at byte 3";
+			if (! want.equals(ex.getMessage()))
+				fail("unmarshal embedded error: %s\nwant: %s", ex.getMessage(), want);
+		}
+	}
+
+	static void unmarshalAny() {
+		// w with o {s "x"}
+		byte[] serial = parseHex("000567656e2e6f0801787f7f");
+		W w = new W();
+		w.v = new O();
+		((O) w.v).s = "x";
+		byte[] buf = new byte[serial.length];
+		if (! Arrays.equals(Arrays.copyOf(buf, w.marshal(buf, 0)), serial))
+			fail("marshal any: got 0x%s", toHex(buf));
+
+		W got = new W();
+		int i = got.unmarshal(serial, 0);
+		if (i != serial.length)
+			fail("unmarshal any: got read index %d", i);
+		if (! w.equals(got))
+			fail("unmarshal any: got %s", got.v);
+
+		// type gen.z not registered
+		try {
+			new W().unmarshal(parseHex("000567656e2e7a7f7f"), 0);
+			fail("unmarshal any: no exception for unknown type");
+		} catch (InputMismatchException ex) {
+			String want = "colfer: unknown header at byte 2";
+			if (! want.equals(ex.getMessage()))
+				fail("unmarshal any error: %s\nwant: %s", ex.getMessage(), want);
+		}
+	}
+
+	static void validate() {
+		R r = new R();
+		r.par = 4;
+		r.name = "ams";
+		r.validate();
+
+		r.next = new R();
+		r.next.par = 6;
+		r.next.name = "x";
+		try {
+			r.validate();
+			fail("validate: no exception for nested par 6");
+		} catch (InputMismatchException ex) {
+			String want = "colfer: field gen.r.par breaks rule max 5";
+			if (! want.equals(ex.getMessage()))
+				fail("validate error: %s\nwant: %s", ex.getMessage(), want);
+		}
+
+		r.next = null;
+		r.big = -1L;
+		try {
+			r.validate();
+			fail("validate: no exception for unsigned big above max");
+		} catch (InputMismatchException ex) {
+			String want = "colfer: field gen.r.big breaks rule max 10000000000000000000";
+			if (! want.equals(ex.getMessage()))
+				fail("validate error: %s\nwant: %s", ex.getMessage(), want);
+		}
+
+		// name absent
+		byte[] serial = parseHex("0004" + "7f");
+		new R().unmarshal(serial, 0);
+		try {
+			new R().unmarshal(serial, 0, serial.length, 1, null, false, true, null);
+			fail("unmarshal validate: no exception for absent name");
+		} catch (InputMismatchException ex) {
+			String want = "colfer: field gen.r.name breaks rule required";
+			if (! want.equals(ex.getMessage()))
+				fail("unmarshal validate error: %s\nwant: %s", ex.getMessage(), want);
+		}
+		// unselected name
+		new R().unmarshal(serial, 0, serial.length, 1, new String[]{"par"}, false, true, null);
+		// skipped next without name
+		serial = parseHex("0004" + "0303" + "616d73" + "05" + "0004" + "7f" + "7f");
+		new R().unmarshal(serial, 0, serial.length, 1, new String[]{"par", "name"}, false, true, null);
+	}
+
+	@SuppressWarnings("deprecation")
+	static void redact() {
+		R r = new R();
+		r.par = (byte) 200;
+		r.name = "ams";
+		r.tags = new String[]{"a"};
+		String want = "gen.r{par=200, lat=0.0, big=0, name=\"ams\", tags=[a], next=null}";
+		if (! want.equals(r.toString()))
+			fail("toString: got %s, want %s", r, want);
+
+		Old o = new Old();
+		o.pin = "1234";
+		o.ref = new Old();
+		o.ref.pin = "5678";
+		want = "gen.old{pin=<redacted>, ref=gen.old{pin=<redacted>, ref=null}}";
+		if (! want.equals(o.toString()))
+			fail("toString: got %s, want %s", o, want);
+	}
+
 	static void stream() throws Exception {
 		ByteArrayOutputStream out = new ByteArrayOutputStream();
 
@@ -190,6 +460,48 @@ public class test {
 			fail("stream: data tail");
 	}
 
+	static void json() {
+		for (Entry<String, O> e : newGoldenCases().entrySet()) {
+			String doc;
+			try {
+				doc = e.getValue().toJSON();
+			} catch (IllegalStateException ex) {
+				continue; // timestamp beyond RFC 3339
+			}
+
+			O got = O.fromJSON(doc);
+			if (! e.getValue().equals(got))
+				fail("json: mismatch for serial 0x%s with %s", e.getKey(), doc);
+		}
+
+		O o = new O();
+		o.u64 = -1L;
+		o.i64 = Long.MIN_VALUE;
+		o.i32 = -1;
+		O nested = new O();
+		nested.i64 = 1;
+		o.os = new O[]{nested};
+		String want = "{\"u64\":\"18446744073709551615\",\"i32\":-1,\"i64\":\"-9223372036854775808\",\"os\":[{\"i64\":\"1\"}]}";
+		String doc = o.toJSON(true);
+		if (! want.equals(doc))
+			fail("json quote64: got %s, want %s", doc, want);
+		if (! o.equals(O.fromJSON(doc)))
+			fail("json quote64: mismatch with %s", doc);
+		want = "{\"u64\":18446744073709551615,\"i32\":-1,\"i64\":-9223372036854775808,\"os\":[{\"i64\":1}]}";
+		doc = o.toJSON();
+		if (! want.equals(doc))
+			fail("json: got %s, want %s", doc, want);
+
+		try {
+			O.fromJSON("{\"o\":{\"ss\":[\"a\",\"b\"]}}", 1);
+			fail("json: no list max exception");
+		} catch (SecurityException e) {
+			want = "colfer: field gen.o.ss length 2 exceeds 1 elements";
+			if (! want.equals(e.getMessage()))
+				fail("json list max error: %s\nwant: %s", e.getMessage(), want);
+		}
+	}
+
 	static void marshalMax() {
 		int origMax = O.colferSizeMax;
 		O.colferSizeMax = 2;
@@ -322,6 +634,112 @@ public class test {
 		}
 	}
 
+	static void unmarshalDepthMax() {
+		int origMax = O.colferDepthMax;
+		O.colferDepthMax = 2;
+		try {
+			byte[] serial = parseHex("0a0a7f7f7f");
+			new O().unmarshal(serial, 0);
+			fail("no unmarshal depth max exception");
+		} catch (SecurityException e) {
+			String want = "colfer: gen.o exceeds nesting depth 2";
+			if (! want.equals(e.getMessage()))
+				fail("unmarshal depth max error: %s\nwant: %s", e.getMessage(), want);
+		} finally {
+			O.colferDepthMax = origMax;
+		}
+	}
+
+	static void unmarshalListPrealloc() {
+		// lists of 65535 elements with one byte of data remaining
+		for (String hex : new String[]{"0bffff037f", "0cffff037f", "0dffff037f", "10ffff037f", "11ffff037f"}) {
+			try {
+				new O().unmarshal(parseHex(hex), 0);
+				fail("0x%s: no unmarshal exception", hex);
+			} catch (BufferUnderflowException e) {
+				// pass
+			} catch (Exception e) {
+				fail("0x%s: unmarshal exception %s, want BufferUnderflowException", hex, e);
+			}
+		}
+	}
+
+	static void unmarshalBudget() {
+		// gen.o with o {os [{}, {}]} and ss ["a", "b"]
+		byte[] serial = parseHex("0a0b027f7f7f" + "0c0201610162" + "7f");
+
+		long[] budget = {1 << 20};
+		new O().unmarshal(serial, 0, serial.length, 1, null, false, false, budget);
+		long used = (1 << 20) - budget[0];
+		if (used <= 0) fail("unmarshal budget reduced by %d bytes", used);
+
+		budget[0] = used;
+		new O().unmarshal(serial, 0, serial.length, 1, null, false, false, budget);
+		if (budget[0] != 0) fail("unmarshal got %d bytes of budget left, want 0", budget[0]);
+
+		budget[0] = used - 1;
+		try {
+			new O().unmarshal(serial, 0, serial.length, 1, null, false, false, budget);
+			fail("unmarshal: no exception with a short budget");
+		} catch (SecurityException e) {
+			String want = "colfer: gen.o.ss exceeds the allocation budget";
+			if (! want.equals(e.getMessage()))
+				fail("unmarshal budget error: %s\nwant: %s", e.getMessage(), want);
+		}
+	}
+
+	static void unmarshalStrict() {
+		for (Entry<String, O> e : newGoldenCases().entrySet()) {
+			byte[] serial = parseHex(e.getKey());
+			try {
+				new O().unmarshalStrict(serial, 0, serial.length);
+			} catch (Exception ex) {
+				fail("0x%s: strict unmarshal exception: %s", e.getKey(), ex);
+			}
+		}
+
+		Map<String, Integer> golden = new LinkedHashMap<>();
+		// explicit zero values
+		golden.put("0e007f", 0);
+		golden.put("01007f", 0);
+		golden.put("83007f", 0);
+		golden.put("05000000007f", 0);
+		golden.put("05800000007f", 0);
+		golden.put("08007f", 0);
+		golden.put("09007f", 0);
+		golden.put("0b007f", 0);
+		golden.put("10007f", 0);
+		// fixed size where compact applies, and vice versa
+		golden.put("0f00017f", 0);
+		golden.put("81000000017f", 0);
+		golden.put("8200000000000000017f", 0);
+		golden.put("01808080017f", 0);
+		golden.put("870000000000000001000000007f", 0);
+		// redundant varint bytes
+		golden.put("0181007f", 0);
+		golden.put("088100617f", 2);
+		// nanoseconds overflow into the seconds
+		golden.put("07000000013b9aca007f", 5);
+		// invalid UTF-8
+		golden.put("080261ff7f", 3);
+		golden.put("0c02016102c3287f", 5);
+		// nested data structures
+		golden.put("0a01007f7f", 1);
+		golden.put("0b0101007f7f", 2);
+
+		for (Entry<String, Integer> e : golden.entrySet()) {
+			byte[] serial = parseHex(e.getKey());
+			String want = String.format("colfer: non-canonical encoding at byte %d", e.getValue());
+			try {
+				new O().unmarshalStrict(serial, 0, serial.length);
+				fail("0x%s: no strict unmarshal exception", e.getKey());
+			} catch (InputMismatchException ex) {
+				if (! want.equals(ex.getMessage()))
+					fail("0x%s: strict unmarshal error: %s\nwant: %s", e.getKey(), ex.getMessage(), want);
+			}
+		}
+	}
+
 	static void serializable() throws Exception {
 		Set<Entry<String, O>> cases = newGoldenCases().entrySet();
 		ByteArrayOutputStream buf = new ByteArrayOutputStream();
@@ -4,9 +4,14 @@ package internal
 // The compiler used schema file internal.colf.
 
 import (
+	"bufio"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
+	"strconv"
+	"unicode/utf8"
 )
 
 var intconv = binary.BigEndian
@@ -15,6 +20,9 @@ var intconv = binary.BigEndian
 var (
 	// ColferSizeMax is the upper limit for serial byte sizes.
 	ColferSizeMax = 16 * 1024 * 1024
+	// ColferDepthMax is the upper limit for the number of nested data
+	// structure levels, including the root.
+	ColferDepthMax = 100
 )
 
 // ColferMax signals an upper limit breach.
@@ -39,6 +47,299 @@ func (i ColferTail) Error() string {
 	return fmt.Sprintf("colfer: data continuation at byte %d", i)
 }
 
+// ColferNonCanonical signals an encoding deviation as a byte index.
+type ColferNonCanonical int
+
+// Error honors the error interface.
+func (i ColferNonCanonical) Error() string {
+	return fmt.Sprintf("colfer: non-canonical encoding at byte %d", i)
+}
+
+// ColferDiff is a field difference between two data structures.
+type ColferDiff struct {
+	// Path locates the field with the schema names, e.g.,
+	// "course.holes[3].par".
+	Path string
+	// Old and New are the respective values, with nil for absence.
+	Old, New interface{}
+}
+
+// String returns the difference in a human readable form.
+func (d ColferDiff) String() string {
+	return fmt.Sprintf("%s: %v -> %v", d.Path, d.Old, d.New)
+}
+
+// ColferInvalid signals a schema rule violation.
+type ColferInvalid struct {
+	// Field is the qualified name, e.g., "demo.hole.par".
+	Field string
+	// Rule is the schema tag, e.g., "max 5".
+	Rule string
+}
+
+// Error honors the error interface.
+func (e ColferInvalid) Error() string {
+	return fmt.Sprintf("colfer: field %s breaks rule %s", e.Field, e.Rule)
+}
+
+// ColferOptions are limits for a single call, as an alternative to the
+// package-level configuration attributes. The zero value of each limit means
+// the respective configuration attribute.
+type ColferOptions struct {
+	// SizeMax is the upper limit for serial byte sizes. Zero means
+	// ColferSizeMax.
+	SizeMax int
+	// ListMax is the upper limit for the number of elements in a list. Zero
+	// means ColferListMax, if the package has lists.
+	ListMax int
+	// DepthMax is the upper limit for the number of data structure levels,
+	// including the root. Zero means ColferDepthMax, and a negative value
+	// means no limit.
+	DepthMax int
+	// Strict makes Unmarshal reject any serial which differs from the
+	// output of Marshal for the same data with a ColferNonCanonical.
+	Strict bool
+	// Validate makes Unmarshal check the schema rules of each data
+	// structure, conform Validate.
+	Validate bool
+	// JSONQuote64 makes MarshalJSONWith encode 64-bit integers as JSON
+	// strings, which keeps them exact for JavaScript consumers.
+	JSONQuote64 bool
+	// Budget, when not nil, is the number of bytes which may still be
+	// allocated for field values, including those of nested data structures.
+	// Unmarshal deducts each allocation, and it fails with ColferMax before
+	// any allocation exceeds the remainder.
+	Budget *int
+	// Fields, when not nil, selects the fields to decode by path, with the
+	// schema names separated by dots, e.g., "o.s". A data structure field
+	// selects all of its nested fields. Unmarshal skips the other fields
+	// without allocation, and it leaves their values as is.
+	// See HasColferPath for validation.
+	Fields []string
+}
+
+// limits returns opts with the package-level configuration attributes in
+// place of any zero limit.
+func (opts ColferOptions) limits() ColferOptions {
+	if opts.SizeMax == 0 {
+		opts.SizeMax = ColferSizeMax
+	}
+	if opts.DepthMax == 0 {
+		opts.DepthMax = ColferDepthMax
+	}
+	return opts
+}
+
+// nested returns the options for a data structure in field, which is a
+// level deeper.
+func (opts ColferOptions) nested(field string) (ColferOptions, error) {
+	switch {
+	case opts.DepthMax == 1:
+		return opts, ColferMax(fmt.Sprintf("colfer: field %s exceeds the nesting depth limit", field))
+	case opts.DepthMax > 1:
+		opts.DepthMax--
+	}
+	return opts, nil
+}
+
+// selects returns whether Fields includes field, either as a whole or with
+// any of its nested fields.
+func (opts ColferOptions) selects(field string) bool {
+	if opts.Fields == nil {
+		return true
+	}
+	for _, p := range opts.Fields {
+		if len(p) >= len(field) && p[:len(field)] == field && (len(p) == len(field) || p[len(field)] == '.') {
+			return true
+		}
+	}
+	return false
+}
+
+// charge deducts n bytes for field from the allocation budget, if any.
+func (opts ColferOptions) charge(field string, n int) error {
+	if opts.Budget == nil {
+		return nil
+	}
+	if n > *opts.Budget {
+		return ColferMax(fmt.Sprintf("colfer: field %s exceeds the allocation budget", field))
+	}
+	*opts.Budget -= n
+	return nil
+}
+
+// colferCanon returns a ColferNonCanonical on the first byte in serial that
+// differs from canon. The serial starts at byte index offset.
+func colferCanon(canon, serial []byte, offset int) error {
+	for i := range serial {
+		if i >= len(canon) || serial[i] != canon[i] {
+			return ColferNonCanonical(offset + i)
+		}
+	}
+	if len(serial) != len(canon) {
+		return ColferNonCanonical(offset + len(serial))
+	}
+	return nil
+}
+
+// colferUTF8Len returns the number of bytes in p before the first invalid
+// UTF-8 sequence, if any.
+func colferUTF8Len(p []byte) int {
+	for i := 0; i < len(p); {
+		r, size := utf8.DecodeRune(p[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return len(p)
+}
+
+// colferOptions returns the package-level configuration attributes.
+func colferOptions() ColferOptions {
+	return ColferOptions{
+		SizeMax:  ColferSizeMax,
+		DepthMax: ColferDepthMax,
+	}
+}
+
+// ColferDecoder reads consecutive Colfer serials from a stream.
+// The read buffer is reused between calls.
+type ColferDecoder struct {
+	r   io.Reader
+	err error // pending read error
+
+	// buf is the read buffer.
+	buf []byte
+	// offset is the index of the first data byte in buf.
+	offset int
+	// i is the index of the data end (exclusive) in buf.
+	i int
+}
+
+// NewColferDecoder returns a new decoder which reads from r.
+func NewColferDecoder(r io.Reader) *ColferDecoder {
+	size := 2048
+	if size > ColferSizeMax {
+		size = ColferSizeMax
+	}
+	return &ColferDecoder{r: r, buf: make([]byte, size)}
+}
+
+// Decode reads the next serial into v, which is any of the generated structs.
+// When the stream ends on a serial boundary, then the error is io.EOF. A stream
+// which ends within a serial gives io.ErrUnexpectedEOF instead.
+// The error return options are io.EOF, io.ErrUnexpectedEOF, ColferError and
+// ColferMax, or any error from the reader.
+func (d *ColferDecoder) Decode(v interface {
+	Unmarshal([]byte) (int, error)
+}) error {
+	for {
+		if d.offset < d.i {
+			n, err := v.Unmarshal(d.buf[d.offset:d.i])
+			if err != io.EOF {
+				if err == nil {
+					d.offset += n
+				}
+				return err
+			}
+		}
+		// not enough data
+
+		if d.err != nil {
+			if d.err == io.EOF && d.offset < d.i {
+				return io.ErrUnexpectedEOF
+			}
+			return d.err
+		}
+
+		if d.offset >= d.i {
+			d.offset, d.i = 0, 0
+		} else if d.i >= len(d.buf) {
+			if d.offset == 0 {
+				if len(d.buf) >= ColferSizeMax {
+					return ColferMax(fmt.Sprintf("colfer: serial exceeds %d bytes", ColferSizeMax))
+				}
+				// grow
+				size := len(d.buf) * 4
+				if size > ColferSizeMax {
+					size = ColferSizeMax
+				}
+				bigger := make([]byte, size)
+				copy(bigger, d.buf)
+				d.buf = bigger
+			} else {
+				// move data to start of buffer
+				copy(d.buf, d.buf[d.offset:d.i])
+				d.i -= d.offset
+				d.offset = 0
+			}
+		}
+
+		var n int
+		n, d.err = d.r.Read(d.buf[d.i:])
+		d.i += n
+	}
+}
+
+// ColferEncoder writes Colfer serials to a stream.
+// The write buffer is reused between calls.
+type ColferEncoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewColferEncoder returns a new encoder which writes to w.
+func NewColferEncoder(w io.Writer) *ColferEncoder {
+	return &ColferEncoder{w: w}
+}
+
+// Encode writes the serial of v, which is any of the generated structs, with
+// a single call to the writer.
+// The error return options are ColferMax, or any error from the writer.
+func (e *ColferEncoder) Encode(v interface {
+	MarshalLen() (int, error)
+	MarshalTo([]byte) int
+}) error {
+	l, err := v.MarshalLen()
+	if err != nil {
+		return err
+	}
+	if l > cap(e.buf) {
+		e.buf = make([]byte, l)
+	}
+	buf := e.buf[:l]
+	v.MarshalTo(buf)
+	_, err = e.w.Write(buf)
+	return err
+}
+
+// ColferSplit returns a bufio.SplitFunc for consecutive serials, which makes
+// each token a serial of v. The tokens are decoded into v as a side effect.
+// Note that bufio.Scanner limits the token size to bufio.MaxScanTokenSize,
+// unless configured otherwise, e.g., with ColferSizeMax as the maximum.
+func ColferSplit(v interface {
+	Unmarshal([]byte) (int, error)
+}) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if len(data) == 0 {
+			return 0, nil, nil
+		}
+		n, err := v.Unmarshal(data)
+		switch err {
+		case nil:
+			return n, data[:n], nil
+		case io.EOF:
+			if atEOF {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		default:
+			return 0, nil, err
+		}
+	}
+}
+
 // Header is a prefix for requests and responses.
 type Header struct {
 	SeqID uint64
@@ -123,6 +424,14 @@ func (o *Header) MarshalTo(buf []byte) int {
 // MarshalLen returns the Colfer serial byte size.
 // The error return option is internal.ColferMax.
 func (o *Header) MarshalLen() (int, error) {
+	return o.MarshalLenWith(colferOptions())
+}
+
+// MarshalLenWith is like MarshalLen, yet with the limits of opts instead of the
+// package-level configuration attributes.
+// The error return option is internal.ColferMax.
+func (o *Header) MarshalLenWith(opts ColferOptions) (int, error) {
+	opts = opts.limits()
 	l := 1
 
 	if x := o.SeqID; x >= 1<<49 {
@@ -134,8 +443,8 @@ func (o *Header) MarshalLen() (int, error) {
 	}
 
 	if x := len(o.Method); x != 0 {
-		if x > ColferSizeMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field internal.header.method exceeds %d bytes", ColferSizeMax))
+		if x > opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field internal.header.method exceeds %d bytes", opts.SizeMax))
 		}
 		for l += x + 2; x >= 0x80; l++ {
 			x >>= 7
@@ -143,8 +452,8 @@ func (o *Header) MarshalLen() (int, error) {
 	}
 
 	if x := len(o.Error); x != 0 {
-		if x > ColferSizeMax {
-			return 0, ColferMax(fmt.Sprintf("colfer: field internal.header.error exceeds %d bytes", ColferSizeMax))
+		if x > opts.SizeMax {
+			return 0, ColferMax(fmt.Sprintf("colfer: field internal.header.error exceeds %d bytes", opts.SizeMax))
 		}
 		for l += x + 2; x >= 0x80; l++ {
 			x >>= 7
@@ -159,8 +468,8 @@ func (o *Header) MarshalLen() (int, error) {
 		}
 	}
 
-	if l > ColferSizeMax {
-		return l, ColferMax(fmt.Sprintf("colfer: struct internal.header exceeds %d bytes", ColferSizeMax))
+	if l > opts.SizeMax {
+		return l, ColferMax(fmt.Sprintf("colfer: struct internal.header exceeds %d bytes", opts.SizeMax))
 	}
 	return l, nil
 }
@@ -177,16 +486,210 @@ func (o *Header) MarshalBinary() (data []byte, err error) {
 	return data, nil
 }
 
+// AppendColfer appends the Colfer serial of o to dst and returns the extended
+// buffer. Unlike MarshalBinary, the object is walked only once.
+// When an error occurs, dst is returned with its original length.
+// The error return option is internal.ColferMax.
+func (o *Header) AppendColfer(dst []byte) ([]byte, error) {
+	return o.AppendColferWith(dst, colferOptions())
+}
+
+// AppendColferWith is like AppendColfer, yet with the limits of opts instead
+// of the package-level configuration attributes.
+// The error return option is internal.ColferMax.
+func (o *Header) AppendColferWith(dst []byte, opts ColferOptions) ([]byte, error) {
+	opts = opts.limits()
+	buf := dst
+
+	if x := o.SeqID; x >= 1<<49 {
+		buf = append(buf, 0|0x80, 0, 0, 0, 0, 0, 0, 0, 0)
+		intconv.PutUint64(buf[len(buf)-8:], x)
+	} else if x != 0 {
+		buf = append(buf, 0)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if l := len(o.Method); l != 0 {
+		if l > opts.SizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field internal.header.method exceeds %d bytes", opts.SizeMax))
+		}
+		buf = append(buf, 1)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Method...)
+	}
+
+	if l := len(o.Error); l != 0 {
+		if l > opts.SizeMax {
+			return dst, ColferMax(fmt.Sprintf("colfer: field internal.header.error exceeds %d bytes", opts.SizeMax))
+		}
+		buf = append(buf, 2)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		buf = append(buf, o.Error...)
+	}
+
+	if x := o.BodySize; x >= 1<<21 {
+		buf = append(buf, 3|0x80, 0, 0, 0, 0)
+		intconv.PutUint32(buf[len(buf)-4:], x)
+	} else if x != 0 {
+		buf = append(buf, 3)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	buf = append(buf, 0x7f)
+	if len(buf)-len(dst) > opts.SizeMax {
+		return dst, ColferMax(fmt.Sprintf("colfer: struct internal.header exceeds %d bytes", opts.SizeMax))
+	}
+	return buf, nil
+}
+
+// ColferHash writes the Colfer serial of o to h, without materializing the
+// serial as a whole. Equal values produce the same input for h in each of the
+// supported languages.
+// The error return option is internal.ColferMax.
+func (o *Header) ColferHash(h hash.Hash) error {
+	_, err := o.ColferHashWith(h, colferOptions())
+	return err
+}
+
+// ColferHashWith is like ColferHash, yet with the limits of opts instead of the
+// package-level configuration attributes. The return is the number of bytes
+// written to h. When an error occurs, then the state of h is undefined.
+// The error return option is internal.ColferMax.
+func (o *Header) ColferHashWith(h hash.Hash, opts ColferOptions) (int, error) {
+	opts = opts.limits()
+	var scratch [32]byte
+	buf := scratch[:0]
+	var n int
+
+	if x := o.SeqID; x >= 1<<49 {
+		buf = append(buf, 0|0x80, 0, 0, 0, 0, 0, 0, 0, 0)
+		intconv.PutUint64(buf[len(buf)-8:], x)
+	} else if x != 0 {
+		buf = append(buf, 0)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	if l := len(o.Method); l != 0 {
+		if l > opts.SizeMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field internal.header.method exceeds %d bytes", opts.SizeMax))
+		}
+		buf = append(buf, 1)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		h.Write(buf)
+		n += len(buf) + l
+		buf = buf[:0]
+		io.WriteString(h, o.Method)
+	}
+
+	if l := len(o.Error); l != 0 {
+		if l > opts.SizeMax {
+			return n, ColferMax(fmt.Sprintf("colfer: field internal.header.error exceeds %d bytes", opts.SizeMax))
+		}
+		buf = append(buf, 2)
+		x := uint(l)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+		h.Write(buf)
+		n += len(buf) + l
+		buf = buf[:0]
+		io.WriteString(h, o.Error)
+	}
+
+	if x := o.BodySize; x >= 1<<21 {
+		buf = append(buf, 3|0x80, 0, 0, 0, 0)
+		intconv.PutUint32(buf[len(buf)-4:], x)
+	} else if x != 0 {
+		buf = append(buf, 3)
+		for x >= 0x80 {
+			buf = append(buf, byte(x|0x80))
+			x >>= 7
+		}
+		buf = append(buf, byte(x))
+	}
+
+	buf = append(buf, 0x7f)
+	h.Write(buf)
+	n += len(buf)
+	if n > opts.SizeMax {
+		return n, ColferMax(fmt.Sprintf("colfer: struct internal.header exceeds %d bytes", opts.SizeMax))
+	}
+	return n, nil
+}
+
 // Unmarshal decodes data as Colfer and returns the number of bytes read.
 // The error return options are io.EOF, internal.ColferError and internal.ColferMax.
 func (o *Header) Unmarshal(data []byte) (int, error) {
+	return o.UnmarshalWith(data, colferOptions())
+}
+
+// UnmarshalWith is like Unmarshal, yet with the limits and the field selection
+// of opts instead of the package-level configuration attributes.
+// The error return options are io.EOF, internal.ColferError, internal.ColferMax,
+// with opts.Strict, internal.ColferNonCanonical and, with opts.Validate,
+// internal.ColferInvalid.
+func (o *Header) UnmarshalWith(data []byte, opts ColferOptions) (int, error) {
+	opts = opts.limits()
 	if len(data) == 0 {
 		return 0, io.EOF
 	}
 	header := data[0]
 	i := 1
 
+	if header&0x7f == 0 && !opts.selects("seqID") {
+		if header&0x80 != 0 {
+			i += 8
+		} else {
+			for n := 1; ; n++ {
+				if i >= len(data) {
+					goto eof
+				}
+				b := data[i]
+				i++
+				if b < 0x80 || n == 9 {
+					break
+				}
+			}
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
 	if header == 0 {
+		at := i - 1
 		start := i
 		i++
 		if i >= len(data) {
@@ -212,15 +715,97 @@ func (o *Header) Unmarshal(data []byte) (int, error) {
 		}
 		o.SeqID = x
 
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.SeqID; x >= 1<<49 {
+				buf = append(buf, 0|0x80, 0, 0, 0, 0, 0, 0, 0, 0)
+				intconv.PutUint64(buf[len(buf)-8:], x)
+			} else if x != 0 {
+				buf = append(buf, 0)
+				for x >= 0x80 {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
 		header = data[i]
 		i++
 	} else if header == 0|0x80 {
+		at := i - 1
 		start := i
 		i += 8
 		if i >= len(data) {
 			goto eof
 		}
 		o.SeqID = intconv.Uint64(data[start:])
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.SeqID; x >= 1<<49 {
+				buf = append(buf, 0|0x80, 0, 0, 0, 0, 0, 0, 0, 0)
+				intconv.PutUint64(buf[len(buf)-8:], x)
+			} else if x != 0 {
+				buf = append(buf, 0)
+				for x >= 0x80 {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
+		header = data[i]
+		i++
+	}
+
+	if header == 1 && !opts.selects("method") {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: internal.header.method size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+		i += int(x)
+
+		if i >= len(data) {
+			goto eof
+		}
 		header = data[i]
 		i++
 	}
@@ -242,6 +827,9 @@ func (o *Header) Unmarshal(data []byte) (int, error) {
 				i++
 
 				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
 					x |= b << shift
 					break
 				}
@@ -249,8 +837,11 @@ func (o *Header) Unmarshal(data []byte) (int, error) {
 			}
 		}
 
-		if x > uint(ColferSizeMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: internal.header.method size %d exceeds %d bytes", x, ColferSizeMax))
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: internal.header.method size %d exceeds %d bytes", x, opts.SizeMax))
 		}
 
 		start := i
@@ -258,12 +849,59 @@ func (o *Header) Unmarshal(data []byte) (int, error) {
 		if i >= len(data) {
 			goto eof
 		}
+		if opts.Strict {
+			if n := colferUTF8Len(data[start:i]); n < int(x) {
+				return 0, ColferNonCanonical(start + n)
+			}
+		}
+		if err := opts.charge("internal.header.method", int(x)); err != nil {
+			return 0, err
+		}
 		o.Method = string(data[start:i])
 
 		header = data[i]
 		i++
 	}
 
+	if header == 2 && !opts.selects("error") {
+		if i >= len(data) {
+			goto eof
+		}
+		x := uint(data[i])
+		i++
+
+		if x >= 0x80 {
+			x &= 0x7f
+			for shift := uint(7); ; shift += 7 {
+				if i >= len(data) {
+					goto eof
+				}
+				b := uint(data[i])
+				i++
+
+				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
+					x |= b << shift
+					break
+				}
+				x |= (b & 0x7f) << shift
+			}
+		}
+
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: internal.header.error size %d exceeds %d bytes", x, opts.SizeMax))
+		}
+		i += int(x)
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
 	if header == 2 {
 		if i >= len(data) {
 			goto eof
@@ -281,6 +919,9 @@ func (o *Header) Unmarshal(data []byte) (int, error) {
 				i++
 
 				if b < 0x80 {
+					if b == 0 && opts.Strict {
+						return 0, ColferNonCanonical(i - 1)
+					}
 					x |= b << shift
 					break
 				}
@@ -288,8 +929,11 @@ func (o *Header) Unmarshal(data []byte) (int, error) {
 			}
 		}
 
-		if x > uint(ColferSizeMax) {
-			return 0, ColferMax(fmt.Sprintf("colfer: internal.header.error size %d exceeds %d bytes", x, ColferSizeMax))
+		if x == 0 && opts.Strict {
+			return 0, ColferNonCanonical(i - 2)
+		}
+		if x > uint(opts.SizeMax) {
+			return 0, ColferMax(fmt.Sprintf("colfer: internal.header.error size %d exceeds %d bytes", x, opts.SizeMax))
 		}
 
 		start := i
@@ -297,13 +941,45 @@ func (o *Header) Unmarshal(data []byte) (int, error) {
 		if i >= len(data) {
 			goto eof
 		}
+		if opts.Strict {
+			if n := colferUTF8Len(data[start:i]); n < int(x) {
+				return 0, ColferNonCanonical(start + n)
+			}
+		}
+		if err := opts.charge("internal.header.error", int(x)); err != nil {
+			return 0, err
+		}
 		o.Error = string(data[start:i])
 
 		header = data[i]
 		i++
 	}
 
+	if header&0x7f == 3 && !opts.selects("bodySize") {
+		if header&0x80 != 0 {
+			i += 4
+		} else {
+			for {
+				if i >= len(data) {
+					goto eof
+				}
+				b := data[i]
+				i++
+				if b < 0x80 {
+					break
+				}
+			}
+		}
+
+		if i >= len(data) {
+			goto eof
+		}
+		header = data[i]
+		i++
+	}
+
 	if header == 3 {
+		at := i - 1
 		start := i
 		i++
 		if i >= len(data) {
@@ -329,15 +1005,58 @@ func (o *Header) Unmarshal(data []byte) (int, error) {
 		}
 		o.BodySize = x
 
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.BodySize; x >= 1<<21 {
+				buf = append(buf, 3|0x80, 0, 0, 0, 0)
+				intconv.PutUint32(buf[len(buf)-4:], x)
+			} else if x != 0 {
+				buf = append(buf, 3)
+				for x >= 0x80 {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
 		header = data[i]
 		i++
 	} else if header == 3|0x80 {
+		at := i - 1
 		start := i
 		i += 4
 		if i >= len(data) {
 			goto eof
 		}
 		o.BodySize = intconv.Uint32(data[start:])
+		if opts.Strict {
+			var canon [13]byte
+			buf := canon[:0]
+
+			if x := o.BodySize; x >= 1<<21 {
+				buf = append(buf, 3|0x80, 0, 0, 0, 0)
+				intconv.PutUint32(buf[len(buf)-4:], x)
+			} else if x != 0 {
+				buf = append(buf, 3)
+				for x >= 0x80 {
+					buf = append(buf, byte(x|0x80))
+					x >>= 7
+				}
+				buf = append(buf, byte(x))
+			}
+
+			if err := colferCanon(buf, data[at:i], at); err != nil {
+				return 0, err
+			}
+		}
+
 		header = data[i]
 		i++
 	}
@@ -345,16 +1064,40 @@ func (o *Header) Unmarshal(data []byte) (int, error) {
 	if header != 0x7f {
 		return 0, ColferError(i - 1)
 	}
-	if i < ColferSizeMax {
+	if i < opts.SizeMax {
 		return i, nil
 	}
 eof:
-	if i >= ColferSizeMax {
-		return 0, ColferMax(fmt.Sprintf("colfer: struct internal.header size exceeds %d bytes", ColferSizeMax))
+	if i >= opts.SizeMax {
+		return 0, ColferMax(fmt.Sprintf("colfer: struct internal.header size exceeds %d bytes", opts.SizeMax))
 	}
 	return 0, io.EOF
 }
 
+// HasColferPath returns whether path locates a field in the schema, with the
+// names separated by dots, e.g., "o.s". See ColferOptions.Fields.
+func (*Header) HasColferPath(path string) bool {
+	name, nested := path, false
+	for i := 0; i < len(path); i++ {
+		if path[i] == '.' {
+			name, nested = path[:i], true
+			break
+		}
+	}
+
+	switch name {
+	case "seqID":
+		return !nested
+	case "method":
+		return !nested
+	case "error":
+		return !nested
+	case "bodySize":
+		return !nested
+	}
+	return false
+}
+
 // UnmarshalBinary decodes data as Colfer conform encoding.BinaryUnmarshaler.
 // The error return options are io.EOF, internal.ColferError, internal.ColferTail and internal.ColferMax.
 func (o *Header) UnmarshalBinary(data []byte) error {
@@ -364,3 +1107,241 @@ func (o *Header) UnmarshalBinary(data []byte) error {
 	}
 	return err
 }
+
+// MarshalJSON encodes o as JSON conform json.Marshaler. The members are named
+// after the schema fields and zero values are omitted. Timestamps are RFC 3339
+// strings in UTC and binaries are base64 strings. NaN and infinite floating
+// points are JSON strings too.
+func (o *Header) MarshalJSON() ([]byte, error) {
+	return o.MarshalJSONWith(colferOptions())
+}
+
+// MarshalJSONWith is like MarshalJSON, yet with the opts.JSONQuote64 mode,
+// including for nested data structures.
+func (o *Header) MarshalJSONWith(opts ColferOptions) ([]byte, error) {
+	buf := []byte{'{'}
+
+	if x := o.SeqID; x != 0 {
+		buf = append(buf, "\"seqID\":"...)
+		if opts.JSONQuote64 {
+			buf = append(buf, '"')
+		}
+		buf = strconv.AppendUint(buf, x, 10)
+		if opts.JSONQuote64 {
+			buf = append(buf, '"')
+		}
+		buf = append(buf, ',')
+	}
+
+	if len(o.Method) != 0 {
+		// infallible for strings and byte slices
+		b, _ := json.Marshal(o.Method)
+		buf = append(buf, "\"method\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+
+	if len(o.Error) != 0 {
+		// infallible for strings and byte slices
+		b, _ := json.Marshal(o.Error)
+		buf = append(buf, "\"error\":"...)
+		buf = append(buf, b...)
+		buf = append(buf, ',')
+	}
+
+	if x := o.BodySize; x != 0 {
+		buf = append(buf, "\"bodySize\":"...)
+		buf = strconv.AppendUint(buf, uint64(x), 10)
+		buf = append(buf, ',')
+	}
+
+	if len(buf) == 1 {
+		return append(buf, '}'), nil
+	}
+	buf[len(buf)-1] = '}'
+	return buf, nil
+}
+
+// UnmarshalJSON decodes data as JSON conform json.Unmarshaler, with the same
+// mapping as MarshalJSON. Integers may also be JSON strings with a decimal
+// value, and JSON null equals the zero value. Unknown members are rejected.
+// The error return option is internal.ColferMax, next to the JSON errors.
+func (o *Header) UnmarshalJSON(data []byte) error {
+	return o.UnmarshalJSONWith(data, colferOptions())
+}
+
+// UnmarshalJSONWith is like UnmarshalJSON, yet with the list limit of opts
+// instead of the package-level configuration attribute, including for nested
+// data structures.
+func (o *Header) UnmarshalJSONWith(data []byte, opts ColferOptions) error {
+	opts = opts.limits()
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	for name, raw := range members {
+		switch name {
+		case "seqID":
+			x, err := strconv.ParseUint(colferJSONNumber(raw), 10, 64)
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field internal.header.seqID: %s", err)
+			}
+			o.SeqID = uint64(x)
+		case "method":
+			// JSON null has no effect on the decode
+			o.Method = ""
+			if err := json.Unmarshal(raw, &o.Method); err != nil {
+				return fmt.Errorf("colfer: JSON for field internal.header.method: %s", err)
+			}
+		case "error":
+			// JSON null has no effect on the decode
+			o.Error = ""
+			if err := json.Unmarshal(raw, &o.Error); err != nil {
+				return fmt.Errorf("colfer: JSON for field internal.header.error: %s", err)
+			}
+		case "bodySize":
+			x, err := strconv.ParseUint(colferJSONNumber(raw), 10, 32)
+			if err != nil {
+				return fmt.Errorf("colfer: JSON for field internal.header.bodySize: %s", err)
+			}
+			o.BodySize = uint32(x)
+		default:
+			return fmt.Errorf("colfer: JSON member %q not in struct internal.header", name)
+		}
+	}
+	return nil
+}
+
+// Equal returns whether o and other have the same Colfer serial. Nil and empty
+// lists are equal, nil list entries equal the zero value, timestamps compare
+// as instants, and floating points compare by their bits, except for zero.
+func (o *Header) Equal(other *Header) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+
+	if o.SeqID != other.SeqID {
+		return false
+	}
+
+	if o.Method != other.Method {
+		return false
+	}
+
+	if o.Error != other.Error {
+		return false
+	}
+
+	if o.BodySize != other.BodySize {
+		return false
+	}
+
+	return true
+}
+
+// Diff returns the fields which differ between o and other in schema order,
+// with o as the old values. Data structures compare field by field and lists
+// compare element by element, conform Equal. A nil o or other compares as the
+// zero value.
+func (o *Header) Diff(other *Header) []ColferDiff {
+	if o == nil {
+		o = new(Header)
+	}
+	if other == nil {
+		other = new(Header)
+	}
+	var diffs []ColferDiff
+
+	if a, b := o.SeqID, other.SeqID; a != b {
+		diffs = append(diffs, ColferDiff{Path: "seqID", Old: a, New: b})
+	}
+
+	if a, b := o.Method, other.Method; a != b {
+		diffs = append(diffs, ColferDiff{Path: "method", Old: a, New: b})
+	}
+
+	if a, b := o.Error, other.Error; a != b {
+		diffs = append(diffs, ColferDiff{Path: "error", Old: a, New: b})
+	}
+
+	if a, b := o.BodySize, other.BodySize; a != b {
+		diffs = append(diffs, ColferDiff{Path: "bodySize", Old: a, New: b})
+	}
+
+	return diffs
+}
+
+// Clone returns a deep copy of o, or nil when o is nil.
+func (o *Header) Clone() *Header {
+	if o == nil {
+		return nil
+	}
+	c := *o
+
+	return &c
+}
+
+// Merge copies the non-zero fields of other into o. Nested data structures
+// merge recursively, and lists are replaced with a copy.
+func (o *Header) Merge(other *Header) {
+	if other == nil {
+		return
+	}
+
+	if other.SeqID != 0 {
+		o.SeqID = other.SeqID
+	}
+
+	if other.Method != "" {
+		o.Method = other.Method
+	}
+
+	if other.Error != "" {
+		o.Error = other.Error
+	}
+
+	if other.BodySize != 0 {
+		o.BodySize = other.BodySize
+	}
+}
+
+// String returns a human-readable form for debugging, with the sensitive
+// fields masked.
+func (o *Header) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("internal.Header{SeqID:%v Method:%q Error:%q BodySize:%v}", o.SeqID, o.Method, o.Error, o.BodySize)
+}
+
+// GoString returns the Go syntax for debugging, with the sensitive fields
+// masked.
+func (o *Header) GoString() string {
+	if o == nil {
+		return "(*internal.Header)(nil)"
+	}
+	return fmt.Sprintf("&internal.Header{SeqID:%#v, Method:%#v, Error:%#v, BodySize:%#v}", o.SeqID, o.Method, o.Error, o.BodySize)
+}
+
+// Validate checks the fields against the schema rules, including the ones of
+// nested data structures. A nil o validates as the zero value.
+// The error return option is internal.ColferInvalid.
+func (o *Header) Validate() error {
+	if o == nil {
+		o = new(Header)
+	}
+	return nil
+}
+
+// colferJSONNumber returns the text of a JSON number, or the content of a JSON
+// string. JSON null reads as zero.
+func colferJSONNumber(raw json.RawMessage) string {
+	switch {
+	case string(raw) == "null":
+		return "0"
+	case len(raw) > 1 && raw[0] == '"':
+		return string(raw[1 : len(raw)-1])
+	}
+	return string(raw)
+}
@@ -7,15 +7,9 @@ import (
 	"net/rpc"
 
 	"github.com/pascaldekloe/colfer/rpc/internal"
+	"github.com/pascaldekloe/colfer/rt"
 )
 
-// colferer covers the encoding methods.
-type colferer interface {
-	MarshalTo([]byte) int
-	MarshalLen() (int, error)
-	Unmarshal([]byte) (int, error)
-}
-
 type codec struct {
 	conn io.ReadWriteCloser
 
@@ -77,7 +71,7 @@ func (c *codec) ReadRequestBody(body interface{}) error {
 		return nil
 	}
 
-	b, ok := body.(colferer)
+	b, ok := body.(rt.Message)
 	if !ok {
 		return fmt.Errorf("colfer/rpc: body type %T not a Colfer type", body)
 	}
@@ -90,7 +84,7 @@ func (c *codec) ReadResponseBody(body interface{}) error {
 		return nil
 	}
 
-	b, ok := body.(colferer)
+	b, ok := body.(rt.Message)
 	if !ok {
 		return fmt.Errorf("colfer/rpc: body type %T not a Colfer type", body)
 	}
@@ -103,7 +97,7 @@ func (c *codec) WriteRequest(header *rpc.Request, body interface{}) error {
 		Method: header.ServiceMethod,
 		SeqID:  header.Seq,
 	}
-	b, ok := body.(colferer)
+	b, ok := body.(rt.Message)
 	if !ok {
 		return fmt.Errorf("colfer/rpc: body type %T not a Colfer type", body)
 	}
@@ -117,7 +111,7 @@ func (c *codec) WriteResponse(header *rpc.Response, body interface{}) error {
 		SeqID:  header.Seq,
 		Error:  header.Error,
 	}
-	b, ok := body.(colferer)
+	b, ok := body.(rt.Message)
 	if !ok {
 		return fmt.Errorf("colfer/rpc: body type %T not a Colfer type", body)
 	}
@@ -128,7 +122,7 @@ func (c *codec) Close() error {
 	return c.conn.Close()
 }
 
-func (c *codec) encode(h *internal.Header, body colferer) error {
+func (c *codec) encode(h *internal.Header, body rt.Message) error {
 	bl, err := body.MarshalLen()
 	if err != nil {
 		return err
@@ -149,7 +143,7 @@ func (c *codec) encode(h *internal.Header, body colferer) error {
 	return err
 }
 
-func (c *codec) decode(v colferer) error {
+func (c *codec) decode(v rt.Message) error {
 	for {
 		if c.offset < c.i {
 			n, err := v.Unmarshal(c.buf[c.offset:c.i])
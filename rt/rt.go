@@ -0,0 +1,85 @@
+// Package rt provides the shared runtime for generated Go code.
+// Packages compiled with the -r option of colf(1) declare their error types as
+// aliases of the ones in here, such that errors from any schema package can be
+// handled uniformly. The size and list limits remain per package, as they are
+// configured per compilation.
+package rt
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// IntConv is the byte order of fixed-size integers.
+var IntConv = binary.BigEndian
+
+// Message is implemented by all generated data structures.
+type Message interface {
+	// MarshalTo encodes the message as Colfer into buf and returns the
+	// number of bytes written. If the buffer is too small, MarshalTo will
+	// panic.
+	MarshalTo(buf []byte) int
+	// MarshalLen returns the Colfer serial byte size.
+	// The error return option is ColferMax.
+	MarshalLen() (int, error)
+	// Unmarshal decodes data as Colfer and returns the number of bytes
+	// read. The error return options are io.EOF, ColferError and ColferMax.
+	Unmarshal(data []byte) (int, error)
+}
+
+// ColferMax signals an upper limit breach.
+type ColferMax string
+
+// Error honors the error interface.
+func (m ColferMax) Error() string { return string(m) }
+
+// ColferError signals a data mismatch as as a byte index.
+type ColferError int
+
+// Error honors the error interface.
+func (i ColferError) Error() string {
+	return fmt.Sprintf("colfer: unknown header at byte %d", i)
+}
+
+// ColferTail signals data continuation as a byte index.
+type ColferTail int
+
+// Error honors the error interface.
+func (i ColferTail) Error() string {
+	return fmt.Sprintf("colfer: data continuation at byte %d", i)
+}
+
+// ColferNonCanonical signals an encoding deviation as a byte index.
+type ColferNonCanonical int
+
+// Error honors the error interface.
+func (i ColferNonCanonical) Error() string {
+	return fmt.Sprintf("colfer: non-canonical encoding at byte %d", i)
+}
+
+// ColferDiff is a field difference between two data structures.
+type ColferDiff struct {
+	// Path locates the field with the schema names, e.g.,
+	// "course.holes[3].par".
+	Path string
+	// Old and New are the respective values, with nil for absence.
+	Old, New interface{}
+}
+
+// String returns the difference in a human readable form.
+func (d ColferDiff) String() string {
+	return fmt.Sprintf("%s: %v -> %v", d.Path, d.Old, d.New)
+}
+
+// ColferInvalid signals a schema rule violation.
+type ColferInvalid struct {
+	// Field is the qualified name, e.g., "demo.hole.par".
+	Field string
+	// Rule is the schema tag, e.g., "max 5".
+	Rule string
+}
+
+// Error honors the error interface.
+func (e ColferInvalid) Error() string {
+	return fmt.Sprintf("colfer: field %s breaks rule %s", e.Field, e.Rule)
+}
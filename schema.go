@@ -2,13 +2,20 @@ package colfer
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/format"
 	"go/parser"
 	"go/token"
 	"io/ioutil"
+	"math"
 	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 // Format normalizes the file's content.
@@ -90,6 +97,15 @@ func ParseFiles(files []string) ([]*Package, error) {
 		for _, s := range pkg.Structs {
 			for _, f := range s.Fields {
 				t := f.Type
+				if t == "colfer" {
+					if f.TypeList {
+						return nil, fmt.Errorf("colfer: unsupported lists type %q for field %s", t, f.String())
+					}
+					// same encoding as binary
+					f.Type = "binary"
+					f.TypeColfer = true
+					continue
+				}
 				_, ok := datatypes[t]
 				if ok {
 					if f.TypeList {
@@ -112,9 +128,47 @@ func ParseFiles(files []string) ([]*Package, error) {
 		}
 	}
 
+	for _, pkg := range packages {
+		for _, s := range pkg.Structs {
+			for _, f := range s.Fields {
+				if err := checkRules(f); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
 	return packages, nil
 }
 
+// Warnings returns notices about the schema which do not prevent code
+// generation, such as references to deprecated data structures, or Colfer
+// keys in struct tags which pass through otherwise.
+func Warnings(packages []*Package) []string {
+	var warnings []string
+	for _, pkg := range packages {
+		for _, s := range pkg.Structs {
+			for _, f := range s.Fields {
+				// tags which pass through may be meant for other tools
+				if len(f.Tags) != 0 && len(f.colferKeys) != 0 {
+					warnings = append(warnings, fmt.Sprintf("field %s struct tag keys %s are reserved for Colfer, and they do not pass through", f, strings.Join(f.colferKeys, ", ")))
+				}
+			}
+
+			if s.Deprecated {
+				// no use in warnings on legacy
+				continue
+			}
+			for _, f := range s.Fields {
+				if f.TypeRef != nil && f.TypeRef.Deprecated && !f.Deprecated {
+					warnings = append(warnings, fmt.Sprintf("field %s references deprecated struct %s", f, f.TypeRef))
+				}
+			}
+		}
+	}
+	return warnings
+}
+
 func addSpec(pkg *Package, decl *ast.GenDecl, spec ast.Spec, file string) error {
 	switch spec := spec.(type) {
 	default:
@@ -126,8 +180,19 @@ func addSpec(pkg *Package, decl *ast.GenDecl, spec ast.Spec, file string) error
 		case *ast.StructType:
 			s := &Struct{Pkg: pkg, Name: spec.Name.Name, SchemaFile: path.Base(file)}
 			pkg.Structs = append(pkg.Structs, s)
+			if _, ok := datatypes[s.Name]; ok || s.Name == "colfer" {
+				return fmt.Errorf("colfer: struct %s: name %q is reserved for a datatype", s, s.Name)
+			}
 
-			s.Docs = append(docs(decl.Doc), docs(spec.Doc)...)
+			s.Docs = s.parseAnnotations(append(docs(decl.Doc), docs(spec.Doc)...))
+			if s.Sensitive {
+				return fmt.Errorf("colfer: struct %s: sensitive annotation applies to fields only", s)
+			}
+			names, err := mapNames(reflect.StructTag(s.names))
+			if err != nil {
+				return fmt.Errorf("colfer: struct %s: %s", s, err)
+			}
+			s.Names = names
 			if err := mapStruct(s, t); err != nil {
 				return err
 			}
@@ -147,7 +212,13 @@ func mapStruct(dst *Struct, src *ast.StructType) error {
 		}
 		field.Name = f.Names[0].Name
 
-		field.Docs = docs(f.Doc)
+		field.Docs = field.parseAnnotations(docs(f.Doc))
+
+		if f.Tag != nil {
+			if err := mapTag(&field, f.Tag.Value); err != nil {
+				return err
+			}
+		}
 
 		expr := f.Type
 		for {
@@ -175,6 +246,347 @@ func mapStruct(dst *Struct, src *ast.StructType) error {
 	return nil
 }
 
+// mapTag sets the validation rules and the name overrides from a struct tag
+// literal.
+func mapTag(f *Field, literal string) error {
+	tag, err := strconv.Unquote(literal)
+	if err != nil {
+		return fmt.Errorf("colfer: malformed tag for field %s: %s", f, err)
+	}
+	st := reflect.StructTag(tag)
+
+	f.Min = st.Get("min")
+	f.Max = st.Get("max")
+	f.MinLen = st.Get("minlen")
+	f.MaxLen = st.Get("maxlen")
+	f.Pattern = st.Get("pattern")
+	if v, ok := st.Lookup("required"); ok {
+		f.Required, err = strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("colfer: field %s rule required %q not a boolean", f, v)
+		}
+	}
+
+	f.Names, err = mapNames(st)
+	if err != nil {
+		return fmt.Errorf("colfer: field %s: %s", f, err)
+	}
+
+	pairs, err := splitTag(tag)
+	if err != nil {
+		return fmt.Errorf("colfer: malformed tag for field %s: %s", f, err)
+	}
+	for _, pair := range pairs {
+		if isColferTagKey(pair[0]) {
+			f.colferKeys = append(f.colferKeys, pair[0])
+		} else {
+			f.Tags = append(f.Tags, pair[0]+":"+pair[1])
+		}
+	}
+	return nil
+}
+
+// ruleKeys are the struct tag keys of the validation rules.
+var ruleKeys = []string{"min", "max", "minlen", "maxlen", "required", "pattern"}
+
+// isColferTagKey returns whether key is reserved for Colfer in struct tags.
+func isColferTagKey(key string) bool {
+	for _, k := range append(ruleKeys, nameLangs...) {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// splitTag returns the key and the quoted value of each pair in a struct tag
+// with the conventional format, as in reflect.StructTag.
+func splitTag(tag string) ([][2]string, error) {
+	var pairs [][2]string
+	for {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			return pairs, nil
+		}
+
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return nil, fmt.Errorf("pair %q not in key:\"value\" format", tag)
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return nil, fmt.Errorf("value of key %q not terminated", key)
+		}
+		if _, err := strconv.Unquote(tag[:i+1]); err != nil {
+			return nil, fmt.Errorf("value of key %q: %s", key, err)
+		}
+		pairs = append(pairs, [2]string{key, tag[:i+1]})
+		tag = tag[i+1:]
+	}
+}
+
+// identPattern matches the identifiers accepted in all languages.
+var identPattern = regexp.MustCompile("^[A-Za-z_][A-Za-z0-9_]*$")
+
+// mapNames returns the native name overrides from tag, if any.
+func mapNames(tag reflect.StructTag) (map[string]string, error) {
+	var names map[string]string
+	for _, lang := range nameLangs {
+		name, ok := tag.Lookup(lang)
+		if !ok {
+			continue
+		}
+
+		if !identPattern.MatchString(name) {
+			return nil, fmt.Errorf("%s name %q is not an identifier", lang, name)
+		}
+		var keyword bool
+		switch lang {
+		case "go":
+			if !ast.IsExported(name) {
+				return nil, fmt.Errorf("go name %q is not exported", name)
+			}
+			keyword = token.Lookup(name).IsKeyword()
+		case "java":
+			keyword = IsJavaKeyword(name)
+		case "ecma":
+			keyword = IsECMAKeyword(name)
+		case "c":
+			keyword = IsCKeyword(name)
+		}
+		if keyword {
+			return nil, fmt.Errorf("%s name %q is a reserved word", lang, name)
+		}
+
+		if names == nil {
+			names = make(map[string]string)
+		}
+		names[lang] = name
+	}
+	return names, nil
+}
+
+// checkRules verifies the validation rules against the datatype, and it
+// normalizes the bounds.
+func checkRules(f *Field) error {
+	if f.Min != "" || f.Max != "" {
+		var parse func(string) (string, float64, error)
+		switch f.Type {
+		case "uint8", "uint16", "uint32", "uint64":
+			bits, _ := strconv.Atoi(f.Type[4:])
+			parse = func(s string) (string, float64, error) {
+				v, err := strconv.ParseUint(s, 10, bits)
+				return strconv.FormatUint(v, 10), float64(v), err
+			}
+		case "int32", "int64":
+			bits, _ := strconv.Atoi(f.Type[3:])
+			parse = func(s string) (string, float64, error) {
+				v, err := strconv.ParseInt(s, 10, bits)
+				return strconv.FormatInt(v, 10), float64(v), err
+			}
+		case "float32", "float64":
+			bits, _ := strconv.Atoi(f.Type[5:])
+			parse = func(s string) (string, float64, error) {
+				v, err := strconv.ParseFloat(s, bits)
+				if err == nil && (math.IsNaN(v) || math.IsInf(v, 0)) {
+					err = strconv.ErrRange
+				}
+				return strconv.FormatFloat(v, 'g', -1, bits), v, err
+			}
+		}
+		if parse == nil || f.TypeList {
+			return fmt.Errorf("colfer: field %s: range rules not applicable to type %s", f, f.typeName())
+		}
+
+		min, max := math.Inf(-1), math.Inf(1)
+		var err error
+		if f.Min != "" {
+			if f.Min, min, err = parse(f.Min); err != nil {
+				return fmt.Errorf("colfer: field %s rule min: %s", f, err)
+			}
+		}
+		if f.Max != "" {
+			if f.Max, max, err = parse(f.Max); err != nil {
+				return fmt.Errorf("colfer: field %s rule max: %s", f, err)
+			}
+		}
+		if min > max {
+			return fmt.Errorf("colfer: field %s rule min %s exceeds max %s", f, f.Min, f.Max)
+		}
+	}
+
+	if f.MinLen != "" || f.MaxLen != "" {
+		if !f.TypeList && f.Type != "text" && f.Type != "binary" {
+			return fmt.Errorf("colfer: field %s: size rules not applicable to type %s", f, f.typeName())
+		}
+
+		var min, max uint64 = 0, math.MaxUint64
+		var err error
+		if f.MinLen != "" {
+			if min, err = strconv.ParseUint(f.MinLen, 10, 31); err != nil {
+				return fmt.Errorf("colfer: field %s rule minlen: %s", f, err)
+			}
+			f.MinLen = strconv.FormatUint(min, 10)
+		}
+		if f.MaxLen != "" {
+			if max, err = strconv.ParseUint(f.MaxLen, 10, 31); err != nil {
+				return fmt.Errorf("colfer: field %s rule maxlen: %s", f, err)
+			}
+			f.MaxLen = strconv.FormatUint(max, 10)
+		}
+		if min > max {
+			return fmt.Errorf("colfer: field %s rule minlen %s exceeds maxlen %s", f, f.MinLen, f.MaxLen)
+		}
+	}
+
+	if f.Pattern != "" {
+		if f.TypeList || f.Type != "text" {
+			return fmt.Errorf("colfer: field %s: pattern rule not applicable to type %s", f, f.typeName())
+		}
+		if _, err := regexp.Compile(f.Pattern); err != nil {
+			return fmt.Errorf("colfer: field %s rule pattern: %s", f, err)
+		}
+		if err := checkPattern(f.Pattern); err != nil {
+			return fmt.Errorf("colfer: field %s rule pattern: %s", f, err)
+		}
+	}
+
+	return nil
+}
+
+// checkPattern verifies that the regular expression sticks to the syntax with
+// the same meaning in Go, Java and JavaScript. The subset consists of literal
+// characters, escapes of the metacharacters, the escapes \t, \n, \r, \f, \d,
+// \D, \w and \W, character classes with ranges, capturing groups, non-capturing
+// groups "(?:", alternation, the anchors "^" and "$", the dot, and repetition,
+// including lazy repetition. Pattern syntax is validated by regexp.Compile
+// beforehand.
+func checkPattern(pattern string) error {
+	var inClass, classEmpty, anchor bool
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if anchor && strings.IndexByte("*+?{", c) >= 0 {
+			return fmt.Errorf("repetition of anchor %q not portable", pattern[i-1])
+		}
+		anchor = false
+
+		switch {
+		case c < ' ' || c == 0x7f:
+			return fmt.Errorf("control character %q not portable; use an escape", c)
+		case c >= 0x80:
+			r, size := utf8.DecodeRuneInString(pattern[i:])
+			if !strconv.IsPrint(r) {
+				return fmt.Errorf("non-printable character %q not portable", r)
+			}
+			i += size - 1
+		case c == '\\':
+			i++
+			if i >= len(pattern) {
+				break // rejected by regexp.Compile
+			}
+			c, classEmpty = pattern[i], false
+			if strings.IndexByte(`\^$.|?*+()[]{}tnrfdDwW`, c) < 0 && !(inClass && c == '-') {
+				return fmt.Errorf("escape \\%c not portable", c)
+			}
+		case inClass:
+			switch {
+			case c == ']' && !classEmpty:
+				inClass = false
+			case c == '[' || c == ']':
+				return fmt.Errorf("unescaped %q in character class not portable", c)
+			case c == '&' && i+1 < len(pattern) && pattern[i+1] == '&':
+				return errors.New("\"&&\" in character class not portable")
+			}
+			classEmpty = false
+		case c == '^' || c == '$':
+			anchor = true
+		case c == '[':
+			inClass, classEmpty = true, true
+			if i+1 < len(pattern) && pattern[i+1] == '^' {
+				i++
+			}
+		case c == '(':
+			if i+1 < len(pattern) && pattern[i+1] == '?' && (i+2 >= len(pattern) || pattern[i+2] != ':') {
+				return errors.New("group flags and names not portable; only \"(?:\" is")
+			}
+		case c == '{':
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 || !repeatPattern.MatchString(pattern[i:i+end+1]) {
+				return errors.New("unescaped \"{\" outside repetition not portable")
+			}
+			i += end
+		case c == '}' || c == ']':
+			return fmt.Errorf("unescaped %q not portable", c)
+		}
+	}
+	return nil
+}
+
+// repeatPattern matches the repetition operators with braces.
+var repeatPattern = regexp.MustCompile(`^\{[0-9]+(,[0-9]*)?\}$`)
+
+// nativePattern returns the regular expression in a form with the same meaning
+// as in Go for the Java and JavaScript engines, with end as the replacement for
+// the "$" anchor. The dot becomes a character class, as Java and JavaScript
+// exclude more line terminators than Go does. Pattern must pass checkPattern.
+func nativePattern(pattern, end string) string {
+	var buf bytes.Buffer
+	var inClass, classEmpty bool
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\':
+			buf.WriteString(pattern[i : i+2])
+			i, classEmpty = i+1, false
+			continue
+		case inClass:
+			if c == ']' && !classEmpty {
+				inClass = false
+			}
+			classEmpty = false
+		case c == '[':
+			inClass, classEmpty = true, true
+			if i+1 < len(pattern) && pattern[i+1] == '^' {
+				buf.WriteString("[^")
+				i++
+				continue
+			}
+		case c == '.':
+			buf.WriteString(`[^\n]`)
+			continue
+		case c == '$':
+			buf.WriteString(end)
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+// typeName returns the datatype as declared in the schema.
+func (f *Field) typeName() string {
+	t := f.Type
+	if f.TypeColfer {
+		t = "colfer"
+	}
+	if f.TypeList {
+		return "[]" + t
+	}
+	return t
+}
+
 func docs(g *ast.CommentGroup) []string {
 	var a []string
 	if g != nil {
@@ -0,0 +1,284 @@
+package colfer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// parseSchema parses the text as a single schema file.
+func parseSchema(t *testing.T, text string) ([]*Package, error) {
+	dir, err := ioutil.TempDir("", "colfer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "test.colf")
+	if err := ioutil.WriteFile(file, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return ParseFiles([]string{file})
+}
+
+func TestParseRules(t *testing.T) {
+	packages, err := ParseFiles([]string{"testdata/rules.colf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := packages[0].Structs[0].Fields
+	if f := fields[0]; f.Min != "3" || f.Max != "5" || f.Required {
+		t.Errorf("got par rules min %q, max %q and required %t", f.Min, f.Max, f.Required)
+	}
+	if f := fields[3]; f.MinLen != "2" || f.MaxLen != "8" || !f.Required || f.Pattern != "^[a-z]+$" {
+		t.Errorf("got name rules minlen %q, maxlen %q, required %t and pattern %q", f.MinLen, f.MaxLen, f.Required, f.Pattern)
+	}
+	if f := fields[5]; f.HasRules() {
+		t.Error("next has rules")
+	}
+}
+
+func TestParseRulesErrors(t *testing.T) {
+	golden := []struct{ field, err string }{
+		{"u8 uint8 `min:\"256\"`", "rule min"},
+		{"u8 uint8 `min:\"-1\"`", "rule min"},
+		{"i32 int32 `min:\"2\" max:\"1\"`", "min 2 exceeds max 1"},
+		{"f64 float64 `max:\"NaN\"`", "rule max"},
+		{"s text `min:\"1\"`", "range rules not applicable to type text"},
+		{"f32s []float32 `max:\"1\"`", "range rules not applicable to type []float32"},
+		{"b bool `minlen:\"1\"`", "size rules not applicable to type bool"},
+		{"s text `minlen:\"2\" maxlen:\"1\"`", "minlen 2 exceeds maxlen 1"},
+		{"s text `maxlen:\"x\"`", "rule maxlen"},
+		{"s text `pattern:\"(\"`", "rule pattern"},
+		{"a binary `pattern:\"a\"`", "pattern rule not applicable to type binary"},
+		{"s text `pattern:\"(?i)a\"`", "group flags and names not portable"},
+		{"s text `pattern:\"a\\\\z\"`", "escape \\z not portable"},
+		{"s text `pattern:\"[[:alpha:]]\"`", "in character class not portable"},
+		{"s text `pattern:\"[a-z&&[^x]]\"`", "not portable"},
+		{"s text `pattern:\"a{\"`", "outside repetition not portable"},
+		{"s text `pattern:\"a]\"`", "not portable"},
+		{"s text `pattern:\"^*a\"`", "repetition of anchor"},
+		{"s text `pattern:\"\\x01\"`", "control character"},
+		{"s text `required:\"maybe\"`", "not a boolean"},
+	}
+	for _, gold := range golden {
+		_, err := parseSchema(t, "package x\n\ntype y struct {\n\t"+gold.field+"\n}\n")
+		if err == nil || !strings.Contains(err.Error(), gold.err) {
+			t.Errorf("%s: got error %v, want %q", gold.field, err, gold.err)
+		}
+	}
+}
+
+func TestNativePattern(t *testing.T) {
+	golden := []struct{ pattern, java string }{
+		{`^[a-z]+$`, `^[a-z]+\z`},
+		{`a.b`, `a[^\n]b`},
+		{`[.$]\.\$`, `[.$]\.\$`},
+		{`[^\]]|[\]-]`, `[^\]]|[\]-]`},
+		{`^\d{2,3}?$`, `^\d{2,3}?\z`},
+	}
+	for _, gold := range golden {
+		if err := checkPattern(gold.pattern); err != nil {
+			t.Errorf("%s: got error %s", gold.pattern, err)
+		}
+		if got := nativePattern(gold.pattern, `\z`); got != gold.java {
+			t.Errorf("%s: got %s, want %s", gold.pattern, got, gold.java)
+		}
+	}
+}
+
+func TestQuoteUTF16(t *testing.T) {
+	got := quoteUTF16("a\"\\\té\u2028😀")
+	want := `"a\"\\\t\u00e9\u2028\ud83d\ude00"`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	packages, err := ParseFiles([]string{"testdata/rules.colf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := packages[0].Structs[1]
+	if !s.Deprecated || s.DeprecatedText != "use r instead." || s.Since != "v1.2" {
+		t.Errorf("got struct deprecated %t %q and since %q", s.Deprecated, s.DeprecatedText, s.Since)
+	}
+	if got, want := s.DocTextBody("// "), "// Old tests the annotations."; got != want {
+		t.Errorf("got struct doc body %q, want %q", got, want)
+	}
+	if f := s.Fields[0]; !f.Sensitive || f.Deprecated {
+		t.Errorf("got pin sensitive %t and deprecated %t", f.Sensitive, f.Deprecated)
+	}
+	if f := s.Fields[1]; !f.Deprecated || f.DeprecatedText != "no replacement." || f.Sensitive {
+		t.Errorf("got ref deprecated %t %q and sensitive %t", f.Deprecated, f.DeprecatedText, f.Sensitive)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	packages, err := parseSchema(t, `package x
+
+// Deprecated: use b instead.
+type a struct {
+	self a
+}
+
+type b struct {
+	legacy a
+	// Deprecated: no replacement.
+	gone a
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Warnings(packages)
+	want := "field x.b.legacy references deprecated struct x.a"
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("got warnings %q, want %q", got, want)
+	}
+
+	packages, err = parseSchema(t, "package x\n\ntype a struct {\n\tb uint8 `min:\"1\" validate:\"min=1\" go:\"B\"`\n\tc uint8 `max:\"9\"`\n}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = Warnings(packages)
+	want = "field x.a.b struct tag keys min, go are reserved for Colfer, and they do not pass through"
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("got warnings %q, want %q", got, want)
+	}
+
+	_, err = parseSchema(t, "package x\n\n// Sensitive:\ntype a struct {\n\tb bool\n}\n")
+	if err == nil || !strings.Contains(err.Error(), "sensitive annotation applies to fields only") {
+		t.Errorf("got error %v for sensitive struct", err)
+	}
+}
+
+func TestParseNames(t *testing.T) {
+	packages, err := ParseFiles([]string{"testdata/rules.colf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := packages[0].Structs[2]
+	if got := s.Names["go"]; got != "Renamed" {
+		t.Errorf("got struct go name %q, want Renamed", got)
+	}
+	if got := s.Fields[0].Names["java"]; got != "ident" {
+		t.Errorf("got field java name %q, want ident", got)
+	}
+	if s.Fields[1].Names != nil {
+		t.Errorf("got field names %q, want none", s.Fields[1].Names)
+	}
+}
+
+func TestParseNamesErrors(t *testing.T) {
+	golden := []struct{ field, err string }{
+		{"a bool `go:\"a\"`", `go name "a" is not exported`},
+		{"a bool `go:\"A-B\"`", `go name "A-B" is not an identifier`},
+		{"a bool `java:\"1a\"`", `java name "1a" is not an identifier`},
+		{"a bool `java:\"int\"`", `java name "int" is a reserved word`},
+		{"a bool `ecma:\"delete\"`", `ecma name "delete" is a reserved word`},
+		{"a bool `c:\"static\"`", `c name "static" is a reserved word`},
+	}
+	for _, gold := range golden {
+		_, err := parseSchema(t, "package x\n\ntype y struct {\n\t"+gold.field+"\n}\n")
+		if err == nil || !strings.Contains(err.Error(), gold.err) {
+			t.Errorf("%s: got error %v, want %q", gold.field, err, gold.err)
+		}
+	}
+
+	_, err := parseSchema(t, "package x\n\n// Names: go:\"type\"\ntype y struct {\n\ta bool\n}\n")
+	if err == nil || !strings.Contains(err.Error(), `go name "type" is not exported`) {
+		t.Errorf("got error %v for struct name override", err)
+	}
+}
+
+func TestReservedStructNames(t *testing.T) {
+	for _, name := range []string{"any", "colfer", "text"} {
+		_, err := parseSchema(t, "package x\n\ntype "+name+" struct {\n\ta bool\n}\n")
+		want := `struct x.` + name + `: name "` + name + `" is reserved for a datatype`
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("struct %s got error %v, want %q", name, err, want)
+		}
+	}
+}
+
+func TestNameCollisions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "colfer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	golden := []struct {
+		schema   string
+		generate func(string, []*Package) error
+		err      string
+	}{
+		{"type y struct {\n\ta bool `go:\"B\"`\n\tb bool\n}\n", GenerateGo,
+			`field x.y.a and x.y.b share the go name "B"`},
+		{"// Names: go:\"Z\"\ntype y struct {\n\ta bool\n}\n\ntype z struct {\n\ta bool\n}\n", GenerateGo,
+			`struct x.y and x.z share the go name "Z"`},
+		{"// Names: go:\"ColferOptions\"\ntype y struct {\n\ta bool\n}\n", GenerateGo,
+			`struct x.y has go name "ColferOptions", which clashes with the generated Colfer prefix`},
+		{"type y struct {\n\ta bool\n}\n\ntype yLazy struct {\n\ta bool\n}\n", generateGoLazy,
+			`struct x.yLazy has go name "YLazy", which clashes with the lazy holder of x.y`},
+		{"type y struct {\n\tclone bool\n}\n", GenerateGo,
+			`field x.y.clone has go name "Clone", which clashes with a generated method`},
+		{"type y struct {\n\ta bool `go:\"Equal\"`\n}\n", GenerateGo,
+			`field x.y.a has go name "Equal", which clashes with a generated method`},
+		{"type y struct {\n\ta bool `java:\"B\"`\n\tb bool\n}\n", GenerateJava,
+			`field x.y.a and x.y.b share the java name "B"`},
+		{"type y struct {\n\ta bool `ecma:\"b\"`\n\tb bool\n}\n", GenerateECMA,
+			`field x.y.a and x.y.b share the ecma name "b"`},
+		{"type y struct {\n\ta bool `c:\"b\"`\n\tb bool\n}\n", GenerateC,
+			`field x.y.a and x.y.b share the c name "b"`},
+	}
+	for _, gold := range golden {
+		packages, err := parseSchema(t, "package x\n\n"+gold.schema)
+		if err != nil {
+			t.Errorf("%q: parse error: %s", gold.schema, err)
+			continue
+		}
+		err = gold.generate(dir, packages)
+		if err == nil || !strings.Contains(err.Error(), gold.err) {
+			t.Errorf("%q: got error %v, want %q", gold.schema, err, gold.err)
+		}
+	}
+}
+
+// generateGoLazy is GenerateGo with lazy decoding.
+func generateGoLazy(basedir string, packages []*Package) error {
+	for _, p := range packages {
+		p.Lazy = true
+	}
+	return GenerateGo(basedir, packages)
+}
+
+func TestGoTags(t *testing.T) {
+	packages, err := parseSchema(t, "package x\n\ntype y struct {\n\ta uint8 `min:\"1\" yaml:\"b\" go:\"A\"`\n\tb bool `json:\"-\"`\n\tc bool \"a:\\\"`\\\"\"\n\td bool\n}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	packages[0].JSONTags = true
+	golden := []string{
+		"`json:\"a,omitempty\" yaml:\"b\"`",
+		"`json:\"-\"`",
+		`"json:\"c,omitempty\" a:\"` + "`" + `\""`,
+		"`json:\"d,omitempty\"`",
+	}
+	for i, f := range packages[0].Structs[0].Fields {
+		if got := goTag(f); got != golden[i] {
+			t.Errorf("field %s got tag %s, want %s", f, got, golden[i])
+		}
+	}
+
+	for _, tag := range []string{"`yaml`", "`yaml:b`", "`yaml:\"b`", "`yaml:\"\\x\"`"} {
+		_, err := parseSchema(t, "package x\n\ntype y struct {\n\ta bool "+tag+"\n}\n")
+		if err == nil || !strings.Contains(err.Error(), "malformed tag") {
+			t.Errorf("tag %s got error %v, want malformed tag", tag, err)
+		}
+	}
+}